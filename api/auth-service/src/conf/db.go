@@ -1,9 +1,12 @@
 package conf
 
 import (
-	"log"
+	"log/slog"
 	"os"
 
+	"github.com/maxg56/matcha/api/common/metrics"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	// local models
@@ -32,19 +35,29 @@ func ConnectDatabase() {
 	dsn := "host=" + config.Host + " user=" + config.User + " password=" + config.Password + " dbname=" + config.DBName + " port=" + config.Port + " sslmode=disable"
 	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Fatal("❌ Erreur connexion DB:", err)
+		slog.Error("failed to connect to the database", "error", err)
+		os.Exit(1)
 	}
 
 	DB = database
-	log.Println("✅ Base de données connectée")
+	slog.Info("database connected")
+
+	if err := DB.Use(tracing.GORMPlugin{}); err != nil {
+		slog.Warn("failed to enable query tracing", "error", err)
+	}
+	if err := metrics.RegisterDB(DB, "auth"); err != nil {
+		slog.Warn("failed to expose connection pool metrics", "error", err)
+	}
 
 	// Optional: enable automatic migrations only when explicitly requested
 	if os.Getenv("AUTO_MIGRATE") == "true" {
-		log.Println("Running DB AutoMigrate (AUTO_MIGRATE=true)")
+		slog.Info("running AutoMigrate (AUTO_MIGRATE=true)")
 		if err := DB.AutoMigrate(
 			&models.Users{},
 			&models.EmailVerification{},
 			&models.PasswordReset{},
+			&models.EmailChange{},
+			&models.EmailOutbox{},
 			&models.Tag{},
 			&models.UserTag{},
 			&models.Image{},
@@ -52,10 +65,10 @@ func ConnectDatabase() {
 			&models.Discussion{},
 			&models.Message{},
 		); err != nil {
-			log.Println("⚠️ AutoMigrate failed:", err)
+			slog.Warn("AutoMigrate failed", "error", err)
 		}
 	} else {
-		log.Println("Skipping AutoMigrate (set AUTO_MIGRATE=true to enable)")
+		slog.Info("skipping AutoMigrate (set AUTO_MIGRATE=true to enable)")
 	}
 }
 
@@ -7,11 +7,11 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/maxg56/matcha/api/common/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
 var Client *redis.Client
-var ctx = context.Background()
 
 // InitRedis initializes the Redis connection
 func InitRedis() error {
@@ -40,8 +40,10 @@ func InitRedis() error {
 		DB:       db,
 	})
 
+	tracing.InstrumentRedis(Client)
+
 	// Test connection
-	_, err := Client.Ping(ctx).Result()
+	_, err := Client.Ping(context.Background()).Result()
 	if err != nil {
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
@@ -50,7 +52,7 @@ func InitRedis() error {
 }
 
 // BlacklistToken adds a JWT token to the blacklist with TTL
-func BlacklistToken(tokenString string, ttl time.Duration) error {
+func BlacklistToken(ctx context.Context, tokenString string, ttl time.Duration) error {
 	if Client == nil {
 		return fmt.Errorf("Redis client not initialized")
 	}
@@ -65,7 +67,7 @@ func BlacklistToken(tokenString string, ttl time.Duration) error {
 }
 
 // IsTokenBlacklisted checks if a token is in the blacklist
-func IsTokenBlacklisted(tokenString string) (bool, error) {
+func IsTokenBlacklisted(ctx context.Context, tokenString string) (bool, error) {
 	if Client == nil {
 		return false, fmt.Errorf("Redis client not initialized")
 	}
@@ -79,15 +81,17 @@ func IsTokenBlacklisted(tokenString string) (bool, error) {
 	return exists > 0, nil
 }
 
-// InvalidateUserTokens invalidates all tokens for a specific user (optional)
-func InvalidateUserTokens(userID string) error {
+// InvalidateUserTokens invalidates all tokens issued to a user so far. ttl must
+// be at least the lifetime of the refresh tokens: once the key expires, the
+// tokens it revoked are accepted again.
+func InvalidateUserTokens(ctx context.Context, userID string, ttl time.Duration) error {
 	if Client == nil {
 		return fmt.Errorf("Redis client not initialized")
 	}
 
 	// Store user invalidation timestamp
 	key := "user_invalidated:" + userID
-	err := Client.Set(ctx, key, time.Now().Unix(), 24*7*time.Hour).Err() // 7 days
+	err := Client.Set(ctx, key, time.Now().Unix(), ttl).Err()
 	if err != nil {
 		return fmt.Errorf("failed to invalidate user tokens: %w", err)
 	}
@@ -96,7 +100,7 @@ func InvalidateUserTokens(userID string) error {
 }
 
 // IsUserTokensInvalidated checks if all user tokens should be considered invalid
-func IsUserTokensInvalidated(userID string, tokenIssuedAt int64) (bool, error) {
+func IsUserTokensInvalidated(ctx context.Context, userID string, tokenIssuedAt int64) (bool, error) {
 	if Client == nil {
 		return false, fmt.Errorf("Redis client not initialized")
 	}
@@ -116,6 +120,7 @@ func IsUserTokensInvalidated(userID string, tokenIssuedAt int64) (bool, error) {
 		return false, fmt.Errorf("invalid invalidation timestamp: %w", err)
 	}
 
-	// Token is invalid if it was issued before the invalidation timestamp
-	return tokenIssuedAt < invalidatedAt, nil
+	// iat has a one second granularity: a token issued in the same second as
+	// the invalidation may predate it, so it is revoked too
+	return tokenIssuedAt <= invalidatedAt, nil
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -109,7 +110,7 @@ func RegisterHandler(c *gin.Context) {
 	}
 
 	// Create user
-	user, err := services.CreateUser(req)
+	user, err := services.CreateUser(c.Request.Context(), req)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -146,7 +147,7 @@ func LoginHandler(c *gin.Context) {
 
 	// Find user by username or email
 	var user models.Users
-	if err := db.DB.Where("username = ? OR email = ?", req.Login, req.Login).First(&user).Error; err != nil || user.ID == 0 {
+	if err := db.DB.WithContext(c.Request.Context()).Where("username = ? OR email = ?", req.Login, req.Login).First(&user).Error; err != nil || user.ID == 0 {
 		utils.RespondError(c, http.StatusUnauthorized, "invalid credentials")
 		return
 	}
@@ -164,6 +165,17 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
+	// Coming back ends a pause the user asked to end on next login. The pause
+	// belongs to user-service; the login goes on when it cannot be reached.
+	profileResumed := false
+	if user.PausedAt != nil && user.ResumeOnLogin {
+		resumed, err := services.ResumeProfileOnLogin(c.Request.Context(), user.ID)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "failed to resume paused profile on login", "user_id", user.ID, "error", err)
+		}
+		profileResumed = resumed
+	}
+
 	utils.RespondSuccess(c, http.StatusOK, gin.H{
 		"message": "Login successful",
 		"user": gin.H{
@@ -171,10 +183,11 @@ func LoginHandler(c *gin.Context) {
 			"username": user.Username,
 			"email":    user.Email,
 		},
-		"access_token":  tokens.AccessToken,
-		"refresh_token": tokens.RefreshToken,
-		"token_type":    "Bearer",
-		"expires_in":    tokens.ExpiresIn,
+		"access_token":    tokens.AccessToken,
+		"refresh_token":   tokens.RefreshToken,
+		"token_type":      "Bearer",
+		"expires_in":      tokens.ExpiresIn,
+		"profile_resumed": profileResumed,
 	})
 }
 
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	db "auth-service/src/conf"
+	"auth-service/src/middleware"
+	"auth-service/src/models"
+	"auth-service/src/services"
+	"auth-service/src/types"
+	"auth-service/src/utils"
+)
+
+const (
+	// emailChangeConfirmTTL is how long the link sent to the new address stays valid
+	emailChangeConfirmTTL = 24 * time.Hour
+	// emailChangeRevertTTL is how long the old address can undo a completed change
+	emailChangeRevertTTL = 7 * 24 * time.Hour
+)
+
+// generateEmailChangeToken generates a random hex token for email change links
+func generateEmailChangeToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// revokeAttempts is how many times revoking the sessions is tried before the
+// change is given up
+const revokeAttempts = 3
+
+// revokeUserSessions invalidates every token issued to the user so far. The
+// gateway and the refresh endpoint fail open when Redis is down, so a change
+// that must sign the user out is only made once this succeeded.
+func revokeUserSessions(ctx context.Context, userID uint) error {
+	// The revocation must outlive every refresh token it revokes
+	ttl := utils.GetDurationFromEnv("JWT_REFRESH_TTL", 7*24*time.Hour)
+	if accessTTL := utils.GetDurationFromEnv("JWT_ACCESS_TTL", 15*time.Minute); accessTTL > ttl {
+		ttl = accessTTL
+	}
+
+	var err error
+	for attempt := 1; attempt <= revokeAttempts; attempt++ {
+		if err = db.InvalidateUserTokens(ctx, strconv.FormatUint(uint64(userID), 10), ttl); err == nil {
+			return nil
+		}
+		slog.WarnContext(ctx, "failed to revoke sessions", "user_id", userID, "attempt", attempt, "max_attempts", revokeAttempts, "error", err)
+		if attempt < revokeAttempts {
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+	}
+	return err
+}
+
+// RequestEmailChangeHandler starts an email change for the authenticated user.
+// It requires the current password and mails a confirmation link to the new address.
+func RequestEmailChangeHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.GetString(middleware.UserIDContextKey), 10, 32)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "invalid user ID")
+		return
+	}
+
+	var req types.EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid payload: "+err.Error())
+		return
+	}
+	newEmail := strings.TrimSpace(req.NewEmail)
+
+	var user models.Users
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "invalid current password")
+		return
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		utils.RespondError(c, http.StatusBadRequest, "new email must be different from the current one")
+		return
+	}
+
+	available, err := utils.CheckEmailAvailability(newEmail)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !available {
+		utils.RespondError(c, http.StatusConflict, "Email déjà utilisé")
+		return
+	}
+
+	confirmToken, err := generateEmailChangeToken()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to generate confirmation token")
+		return
+	}
+
+	// Only one pending change per user: a new request replaces the previous one
+	db.DB.Where("user_id = ? AND confirmed_at IS NULL", user.ID).Delete(&models.EmailChange{})
+
+	change := models.EmailChange{
+		UserID:           user.ID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmToken:     confirmToken,
+		ConfirmExpiresAt: time.Now().Add(emailChangeConfirmTTL),
+	}
+	if err := db.DB.Create(&change).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to create email change request")
+		return
+	}
+
+	emailService := services.NewEmailService()
+	if err := emailService.SendEmailChangeConfirmationEmail(newEmail, confirmToken, c.GetHeader("Accept-Language")); err != nil {
+		// Log error but don't fail the request - the user can ask for a new link
+		slog.WarnContext(c.Request.Context(), "failed to send email change confirmation", "email", newEmail, "error", err)
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message":    "Confirmation email sent to the new address",
+		"new_email":  newEmail,
+		"expires_at": change.ConfirmExpiresAt,
+	})
+}
+
+// ConfirmEmailChangeHandler applies a pending email change from the link sent to
+// the new address, revokes all sessions and mails a revert link to the old address.
+func ConfirmEmailChangeHandler(c *gin.Context) {
+	var req types.EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid payload: "+err.Error())
+		return
+	}
+
+	var change models.EmailChange
+	if err := db.DB.Preload("User").
+		Where("confirm_token = ? AND confirmed_at IS NULL AND confirm_expires_at > ?", req.Token, time.Now()).
+		First(&change).Error; err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid or expired confirmation token")
+		return
+	}
+
+	// The account email moved on since the request (e.g. a revert): the link is stale
+	if change.User.Email != change.OldEmail {
+		db.DB.Delete(&change)
+		utils.RespondError(c, http.StatusConflict, "email change request is no longer valid")
+		return
+	}
+
+	available, err := utils.CheckEmailAvailability(change.NewEmail)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !available {
+		utils.RespondError(c, http.StatusConflict, "Email déjà utilisé")
+		return
+	}
+
+	revertToken, err := generateEmailChangeToken()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to generate revert token")
+		return
+	}
+
+	now := time.Now()
+	revertExpiresAt := now.Add(emailChangeRevertTTL)
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Users{}).Where("id = ?", change.UserID).Updates(map[string]interface{}{
+			"email":          change.NewEmail,
+			"email_verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&change).Updates(map[string]interface{}{
+			"confirmed_at":      now,
+			"revert_token":      revertToken,
+			"revert_expires_at": revertExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		// Last, so that the email is not changed while the sessions live on
+		return revokeUserSessions(c.Request.Context(), change.UserID)
+	})
+	if err != nil {
+		utils.RespondError(c, http.StatusServiceUnavailable, "failed to update email, please try again")
+		return
+	}
+
+	emailService := services.NewEmailService()
+	if err := emailService.SendEmailChangeRevertEmail(change.OldEmail, change.NewEmail, revertToken, int(emailChangeRevertTTL.Hours()/24), c.GetHeader("Accept-Language")); err != nil {
+		slog.WarnContext(c.Request.Context(), "failed to send email change revert link", "email", change.OldEmail, "error", err)
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message": "Email changed successfully, please log in again",
+		"email":   change.NewEmail,
+	})
+}
+
+// RevertEmailChangeHandler restores the previous email from the "this wasn't me"
+// link sent to the old address and revokes all sessions again.
+func RevertEmailChangeHandler(c *gin.Context) {
+	var req types.EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid payload: "+err.Error())
+		return
+	}
+
+	var change models.EmailChange
+	if err := db.DB.Where("revert_token = ? AND reverted_at IS NULL AND revert_expires_at > ?", req.Token, time.Now()).
+		First(&change).Error; err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid or expired revert token")
+		return
+	}
+
+	// The old address may have been claimed by another account in the meantime
+	var owner models.Users
+	if err := db.DB.Where("email = ? AND id <> ?", change.OldEmail, change.UserID).First(&owner).Error; err == nil {
+		utils.RespondError(c, http.StatusConflict, "previous email is now used by another account")
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		utils.RespondError(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Users{}).Where("id = ?", change.UserID).Updates(map[string]interface{}{
+			"email":          change.OldEmail,
+			"email_verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&change).Update("reverted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		// Whoever made the change may also have started other flows on the account
+		if err := tx.Where("user_id = ? AND confirmed_at IS NULL", change.UserID).Delete(&models.EmailChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", change.UserID).Delete(&models.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		// Last, so that nothing is reverted while the sessions live on
+		return revokeUserSessions(c.Request.Context(), change.UserID)
+	})
+	if err != nil {
+		utils.RespondError(c, http.StatusServiceUnavailable, "failed to revert email change, please try again")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message": "Email change reverted, all sessions have been logged out. Please reset your password.",
+		"email":   change.OldEmail,
+	})
+}
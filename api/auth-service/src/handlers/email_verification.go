@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"crypto/rand"
-	"fmt"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"time"
@@ -87,10 +87,10 @@ func SendEmailVerificationHandler(c *gin.Context) {
 
 	// Send verification email
 	emailService := services.NewEmailService()
-	if err := emailService.SendVerificationEmail(req.Email, code); err != nil {
+	if err := emailService.SendVerificationEmail(req.Email, code, c.GetHeader("Accept-Language")); err != nil {
 		// Log error but don't fail the request - code is still valid
-		fmt.Printf("Failed to send verification email to %s: %v\n", req.Email, err)
-		fmt.Printf("Verification code for %s: %s (email failed to send)\n", req.Email, code)
+		slog.WarnContext(c.Request.Context(), "failed to send verification email", "email", req.Email, "error", err)
+		slog.DebugContext(c.Request.Context(), "verification code not sent", "email", req.Email, "code", code)
 	}
 
 	utils.RespondSuccess(c, http.StatusOK, gin.H{
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	db "auth-service/src/conf"
+	"auth-service/src/services/mailer"
+	"auth-service/src/types"
+	"auth-service/src/utils"
+)
+
+// QueueEmailHandler lets other services (match, chat, user) send a templated
+// email through the shared outbox: POST /api/v1/internal/emails
+func QueueEmailHandler(c *gin.Context) {
+	var req types.QueueEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid payload: "+err.Error())
+		return
+	}
+
+	m := mailer.New(db.DB, mailer.DefaultRenderer())
+	email, err := m.Enqueue(req.To, req.Template, req.Locale, req.Data)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusAccepted, gin.H{
+		"id":     email.ID,
+		"status": email.Status,
+		"locale": email.Locale,
+	})
+}
+
+// ListDeadEmailsHandler returns emails that could not be delivered
+func ListDeadEmailsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	emails, err := mailer.New(db.DB, mailer.DefaultRenderer()).DeadLetters(limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"emails": emails,
+		"count":  len(emails),
+	})
+}
+
+// RetryDeadEmailHandler queues a dead email again with a fresh attempt budget
+func RetryDeadEmailHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid email id")
+		return
+	}
+
+	if err := mailer.New(db.DB, mailer.DefaultRenderer()).Retry(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondError(c, http.StatusNotFound, "dead email not found")
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"message": "email queued for retry"})
+}
@@ -69,7 +69,7 @@ func ForgotPasswordHandler(c *gin.Context) {
 
 	// Send password reset email
 	emailService := services.NewEmailService()
-	emailErr := emailService.SendPasswordResetEmail(user.Email, resetToken)
+	emailErr := emailService.SendPasswordResetEmail(user.Email, resetToken, c.GetHeader("Accept-Language"))
 
 	// Always respond with success for security (don't reveal if email exists)
 	// Even if email fails, the reset token is created and valid
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -18,6 +19,20 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// isSessionRevoked reports whether the token was issued before the user's sessions
+// were revoked (email change, revert...). Redis errors fail open like the blacklist.
+func isSessionRevoked(ctx context.Context, userID string, claims jwt.MapClaims) bool {
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return false
+	}
+	revoked, err := db.IsUserTokensInvalidated(ctx, userID, int64(iat))
+	if err != nil {
+		return false
+	}
+	return revoked
+}
+
 // VerifyTokenHandler validates JWT tokens
 func VerifyTokenHandler(c *gin.Context) {
 	auth := c.GetHeader("Authorization")
@@ -58,6 +73,11 @@ func VerifyTokenHandler(c *gin.Context) {
 		return
 	}
 
+	if isSessionRevoked(c.Request.Context(), userID, claims) {
+		utils.RespondError(c, http.StatusUnauthorized, "token revoked")
+		return
+	}
+
 	utils.RespondSuccess(c, http.StatusOK, gin.H{
 		"valid":   true,
 		"user_id": userID,
@@ -103,6 +123,11 @@ func RefreshTokenHandler(c *gin.Context) {
 		return
 	}
 
+	if isSessionRevoked(c.Request.Context(), userID, claims) {
+		utils.RespondError(c, http.StatusUnauthorized, "refresh token revoked")
+		return
+	}
+
 	// Convert string userID back to uint for token generation
 	var userIDUint uint
 	if _, err := fmt.Sscanf(userID, "%d", &userIDUint); err != nil {
@@ -188,7 +213,7 @@ func LogoutHandler(c *gin.Context) {
 	}
 
 	// Blacklist the token
-	err = db.BlacklistToken(tokenString, ttl)
+	err = db.BlacklistToken(c.Request.Context(), tokenString, ttl)
 	if err != nil {
 		// Log error but still consider logout successful
 		utils.RespondSuccess(c, http.StatusOK, gin.H{
@@ -2,23 +2,31 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	db "auth-service/src/conf"
 	"auth-service/src/handlers"
+	"auth-service/src/middleware"
 	models "auth-service/src/models"
 	types "auth-service/src/types"
 )
@@ -30,7 +38,7 @@ func setupTestDB() *gorm.DB {
 	}
 
 	// Auto-migrate models
-	database.AutoMigrate(&models.Users{}, &models.PasswordReset{})
+	database.AutoMigrate(&models.Users{}, &models.PasswordReset{}, &models.EmailChange{}, &models.EmailOutbox{})
 
 	return database
 }
@@ -54,6 +62,9 @@ func setupTestRouter() *gin.Engine {
 			auth.GET("/verify", handlers.VerifyTokenHandler)
 			auth.POST("/forgot-password", handlers.ForgotPasswordHandler)
 			auth.POST("/reset-password", handlers.ResetPasswordHandler)
+			auth.POST("/email-change/confirm", handlers.ConfirmEmailChangeHandler)
+			auth.POST("/email-change/revert", handlers.RevertEmailChangeHandler)
+			auth.POST("/email-change", middleware.AuthMiddleware(), handlers.RequestEmailChangeHandler)
 		}
 	}
 
@@ -254,6 +265,66 @@ func TestLoginHandler(t *testing.T) {
 	}
 }
 
+func TestLoginHandler_ResumesPausedProfile(t *testing.T) {
+	router := setupTestRouter()
+
+	pausedAt := time.Now().Add(-24 * time.Hour)
+	user := models.Users{
+		Username:         "pauseduser",
+		Email:            "paused@example.com",
+		PasswordHash:     "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // "password"
+		FirstName:        "Paused",
+		LastName:         "User",
+		BirthDate:        time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Gender:           string(types.GenderFemale),
+		SexPref:          string(types.SexPrefBoth),
+		RelationshipType: "long_term",
+		PausedAt:         &pausedAt,
+		ResumeOnLogin:    true,
+	}
+	db.DB.Create(&user)
+
+	// user-service resumes the profile through its pause service
+	var calls []string
+	userService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		result := db.DB.Model(&models.Users{}).
+			Where("id = ? AND paused_at IS NOT NULL AND resume_on_login = ?", user.ID, true).
+			Updates(map[string]interface{}{"paused_at": nil, "pause_until": nil, "resume_on_login": false})
+		fmt.Fprintf(w, `{"success":true,"data":{"resumed":%t}}`, result.RowsAffected == 1)
+	}))
+	defer userService.Close()
+	t.Setenv("USER_SERVICE_URL", userService.URL)
+
+	login := func() map[string]interface{} {
+		jsonPayload, _ := json.Marshal(map[string]interface{}{"login": "pauseduser", "password": "password"})
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonPayload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["data"].(map[string]interface{})
+	}
+
+	assert.Equal(t, true, login()["profile_resumed"])
+
+	var stored models.Users
+	db.DB.First(&stored, user.ID)
+	assert.Nil(t, stored.PausedAt)
+	assert.False(t, stored.ResumeOnLogin)
+
+	assert.Equal(t, false, login()["profile_resumed"], "only the first login resumes the profile")
+	assert.Equal(t, []string{fmt.Sprintf("POST /api/v1/internal/users/%d/resume-on-login", user.ID)}, calls)
+
+	// The login goes on while user-service is down
+	db.DB.Model(&stored).Updates(map[string]interface{}{"paused_at": pausedAt, "resume_on_login": true})
+	userService.Close()
+	assert.Equal(t, false, login()["profile_resumed"])
+}
+
 func TestVerifyTokenHandler(t *testing.T) {
 	router := setupTestRouter()
 
@@ -721,3 +792,212 @@ func TestResetPasswordHandler(t *testing.T) {
 		})
 	}
 }
+
+// memoryRedis answers the SET and GET commands of db.Client from memory, or
+// fails every command when down is set
+type memoryRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+	down   bool
+}
+
+func (m *memoryRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (m *memoryRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (m *memoryRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.down {
+			cmd.SetErr(errors.New("connection refused"))
+			return cmd.Err()
+		}
+		args := cmd.Args()
+		switch c := cmd.(type) {
+		case *redis.StatusCmd:
+			key := args[1].(string)
+			m.values[key] = fmt.Sprint(args[2])
+			if len(args) >= 5 {
+				m.ttls[key] = time.Duration(args[4].(int64)) * time.Second
+			}
+			c.SetVal("OK")
+		case *redis.StringCmd:
+			value, ok := m.values[args[1].(string)]
+			if !ok {
+				c.SetErr(redis.Nil)
+				return redis.Nil
+			}
+			c.SetVal(value)
+		}
+		return nil
+	}
+}
+
+func useMemoryRedis(t *testing.T) *memoryRedis {
+	t.Helper()
+	fake := &memoryRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(fake)
+	previous := db.Client
+	db.Client = client
+	t.Cleanup(func() { db.Client = previous })
+	return fake
+}
+
+func TestEmailChangeFlow(t *testing.T) {
+	db.DB = setupTestDB()
+	router := setupTestRouter()
+	fakeRedis := useMemoryRedis(t)
+	t.Setenv("JWT_REFRESH_TTL", "720h")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := models.Users{
+		Username:         "testuser",
+		FirstName:        "Test",
+		LastName:         "User",
+		Email:            "old@example.com",
+		PasswordHash:     string(hash),
+		BirthDate:        time.Now().AddDate(-25, 0, 0),
+		Gender:           "male",
+		SexPref:          "both",
+		RelationshipType: "serious",
+	}
+	require.NoError(t, db.DB.Create(&user).Error)
+	other := models.Users{
+		Username:         "otheruser",
+		FirstName:        "Other",
+		LastName:         "User",
+		Email:            "taken@example.com",
+		PasswordHash:     string(hash),
+		BirthDate:        time.Now().AddDate(-25, 0, 0),
+		Gender:           "male",
+		SexPref:          "both",
+		RelationshipType: "serious",
+	}
+	require.NoError(t, db.DB.Create(&other).Error)
+
+	post := func(path string, payload map[string]interface{}, auth bool) *httptest.ResponseRecorder {
+		jsonBytes, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonBytes))
+		req.Header.Set("Content-Type", "application/json")
+		if auth {
+			req.Header.Set("Authorization", "Bearer "+generateTestToken(int(user.ID)))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requires authentication", func(t *testing.T) {
+		w := post("/api/v1/auth/email-change", map[string]interface{}{
+			"current_password": "password123",
+			"new_email":        "new@example.com",
+		}, false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		w := post("/api/v1/auth/email-change", map[string]interface{}{
+			"current_password": "wrong-password",
+			"new_email":        "new@example.com",
+		}, true)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("email already used", func(t *testing.T) {
+		w := post("/api/v1/auth/email-change", map[string]interface{}{
+			"current_password": "password123",
+			"new_email":        "taken@example.com",
+		}, true)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("request, confirm and revert", func(t *testing.T) {
+		w := post("/api/v1/auth/email-change", map[string]interface{}{
+			"current_password": "password123",
+			"new_email":        "new@example.com",
+		}, true)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var change models.EmailChange
+		require.NoError(t, db.DB.Where("user_id = ?", user.ID).First(&change).Error)
+		assert.Equal(t, "old@example.com", change.OldEmail)
+		assert.Nil(t, change.ConfirmedAt)
+
+		// The email is not changed while the sessions cannot be revoked
+		fakeRedis.mu.Lock()
+		fakeRedis.down = true
+		fakeRedis.mu.Unlock()
+		w = post("/api/v1/auth/email-change/confirm", map[string]interface{}{"token": change.ConfirmToken}, false)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var updated models.Users
+		db.DB.First(&updated, user.ID)
+		assert.Equal(t, "old@example.com", updated.Email)
+		fakeRedis.mu.Lock()
+		fakeRedis.down = false
+		fakeRedis.mu.Unlock()
+
+		w = post("/api/v1/auth/email-change/confirm", map[string]interface{}{"token": change.ConfirmToken}, false)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		db.DB.First(&updated, user.ID)
+		assert.Equal(t, "new@example.com", updated.Email)
+		assert.True(t, updated.EmailVerified)
+		// The revocation outlives the refresh tokens
+		key := fmt.Sprintf("user_invalidated:%d", user.ID)
+		assert.Contains(t, fakeRedis.values, key)
+		assert.Equal(t, 720*time.Hour, fakeRedis.ttls[key])
+		// Tokens issued in the same second as the revocation are revoked too
+		invalidatedAt, err := strconv.ParseInt(fakeRedis.values[key], 10, 64)
+		require.NoError(t, err)
+		userKey := strconv.FormatUint(uint64(user.ID), 10)
+		revoked, err := db.IsUserTokensInvalidated(context.Background(), userKey, invalidatedAt)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = db.IsUserTokensInvalidated(context.Background(), userKey, invalidatedAt+1)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		// A confirmation link can only be used once
+		w = post("/api/v1/auth/email-change/confirm", map[string]interface{}{"token": change.ConfirmToken}, false)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		db.DB.First(&change, change.ID)
+		require.NotNil(t, change.RevertToken)
+		require.NotNil(t, change.RevertExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), *change.RevertExpiresAt, time.Minute)
+
+		w = post("/api/v1/auth/email-change/revert", map[string]interface{}{"token": *change.RevertToken}, false)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		db.DB.First(&updated, user.ID)
+		assert.Equal(t, "old@example.com", updated.Email)
+
+		w = post("/api/v1/auth/email-change/revert", map[string]interface{}{"token": *change.RevertToken}, false)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("expired revert link", func(t *testing.T) {
+		expired := time.Now().Add(-time.Hour)
+		confirmedAt := time.Now().Add(-8 * 24 * time.Hour)
+		token := "expired-revert-token"
+		require.NoError(t, db.DB.Create(&models.EmailChange{
+			UserID:           user.ID,
+			OldEmail:         "older@example.com",
+			NewEmail:         "old@example.com",
+			ConfirmToken:     "used-confirm-token",
+			ConfirmExpiresAt: confirmedAt,
+			ConfirmedAt:      &confirmedAt,
+			RevertToken:      &token,
+			RevertExpiresAt:  &expired,
+		}).Error)
+
+		w := post("/api/v1/auth/email-change/revert", map[string]interface{}{"token": token}, false)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
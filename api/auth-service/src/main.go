@@ -1,31 +1,69 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"log/slog"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
 
 	db "auth-service/src/conf"
 	"auth-service/src/handlers"
+	"auth-service/src/middleware"
+	"auth-service/src/services/mailer"
 )
 
 func main() {
+	logging.Init("auth-service")
+
+	// Initialize tracing (OTEL_TRACES_EXPORTER)
+	shutdownTracing, err := tracing.Init("auth-service")
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	lc := server.New("auth-service")
+
 	// Initialize database (will AutoMigrate models)
 	db.ConnectDatabase()
+	lc.AddCheck("database", server.DBCheck(db.DB))
+	lc.OnClose("database", server.CloseDB(db.DB))
 
 	// Initialize Redis for token blacklisting
 	if err := db.InitRedis(); err != nil {
-		log.Printf("Failed to initialize Redis: %v", err)
-		log.Println("Redis initialization failed - tokens will not be blacklisted on logout")
+		slog.Warn("redis unavailable, tokens will not be blacklisted on logout", "error", err)
 	} else {
-		log.Println("Redis initialized successfully for token blacklisting")
+		slog.Info("redis connected for token blacklisting")
+		lc.AddCheck("redis", server.RedisCheck(db.Client))
+		lc.OnClose("redis", func(ctx context.Context) error { return db.Client.Close() })
 	}
 
-	r := gin.Default()
+	// Start the background email sender (outbox -> transport). The emails
+	// queued by the last requests are sent before exiting.
+	transport, err := mailer.NewTransportFromEnv()
+	if err != nil {
+		slog.Error("invalid email transport configuration", "error", err)
+		os.Exit(1)
+	}
+	sender := mailer.NewSender(db.DB, transport)
+	lc.Go("email-sender", sender.Run)
+	lc.OnFlush("email-outbox", sender.Flush)
 
-	// Health check
+	r := gin.New()
+	r.Use(gin.Recovery(), logging.Middleware())
+	r.Use(tracing.Middleware("auth-service"))
+	metrics.Register(r)
+
+	// Health check, liveness and readiness probes
 	r.GET("/health", handlers.HealthCheckHandler)
+	lc.RegisterProbes(r)
 
 	// API routes
 	api := r.Group("/api/v1")
@@ -42,9 +80,20 @@ func main() {
 			auth.POST("/reset-password", handlers.ResetPasswordHandler)
 			auth.POST("/send-email-verification", handlers.SendEmailVerificationHandler)
 			auth.POST("/verify-email", handlers.VerifyEmailHandler)
+			auth.POST("/email-change/confirm", handlers.ConfirmEmailChangeHandler)
+			auth.POST("/email-change/revert", handlers.RevertEmailChangeHandler)
+			auth.POST("/email-change", middleware.AuthMiddleware(), handlers.RequestEmailChangeHandler)
+		}
+
+		// Internal API used by the other services to send transactional emails
+		internal := api.Group("/internal", internalapi.Middleware())
+		{
+			internal.POST("/emails", handlers.QueueEmailHandler)
+			internal.GET("/emails/dead", handlers.ListDeadEmailsHandler)
+			internal.POST("/emails/:id/retry", handlers.RetryDeadEmailHandler)
 		}
 	}
 
-	log.Println("Auth service starting on port 8001")
-	log.Fatal(http.ListenAndServe(":8001", r))
+	slog.Info("auth service starting", "port", 8001)
+	lc.Run(":8001", r)
 }
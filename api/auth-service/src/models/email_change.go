@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// EmailChange tracks a pending or completed change of a user's email address.
+// The confirm token is mailed to the new address; once confirmed, the revert
+// token is mailed to the old address and stays usable until RevertExpiresAt.
+type EmailChange struct {
+	ID               uint       `gorm:"primaryKey;column:id" json:"id"`
+	UserID           uint       `gorm:"column:user_id;not null;index" json:"user_id"`
+	OldEmail         string     `gorm:"column:old_email;type:varchar(255);not null" json:"old_email"`
+	NewEmail         string     `gorm:"column:new_email;type:varchar(255);not null" json:"new_email"`
+	ConfirmToken     string     `gorm:"column:confirm_token;type:varchar(64);not null;uniqueIndex" json:"-"`
+	ConfirmExpiresAt time.Time  `gorm:"column:confirm_expires_at;not null" json:"confirm_expires_at"`
+	ConfirmedAt      *time.Time `gorm:"column:confirmed_at" json:"confirmed_at,omitempty"`
+	RevertToken      *string    `gorm:"column:revert_token;type:varchar(64);uniqueIndex" json:"-"`
+	RevertExpiresAt  *time.Time `gorm:"column:revert_expires_at" json:"revert_expires_at,omitempty"`
+	RevertedAt       *time.Time `gorm:"column:reverted_at" json:"reverted_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// Association
+	User Users `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (EmailChange) TableName() string { return "email_changes" }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Outbox statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSending = "sending"
+	EmailStatusSent    = "sent"
+	EmailStatusDead    = "dead"
+)
+
+// EmailOutbox is a rendered email waiting to be delivered by the background sender.
+// Rows are retried with exponential backoff and end up "dead" once MaxAttempts
+// is reached or the transport reports a permanent failure.
+type EmailOutbox struct {
+	ID            uint       `gorm:"primaryKey;column:id" json:"id"`
+	ToEmail       string     `gorm:"column:to_email;type:varchar(255);not null" json:"to_email"`
+	Template      string     `gorm:"column:template;type:varchar(64);not null" json:"template"`
+	Locale        string     `gorm:"column:locale;type:varchar(8);not null" json:"locale"`
+	Subject       string     `gorm:"column:subject;type:varchar(255);not null" json:"subject"`
+	HTMLBody      string     `gorm:"column:html_body;type:text;not null" json:"-"`
+	TextBody      string     `gorm:"column:text_body;type:text;not null" json:"-"`
+	Status        string     `gorm:"column:status;type:varchar(16);not null;default:pending;index:idx_email_outbox_due,priority:1" json:"status"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	MaxAttempts   int        `gorm:"column:max_attempts;not null;default:8" json:"max_attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_email_outbox_due,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time `gorm:"column:sent_at" json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (EmailOutbox) TableName() string { return "email_outbox" }
@@ -56,6 +56,11 @@ type Users struct {
 	Longitude sql.NullFloat64 `gorm:"column:longitude" json:"longitude"`
 	CreatedAt time.Time       `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time       `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// Pause mode is managed by user-service; login only ends it when asked to
+	PausedAt      *time.Time `gorm:"column:paused_at" json:"-"`
+	PauseUntil    *time.Time `gorm:"column:pause_until" json:"-"`
+	ResumeOnLogin bool       `gorm:"column:resume_on_login;default:false" json:"-"`
 }
 
 func (Users) TableName() string { return "users" }
@@ -2,7 +2,7 @@ package services
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"gorm.io/gorm"
 
@@ -11,32 +11,33 @@ import (
 
 
 func createUserTags(tx *gorm.DB, userID uint, tagNames []string) error {
+	ctx := tx.Statement.Context
 	if len(tagNames) == 0 {
-		log.Printf("No tags provided for user %d", userID)
+		slog.DebugContext(ctx, "no tags provided", "user_id", userID)
 		return nil
 	}
 
-	log.Printf("Creating tags for user %d: %v", userID, tagNames)
+	slog.DebugContext(ctx, "creating user tags", "user_id", userID, "tags", tagNames)
 
 	// 1. Insérer les tags individuellement pour éviter les problèmes avec ON CONFLICT
 	for _, name := range tagNames {
 		tag := models.Tag{Name: name}
 		result := tx.Where("name = ?", name).FirstOrCreate(&tag)
 		if result.Error != nil {
-			log.Printf("Error creating/finding tag %s: %v", name, result.Error)
+			slog.ErrorContext(ctx, "failed to create or find tag", "tag", name, "error", result.Error)
 			return fmt.Errorf("failed to create/find tag %s: %w", name, result.Error)
 		}
-		log.Printf("Tag %s has ID %d", name, tag.ID)
+		slog.DebugContext(ctx, "tag found", "tag", name, "tag_id", tag.ID)
 	}
 
 	// 2. Récupérer tous les tags créés/trouvés
 	var dbTags []models.Tag
 	if err := tx.Where("name IN ?", tagNames).Find(&dbTags).Error; err != nil {
-		log.Printf("Error fetching tags: %v", err)
+		slog.ErrorContext(ctx, "failed to fetch tags", "error", err)
 		return fmt.Errorf("failed to fetch tags: %w", err)
 	}
 
-	log.Printf("Found %d tags in database", len(dbTags))
+	slog.DebugContext(ctx, "tags found", "count", len(dbTags))
 
 	// 3. Créer les associations user-tags
 	for _, tag := range dbTags {
@@ -47,12 +48,12 @@ func createUserTags(tx *gorm.DB, userID uint, tagNames []string) error {
 		
 		result := tx.Where("user_id = ? AND tag_id = ?", userID, tag.ID).FirstOrCreate(&userTag)
 		if result.Error != nil {
-			log.Printf("Error creating user_tag association for user %d, tag %d: %v", userID, tag.ID, result.Error)
+			slog.ErrorContext(ctx, "failed to create user tag", "user_id", userID, "tag_id", tag.ID, "error", result.Error)
 			return fmt.Errorf("failed to create user_tag association: %w", result.Error)
 		}
-		log.Printf("Created user_tag association: user %d, tag %d (%s)", userID, tag.ID, tag.Name)
+		slog.DebugContext(ctx, "user tag created", "user_id", userID, "tag_id", tag.ID, "tag", tag.Name)
 	}
 
-	log.Printf("Successfully created %d tag associations for user %d", len(dbTags), userID)
+	slog.InfoContext(ctx, "user tags created", "user_id", userID, "count", len(dbTags))
 	return nil
 }
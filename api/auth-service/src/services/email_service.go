@@ -1,72 +1,42 @@
 package services
 
 import (
-	"bytes"
-	"crypto/tls"
 	"fmt"
-	"html/template"
-	"net/smtp"
 	"os"
-	"path/filepath"
-	"strings"
+
+	db "auth-service/src/conf"
+	"auth-service/src/services/mailer"
 )
 
-// EmailService handles sending emails
+// EmailService queues transactional emails in the outbox; the background
+// mailer.Sender takes care of the actual delivery
 type EmailService struct {
-	SMTPHost     string
-	SMTPPort     string
-	SMTPUsername string
-	SMTPPassword string
-	FromEmail    string
-	FromName     string
+	mailer *mailer.Mailer
 }
 
 // NewEmailService creates a new email service instance
 func NewEmailService() *EmailService {
 	return &EmailService{
-		SMTPHost:     getEnvOrDefault("SMTP_HOST", "smtp.gmail.com"),
-		SMTPPort:     getEnvOrDefault("SMTP_PORT", "587"),
-		SMTPUsername: getEnvOrDefault("SMTP_USERNAME", ""),
-		SMTPPassword: getEnvOrDefault("SMTP_PASSWORD", ""),
-		FromEmail:    getEnvOrDefault("FROM_EMAIL", "noreply@matcha.app"),
-		FromName:     getEnvOrDefault("FROM_NAME", "Matcha"),
+		mailer: mailer.New(db.DB, mailer.DefaultRenderer()),
 	}
 }
 
+// Send queues any template from templates/email in the recipient's locale
+func (es *EmailService) Send(toEmail, template, locale string, data interface{}) error {
+	_, err := es.mailer.Enqueue(toEmail, template, locale, data)
+	return err
+}
+
 // VerificationEmailData contains data for verification email template
 type VerificationEmailData struct {
 	VerificationCode string
 }
 
 // SendVerificationEmail sends a verification code email
-func (es *EmailService) SendVerificationEmail(toEmail, verificationCode string) error {
-	// Skip sending email if SMTP is not configured (development mode)
-	if es.SMTPUsername == "" || es.SMTPPassword == "" {
-		fmt.Printf("📧 Email verification code for %s: %s\n", toEmail, verificationCode)
-		fmt.Printf("   (SMTP not configured - email not sent)\n")
-		return nil
-	}
-
-	// Load and parse template (assuming working dir is /app)
-	templatePath := filepath.Join("templates", "email", "verification_email.html")
-	tmpl, err := template.ParseFiles(templatePath)
-	if err != nil {
-		return fmt.Errorf("failed to parse email template: %v", err)
-	}
-
-	// Execute template with data
-	var body bytes.Buffer
-	data := VerificationEmailData{
+func (es *EmailService) SendVerificationEmail(toEmail, verificationCode, locale string) error {
+	return es.Send(toEmail, "verification", locale, VerificationEmailData{
 		VerificationCode: verificationCode,
-	}
-	if err := tmpl.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute email template: %v", err)
-	}
-
-	// Email subject
-	subject := "Vérification de votre email - Matcha"
-
-	return es.sendEmail(toEmail, subject, body.String())
+	})
 }
 
 // PasswordResetData contains data for password reset email template
@@ -75,119 +45,46 @@ type PasswordResetData struct {
 }
 
 // SendPasswordResetEmail sends a password reset email
-func (es *EmailService) SendPasswordResetEmail(toEmail, resetToken string) error {
-	// Get frontend URL from environment
-	frontendURL := getEnvOrDefault("FRONTEND_URL", "https://localhost:8443")
-	resetURL := fmt.Sprintf("%s/reinitialiser-mot-de-passe?token=%s", frontendURL, resetToken)
-	
-	// Skip sending email if SMTP is not configured (development mode)
-	if es.SMTPUsername == "" || es.SMTPPassword == "" {
-		fmt.Printf("🔑 Password reset for %s:\n", toEmail)
-		fmt.Printf("   Link: %s\n", resetURL)
-		fmt.Printf("   (SMTP not configured - email not sent)\n")
-		return nil
-	}
-
-	// Load and parse template
-	templatePath := filepath.Join("templates", "email", "password_reset.html")
-	tmpl, err := template.ParseFiles(templatePath)
-	if err != nil {
-		return fmt.Errorf("failed to parse email template: %v", err)
-	}
-
-	// Execute template with data
-	var body bytes.Buffer
-	data := PasswordResetData{
-		ResetURL: resetURL,
-	}
-	if err := tmpl.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute email template: %v", err)
-	}
-
-	// Email subject
-	subject := "Réinitialisation de votre mot de passe - Matcha"
-
-	return es.sendEmail(toEmail, subject, body.String())
+func (es *EmailService) SendPasswordResetEmail(toEmail, resetToken, locale string) error {
+	return es.Send(toEmail, "password_reset", locale, PasswordResetData{
+		ResetURL: frontendURL("/reinitialiser-mot-de-passe?token=" + resetToken),
+	})
 }
 
-// sendEmail sends an email using SMTP
-func (es *EmailService) sendEmail(to, subject, body string) error {
-	// Set up authentication information
-	auth := smtp.PlainAuth("", es.SMTPUsername, es.SMTPPassword, es.SMTPHost)
-
-	// Build email message
-	message := fmt.Sprintf("From: %s <%s>\r\n", es.FromName, es.FromEmail)
-	message += fmt.Sprintf("To: %s\r\n", to)
-	message += fmt.Sprintf("Subject: %s\r\n", subject)
-	message += "MIME-Version: 1.0\r\n"
-	message += "Content-Type: text/html; charset=UTF-8\r\n"
-	message += "\r\n"
-	message += body
-
-	// Connect to the server, authenticate, set the sender and recipient,
-	// and send the email all in one step
-	serverAddr := es.SMTPHost + ":" + es.SMTPPort
-
-	// For Gmail and other TLS-required servers
-	if es.SMTPHost == "smtp.gmail.com" || strings.Contains(es.SMTPHost, "gmail") {
-		return es.sendEmailWithTLS(serverAddr, auth, es.FromEmail, []string{to}, []byte(message))
-	}
-
-	// Standard SMTP (for other providers)
-	return smtp.SendMail(serverAddr, auth, es.FromEmail, []string{to}, []byte(message))
+// EmailChangeConfirmData contains data for the email change confirmation template
+type EmailChangeConfirmData struct {
+	ConfirmURL string
+	NewEmail   string
 }
 
-// sendEmailWithTLS sends email with explicit TLS (needed for Gmail)
-func (es *EmailService) sendEmailWithTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
-	// Connect to server
-	client, err := smtp.Dial(addr)
-	if err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %v", err)
-	}
-	defer client.Close()
-
-	// Start TLS
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: false,
-		ServerName:         es.SMTPHost,
-	}
-
-	if err = client.StartTLS(tlsConfig); err != nil {
-		return fmt.Errorf("failed to start TLS: %v", err)
-	}
-
-	// Authenticate
-	if err = client.Auth(auth); err != nil {
-		return fmt.Errorf("failed to authenticate: %v", err)
-	}
-
-	// Set sender
-	if err = client.Mail(from); err != nil {
-		return fmt.Errorf("failed to set sender: %v", err)
-	}
-
-	// Set recipients
-	for _, addr := range to {
-		if err = client.Rcpt(addr); err != nil {
-			return fmt.Errorf("failed to set recipient: %v", err)
-		}
-	}
-
-	// Send message
-	writer, err := client.Data()
-	if err != nil {
-		return fmt.Errorf("failed to get data writer: %v", err)
-	}
+// SendEmailChangeConfirmationEmail sends the confirmation link to the requested new address
+func (es *EmailService) SendEmailChangeConfirmationEmail(toEmail, confirmToken, locale string) error {
+	return es.Send(toEmail, "email_change_confirm", locale, EmailChangeConfirmData{
+		ConfirmURL: frontendURL("/confirmer-email?token=" + confirmToken),
+		NewEmail:   toEmail,
+	})
+}
 
-	if _, err = writer.Write(msg); err != nil {
-		return fmt.Errorf("failed to write message: %v", err)
-	}
+// EmailChangeRevertData contains data for the "this wasn't me" email template
+type EmailChangeRevertData struct {
+	RevertURL string
+	NewEmail  string
+	ValidDays int
+}
 
-	if err = writer.Close(); err != nil {
-		return fmt.Errorf("failed to close writer: %v", err)
-	}
+// SendEmailChangeRevertEmail warns the previous address that the email was changed
+// and gives it a link to undo the change
+func (es *EmailService) SendEmailChangeRevertEmail(toEmail, newEmail, revertToken string, validDays int, locale string) error {
+	return es.Send(toEmail, "email_change_revert", locale, EmailChangeRevertData{
+		RevertURL: frontendURL("/annuler-changement-email?token=" + revertToken),
+		NewEmail:  newEmail,
+		ValidDays: validDays,
+	})
+}
 
-	return client.Quit()
+// frontendURL builds an absolute link to a frontend page
+func frontendURL(path string) string {
+	return fmt.Sprintf("%s%s", getEnvOrDefault("FRONTEND_URL", "https://localhost:8443"), path)
 }
 
 // getEnvOrDefault gets environment variable or returns default value
@@ -196,4 +93,4 @@ func getEnvOrDefault(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
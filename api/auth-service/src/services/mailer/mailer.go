@@ -0,0 +1,92 @@
+// Package mailer implements Matcha's transactional emails: localized
+// html/text templates, a database outbox and a background sender that
+// delivers through a pluggable transport with retries and a dead-letter state.
+package mailer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"auth-service/src/models"
+)
+
+// defaultMaxAttempts is used when EMAIL_MAX_ATTEMPTS is not set
+const defaultMaxAttempts = 8
+
+// Mailer renders emails and stores them in the outbox
+type Mailer struct {
+	db          *gorm.DB
+	renderer    *Renderer
+	maxAttempts int
+}
+
+// New creates a mailer writing to the email_outbox table of db
+func New(db *gorm.DB, renderer *Renderer) *Mailer {
+	maxAttempts, err := strconv.Atoi(getEnvOrDefault("EMAIL_MAX_ATTEMPTS", ""))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	return &Mailer{db: db, renderer: renderer, maxAttempts: maxAttempts}
+}
+
+// Enqueue renders the template and queues the result for delivery.
+// Rendering happens now so a template error is reported to the caller
+// instead of surfacing later in the sender.
+func (m *Mailer) Enqueue(to, template, locale string, data interface{}) (*models.EmailOutbox, error) {
+	if m.db == nil {
+		return nil, fmt.Errorf("email outbox database is not initialized")
+	}
+
+	rendered, err := m.renderer.Render(template, locale, data)
+	if err != nil {
+		return nil, err
+	}
+
+	email := models.EmailOutbox{
+		ToEmail:       to,
+		Template:      template,
+		Locale:        NormalizeLocale(locale),
+		Subject:       rendered.Subject,
+		HTMLBody:      rendered.HTML,
+		TextBody:      rendered.Text,
+		Status:        models.EmailStatusPending,
+		MaxAttempts:   m.maxAttempts,
+		NextAttemptAt: time.Now(),
+	}
+	if err := m.db.Create(&email).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue email: %v", err)
+	}
+	return &email, nil
+}
+
+// DeadLetters lists emails that exhausted their attempts, most recent first
+func (m *Mailer) DeadLetters(limit int) ([]models.EmailOutbox, error) {
+	var emails []models.EmailOutbox
+	err := m.db.Where("status = ?", models.EmailStatusDead).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&emails).Error
+	return emails, err
+}
+
+// Retry moves a dead email back to pending with a fresh attempt budget
+func (m *Mailer) Retry(id uint) error {
+	result := m.db.Model(&models.EmailOutbox{}).
+		Where("id = ? AND status = ?", id, models.EmailStatusDead).
+		Updates(map[string]interface{}{
+			"status":          models.EmailStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"updated_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
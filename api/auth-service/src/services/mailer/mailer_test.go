@@ -0,0 +1,260 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"auth-service/src/models"
+)
+
+// fakeTransport records messages and returns the queued errors in order
+type fakeTransport struct {
+	sent   []*Message
+	errors []error
+}
+
+func (f *fakeTransport) Name() string { return "fake" }
+
+func (f *fakeTransport) Send(_ context.Context, msg *Message) error {
+	if len(f.errors) > 0 {
+		err := f.errors[0]
+		f.errors = f.errors[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func testRenderer() *Renderer {
+	return NewRenderer(filepath.Join("..", "..", "..", "templates", "email"))
+}
+
+func setupOutboxDB(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.EmailOutbox{}))
+	return database
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	assert.Equal(t, "fr", NormalizeLocale(""))
+	assert.Equal(t, "en", NormalizeLocale("en"))
+	assert.Equal(t, "en", NormalizeLocale("en-US,en;q=0.9"))
+	assert.Equal(t, "fr", NormalizeLocale("fr-FR,fr;q=0.9,en;q=0.8"))
+	assert.Equal(t, "en", NormalizeLocale("de-DE,en;q=0.5,fr;q=0.3"))
+	assert.Equal(t, "fr", NormalizeLocale("es"))
+}
+
+func TestRenderer_AllTemplatesBothLocales(t *testing.T) {
+	r := testRenderer()
+	data := map[string]interface{}{
+		"VerificationCode": "123456",
+		"ResetURL":         "https://example.com/reset",
+		"ConfirmURL":       "https://example.com/confirm",
+		"RevertURL":        "https://example.com/revert",
+		"NewEmail":         "new@example.com",
+		"ValidDays":        7,
+		"MatchName":        "Alice",
+		"AppURL":           "https://example.com",
+		"UnreadCount":      2,
+		"Conversations":    []map[string]interface{}{{"Name": "Alice", "Count": 2, "Preview": "Salut"}},
+		"Event":            "New login",
+		"OccurredAt":       "2024-01-01 10:00",
+		"Details":          "Paris, France",
+		"Message":          "Quelqu'un vous a liké",
+	}
+
+	for _, locale := range SupportedLocales {
+		for _, name := range []string{"verification", "password_reset", "email_change_confirm", "email_change_revert", "new_match", "chat_digest", "security_alert", "data_export_ready", "account_deletion_scheduled", "profile_resumed", "notification"} {
+			rendered, err := r.Render(name, locale, data)
+			require.NoError(t, err, "%s/%s", locale, name)
+			assert.NotEmpty(t, rendered.Subject, "%s/%s", locale, name)
+			assert.Contains(t, rendered.HTML, "<html lang=\""+locale+"\">")
+			assert.NotEmpty(t, rendered.Text)
+		}
+	}
+}
+
+func TestRenderer_LocaleAndEscaping(t *testing.T) {
+	r := testRenderer()
+
+	fr, err := r.Render("new_match", "fr-FR", map[string]string{"MatchName": "<b>Bob</b>", "AppURL": "https://example.com"})
+	require.NoError(t, err)
+	assert.Contains(t, fr.Subject, "C'est un match")
+	assert.Contains(t, fr.HTML, "&lt;b&gt;Bob&lt;/b&gt;")
+	assert.Contains(t, fr.Text, "<b>Bob</b>")
+
+	en, err := r.Render("new_match", "en", map[string]string{"MatchName": "Bob"})
+	require.NoError(t, err)
+	assert.Contains(t, en.Subject, "It's a match")
+
+	// Unknown locales fall back to French
+	de, err := r.Render("new_match", "de", map[string]string{"MatchName": "Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "C'est un match avec Bob ! - Matcha", de.Subject)
+
+	_, err = r.Render("../secret", "fr", nil)
+	assert.Error(t, err)
+	_, err = r.Render("does_not_exist", "fr", nil)
+	assert.Error(t, err)
+}
+
+func TestSender_DeliversQueuedEmail(t *testing.T) {
+	database := setupOutboxDB(t)
+	m := New(database, testRenderer())
+
+	queued, err := m.Enqueue("user@example.com", "verification", "en", map[string]string{"VerificationCode": "654321"})
+	require.NoError(t, err)
+	assert.Equal(t, models.EmailStatusPending, queued.Status)
+
+	transport := &fakeTransport{}
+	processed, err := NewSender(database, transport).ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	require.Len(t, transport.sent, 1)
+	assert.Equal(t, "user@example.com", transport.sent[0].To)
+	assert.Equal(t, "Verify your email - Matcha", transport.sent[0].Subject)
+	assert.Contains(t, transport.sent[0].Text, "654321")
+
+	var stored models.EmailOutbox
+	require.NoError(t, database.First(&stored, queued.ID).Error)
+	assert.Equal(t, models.EmailStatusSent, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.NotNil(t, stored.SentAt)
+
+	// Nothing left to do
+	processed, err = NewSender(database, transport).ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+}
+
+func TestSender_RetriesThenDeadLetters(t *testing.T) {
+	database := setupOutboxDB(t)
+	m := New(database, testRenderer())
+	m.maxAttempts = 2
+
+	queued, err := m.Enqueue("user@example.com", "verification", "fr", map[string]string{"VerificationCode": "1"})
+	require.NoError(t, err)
+
+	transport := &fakeTransport{errors: []error{errors.New("connection refused"), errors.New("connection refused")}}
+	sender := NewSender(database, transport)
+
+	_, err = sender.ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	var stored models.EmailOutbox
+	require.NoError(t, database.First(&stored, queued.ID).Error)
+	assert.Equal(t, models.EmailStatusPending, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, "connection refused", stored.LastError)
+	assert.True(t, stored.NextAttemptAt.After(time.Now().Add(25*time.Second)))
+
+	// Not due yet: the backoff must be respected
+	processed, err := sender.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	database.Model(&stored).Update("next_attempt_at", time.Now().Add(-time.Second))
+	_, err = sender.ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, database.First(&stored, queued.ID).Error)
+	assert.Equal(t, models.EmailStatusDead, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+
+	dead, err := m.DeadLetters(10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+
+	// Manual retry from the dead-letter queue
+	require.NoError(t, m.Retry(queued.ID))
+	_, err = sender.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, database.First(&stored, queued.ID).Error)
+	assert.Equal(t, models.EmailStatusSent, stored.Status)
+	assert.Len(t, transport.sent, 1)
+
+	assert.ErrorIs(t, m.Retry(queued.ID), gorm.ErrRecordNotFound)
+}
+
+func TestSender_PermanentErrorSkipsRetries(t *testing.T) {
+	database := setupOutboxDB(t)
+	queued, err := New(database, testRenderer()).Enqueue("bad@example.com", "verification", "fr", map[string]string{"VerificationCode": "1"})
+	require.NoError(t, err)
+
+	transport := &fakeTransport{errors: []error{&PermanentError{Err: errors.New("550 mailbox unavailable")}}}
+	_, err = NewSender(database, transport).ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	var stored models.EmailOutbox
+	require.NoError(t, database.First(&stored, queued.ID).Error)
+	assert.Equal(t, models.EmailStatusDead, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+}
+
+func TestSender_FlushDeliversEveryDueEmail(t *testing.T) {
+	database := setupOutboxDB(t)
+	m := New(database, testRenderer())
+	for i := 0; i < 3; i++ {
+		_, err := m.Enqueue("user@example.com", "verification", "fr", map[string]string{"VerificationCode": "1"})
+		require.NoError(t, err)
+	}
+
+	// A failed email is retried later, it does not keep the flush going
+	transport := &fakeTransport{errors: []error{errors.New("connection refused")}}
+	sender := NewSender(database, transport)
+	sender.batchSize = 2
+	require.NoError(t, sender.Flush(context.Background()))
+	assert.Len(t, transport.sent, 2)
+
+	var pending int64
+	database.Model(&models.EmailOutbox{}).Where("status = ?", models.EmailStatusPending).Count(&pending)
+	assert.Equal(t, int64(1), pending)
+}
+
+func TestBackoff(t *testing.T) {
+	first := Backoff(1)
+	assert.GreaterOrEqual(t, first, 30*time.Second)
+	assert.LessOrEqual(t, first, 36*time.Second)
+
+	third := Backoff(3)
+	assert.GreaterOrEqual(t, third, 2*time.Minute)
+
+	capped := Backoff(50)
+	assert.GreaterOrEqual(t, capped, time.Hour)
+	assert.LessOrEqual(t, capped, time.Hour+12*time.Minute)
+}
+
+func TestLogTransport_RedactsTokensAndRecipient(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New("auth-service", logging.Options{Level: slog.LevelInfo, Format: logging.FormatJSON, Output: &buf}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	err := LogTransport{}.Send(context.Background(), &Message{
+		To:      "alice@example.com",
+		Subject: "Confirm your email",
+		Text:    "Confirm: http://localhost:3000/confirmer-email?token=s3cr3t\nCode: 123456",
+	})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.NotContains(t, out, "s3cr3t")
+	assert.NotContains(t, out, "alice@")
+	assert.Contains(t, out, "Confirm your email")
+	assert.Contains(t, out, "123456")
+}
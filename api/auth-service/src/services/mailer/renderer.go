@@ -0,0 +1,172 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used when the requested locale has no translation
+const DefaultLocale = "fr"
+
+// SupportedLocales lists the locales shipped in templates/email/<locale>/
+var SupportedLocales = []string{"fr", "en"}
+
+var templateNameRe = regexp.MustCompile(`^[a-z_]+$`)
+
+// Rendered is the output of a template for one locale
+type Rendered struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Renderer renders localized email templates.
+//
+// Each locale directory holds a shared _layout.html plus, per email, a
+// <name>.html defining "title" and "content" and a <name>.txt defining
+// "subject" and "body". Parsed templates are cached.
+type Renderer struct {
+	dir   string
+	mu    sync.RWMutex
+	html  map[string]*htmltemplate.Template
+	texts map[string]*texttemplate.Template
+}
+
+// NewRenderer creates a renderer reading templates from dir
+func NewRenderer(dir string) *Renderer {
+	return &Renderer{
+		dir:   dir,
+		html:  make(map[string]*htmltemplate.Template),
+		texts: make(map[string]*texttemplate.Template),
+	}
+}
+
+var (
+	defaultRenderer     *Renderer
+	defaultRendererOnce sync.Once
+)
+
+// DefaultRenderer returns the process-wide renderer using EMAIL_TEMPLATES_DIR
+// (templates/email relative to the working directory by default)
+func DefaultRenderer() *Renderer {
+	defaultRendererOnce.Do(func() {
+		dir := os.Getenv("EMAIL_TEMPLATES_DIR")
+		if dir == "" {
+			dir = filepath.Join("templates", "email")
+		}
+		defaultRenderer = NewRenderer(dir)
+	})
+	return defaultRenderer
+}
+
+// Render renders the named template in the given locale, falling back to
+// DefaultLocale when the locale is unknown or has no such template.
+func (r *Renderer) Render(name, locale string, data interface{}) (*Rendered, error) {
+	if !templateNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	locale = NormalizeLocale(locale)
+
+	htmlTmpl, textTmpl, err := r.load(name, locale)
+	if err != nil && locale != DefaultLocale {
+		htmlTmpl, textTmpl, err = r.load(name, DefaultLocale)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to execute subject of %s: %v", name, err)
+	}
+	if err := textTmpl.ExecuteTemplate(&text, "body", data); err != nil {
+		return nil, fmt.Errorf("failed to execute text body of %s: %v", name, err)
+	}
+	if err := htmlTmpl.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, fmt.Errorf("failed to execute html body of %s: %v", name, err)
+	}
+
+	return &Rendered{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// load returns the cached templates for name/locale, parsing them on first use
+func (r *Renderer) load(name, locale string) (*htmltemplate.Template, *texttemplate.Template, error) {
+	key := locale + "/" + name
+
+	r.mu.RLock()
+	h, hok := r.html[key]
+	t, tok := r.texts[key]
+	r.mu.RUnlock()
+	if hok && tok {
+		return h, t, nil
+	}
+
+	dir := filepath.Join(r.dir, locale)
+	h, err := htmltemplate.ParseFiles(filepath.Join(dir, "_layout.html"), filepath.Join(dir, name+".html"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse email template: %v", err)
+	}
+	t, err = texttemplate.ParseFiles(filepath.Join(dir, name+".txt"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse email template: %v", err)
+	}
+
+	r.mu.Lock()
+	r.html[key] = h
+	r.texts[key] = t
+	r.mu.Unlock()
+	return h, t, nil
+}
+
+// NormalizeLocale maps a locale or an Accept-Language header value to one of
+// SupportedLocales, defaulting to DefaultLocale
+func NormalizeLocale(value string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" {
+			continue
+		}
+		if i := strings.IndexAny(lang, "-_"); i > 0 {
+			lang = lang[:i]
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		candidates = append(candidates, candidate{lang, q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		for _, supported := range SupportedLocales {
+			if c.lang == supported {
+				return supported
+			}
+		}
+	}
+	return DefaultLocale
+}
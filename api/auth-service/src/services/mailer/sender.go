@@ -0,0 +1,189 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+
+	"auth-service/src/models"
+)
+
+const (
+	// baseBackoff is the delay before the first retry, doubled on each attempt
+	baseBackoff = 30 * time.Second
+	// maxBackoff caps the delay between two attempts
+	maxBackoff = time.Hour
+	// stuckSendingAfter releases rows left in "sending" by a crashed sender
+	stuckSendingAfter = 10 * time.Minute
+	// sendTimeout bounds a single transport call
+	sendTimeout = 30 * time.Second
+)
+
+// Sender polls the outbox and delivers due emails through a transport
+type Sender struct {
+	db        *gorm.DB
+	transport Transport
+	fromEmail string
+	fromName  string
+	interval  time.Duration
+	batchSize int
+}
+
+// NewSender creates a sender; FROM_EMAIL and FROM_NAME set the envelope
+func NewSender(db *gorm.DB, transport Transport) *Sender {
+	return &Sender{
+		db:        db,
+		transport: transport,
+		fromEmail: getEnvOrDefault("FROM_EMAIL", "noreply@matcha.app"),
+		fromName:  getEnvOrDefault("FROM_NAME", "Matcha"),
+		interval:  5 * time.Second,
+		batchSize: 20,
+	}
+}
+
+// Run processes the outbox until ctx is cancelled
+func (s *Sender) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "email sender started", "transport", s.transport.Name())
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.releaseStuck()
+		if _, err := s.ProcessBatch(ctx); err != nil {
+			slog.WarnContext(ctx, "email outbox batch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "email sender stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush delivers the emails that are due until none is left or ctx is done.
+// It runs at shutdown, once Run has returned, so that the emails queued by the
+// last requests are not left waiting for the next start.
+func (s *Sender) Flush(ctx context.Context) error {
+	flushed := 0
+	for ctx.Err() == nil {
+		handled, err := s.ProcessBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if handled == 0 {
+			break
+		}
+		flushed += handled
+	}
+	if flushed > 0 {
+		slog.InfoContext(ctx, "email outbox flushed", "count", flushed)
+	}
+	return ctx.Err()
+}
+
+// ProcessBatch delivers up to batchSize due emails and returns how many were handled
+func (s *Sender) ProcessBatch(ctx context.Context) (int, error) {
+	var due []models.EmailOutbox
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(s.batchSize).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if !s.claim(&due[i]) {
+			// Another sender instance picked it up
+			continue
+		}
+		s.deliver(ctx, &due[i])
+		handled++
+	}
+	return handled, nil
+}
+
+// claim marks the email as sending; it fails if someone else already did
+func (s *Sender) claim(email *models.EmailOutbox) bool {
+	result := s.db.Model(&models.EmailOutbox{}).
+		Where("id = ? AND status = ?", email.ID, models.EmailStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.EmailStatusSending,
+			"updated_at": time.Now(),
+		})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func (s *Sender) deliver(ctx context.Context, email *models.EmailOutbox) {
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := s.transport.Send(sendCtx, &Message{
+		FromEmail: s.fromEmail,
+		FromName:  s.fromName,
+		To:        email.ToEmail,
+		Subject:   email.Subject,
+		HTML:      email.HTMLBody,
+		Text:      email.TextBody,
+	})
+	cancel()
+
+	now := time.Now()
+	attempts := email.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"updated_at": now,
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = models.EmailStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	case IsPermanent(err) || attempts >= email.MaxAttempts:
+		updates["status"] = models.EmailStatusDead
+		updates["last_error"] = err.Error()
+		slog.ErrorContext(ctx, "email moved to dead-letter",
+			"email_id", email.ID, "template", email.Template, "to", email.ToEmail, "attempts", attempts, "error", err)
+	default:
+		updates["status"] = models.EmailStatusPending
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(Backoff(attempts))
+		slog.WarnContext(ctx, "email delivery failed",
+			"email_id", email.ID, "template", email.Template, "to", email.ToEmail,
+			"attempt", attempts, "max_attempts", email.MaxAttempts, "error", err)
+	}
+
+	if err := s.db.Model(&models.EmailOutbox{}).Where("id = ?", email.ID).Updates(updates).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to update outbox email", "email_id", email.ID, "error", err)
+	}
+}
+
+// releaseStuck puts back emails whose sender died mid-delivery
+func (s *Sender) releaseStuck() {
+	s.db.Model(&models.EmailOutbox{}).
+		Where("status = ? AND updated_at < ?", models.EmailStatusSending, time.Now().Add(-stuckSendingAfter)).
+		Updates(map[string]interface{}{
+			"status":     models.EmailStatusPending,
+			"updated_at": time.Now(),
+		})
+}
+
+// Backoff returns the delay before the next attempt: 30s, 1m, 2m... capped
+// at one hour, with up to 20% jitter so failed batches don't retry in lockstep
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay/5)+1))
+}
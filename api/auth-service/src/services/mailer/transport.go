@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is a fully rendered email ready to be handed to a transport
+type Message struct {
+	FromEmail string
+	FromName  string
+	To        string
+	Subject   string
+	HTML      string
+	Text      string
+}
+
+// Transport delivers messages. Implementations return a *PermanentError when
+// retrying cannot succeed (invalid recipient, rejected payload...).
+type Transport interface {
+	Name() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+// PermanentError marks a delivery failure that must not be retried
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return "permanent: " + e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// IsPermanent reports whether err should send the email straight to dead-letter
+func IsPermanent(err error) bool {
+	var perm *PermanentError
+	return errors.As(err, &perm)
+}
+
+// NewTransportFromEnv builds the transport selected by EMAIL_TRANSPORT
+// (smtp, http, file or log). When unset, SMTP is used if credentials are
+// configured and the log transport otherwise, which keeps the historical
+// development behaviour of showing the codes in the logs.
+func NewTransportFromEnv() (Transport, error) {
+	kind := strings.ToLower(os.Getenv("EMAIL_TRANSPORT"))
+	if kind == "" {
+		if os.Getenv("SMTP_USERNAME") != "" && os.Getenv("SMTP_PASSWORD") != "" {
+			kind = "smtp"
+		} else {
+			kind = "log"
+		}
+	}
+
+	switch kind {
+	case "smtp":
+		return NewSMTPTransportFromEnv(), nil
+	case "http":
+		return NewHTTPTransportFromEnv()
+	case "file":
+		return NewFileTransport(getEnvOrDefault("EMAIL_MAILBOX_DIR", "mailbox"))
+	case "log":
+		return LogTransport{}, nil
+	default:
+		return nil, fmt.Errorf("unknown EMAIL_TRANSPORT %q", kind)
+	}
+}
+
+// LogTransport logs emails instead of sending them (development). They go
+// through slog, so the links carrying tokens and the recipient are redacted:
+// use the file transport to follow the links.
+type LogTransport struct{}
+
+func (LogTransport) Name() string { return "log" }
+
+func (LogTransport) Send(ctx context.Context, msg *Message) error {
+	slog.InfoContext(ctx, "email not sent (EMAIL_TRANSPORT=log)",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"text", strings.TrimSpace(msg.Text),
+	)
+	return nil
+}
+
+// buildMIME renders msg as a multipart/alternative RFC 5322 message
+func buildMIME(msg *Message) []byte {
+	boundary := randomBoundary()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s <%s>\r\n", mime.QEncoding.Encode("utf-8", msg.FromName), msg.FromEmail)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+	b.WriteString("\r\n")
+
+	writePart := func(contentType, body string) {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; charset=UTF-8\r\n", contentType)
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&b)
+		qp.Write([]byte(body))
+		qp.Close()
+		b.WriteString("\r\n")
+	}
+	writePart("text/plain", msg.Text)
+	writePart("text/html", msg.HTML)
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return b.Bytes()
+}
+
+func randomBoundary() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		slog.Warn("failed to generate MIME boundary", "error", err)
+		return fmt.Sprintf("matcha-%d", time.Now().UnixNano())
+	}
+	return "matcha-" + hex.EncodeToString(buf)
+}
+
+// getEnvOrDefault gets environment variable or returns default value
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// FileTransport writes every message as an .eml file in a mailbox directory.
+// Useful in development and end-to-end tests to inspect what would be sent.
+type FileTransport struct {
+	Dir string
+}
+
+// NewFileTransport creates the mailbox directory if needed
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mailbox directory: %v", err)
+	}
+	return &FileTransport{Dir: dir}, nil
+}
+
+func (t *FileTransport) Name() string { return "file" }
+
+func (t *FileTransport) Send(_ context.Context, msg *Message) error {
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(t.Dir, name), buildMIME(msg), 0o644)
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HTTPTransport posts messages as JSON to a transactional email provider
+// (or any relay exposing a compatible endpoint)
+type HTTPTransport struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+// httpEmailPayload is the JSON body sent to the provider
+type httpEmailPayload struct {
+	From     string `json:"from"`
+	FromName string `json:"from_name"`
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	HTML     string `json:"html"`
+	Text     string `json:"text"`
+}
+
+// NewHTTPTransportFromEnv configures the transport from EMAIL_HTTP_URL and EMAIL_HTTP_API_KEY
+func NewHTTPTransportFromEnv() (*HTTPTransport, error) {
+	url := os.Getenv("EMAIL_HTTP_URL")
+	if url == "" {
+		return nil, fmt.Errorf("EMAIL_HTTP_URL is required when EMAIL_TRANSPORT=http")
+	}
+	return &HTTPTransport{
+		URL:    url,
+		APIKey: os.Getenv("EMAIL_HTTP_API_KEY"),
+		Client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (t *HTTPTransport) Name() string { return "http" }
+
+func (t *HTTPTransport) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(httpEmailPayload{
+		From:     msg.FromEmail,
+		FromName: msg.FromName,
+		To:       msg.To,
+		Subject:  msg.Subject,
+		HTML:     msg.HTML,
+		Text:     msg.Text,
+	})
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("email provider unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("email provider returned %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	// Client errors other than throttling will fail the same way next time
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// SMTPTransport sends emails through an SMTP relay
+type SMTPTransport struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+// NewSMTPTransportFromEnv configures SMTP from the SMTP_* variables
+func NewSMTPTransportFromEnv() *SMTPTransport {
+	return &SMTPTransport{
+		Host:     getEnvOrDefault("SMTP_HOST", "smtp.gmail.com"),
+		Port:     getEnvOrDefault("SMTP_PORT", "587"),
+		Username: getEnvOrDefault("SMTP_USERNAME", ""),
+		Password: getEnvOrDefault("SMTP_PASSWORD", ""),
+	}
+}
+
+func (t *SMTPTransport) Name() string { return "smtp" }
+
+// Send sends an email using SMTP
+func (t *SMTPTransport) Send(_ context.Context, msg *Message) error {
+	auth := smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	serverAddr := t.Host + ":" + t.Port
+	body := buildMIME(msg)
+
+	// For Gmail and other TLS-required servers
+	if t.Host == "smtp.gmail.com" || strings.Contains(t.Host, "gmail") {
+		return t.sendWithTLS(serverAddr, auth, msg.FromEmail, []string{msg.To}, body)
+	}
+
+	// Standard SMTP (for other providers)
+	return classifySMTPError(smtp.SendMail(serverAddr, auth, msg.FromEmail, []string{msg.To}, body))
+}
+
+// sendWithTLS sends email with explicit TLS (needed for Gmail)
+func (t *SMTPTransport) sendWithTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	client, err := smtp.Dial(addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %v", err)
+	}
+	defer client.Close()
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: false,
+		ServerName:         t.Host,
+	}
+	if err = client.StartTLS(tlsConfig); err != nil {
+		return fmt.Errorf("failed to start TLS: %v", err)
+	}
+
+	if err = client.Auth(auth); err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	if err = client.Mail(from); err != nil {
+		return classifySMTPError(fmt.Errorf("failed to set sender: %w", err))
+	}
+
+	for _, addr := range to {
+		if err = client.Rcpt(addr); err != nil {
+			return classifySMTPError(fmt.Errorf("failed to set recipient: %w", err))
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to get data writer: %v", err)
+	}
+	if _, err = writer.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		return classifySMTPError(fmt.Errorf("failed to close writer: %w", err))
+	}
+
+	return client.Quit()
+}
+
+// classifySMTPError turns 5xx replies (e.g. "550 mailbox unavailable") into
+// permanent errors; 4xx replies and network errors stay retryable
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
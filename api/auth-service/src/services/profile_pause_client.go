@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+// ResumeProfileOnLogin asks user-service to end the pause of a user who just
+// logged in, so that the resume goes through its pause service and notifies
+// the user. It reports whether the profile was resumed.
+func ResumeProfileOnLogin(ctx context.Context, userID uint) (bool, error) {
+	userServiceURL := os.Getenv("USER_SERVICE_URL")
+	if userServiceURL == "" {
+		userServiceURL = "http://user-service:8002"
+	}
+
+	url := fmt.Sprintf("%s/api/v1/internal/users/%d/resume-on-login", userServiceURL, userID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create resume request: %w", err)
+	}
+	req.Header.Set("User-Agent", "matcha-internal-service/auth-service")
+	if internalKey := os.Getenv("INTERNAL_API_KEY"); internalKey != "" {
+		req.Header.Set("X-Internal-Key", internalKey)
+	}
+
+	resp, err := tracing.NewClient(5 * time.Second).Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to call user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("user service returned status: %d", resp.StatusCode)
+	}
+	var body struct {
+		Data struct {
+			Resumed bool `json:"resumed"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("failed to decode resume response: %w", err)
+	}
+	return body.Data.Resumed, nil
+}
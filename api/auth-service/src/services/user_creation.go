@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -13,7 +15,7 @@ import (
 )
 
 // createUser creates a new user in the database with full profile
-func CreateUser(req types.RegisterRequest) (*models.Users, error) {
+func CreateUser(ctx context.Context, req types.RegisterRequest) (*models.Users, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process password")
@@ -70,7 +72,7 @@ func CreateUser(req types.RegisterRequest) (*models.Users, error) {
 		user.Bio = *req.Bio
 	}
 
-	tx := db.DB.Begin()
+	tx := db.DB.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -93,11 +95,13 @@ func CreateUser(req types.RegisterRequest) (*models.Users, error) {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Setup default preferences in user-service (non-blocking)
+	// Setup default preferences in user-service (non-blocking), in the
+	// trace of the registration but past the end of its request
+	setupCtx := context.WithoutCancel(ctx)
 	go func() {
-		if err := CallUserSetup(user.ID, user.Age, user.SexPref); err != nil {
+		if err := CallUserSetup(setupCtx, user.ID, user.Age, user.SexPref); err != nil {
 			// Log error but don't fail the registration
-			fmt.Printf("Warning: Failed to setup user preferences for user %d: %v\n", user.ID, err)
+			slog.WarnContext(setupCtx, "failed to set up user preferences", "user_id", user.ID, "error", err)
 		}
 	}()
 
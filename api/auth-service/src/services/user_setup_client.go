@@ -2,11 +2,14 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 // UserSetupRequest represents the payload for user setup
@@ -17,7 +20,7 @@ type UserSetupRequest struct {
 }
 
 // CallUserSetup calls the user-service to setup default preferences for a new user
-func CallUserSetup(userID uint, age int, sexPref string) error {
+func CallUserSetup(ctx context.Context, userID uint, age int, sexPref string) error {
 	// Get user-service URL from environment or use default
 	userServiceURL := os.Getenv("USER_SERVICE_URL")
 	if userServiceURL == "" {
@@ -36,13 +39,11 @@ func CallUserSetup(userID uint, age int, sexPref string) error {
 	}
 
 	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	client := tracing.NewClient(10 * time.Second)
 
 	// Create request
 	url := fmt.Sprintf("%s/api/v1/users/setup", userServiceURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create setup request: %w", err)
 	}
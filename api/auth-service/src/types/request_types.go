@@ -69,4 +69,23 @@ type EmailVerificationRequest struct {
 type VerifyEmailRequest struct {
 	Email            string `json:"email" binding:"required,email"`
 	VerificationCode string `json:"verification_code" binding:"required"`
-}
\ No newline at end of file
+}
+
+// EmailChangeRequest represents an authenticated request to change the account email
+type EmailChangeRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewEmail        string `json:"new_email" binding:"required,email"`
+}
+
+// EmailChangeTokenRequest carries a confirm or revert token from an email link
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// QueueEmailRequest is sent by other services to deliver a templated email
+type QueueEmailRequest struct {
+	To       string                 `json:"to" binding:"required,email"`
+	Template string                 `json:"template" binding:"required"`
+	Locale   string                 `json:"locale"`
+	Data     map[string]interface{} `json:"data"`
+}
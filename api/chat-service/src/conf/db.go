@@ -2,10 +2,12 @@ package conf
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"chat-service/src/models"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -24,18 +26,26 @@ func ConnectDB() {
 
 	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Fatal("Failed to connect to database: ", err)
+		slog.Error("failed to connect to the database", "error", err)
+		os.Exit(1)
 	}
 
 	DB = database
-	log.Printf("Connected to database: %s@%s:%s/%s", user, host, port, dbname)
+	slog.Info("database connected", "user", user, "host", host, "port", port, "name", dbname)
+
+	if err := DB.Use(tracing.GORMPlugin{}); err != nil {
+		slog.Warn("failed to enable query tracing", "error", err)
+	}
+	if err := metrics.RegisterDB(DB, "chat"); err != nil {
+		slog.Warn("failed to expose connection pool metrics", "error", err)
+	}
 
 	AutoMigrate()
 }
 
 func AutoMigrate() {
 	if getEnv("AUTO_MIGRATE", "true") == "true" {
-		log.Println("Running database auto-migration...")
+		slog.Info("running AutoMigrate (AUTO_MIGRATE=true)")
 
 		// Migrate chat service tables
 		err := DB.AutoMigrate(
@@ -43,9 +53,14 @@ func AutoMigrate() {
 			&models.Discussion{},
 			&models.MessageReaction{},
 			&models.UserPresence{},
+			&models.MessageEdit{},
+			&models.MessageDeletion{},
+			&models.MessageAttachment{},
+			&models.ChatSettings{},
+			&models.ConversationSettings{},
 		)
 		if err != nil {
-			log.Printf("Migration error: %v", err)
+			slog.Warn("AutoMigrate failed", "error", err)
 		}
 
 		// Create unique constraint for message reactions (one reaction per user per message per emoji)
@@ -56,7 +71,13 @@ func AutoMigrate() {
 		DB.Exec(`CREATE INDEX IF NOT EXISTS idx_user_presence_activity
 				  ON user_presence (is_online, last_activity)`)
 
-		log.Println("Chat service migration completed")
+		// Full-text search on messages, in French and English
+		DB.Exec(`ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+				  GENERATED ALWAYS AS (to_tsvector('french', msg) || to_tsvector('english', msg)) STORED`)
+		DB.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_search
+				  ON messages USING GIN (search_vector)`)
+
+		slog.Info("AutoMigrate completed")
 	}
 }
 
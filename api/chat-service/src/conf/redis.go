@@ -2,9 +2,11 @@ package conf
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"os"
 
-	"github.com/go-redis/redis/v8"
+	"github.com/maxg56/matcha/api/common/tracing"
+	"github.com/redis/go-redis/v9"
 )
 
 var RedisClient *redis.Client
@@ -22,9 +24,11 @@ func ConnectRedis() {
 
 	_, err := rdb.Ping(Ctx).Result()
 	if err != nil {
-		log.Fatal("Failed to connect to Redis: ", err)
+		slog.Error("failed to connect to redis", "error", err)
+		os.Exit(1)
 	}
 
+	tracing.InstrumentRedis(rdb)
 	RedisClient = rdb
-	log.Printf("Connected to Redis: %s:%s", host, port)
+	slog.Info("redis connected", "host", host, "port", port)
 }
\ No newline at end of file
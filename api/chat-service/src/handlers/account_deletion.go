@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"chat-service/src/conf"
+	"chat-service/src/models"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeleteUserDataHandler removes the chat data of a deleted account: its conversations
+// with every message, edit, attachment link and reaction they hold, the reactions it added elsewhere, its
+// settings and its presence row. Idempotent, so the deletion saga of user-service can safely retry it.
+func DeleteUserDataHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	deleted := map[string]int64{}
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		convIDs := tx.Model(&models.Discussion{}).
+			Select("id").
+			Where("user1_id = ? OR user2_id = ?", userID, userID)
+		messageIDs := tx.Model(&models.Message{}).
+			Select("id").
+			Where("conv_id IN (?)", convIDs)
+
+		result := tx.Where("user_id = ? OR message_id IN (?)", userID, messageIDs).Delete(&models.MessageReaction{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted["reactions"] = result.RowsAffected
+
+		if err := tx.Where("message_id IN (?)", messageIDs).Delete(&models.MessageAttachment{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Where("message_id IN (?)", messageIDs).Delete(&models.MessageEdit{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted["message_edits"] = result.RowsAffected
+
+		if err := tx.Where("user_id = ? OR message_id IN (?)", userID, messageIDs).Delete(&models.MessageDeletion{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Where("conv_id IN (?)", convIDs).Delete(&models.Message{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted["messages"] = result.RowsAffected
+
+		if err := tx.Where("user_id = ? OR conversation_id IN (?)", userID, convIDs).Delete(&models.ConversationSettings{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Where("user1_id = ? OR user2_id = ?", userID, userID).Delete(&models.Discussion{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted["conversations"] = result.RowsAffected
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.ChatSettings{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserPresence{}).Error
+	})
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to delete user data")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"user_id": userID,
+		"deleted": deleted,
+	})
+}
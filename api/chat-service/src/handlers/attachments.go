@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"chat-service/src/middleware"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAttachment returns an attachment with fresh signed URLs, for clients
+// whose URLs from the message list expired
+func (h *ChatHandlers) GetAttachment(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentID"), 10, 32)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	attachment, err := h.chatService.GetAttachment(userID, uint(attachmentID))
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, attachment)
+}
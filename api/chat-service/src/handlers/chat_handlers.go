@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -22,15 +23,17 @@ func NewChatHandlers(chatService types.ChatService) *ChatHandlers {
 	}
 }
 
-// GetUserConversations lists all conversations for a user
+// GetUserConversations lists all conversations for a user, pinned first;
+// ?archived=true lists the archived ones instead
 func (h *ChatHandlers) GetUserConversations(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
 		return
 	}
+	archived := c.Query("archived") == "true"
 
-	conversationsResponse, err := h.chatService.GetUserConversations(userID)
+	conversationsResponse, err := h.chatService.GetUserConversations(userID, archived)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to retrieve conversations")
 		return
@@ -80,9 +83,9 @@ func (h *ChatHandlers) CreateConversation(c *gin.Context) {
 		return
 	}
 
-	conversation, err := h.chatService.CreateConversation(userID, req.UserID)
+	conversation, err := h.chatService.CreateConversation(c.Request.Context(), userID, req.UserID)
 	if err != nil {
-		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		respondChatError(c, err)
 		return
 	}
 
@@ -103,11 +106,24 @@ func (h *ChatHandlers) GetMessages(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	// Parse pagination: before_id/after_id cursors, or limit/offset
+	query := types.MessageQuery{}
+	query.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	query.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if query.BeforeID, err = parseCursorID(c.Query("before_id")); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid before_id")
+		return
+	}
+	if query.AfterID, err = parseCursorID(c.Query("after_id")); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid after_id")
+		return
+	}
+	if query.BeforeID > 0 && query.AfterID > 0 {
+		utils.RespondError(c, http.StatusBadRequest, "before_id and after_id cannot be combined")
+		return
+	}
 
-	messages, err := h.chatService.GetMessages(userID, conversationID, limit, offset)
+	messages, err := h.chatService.GetMessages(userID, conversationID, query)
 	if err != nil {
 		if err.Error() == "access denied" {
 			utils.RespondError(c, http.StatusForbidden, "Access denied")
@@ -134,13 +150,13 @@ func (h *ChatHandlers) SendMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := h.chatService.SendMessage(userID, req.ConversationID, req.Message)
+	message, err := h.chatService.SendMessageWithAttachments(c.Request.Context(), userID, req.ConversationID, req.Message, req.AttachmentIDs)
 	if err != nil {
 		if err.Error() == "access denied" {
 			utils.RespondError(c, http.StatusForbidden, "Access denied")
 			return
 		}
-		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		respondChatError(c, err)
 		return
 	}
 
@@ -161,7 +177,16 @@ func (h *ChatHandlers) MarkMessagesAsRead(c *gin.Context) {
 		return
 	}
 
-	err = h.chatService.MarkMessagesAsRead(userID, conversationID)
+	// Optional body: read up to message_id instead of the whole conversation
+	var req types.ReadRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+			return
+		}
+	}
+
+	receipt, err := h.chatService.MarkMessagesAsRead(userID, conversationID, req.MessageID)
 	if err != nil {
 		if err.Error() == "access denied" {
 			utils.RespondError(c, http.StatusForbidden, "Access denied")
@@ -170,6 +195,9 @@ func (h *ChatHandlers) MarkMessagesAsRead(c *gin.Context) {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to mark messages as read")
 		return
 	}
+	if globalHub != nil {
+		globalHub.BroadcastReceipt(receipt)
+	}
 
 	utils.RespondSuccess(c, http.StatusOK, gin.H{"message": "Messages marked as read"})
 }
@@ -341,4 +369,28 @@ func (h *ChatHandlers) parseConversationID(c *gin.Context) (uint, error) {
 	conversationIDStr := c.Param("conversationID")
 	conversationID, err := strconv.ParseUint(conversationIDStr, 10, 32)
 	return uint(conversationID), err
-}
\ No newline at end of file
+}
+// respondChatError maps chat service errors to their status code
+func respondChatError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "access denied":
+		utils.RespondError(c, http.StatusForbidden, "Access denied")
+	case errors.Is(err, types.ErrNotMatched), errors.Is(err, types.ErrConversationClosed),
+		errors.Is(err, types.ErrNotMessageAuthor), errors.Is(err, types.ErrEditWindowExpired):
+		utils.RespondError(c, http.StatusForbidden, err.Error())
+	case err.Error() == "conversation not found", errors.Is(err, types.ErrMessageNotFound), errors.Is(err, types.ErrAttachmentNotFound):
+		utils.RespondError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, types.ErrMessageDeleted):
+		utils.RespondError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, types.ErrAttachmentUnavailable), errors.Is(err, types.ErrPinArchived), errors.Is(err, types.ErrTooManyPinned):
+		utils.RespondError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, types.ErrMessageBlocked):
+		utils.RespondError(c, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, types.ErrMatchCheckUnavailable):
+		utils.RespondError(c, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, types.ErrMediaUnavailable):
+		utils.RespondError(c, http.StatusServiceUnavailable, types.ErrMediaUnavailable.Error())
+	default:
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"chat-service/src/middleware"
+	"chat-service/src/types"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetConversationSettings returns the settings of the user for a conversation
+func (h *ChatHandlers) GetConversationSettings(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	conversationID, err := h.parseConversationID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid conversation ID")
+		return
+	}
+
+	settings, err := h.chatService.GetConversationSettings(userID, conversationID)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, settings)
+}
+
+// UpdateConversationSettings mutes, pins or archives a conversation for the
+// user and syncs the change to their other devices
+func (h *ChatHandlers) UpdateConversationSettings(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	conversationID, err := h.parseConversationID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid conversation ID")
+		return
+	}
+
+	var req types.ConversationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	settings, err := h.chatService.UpdateConversationSettings(userID, conversationID, req)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+	if globalHub != nil {
+		globalHub.BroadcastConversationSettings(userID, settings)
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, settings)
+}
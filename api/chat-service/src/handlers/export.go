@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"chat-service/src/conf"
+	"chat-service/src/models"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportedMessage is a message of one of the user's conversations
+type exportedMessage struct {
+	ID          uint       `json:"id"`
+	ConvID      uint       `json:"conv_id"`
+	SenderID    uint       `json:"sender_id"`
+	Msg         string     `json:"msg"`
+	Time        time.Time  `json:"time"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	ReadAt      *time.Time `json:"read_at"`
+	EditedAt    *time.Time `json:"edited_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ExportUserData returns the chat-service section of a GDPR export: the
+// user's conversations with their messages, and the reactions the user added.
+// The read time of the user's messages is left out where the app hides it.
+// Internal route, called by user-service.
+func (h *ChatHandlers) ExportUserData(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var conversations []models.Discussion
+	if err := conf.DB.Where("user1_id = ? OR user2_id = ?", userID, userID).
+		Order("created_at").
+		Find(&conversations).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load conversations")
+		return
+	}
+
+	convIDs := make([]uint, 0, len(conversations))
+	receiptsVisible := make(map[uint]bool, len(conversations))
+	for _, conv := range conversations {
+		convIDs = append(convIDs, conv.ID)
+		other := conv.User1ID
+		if other == uint(userID) {
+			other = conv.User2ID
+		}
+		receiptsVisible[conv.ID] = h.chatService.ReadReceiptsVisible(uint(userID), other)
+	}
+
+	messages := []exportedMessage{}
+	if len(convIDs) > 0 {
+		if err := conf.DB.Model(&models.Message{}).
+			Where("conv_id IN ?", convIDs).
+			Order("conv_id, time").
+			Find(&messages).Error; err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, "Failed to load messages")
+			return
+		}
+	}
+	for i := range messages {
+		if messages[i].SenderID == uint(userID) && !receiptsVisible[messages[i].ConvID] {
+			messages[i].ReadAt = nil
+		}
+	}
+
+	var reactions []models.MessageReaction
+	if err := conf.DB.Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&reactions).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load reactions")
+		return
+	}
+
+	settings := models.DefaultChatSettings(uint(userID))
+	if err := conf.DB.Where("user_id = ?", userID).Limit(1).Find(settings).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load settings")
+		return
+	}
+
+	conversationSettings := []models.ConversationSettings{}
+	if err := conf.DB.Where("user_id = ?", userID).
+		Order("conversation_id").
+		Find(&conversationSettings).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load conversation settings")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"conversations":         conversations,
+		"messages":              messages,
+		"reactions":             reactions,
+		"settings":              settings,
+		"conversation_settings": conversationSettings,
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"chat-service/src/models"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// matchConversationRequest identifies the conversation of a match
+type matchConversationRequest struct {
+	User1ID uint   `json:"user1_id" binding:"required,min=1"`
+	User2ID uint   `json:"user2_id" binding:"required,min=1"`
+	Reason  string `json:"reason"`
+}
+
+// OpenMatchConversation creates (or reopens) the conversation of a new match.
+// Called by match-service when two users match.
+func (h *ChatHandlers) OpenMatchConversation(c *gin.Context) {
+	var req matchConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	conversation, err := h.chatService.OpenMatchConversation(req.User1ID, req.User2ID)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"conversation": conversation})
+}
+
+// CloseMatchConversation makes the conversation of an ended match read-only
+// (reason "unmatched") or archives it (reason "blocked").
+// Called by match-service on unmatch and block; idempotent.
+func (h *ChatHandlers) CloseMatchConversation(c *gin.Context) {
+	var req matchConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+	if req.Reason != models.CloseReasonUnmatched && req.Reason != models.CloseReasonBlocked {
+		utils.RespondError(c, http.StatusBadRequest, "reason must be 'unmatched' or 'blocked'")
+		return
+	}
+
+	conversation, err := h.chatService.CloseMatchConversation(req.User1ID, req.User2ID, req.Reason)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to close conversation")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"conversation": conversation})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"chat-service/src/middleware"
+	"chat-service/src/models"
+	"chat-service/src/types"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EditMessage replaces the content of a message within the edit window
+func (h *ChatHandlers) EditMessage(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	var req types.EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	message, err := h.chatService.EditMessage(c.Request.Context(), userID, messageID, req.Message)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	if globalHub != nil {
+		globalHub.BroadcastMessageEdited(message)
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, message)
+}
+
+// DeleteMessage deletes a message for the user (?scope=me, default) or
+// unsends it for both participants (?scope=everyone)
+func (h *ChatHandlers) DeleteMessage(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	scope := c.DefaultQuery("scope", models.DeleteForMe)
+	message, err := h.chatService.DeleteMessage(c.Request.Context(), userID, messageID, scope)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	if globalHub != nil {
+		globalHub.BroadcastMessageDeleted(message, userID, scope)
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message_id": message.ID,
+		"scope":      scope,
+	})
+}
+
+// GetMessageEdits returns the previous versions of a message
+func (h *ChatHandlers) GetMessageEdits(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	edits, err := h.chatService.GetMessageEdits(userID, messageID)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, edits)
+}
+
+func parseMessageID(c *gin.Context) (uint, error) {
+	messageID, err := strconv.ParseUint(c.Param("messageID"), 10, 32)
+	return uint(messageID), err
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"chat-service/src/middleware"
+	"chat-service/src/types"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncConversation returns what changed in a conversation since a message id
+// or an RFC 3339 time, for clients catching up after a reconnection
+func (h *ChatHandlers) SyncConversation(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	conversationID, err := h.parseConversationID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid conversation ID")
+		return
+	}
+
+	query, err := parseSyncCursor(c.Query("since"))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, types.ErrInvalidSyncCursor.Error())
+		return
+	}
+	query.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	sync, err := h.chatService.SyncConversation(userID, conversationID, query)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, sync)
+}
+
+// parseSyncCursor reads since as a message id, or else as a time
+func parseSyncCursor(since string) (types.MessageQuery, error) {
+	if id, err := strconv.ParseUint(since, 10, 32); err == nil && id > 0 {
+		return types.MessageQuery{AfterID: uint(id)}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, since)
+	if err != nil {
+		return types.MessageQuery{}, err
+	}
+	return types.MessageQuery{Since: t}, nil
+}
+
+// parseCursorID reads an optional message id used as a pagination cursor
+func parseCursorID(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	return uint(id), err
+}
@@ -32,7 +32,7 @@ func GetConnectionStats(c *gin.Context) {
 	}
 
 	stats := ConnectionStats{
-		ActiveConnections: len(globalHub.GetConnectedUsers()),
+		ActiveConnections: globalHub.ConnectionCount(),
 		ConnectedUsers:    globalHub.GetConnectedUsers(),
 		TotalMessages:     totalMessagesSent,
 		Uptime:           time.Since(serviceStartTime),
@@ -58,7 +58,7 @@ func GetDetailedStats(c *gin.Context) {
 	
 	detailedStats := gin.H{
 		"websocket": gin.H{
-			"active_connections": len(connectedUsers),
+			"active_connections": globalHub.ConnectionCount(),
 			"connected_users":    connectedUsers,
 			"hub_status":        "running",
 		},
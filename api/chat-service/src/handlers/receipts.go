@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"chat-service/src/middleware"
+	"chat-service/src/types"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarkMessagesDelivered acknowledges the receipt of messages, for clients
+// that fetched them over HTTP
+func (h *ChatHandlers) MarkMessagesDelivered(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	conversationID, err := h.parseConversationID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid conversation ID")
+		return
+	}
+
+	var req types.DeliveredRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	receipt, err := h.chatService.MarkMessagesDelivered(userID, conversationID, req.MessageIDs)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+	if globalHub != nil {
+		globalHub.BroadcastReceipt(receipt)
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"message": "Messages marked as delivered"})
+}
+
+// GetChatSettings returns the chat settings of the user
+func (h *ChatHandlers) GetChatSettings(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	settings, err := h.chatService.GetChatSettings(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to retrieve settings")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, settings)
+}
+
+// UpdateChatSettings changes the chat settings of the user
+func (h *ChatHandlers) UpdateChatSettings(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	var req types.ChatSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	settings, err := h.chatService.UpdateChatSettings(userID, req)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to update settings")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, settings)
+}
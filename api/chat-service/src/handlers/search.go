@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"chat-service/src/middleware"
+	"chat-service/src/types"
+	"chat-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchMessages searches the messages of the user's conversations
+func (h *ChatHandlers) SearchMessages(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		utils.RespondError(c, http.StatusUnauthorized, "Invalid user ID")
+		return
+	}
+
+	query := types.SearchQuery{Text: c.Query("q")}
+	query.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if query.BeforeID, err = parseCursorID(c.Query("before_id")); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid before_id")
+		return
+	}
+	if query.ConversationID, err = parseCursorID(c.Query("conversation_id")); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid conversation ID")
+		return
+	}
+
+	results, err := h.chatService.SearchMessages(userID, query)
+	if err != nil {
+		if errors.Is(err, types.ErrInvalidSearch) {
+			utils.RespondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondChatError(c, err)
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, results)
+}
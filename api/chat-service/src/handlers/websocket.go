@@ -3,7 +3,7 @@ package handlers
 import (
 	"chat-service/src/middleware"
 	wsocket "chat-service/src/websocket"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -53,7 +53,7 @@ var upgrader = websocket.Upgrader{
 			}
 		}
 		
-		log.Printf("WebSocket connection rejected from origin: %s", origin)
+		slog.WarnContext(r.Context(), "websocket connection rejected", "origin", origin)
 		return false
 	},
 	ReadBufferSize:  1024,
@@ -122,8 +122,8 @@ func HandleGatewayWebSocket(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ Gateway WebSocket connection established")
+	slog.InfoContext(c.Request.Context(), "gateway websocket connection established")
 
 	// Handle Gateway WebSocket communication
-	globalHub.HandleGatewayConnection(conn)
+	globalHub.HandleGatewayConnection(c.Request.Context(), conn)
 }
\ No newline at end of file
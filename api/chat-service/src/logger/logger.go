@@ -1,30 +1,18 @@
+// Package logger provides the contextual logging helpers of the chat service.
+// Records go through the slog logger installed by logging.Init, the fields of
+// LogContext are added as attributes, and the request ID and trace ID of the
+// context given to WithRequest.
 package logger
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"runtime"
-	"strings"
+	"sort"
 	"time"
 )
 
-// LogLevel represents the severity of a log message
-type LogLevel int
-
-const (
-	DEBUG LogLevel = iota
-	INFO
-	WARN
-	ERROR
-	FATAL
-)
-
-func (l LogLevel) String() string {
-	return [...]string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}[l]
-}
-
 // LogContext holds contextual information for logs
 type LogContext struct {
 	UserID         *uint              `json:"user_id,omitempty"`
@@ -34,142 +22,56 @@ type LogContext struct {
 	Component      string             `json:"component,omitempty"`
 	Duration       *time.Duration     `json:"duration,omitempty"`
 	Extra          map[string]any     `json:"extra,omitempty"`
+	request        context.Context
 }
 
-// Logger is the centralized logger for the chat service
-type Logger struct {
-	level      LogLevel
-	jsonFormat bool
-	service    string
-}
-
-var defaultLogger *Logger
-
-func init() {
-	defaultLogger = &Logger{
-		level:      INFO,
-		jsonFormat: os.Getenv("LOG_FORMAT") == "json",
-		service:    "chat-service",
+func log(level slog.Level, msg string, ctx *LogContext, args ...any) {
+	request := context.Background()
+	if ctx != nil && ctx.request != nil {
+		request = ctx.request
 	}
-
-	// Set log level from environment
-	if lvl := os.Getenv("LOG_LEVEL"); lvl != "" {
-		switch strings.ToUpper(lvl) {
-		case "DEBUG":
-			defaultLogger.level = DEBUG
-		case "INFO":
-			defaultLogger.level = INFO
-		case "WARN":
-			defaultLogger.level = WARN
-		case "ERROR":
-			defaultLogger.level = ERROR
-		case "FATAL":
-			defaultLogger.level = FATAL
-		}
+	logger := slog.Default()
+	if !logger.Enabled(request, level) {
+		return
 	}
+	logger.LogAttrs(request, level, fmt.Sprintf(msg, args...), ctx.attrs()...)
 }
 
-// GetLogger returns the default logger instance
-func GetLogger() *Logger {
-	return defaultLogger
-}
-
-func (l *Logger) log(level LogLevel, msg string, ctx *LogContext, args ...any) {
-	if level < l.level {
-		return
+// attrs returns the fields of ctx as slog attributes
+func (ctx *LogContext) attrs() []slog.Attr {
+	if ctx == nil {
+		return nil
 	}
 
-	timestamp := time.Now().UTC()
-
-	if l.jsonFormat {
-		l.logJSON(level, timestamp, msg, ctx, args...)
-	} else {
-		l.logText(level, timestamp, msg, ctx, args...)
+	var attrs []slog.Attr
+	if ctx.Component != "" {
+		attrs = append(attrs, slog.String("component", ctx.Component))
 	}
-}
-
-func (l *Logger) logJSON(level LogLevel, timestamp time.Time, msg string, ctx *LogContext, args ...any) {
-	logEntry := map[string]any{
-		"timestamp": timestamp.Format(time.RFC3339),
-		"level":     level.String(),
-		"service":   l.service,
-		"message":   fmt.Sprintf(msg, args...),
+	if ctx.UserID != nil {
+		attrs = append(attrs, slog.Any("user_id", *ctx.UserID))
 	}
-
-	// Add caller info for ERROR and FATAL
-	if level >= ERROR {
-		if _, file, line, ok := runtime.Caller(3); ok {
-			logEntry["file"] = fmt.Sprintf("%s:%d", file, line)
-		}
+	if ctx.ConversationID != nil {
+		attrs = append(attrs, slog.Any("conversation_id", *ctx.ConversationID))
 	}
-
-	// Add context if provided
-	if ctx != nil {
-		if ctx.UserID != nil {
-			logEntry["user_id"] = *ctx.UserID
-		}
-		if ctx.ConversationID != nil {
-			logEntry["conversation_id"] = *ctx.ConversationID
-		}
-		if ctx.MessageID != nil {
-			logEntry["message_id"] = *ctx.MessageID
-		}
-		if ctx.Action != "" {
-			logEntry["action"] = ctx.Action
-		}
-		if ctx.Component != "" {
-			logEntry["component"] = ctx.Component
-		}
-		if ctx.Duration != nil {
-			logEntry["duration_ms"] = float64(ctx.Duration.Nanoseconds()) / 1e6
-		}
-		if ctx.Extra != nil {
-			for k, v := range ctx.Extra {
-				logEntry[k] = v
-			}
-		}
+	if ctx.MessageID != nil {
+		attrs = append(attrs, slog.Any("message_id", *ctx.MessageID))
 	}
-
-	jsonData, _ := json.Marshal(logEntry)
-	log.Println(string(jsonData))
-}
-
-func (l *Logger) logText(level LogLevel, timestamp time.Time, msg string, ctx *LogContext, args ...any) {
-	formattedMsg := fmt.Sprintf(msg, args...)
-
-	var contextParts []string
-	if ctx != nil {
-		if ctx.Component != "" {
-			contextParts = append(contextParts, fmt.Sprintf("[%s]", ctx.Component))
-		}
-		if ctx.UserID != nil {
-			contextParts = append(contextParts, fmt.Sprintf("[U:%d]", *ctx.UserID))
-		}
-		if ctx.ConversationID != nil {
-			contextParts = append(contextParts, fmt.Sprintf("[C:%d]", *ctx.ConversationID))
-		}
-		if ctx.Action != "" {
-			contextParts = append(contextParts, fmt.Sprintf("[%s]", ctx.Action))
-		}
-		if ctx.Duration != nil {
-			contextParts = append(contextParts, fmt.Sprintf("[%.2fms]", float64(ctx.Duration.Nanoseconds())/1e6))
-		}
+	if ctx.Action != "" {
+		attrs = append(attrs, slog.String("action", ctx.Action))
 	}
-
-	contextStr := ""
-	if len(contextParts) > 0 {
-		contextStr = " " + strings.Join(contextParts, " ")
+	if ctx.Duration != nil {
+		attrs = append(attrs, slog.Float64("duration_ms", float64(ctx.Duration.Nanoseconds())/1e6))
 	}
 
-	levelEmoji := map[LogLevel]string{
-		DEBUG: "🐛",
-		INFO:  "ℹ️",
-		WARN:  "⚠️",
-		ERROR: "❌",
-		FATAL: "💀",
+	keys := make([]string, 0, len(ctx.Extra))
+	for k := range ctx.Extra {
+		keys = append(keys, k)
 	}
-
-	log.Printf("%s [%s]%s %s", levelEmoji[level], level.String(), contextStr, formattedMsg)
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, ctx.Extra[k]))
+	}
+	return attrs
 }
 
 // Context helper functions
@@ -201,6 +103,11 @@ func WithExtra(key string, value any) *LogContext {
 	return &LogContext{Extra: map[string]any{key: value}}
 }
 
+// WithRequest logs with the request ID and trace ID of ctx
+func WithRequest(ctx context.Context) *LogContext {
+	return &LogContext{request: ctx}
+}
+
 // Chain context builders
 func (ctx *LogContext) WithUser(userID uint) *LogContext {
 	ctx.UserID = &userID
@@ -240,45 +147,50 @@ func (ctx *LogContext) WithExtra(key string, value any) *LogContext {
 	return ctx
 }
 
+func (ctx *LogContext) WithRequest(request context.Context) *LogContext {
+	ctx.request = request
+	return ctx
+}
+
 // Public logging functions
 func Debug(msg string, args ...any) {
-	defaultLogger.log(DEBUG, msg, nil, args...)
+	log(slog.LevelDebug, msg, nil, args...)
 }
 
 func DebugWithContext(ctx *LogContext, msg string, args ...any) {
-	defaultLogger.log(DEBUG, msg, ctx, args...)
+	log(slog.LevelDebug, msg, ctx, args...)
 }
 
 func Info(msg string, args ...any) {
-	defaultLogger.log(INFO, msg, nil, args...)
+	log(slog.LevelInfo, msg, nil, args...)
 }
 
 func InfoWithContext(ctx *LogContext, msg string, args ...any) {
-	defaultLogger.log(INFO, msg, ctx, args...)
+	log(slog.LevelInfo, msg, ctx, args...)
 }
 
 func Warn(msg string, args ...any) {
-	defaultLogger.log(WARN, msg, nil, args...)
+	log(slog.LevelWarn, msg, nil, args...)
 }
 
 func WarnWithContext(ctx *LogContext, msg string, args ...any) {
-	defaultLogger.log(WARN, msg, ctx, args...)
+	log(slog.LevelWarn, msg, ctx, args...)
 }
 
 func Error(msg string, args ...any) {
-	defaultLogger.log(ERROR, msg, nil, args...)
+	log(slog.LevelError, msg, nil, args...)
 }
 
 func ErrorWithContext(ctx *LogContext, msg string, args ...any) {
-	defaultLogger.log(ERROR, msg, ctx, args...)
+	log(slog.LevelError, msg, ctx, args...)
 }
 
 func Fatal(msg string, args ...any) {
-	defaultLogger.log(FATAL, msg, nil, args...)
+	log(slog.LevelError, msg, nil, args...)
 	os.Exit(1)
 }
 
 func FatalWithContext(ctx *LogContext, msg string, args ...any) {
-	defaultLogger.log(FATAL, msg, ctx, args...)
+	log(slog.LevelError, msg, ctx, args...)
 	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/maxg56/matcha/api/common/logging"
+)
+
+func TestWithRequest_AddsTheRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New("chat-service", logging.Options{Level: slog.LevelInfo, Format: logging.FormatJSON, Output: &buf}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	ctx := logging.WithRequest(context.Background(), "req-1", "/api/v1/chat/messages")
+	InfoWithContext(WithComponent("chat_service").WithUser(7).WithRequest(ctx), "Message %s", "sent")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "Message sent" || record["component"] != "chat_service" || record["user_id"] != float64(7) {
+		t.Errorf("unexpected record %v", record)
+	}
+	if record["request_id"] != "req-1" {
+		t.Errorf("request_id = %v, want req-1", record["request_id"])
+	}
+}
@@ -1,38 +1,78 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"log/slog"
+	"os"
 
 	"chat-service/src/conf"
 	"chat-service/src/handlers"
 	"chat-service/src/middleware"
+	"chat-service/src/pubsub"
 	"chat-service/src/repository"
+	"chat-service/src/screening"
 	"chat-service/src/services"
 	"chat-service/src/websocket"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 func main() {
-	log.Println("🚀 Initializing Chat Service...")
+	logging.Init("chat-service")
+
+	// Initialize tracing (OTEL_TRACES_EXPORTER)
+	shutdownTracing, err := tracing.Init("chat-service")
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	lc := server.New("chat-service")
 
 	// Initialize database and Redis
 	conf.ConnectDB()
 	conf.ConnectRedis()
+	lc.AddCheck("database", server.DBCheck(conf.DB))
+	lc.AddCheck("redis", func(ctx context.Context) error { return conf.RedisClient.Ping(ctx).Err() })
+	lc.OnClose("database", server.CloseDB(conf.DB))
+	lc.OnClose("redis", func(ctx context.Context) error { return conf.RedisClient.Close() })
 
 	// Initialize dependencies with interfaces
 	chatRepo := repository.NewChatRepository(conf.DB)
 	
-	// Initialize WebSocket hub first
+	// Initialize WebSocket hub first. At shutdown, its clients and the
+	// gateways are told to reconnect to another replica.
 	hub := websocket.NewHub(nil, chatRepo) // Will set chatService after creation
-	go hub.Run()
+	lc.Go("websocket-hub", hub.Run)
+	lc.OnDrain("websocket", hub.Drain)
+	metrics.WebSocketConnections(hub.ConnectionCount)
+	metrics.GaugeFunc("chat_hub_broadcast_queue_depth", "Broadcasts waiting for the WebSocket hub loop.", func() float64 {
+		return float64(hub.BroadcastQueueDepth())
+	})
+
+	// Screen messages for abuse, contact details and spam before they are saved
+	screener, err := screening.NewPipelineFromEnv(screening.NewRedisSpamStore(conf.RedisClient))
+	if err != nil {
+		slog.Error("failed to load screening word lists", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize chat service with hub as connection manager
-	chatService := services.NewChatService(chatRepo, hub)
+	chatService := services.NewChatService(chatRepo, hub, services.NewMatchClient(), services.NewMediaClient(), screener, services.NewModerationClient())
 	
 	// Update hub with chat service
 	hub.SetChatService(chatService)
+
+	// Share events with the other replicas and the gateway through Redis
+	hub.SetRelay(pubsub.NewBus(conf.RedisClient))
+	hub.SetPresence(pubsub.NewPresence(conf.RedisClient))
+	lc.Go("event-subscriber", pubsub.NewSubscriber(conf.RedisClient, hub.HandleEvent).Run)
 	
 	// Initialize handlers
 	chatHandlers := handlers.NewChatHandlers(chatService)
@@ -41,15 +81,31 @@ func main() {
 	handlers.SetGlobalHub(hub)
 
 	// Setup Gin router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), logging.Middleware())
+	r.Use(tracing.Middleware("chat-service"))
+	metrics.Register(r)
 
-	// Health check
+	// Health check, liveness and readiness probes
 	r.GET("/health", handlers.HealthCheck)
+	lc.RegisterProbes(r)
 
 	// Monitoring routes
 	r.GET("/stats", handlers.GetConnectionStats)
 	r.GET("/stats/detailed", handlers.GetDetailedStats)
 
+	// Internal routes (called by other services)
+	internal := r.Group("/api/v1/internal")
+	internal.Use(internalapi.Middleware())
+	{
+		internal.GET("/users/:user_id/export", chatHandlers.ExportUserData)
+		internal.DELETE("/users/:user_id", handlers.DeleteUserDataHandler)
+
+		// Conversation lifecycle driven by match-service
+		internal.POST("/conversations", chatHandlers.OpenMatchConversation)
+		internal.POST("/conversations/close", chatHandlers.CloseMatchConversation)
+	}
+
 	// Chat API routes
 	chat := r.Group("/api/v1/chat")
 
@@ -69,8 +125,17 @@ func main() {
 
 		// Message endpoints
 		chat.GET("/conversations/:conversationID/messages", chatHandlers.GetMessages)
+		chat.GET("/conversations/:conversationID/sync", chatHandlers.SyncConversation)
 		chat.POST("/messages", chatHandlers.SendMessage)
 		chat.PUT("/conversations/:conversationID/read", chatHandlers.MarkMessagesAsRead)
+		chat.PUT("/conversations/:conversationID/delivered", chatHandlers.MarkMessagesDelivered)
+		chat.GET("/conversations/:conversationID/settings", chatHandlers.GetConversationSettings)
+		chat.PUT("/conversations/:conversationID/settings", chatHandlers.UpdateConversationSettings)
+		chat.PUT("/messages/:messageID", chatHandlers.EditMessage)
+		chat.DELETE("/messages/:messageID", chatHandlers.DeleteMessage)
+		chat.GET("/messages/:messageID/edits", chatHandlers.GetMessageEdits)
+		chat.GET("/attachments/:attachmentID", chatHandlers.GetAttachment)
+		chat.GET("/search", chatHandlers.SearchMessages)
 
 		// Reaction endpoints
 		chat.POST("/reactions", chatHandlers.AddReaction)
@@ -81,8 +146,12 @@ func main() {
 		chat.PUT("/presence/online", chatHandlers.SetUserOnline)
 		chat.PUT("/presence/offline", chatHandlers.SetUserOffline)
 		chat.GET("/users/:userID/presence", chatHandlers.GetUserPresence)
+
+		// Settings endpoints
+		chat.GET("/settings", chatHandlers.GetChatSettings)
+		chat.PUT("/settings", chatHandlers.UpdateChatSettings)
 	}
 
-	log.Println("✅ Chat service starting on port 8004")
-	log.Fatal(http.ListenAndServe(":8004", r))
+	slog.Info("chat service starting", "port", 8004)
+	lc.Run(":8004", r)
 }
\ No newline at end of file
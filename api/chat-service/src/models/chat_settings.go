@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ChatSettings holds the chat preferences of a user. Users without a row use
+// DefaultChatSettings.
+type ChatSettings struct {
+	UserID       uint      `gorm:"primaryKey;column:user_id" json:"-"`
+	ReadReceipts bool      `gorm:"column:read_receipts;not null;default:true" json:"read_receipts"` // let senders see when their messages are read
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (ChatSettings) TableName() string {
+	return "chat_settings"
+}
+
+// DefaultChatSettings returns the settings of a user who never changed them
+func DefaultChatSettings(userID uint) *ChatSettings {
+	return &ChatSettings{UserID: userID, ReadReceipts: true}
+}
+
+// ConversationSettings holds the preferences of one participant for a
+// conversation. Participants without a row have none of them set.
+type ConversationSettings struct {
+	ConversationID uint       `gorm:"primaryKey;column:conversation_id" json:"conversation_id"`
+	UserID         uint       `gorm:"primaryKey;column:user_id;index" json:"-"`
+	MutedUntil     *time.Time `gorm:"column:muted_until" json:"muted_until"` // no push notifications until then
+	Pinned         bool       `gorm:"column:pinned;not null;default:false" json:"pinned"`
+	Archived       bool       `gorm:"column:archived;not null;default:false" json:"archived"` // listed apart from the other conversations
+	UpdatedAt      time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (ConversationSettings) TableName() string {
+	return "conversation_settings"
+}
+
+// IsMuted reports whether the conversation is muted at the given time
+func (s *ConversationSettings) IsMuted(at time.Time) bool {
+	return s.MutedUntil != nil && s.MutedUntil.After(at)
+}
+
+// MaxPinnedConversations is how many conversations a user can pin
+const MaxPinnedConversations = 3
+
+// Receipt statuses of a message, reported to its sender
+const (
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+)
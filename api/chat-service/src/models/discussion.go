@@ -2,16 +2,47 @@ package models
 
 import "time"
 
+// Conversation statuses, driven by the state of the match between the two users
+const (
+	ConversationActive   = "active"    // matched: both users can write
+	ConversationReadOnly = "read_only" // unmatched: history is kept, nobody can write
+	ConversationArchived = "archived"  // blocked: hidden from the conversation list
+)
+
+// Reasons a match-backed conversation is closed
+const (
+	CloseReasonUnmatched = "unmatched"
+	CloseReasonBlocked   = "blocked"
+)
+
 type Discussion struct {
 	ID                 uint       `gorm:"primaryKey;column:id" json:"id"`
 	User1ID            uint       `gorm:"column:user1_id;not null" json:"user1_id"`
 	User2ID            uint       `gorm:"column:user2_id;not null" json:"user2_id"`
 	LastMessageContent string     `gorm:"column:last_message_content" json:"last_message_content"`
 	LastMessageAt      *time.Time `gorm:"column:last_message_at" json:"last_message_at"`
+	Status             string     `gorm:"column:status;default:active" json:"status"`
+	ClosedReason       string     `gorm:"column:closed_reason" json:"closed_reason,omitempty"`
+	ClosedAt           *time.Time `gorm:"column:closed_at" json:"closed_at,omitempty"`
+	MatchSyncedAt      *time.Time `gorm:"column:match_synced_at" json:"-"` // last status set from the match state
 	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (Discussion) TableName() string {
 	return "discussion"
-}
\ No newline at end of file
+}
+
+// IsWritable reports whether new messages can be sent in the conversation
+func (d *Discussion) IsWritable() bool {
+	return d.Status == "" || d.Status == ConversationActive
+}
+
+// ClosedStatusFor is the server-side policy applied when a match ends:
+// an unmatch keeps the history readable, a block archives the conversation.
+func ClosedStatusFor(reason string) string {
+	if reason == CloseReasonBlocked {
+		return ConversationArchived
+	}
+	return ConversationReadOnly
+}
@@ -3,20 +3,121 @@ package models
 import "time"
 
 type Message struct {
-	ID        uint              `gorm:"primaryKey;column:id" json:"id"`
-	ConvID    uint              `gorm:"column:conv_id;not null" json:"conv_id"`
-	SenderID  uint              `gorm:"column:sender_id;not null" json:"sender_id"`
-	Msg       string            `gorm:"column:msg;type:text;not null" json:"msg"`
-	Time      time.Time         `gorm:"column:time;autoCreateTime" json:"time"`
-	ReadAt    *time.Time        `gorm:"column:read_at" json:"read_at"`
-	Reactions []MessageReaction `gorm:"foreignKey:MessageID" json:"reactions"`
+	ID          uint                `gorm:"primaryKey;column:id" json:"id"`
+	ConvID      uint                `gorm:"column:conv_id;not null" json:"conv_id"`
+	SenderID    uint                `gorm:"column:sender_id;not null" json:"sender_id"`
+	Msg         string              `gorm:"column:msg;type:text;not null" json:"msg"`
+	Time        time.Time           `gorm:"column:time;autoCreateTime" json:"time"`
+	DeliveredAt *time.Time          `gorm:"column:delivered_at" json:"delivered_at"`
+	ReadAt      *time.Time          `gorm:"column:read_at" json:"read_at"`
+	EditedAt    *time.Time          `gorm:"column:edited_at" json:"edited_at,omitempty"`
+	DeletedAt   *time.Time          `gorm:"column:deleted_at" json:"deleted_at,omitempty"`   // deleted for everyone, content cleared
+	Warning     string              `gorm:"column:warning;size:30" json:"warning,omitempty"` // screening rule the content broke, shown blurred to the recipient
+	Reactions   []MessageReaction   `gorm:"foreignKey:MessageID" json:"reactions"`
+	Attachments []MessageAttachment `gorm:"foreignKey:MessageID" json:"attachments,omitempty"`
+
+	// ReactionSummary groups Reactions by emoji for the reading user
+	ReactionSummary map[string]ReactionInfo `gorm:"-" json:"reaction_summary,omitempty"`
 }
 
 func (Message) TableName() string {
 	return "messages"
 }
 
+// MessageEditWindow is how long after sending a message its author can edit it
+const MessageEditWindow = 15 * time.Minute
+
+// Scopes of a message deletion
+const (
+	DeleteForMe       = "me"       // hidden for the requesting user only
+	DeleteForEveryone = "everyone" // unsent: content cleared for both participants
+)
+
+// IsDeleted reports whether the message was deleted for everyone
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// MessageEdit keeps the previous content of an edited message
+type MessageEdit struct {
+	ID          uint      `gorm:"primaryKey;column:id" json:"id"`
+	MessageID   uint      `gorm:"column:message_id;not null;index" json:"message_id"`
+	PreviousMsg string    `gorm:"column:previous_msg;type:text;not null" json:"previous_msg"`
+	EditedAt    time.Time `gorm:"column:edited_at;autoCreateTime" json:"edited_at"`
+}
+
+func (MessageEdit) TableName() string {
+	return "message_edits"
+}
+
+// MessageDeletion hides a message for one user (delete for me)
+type MessageDeletion struct {
+	MessageID uint      `gorm:"primaryKey;column:message_id" json:"message_id"`
+	UserID    uint      `gorm:"primaryKey;column:user_id" json:"user_id"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (MessageDeletion) TableName() string {
+	return "message_deletions"
+}
+
 type MessageRequest struct {
 	ConversationID uint   `json:"conversation_id" binding:"required"`
 	Message        string `json:"message" binding:"required"`
-}
\ No newline at end of file
+}
+
+// MaxAttachments is the number of attachments a message can carry
+const MaxAttachments = 10
+
+// Kinds of attachments, uploaded to media-service
+const (
+	AttachmentImage = "image"
+	AttachmentVoice = "voice"
+)
+
+// MessageAttachment links a message to a file uploaded to media-service. The
+// metadata is copied when the message is sent so that listing messages does
+// not call media-service; the URLs are signed on each read.
+type MessageAttachment struct {
+	MessageID    uint       `gorm:"primaryKey;column:message_id" json:"-"`
+	AttachmentID uint       `gorm:"primaryKey;column:attachment_id" json:"id"`
+	Position     int        `gorm:"column:position;not null;default:0" json:"-"`
+	Kind         string     `gorm:"column:kind;not null;size:10" json:"kind"`
+	MimeType     string     `gorm:"column:mime_type;not null;size:100" json:"mime_type"`
+	FileSize     int64      `gorm:"column:file_size;not null" json:"file_size"`
+	Width        *int       `gorm:"column:width" json:"width,omitempty"`
+	Height       *int       `gorm:"column:height" json:"height,omitempty"`
+	DurationMs   *int       `gorm:"column:duration_ms" json:"duration_ms,omitempty"`
+	Waveform     []int      `gorm:"column:waveform;type:text;serializer:json" json:"waveform,omitempty"`
+	URL          string     `gorm:"-" json:"url,omitempty"`
+	ThumbnailURL string     `gorm:"-" json:"thumbnail_url,omitempty"`
+	ExpiresAt    *time.Time `gorm:"-" json:"expires_at,omitempty"`
+}
+
+func (MessageAttachment) TableName() string {
+	return "message_attachments"
+}
+
+// WarnedMessagePreview replaces the preview of a message flagged by screening
+const WarnedMessagePreview = "⚠️ Message masqué"
+
+// Preview is the conversation preview of the message, which never shows
+// content flagged by screening
+func (m *Message) Preview() string {
+	if m.Warning != "" {
+		return WarnedMessagePreview
+	}
+	return MessagePreview(m.Msg, m.Attachments)
+}
+
+// MessagePreview is the conversation preview of a message: its text, or the
+// kind of its first attachment when it has none
+func MessagePreview(content string, attachments []MessageAttachment) string {
+	if content != "" || len(attachments) == 0 {
+		return content
+	}
+	if attachments[0].Kind == AttachmentVoice {
+		return "🎤 Message vocal"
+	}
+	return "📷 Photo"
+}
@@ -0,0 +1,99 @@
+// Package pubsub carries real-time chat events between chat-service replicas
+// and the gateway through Redis, so a user is reached whichever process holds
+// their WebSocket connection.
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"chat-service/src/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Origin identifies this replica in the events it publishes
+var Origin = newOrigin()
+
+// Bus publishes events on Redis
+type Bus struct {
+	client *redis.Client
+}
+
+// NewBus creates a publisher on the given Redis client
+func NewBus(client *redis.Client) *Bus {
+	return &Bus{client: client}
+}
+
+// NewEventID returns a random event identifier
+func NewEventID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s-%d", Origin, time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Publish sends the event to the channel of every recipient and returns how
+// many channels it reached. Missing ID, origin and timestamp are filled in.
+func (b *Bus) Publish(ctx context.Context, event *types.Event, userIDs []uint) (int, error) {
+	payload, err := b.encode(event)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var lastErr error
+	for _, userID := range userIDs {
+		if err := b.client.Publish(ctx, types.UserChannel(userID), payload).Err(); err != nil {
+			lastErr = err
+			continue
+		}
+		published++
+	}
+	if lastErr != nil {
+		return published, fmt.Errorf("failed to publish event %s: %w", event.ID, lastErr)
+	}
+	return published, nil
+}
+
+// PublishPresence sends a presence update to every replica
+func (b *Bus) PublishPresence(ctx context.Context, event *types.Event) error {
+	payload, err := b.encode(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, types.PresenceChannel, payload).Err()
+}
+
+func (b *Bus) encode(event *types.Event) ([]byte, error) {
+	if event.ID == "" {
+		event.ID = NewEventID()
+	}
+	if event.Origin == "" {
+		event.Origin = Origin
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return payload, nil
+}
+
+func newOrigin() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "chat-service"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
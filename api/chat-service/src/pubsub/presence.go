@@ -0,0 +1,96 @@
+package pubsub
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"chat-service/src/conf"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// PresenceRefresh is how often a replica refreshes its connections
+	PresenceRefresh = 30 * time.Second
+
+	// PresenceTTL is how long a connection counts without a refresh, so the
+	// connections of a replica that died stop counting
+	PresenceTTL = 3 * PresenceRefresh
+)
+
+// Presence counts the WebSocket connections of each user across replicas.
+// Each user has a sorted set of connection IDs scored by their expiry.
+type Presence struct {
+	client *redis.Client
+}
+
+// NewPresence creates a presence counter on the given Redis client
+func NewPresence(client *redis.Client) *Presence {
+	return &Presence{client: client}
+}
+
+// Connect records a connection and reports whether it is the only live one
+// of the user, on any replica
+func (p *Presence) Connect(userID uint, connID string) (bool, error) {
+	count, err := p.update(userID, func(pipe redis.Pipeliner, key string) {
+		pipe.ZAdd(conf.Ctx, key, redis.Z{Score: presenceExpiry(), Member: connID})
+	})
+	return count == 1, err
+}
+
+// Disconnect removes a connection and reports whether the user has no live
+// connection left, on any replica
+func (p *Presence) Disconnect(userID uint, connID string) (bool, error) {
+	count, err := p.update(userID, func(pipe redis.Pipeliner, key string) {
+		pipe.ZRem(conf.Ctx, key, connID)
+	})
+	return count == 0, err
+}
+
+// Refresh extends the connections held by this replica, by user
+func (p *Presence) Refresh(connections map[uint][]string) error {
+	if len(connections) == 0 {
+		return nil
+	}
+
+	expiry := presenceExpiry()
+	pipe := p.client.Pipeline()
+	for userID, connIDs := range connections {
+		key := presenceKey(userID)
+		for _, connID := range connIDs {
+			pipe.ZAdd(conf.Ctx, key, redis.Z{Score: expiry, Member: connID})
+		}
+		pipe.Expire(conf.Ctx, key, PresenceTTL)
+	}
+	if _, err := pipe.Exec(conf.Ctx); err != nil {
+		return fmt.Errorf("failed to refresh presence: %w", err)
+	}
+	return nil
+}
+
+// update applies change to the set of the user, drops the expired
+// connections and returns how many are left
+func (p *Presence) update(userID uint, change func(redis.Pipeliner, string)) (int64, error) {
+	key := presenceKey(userID)
+	var count *redis.IntCmd
+	_, err := p.client.TxPipelined(conf.Ctx, func(pipe redis.Pipeliner) error {
+		change(pipe, key)
+		pipe.ZRemRangeByScore(conf.Ctx, key, "-inf", strconv.FormatInt(time.Now().UnixMilli(), 10))
+		pipe.Expire(conf.Ctx, key, PresenceTTL)
+		count = pipe.ZCard(conf.Ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update presence of user %d: %w", userID, err)
+	}
+	return count.Val(), nil
+}
+
+func presenceKey(userID uint) string {
+	return fmt.Sprintf("chat:presence:%d", userID)
+}
+
+func presenceExpiry() float64 {
+	return float64(time.Now().Add(PresenceTTL).UnixMilli())
+}
@@ -0,0 +1,46 @@
+package pubsub
+
+import (
+	"testing"
+
+	"chat-service/src/types"
+)
+
+func TestParseChannel(t *testing.T) {
+	cases := []struct {
+		channel string
+		userID  uint
+		ok      bool
+	}{
+		{"user:42", 42, true},
+		{types.PresenceChannel, 0, true},
+		{"user:online:42", 0, false},
+		{"user:0", 0, false},
+		{"conversation:3", 0, false},
+	}
+
+	for _, tc := range cases {
+		userID, ok := parseChannel(tc.channel)
+		if userID != tc.userID || ok != tc.ok {
+			t.Errorf("parseChannel(%q) = %d, %v; want %d, %v", tc.channel, userID, ok, tc.userID, tc.ok)
+		}
+	}
+}
+
+func TestSubscriberDispatch(t *testing.T) {
+	var got []types.Event
+	s := NewSubscriber(nil, func(userID uint, event types.Event) {
+		if userID != 7 {
+			t.Errorf("Expected user 7, got %d", userID)
+		}
+		got = append(got, event)
+	})
+
+	s.dispatch("user:7", `{"event_id":"e1","type":"typing","conversation_id":3}`)
+	s.dispatch("user:7", `{"type":"typing"}`) // no ID: cannot be deduplicated
+	s.dispatch("user:7", `not json`)
+
+	if len(got) != 1 || got[0].ID != "e1" || got[0].ConversationID != 3 {
+		t.Fatalf("Expected only the event with an ID, got %+v", got)
+	}
+}
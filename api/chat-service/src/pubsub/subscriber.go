@@ -0,0 +1,92 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"chat-service/src/logger"
+	"chat-service/src/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Handler receives the events of a user. userID is 0 for presence updates,
+// which are meant for everyone connected.
+type Handler func(userID uint, event types.Event)
+
+// Subscriber listens to the user and presence channels
+type Subscriber struct {
+	client  *redis.Client
+	handler Handler
+}
+
+// NewSubscriber creates a subscriber calling handler for every event
+func NewSubscriber(client *redis.Client, handler Handler) *Subscriber {
+	return &Subscriber{client: client, handler: handler}
+}
+
+// Run dispatches events until ctx is cancelled. The Redis client reconnects
+// and resubscribes on its own when the connection drops.
+func (s *Subscriber) Run(ctx context.Context) {
+	logCtx := logger.WithComponent("pubsub").WithAction("subscribe")
+
+	sub := s.client.PSubscribe(ctx, types.UserChannelPattern)
+	defer sub.Close()
+	if err := sub.Subscribe(ctx, types.PresenceChannel); err != nil {
+		logger.ErrorWithContext(logCtx, "Failed to subscribe to %s: %v", types.PresenceChannel, err)
+	}
+
+	logger.InfoWithContext(logCtx, "📡 Listening to %s and %s as %s", types.UserChannelPattern, types.PresenceChannel, Origin)
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.InfoWithContext(logCtx, "📡 Event subscriber stopped")
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			s.dispatch(msg.Channel, msg.Payload)
+		}
+	}
+}
+
+func (s *Subscriber) dispatch(channel, payload string) {
+	userID, ok := parseChannel(channel)
+	if !ok {
+		return
+	}
+
+	var event types.Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		logger.WarnWithContext(logger.WithComponent("pubsub"), "Dropping malformed event on %s: %v", channel, err)
+		return
+	}
+	if event.ID == "" {
+		// Not published by a replica aware of event IDs, nothing to dedupe on
+		logger.DebugWithContext(logger.WithComponent("pubsub"), "Dropping event without ID on %s", channel)
+		return
+	}
+	s.handler(userID, event)
+}
+
+// parseChannel returns the user of a user:<id> channel, or 0 for the
+// presence channel
+func parseChannel(channel string) (uint, bool) {
+	if channel == types.PresenceChannel {
+		return 0, true
+	}
+	raw, found := strings.CutPrefix(channel, "user:")
+	if !found {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil || userID == 0 {
+		return 0, false
+	}
+	return uint(userID), true
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type chatRepository struct {
@@ -20,11 +21,15 @@ func NewChatRepository(db *gorm.DB) types.ChatRepository {
 }
 
 // Conversation operations
-func (r *chatRepository) GetUserConversations(userID uint) ([]models.Discussion, error) {
+func (r *chatRepository) GetUserConversations(userID uint, archived bool) ([]models.Discussion, error) {
 	var conversations []models.Discussion
 	
-	err := r.db.Where("user1_id = ? OR user2_id = ?", userID, userID).
-		Order("last_message_at DESC NULLS LAST, created_at DESC").
+	err := r.db.Select("discussion.*").
+		Joins("LEFT JOIN conversation_settings cs ON cs.conversation_id = discussion.id AND cs.user_id = ?", userID).
+		Where("(discussion.user1_id = ? OR discussion.user2_id = ?) AND (discussion.status IS NULL OR discussion.status <> ?)",
+			userID, userID, models.ConversationArchived).
+		Where("COALESCE(cs.archived, false) = ?", archived).
+		Order("COALESCE(cs.pinned, false) DESC, discussion.last_message_at DESC NULLS LAST, discussion.created_at DESC").
 		Find(&conversations).Error
 	
 	return conversations, err
@@ -219,23 +224,110 @@ func (r *chatRepository) UpdateLastMessage(conversationID uint, content string)
 		}).Error
 }
 
+func (r *chatRepository) SetConversationStatus(conversationID uint, status, reason string) error {
+	now := time.Now()
+	updates := map[string]any{
+		"status":          status,
+		"closed_reason":   reason,
+		"closed_at":       nil,
+		"match_synced_at": &now,
+	}
+	if status != models.ConversationActive {
+		updates["closed_at"] = &now
+	}
+	return r.db.Model(&models.Discussion{}).
+		Where("id = ?", conversationID).
+		Updates(updates).Error
+}
+
+// MarkMatchChecked records that the match of the conversation was just
+// confirmed. A conversation closed in the meantime is left as it is.
+func (r *chatRepository) MarkMatchChecked(conversationID uint) error {
+	return r.db.Model(&models.Discussion{}).
+		Where("id = ? AND (status = ? OR status = '' OR status IS NULL)", conversationID, models.ConversationActive).
+		Update("match_synced_at", time.Now()).Error
+}
+
 // Message operations
-func (r *chatRepository) GetMessages(conversationID uint, limit, offset int) ([]models.Message, error) {
+func (r *chatRepository) GetMessages(conversationID, userID uint, query types.MessageQuery) ([]models.Message, error) {
 	var messages []models.Message
 
-	err := r.db.Where("conv_id = ?", conversationID).
-		Order("time DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&messages).Error
+	db := r.visibleMessages(conversationID, userID).
+		Preload("Attachments", orderAttachments)
 
+	switch {
+	case query.AfterID > 0:
+		db = db.Where("id > ?", query.AfterID).Order("id ASC")
+	case !query.Since.IsZero():
+		db = db.Where("time > ?", query.Since).Order("id ASC")
+	case query.BeforeID > 0:
+		db = db.Where("id < ?", query.BeforeID).Order("id DESC")
+	default:
+		db = db.Order("id DESC").Offset(query.Offset)
+	}
+
+	err := db.Limit(query.Limit).Find(&messages).Error
 	return messages, err
 }
 
+// GetChangedMessages returns the messages up to the cursor of until that
+// were edited or deleted for everyone after since
+func (r *chatRepository) GetChangedMessages(conversationID, userID uint, until types.MessageQuery, since time.Time) ([]models.Message, error) {
+	var messages []models.Message
+
+	db := r.visibleMessages(conversationID, userID).
+		Preload("Attachments", orderAttachments).
+		Where("edited_at > ? OR deleted_at > ?", since, since)
+
+	if until.AfterID > 0 {
+		db = db.Where("id <= ?", until.AfterID)
+	} else {
+		db = db.Where("time <= ?", until.Since)
+	}
+
+	err := db.Order("id ASC").Find(&messages).Error
+	return messages, err
+}
+
+// GetHiddenMessageIDs returns the messages of the conversation userID
+// deleted for themselves after since
+func (r *chatRepository) GetHiddenMessageIDs(conversationID, userID uint, since time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.MessageDeletion{}).
+		Joins("JOIN messages ON messages.id = message_deletions.message_id").
+		Where("messages.conv_id = ? AND message_deletions.user_id = ? AND message_deletions.created_at > ?", conversationID, userID, since).
+		Order("message_deletions.message_id").
+		Pluck("message_deletions.message_id", &ids).Error
+	return ids, err
+}
+
+// GetReactedMessageIDs returns the messages of the conversation that got a
+// reaction after since. Removed reactions leave no trace and are not reported.
+func (r *chatRepository) GetReactedMessageIDs(conversationID uint, since time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.MessageReaction{}).
+		Joins("JOIN messages ON messages.id = message_reactions.message_id").
+		Where("messages.conv_id = ? AND message_reactions.created_at > ?", conversationID, since).
+		Distinct().
+		Order("message_reactions.message_id").
+		Pluck("message_reactions.message_id", &ids).Error
+	return ids, err
+}
+
+// visibleMessages scopes a query to the messages of the conversation that
+// userID did not delete for themselves
+func (r *chatRepository) visibleMessages(conversationID, userID uint) *gorm.DB {
+	hidden := r.db.Model(&models.MessageDeletion{}).
+		Select("message_id").
+		Where("user_id = ?", userID)
+
+	return r.db.Where("conv_id = ? AND id NOT IN (?)", conversationID, hidden)
+}
+
 func (r *chatRepository) GetMessage(messageID uint) (*models.Message, error) {
 	var message models.Message
 
-	err := r.db.First(&message, messageID).Error
+	err := r.db.Preload("Attachments", orderAttachments).First(&message, messageID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +335,18 @@ func (r *chatRepository) GetMessage(messageID uint) (*models.Message, error) {
 	return &message, nil
 }
 
+func orderAttachments(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+func (r *chatRepository) GetMessageAttachment(attachmentID uint) (*models.MessageAttachment, error) {
+	var attachment models.MessageAttachment
+	if err := r.db.Where("attachment_id = ?", attachmentID).First(&attachment).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
 func (r *chatRepository) SaveMessage(senderID, conversationID uint, content string) (*models.Message, error) {
 	message := &models.Message{
 		ConvID:   conversationID,
@@ -262,11 +366,143 @@ func (r *chatRepository) SaveMessage(senderID, conversationID uint, content stri
 	return message, nil
 }
 
-func (r *chatRepository) MarkMessagesAsRead(conversationID, userID uint) error {
+// EditMessage replaces the content of a message and keeps the previous one
+// in the edit history
+func (r *chatRepository) EditMessage(messageID uint, previous, content, warning string) (*models.Message, error) {
 	now := time.Now()
-	return r.db.Model(&models.Message{}).
-		Where("conv_id = ? AND sender_id != ? AND read_at IS NULL", conversationID, userID).
-		Update("read_at", &now).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		edit := models.MessageEdit{MessageID: messageID, PreviousMsg: previous, EditedAt: now}
+		if err := tx.Create(&edit).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Message{}).
+			Where("id = ?", messageID).
+			Updates(map[string]any{"msg": content, "edited_at": &now, "warning": warning}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetMessage(messageID)
+}
+
+func (r *chatRepository) CountSentMessages(conversationID, senderID, beforeID uint) (int64, error) {
+	query := r.db.Model(&models.Message{}).Where("conv_id = ? AND sender_id = ?", conversationID, senderID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *chatRepository) GetMessageEdits(messageID uint) ([]models.MessageEdit, error) {
+	var edits []models.MessageEdit
+	err := r.db.Where("message_id = ?", messageID).
+		Order("edited_at").
+		Find(&edits).Error
+	return edits, err
+}
+
+// DeleteMessageForEveryone clears the content of a message, its attachments
+// and its edit history. The message stays as a tombstone so the thread keeps
+// its shape.
+func (r *chatRepository) DeleteMessageForEveryone(messageID uint) (*models.Message, error) {
+	now := time.Now()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Delete(&models.MessageEdit{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", messageID).Delete(&models.MessageAttachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Message{}).
+			Where("id = ?", messageID).
+			Updates(map[string]any{"msg": "", "deleted_at": &now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetMessage(messageID)
+}
+
+// HideMessage deletes a message for one user only
+func (r *chatRepository) HideMessage(messageID, userID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.MessageDeletion{MessageID: messageID, UserID: userID}).Error
+}
+
+func (r *chatRepository) IsMessageHidden(messageID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.MessageDeletion{}).
+		Where("message_id = ? AND user_id = ?", messageID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RefreshLastMessage recomputes the conversation preview from its latest
+// message still visible to everyone, after an edit or an unsend
+func (r *chatRepository) RefreshLastMessage(conversationID uint) error {
+	var last models.Message
+	err := r.db.Where("conv_id = ? AND deleted_at IS NULL", conversationID).
+		Preload("Attachments", orderAttachments).
+		Order("time DESC, id DESC").
+		First(&last).Error
+
+	updates := map[string]any{"last_message_content": "", "last_message_at": nil}
+	switch {
+	case err == nil:
+		updates["last_message_content"] = last.Preview()
+		updates["last_message_at"] = &last.Time
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	return r.db.Model(&models.Discussion{}).
+		Where("id = ?", conversationID).
+		Updates(updates).Error
+}
+
+// MarkMessagesDelivered records that userID received the given messages of
+// the conversation and returns those not acknowledged before
+func (r *chatRepository) MarkMessagesDelivered(conversationID, userID uint, messageIDs []uint, at time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Message{}).
+			Where("conv_id = ? AND sender_id != ? AND id IN ? AND delivered_at IS NULL", conversationID, userID, messageIDs).
+			Order("id").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.Message{}).
+			Where("id IN ?", ids).
+			Update("delivered_at", at).Error
+	})
+	return ids, err
+}
+
+// MarkMessagesAsRead records that userID read the messages of the
+// conversation up to upToID and returns those that were unread. Reading a
+// message also marks it delivered.
+func (r *chatRepository) MarkMessagesAsRead(conversationID, userID, upToID uint, at time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		unread := tx.Model(&models.Message{}).
+			Where("conv_id = ? AND sender_id != ? AND read_at IS NULL", conversationID, userID)
+		if upToID > 0 {
+			unread = unread.Where("id <= ?", upToID)
+		}
+		if err := unread.Order("id").Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.Message{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"read_at":      at,
+				"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", at),
+			}).Error
+	})
+	return ids, err
 }
 
 func (r *chatRepository) GetUnreadCount(conversationID, userID uint) (int64, error) {
@@ -455,4 +691,91 @@ func (r *chatRepository) SetUserOffline(userID uint) error {
 			LastSeen:     &now,
 			LastActivity: now,
 		}).Error
-}
\ No newline at end of file
+}
+
+// Settings operations
+func (r *chatRepository) GetChatSettings(userID uint) (*models.ChatSettings, error) {
+	var settings models.ChatSettings
+	err := r.db.Where("user_id = ?", userID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultChatSettings(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *chatRepository) SaveChatSettings(settings *models.ChatSettings) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(settings).Error
+}
+
+func (r *chatRepository) GetConversationSettings(userID uint, conversationIDs []uint) (map[uint]models.ConversationSettings, error) {
+	settings := make(map[uint]models.ConversationSettings)
+	if len(conversationIDs) == 0 {
+		return settings, nil
+	}
+
+	var rows []models.ConversationSettings
+	if err := r.db.Where("user_id = ? AND conversation_id IN ?", userID, conversationIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		settings[row.ConversationID] = row
+	}
+	return settings, nil
+}
+
+func (r *chatRepository) SaveConversationSettings(settings *models.ConversationSettings) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(settings).Error
+}
+
+// PinConversation saves settings that pin a conversation, unless the user
+// already pinned maxPinned conversations, and reports whether it did. The
+// settings rows of the user stay locked until the save so that two pins at
+// the same time cannot both pass the cap.
+func (r *chatRepository) PinConversation(settings *models.ConversationSettings, maxPinned int64) (bool, error) {
+	saved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var locked []uint
+		err := tx.Model(&models.ConversationSettings{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", settings.UserID).
+			Pluck("conversation_id", &locked).Error
+		if err != nil {
+			return err
+		}
+
+		var pinned int64
+		err = tx.Model(&models.ConversationSettings{}).
+			Where("user_id = ? AND pinned AND conversation_id <> ?", settings.UserID, settings.ConversationID).
+			Count(&pinned).Error
+		if err != nil || pinned >= maxPinned {
+			return err
+		}
+
+		saved = true
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(settings).Error
+	})
+	return saved && err == nil, err
+}
+
+// GetMutedParticipants returns the participants who muted the conversation
+// until after the given time
+func (r *chatRepository) GetMutedParticipants(conversationID uint, at time.Time) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.Model(&models.ConversationSettings{}).
+		Where("conversation_id = ? AND muted_until > ?", conversationID, at).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// IsPremium reports whether the premium subscription of the user runs
+// (users.premium is its end date)
+func (r *chatRepository) IsPremium(userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Users{}).
+		Where("id = ? AND premium > ?", userID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
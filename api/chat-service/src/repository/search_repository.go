@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"chat-service/src/types"
+)
+
+// searchSQL finds the messages matching a websearch query in French or
+// English among the conversations of a user, newest first, with the message
+// before and after each one. Unsent messages and the ones the user deleted for
+// themselves are left out. %s takes the extra conditions.
+const searchSQL = `
+WITH q AS (
+	SELECT websearch_to_tsquery('french', @text) || websearch_to_tsquery('english', @text) AS query
+)
+SELECT m.id AS message_id, m.conv_id AS conversation_id, m.sender_id, m.time,
+	CASE WHEN d.user1_id = @user THEN d.user2_id ELSE d.user1_id END AS other_user_id,
+	ts_headline('french', m.msg, q.query, @options) AS snippet,
+	prev.id AS prev_id, prev.sender_id AS prev_sender_id, prev.msg AS prev_msg, prev.time AS prev_time,
+	nxt.id AS next_id, nxt.sender_id AS next_sender_id, nxt.msg AS next_msg, nxt.time AS next_time
+FROM messages m
+CROSS JOIN q
+JOIN discussion d ON d.id = m.conv_id AND (d.user1_id = @user OR d.user2_id = @user)
+LEFT JOIN LATERAL (
+	SELECT p.id, p.sender_id, p.msg, p.time FROM messages p
+	WHERE p.conv_id = m.conv_id AND p.id < m.id AND p.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM message_deletions h WHERE h.message_id = p.id AND h.user_id = @user)
+	ORDER BY p.id DESC LIMIT 1
+) prev ON true
+LEFT JOIN LATERAL (
+	SELECT n.id, n.sender_id, n.msg, n.time FROM messages n
+	WHERE n.conv_id = m.conv_id AND n.id > m.id AND n.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM message_deletions h WHERE h.message_id = n.id AND h.user_id = @user)
+	ORDER BY n.id ASC LIMIT 1
+) nxt ON true
+WHERE m.search_vector @@ q.query AND m.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM message_deletions h WHERE h.message_id = m.id AND h.user_id = @user)
+	%s
+ORDER BY m.id DESC
+LIMIT @limit`
+
+// searchRow is a row of searchSQL
+type searchRow struct {
+	MessageID      uint
+	ConversationID uint
+	SenderID       uint
+	Time           time.Time
+	OtherUserID    uint
+	Snippet        string
+	PrevID         *uint
+	PrevSenderID   *uint
+	PrevMsg        *string
+	PrevTime       *time.Time
+	NextID         *uint
+	NextSenderID   *uint
+	NextMsg        *string
+	NextTime       *time.Time
+}
+
+func (r *chatRepository) SearchMessages(userID uint, query types.SearchQuery) ([]types.SearchResult, error) {
+	args := map[string]interface{}{
+		"text":    query.Text,
+		"user":    userID,
+		"limit":   query.Limit,
+		"options": fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=20, MinWords=8, MaxFragments=2", types.SearchMatchStart, types.SearchMatchEnd),
+	}
+	conditions := ""
+	if query.ConversationID > 0 {
+		conditions += " AND m.conv_id = @conversation"
+		args["conversation"] = query.ConversationID
+	}
+	if query.BeforeID > 0 {
+		conditions += " AND m.id < @before"
+		args["before"] = query.BeforeID
+	}
+
+	var rows []searchRow
+	if err := r.db.Raw(fmt.Sprintf(searchSQL, conditions), args).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	results := make([]types.SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = types.SearchResult{
+			MessageID:      row.MessageID,
+			ConversationID: row.ConversationID,
+			SenderID:       row.SenderID,
+			Time:           row.Time,
+			OtherUserID:    row.OtherUserID,
+			Snippet:        row.Snippet,
+			Previous:       contextMessage(row.PrevID, row.PrevSenderID, row.PrevMsg, row.PrevTime),
+			Next:           contextMessage(row.NextID, row.NextSenderID, row.NextMsg, row.NextTime),
+		}
+	}
+	return results, nil
+}
+
+func contextMessage(id, senderID *uint, msg *string, sent *time.Time) *types.ContextMessage {
+	if id == nil || senderID == nil || msg == nil || sent == nil {
+		return nil
+	}
+	return &types.ContextMessage{ID: *id, SenderID: *senderID, Message: *msg, Time: *sent}
+}
@@ -0,0 +1,69 @@
+package screening
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Defaults of the pipeline built from the environment
+const (
+	defaultContactMessages        = 5
+	defaultSpamWindow             = time.Hour
+	defaultDuplicateConversations = 3
+	defaultMassConversations      = 8
+)
+
+// NewPipelineFromEnv builds the default pipeline: word lists of every locale,
+// contact details in the first messages and spam across conversations. The
+// environment tunes it:
+//
+//	SCREENING_PROFANITY_ACTION        action on profanity (warn)
+//	SCREENING_HARASSMENT_ACTION       action on threats and harassment (report)
+//	SCREENING_CONTACT_ACTION          action on contact details (warn)
+//	SCREENING_CONTACT_MESSAGES        first messages checked for contact details (5)
+//	SCREENING_SPAM_WINDOW             window of spam detection (1h)
+//	SCREENING_DUPLICATE_CONVERSATIONS conversations a message is blocked from (3)
+//	SCREENING_MASS_CONVERSATIONS      conversations a sender is reported from (8)
+func NewPipelineFromEnv(store SpamStore) (*Pipeline, error) {
+	profanity, err := LoadWordList("profanity")
+	if err != nil {
+		return nil, err
+	}
+	harassment, err := LoadWordList("harassment")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPipeline(
+		NewWordListScreener(RuleHarassment, envAction("SCREENING_HARASSMENT_ACTION", Report), harassment),
+		NewWordListScreener(RuleProfanity, envAction("SCREENING_PROFANITY_ACTION", Warn), profanity),
+		NewContactScreener(envInt("SCREENING_CONTACT_MESSAGES", defaultContactMessages), envAction("SCREENING_CONTACT_ACTION", Warn)),
+		NewSpamScreener(store,
+			envDuration("SCREENING_SPAM_WINDOW", defaultSpamWindow),
+			envInt("SCREENING_DUPLICATE_CONVERSATIONS", defaultDuplicateConversations),
+			envInt("SCREENING_MASS_CONVERSATIONS", defaultMassConversations),
+		),
+	), nil
+}
+
+func envAction(key string, fallback Action) Action {
+	if action, ok := ParseAction(os.Getenv(key)); ok {
+		return action
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
@@ -0,0 +1,66 @@
+package screening
+
+import (
+	"context"
+	"regexp"
+)
+
+// Patterns of contact details shared to move the conversation off the platform
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	urlPattern    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+	domainPattern = regexp.MustCompile(`(?i)\b[a-z0-9-]+\.(?:com|fr|net|org|io|me|co|be|ch|app|link|ly|gg|to)\b`)
+	handlePattern = regexp.MustCompile(`(?:^|\s)@[A-Za-z0-9_.]{3,}`)
+	// Nine digits or more, possibly separated by spaces, dots, dashes or brackets
+	phonePattern = regexp.MustCompile(`\+?\d(?:[\s.\-()]*\d){8,}`)
+)
+
+// offPlatformApps are the messaging apps people move to
+var offPlatformApps = NewWordList(
+	"snap", "snapchat", "insta", "instagram", "whatsapp", "telegram",
+	"kik", "discord", "tiktok", "facebook", "messenger", "wechat", "viber",
+)
+
+// ContactScreener flags phone numbers, emails, links and off-platform app
+// names in the first messages a sender writes in a conversation, when scams
+// and people avoiding the platform's protections usually share them
+type ContactScreener struct {
+	firstMessages int
+	action        Action
+}
+
+// NewContactScreener creates a screener checking the first firstMessages
+// messages of each sender of a conversation
+func NewContactScreener(firstMessages int, action Action) *ContactScreener {
+	return &ContactScreener{firstMessages: firstMessages, action: action}
+}
+
+func (s *ContactScreener) Name() string { return RuleContact }
+
+func (s *ContactScreener) Screen(ctx context.Context, input Input) []Flag {
+	if input.PriorMessages >= s.firstMessages {
+		return nil
+	}
+	if kind := contactKind(input.Content); kind != "" {
+		return []Flag{{Rule: RuleContact, Action: s.action, Detail: kind}}
+	}
+	return nil
+}
+
+// contactKind returns the kind of contact detail found in content, or ""
+func contactKind(content string) string {
+	switch {
+	case emailPattern.MatchString(content):
+		return "email"
+	case urlPattern.MatchString(content), domainPattern.MatchString(content):
+		return "link"
+	case phonePattern.MatchString(content):
+		return "phone"
+	case handlePattern.MatchString(content):
+		return "handle"
+	}
+	if app := offPlatformApps.Match(tokenize(content)); app != "" {
+		return app
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package screening
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldRunes maps accented letters and the usual character substitutions to
+// plain letters so that "sàl0pe" and "$alope" match the word lists
+var foldRunes = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'ç': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i',
+	'ñ': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u',
+	'ý': 'y', 'ÿ': 'y',
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't',
+	'@': 'a', '$': 's', '€': 'e',
+}
+
+// normalize lowercases text, folds accents and substitutions and collapses
+// everything that is not a letter into single spaces
+func normalize(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	space := true
+	for _, r := range strings.ToLower(text) {
+		if folded, ok := foldRunes[r]; ok {
+			r = folded
+		}
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// tokenize splits normalized text into words
+func tokenize(text string) []string {
+	return strings.Fields(normalize(text))
+}
+
+// fingerprint reduces a message to what stays the same when it is copied and
+// pasted with small changes: its letters, lowercased, without accents
+func fingerprint(text string) string {
+	return strings.ReplaceAll(normalize(text), " ", "")
+}
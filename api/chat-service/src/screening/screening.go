@@ -0,0 +1,128 @@
+// Package screening checks chat messages before they are saved. A Pipeline
+// runs a list of Screeners; each one can flag the message and the most severe
+// action wins.
+package screening
+
+import (
+	"context"
+	"strings"
+)
+
+// Action is what happens to a screened message, from the mildest to the most severe
+type Action int
+
+const (
+	// Allow delivers the message as is
+	Allow Action = iota
+	// Warn delivers the message with a warning: recipients see a blurred preview
+	Warn
+	// Block rejects the message
+	Block
+	// Report rejects the message and reports the sender to moderation
+	Report
+)
+
+func (a Action) String() string {
+	switch a {
+	case Warn:
+		return "warn"
+	case Block:
+		return "block"
+	case Report:
+		return "report"
+	default:
+		return "allow"
+	}
+}
+
+// ParseAction reads an action name, as used in the configuration
+func ParseAction(name string) (Action, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "allow":
+		return Allow, true
+	case "warn":
+		return Warn, true
+	case "block":
+		return Block, true
+	case "report":
+		return Report, true
+	}
+	return Allow, false
+}
+
+// Rules flagging messages
+const (
+	RuleProfanity  = "profanity"
+	RuleHarassment = "harassment"
+	RuleContact    = "contact_info"
+	RuleDuplicate  = "duplicate"
+	RuleMass       = "mass_message"
+)
+
+// Input is a message to screen
+type Input struct {
+	SenderID       uint
+	ConversationID uint
+	Content        string
+	PriorMessages  int  // messages the sender sent in the conversation before this one
+	Edit           bool // the message exists and its content is being edited
+}
+
+// Flag is a rule a message broke
+type Flag struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"-"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Verdict is the outcome of screening a message
+type Verdict struct {
+	Action Action
+	Flags  []Flag
+}
+
+// Rule returns the rule behind the action of the verdict, or "" when the
+// message is allowed
+func (v Verdict) Rule() string {
+	for _, flag := range v.Flags {
+		if flag.Action == v.Action && v.Action != Allow {
+			return flag.Rule
+		}
+	}
+	return ""
+}
+
+// Screener checks a message for one kind of problem
+type Screener interface {
+	Name() string
+	Screen(ctx context.Context, input Input) []Flag
+}
+
+// Pipeline runs screeners in order
+type Pipeline struct {
+	screeners []Screener
+}
+
+// NewPipeline creates a pipeline running the given screeners
+func NewPipeline(screeners ...Screener) *Pipeline {
+	return &Pipeline{screeners: screeners}
+}
+
+// Screen runs every screener and returns their flags, with the most severe
+// action among them. A nil pipeline allows everything.
+func (p *Pipeline) Screen(ctx context.Context, input Input) Verdict {
+	verdict := Verdict{Action: Allow}
+	if p == nil || strings.TrimSpace(input.Content) == "" {
+		return verdict
+	}
+
+	for _, screener := range p.screeners {
+		for _, flag := range screener.Screen(ctx, input) {
+			verdict.Flags = append(verdict.Flags, flag)
+			if flag.Action > verdict.Action {
+				verdict.Action = flag.Action
+			}
+		}
+	}
+	return verdict
+}
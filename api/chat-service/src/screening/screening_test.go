@@ -0,0 +1,124 @@
+package screening
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	got := normalize("  Sàl0pe!!  C'est $UPER  ")
+	want := "salope c est super"
+	if got != want {
+		t.Errorf("normalize() = %q, want %q", got, want)
+	}
+}
+
+func TestWordListMatchesWholeWordsAndPhrases(t *testing.T) {
+	list := NewWordList("con", "je vais te tuer")
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"quel C0N celui-là", "con"},
+		{"on se voit au concert", ""},
+		{"Je vais te TUER demain", "je vais te tuer"},
+		{"je vais te voir", ""},
+	}
+	for _, tt := range tests {
+		if got := list.Match(tokenize(tt.text)); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestShippedWordListsLoad(t *testing.T) {
+	for _, name := range []string{"profanity", "harassment"} {
+		list, err := LoadWordList(name)
+		if err != nil {
+			t.Fatalf("LoadWordList(%q) error: %v", name, err)
+		}
+		if list.Len() == 0 {
+			t.Errorf("LoadWordList(%q) is empty", name)
+		}
+	}
+}
+
+func TestContactKind(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"écris-moi à marie.dupont@gmail.com", "email"},
+		{"regarde https://example.org/profil", "link"},
+		{"mon site c'est monsite.fr", "link"},
+		{"appelle moi au 06 12 34 56 78", "phone"},
+		{"ajoute moi sur snap", "snap"},
+		{"suis moi @marie_d", "handle"},
+		{"on se retrouve à 18h30 le 12", ""},
+	}
+	for _, tt := range tests {
+		if got := contactKind(tt.text); got != tt.want {
+			t.Errorf("contactKind(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestContactScreenerOnlyChecksFirstMessages(t *testing.T) {
+	screener := NewContactScreener(5, Warn)
+	input := Input{Content: "mon insta c'est marie", PriorMessages: 2}
+
+	if flags := screener.Screen(context.Background(), input); len(flags) != 1 || flags[0].Action != Warn {
+		t.Errorf("Screen() in the first messages = %v, want one warning", flags)
+	}
+	input.PriorMessages = 5
+	if flags := screener.Screen(context.Background(), input); len(flags) != 0 {
+		t.Errorf("Screen() after the first messages = %v, want none", flags)
+	}
+}
+
+func TestSpamScreenerEscalates(t *testing.T) {
+	screener := NewSpamScreener(NewMemorySpamStore(), time.Hour, 3, 5)
+	content := "Salut ! Rejoins-moi sur mon site, c'est gratuit"
+
+	want := []Action{Allow, Allow, Block, Block, Report}
+	for i, action := range want {
+		verdict := NewPipeline(screener).Screen(context.Background(), Input{SenderID: 1, ConversationID: uint(i + 1), Content: content})
+		if verdict.Action != action {
+			t.Errorf("conversation %d: action = %s, want %s", i+1, verdict.Action, action)
+		}
+	}
+
+	// Each sender is counted apart, and edits are not tracked
+	verdict := NewPipeline(screener).Screen(context.Background(), Input{SenderID: 2, ConversationID: 1, Content: content})
+	if verdict.Action != Allow {
+		t.Errorf("other sender: action = %s, want allow", verdict.Action)
+	}
+	verdict = NewPipeline(screener).Screen(context.Background(), Input{SenderID: 1, ConversationID: 9, Content: content, Edit: true})
+	if verdict.Action != Allow {
+		t.Errorf("edit: action = %s, want allow", verdict.Action)
+	}
+}
+
+func TestPipelineKeepsMostSevereAction(t *testing.T) {
+	pipeline := NewPipeline(
+		NewWordListScreener(RuleProfanity, Warn, NewWordList("merde")),
+		NewWordListScreener(RuleHarassment, Report, NewWordList("je sais ou tu habites")),
+	)
+
+	verdict := pipeline.Screen(context.Background(), Input{Content: "merde, je sais où tu habites"})
+	if verdict.Action != Report || verdict.Rule() != RuleHarassment || len(verdict.Flags) != 2 {
+		t.Errorf("Screen() = %+v, want report for harassment with two flags", verdict)
+	}
+
+	verdict = pipeline.Screen(context.Background(), Input{Content: "à demain"})
+	if verdict.Action != Allow || verdict.Rule() != "" {
+		t.Errorf("Screen() = %+v, want allow", verdict)
+	}
+
+	var none *Pipeline
+	if verdict := none.Screen(context.Background(), Input{Content: "merde"}); verdict.Action != Allow {
+		t.Errorf("nil pipeline: action = %s, want allow", verdict.Action)
+	}
+}
@@ -0,0 +1,125 @@
+package screening
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"chat-service/src/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// minSpamLength is the shortest fingerprint tracked: "hi" or "ok" sent
+// everywhere is not spam
+const minSpamLength = 10
+
+// SpamStore counts the conversations a sender posted a message to
+type SpamStore interface {
+	// Record notes that senderID sent the message with this fingerprint to
+	// the conversation and returns in how many distinct conversations they
+	// sent it within the window
+	Record(ctx context.Context, senderID uint, fingerprint string, conversationID uint, window time.Duration) (int, error)
+}
+
+// SpamScreener flags the same message copied to many conversations: a few
+// copies are blocked as duplicates, a mass mailing gets the sender reported
+type SpamScreener struct {
+	store     SpamStore
+	window    time.Duration
+	duplicate int
+	mass      int
+}
+
+// NewSpamScreener creates a screener blocking a message sent to duplicate
+// conversations within window and reporting one sent to mass conversations
+func NewSpamScreener(store SpamStore, window time.Duration, duplicate, mass int) *SpamScreener {
+	return &SpamScreener{store: store, window: window, duplicate: duplicate, mass: mass}
+}
+
+func (s *SpamScreener) Name() string { return RuleDuplicate }
+
+func (s *SpamScreener) Screen(ctx context.Context, input Input) []Flag {
+	fp := fingerprint(input.Content)
+	if input.Edit || len(fp) < minSpamLength {
+		return nil
+	}
+
+	count, err := s.store.Record(ctx, input.SenderID, fp, input.ConversationID, s.window)
+	if err != nil {
+		// Spam detection must not stop the chat when Redis is down
+		logger.WarnWithContext(logger.WithComponent("screening").WithUser(input.SenderID).WithRequest(ctx), "Failed to record message for spam detection: %v", err)
+		return nil
+	}
+
+	detail := fmt.Sprintf("%d conversations", count)
+	switch {
+	case count >= s.mass:
+		return []Flag{{Rule: RuleMass, Action: Report, Detail: detail}}
+	case count >= s.duplicate:
+		return []Flag{{Rule: RuleDuplicate, Action: Block, Detail: detail}}
+	}
+	return nil
+}
+
+// RedisSpamStore keeps a sorted set per sender and message, the conversations
+// scored by when the message was last sent there, shared by every replica
+type RedisSpamStore struct {
+	client *redis.Client
+}
+
+// NewRedisSpamStore creates a spam store on the given Redis client
+func NewRedisSpamStore(client *redis.Client) *RedisSpamStore {
+	return &RedisSpamStore{client: client}
+}
+
+func (s *RedisSpamStore) Record(ctx context.Context, senderID uint, fingerprint string, conversationID uint, window time.Duration) (int, error) {
+	sum := sha1.Sum([]byte(fingerprint))
+	key := fmt.Sprintf("chat:spam:%d:%s", senderID, hex.EncodeToString(sum[:]))
+	now := time.Now()
+
+	pipe := s.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixMilli(), 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: conversationID})
+	count := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
+
+// MemorySpamStore is a SpamStore for a single process
+type MemorySpamStore struct {
+	mu   sync.Mutex
+	sent map[string]map[uint]time.Time
+}
+
+// NewMemorySpamStore creates an empty in-memory spam store
+func NewMemorySpamStore() *MemorySpamStore {
+	return &MemorySpamStore{sent: make(map[string]map[uint]time.Time)}
+}
+
+func (s *MemorySpamStore) Record(ctx context.Context, senderID uint, fingerprint string, conversationID uint, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%d:%s", senderID, fingerprint)
+	now := time.Now()
+	conversations := s.sent[key]
+	if conversations == nil {
+		conversations = make(map[uint]time.Time)
+		s.sent[key] = conversations
+	}
+	for id, at := range conversations {
+		if now.Sub(at) > window {
+			delete(conversations, id)
+		}
+	}
+	conversations[conversationID] = now
+	return len(conversations), nil
+}
@@ -0,0 +1,128 @@
+package screening
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Word lists shipped with the service, one directory per locale
+//
+//go:embed wordlists
+var wordlists embed.FS
+
+// WordList is a set of words and phrases matched on whole words, after
+// normalization
+type WordList struct {
+	words   map[string]bool
+	phrases [][]string
+}
+
+// NewWordList builds a word list from words and phrases
+func NewWordList(entries ...string) *WordList {
+	list := &WordList{words: make(map[string]bool)}
+	for _, entry := range entries {
+		list.add(entry)
+	}
+	return list
+}
+
+func (l *WordList) add(entry string) {
+	tokens := tokenize(entry)
+	switch len(tokens) {
+	case 0:
+	case 1:
+		l.words[tokens[0]] = true
+	default:
+		l.phrases = append(l.phrases, tokens)
+	}
+}
+
+// Len returns the number of entries of the list
+func (l *WordList) Len() int {
+	return len(l.words) + len(l.phrases)
+}
+
+// Match returns the first entry of the list found in tokens, or ""
+func (l *WordList) Match(tokens []string) string {
+	for i, token := range tokens {
+		if l.words[token] {
+			return token
+		}
+		for _, phrase := range l.phrases {
+			if hasPrefix(tokens[i:], phrase) {
+				return strings.Join(phrase, " ")
+			}
+		}
+	}
+	return ""
+}
+
+func hasPrefix(tokens, prefix []string) bool {
+	if len(tokens) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if tokens[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadWordList merges the list called name (profanity, harassment) of every
+// shipped locale. Lines starting with # are comments.
+func LoadWordList(name string) (*WordList, error) {
+	list := NewWordList()
+	locales, err := fs.ReadDir(wordlists, "wordlists")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+		file, err := wordlists.Open(path.Join("wordlists", locale.Name(), name+".txt"))
+		if err != nil {
+			continue // not every locale has every list
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			list.add(line)
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// WordListScreener flags messages containing an entry of a word list
+type WordListScreener struct {
+	rule   string
+	action Action
+	list   *WordList
+}
+
+// NewWordListScreener creates a screener flagging the entries of list under rule
+func NewWordListScreener(rule string, action Action, list *WordList) *WordListScreener {
+	return &WordListScreener{rule: rule, action: action, list: list}
+}
+
+func (s *WordListScreener) Name() string { return s.rule }
+
+func (s *WordListScreener) Screen(ctx context.Context, input Input) []Flag {
+	if match := s.list.Match(tokenize(input.Content)); match != "" {
+		return []Flag{{Rule: s.rule, Action: s.action, Detail: match}}
+	}
+	return nil
+}
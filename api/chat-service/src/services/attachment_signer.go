@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"chat-service/src/logger"
+	"chat-service/src/models"
+)
+
+const defaultAttachmentURLTTL = 15 * time.Minute
+
+// AttachmentSigner builds the expiring URLs under which media-service serves
+// attachments. media-service checks the signature with the same secret.
+type AttachmentSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewAttachmentSigner creates a signer (ATTACHMENT_URL_SECRET, ATTACHMENT_URL_TTL).
+// Without a secret attachments are listed without URLs.
+func NewAttachmentSigner() *AttachmentSigner {
+	ttl := defaultAttachmentURLTTL
+	if raw := os.Getenv("ATTACHMENT_URL_TTL"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			ttl = parsed
+		}
+	}
+
+	key := os.Getenv("ATTACHMENT_URL_SECRET")
+	if key == "" {
+		logger.WarnWithContext(logger.WithComponent("attachment_signer"), "ATTACHMENT_URL_SECRET not set, attachment URLs disabled")
+	}
+	return &AttachmentSigner{key: []byte(key), ttl: ttl, now: time.Now}
+}
+
+// Sign fills the URLs of the attachments
+func (s *AttachmentSigner) Sign(attachments []models.MessageAttachment) {
+	if s == nil || len(s.key) == 0 {
+		return
+	}
+
+	// Round the expiry to the minute so that URLs stay cacheable between reads
+	expiresAt := s.now().Add(s.ttl).Truncate(time.Minute).Add(time.Minute)
+	for i := range attachments {
+		attachments[i].URL = s.url(attachments[i].AttachmentID, "original", expiresAt)
+		if attachments[i].Kind == models.AttachmentImage {
+			attachments[i].ThumbnailURL = s.url(attachments[i].AttachmentID, "thumbnail", expiresAt)
+		}
+		attachments[i].ExpiresAt = &expiresAt
+	}
+}
+
+func (s *AttachmentSigner) url(attachmentID uint, variant string, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d:%s:%d", attachmentID, variant, expires)
+	return fmt.Sprintf("/api/v1/media/attachments/%d?variant=%s&expires=%d&sig=%s",
+		attachmentID, variant, expires, hex.EncodeToString(mac.Sum(nil)))
+}
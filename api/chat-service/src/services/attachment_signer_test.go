@@ -0,0 +1,66 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"chat-service/src/models"
+)
+
+func TestAttachmentSignerSign(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	signer := &AttachmentSigner{key: []byte("secret"), ttl: 15 * time.Minute, now: func() time.Time { return now }}
+
+	attachments := []models.MessageAttachment{
+		{AttachmentID: 7, Kind: models.AttachmentImage},
+		{AttachmentID: 8, Kind: models.AttachmentVoice},
+	}
+	signer.Sign(attachments)
+
+	if attachments[0].ThumbnailURL == "" || attachments[1].ThumbnailURL != "" {
+		t.Fatalf("Expected a thumbnail URL for images only, got %q and %q", attachments[0].ThumbnailURL, attachments[1].ThumbnailURL)
+	}
+
+	signed, err := url.Parse(attachments[0].URL)
+	if err != nil {
+		t.Fatalf("Invalid URL %q: %v", attachments[0].URL, err)
+	}
+	if signed.Path != "/api/v1/media/attachments/7" {
+		t.Errorf("Unexpected path %s", signed.Path)
+	}
+
+	query := signed.Query()
+	expires := now.Add(16 * time.Minute).Truncate(time.Minute).Unix()
+	if query.Get("expires") != fmt.Sprint(expires) || !attachments[0].ExpiresAt.Equal(time.Unix(expires, 0)) {
+		t.Errorf("Expected expiry %d, got %s", expires, query.Get("expires"))
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	fmt.Fprintf(mac, "7:original:%d", expires)
+	if query.Get("variant") != "original" || query.Get("sig") != hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("Unexpected signature in %s", attachments[0].URL)
+	}
+}
+
+func TestAttachmentSignerWithoutKey(t *testing.T) {
+	attachments := []models.MessageAttachment{{AttachmentID: 7, Kind: models.AttachmentImage}}
+	(&AttachmentSigner{}).Sign(attachments)
+	if attachments[0].URL != "" || attachments[0].ExpiresAt != nil {
+		t.Fatalf("Expected no URL without a signing key, got %+v", attachments[0])
+	}
+}
+
+func TestMessagePreview(t *testing.T) {
+	voice := []models.MessageAttachment{{Kind: models.AttachmentVoice}}
+	if got := models.MessagePreview("", voice); got != "🎤 Message vocal" {
+		t.Errorf("Unexpected voice preview %q", got)
+	}
+	if got := models.MessagePreview("salut", voice); got != "salut" {
+		t.Errorf("Expected the text to win over attachments, got %q", got)
+	}
+}
@@ -3,12 +3,24 @@ package services
 import (
 	"chat-service/src/models"
 	"chat-service/src/types"
+	"context"
 	"errors"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type chatService struct {
 	repo            types.ChatRepository
 	connMgr         types.ConnectionManager
+	matches         types.MatchChecker
+	attachments     types.AttachmentClaimer
+	screener        types.MessageScreener
+	reporter        types.ModerationReporter
+	signer          *AttachmentSigner
+	receiptsPremium bool          // read receipts only shown to premium senders
+	matchRecheck    time.Duration // how long the match status synced by match-service is trusted
 	messageService  *MessageService
 	notificationSvc *NotificationService
 }
@@ -16,19 +28,30 @@ type chatService struct {
 func NewChatService(
 	repo types.ChatRepository,
 	connMgr types.ConnectionManager,
+	matches types.MatchChecker,
+	attachments types.AttachmentClaimer,
+	screener types.MessageScreener,
+	reporter types.ModerationReporter,
 ) types.ChatService {
 	return &chatService{
 		repo:            repo,
 		connMgr:         connMgr,
+		matches:         matches,
+		attachments:     attachments,
+		screener:        screener,
+		reporter:        reporter,
+		signer:          NewAttachmentSigner(),
+		receiptsPremium: os.Getenv("READ_RECEIPTS_PREMIUM_ONLY") == "true",
+		matchRecheck:    matchRecheckFromEnv(),
 		messageService:  NewMessageService(),
-		notificationSvc: NewNotificationService(),
+		notificationSvc: NewNotificationService(repo),
 	}
 }
 
 // Conversation methods
-func (s *chatService) GetUserConversations(userID uint) (*types.ConversationListResponse, error) {
+func (s *chatService) GetUserConversations(userID uint, archived bool) (*types.ConversationListResponse, error) {
 	// Get raw conversations
-	discussions, err := s.repo.GetUserConversations(userID)
+	discussions, err := s.repo.GetUserConversations(userID, archived)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +87,16 @@ func (s *chatService) GetUserConversations(userID uint) (*types.ConversationList
 		return nil, err
 	}
 
+	// Get the settings of the user for these conversations
+	conversationIDs := make([]uint, len(discussions))
+	for i, discussion := range discussions {
+		conversationIDs[i] = discussion.ID
+	}
+	settings, err := s.repo.GetConversationSettings(userID, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Enrich conversations
 	enrichedConversations := make([]types.ConversationResponse, len(discussions))
 	for i, discussion := range discussions {
@@ -101,8 +134,11 @@ func (s *chatService) GetUserConversations(userID uint) (*types.ConversationList
 			LastMessageAt: discussion.LastMessageAt,
 			UnreadCount:   unreadCount,
 			OtherUser:     otherUser,
+			Status:        conversationStatus(discussion),
+			ClosedReason:  discussion.ClosedReason,
 			CreatedAt:     discussion.CreatedAt,
 		}
+		applyConversationSettings(&enrichedConversations[i], settings[discussion.ID])
 	}
 
 	return &types.ConversationListResponse{
@@ -156,7 +192,12 @@ func (s *chatService) GetConversation(userID, conversationID uint) (*types.Conve
 		unreadCount = 0
 	}
 
-	return &types.ConversationResponse{
+	settings, err := s.repo.GetConversationSettings(userID, []uint{conversationID})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.ConversationResponse{
 		ID:            discussion.ID,
 		User1ID:       discussion.User1ID,
 		User2ID:       discussion.User2ID,
@@ -164,16 +205,98 @@ func (s *chatService) GetConversation(userID, conversationID uint) (*types.Conve
 		LastMessageAt: discussion.LastMessageAt,
 		UnreadCount:   unreadCount,
 		OtherUser:     otherUser,
+		Status:        conversationStatus(*discussion),
+		ClosedReason:  discussion.ClosedReason,
 		CreatedAt:     discussion.CreatedAt,
-	}, nil
+	}
+	applyConversationSettings(response, settings[conversationID])
+	return response, nil
 }
 
-func (s *chatService) CreateConversation(user1ID, user2ID uint) (*models.Discussion, error) {
+func (s *chatService) CreateConversation(ctx context.Context, user1ID, user2ID uint) (*models.Discussion, error) {
 	if user1ID == user2ID {
 		return nil, errors.New("cannot create conversation with yourself")
 	}
-	
-	return s.repo.CreateConversation(user1ID, user2ID)
+
+	matched, err := s.matches.AreMatched(ctx, user1ID, user2ID)
+	if err != nil {
+		return nil, types.ErrMatchCheckUnavailable
+	}
+	if !matched {
+		return nil, types.ErrNotMatched
+	}
+
+	return s.OpenMatchConversation(user1ID, user2ID)
+}
+
+// OpenMatchConversation creates the conversation of a new match, or reopens
+// the one closed by a previous unmatch. Called by match-service on match.
+func (s *chatService) OpenMatchConversation(user1ID, user2ID uint) (*models.Discussion, error) {
+	if user1ID == user2ID {
+		return nil, errors.New("cannot create conversation with yourself")
+	}
+
+	conversation, err := s.repo.CreateConversation(user1ID, user2ID)
+	if err != nil {
+		return nil, err
+	}
+	reopened := !conversation.IsWritable()
+	if !reopened && conversation.MatchSyncedAt != nil {
+		return conversation, nil
+	}
+
+	if err := s.repo.SetConversationStatus(conversation.ID, models.ConversationActive, ""); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	conversation.Status = models.ConversationActive
+	conversation.ClosedReason = ""
+	conversation.ClosedAt = nil
+	conversation.MatchSyncedAt = &now
+	if reopened {
+		_ = s.notificationSvc.PublishConversationUpdate(*conversation)
+	}
+	return conversation, nil
+}
+
+// CloseMatchConversation applies the closing policy to the conversation of an
+// ended match. Returns nil without error when the users never talked.
+func (s *chatService) CloseMatchConversation(user1ID, user2ID uint, reason string) (*models.Discussion, error) {
+	conversation, err := s.repo.FindConversationBetweenUsers(user1ID, user2ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.ClosedStatusFor(reason)
+	// A block outranks an unmatch: an archived conversation is never downgraded
+	if conversation.Status == models.ConversationArchived && status != models.ConversationArchived {
+		return conversation, nil
+	}
+	if conversation.Status == status && conversation.ClosedReason == reason {
+		return conversation, nil
+	}
+
+	if err := s.repo.SetConversationStatus(conversation.ID, status, reason); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	conversation.Status = status
+	conversation.ClosedReason = reason
+	conversation.ClosedAt = &now
+	conversation.MatchSyncedAt = &now
+	_ = s.notificationSvc.PublishConversationUpdate(*conversation)
+	return conversation, nil
+}
+
+// conversationStatus reports rows created before statuses existed as active
+func conversationStatus(discussion models.Discussion) string {
+	if discussion.Status == "" {
+		return models.ConversationActive
+	}
+	return discussion.Status
 }
 
 func (s *chatService) DeleteConversation(userID, targetUserID uint) error {
@@ -206,44 +329,25 @@ func (s *chatService) DeleteConversation(userID, targetUserID uint) error {
 }
 
 // Message methods
-func (s *chatService) GetMessages(userID, conversationID uint, limit, offset int) ([]models.Message, error) {
-	// Verify access
-	hasAccess, err := s.repo.IsUserInConversation(userID, conversationID)
-	if err != nil {
+func (s *chatService) GetMessages(userID, conversationID uint, query types.MessageQuery) ([]models.Message, error) {
+	if err := s.ensureParticipant(userID, conversationID); err != nil {
 		return nil, err
 	}
-	if !hasAccess {
-		// Check if conversation exists at all
-		_, err := s.repo.GetConversation(conversationID)
-		if err != nil {
-			return nil, errors.New("conversation not found")
-		}
-		return nil, errors.New("access denied")
-	}
 
 	// Set default pagination
-	if limit <= 0 || limit > 100 {
-		limit = 50
+	if query.Limit <= 0 || query.Limit > 100 {
+		query.Limit = 50
 	}
-	if offset < 0 {
-		offset = 0
+	if query.Offset < 0 {
+		query.Offset = 0
 	}
 
-	messages, err := s.repo.GetMessages(conversationID, limit, offset)
+	messages, err := s.repo.GetMessages(conversationID, userID, query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Enrich messages with reactions
-	for i := range messages {
-		reactions, err := s.repo.GetMessageReactions(messages[i].ID)
-		if err != nil {
-			// Don't fail the whole request if reactions fail
-			reactions = []models.MessageReaction{}
-		}
-		messages[i].Reactions = reactions
-	}
-
+	s.enrichMessages(userID, messages)
 	return messages, nil
 }
 
@@ -251,7 +355,13 @@ func (s *chatService) GetMessage(messageID uint) (*models.Message, error) {
 	return s.repo.GetMessage(messageID)
 }
 
-func (s *chatService) SendMessage(senderID, conversationID uint, content string) (*models.Message, error) {
+func (s *chatService) SendMessage(ctx context.Context, senderID, conversationID uint, content string) (*models.Message, error) {
+	return s.SendMessageWithAttachments(ctx, senderID, conversationID, content, nil)
+}
+
+// SendMessageWithAttachments sends a message carrying files the sender
+// uploaded to media-service. The text is optional when there are attachments.
+func (s *chatService) SendMessageWithAttachments(ctx context.Context, senderID, conversationID uint, content string, attachmentIDs []uint) (*models.Message, error) {
 	// Verify access
 	hasAccess, err := s.repo.IsUserInConversation(senderID, conversationID)
 	if err != nil {
@@ -265,47 +375,101 @@ func (s *chatService) SendMessage(senderID, conversationID uint, content string)
 		}
 		return nil, errors.New("access denied")
 	}
+
+	if err := s.ensureWritable(ctx, senderID, conversationID); err != nil {
+		return nil, err
+	}
 	
 	// Validate message
-	if content == "" {
+	attachmentIDs = uniqueIDs(attachmentIDs)
+	if content == "" && len(attachmentIDs) == 0 {
 		return nil, errors.New("message cannot be empty")
 	}
 	if len(content) > 1000 {
 		return nil, errors.New("message too long")
 	}
-	
+	if len(attachmentIDs) > models.MaxAttachments {
+		return nil, types.ErrTooManyAttachments
+	}
+
+	// Screen the content before the attachments are claimed
+	warning, err := s.screenMessage(ctx, senderID, conversationID, 0, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []models.MessageAttachment
+	if len(attachmentIDs) > 0 {
+		if s.attachments == nil {
+			return nil, types.ErrMediaUnavailable
+		}
+		attachments, err = s.attachments.ClaimAttachments(ctx, senderID, conversationID, attachmentIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Save message using the message service
-	message, err := s.messageService.SaveMessage(senderID, conversationID, content)
+	message, err := s.messageService.SaveMessage(senderID, conversationID, content, warning, attachments)
 	if err != nil {
 		return nil, err
 	}
+	s.signer.Sign(message.Attachments)
 
 	// Send notifications using the notification service
 	participants, err := s.repo.GetConversationParticipants(conversationID)
 	if err == nil {
 		// Don't fail the message send if notifications fail
-		_ = s.notificationSvc.PublishMessage(*message, participants)
+		_ = s.notificationSvc.PublishMessage(ctx, *message, participants)
 	}
 
 	return message, nil
 }
 
-func (s *chatService) MarkMessagesAsRead(userID, conversationID uint) error {
-	// Verify access
-	hasAccess, err := s.repo.IsUserInConversation(userID, conversationID)
+// defaultMatchRecheck is how long a conversation synced by match-service is
+// trusted before the match is checked again
+const defaultMatchRecheck = time.Minute
+
+// matchRecheckFromEnv reads MATCH_RECHECK_INTERVAL
+func matchRecheckFromEnv() time.Duration {
+	if raw := os.Getenv("MATCH_RECHECK_INTERVAL"); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultMatchRecheck
+}
+
+// ensureWritable checks that the conversation is open. Its status follows the
+// match as match-service opens and closes it, but a close may have been lost
+// while chat-service was unreachable: once the status is older than
+// matchRecheck, the match is checked against match-service again.
+func (s *chatService) ensureWritable(ctx context.Context, senderID, conversationID uint) error {
+	conversation, err := s.repo.GetConversation(conversationID)
 	if err != nil {
-		return err
+		return errors.New("conversation not found")
 	}
-	if !hasAccess {
-		// Check if conversation exists at all
-		_, err := s.repo.GetConversation(conversationID)
-		if err != nil {
-			return errors.New("conversation not found")
-		}
-		return errors.New("access denied")
+	if !conversation.IsWritable() {
+		return types.ErrConversationClosed
 	}
-	
-	return s.repo.MarkMessagesAsRead(conversationID, userID)
+	if conversation.MatchSyncedAt != nil && time.Since(*conversation.MatchSyncedAt) < s.matchRecheck {
+		return nil
+	}
+
+	otherUserID := conversation.User1ID
+	if otherUserID == senderID {
+		otherUserID = conversation.User2ID
+	}
+	matched, err := s.matches.AreMatched(ctx, senderID, otherUserID)
+	if err != nil {
+		return types.ErrMatchCheckUnavailable
+	}
+	if !matched {
+		_, _ = s.CloseMatchConversation(senderID, otherUserID, models.CloseReasonUnmatched)
+		return types.ErrConversationClosed
+	}
+	_ = s.repo.MarkMatchChecked(conversation.ID)
+	return nil
 }
 
 // Real-time methods
@@ -313,13 +477,13 @@ func (s *chatService) HandleConnection(userID uint, conn types.WebSocketConnecti
 	return s.connMgr.AddConnection(userID, conn)
 }
 
-func (s *chatService) BroadcastMessage(message models.Message) error {
+func (s *chatService) BroadcastMessage(ctx context.Context, message models.Message) error {
 	participants, err := s.repo.GetConversationParticipants(message.ConvID)
 	if err != nil {
 		return err
 	}
 
-	return s.notificationSvc.PublishMessage(message, participants)
+	return s.notificationSvc.PublishMessage(ctx, message, participants)
 }
 
 // Reaction methods
@@ -392,4 +556,35 @@ func (s *chatService) SetUserOffline(userID uint) error {
 
 func (s *chatService) GetUserPresence(userID uint) (*models.UserPresence, error) {
 	return s.repo.GetUserPresence(userID)
-}
\ No newline at end of file
+}
+// GetAttachment returns an attachment with fresh URLs, for a participant of
+// the conversation it was sent in
+func (s *chatService) GetAttachment(userID, attachmentID uint) (*models.MessageAttachment, error) {
+	attachment, err := s.repo.GetMessageAttachment(attachmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.visibleMessage(userID, attachment.MessageID); err != nil {
+		return nil, err
+	}
+
+	attachments := []models.MessageAttachment{*attachment}
+	s.signer.Sign(attachments)
+	return &attachments[0], nil
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
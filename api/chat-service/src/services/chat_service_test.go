@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"chat-service/src/models"
+	"chat-service/src/types"
+)
+
+// conversationRepo serves a single conversation
+type conversationRepo struct {
+	types.ChatRepository
+	conversation models.Discussion
+}
+
+func (r *conversationRepo) GetConversation(conversationID uint) (*models.Discussion, error) {
+	conversation := r.conversation
+	return &conversation, nil
+}
+
+func (r *conversationRepo) SetConversationStatus(conversationID uint, status, reason string) error {
+	now := time.Now()
+	r.conversation.Status = status
+	r.conversation.MatchSyncedAt = &now
+	return nil
+}
+
+func (r *conversationRepo) MarkMatchChecked(conversationID uint) error {
+	now := time.Now()
+	r.conversation.MatchSyncedAt = &now
+	return nil
+}
+
+// matchChecker counts the calls made to match-service
+type matchChecker struct {
+	calls   int
+	matched bool
+	err     error
+}
+
+func (m *matchChecker) AreMatched(ctx context.Context, user1ID, user2ID uint) (bool, error) {
+	m.calls++
+	return m.matched, m.err
+}
+
+func TestEnsureWritable_TrustsARecentSyncOnly(t *testing.T) {
+	synced := time.Now()
+	repo := &conversationRepo{conversation: models.Discussion{ID: 3, User1ID: 1, User2ID: 2, Status: models.ConversationActive, MatchSyncedAt: &synced}}
+	matches := &matchChecker{err: errors.New("match-service down")}
+	s := &chatService{repo: repo, matches: matches, matchRecheck: time.Minute}
+
+	if err := s.ensureWritable(context.Background(), 1, 3); err != nil {
+		t.Fatalf("expected a recently synced conversation to be writable, got %v", err)
+	}
+	repo.conversation.Status = models.ConversationReadOnly
+	if err := s.ensureWritable(context.Background(), 1, 3); !errors.Is(err, types.ErrConversationClosed) {
+		t.Errorf("expected a read-only conversation to be closed, got %v", err)
+	}
+	if matches.calls != 0 {
+		t.Errorf("expected no call to match-service, got %d", matches.calls)
+	}
+
+	// A close lost by match-service is caught once the sync is stale
+	stale := time.Now().Add(-2 * time.Minute)
+	repo.conversation.Status = models.ConversationActive
+	repo.conversation.MatchSyncedAt = &stale
+	if err := s.ensureWritable(context.Background(), 1, 3); !errors.Is(err, types.ErrMatchCheckUnavailable) {
+		t.Errorf("expected a stale conversation to be checked again, got %v", err)
+	}
+	if matches.calls != 1 {
+		t.Errorf("expected match-service to be asked, got %d calls", matches.calls)
+	}
+
+	matches.err = nil
+	matches.matched = true
+	if err := s.ensureWritable(context.Background(), 1, 3); err != nil {
+		t.Fatalf("expected a matched conversation to be writable, got %v", err)
+	}
+	if !repo.conversation.MatchSyncedAt.After(stale) {
+		t.Error("expected the check to refresh the sync time")
+	}
+}
+
+func TestEnsureWritable_FallsBackToMatchService(t *testing.T) {
+	repo := &conversationRepo{conversation: models.Discussion{ID: 3, User1ID: 1, User2ID: 2, Status: models.ConversationActive}}
+	matches := &matchChecker{err: errors.New("match-service down")}
+	s := &chatService{repo: repo, matches: matches, matchRecheck: time.Minute}
+
+	if err := s.ensureWritable(context.Background(), 1, 3); !errors.Is(err, types.ErrMatchCheckUnavailable) {
+		t.Errorf("expected the check to be unavailable, got %v", err)
+	}
+
+	matches.err = nil
+	matches.matched = true
+	for i := 0; i < 2; i++ {
+		if err := s.ensureWritable(context.Background(), 1, 3); err != nil {
+			t.Fatalf("expected a matched conversation to be writable, got %v", err)
+		}
+	}
+	if matches.calls != 2 {
+		t.Errorf("expected match-service to be asked until the conversation is synced, got %d calls", matches.calls)
+	}
+	if repo.conversation.MatchSyncedAt == nil {
+		t.Error("expected the conversation to follow the match from now on")
+	}
+}
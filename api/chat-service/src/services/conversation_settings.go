@@ -0,0 +1,98 @@
+package services
+
+import (
+	"time"
+
+	"chat-service/src/logger"
+	"chat-service/src/models"
+	"chat-service/src/types"
+)
+
+// GetConversationSettings returns the settings of userID for the conversation
+func (s *chatService) GetConversationSettings(userID, conversationID uint) (*models.ConversationSettings, error) {
+	if err := s.ensureParticipant(userID, conversationID); err != nil {
+		return nil, err
+	}
+	return s.conversationSettings(userID, conversationID)
+}
+
+// UpdateConversationSettings mutes, pins or archives a conversation for
+// userID. Archiving a conversation unpins it.
+func (s *chatService) UpdateConversationSettings(userID, conversationID uint, req types.ConversationSettingsRequest) (*models.ConversationSettings, error) {
+	if err := s.ensureParticipant(userID, conversationID); err != nil {
+		return nil, err
+	}
+	settings, err := s.conversationSettings(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MutedUntil != nil {
+		settings.MutedUntil = nil
+		if *req.MutedUntil != "" {
+			until, err := time.Parse(time.RFC3339, *req.MutedUntil)
+			if err != nil {
+				return nil, types.ErrInvalidMuteUntil
+			}
+			if until.After(time.Now()) {
+				settings.MutedUntil = &until
+			}
+		}
+	}
+
+	if req.Archived != nil {
+		settings.Archived = *req.Archived
+		if settings.Archived {
+			settings.Pinned = false
+		}
+	}
+
+	pin := false
+	if req.Pinned != nil && *req.Pinned != settings.Pinned {
+		if *req.Pinned && settings.Archived {
+			return nil, types.ErrPinArchived
+		}
+		pin = *req.Pinned
+		settings.Pinned = *req.Pinned
+	}
+
+	if pin {
+		// The cap is checked in the same transaction as the save
+		saved, err := s.repo.PinConversation(settings, models.MaxPinnedConversations)
+		if err != nil {
+			return nil, err
+		}
+		if !saved {
+			return nil, types.ErrTooManyPinned
+		}
+	} else if err := s.repo.SaveConversationSettings(settings); err != nil {
+		return nil, err
+	}
+
+	logger.InfoWithContext(logger.WithComponent("chat_service").WithUser(userID).WithConversation(conversationID),
+		"Conversation settings updated: muted=%t pinned=%t archived=%t", settings.IsMuted(time.Now()), settings.Pinned, settings.Archived)
+	return settings, nil
+}
+
+// conversationSettings returns the saved settings of userID for the
+// conversation, or empty ones
+func (s *chatService) conversationSettings(userID, conversationID uint) (*models.ConversationSettings, error) {
+	saved, err := s.repo.GetConversationSettings(userID, []uint{conversationID})
+	if err != nil {
+		return nil, err
+	}
+	settings := saved[conversationID]
+	settings.ConversationID = conversationID
+	settings.UserID = userID
+	return &settings, nil
+}
+
+// applyConversationSettings copies the settings of the user into the
+// conversation listed for them. An expired mute is not shown.
+func applyConversationSettings(response *types.ConversationResponse, settings models.ConversationSettings) {
+	if settings.IsMuted(time.Now()) {
+		response.MutedUntil = settings.MutedUntil
+	}
+	response.Pinned = settings.Pinned
+	response.Archived = settings.Archived
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+// MatchClient asks match-service whether two users have an active match
+type MatchClient struct {
+	matchServiceURL string
+	internalKey     string
+	httpClient      *http.Client
+}
+
+// NewMatchClient creates a match client (MATCH_SERVICE_URL, INTERNAL_API_KEY)
+func NewMatchClient() *MatchClient {
+	url := os.Getenv("MATCH_SERVICE_URL")
+	if url == "" {
+		url = "http://match-service:8003"
+	}
+	return &MatchClient{
+		matchServiceURL: url,
+		internalKey:     os.Getenv("INTERNAL_API_KEY"),
+		httpClient:      tracing.NewClient(5 * time.Second),
+	}
+}
+
+// AreMatched calls GET /api/v1/internal/matches/check on match-service
+func (mc *MatchClient) AreMatched(ctx context.Context, user1ID, user2ID uint) (bool, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/matches/check?user1_id=%d&user2_id=%d", mc.matchServiceURL, user1ID, user2ID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", "matcha-internal-service/chat-service")
+	if mc.internalKey != "" {
+		req.Header.Set("X-Internal-Key", mc.internalKey)
+	}
+
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("match service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("match service returned status: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Matched bool `json:"matched"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("failed to decode match status: %w", err)
+	}
+	return body.Data.Matched, nil
+}
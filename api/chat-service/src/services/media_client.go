@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"chat-service/src/models"
+	"chat-service/src/types"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+// MediaClient claims and deletes chat attachments uploaded to media-service
+type MediaClient struct {
+	mediaServiceURL string
+	internalKey     string
+	httpClient      *http.Client
+}
+
+// NewMediaClient creates a media client (MEDIA_SERVICE_URL, INTERNAL_API_KEY)
+func NewMediaClient() *MediaClient {
+	url := os.Getenv("MEDIA_SERVICE_URL")
+	if url == "" {
+		url = "http://media-service:8006"
+	}
+	return &MediaClient{
+		mediaServiceURL: url,
+		internalKey:     os.Getenv("INTERNAL_API_KEY"),
+		httpClient:      tracing.NewClient(5 * time.Second),
+	}
+}
+
+// ClaimAttachments calls POST /api/v1/internal/attachments/claim on
+// media-service, which checks that userID uploaded the attachments and that
+// they were not sent yet
+func (mc *MediaClient) ClaimAttachments(ctx context.Context, userID, conversationID uint, attachmentIDs []uint) ([]models.MessageAttachment, error) {
+	body, err := json.Marshal(map[string]any{
+		"user_id":         userID,
+		"conversation_id": conversationID,
+		"attachment_ids":  attachmentIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mc.mediaServiceURL+"/api/v1/internal/attachments/claim", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "matcha-internal-service/chat-service")
+	if mc.internalKey != "" {
+		req.Header.Set("X-Internal-Key", mc.internalKey)
+	}
+
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrMediaUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return nil, types.ErrAttachmentUnavailable
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: status %d", types.ErrMediaUnavailable, resp.StatusCode)
+	}
+
+	var claimed struct {
+		Data struct {
+			Attachments []struct {
+				ID         uint   `json:"id"`
+				Kind       string `json:"kind"`
+				MimeType   string `json:"mime_type"`
+				FileSize   int64  `json:"file_size"`
+				Width      *int   `json:"width"`
+				Height     *int   `json:"height"`
+				DurationMs *int   `json:"duration_ms"`
+				Waveform   []int  `json:"waveform"`
+			} `json:"attachments"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&claimed); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+
+	// Keep the order chosen by the sender
+	byID := make(map[uint]models.MessageAttachment, len(claimed.Data.Attachments))
+	for _, a := range claimed.Data.Attachments {
+		byID[a.ID] = models.MessageAttachment{
+			AttachmentID: a.ID,
+			Kind:         a.Kind,
+			MimeType:     a.MimeType,
+			FileSize:     a.FileSize,
+			Width:        a.Width,
+			Height:       a.Height,
+			DurationMs:   a.DurationMs,
+			Waveform:     a.Waveform,
+		}
+	}
+	attachments := make([]models.MessageAttachment, 0, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		attachment, ok := byID[id]
+		if !ok {
+			return nil, types.ErrAttachmentUnavailable
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// DeleteAttachments calls POST /api/v1/internal/attachments/delete on
+// media-service, which removes the records and the files of the attachments
+// sent in conversationID. Attachments already gone are ignored.
+func (mc *MediaClient) DeleteAttachments(ctx context.Context, conversationID uint, attachmentIDs []uint) error {
+	body, err := json.Marshal(map[string]any{
+		"conversation_id": conversationID,
+		"attachment_ids":  attachmentIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mc.mediaServiceURL+"/api/v1/internal/attachments/delete", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "matcha-internal-service/chat-service")
+	if mc.internalKey != "" {
+		req.Header.Set("X-Internal-Key", mc.internalKey)
+	}
+
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", types.ErrMediaUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", types.ErrMediaUnavailable, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"chat-service/src/logger"
+	"chat-service/src/models"
+	"chat-service/src/types"
+
+	"gorm.io/gorm"
+)
+
+// EditMessage replaces the content of a message sent by userID less than
+// models.MessageEditWindow ago. The previous content goes to the edit history.
+func (s *chatService) EditMessage(ctx context.Context, userID, messageID uint, content string) (*models.Message, error) {
+	message, err := s.visibleMessage(userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != userID {
+		return nil, types.ErrNotMessageAuthor
+	}
+	if message.IsDeleted() {
+		return nil, types.ErrMessageDeleted
+	}
+	if time.Since(message.Time) > models.MessageEditWindow {
+		return nil, types.ErrEditWindowExpired
+	}
+	if err := s.ensureWritable(ctx, userID, message.ConvID); err != nil {
+		return nil, err
+	}
+
+	if content == "" {
+		return nil, errors.New("message cannot be empty")
+	}
+	if len(content) > 1000 {
+		return nil, errors.New("message too long")
+	}
+	if content == message.Msg {
+		return message, nil
+	}
+
+	warning, err := s.screenMessage(ctx, userID, message.ConvID, message.ID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	edited, err := s.repo.EditMessage(message.ID, message.Msg, content, warning)
+	if err != nil {
+		return nil, err
+	}
+	s.refreshLastMessage(edited.ConvID)
+
+	logger.InfoWithContext(logger.WithComponent("chat_service").WithUser(userID).WithMessage(messageID).WithRequest(ctx), "Message edited")
+	return edited, nil
+}
+
+// DeleteMessage hides a message for userID (scope "me"), or unsends it for
+// both participants (scope "everyone", author only)
+func (s *chatService) DeleteMessage(ctx context.Context, userID, messageID uint, scope string) (*models.Message, error) {
+	message, err := s.visibleMessage(userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scope {
+	case models.DeleteForMe:
+		if err := s.repo.HideMessage(messageID, userID); err != nil {
+			return nil, err
+		}
+	case models.DeleteForEveryone:
+		if message.SenderID != userID {
+			return nil, types.ErrNotMessageAuthor
+		}
+		if message.IsDeleted() {
+			return message, nil
+		}
+		// The files go first: an unsent attachment must not stay reachable
+		if err := s.deleteAttachments(ctx, message); err != nil {
+			return nil, err
+		}
+		message, err = s.repo.DeleteMessageForEveryone(messageID)
+		if err != nil {
+			return nil, err
+		}
+		s.refreshLastMessage(message.ConvID)
+	default:
+		return nil, types.ErrInvalidDeleteScope
+	}
+
+	logger.InfoWithContext(logger.WithComponent("chat_service").WithUser(userID).WithMessage(messageID).WithRequest(ctx), "Message deleted for %s", scope)
+	return message, nil
+}
+
+// deleteAttachments removes the files of a message from media-service
+func (s *chatService) deleteAttachments(ctx context.Context, message *models.Message) error {
+	if len(message.Attachments) == 0 {
+		return nil
+	}
+	if s.attachments == nil {
+		return types.ErrMediaUnavailable
+	}
+	attachmentIDs := make([]uint, 0, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		attachmentIDs = append(attachmentIDs, attachment.AttachmentID)
+	}
+	return s.attachments.DeleteAttachments(ctx, message.ConvID, attachmentIDs)
+}
+
+// GetMessageEdits returns the previous versions of a message, oldest first
+func (s *chatService) GetMessageEdits(userID, messageID uint) ([]models.MessageEdit, error) {
+	if _, err := s.visibleMessage(userID, messageID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMessageEdits(messageID)
+}
+
+// visibleMessage loads a message of one of userID's conversations that
+// userID did not delete for themselves
+func (s *chatService) visibleMessage(userID, messageID uint) (*models.Message, error) {
+	message, err := s.repo.GetMessage(messageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, types.ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hasAccess, err := s.repo.IsUserInConversation(userID, message.ConvID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, errors.New("access denied")
+	}
+
+	hidden, err := s.repo.IsMessageHidden(messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if hidden {
+		return nil, types.ErrMessageNotFound
+	}
+	return message, nil
+}
+
+// refreshLastMessage fixes up the conversation preview; a stale preview is
+// not worth failing the change for
+func (s *chatService) refreshLastMessage(conversationID uint) {
+	if err := s.repo.RefreshLastMessage(conversationID); err != nil {
+		logger.WarnWithContext(logger.WithComponent("chat_service").WithConversation(conversationID), "Failed to refresh last message: %v", err)
+	}
+}
@@ -21,8 +21,9 @@ func min(a, b int) int {
 	return b
 }
 
-// SaveMessage saves a new message to the database
-func (ms *MessageService) SaveMessage(senderID, conversationID uint, content string) (*models.Message, error) {
+// SaveMessage saves a new message and its attachments to the database,
+// with the warning screening raised about its content, if any
+func (ms *MessageService) SaveMessage(senderID, conversationID uint, content, warning string, attachments []models.MessageAttachment) (*models.Message, error) {
 	ctx := logger.WithComponent("message_service").
 		WithUser(senderID).
 		WithConversation(conversationID).
@@ -35,7 +36,12 @@ func (ms *MessageService) SaveMessage(senderID, conversationID uint, content str
 		SenderID: senderID,
 		Msg:      content,
 		Time:     time.Now(),
+		Warning:  warning,
 	}
+	for i := range attachments {
+		attachments[i].Position = i
+	}
+	message.Attachments = attachments
 
 	result := conf.DB.Create(&message)
 	if result.Error != nil {
@@ -45,7 +51,7 @@ func (ms *MessageService) SaveMessage(senderID, conversationID uint, content str
 
 	logger.InfoWithContext(ctx.WithMessage(message.ID), "Message saved successfully")
 
-	err := ms.UpdateConversationLastMessage(conversationID, content)
+	err := ms.UpdateConversationLastMessage(conversationID, message.Preview())
 	if err != nil {
 		logger.WarnWithContext(ctx, "Failed to update conversation last message: %v", err)
 	}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"chat-service/src/logger"
+	"chat-service/src/models"
+	"chat-service/src/types"
+)
+
+// maxSyncMessages caps the new messages returned by one sync call
+const maxSyncMessages = 200
+
+// SyncConversation returns what changed in a conversation after the cursor of
+// query (AfterID or Since) so that a reconnecting client can catch up: new
+// messages, edits and unsends of older ones, messages the user deleted for
+// themselves on another device and new reactions.
+func (s *chatService) SyncConversation(userID, conversationID uint, query types.MessageQuery) (*types.SyncResponse, error) {
+	if err := s.ensureParticipant(userID, conversationID); err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 || query.Limit > maxSyncMessages {
+		query.Limit = maxSyncMessages
+	}
+
+	syncedAt := time.Now()
+	since := query.Since
+	if query.AfterID > 0 {
+		cursor, err := s.repo.GetMessage(query.AfterID)
+		if err != nil || cursor.ConvID != conversationID {
+			return nil, types.ErrInvalidSyncCursor
+		}
+		since = cursor.Time
+	}
+
+	limit := query.Limit
+	query.Limit++
+	messages, err := s.repo.GetMessages(conversationID, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	edited, err := s.repo.GetChangedMessages(conversationID, userID, query, since)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.repo.GetHiddenMessageIDs(conversationID, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	reacted, err := s.repo.GetReactedMessageIDs(conversationID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	s.enrichMessages(userID, messages)
+	s.enrichMessages(userID, edited)
+
+	reactions, err := s.repo.GetReactionsSummary(reacted, userID)
+	if err != nil {
+		logger.WarnWithContext(logger.WithComponent("chat_service").WithUser(userID).WithConversation(conversationID), "Failed to load reactions: %v", err)
+		reactions = make(map[uint]models.ReactionSummary)
+	}
+
+	response := &types.SyncResponse{
+		Messages:  messages,
+		Edited:    edited,
+		Deleted:   deleted,
+		Reactions: reactions,
+		HasMore:   hasMore,
+		NextSince: syncedAt.UTC().Format(time.RFC3339Nano),
+	}
+	// Resume paging from the last message until the client caught up
+	if hasMore {
+		response.NextSince = strconv.FormatUint(uint64(messages[len(messages)-1].ID), 10)
+	}
+	return response, nil
+}
+
+// enrichMessages loads the reactions of the messages of a conversation in one
+// query, signs their attachment URLs and hides the read receipts userID may
+// not see. Reactions are not worth failing the read for.
+func (s *chatService) enrichMessages(userID uint, messages []models.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	s.hideReadReceipts(userID, messages[0].ConvID, messages)
+
+	ids := make([]uint, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+	summaries, err := s.repo.GetReactionsSummary(ids, userID)
+	if err != nil {
+		logger.WarnWithContext(logger.WithComponent("chat_service").WithUser(userID), "Failed to load reactions: %v", err)
+	}
+
+	for i := range messages {
+		summary := summaries[messages[i].ID]
+		messages[i].ReactionSummary = summary.Reactions
+		messages[i].Reactions = reactionsFromSummary(summary)
+		s.signer.Sign(messages[i].Attachments)
+	}
+}
+
+// reactionsFromSummary lists the reactions of a summary, grouped by emoji,
+// for the clients reading the reactions field
+func reactionsFromSummary(summary models.ReactionSummary) []models.MessageReaction {
+	emojis := make([]string, 0, len(summary.Reactions))
+	for emoji := range summary.Reactions {
+		emojis = append(emojis, emoji)
+	}
+	sort.Strings(emojis)
+
+	reactions := []models.MessageReaction{}
+	for _, emoji := range emojis {
+		for _, user := range summary.Reactions[emoji].Users {
+			reactions = append(reactions, models.MessageReaction{
+				MessageID: summary.MessageID,
+				UserID:    user,
+				Emoji:     emoji,
+			})
+		}
+	}
+	return reactions
+}
+
+// ensureParticipant checks that userID takes part in the conversation
+func (s *chatService) ensureParticipant(userID, conversationID uint) error {
+	hasAccess, err := s.repo.IsUserInConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if !hasAccess {
+		// Check if conversation exists at all
+		if _, err := s.repo.GetConversation(conversationID); err != nil {
+			return errors.New("conversation not found")
+		}
+		return errors.New("access denied")
+	}
+	return nil
+}
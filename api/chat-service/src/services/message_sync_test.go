@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"chat-service/src/models"
+)
+
+func TestReactionsFromSummary(t *testing.T) {
+	summary := models.ReactionSummary{
+		MessageID: 7,
+		Reactions: map[string]models.ReactionInfo{
+			"😂":  {Count: 1, Users: []uint{2}},
+			"❤️": {Count: 2, Users: []uint{1, 2}, HasUser: true},
+		},
+	}
+
+	reactions := reactionsFromSummary(summary)
+	if len(reactions) != 3 {
+		t.Fatalf("got %d reactions, want 3", len(reactions))
+	}
+	want := []struct {
+		user  uint
+		emoji string
+	}{{1, "❤️"}, {2, "❤️"}, {2, "😂"}}
+	for i, w := range want {
+		r := reactions[i]
+		if r.MessageID != 7 || r.UserID != w.user || r.Emoji != w.emoji {
+			t.Errorf("reaction %d = %+v, want user %d %s", i, r, w.user, w.emoji)
+		}
+	}
+}
+
+func TestReactionsFromEmptySummary(t *testing.T) {
+	reactions := reactionsFromSummary(models.ReactionSummary{})
+	if reactions == nil || len(reactions) != 0 {
+		t.Errorf("got %v, want an empty list", reactions)
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"chat-service/src/types"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+// ModerationClient files automatic reports with user-service
+type ModerationClient struct {
+	userServiceURL string
+	internalKey    string
+	httpClient     *http.Client
+}
+
+// NewModerationClient creates a moderation client (USER_SERVICE_URL, INTERNAL_API_KEY)
+func NewModerationClient() *ModerationClient {
+	url := os.Getenv("USER_SERVICE_URL")
+	if url == "" {
+		url = "http://user-service:8002"
+	}
+	return &ModerationClient{
+		userServiceURL: url,
+		internalKey:    os.Getenv("INTERNAL_API_KEY"),
+		httpClient:     tracing.NewClient(5 * time.Second),
+	}
+}
+
+// ReportUser calls POST /api/v1/internal/reports on user-service
+func (mc *ModerationClient) ReportUser(ctx context.Context, report types.AutoReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mc.userServiceURL+"/api/v1/internal/reports", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "matcha-internal-service/chat-service")
+	if mc.internalKey != "" {
+		req.Header.Set("X-Internal-Key", mc.internalKey)
+	}
+
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("user service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("user service returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -4,76 +4,75 @@ import (
 	"chat-service/src/conf"
 	"chat-service/src/logger"
 	"chat-service/src/models"
+	"chat-service/src/pubsub"
+	"chat-service/src/types"
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
-// NotificationService handles message publishing and notifications
-type NotificationService struct{}
+// notificationQueue is the Redis list of notification requests read by user-service
+const notificationQueue = "notification_requests"
 
-func NewNotificationService() *NotificationService {
-	return &NotificationService{}
+// NotificationService handles message publishing and notifications
+type NotificationService struct {
+	repo types.ChatRepository
 }
 
-// MessageEvent represents a message event for publishing
-type MessageEvent struct {
-	Type           string         `json:"type"`
-	ConversationID uint           `json:"conversation_id"`
-	Message        models.Message `json:"message"`
-	Timestamp      time.Time      `json:"timestamp"`
-	Data           map[string]any `json:"data,omitempty"`
+func NewNotificationService(repo types.ChatRepository) *NotificationService {
+	return &NotificationService{repo: repo}
 }
 
-// PublishMessage publishes a message to Redis channels and sends notifications
-func (ns *NotificationService) PublishMessage(message models.Message, participants []uint) error {
+// PublishMessage publishes a message to Redis channels and sends notifications.
+// Every replica and the gateway relay it to the participants they hold.
+func (ns *NotificationService) PublishMessage(reqCtx context.Context, message models.Message, participants []uint) error {
 	start := time.Now()
 	ctx := logger.WithComponent("notification_service").
 		WithUser(message.SenderID).
 		WithConversation(message.ConvID).
 		WithMessage(message.ID).
-		WithAction("publish_message")
+		WithAction("publish_message").
+		WithRequest(reqCtx)
 
 	logger.InfoWithContext(ctx, "Publishing message to %d participants", len(participants))
 
-	event := MessageEvent{
-		Type:           "new_message",
+	event := types.Event{
+		ID:             types.MessageEventID(message.ID),
+		Origin:         pubsub.Origin,
+		Type:           types.EventNewMessage,
 		ConversationID: message.ConvID,
-		Message:        message,
+		UserID:         message.SenderID,
+		Message:        &message,
 		Timestamp:      time.Now(),
 	}
 
-	eventJSON, err := json.Marshal(event)
+	// Publish to conversation channel
+	payload, err := json.Marshal(event)
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to marshal message event: %v", err)
 		return fmt.Errorf("failed to marshal message event: %w", err)
 	}
-
-	// Publish to conversation channel
 	channel := fmt.Sprintf("conversation:%d", message.ConvID)
-	err = conf.RedisClient.Publish(conf.Ctx, channel, eventJSON).Err()
+	err = conf.RedisClient.Publish(reqCtx, channel, payload).Err()
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to publish to conversation channel: %v", err)
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
 	// Publish to individual user channels
-	publishedCount := 0
-	for _, userID := range participants {
-		userChannel := fmt.Sprintf("user:%d", userID)
-		err = conf.RedisClient.Publish(conf.Ctx, userChannel, eventJSON).Err()
-		if err != nil {
-			logger.ErrorWithContext(ctx.WithUser(userID), "Failed to publish to user channel %s: %v", userChannel, err)
-		} else {
-			publishedCount++
-		}
+	publishedCount, err := pubsub.NewBus(conf.RedisClient).Publish(reqCtx, &event, participants)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to publish to some user channels: %v", err)
 	}
 
 	logger.InfoWithContext(ctx.WithExtra("published_to_users", publishedCount),
 		"Message published to %d/%d user channels", publishedCount, len(participants))
 
 	// Send push notifications
-	err = ns.sendMessageNotification(message, participants)
+	err = ns.sendMessageNotification(reqCtx, message, participants)
 	if err != nil {
 		logger.WarnWithContext(ctx, "Failed to send message notification: %v", err)
 		// Ne pas faire échouer la publication du message si les notifications échouent
@@ -85,17 +84,55 @@ func (ns *NotificationService) PublishMessage(message models.Message, participan
 	return nil
 }
 
-// sendMessageNotification sends push notifications for the message
-func (ns *NotificationService) sendMessageNotification(message models.Message, participants []uint) error {
+// PublishConversationUpdate tells both participants that the conversation
+// status changed (opened on match, read-only on unmatch, archived on block)
+func (ns *NotificationService) PublishConversationUpdate(discussion models.Discussion) error {
+	ctx := logger.WithComponent("notification_service").
+		WithConversation(discussion.ID).
+		WithAction("publish_conversation_update")
+
+	event := types.Event{
+		Type:           types.MessageTypeConversationUpdated,
+		ConversationID: discussion.ID,
+		Timestamp:      time.Now(),
+		Data: map[string]any{
+			"status":        discussion.Status,
+			"closed_reason": discussion.ClosedReason,
+		},
+	}
+
+	participants := []uint{discussion.User1ID, discussion.User2ID}
+	if _, err := pubsub.NewBus(conf.RedisClient).Publish(conf.Ctx, &event, participants); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to publish conversation update: %v", err)
+	}
+
+	logger.InfoWithContext(ctx, "Conversation is now %s", discussion.Status)
+	return nil
+}
+
+// sendMessageNotification queues push notifications for the message, within
+// the trace of the request that sent it
+func (ns *NotificationService) sendMessageNotification(reqCtx context.Context, message models.Message, participants []uint) error {
 	ctx := logger.WithComponent("notification_service").
 		WithUser(message.SenderID).
 		WithConversation(message.ConvID).
 		WithMessage(message.ID).
-		WithAction("send_notification")
+		WithAction("send_notification").
+		WithRequest(reqCtx)
+
+	// Participants who muted the conversation still get the message, not the push
+	muted := make(map[uint]bool)
+	mutedIDs, err := ns.repo.GetMutedParticipants(message.ConvID, time.Now())
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to load muted participants: %v", err)
+	}
+	for _, userID := range mutedIDs {
+		muted[userID] = true
+	}
 
 	recipientCount := 0
 	for _, userID := range participants {
-		if userID != message.SenderID {
+		if userID != message.SenderID && !muted[userID] {
 			recipientCount++
 		}
 	}
@@ -108,13 +145,23 @@ func (ns *NotificationService) sendMessageNotification(message models.Message, p
 		if userID == message.SenderID {
 			continue // Ne pas notifier l'expéditeur
 		}
+		if muted[userID] {
+			logger.DebugWithContext(ctx.WithUser(userID), "🔕 Conversation muted, notification skipped")
+			continue
+		}
 
 		userCtx := ctx.WithUser(userID)
 
 		notification := map[string]any{
-			"to_user_id": userID,
-			"notif_type": "3", // Type 3 = message selon notification_types.py
-			"message":    "Vous avez reçu un nouveau message",
+			"to_user_id":   userID,
+			"kind":         "message",
+			"from_user_id": message.SenderID,
+			"message":      "Vous avez reçu un nouveau message",
+			"data": map[string]any{
+				"conversation_id": message.ConvID,
+				"message_id":      message.ID,
+			},
+			"traceparent": tracing.TraceParent(reqCtx),
 		}
 
 		notificationJSON, err := json.Marshal(notification)
@@ -123,11 +170,11 @@ func (ns *NotificationService) sendMessageNotification(message models.Message, p
 			continue
 		}
 
-		// Publier directement sur le canal Redis du service de notification
-		// C'est exactement ce que fait l'API /notifications/send en interne
-		err = conf.RedisClient.Publish(conf.Ctx, "notifications", notificationJSON).Err()
+		// user-service consumes the queue, stores the notification and
+		// delivers it on the channels the user chose
+		err = conf.RedisClient.LPush(conf.Ctx, notificationQueue, notificationJSON).Err()
 		if err != nil {
-			logger.ErrorWithContext(userCtx, "Failed to publish notification: %v", err)
+			logger.ErrorWithContext(userCtx, "Failed to queue notification: %v", err)
 			continue
 		}
 
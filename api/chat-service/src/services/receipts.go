@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"chat-service/src/logger"
+	"chat-service/src/models"
+	"chat-service/src/types"
+)
+
+// maxReceiptMessages caps the messages acknowledged at once
+const maxReceiptMessages = 100
+
+// MarkMessagesDelivered records that userID received messages of the
+// conversation. The returned receipt, for their sender, is nil when they were
+// all acknowledged before.
+func (s *chatService) MarkMessagesDelivered(userID, conversationID uint, messageIDs []uint) (*types.Receipt, error) {
+	messageIDs = uniqueIDs(messageIDs)
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+	if len(messageIDs) > maxReceiptMessages {
+		return nil, errors.New("too many messages")
+	}
+	if err := s.ensureParticipant(userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	ids, err := s.repo.MarkMessagesDelivered(conversationID, userID, messageIDs, at)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return s.newReceipt(userID, conversationID, models.ReceiptDelivered, ids, at)
+}
+
+// MarkMessagesAsRead records that userID read the messages of the
+// conversation up to upToID, or all of them when it is 0. When userID turned
+// read receipts off the sender is only told the messages were delivered.
+func (s *chatService) MarkMessagesAsRead(userID, conversationID, upToID uint) (*types.Receipt, error) {
+	if err := s.ensureParticipant(userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	ids, err := s.repo.MarkMessagesAsRead(conversationID, userID, upToID, at)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	receipt, err := s.newReceipt(userID, conversationID, models.ReceiptRead, ids, at)
+	if err != nil {
+		return nil, err
+	}
+	if !s.ReadReceiptsVisible(receipt.SenderID, userID) {
+		receipt.Status = models.ReceiptDelivered
+	}
+	return receipt, nil
+}
+
+// GetChatSettings returns the chat settings of the user
+func (s *chatService) GetChatSettings(userID uint) (*models.ChatSettings, error) {
+	return s.repo.GetChatSettings(userID)
+}
+
+// UpdateChatSettings changes the chat settings of the user
+func (s *chatService) UpdateChatSettings(userID uint, req types.ChatSettingsRequest) (*models.ChatSettings, error) {
+	settings, err := s.repo.GetChatSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ReadReceipts != nil {
+		settings.ReadReceipts = *req.ReadReceipts
+	}
+	if err := s.repo.SaveChatSettings(settings); err != nil {
+		return nil, err
+	}
+
+	logger.InfoWithContext(logger.WithComponent("chat_service").WithUser(userID), "Chat settings updated: read_receipts=%t", settings.ReadReceipts)
+	return settings, nil
+}
+
+// hideReadReceipts clears the read time of the messages userID sent when the
+// other participant of the conversation does not share read receipts with them
+func (s *chatService) hideReadReceipts(userID, conversationID uint, messages []models.Message) {
+	read := false
+	for i := range messages {
+		if messages[i].SenderID == userID && messages[i].ReadAt != nil {
+			read = true
+			break
+		}
+	}
+	if !read {
+		return
+	}
+
+	reader, err := s.otherParticipant(userID, conversationID)
+	if err == nil && s.ReadReceiptsVisible(userID, reader) {
+		return
+	}
+	for i := range messages {
+		if messages[i].SenderID == userID {
+			messages[i].ReadAt = nil
+		}
+	}
+}
+
+// ReadReceiptsVisible reports whether senderID may see when readerID read
+// their messages. Failing lookups hide the receipts.
+func (s *chatService) ReadReceiptsVisible(senderID, readerID uint) bool {
+	settings, err := s.repo.GetChatSettings(readerID)
+	if err != nil || !settings.ReadReceipts {
+		return false
+	}
+	if !s.receiptsPremium {
+		return true
+	}
+	premium, err := s.repo.IsPremium(senderID)
+	return err == nil && premium
+}
+
+func (s *chatService) newReceipt(userID, conversationID uint, status string, messageIDs []uint, at time.Time) (*types.Receipt, error) {
+	sender, err := s.otherParticipant(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Receipt{
+		ConversationID: conversationID,
+		SenderID:       sender,
+		UserID:         userID,
+		Status:         status,
+		MessageIDs:     messageIDs,
+		At:             at,
+	}, nil
+}
+
+// otherParticipant returns the participant of the conversation other than userID
+func (s *chatService) otherParticipant(userID, conversationID uint) (uint, error) {
+	participants, err := s.repo.GetConversationParticipants(conversationID)
+	if err != nil {
+		return 0, err
+	}
+	for _, participant := range participants {
+		if participant != userID {
+			return participant, nil
+		}
+	}
+	return 0, errors.New("conversation has no other participant")
+}
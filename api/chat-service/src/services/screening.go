@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"chat-service/src/logger"
+	"chat-service/src/screening"
+	"chat-service/src/types"
+)
+
+// Bounds of the description of an automatic report, which user-service
+// caps at 500 characters
+const (
+	maxReportExcerpt     = 300
+	maxReportDescription = 500
+)
+
+// reportTypes maps screening rules to the report types of user-service
+var reportTypes = map[string]string{
+	screening.RuleHarassment: "harassment",
+	screening.RuleDuplicate:  "spam",
+	screening.RuleMass:       "spam",
+	screening.RuleProfanity:  "inappropriate_content",
+	screening.RuleContact:    "inappropriate_content",
+}
+
+// screenMessage runs content screening on a message senderID writes in the
+// conversation. It returns the warning to store with a message the recipient
+// should see blurred, or ErrMessageBlocked. beforeID is the message being
+// edited, 0 for a new one.
+func (s *chatService) screenMessage(ctx context.Context, senderID, conversationID, beforeID uint, content string) (string, error) {
+	if s.screener == nil || content == "" {
+		return "", nil
+	}
+
+	logCtx := logger.WithComponent("chat_service").WithUser(senderID).WithConversation(conversationID).WithRequest(ctx)
+	prior, err := s.repo.CountSentMessages(conversationID, senderID, beforeID)
+	if err != nil {
+		// Treat the message as one of the first rather than skipping the checks
+		logger.WarnWithContext(logCtx, "Failed to count sent messages for screening: %v", err)
+	}
+
+	verdict := s.screener.Screen(ctx, screening.Input{
+		SenderID:       senderID,
+		ConversationID: conversationID,
+		Content:        content,
+		PriorMessages:  int(prior),
+		Edit:           beforeID > 0,
+	})
+
+	switch verdict.Action {
+	case screening.Allow:
+		return "", nil
+	case screening.Warn:
+		return verdict.Rule(), nil
+	case screening.Report:
+		s.reportSender(ctx, senderID, conversationID, verdict, content)
+	}
+	logger.InfoWithContext(logCtx.WithAction("message_blocked"), "Message blocked by screening: %s", verdict.Rule())
+	return "", types.ErrMessageBlocked
+}
+
+// reportSender files a report against senderID on behalf of the other
+// participant, in the background: the message is blocked either way
+func (s *chatService) reportSender(ctx context.Context, senderID, conversationID uint, verdict screening.Verdict, content string) {
+	if s.reporter == nil {
+		return
+	}
+	logCtx := logger.WithComponent("chat_service").WithUser(senderID).WithConversation(conversationID).WithRequest(ctx)
+
+	recipient, err := s.otherParticipant(senderID, conversationID)
+	if err != nil {
+		logger.WarnWithContext(logCtx, "Failed to find who to report on behalf of: %v", err)
+		return
+	}
+
+	rule := verdict.Rule()
+	reportType, ok := reportTypes[rule]
+	if !ok {
+		reportType = "other"
+	}
+	report := types.AutoReport{
+		ReporterID:  recipient,
+		ReportedID:  senderID,
+		ReportType:  reportType,
+		Description: reportDescription(verdict, content),
+	}
+
+	// The report outlives the request, in the same trace
+	reportCtx := context.WithoutCancel(ctx)
+	go func() {
+		if err := s.reporter.ReportUser(reportCtx, report); err != nil {
+			logger.ErrorWithContext(logCtx, "Failed to report sender of blocked message: %v", err)
+			return
+		}
+		logger.InfoWithContext(logCtx.WithAction("sender_reported"), "Sender reported to moderation: %s", rule)
+	}()
+}
+
+// reportDescription tells moderators why the message was blocked, quoting its
+// beginning
+func reportDescription(verdict screening.Verdict, content string) string {
+	detail := ""
+	for _, flag := range verdict.Flags {
+		if flag.Action == verdict.Action {
+			detail = flag.Detail
+			break
+		}
+	}
+	if utf8.RuneCountInString(content) > maxReportExcerpt {
+		content = string([]rune(content)[:maxReportExcerpt]) + "…"
+	}
+	description := fmt.Sprintf("Message blocked automatically (%s: %s): %q", verdict.Rule(), detail, content)
+	if utf8.RuneCountInString(description) > maxReportDescription {
+		description = string([]rune(description)[:maxReportDescription])
+	}
+	return description
+}
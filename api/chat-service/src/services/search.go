@@ -0,0 +1,85 @@
+package services
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"chat-service/src/logger"
+	"chat-service/src/types"
+)
+
+// Bounds of a search
+const (
+	minSearchLength    = 2
+	maxSearchLength    = 200
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// SearchMessages searches the messages of the conversations of userID, newest
+// first. Each result carries a highlighted snippet, the other participant of
+// the conversation and the messages sent just before and after.
+func (s *chatService) SearchMessages(userID uint, query types.SearchQuery) (*types.SearchResponse, error) {
+	query.Text = strings.TrimSpace(query.Text)
+	if n := utf8.RuneCountInString(query.Text); n < minSearchLength || n > maxSearchLength {
+		return nil, types.ErrInvalidSearch
+	}
+	if query.Limit <= 0 || query.Limit > maxSearchLimit {
+		query.Limit = defaultSearchLimit
+	}
+	if query.ConversationID > 0 {
+		if err := s.ensureParticipant(userID, query.ConversationID); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := query.Limit
+	query.Limit++
+	results, err := s.repo.SearchMessages(userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.SearchResponse{Results: results}
+	if len(results) > limit {
+		response.Results = results[:limit]
+		response.HasMore = true
+		response.NextBeforeID = response.Results[limit-1].MessageID
+	}
+
+	s.enrichSearchResults(userID, response.Results)
+	return response, nil
+}
+
+// enrichSearchResults highlights the snippets and adds the other participant
+// of each conversation. Missing user info is not worth failing the search for.
+func (s *chatService) enrichSearchResults(userID uint, results []types.SearchResult) {
+	userIDs := make([]uint, 0, len(results))
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+		userIDs = append(userIDs, results[i].OtherUserID)
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	users, err := s.repo.GetUsersInfo(uniqueIDs(userIDs))
+	if err != nil {
+		logger.WarnWithContext(logger.WithComponent("chat_service").WithUser(userID), "Failed to load users of search results: %v", err)
+		return
+	}
+	for i := range results {
+		results[i].OtherUser = users[results[i].OtherUserID]
+	}
+}
+
+// highlightSnippet escapes a snippet for HTML and turns the match delimiters
+// into <mark> tags
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(
+		types.SearchMatchStart, "<mark>",
+		types.SearchMatchEnd, "</mark>",
+	).Replace(escaped)
+}
@@ -0,0 +1,16 @@
+package services
+
+import (
+	"testing"
+
+	"chat-service/src/types"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	snippet := "on se voit " + types.SearchMatchStart + "demain" + types.SearchMatchEnd + " <script>?"
+	got := highlightSnippet(snippet)
+	want := "on se voit <mark>demain</mark> &lt;script&gt;?"
+	if got != want {
+		t.Errorf("highlightSnippet() = %q, want %q", got, want)
+	}
+}
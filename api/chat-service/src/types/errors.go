@@ -0,0 +1,42 @@
+package types
+
+import "errors"
+
+var (
+	// ErrNotMatched is returned when two users without an active match try to chat
+	ErrNotMatched = errors.New("you can only chat with your matches")
+	// ErrConversationClosed is returned when writing to a read-only or archived conversation
+	ErrConversationClosed = errors.New("conversation is read-only")
+	// ErrMatchCheckUnavailable is returned when match-service cannot confirm the match
+	ErrMatchCheckUnavailable = errors.New("unable to verify match status")
+	// ErrMessageNotFound is returned when the message does not exist or is hidden for the user
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrNotMessageAuthor is returned when editing or unsending someone else's message
+	ErrNotMessageAuthor = errors.New("you can only change your own messages")
+	// ErrEditWindowExpired is returned when editing a message after models.MessageEditWindow
+	ErrEditWindowExpired = errors.New("message can no longer be edited")
+	// ErrMessageDeleted is returned when editing a message deleted for everyone
+	ErrMessageDeleted = errors.New("message was deleted")
+	// ErrInvalidDeleteScope is returned for a deletion scope other than "me" or "everyone"
+	ErrInvalidDeleteScope = errors.New("scope must be \"me\" or \"everyone\"")
+	// ErrTooManyAttachments is returned when a message carries more than models.MaxAttachments files
+	ErrTooManyAttachments = errors.New("too many attachments")
+	// ErrAttachmentNotFound is returned when the attachment was not sent in a conversation of the user
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentUnavailable is returned when an attachment was not uploaded by the sender or was already sent
+	ErrAttachmentUnavailable = errors.New("attachment not found or already sent")
+	// ErrMediaUnavailable is returned when media-service cannot be reached
+	ErrMediaUnavailable = errors.New("unable to reach media service")
+	// ErrInvalidSearch is returned for a search query too short or too long
+	ErrInvalidSearch = errors.New("search query must be between 2 and 200 characters")
+	// ErrInvalidSyncCursor is returned when the sync cursor is not a message of the conversation
+	ErrInvalidSyncCursor = errors.New("since must be a message of the conversation or an RFC 3339 time")
+	// ErrInvalidMuteUntil is returned when muted_until is not an RFC 3339 time
+	ErrInvalidMuteUntil = errors.New("muted_until must be an RFC 3339 time")
+	// ErrPinArchived is returned when pinning a conversation that stays archived
+	ErrPinArchived = errors.New("an archived conversation cannot be pinned")
+	// ErrTooManyPinned is returned when the user already pinned MaxPinnedConversations conversations
+	ErrTooManyPinned = errors.New("you can pin up to 3 conversations")
+	// ErrMessageBlocked is returned when content screening rejects a message
+	ErrMessageBlocked = errors.New("message was blocked by content screening")
+)
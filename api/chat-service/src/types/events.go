@@ -0,0 +1,53 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"chat-service/src/models"
+)
+
+// Redis channels carrying real-time events between replicas. Every event is
+// published on the user:<id> channel of each recipient; presence updates go
+// to everyone connected and use a single broadcast channel.
+const (
+	UserChannelPattern = "user:*"
+	PresenceChannel    = "chat:presence"
+)
+
+// Event types published on Redis
+const (
+	EventNewMessage       = "new_message"
+	EventTyping           = "typing"
+	EventReactionUpdate   = "reaction_update"
+	EventPresenceUpdate   = "presence_update"
+	EventMessageEdited    = "message_edited"
+	EventMessageDeleted   = "message_deleted"
+	EventMessageDelivered = "message_delivered"
+	EventMessageRead      = "message_read"
+	EventConvSettings     = "conversation_settings"
+)
+
+// Event is the payload published on Redis. ID is unique per event so that
+// subscribers can drop copies of an event already delivered locally.
+type Event struct {
+	ID             string          `json:"event_id"`
+	Origin         string          `json:"origin,omitempty"`
+	Type           string          `json:"type"`
+	ConversationID uint            `json:"conversation_id,omitempty"`
+	UserID         uint            `json:"user_id,omitempty"` // user the event is about (sender, typist, ...)
+	Message        *models.Message `json:"message,omitempty"`
+	Timestamp      time.Time       `json:"timestamp"`
+	Data           any             `json:"data,omitempty"`
+}
+
+// UserChannel returns the Redis channel of a user
+func UserChannel(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// MessageEventID is the event ID of a new message. It is derived from the
+// message so that every path delivering the message shares the same ID.
+func MessageEventID(messageID uint) string {
+	return fmt.Sprintf("message:%d", messageID)
+}
@@ -2,13 +2,15 @@ package types
 
 import (
 	"chat-service/src/models"
+	"chat-service/src/screening"
 	"context"
+	"time"
 )
 
 // ChatRepository defines database operations interface
 type ChatRepository interface {
 	// Conversation operations
-	GetUserConversations(userID uint) ([]models.Discussion, error)
+	GetUserConversations(userID uint, archived bool) ([]models.Discussion, error) // pinned first, then by last message
 	GetConversation(conversationID uint) (*models.Discussion, error)
 	CreateConversation(user1ID, user2ID uint) (*models.Discussion, error)
 	FindConversationBetweenUsers(user1ID, user2ID uint) (*models.Discussion, error)
@@ -16,16 +18,31 @@ type ChatRepository interface {
 	IsUserInConversation(userID, conversationID uint) (bool, error)
 	GetConversationParticipants(conversationID uint) ([]uint, error)
 	UpdateLastMessage(conversationID uint, content string) error
+	SetConversationStatus(conversationID uint, status, reason string) error
+	MarkMatchChecked(conversationID uint) error // refreshes the sync time of an open conversation
 
 	// User operations (for enriching conversations)
 	GetUserInfo(userID uint) (*UserInfo, error)
 	GetUsersInfo(userIDs []uint) (map[uint]*UserInfo, error)
 
 	// Message operations
-	GetMessages(conversationID uint, limit, offset int) ([]models.Message, error)
+	GetMessages(conversationID, userID uint, query MessageQuery) ([]models.Message, error) // without the ones userID deleted for themselves
+	GetChangedMessages(conversationID, userID uint, until MessageQuery, since time.Time) ([]models.Message, error)
+	GetHiddenMessageIDs(conversationID, userID uint, since time.Time) ([]uint, error)
+	GetReactedMessageIDs(conversationID uint, since time.Time) ([]uint, error)
+	SearchMessages(userID uint, query SearchQuery) ([]SearchResult, error) // snippets delimited by SearchMatchStart/SearchMatchEnd
 	GetMessage(messageID uint) (*models.Message, error)
 	SaveMessage(senderID, conversationID uint, content string) (*models.Message, error)
-	MarkMessagesAsRead(conversationID, userID uint) error
+	EditMessage(messageID uint, previous, content, warning string) (*models.Message, error)
+	CountSentMessages(conversationID, senderID, beforeID uint) (int64, error) // beforeID 0 counts them all
+	GetMessageEdits(messageID uint) ([]models.MessageEdit, error)
+	DeleteMessageForEveryone(messageID uint) (*models.Message, error)
+	HideMessage(messageID, userID uint) error
+	IsMessageHidden(messageID, userID uint) (bool, error)
+	RefreshLastMessage(conversationID uint) error
+	GetMessageAttachment(attachmentID uint) (*models.MessageAttachment, error)
+	MarkMessagesDelivered(conversationID, userID uint, messageIDs []uint, at time.Time) ([]uint, error)
+	MarkMessagesAsRead(conversationID, userID, upToID uint, at time.Time) ([]uint, error) // upToID 0 marks them all
 	GetUnreadCount(conversationID, userID uint) (int64, error)
 
 	// Reaction operations
@@ -39,11 +56,50 @@ type ChatRepository interface {
 	GetUserPresence(userID uint) (*models.UserPresence, error)
 	GetUsersPresence(userIDs []uint) ([]models.UserPresence, error)
 	SetUserOffline(userID uint) error
+
+	// Settings operations
+	GetChatSettings(userID uint) (*models.ChatSettings, error) // models.DefaultChatSettings when never saved
+	SaveChatSettings(settings *models.ChatSettings) error
+	IsPremium(userID uint) (bool, error)
+	GetConversationSettings(userID uint, conversationIDs []uint) (map[uint]models.ConversationSettings, error) // only the conversations with settings
+	SaveConversationSettings(settings *models.ConversationSettings) error
+	PinConversation(settings *models.ConversationSettings, maxPinned int64) (bool, error)
+	GetMutedParticipants(conversationID uint, at time.Time) ([]uint, error)
+}
+
+// MatchChecker confirms that two users have an active match
+type MatchChecker interface {
+	AreMatched(ctx context.Context, user1ID, user2ID uint) (bool, error)
+}
+
+// AttachmentClaimer attaches files uploaded to media-service to a conversation,
+// and deletes them when their message is deleted for everyone
+type AttachmentClaimer interface {
+	ClaimAttachments(ctx context.Context, userID, conversationID uint, attachmentIDs []uint) ([]models.MessageAttachment, error)
+	DeleteAttachments(ctx context.Context, conversationID uint, attachmentIDs []uint) error
+}
+
+// MessageScreener checks the content of a message before it is saved
+type MessageScreener interface {
+	Screen(ctx context.Context, input screening.Input) screening.Verdict
+}
+
+// ModerationReporter files reports to the moderation queue of user-service
+type ModerationReporter interface {
+	ReportUser(ctx context.Context, report AutoReport) error
+}
+
+// AutoReport is a report filed on behalf of the recipient of a blocked message
+type AutoReport struct {
+	ReporterID  uint   `json:"reporter_id"`
+	ReportedID  uint   `json:"reported_id"`
+	ReportType  string `json:"report_type"`
+	Description string `json:"description"`
 }
 
 // MessagePublisher handles message broadcasting
 type MessagePublisher interface {
-	PublishMessage(message models.Message, participants []uint) error
+	PublishMessage(ctx context.Context, message models.Message, participants []uint) error
 	NotifyOnline(userIDs []uint, message any) error
 }
 
@@ -80,16 +136,31 @@ type NotificationService interface {
 // ChatService combines all chat operations
 type ChatService interface {
 	// Conversation methods
-	GetUserConversations(userID uint) (*ConversationListResponse, error)
+	GetUserConversations(userID uint, archived bool) (*ConversationListResponse, error)
 	GetConversation(userID, conversationID uint) (*ConversationResponse, error)
-	CreateConversation(user1ID, user2ID uint) (*models.Discussion, error)
+	GetConversationSettings(userID, conversationID uint) (*models.ConversationSettings, error)
+	UpdateConversationSettings(userID, conversationID uint, req ConversationSettingsRequest) (*models.ConversationSettings, error)
+	CreateConversation(ctx context.Context, user1ID, user2ID uint) (*models.Discussion, error)
 	DeleteConversation(userID, targetUserID uint) error
+	OpenMatchConversation(user1ID, user2ID uint) (*models.Discussion, error)
+	CloseMatchConversation(user1ID, user2ID uint, reason string) (*models.Discussion, error)
 	
 	// Message methods
-	GetMessages(userID, conversationID uint, limit, offset int) ([]models.Message, error)
+	GetMessages(userID, conversationID uint, query MessageQuery) ([]models.Message, error)
+	SyncConversation(userID, conversationID uint, query MessageQuery) (*SyncResponse, error)
+	SearchMessages(userID uint, query SearchQuery) (*SearchResponse, error)
 	GetMessage(messageID uint) (*models.Message, error)
-	SendMessage(senderID, conversationID uint, content string) (*models.Message, error)
-	MarkMessagesAsRead(userID, conversationID uint) error
+	SendMessage(ctx context.Context, senderID, conversationID uint, content string) (*models.Message, error)
+	SendMessageWithAttachments(ctx context.Context, senderID, conversationID uint, content string, attachmentIDs []uint) (*models.Message, error)
+	GetAttachment(userID, attachmentID uint) (*models.MessageAttachment, error)
+	EditMessage(ctx context.Context, userID, messageID uint, content string) (*models.Message, error)
+	DeleteMessage(ctx context.Context, userID, messageID uint, scope string) (*models.Message, error)
+	GetMessageEdits(userID, messageID uint) ([]models.MessageEdit, error)
+	MarkMessagesDelivered(userID, conversationID uint, messageIDs []uint) (*Receipt, error)
+	MarkMessagesAsRead(userID, conversationID, upToID uint) (*Receipt, error)
+	GetChatSettings(userID uint) (*models.ChatSettings, error)
+	UpdateChatSettings(userID uint, req ChatSettingsRequest) (*models.ChatSettings, error)
+	ReadReceiptsVisible(senderID, readerID uint) bool
 
 	// Reaction methods
 	AddReaction(userID, messageID uint, emoji string) (*models.MessageReaction, error)
@@ -103,7 +174,7 @@ type ChatService interface {
 
 	// Real-time methods
 	HandleConnection(userID uint, conn WebSocketConnection) error
-	BroadcastMessage(message models.Message) error
+	BroadcastMessage(ctx context.Context, message models.Message) error
 }
 
 // TokenClaims represents JWT token claims
@@ -129,4 +200,5 @@ const (
 	MessageTypeReaction     = "reaction"
 	MessageTypeReactionAdd  = "reaction_add"
 	MessageTypeReactionRemove = "reaction_remove"
+	MessageTypeConversationUpdated = "conversation_updated"
 )
\ No newline at end of file
@@ -1,16 +1,82 @@
 package types
 
+import "time"
+
 // ConversationRequest represents request to create a conversation
 type ConversationRequest struct {
 	UserID uint `json:"user_id" binding:"required,min=1"`
 }
 
-// MessageRequest represents request to send a message
+// MessageRequest represents request to send a message. The text can be
+// empty when the message carries attachments.
 type MessageRequest struct {
 	ConversationID uint   `json:"conversation_id" binding:"required,min=1"`
-	Message        string `json:"message" binding:"required,min=1,max=1000"`
+	Message        string `json:"message" binding:"max=1000"`
+	AttachmentIDs  []uint `json:"attachment_ids" binding:"max=10"`
+}
+
+// EditMessageRequest represents request to edit a message
+type EditMessageRequest struct {
+	Message string `json:"message" binding:"required,min=1,max=1000"`
+}
+
+// MessageQuery selects a page of messages. With BeforeID the page holds the
+// messages older than that id, newest first; with AfterID or Since it holds
+// the next messages in chronological order. Otherwise it is the latest
+// messages, newest first, skipping Offset.
+type MessageQuery struct {
+	Limit    int
+	Offset   int
+	BeforeID uint
+	AfterID  uint
+	Since    time.Time
+}
+
+// Forward reports whether the page is read oldest first
+func (q MessageQuery) Forward() bool {
+	return q.AfterID > 0 || !q.Since.IsZero()
+}
+
+// ChatSettingsRequest represents request to change the chat settings
+type ChatSettingsRequest struct {
+	ReadReceipts *bool `json:"read_receipts" binding:"required"`
 }
 
+// ConversationSettingsRequest represents request to change the settings of
+// a conversation. Omitted fields are left as they are; an empty muted_until
+// unmutes the conversation.
+type ConversationSettingsRequest struct {
+	MutedUntil *string `json:"muted_until"` // RFC 3339
+	Pinned     *bool   `json:"pinned"`
+	Archived   *bool   `json:"archived"`
+}
+
+// DeliveredRequest represents request to acknowledge the receipt of messages
+type DeliveredRequest struct {
+	MessageIDs []uint `json:"message_ids" binding:"required,min=1,max=100"`
+}
+
+// ReadRequest represents request to mark messages as read, up to MessageID
+// or all of them when it is omitted
+type ReadRequest struct {
+	MessageID uint `json:"message_id"`
+}
+
+// SearchQuery selects a page of search results, newest first. BeforeID is the
+// last message of the previous page; ConversationID narrows the search.
+type SearchQuery struct {
+	Text           string
+	ConversationID uint
+	BeforeID       uint
+	Limit          int
+}
+
+// Delimiters of the matches in the snippets returned by ChatRepository.SearchMessages
+const (
+	SearchMatchStart = "\x02"
+	SearchMatchEnd   = "\x03"
+)
+
 // PaginationRequest represents pagination parameters
 type PaginationRequest struct {
 	Limit  int `form:"limit" binding:"omitempty,min=1,max=100"`
@@ -14,6 +14,11 @@ type ConversationResponse struct {
 	LastMessageAt      *time.Time          `json:"last_message_at"`
 	UnreadCount        int64               `json:"unread_count"`
 	OtherUser          *UserInfo           `json:"other_user"`
+	Status             string              `json:"status"`
+	ClosedReason       string              `json:"closed_reason,omitempty"`
+	MutedUntil         *time.Time          `json:"muted_until"`
+	Pinned             bool                `json:"pinned"`
+	Archived           bool                `json:"archived"`
 	CreatedAt          time.Time           `json:"created_at"`
 }
 
@@ -37,6 +42,54 @@ type UserInfo struct {
 	Avatar    string `json:"avatar,omitempty"`
 }
 
+// Receipt tells SenderID that UserID received or read some of their messages
+type Receipt struct {
+	ConversationID uint      `json:"conversation_id"`
+	SenderID       uint      `json:"-"`
+	UserID         uint      `json:"user_id"`
+	Status         string    `json:"status"` // models.ReceiptDelivered or models.ReceiptRead
+	MessageIDs     []uint    `json:"message_ids"`
+	At             time.Time `json:"at"`
+}
+
+// SyncResponse holds what changed in a conversation since a sync cursor
+type SyncResponse struct {
+	Messages  []models.Message                `json:"messages"`  // sent after the cursor, oldest first
+	Edited    []models.Message                `json:"edited"`    // sent before the cursor, edited or unsent since
+	Deleted   []uint                          `json:"deleted"`   // deleted by the user for themselves since
+	Reactions map[uint]models.ReactionSummary `json:"reactions"` // current reactions of the messages that got new ones
+	HasMore   bool                            `json:"has_more"`  // more new messages, fetch again with next_since
+	NextSince string                          `json:"next_since"`
+}
+
+// SearchResult is a message matching a search, with the messages around it
+type SearchResult struct {
+	MessageID      uint            `json:"message_id"`
+	ConversationID uint            `json:"conversation_id"`
+	SenderID       uint            `json:"sender_id"`
+	Time           time.Time       `json:"time"`
+	Snippet        string          `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+	OtherUserID    uint            `json:"other_user_id"`
+	OtherUser      *UserInfo       `json:"other_user,omitempty"`
+	Previous       *ContextMessage `json:"previous,omitempty"`
+	Next           *ContextMessage `json:"next,omitempty"`
+}
+
+// ContextMessage is a message shown around a search result
+type ContextMessage struct {
+	ID       uint      `json:"id"`
+	SenderID uint      `json:"sender_id"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// SearchResponse represents a page of search results
+type SearchResponse struct {
+	Results      []SearchResult `json:"results"`
+	HasMore      bool           `json:"has_more"`
+	NextBeforeID uint           `json:"next_before_id,omitempty"` // before_id of the next page
+}
+
 // MessagesResponse represents paginated messages
 type MessagesResponse struct {
 	Messages []MessageResponse `json:"messages"`
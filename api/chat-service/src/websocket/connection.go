@@ -3,17 +3,27 @@ package websocket
 import (
 	"chat-service/src/logger"
 	"chat-service/src/types"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Connection wraps a WebSocket connection with user info
+// Connection wraps a WebSocket connection with user info. A user may hold
+// several connections at once (one per device), told apart by their ID.
 type Connection struct {
+	id           string
 	conn         *websocket.Conn
 	userID       uint
 	send         chan OutgoingMessage
+	closed       bool
 	hub          *Hub
 	mutex        sync.Mutex
 	lastPing     time.Time
@@ -26,6 +36,7 @@ type Connection struct {
 // NewConnection creates a new WebSocket connection
 func NewConnection(conn *websocket.Conn, userID uint, hub *Hub) *Connection {
 	return &Connection{
+		id:           newConnectionID(),
 		conn:         conn,
 		userID:       userID,
 		send:         make(chan OutgoingMessage, 256),
@@ -35,6 +46,20 @@ func NewConnection(conn *websocket.Conn, userID uint, hub *Hub) *Connection {
 	}
 }
 
+// newConnectionID returns a random identifier for a device connection
+func newConnectionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// ID returns the connection identifier
+func (c *Connection) ID() string {
+	return c.id
+}
+
 // WriteMessage implements types.WebSocketConnection
 func (c *Connection) WriteMessage(messageType int, data []byte) error {
 	c.mutex.Lock()
@@ -49,12 +74,18 @@ func (c *Connection) ReadMessage() (int, []byte, error) {
 	return c.conn.ReadMessage()
 }
 
-// Close implements types.WebSocketConnection
+// Close implements types.WebSocketConnection. Safe to call more than once.
 func (c *Connection) Close() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	close(c.send)
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+	if c.conn == nil {
+		return nil
+	}
 	return c.conn.Close()
 }
 
@@ -110,7 +141,11 @@ func (c *Connection) readPump(chatService types.ChatService) {
 			break
 		}
 
-		if err := c.handleMessage(msg, chatService); err != nil {
+		msgCtx, span := tracing.StartMessage(context.Background(), "", string(msg.Type))
+		span.SetAttributes(attribute.String("enduser.id", strconv.FormatUint(uint64(c.userID), 10)))
+		err = c.handleMessage(msgCtx, msg, chatService)
+		tracing.End(span, err)
+		if err != nil {
 			c.sendError("HANDLE_ERROR", err.Error())
 		}
 	}
@@ -144,11 +179,19 @@ func (c *Connection) writePump() {
 	}
 }
 
-// sendMessage sends a message to this connection
+// sendMessage sends a message to this connection. A client too slow to
+// drain its buffer is dropped.
 func (c *Connection) sendMessage(msg OutgoingMessage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return
+	}
 	select {
 	case c.send <- msg:
 	default:
+		c.closed = true
 		close(c.send)
 	}
 }
@@ -167,6 +210,23 @@ func (c *Connection) sendError(code, message string) {
 
 // cleanup handles connection cleanup
 func (c *Connection) cleanup() {
-	c.hub.unregister <- c
+	c.hub.UnregisterConnection(c)
 	c.conn.Close()
+}
+
+// closeForRestart closes the connection when the service stops. The read
+// pump then fails and unregisters it.
+func (c *Connection) closeForRestart() {
+	if c.conn != nil {
+		closeForRestart(c.conn)
+	}
+	c.Close()
+}
+
+// closeForRestart sends a service restart close frame (1012) telling the
+// client when to reconnect, then closes conn
+func closeForRestart(conn *websocket.Conn) {
+	frame := websocket.FormatCloseMessage(websocket.CloseServiceRestart, server.ReconnectHint())
+	conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+	conn.Close()
 }
\ No newline at end of file
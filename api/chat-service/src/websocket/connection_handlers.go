@@ -2,12 +2,14 @@ package websocket
 
 import (
 	"chat-service/src/logger"
+	"chat-service/src/models"
 	"chat-service/src/types"
+	"context"
 	"time"
 )
 
 // handleMessage processes incoming messages
-func (c *Connection) handleMessage(msg IncomingMessage, chatService types.ChatService) error {
+func (c *Connection) handleMessage(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
 	// Check rate limiting for send messages
 	if msg.Type == MessageTypeSend {
 		if !c.checkRateLimit() {
@@ -17,75 +19,116 @@ func (c *Connection) handleMessage(msg IncomingMessage, chatService types.ChatSe
 
 	switch msg.Type {
 	case MessageTypeSend:
-		return c.handleSendMessage(msg, chatService)
+		return c.handleSendMessage(ctx, msg, chatService)
 	case MessageTypeJoin:
-		return c.handleJoinConversation(msg, chatService)
+		return c.handleJoinConversation(ctx, msg, chatService)
 	case MessageTypeTyping:
-		return c.handleTyping(msg)
+		return c.handleTyping(ctx, msg)
 	case MessageTypeReactionAdd:
-		return c.handleReactionAdd(msg, chatService)
+		return c.handleReactionAdd(ctx, msg, chatService)
 	case MessageTypeReactionRemove:
-		return c.handleReactionRemove(msg, chatService)
+		return c.handleReactionRemove(ctx, msg, chatService)
+	case MessageTypeEditMessage:
+		return c.handleEditMessage(ctx, msg, chatService)
+	case MessageTypeDeleteMessage:
+		return c.handleDeleteMessage(ctx, msg, chatService)
+	case MessageTypeAckDelivered:
+		return c.handleAckDelivered(ctx, msg, chatService)
+	case MessageTypeAckRead:
+		return c.handleAckRead(ctx, msg, chatService)
 	default:
 		return ErrUnknownMessageType
 	}
 }
 
 // handleSendMessage processes send message requests
-func (c *Connection) handleSendMessage(msg IncomingMessage, chatService types.ChatService) error {
-	if msg.ConversationID == 0 || msg.Content == "" {
+func (c *Connection) handleSendMessage(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
+	if msg.ConversationID == 0 || (msg.Content == "" && len(msg.AttachmentIDs) == 0) {
 		return ErrInvalidMessage
 	}
 
-	message, err := chatService.SendMessage(c.userID, msg.ConversationID, msg.Content)
+	message, err := chatService.SendMessageWithAttachments(ctx, c.userID, msg.ConversationID, msg.Content, msg.AttachmentIDs)
 	if err != nil {
-		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("send_message"), "Failed to send chat message: %v", err)
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("send_message"), "Failed to send chat message: %v", err)
 		return err
 	}
 
-	// Send confirmation to sender
-	c.sendMessage(OutgoingMessage{
+	newMessage := OutgoingMessage{
 		Type:           MessageTypeNewMessage,
 		ConversationID: msg.ConversationID,
-		Data: MessageData{
-			ID:        message.ID,
-			SenderID:  message.SenderID,
-			Message:   message.Msg,
-			Timestamp: message.Time,
-			ReadAt:    message.ReadAt,
-		},
+		Data:           newMessageData(message),
 		Timestamp: time.Now(),
-	})
+	}
+
+	// Send confirmation to sender, then to the other devices and participants
+	// connected here. The chat service already published the message on
+	// Redis for the other replicas; its echo is dropped by event ID.
+	eventID := types.MessageEventID(message.ID)
+	c.hub.deliverTo(c, eventID, newMessage)
+	c.hub.DeliverToConversation(eventID, msg.ConversationID, newMessage)
 
 	// Update monitoring stats (would normally be done via callback or interface)
-	logger.InfoWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithConversation(msg.ConversationID).WithAction("message_sent"), "Message sent successfully")
+	logger.InfoWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithConversation(msg.ConversationID).WithAction("message_sent"), "Message sent successfully")
 
 	return nil
 }
 
 // handleJoinConversation processes join conversation requests
-func (c *Connection) handleJoinConversation(msg IncomingMessage, chatService types.ChatService) error {
+func (c *Connection) handleJoinConversation(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
 	if msg.ConversationID == 0 {
 		return ErrInvalidConversation
 	}
 
-	err := chatService.MarkMessagesAsRead(c.userID, msg.ConversationID)
+	receipt, err := chatService.MarkMessagesAsRead(c.userID, msg.ConversationID, 0)
 	if err != nil {
 		if err.Error() == "conversation not found" {
-			logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithConversation(msg.ConversationID), "Conversation not found (404)")
+			logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithConversation(msg.ConversationID), "Conversation not found (404)")
 		} else if err.Error() == "access denied" {
-			logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithConversation(msg.ConversationID), "Access denied to conversation")
+			logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithConversation(msg.ConversationID), "Access denied to conversation")
 		}
-		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("access_validation"), "Access denied: %s", err.Error())
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("access_validation"), "Access denied: %s", err.Error())
+		return err
+	}
+	c.hub.BroadcastReceipt(receipt)
+
+	logger.DebugWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithDuration(1*time.Millisecond), "Message processing completed")
+	return nil
+}
+
+// handleAckDelivered records that the client received messages
+func (c *Connection) handleAckDelivered(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
+	if msg.ConversationID == 0 || len(msg.MessageIDs) == 0 {
+		return ErrInvalidMessage
+	}
+
+	receipt, err := chatService.MarkMessagesDelivered(c.userID, msg.ConversationID, msg.MessageIDs)
+	if err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("ack_delivered"), "Failed to mark messages delivered: %v", err)
+		return err
+	}
+
+	c.hub.BroadcastReceipt(receipt)
+	return nil
+}
+
+// handleAckRead records that the client viewed the messages up to MessageID
+func (c *Connection) handleAckRead(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
+	if msg.ConversationID == 0 || msg.MessageID == 0 {
+		return ErrInvalidMessage
+	}
+
+	receipt, err := chatService.MarkMessagesAsRead(c.userID, msg.ConversationID, msg.MessageID)
+	if err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("ack_read"), "Failed to mark messages read: %v", err)
 		return err
 	}
 
-	logger.DebugWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithDuration(1*time.Millisecond), "Message processing completed")
+	c.hub.BroadcastReceipt(receipt)
 	return nil
 }
 
 // handleTyping processes typing notifications
-func (c *Connection) handleTyping(msg IncomingMessage) error {
+func (c *Connection) handleTyping(ctx context.Context, msg IncomingMessage) error {
 	if msg.ConversationID == 0 {
 		return ErrInvalidConversation
 	}
@@ -134,21 +177,21 @@ func (c *Connection) checkRateLimit() bool {
 }
 
 // handleReactionAdd processes add reaction requests
-func (c *Connection) handleReactionAdd(msg IncomingMessage, chatService types.ChatService) error {
+func (c *Connection) handleReactionAdd(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
 	if msg.MessageID == 0 || msg.Emoji == "" {
 		return ErrInvalidMessage
 	}
 
 	_, err := chatService.AddReaction(c.userID, msg.MessageID, msg.Emoji)
 	if err != nil {
-		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("add_reaction"), "Failed to add reaction: %v", err)
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("add_reaction"), "Failed to add reaction: %v", err)
 		return err
 	}
 
 	// Get the conversation ID from the message
 	message, err := chatService.GetMessage(msg.MessageID)
 	if err != nil {
-		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("get_message"), "Failed to get message: %v", err)
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("get_message"), "Failed to get message: %v", err)
 		return err
 	}
 
@@ -166,33 +209,33 @@ func (c *Connection) handleReactionAdd(msg IncomingMessage, chatService types.Ch
 	}
 
 	logger.InfoWithContext(
-		logger.WithComponent("websocket_conn").WithUser(c.userID).WithConversation(message.ConvID),
+		logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithConversation(message.ConvID),
 		"🚀 Broadcasting reaction ADD: messageID=%d, emoji=%s, userID=%d",
 		msg.MessageID, msg.Emoji, c.userID,
 	)
 
 	c.hub.BroadcastToConversation(message.ConvID, reactionMsg, 0) // Don't exclude anyone, everyone should see reactions
 
-	logger.InfoWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("reaction_added"), "Reaction added successfully")
+	logger.InfoWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("reaction_added"), "Reaction added successfully")
 	return nil
 }
 
 // handleReactionRemove processes remove reaction requests
-func (c *Connection) handleReactionRemove(msg IncomingMessage, chatService types.ChatService) error {
+func (c *Connection) handleReactionRemove(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
 	if msg.MessageID == 0 || msg.Emoji == "" {
 		return ErrInvalidMessage
 	}
 
 	err := chatService.RemoveReaction(c.userID, msg.MessageID, msg.Emoji)
 	if err != nil {
-		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("remove_reaction"), "Failed to remove reaction: %v", err)
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("remove_reaction"), "Failed to remove reaction: %v", err)
 		return err
 	}
 
 	// Get the conversation ID from the message
 	message, err := chatService.GetMessage(msg.MessageID)
 	if err != nil {
-		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("get_message"), "Failed to get message: %v", err)
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("get_message"), "Failed to get message: %v", err)
 		return err
 	}
 
@@ -210,14 +253,14 @@ func (c *Connection) handleReactionRemove(msg IncomingMessage, chatService types
 	}
 
 	logger.InfoWithContext(
-		logger.WithComponent("websocket_conn").WithUser(c.userID).WithConversation(message.ConvID),
+		logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithConversation(message.ConvID),
 		"🚀 Broadcasting reaction REMOVE: messageID=%d, emoji=%s, userID=%d",
 		msg.MessageID, msg.Emoji, c.userID,
 	)
 
 	c.hub.BroadcastToConversation(message.ConvID, reactionMsg, 0) // Don't exclude anyone, everyone should see reaction removals
 
-	logger.InfoWithContext(logger.WithComponent("websocket_conn").WithUser(c.userID).WithAction("reaction_removed"), "Reaction removed successfully")
+	logger.InfoWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("reaction_removed"), "Reaction removed successfully")
 	return nil
 }
 
@@ -236,4 +279,39 @@ func (c *Connection) getMessageConversationParticipants(messageID uint, chatServ
 	}
 
 	return participants, nil
-}
\ No newline at end of file
+}
+// handleEditMessage processes edit message requests
+func (c *Connection) handleEditMessage(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
+	if msg.MessageID == 0 || msg.Content == "" {
+		return ErrInvalidMessage
+	}
+
+	message, err := chatService.EditMessage(ctx, c.userID, msg.MessageID, msg.Content)
+	if err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("edit_message"), "Failed to edit message: %v", err)
+		return err
+	}
+
+	c.hub.BroadcastMessageEdited(message)
+	return nil
+}
+
+// handleDeleteMessage processes delete message requests
+func (c *Connection) handleDeleteMessage(ctx context.Context, msg IncomingMessage, chatService types.ChatService) error {
+	if msg.MessageID == 0 {
+		return ErrInvalidMessage
+	}
+	scope := msg.Scope
+	if scope == "" {
+		scope = models.DeleteForMe
+	}
+
+	message, err := chatService.DeleteMessage(ctx, c.userID, msg.MessageID, scope)
+	if err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_conn").WithRequest(ctx).WithUser(c.userID).WithAction("delete_message"), "Failed to delete message: %v", err)
+		return err
+	}
+
+	c.hub.BroadcastMessageDeleted(message, c.userID, scope)
+	return nil
+}
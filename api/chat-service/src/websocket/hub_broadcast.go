@@ -2,28 +2,56 @@ package websocket
 
 import (
 	"chat-service/src/logger"
+	"chat-service/src/models"
+	"chat-service/src/pubsub"
+	"chat-service/src/types"
+	"context"
 )
 
-// BroadcastToConversation sends a message to all participants in a conversation
+// BroadcastToConversation sends a message to all participants in a conversation,
+// on this replica and, through the relay, on every other one
 func (h *Hub) BroadcastToConversation(conversationID uint, msg OutgoingMessage, excludeUserID uint) {
-	h.broadcast <- BroadcastMessage{
+	h.broadcastEvent(pubsub.NewEventID(), conversationID, msg, excludeUserID)
+}
+
+// broadcastEvent is BroadcastToConversation with a known event ID, for events
+// the gateway also delivers on its own
+func (h *Hub) broadcastEvent(eventID string, conversationID uint, msg OutgoingMessage, excludeUserID uint) {
+	h.queueBroadcast(BroadcastMessage{
 		Message:        msg,
 		ConversationID: conversationID,
 		ExcludeUserID:  excludeUserID,
-	}
+		EventID:        eventID,
+	})
 }
 
-// BroadcastToUser sends a message to a specific user
-func (h *Hub) BroadcastToUser(userID uint, msg OutgoingMessage) {
-	h.mutex.RLock()
-	conn, exists := h.connections[userID]
-	h.mutex.RUnlock()
+// DeliverToConversation sends an event already published on Redis to the
+// participants connected to this replica. The Redis copy is then dropped.
+func (h *Hub) DeliverToConversation(eventID string, conversationID uint, msg OutgoingMessage) {
+	h.queueBroadcast(BroadcastMessage{
+		Message:        msg,
+		ConversationID: conversationID,
+		EventID:        eventID,
+		LocalOnly:      true,
+	})
+}
 
-	if exists {
-		conn.sendMessage(msg)
+// queueBroadcast hands a broadcast to the main loop. It is dropped once the
+// hub is stopped, there is nobody left to deliver it to.
+func (h *Hub) queueBroadcast(msg BroadcastMessage) {
+	h.pendingBroadcasts.Add(1)
+	select {
+	case h.broadcast <- msg:
+	case <-h.stopped:
+		h.pendingBroadcasts.Add(-1)
 	}
 }
 
+// BroadcastToUser sends a message to every device of a user
+func (h *Hub) BroadcastToUser(userID uint, msg OutgoingMessage) {
+	h.deliver(userID, "", msg)
+}
+
 // BroadcastToUsers sends a message to multiple users
 func (h *Hub) BroadcastToUsers(userIDs []uint, message any) error {
 	msg, ok := message.(OutgoingMessage)
@@ -32,18 +60,48 @@ func (h *Hub) BroadcastToUsers(userIDs []uint, message any) error {
 		return ErrInvalidMessage
 	}
 
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
-
 	for _, userID := range userIDs {
-		if conn, exists := h.connections[userID]; exists {
-			conn.sendMessage(msg)
-		}
+		h.BroadcastToUser(userID, msg)
 	}
 
 	return nil
 }
 
+// HandleEvent delivers an event received from Redis to the local connections
+// of userID, or of everyone connected when userID is 0. Connections that
+// already got the event are skipped.
+func (h *Hub) HandleEvent(userID uint, event types.Event) {
+	msg, ok := outgoingFromEvent(event)
+	if !ok {
+		return
+	}
+
+	if userID != 0 {
+		h.deliver(userID, event.ID, msg)
+		return
+	}
+	for _, connectedUserID := range h.GetConnectedUsers() {
+		if connectedUserID != event.UserID {
+			h.deliver(connectedUserID, event.ID, msg)
+		}
+	}
+}
+
+// deliver sends a message to every device of a user. With an event ID, each
+// connection receives the event at most once.
+func (h *Hub) deliver(userID uint, eventID string, msg OutgoingMessage) {
+	for _, conn := range h.userConnections(userID) {
+		h.deliverTo(conn, eventID, msg)
+	}
+}
+
+func (h *Hub) deliverTo(conn *Connection, eventID string, msg OutgoingMessage) {
+	if eventID != "" && !h.delivered.MarkDelivered(eventID+"/"+conn.id) {
+		return
+	}
+	conn.sendMessage(msg)
+}
+
 // handleBroadcast processes broadcast messages
 func (h *Hub) handleBroadcast(broadcastMsg BroadcastMessage) {
 	// Get conversation participants
@@ -53,17 +111,73 @@ func (h *Hub) handleBroadcast(broadcastMsg BroadcastMessage) {
 		return
 	}
 
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
-
 	// Send to all participants except excluded user
+	recipients := make([]uint, 0, len(participants))
 	for _, userID := range participants {
 		if userID == broadcastMsg.ExcludeUserID {
 			continue
 		}
+		recipients = append(recipients, userID)
+		h.deliver(userID, broadcastMsg.EventID, broadcastMsg.Message)
+	}
+
+	if broadcastMsg.LocalOnly || h.relay == nil || len(recipients) == 0 {
+		return
+	}
+
+	event := eventFromOutgoing(broadcastMsg.EventID, broadcastMsg.Message)
+	if _, err := h.relay.Publish(context.Background(), &event, recipients); err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithConversation(broadcastMsg.ConversationID), "Failed to relay %s: %v", broadcastMsg.Message.Type, err)
+	}
+}
 
-		if conn, exists := h.connections[userID]; exists {
-			conn.sendMessage(broadcastMsg.Message)
+// eventFromOutgoing wraps a hub message for Redis
+func eventFromOutgoing(eventID string, msg OutgoingMessage) types.Event {
+	event := types.Event{
+		ID:             eventID,
+		Type:           string(msg.Type),
+		ConversationID: msg.ConversationID,
+		Timestamp:      msg.Timestamp,
+		Data:           msg.Data,
+	}
+	switch data := msg.Data.(type) {
+	case TypingData:
+		event.UserID = data.UserID
+	case ReactionData:
+		event.UserID = data.UserID
+	case MessageEditedData:
+		event.UserID = data.SenderID
+	case MessageDeletedData:
+		event.UserID = data.UserID
+	case types.Receipt:
+		event.UserID = data.UserID
+	case models.ConversationSettings:
+		event.UserID = data.UserID
+	}
+	return event
+}
+
+// outgoingFromEvent turns an event received from Redis into a client message
+func outgoingFromEvent(event types.Event) (OutgoingMessage, bool) {
+	msg := OutgoingMessage{
+		Type:           MessageType(event.Type),
+		ConversationID: event.ConversationID,
+		Data:           event.Data,
+		Timestamp:      event.Timestamp,
+	}
+
+	switch event.Type {
+	case types.EventNewMessage:
+		if event.Message == nil {
+			return msg, false
 		}
+		msg.Data = newMessageData(event.Message)
+		return msg, true
+	case types.EventTyping, types.EventReactionUpdate, types.EventPresenceUpdate, types.MessageTypeConversationUpdated,
+		types.EventMessageEdited, types.EventMessageDeleted, types.EventMessageDelivered, types.EventMessageRead,
+		types.EventConvSettings:
+		return msg, true
+	default:
+		return msg, false
 	}
-}
\ No newline at end of file
+}
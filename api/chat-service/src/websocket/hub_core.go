@@ -2,14 +2,41 @@ package websocket
 
 import (
 	"chat-service/src/logger"
+	"chat-service/src/pubsub"
 	"chat-service/src/types"
+	"context"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/dedupe"
 )
 
+// deliveryTTL is how long a delivery is remembered to drop Redis echoes
+const deliveryTTL = 2 * time.Minute
+
+// Relay publishes hub events to the other replicas and the gateway
+type Relay interface {
+	Publish(ctx context.Context, event *types.Event, userIDs []uint) (int, error)
+	PublishPresence(ctx context.Context, event *types.Event) error
+}
+
+// PresenceCounter counts the connections of each user across replicas, so a
+// user stays online while any replica holds one of their devices
+type PresenceCounter interface {
+	Connect(userID uint, connID string) (first bool, err error)
+	Disconnect(userID uint, connID string) (last bool, err error)
+	Refresh(connections map[uint][]string) error
+}
+
 // Hub manages WebSocket connections
 type Hub struct {
-	// Registered connections
-	connections map[uint]*Connection
+	// Registered connections, by user then by connection ID
+	connections map[uint]map[string]*Connection
+
+	// Open connections from the gateways
+	gateways map[*websocket.Conn]struct{}
 
 	// Channel for registering connections
 	register chan *Connection
@@ -20,6 +47,12 @@ type Hub struct {
 	// Channel for broadcasting messages
 	broadcast chan BroadcastMessage
 
+	// Broadcasts waiting for the main loop to take them
+	pendingBroadcasts atomic.Int64
+
+	// Closed once the main loop has returned
+	stopped chan struct{}
+
 	// Mutex for thread-safe operations
 	mutex sync.RWMutex
 
@@ -28,6 +61,15 @@ type Hub struct {
 
 	// Repository for direct data access (needed for system operations)
 	repository types.ChatRepository
+
+	// Relay to the other replicas, nil when running alone
+	relay Relay
+
+	// Connections of every replica, nil when running alone
+	presence PresenceCounter
+
+	// Deliveries already made, by event and connection
+	delivered *dedupe.Cache
 }
 
 // BroadcastMessage represents a message to broadcast
@@ -35,23 +77,33 @@ type BroadcastMessage struct {
 	Message        OutgoingMessage
 	ConversationID uint
 	ExcludeUserID  uint
+	EventID        string
+	LocalOnly      bool // already published on Redis by the chat service
 }
 
 // NewHub creates a new WebSocket hub
 func NewHub(chatService types.ChatService, repository types.ChatRepository) *Hub {
 	return &Hub{
-		connections: make(map[uint]*Connection),
+		connections: make(map[uint]map[string]*Connection),
+		gateways:    make(map[*websocket.Conn]struct{}),
 		register:    make(chan *Connection),
 		unregister:  make(chan *Connection),
 		broadcast:   make(chan BroadcastMessage),
+		stopped:     make(chan struct{}),
 		chatService: chatService,
 		repository:  repository,
+		delivered:   dedupe.New(deliveryTTL),
 	}
 }
 
-// Run starts the hub's main loop
-func (h *Hub) Run() {
+// Run starts the hub's main loop, until ctx is cancelled. Call Drain first
+// to close the connections.
+func (h *Hub) Run(ctx context.Context) {
 	logger.InfoWithContext(logger.WithComponent("websocket_hub"), "🔌 WebSocket Hub started")
+	defer close(h.stopped)
+
+	presenceTicker := time.NewTicker(pubsub.PresenceRefresh)
+	defer presenceTicker.Stop()
 
 	for {
 		select {
@@ -62,28 +114,129 @@ func (h *Hub) Run() {
 			h.unregisterConnection(conn)
 
 		case broadcastMsg := <-h.broadcast:
+			h.pendingBroadcasts.Add(-1)
 			h.handleBroadcast(broadcastMsg)
+
+		case <-presenceTicker.C:
+			h.refreshPresence()
+
+		case <-ctx.Done():
+			logger.InfoWithContext(logger.WithComponent("websocket_hub"), "🔌 WebSocket Hub stopped")
+			return
 		}
 	}
 }
 
 // RegisterConnection adds a new connection
 func (h *Hub) RegisterConnection(conn *Connection) {
-	h.register <- conn
+	select {
+	case h.register <- conn:
+	case <-h.stopped:
+		conn.Close()
+	}
 }
 
 // UnregisterConnection removes a connection
 func (h *Hub) UnregisterConnection(conn *Connection) {
-	h.unregister <- conn
+	select {
+	case h.unregister <- conn:
+	case <-h.stopped:
+	}
 }
 
-// IsUserOnline checks if a user is connected
+// Drain closes every connection, the gateways included, with a service
+// restart close frame telling the clients when to reconnect. It returns once
+// the connections are unregistered, or when ctx is done.
+func (h *Hub) Drain(ctx context.Context) error {
+	h.mutex.RLock()
+	var conns []*Connection
+	for _, userConns := range h.connections {
+		for _, conn := range userConns {
+			conns = append(conns, conn)
+		}
+	}
+	gateways := make([]*websocket.Conn, 0, len(h.gateways))
+	for conn := range h.gateways {
+		gateways = append(gateways, conn)
+	}
+	h.mutex.RUnlock()
+
+	logger.InfoWithContext(logger.WithComponent("websocket_hub"),
+		"Draining %d connection(s) and %d gateway(s)", len(conns), len(gateways))
+	for _, conn := range conns {
+		conn.closeForRestart()
+	}
+	for _, conn := range gateways {
+		closeForRestart(conn)
+	}
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for h.ConnectionCount() > 0 || h.gatewayCount() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// addGateway tracks a gateway connection until removeGateway, for Drain
+func (h *Hub) addGateway(conn *websocket.Conn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.gateways[conn] = struct{}{}
+}
+
+func (h *Hub) removeGateway(conn *websocket.Conn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.gateways, conn)
+}
+
+func (h *Hub) gatewayCount() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.gateways)
+}
+
+// IsUserOnline checks if a user is connected on at least one device
 func (h *Hub) IsUserOnline(userID uint) bool {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
-	_, exists := h.connections[userID]
-	return exists
+	return len(h.connections[userID]) > 0
+}
+
+// ConnectionCount returns the number of open connections, all users included
+func (h *Hub) ConnectionCount() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	count := 0
+	for _, conns := range h.connections {
+		count += len(conns)
+	}
+	return count
+}
+
+// BroadcastQueueDepth returns the number of broadcasts waiting for the main
+// loop, which grows when it falls behind
+func (h *Hub) BroadcastQueueDepth() int {
+	return int(h.pendingBroadcasts.Load())
+}
+
+// userConnections returns a snapshot of the connections of a user
+func (h *Hub) userConnections(userID uint) []*Connection {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	conns := make([]*Connection, 0, len(h.connections[userID]))
+	for _, conn := range h.connections[userID] {
+		conns = append(conns, conn)
+	}
+	return conns
 }
 
 // GetConnectedUsers returns list of connected user IDs
@@ -98,19 +251,36 @@ func (h *Hub) GetConnectedUsers() []uint {
 	return users
 }
 
-// registerConnection handles connection registration
+// registerConnection handles connection registration. Other devices of the
+// same user stay connected; presence only changes with the first device on
+// any replica.
 func (h *Hub) registerConnection(conn *Connection) {
 	h.mutex.Lock()
-	defer h.mutex.Unlock()
-
-	// Close existing connection if any
-	if existing, exists := h.connections[conn.userID]; exists {
-		existing.Close()
-		logger.WarnWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID), "Replaced existing connection")
+	conns, online := h.connections[conn.userID]
+	if !online {
+		conns = make(map[string]*Connection)
+		h.connections[conn.userID] = conns
 	}
+	conns[conn.id] = conn
+	devices := len(conns)
+	h.mutex.Unlock()
+
+	logger.InfoWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID),
+		"User connected (connection %s, %d device(s))", conn.id, devices)
 
-	h.connections[conn.userID] = conn
-	logger.InfoWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID), "User connected, total: %d", len(h.connections))
+	// Send connected confirmation
+	conn.sendMessage(OutgoingMessage{
+		Type: MessageTypeConnected,
+		Data: ConnectionData{
+			UserID:       conn.userID,
+			ConnectionID: conn.id,
+			Status:       "connected",
+		},
+	})
+
+	if !h.firstConnection(conn, !online) {
+		return
+	}
 
 	// Update user presence to online
 	if h.chatService != nil {
@@ -120,29 +290,33 @@ func (h *Hub) registerConnection(conn *Connection) {
 		}
 	}
 
-	// Send connected confirmation
-	conn.sendMessage(OutgoingMessage{
-		Type: MessageTypeConnected,
-		Data: ConnectionData{
-			UserID: conn.userID,
-			Status: "connected",
-		},
-	})
-
 	// Notify other users about online status
 	h.notifyUserStatus(conn.userID, "online")
 }
 
-// unregisterConnection handles connection unregistration
+// unregisterConnection handles connection unregistration. The user goes
+// offline when their last device leaves, on every replica.
 func (h *Hub) unregisterConnection(conn *Connection) {
 	h.mutex.Lock()
-	defer h.mutex.Unlock()
+	conns := h.connections[conn.userID]
+	registered := conns[conn.id] == conn
+	if registered {
+		delete(conns, conn.id)
+		if len(conns) == 0 {
+			delete(h.connections, conn.userID)
+		}
+	}
+	remaining := len(conns)
+	h.mutex.Unlock()
 
-	if _, exists := h.connections[conn.userID]; exists {
-		delete(h.connections, conn.userID)
-		conn.Close()
-		logger.InfoWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID), "User disconnected, total: %d", len(h.connections))
+	if !registered {
+		return
+	}
+	conn.Close()
+	logger.InfoWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID),
+		"User disconnected (connection %s, %d device(s) left)", conn.id, remaining)
 
+	if h.lastConnection(conn, remaining == 0) {
 		// Update user presence to offline
 		if h.chatService != nil {
 			err := h.chatService.SetUserOffline(conn.userID)
@@ -156,6 +330,56 @@ func (h *Hub) unregisterConnection(conn *Connection) {
 	}
 }
 
+// firstConnection reports whether conn is the first connection of its user
+// across replicas, or on this one when there is no presence counter or Redis
+// fails
+func (h *Hub) firstConnection(conn *Connection, firstLocal bool) bool {
+	if h.presence == nil {
+		return firstLocal
+	}
+	first, err := h.presence.Connect(conn.userID, conn.id)
+	if err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID), "Failed to count connection %s: %v", conn.id, err)
+		return firstLocal
+	}
+	return first
+}
+
+// lastConnection reports whether conn was the last connection of its user
+// across replicas, or on this one when there is no presence counter or Redis
+// fails
+func (h *Hub) lastConnection(conn *Connection, lastLocal bool) bool {
+	if h.presence == nil {
+		return lastLocal
+	}
+	last, err := h.presence.Disconnect(conn.userID, conn.id)
+	if err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithUser(conn.userID), "Failed to uncount connection %s: %v", conn.id, err)
+		return lastLocal
+	}
+	return last
+}
+
+// refreshPresence keeps the connections of this replica counted
+func (h *Hub) refreshPresence() {
+	if h.presence == nil {
+		return
+	}
+
+	h.mutex.RLock()
+	connections := make(map[uint][]string, len(h.connections))
+	for userID, conns := range h.connections {
+		for connID := range conns {
+			connections[userID] = append(connections[userID], connID)
+		}
+	}
+	h.mutex.RUnlock()
+
+	if err := h.presence.Refresh(connections); err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_hub"), "Failed to refresh presence: %v", err)
+	}
+}
+
 // notifyUserStatus notifies about user online/offline status
 func (h *Hub) notifyUserStatus(userID uint, status string) {
 	// Get user presence information
@@ -182,10 +406,24 @@ func (h *Hub) notifyUserStatus(userID uint, status string) {
 	}
 
 	// Get all connected users to broadcast to
+	eventID := pubsub.NewEventID()
 	connectedUsers := h.GetConnectedUsers()
 	for _, connectedUserID := range connectedUsers {
 		if connectedUserID != userID { // Don't broadcast to the user themselves
-			h.BroadcastToUser(connectedUserID, presenceMsg)
+			h.deliver(connectedUserID, eventID, presenceMsg)
+		}
+	}
+
+	// Users connected to other replicas
+	if h.relay != nil {
+		err := h.relay.PublishPresence(context.Background(), &types.Event{
+			ID:     eventID,
+			Type:   types.EventPresenceUpdate,
+			UserID: userID,
+			Data:   presenceMsg.Data,
+		})
+		if err != nil {
+			logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithUser(userID), "Failed to publish presence: %v", err)
 		}
 	}
 
@@ -202,6 +440,18 @@ func (h *Hub) SetChatService(chatService types.ChatService) {
 	h.chatService = chatService
 }
 
+// SetRelay publishes the hub events on Redis so that users connected to
+// other replicas receive them too
+func (h *Hub) SetRelay(relay Relay) {
+	h.relay = relay
+}
+
+// SetPresence counts the connections in Redis so that a user connected to
+// several replicas goes offline only when they leave the last one
+func (h *Hub) SetPresence(presence PresenceCounter) {
+	h.presence = presence
+}
+
 // getConversationParticipants gets participants for a conversation
 func (h *Hub) getConversationParticipants(conversationID uint) ([]uint, error) {
 	if h.repository == nil {
@@ -220,20 +470,19 @@ func (h *Hub) AddConnection(userID uint, conn types.WebSocketConnection) error {
 	return nil
 }
 
+// RemoveConnection disconnects every device of the user
 func (h *Hub) RemoveConnection(userID uint) error {
-	h.mutex.Lock()
-	defer h.mutex.Unlock()
-
-	if conn, exists := h.connections[userID]; exists {
-		h.unregister <- conn
+	for _, conn := range h.userConnections(userID) {
+		h.UnregisterConnection(conn)
 	}
 	return nil
 }
 
+// GetConnection returns one of the user's connections
 func (h *Hub) GetConnection(userID uint) (types.WebSocketConnection, bool) {
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
-
-	conn, exists := h.connections[userID]
-	return conn, exists
+	conns := h.userConnections(userID)
+	if len(conns) == 0 {
+		return nil, false
+	}
+	return conns[0], true
 }
\ No newline at end of file
@@ -2,10 +2,15 @@ package websocket
 
 import (
 	"chat-service/src/logger"
+	"chat-service/src/pubsub"
+	"chat-service/src/types"
+	"context"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // GatewayMessage represents messages from Gateway
@@ -17,6 +22,8 @@ type GatewayMessage struct {
 	Token          string                 `json:"token,omitempty"`
 	RequestID      string                 `json:"request_id,omitempty"`
 	Data           map[string]interface{} `json:"data,omitempty"`
+	// TraceParent carries the trace of the client message (W3C traceparent)
+	TraceParent string `json:"traceparent,omitempty"`
 }
 
 // GatewayResponse represents responses to Gateway
@@ -31,13 +38,16 @@ type GatewayResponse struct {
 	Error          string                 `json:"error,omitempty"`
 }
 
-// HandleGatewayConnection handles WebSocket connection from Gateway
-func (h *Hub) HandleGatewayConnection(conn *websocket.Conn) {
-	logger.InfoWithContext(logger.WithComponent("websocket_hub").WithAction("gateway_connection"), "Gateway WebSocket connection established")
+// HandleGatewayConnection handles WebSocket connection from Gateway. ctx is
+// the context of the upgrade request.
+func (h *Hub) HandleGatewayConnection(ctx context.Context, conn *websocket.Conn) {
+	logger.InfoWithContext(logger.WithComponent("websocket_hub").WithRequest(ctx).WithAction("gateway_connection"), "Gateway WebSocket connection established")
 
+	h.addGateway(conn)
 	defer func() {
+		h.removeGateway(conn)
 		conn.Close()
-		logger.InfoWithContext(logger.WithComponent("websocket_hub").WithAction("gateway_disconnection"), "Gateway WebSocket connection closed")
+		logger.InfoWithContext(logger.WithComponent("websocket_hub").WithRequest(ctx).WithAction("gateway_disconnection"), "Gateway WebSocket connection closed")
 	}()
 
 	// Set read deadline for ping/pong
@@ -53,64 +63,84 @@ func (h *Hub) HandleGatewayConnection(conn *websocket.Conn) {
 		err := conn.ReadJSON(&gatewayMsg)
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithAction("gateway_read_error"), "Gateway WebSocket read error: %v", err)
+				logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithRequest(ctx).WithAction("gateway_read_error"), "Gateway WebSocket read error: %v", err)
 			}
 			break
 		}
 
-		// Handle the message from Gateway
-		h.handleGatewayMessage(gatewayMsg, conn)
+		// Handle the message from Gateway, within the trace of the client
+		msgCtx, span := tracing.StartMessage(ctx, gatewayMsg.TraceParent, gatewayMsg.Type)
+		span.SetAttributes(attribute.String("enduser.id", gatewayMsg.UserID))
+		h.handleGatewayMessage(msgCtx, gatewayMsg, conn)
+		span.End()
 	}
 }
 
 // handleGatewayMessage processes messages from Gateway
-func (h *Hub) handleGatewayMessage(msg GatewayMessage, conn *websocket.Conn) {
+func (h *Hub) handleGatewayMessage(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
 	logger.DebugWithContext(
-		logger.WithComponent("websocket_hub").WithAction("gateway_message"),
+		logger.WithComponent("websocket_hub").WithRequest(ctx).WithAction("gateway_message"),
 		"Received from Gateway: type=%s, user=%s", msg.Type, msg.UserID,
 	)
 
 	switch msg.Type {
 	case "chat_message":
-		h.handleGatewayChatMessage(msg, conn)
+		h.handleGatewayChatMessage(ctx, msg, conn)
 	case "join_conversation":
-		h.handleGatewayJoinConversation(msg, conn)
+		h.handleGatewayJoinConversation(ctx, msg, conn)
 	case "typing":
-		h.handleGatewayTyping(msg, conn)
+		h.handleGatewayTyping(ctx, msg, conn)
 	case "reaction_add":
-		h.handleGatewayReactionAdd(msg, conn)
+		h.handleGatewayReactionAdd(ctx, msg, conn)
 	case "reaction_remove":
-		h.handleGatewayReactionRemove(msg, conn)
+		h.handleGatewayReactionRemove(ctx, msg, conn)
+	case "edit_message":
+		h.handleGatewayEditMessage(ctx, msg, conn)
+	case "delete_message":
+		h.handleGatewayDeleteMessage(ctx, msg, conn)
+	case "ack_delivered", "ack_read":
+		h.handleGatewayAck(ctx, msg, conn)
 	default:
 		logger.WarnWithContext(
-			logger.WithComponent("websocket_hub").WithAction("unknown_gateway_message"),
+			logger.WithComponent("websocket_hub").WithRequest(ctx).WithAction("unknown_gateway_message"),
 			"Unknown message type from Gateway: %s", msg.Type,
 		)
 	}
 }
 
 // handleGatewayChatMessage handles chat messages from Gateway
-func (h *Hub) handleGatewayChatMessage(msg GatewayMessage, conn *websocket.Conn) {
+func (h *Hub) handleGatewayChatMessage(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
 	// Parse user and conversation IDs
 	userID := parseUintFromString(msg.UserID)
 	conversationID := parseUintFromString(msg.ConversationID)
 
-	if userID == 0 || conversationID == 0 || msg.Content == "" {
+	attachmentIDs := gatewayAttachmentIDs(msg)
+	if userID == 0 || conversationID == 0 || (msg.Content == "" && len(attachmentIDs) == 0) {
 		h.sendErrorToGateway(conn, msg.RequestID, "Invalid message parameters")
 		return
 	}
 
 	// Send message through chat service
-	message, err := h.chatService.SendMessage(userID, conversationID, msg.Content)
+	message, err := h.chatService.SendMessageWithAttachments(ctx, userID, conversationID, msg.Content, attachmentIDs)
 	if err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub").WithUser(userID).WithConversation(conversationID),
+			logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID).WithConversation(conversationID),
 			"Failed to send message via Gateway: %v", err,
 		)
 		h.sendErrorToGateway(conn, msg.RequestID, err.Error())
 		return
 	}
 
+	// Deliver to the participants connected here directly; the gateway
+	// broadcasts on its side and drops the Redis copy by event ID
+	eventID := types.MessageEventID(message.ID)
+	h.DeliverToConversation(eventID, conversationID, OutgoingMessage{
+		Type:           MessageTypeNewMessage,
+		ConversationID: conversationID,
+		Data:           newMessageData(message),
+		Timestamp: time.Now(),
+	})
+
 	// Send the message back to Gateway for broadcasting to users
 	broadcastResponse := GatewayResponse{
 		Type:           "chat_message",
@@ -123,12 +153,15 @@ func (h *Hub) handleGatewayChatMessage(msg GatewayMessage, conn *websocket.Conn)
 			"message":      message.Msg,
 			"timestamp":    message.Time.Unix(),
 			"read_at":      message.ReadAt,
+			"attachments":  message.Attachments,
+			"warning":      message.Warning,
+			"event_id":     eventID,
 		},
 	}
 
 	if err := conn.WriteJSON(broadcastResponse); err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub"),
+			logger.WithComponent("websocket_hub").WithRequest(ctx),
 			"Failed to send broadcast message to Gateway: %v", err,
 		)
 	}
@@ -148,19 +181,19 @@ func (h *Hub) handleGatewayChatMessage(msg GatewayMessage, conn *websocket.Conn)
 
 	if err := conn.WriteJSON(ackResponse); err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub"),
+			logger.WithComponent("websocket_hub").WithRequest(ctx),
 			"Failed to send ack to Gateway: %v", err,
 		)
 	}
 
 	logger.InfoWithContext(
-		logger.WithComponent("websocket_hub").WithUser(userID).WithConversation(conversationID),
+		logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID).WithConversation(conversationID),
 		"Message relayed from Gateway successfully",
 	)
 }
 
 // handleGatewayJoinConversation handles join requests from Gateway
-func (h *Hub) handleGatewayJoinConversation(msg GatewayMessage, conn *websocket.Conn) {
+func (h *Hub) handleGatewayJoinConversation(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
 	userID := parseUintFromString(msg.UserID)
 	conversationID := parseUintFromString(msg.ConversationID)
 
@@ -169,11 +202,12 @@ func (h *Hub) handleGatewayJoinConversation(msg GatewayMessage, conn *websocket.
 		return
 	}
 
-	err := h.chatService.MarkMessagesAsRead(userID, conversationID)
+	receipt, err := h.chatService.MarkMessagesAsRead(userID, conversationID, 0)
 	if err != nil {
 		h.sendErrorToGateway(conn, msg.RequestID, err.Error())
 		return
 	}
+	h.BroadcastReceipt(receipt)
 
 	// Send success response
 	response := GatewayResponse{
@@ -186,14 +220,14 @@ func (h *Hub) handleGatewayJoinConversation(msg GatewayMessage, conn *websocket.
 
 	if err := conn.WriteJSON(response); err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub"),
+			logger.WithComponent("websocket_hub").WithRequest(ctx),
 			"Failed to send join response to Gateway: %v", err,
 		)
 	}
 }
 
 // handleGatewayTyping handles typing notifications from Gateway
-func (h *Hub) handleGatewayTyping(msg GatewayMessage, conn *websocket.Conn) {
+func (h *Hub) handleGatewayTyping(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
 	userID := parseUintFromString(msg.UserID)
 	conversationID := parseUintFromString(msg.ConversationID)
 
@@ -240,7 +274,7 @@ func (h *Hub) sendErrorToGateway(conn *websocket.Conn, requestID, errorMsg strin
 }
 
 // handleGatewayReactionAdd handles reaction add requests from Gateway
-func (h *Hub) handleGatewayReactionAdd(msg GatewayMessage, conn *websocket.Conn) {
+func (h *Hub) handleGatewayReactionAdd(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
 	userID := parseUintFromString(msg.UserID)
 	if userID == 0 {
 		h.sendErrorToGateway(conn, msg.RequestID, "Invalid user ID")
@@ -290,13 +324,13 @@ func (h *Hub) handleGatewayReactionAdd(msg GatewayMessage, conn *websocket.Conn)
 			// This was a toggle operation - reaction was removed
 			isToggleRemoval = true
 			logger.InfoWithContext(
-				logger.WithComponent("websocket_hub").WithUser(userID),
+				logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 				"Reaction toggled (removed): message=%d, emoji=%s", messageID, emoji,
 			)
 		} else {
 			// Real error occurred
 			logger.ErrorWithContext(
-				logger.WithComponent("websocket_hub").WithUser(userID),
+				logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 				"Failed to add reaction via Gateway: %v", err,
 			)
 			h.sendErrorToGateway(conn, msg.RequestID, err.Error())
@@ -308,7 +342,7 @@ func (h *Hub) handleGatewayReactionAdd(msg GatewayMessage, conn *websocket.Conn)
 	message, err := h.chatService.GetMessage(messageID)
 	if err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub").WithUser(userID),
+			logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 			"Failed to get message for reaction broadcast: %v", err,
 		)
 		return
@@ -334,6 +368,9 @@ func (h *Hub) handleGatewayReactionAdd(msg GatewayMessage, conn *websocket.Conn)
 	}
 	broadcastData["action"] = action
 
+	eventID := h.relayGatewayReaction(message.ConvID, messageID, userID, emoji, action)
+	broadcastData["event_id"] = eventID
+
 	// Send reaction update to Gateway for broadcasting to all conversation participants
 	broadcastResponse := GatewayResponse{
 		Type:           "reaction_update",
@@ -344,19 +381,19 @@ func (h *Hub) handleGatewayReactionAdd(msg GatewayMessage, conn *websocket.Conn)
 
 	if err := conn.WriteJSON(broadcastResponse); err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub"),
+			logger.WithComponent("websocket_hub").WithRequest(ctx),
 			"Failed to send reaction broadcast to Gateway: %v", err,
 		)
 	}
 
 	logger.InfoWithContext(
-		logger.WithComponent("websocket_hub").WithUser(userID),
+		logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 		"Reaction add relayed from Gateway successfully: message=%d, emoji=%s", messageID, emoji,
 	)
 }
 
 // handleGatewayReactionRemove handles reaction remove requests from Gateway
-func (h *Hub) handleGatewayReactionRemove(msg GatewayMessage, conn *websocket.Conn) {
+func (h *Hub) handleGatewayReactionRemove(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
 	userID := parseUintFromString(msg.UserID)
 	if userID == 0 {
 		h.sendErrorToGateway(conn, msg.RequestID, "Invalid user ID")
@@ -401,7 +438,7 @@ func (h *Hub) handleGatewayReactionRemove(msg GatewayMessage, conn *websocket.Co
 	err := h.chatService.RemoveReaction(userID, messageID, emoji)
 	if err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub").WithUser(userID),
+			logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 			"Failed to remove reaction via Gateway: %v", err,
 		)
 		h.sendErrorToGateway(conn, msg.RequestID, err.Error())
@@ -412,7 +449,7 @@ func (h *Hub) handleGatewayReactionRemove(msg GatewayMessage, conn *websocket.Co
 	message, err := h.chatService.GetMessage(messageID)
 	if err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub").WithUser(userID),
+			logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 			"Failed to get message for reaction broadcast: %v", err,
 		)
 		return
@@ -430,22 +467,54 @@ func (h *Hub) handleGatewayReactionRemove(msg GatewayMessage, conn *websocket.Co
 			"emoji":           emoji,
 			"action":          "remove",
 			"timestamp":       time.Now().Unix(),
+			"event_id":        h.relayGatewayReaction(message.ConvID, messageID, userID, emoji, "remove"),
 		},
 	}
 
 	if err := conn.WriteJSON(broadcastResponse); err != nil {
 		logger.ErrorWithContext(
-			logger.WithComponent("websocket_hub"),
+			logger.WithComponent("websocket_hub").WithRequest(ctx),
 			"Failed to send reaction broadcast to Gateway: %v", err,
 		)
 	}
 
 	logger.InfoWithContext(
-		logger.WithComponent("websocket_hub").WithUser(userID),
+		logger.WithComponent("websocket_hub").WithRequest(ctx).WithUser(userID),
 		"Reaction remove relayed from Gateway successfully: message=%d, emoji=%s", messageID, emoji,
 	)
 }
 
+// relayGatewayReaction broadcasts a reaction made through the Gateway to the
+// participants connected directly and to the other replicas. The returned
+// event ID lets the Gateway drop the Redis copy of its own broadcast.
+func (h *Hub) relayGatewayReaction(conversationID, messageID, userID uint, emoji, action string) string {
+	eventID := pubsub.NewEventID()
+	h.broadcastEvent(eventID, conversationID, OutgoingMessage{
+		Type:           MessageTypeReactionUpdate,
+		ConversationID: conversationID,
+		Data: ReactionData{
+			MessageID: messageID,
+			UserID:    userID,
+			Emoji:     emoji,
+			Action:    action,
+		},
+		Timestamp: time.Now(),
+	}, 0)
+	return eventID
+}
+
+// gatewayAttachmentIDs reads the attachment_ids of a Gateway chat message
+func gatewayAttachmentIDs(msg GatewayMessage) []uint {
+	raw, _ := msg.Data["attachment_ids"].([]interface{})
+	ids := make([]uint, 0, len(raw))
+	for _, value := range raw {
+		if id, ok := value.(float64); ok && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
 // parseUintFromString safely parses a string to uint
 func parseUintFromString(s string) uint {
 	if s == "" {
@@ -0,0 +1,242 @@
+package websocket
+
+import (
+	"chat-service/src/logger"
+	"chat-service/src/models"
+	"chat-service/src/pubsub"
+	"chat-service/src/types"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// BroadcastMessageEdited tells the participants that a message was edited
+// and returns the event ID
+func (h *Hub) BroadcastMessageEdited(message *models.Message) string {
+	eventID := pubsub.NewEventID()
+	h.broadcastEvent(eventID, message.ConvID, OutgoingMessage{
+		Type:           MessageTypeMessageEdited,
+		ConversationID: message.ConvID,
+		Data: MessageEditedData{
+			MessageID: message.ID,
+			SenderID:  message.SenderID,
+			Message:   message.Msg,
+			EditedAt:  message.EditedAt,
+			Warning:   message.Warning,
+		},
+		Timestamp: time.Now(),
+	}, 0)
+	return eventID
+}
+
+// BroadcastMessageDeleted tells the participants that a message was unsent,
+// or only the devices of userID when they deleted it for themselves. It
+// returns the event ID.
+func (h *Hub) BroadcastMessageDeleted(message *models.Message, userID uint, scope string) string {
+	msg := OutgoingMessage{
+		Type:           MessageTypeMessageDeleted,
+		ConversationID: message.ConvID,
+		Data: MessageDeletedData{
+			MessageID: message.ID,
+			UserID:    userID,
+			Scope:     scope,
+		},
+		Timestamp: time.Now(),
+	}
+
+	eventID := pubsub.NewEventID()
+	if scope == models.DeleteForEveryone {
+		h.broadcastEvent(eventID, message.ConvID, msg, 0)
+		return eventID
+	}
+
+	h.sendToUser(userID, eventID, msg)
+	return eventID
+}
+
+// BroadcastReceipt tells the sender of the messages that they were delivered
+// or read, and returns the event ID. A nil receipt sends nothing.
+func (h *Hub) BroadcastReceipt(receipt *types.Receipt) string {
+	if receipt == nil {
+		return ""
+	}
+
+	msgType := MessageTypeDelivered
+	if receipt.Status == models.ReceiptRead {
+		msgType = MessageTypeRead
+	}
+	eventID := pubsub.NewEventID()
+	h.sendToUser(receipt.SenderID, eventID, OutgoingMessage{
+		Type:           msgType,
+		ConversationID: receipt.ConversationID,
+		Data:           *receipt,
+		Timestamp:      receipt.At,
+	})
+	return eventID
+}
+
+// BroadcastConversationSettings syncs the settings userID changed for a
+// conversation to all their devices, and returns the event ID
+func (h *Hub) BroadcastConversationSettings(userID uint, settings *models.ConversationSettings) string {
+	eventID := pubsub.NewEventID()
+	h.sendToUser(userID, eventID, OutgoingMessage{
+		Type:           MessageTypeConvSettings,
+		ConversationID: settings.ConversationID,
+		Data:           *settings,
+		Timestamp:      time.Now(),
+	})
+	return eventID
+}
+
+// sendToUser delivers an event to the devices of one user, on this replica
+// and through Redis on the others
+func (h *Hub) sendToUser(userID uint, eventID string, msg OutgoingMessage) {
+	h.deliver(userID, eventID, msg)
+	if h.relay != nil {
+		event := eventFromOutgoing(eventID, msg)
+		if _, err := h.relay.Publish(context.Background(), &event, []uint{userID}); err != nil {
+			logger.ErrorWithContext(logger.WithComponent("websocket_hub").WithUser(userID), "Failed to relay %s: %v", msg.Type, err)
+		}
+	}
+}
+
+// handleGatewayEditMessage handles edit requests from Gateway
+func (h *Hub) handleGatewayEditMessage(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
+	userID := parseUintFromString(msg.UserID)
+	messageID := gatewayMessageID(msg)
+	if userID == 0 || messageID == 0 || msg.Content == "" {
+		h.sendErrorToGateway(conn, msg.RequestID, "Missing user_id, message_id or content")
+		return
+	}
+
+	message, err := h.chatService.EditMessage(ctx, userID, messageID, msg.Content)
+	if err != nil {
+		h.sendErrorToGateway(conn, msg.RequestID, err.Error())
+		return
+	}
+
+	eventID := h.BroadcastMessageEdited(message)
+	h.respondToGateway(conn, GatewayResponse{
+		Type:           string(MessageTypeMessageEdited),
+		RequestID:      msg.RequestID,
+		Status:         "success",
+		ConversationID: strconv.FormatUint(uint64(message.ConvID), 10),
+		UserID:         msg.UserID,
+		Data: map[string]interface{}{
+			"message_id": message.ID,
+			"sender_id":  message.SenderID,
+			"message":    message.Msg,
+			"edited_at":  message.EditedAt,
+			"warning":    message.Warning,
+			"event_id":   eventID,
+		},
+	})
+}
+
+// handleGatewayDeleteMessage handles delete requests from Gateway
+func (h *Hub) handleGatewayDeleteMessage(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
+	userID := parseUintFromString(msg.UserID)
+	messageID := gatewayMessageID(msg)
+	if userID == 0 || messageID == 0 {
+		h.sendErrorToGateway(conn, msg.RequestID, "Missing user_id or message_id")
+		return
+	}
+	scope := models.DeleteForMe
+	if s, ok := msg.Data["scope"].(string); ok && s != "" {
+		scope = s
+	}
+
+	message, err := h.chatService.DeleteMessage(ctx, userID, messageID, scope)
+	if err != nil {
+		h.sendErrorToGateway(conn, msg.RequestID, err.Error())
+		return
+	}
+
+	eventID := h.BroadcastMessageDeleted(message, userID, scope)
+	h.respondToGateway(conn, GatewayResponse{
+		Type:           string(MessageTypeMessageDeleted),
+		RequestID:      msg.RequestID,
+		Status:         "success",
+		ConversationID: strconv.FormatUint(uint64(message.ConvID), 10),
+		UserID:         msg.UserID,
+		Data: map[string]interface{}{
+			"message_id": message.ID,
+			"user_id":    userID,
+			"scope":      scope,
+			"event_id":   eventID,
+		},
+	})
+}
+
+// handleGatewayAck handles delivery and read acknowledgments from Gateway
+func (h *Hub) handleGatewayAck(ctx context.Context, msg GatewayMessage, conn *websocket.Conn) {
+	userID := parseUintFromString(msg.UserID)
+	conversationID := parseUintFromString(msg.ConversationID)
+	if userID == 0 || conversationID == 0 {
+		h.sendErrorToGateway(conn, msg.RequestID, "Missing user_id or conversation_id")
+		return
+	}
+
+	var receipt *types.Receipt
+	var err error
+	if msg.Type == string(MessageTypeAckRead) {
+		messageID := gatewayMessageID(msg)
+		if messageID == 0 {
+			h.sendErrorToGateway(conn, msg.RequestID, "Missing message_id")
+			return
+		}
+		receipt, err = h.chatService.MarkMessagesAsRead(userID, conversationID, messageID)
+	} else {
+		receipt, err = h.chatService.MarkMessagesDelivered(userID, conversationID, gatewayMessageIDs(msg))
+	}
+	if err != nil {
+		h.sendErrorToGateway(conn, msg.RequestID, err.Error())
+		return
+	}
+
+	h.BroadcastReceipt(receipt)
+	h.respondToGateway(conn, GatewayResponse{
+		Type:           "response",
+		RequestID:      msg.RequestID,
+		Status:         "success",
+		ConversationID: msg.ConversationID,
+		UserID:         msg.UserID,
+	})
+}
+
+func (h *Hub) respondToGateway(conn *websocket.Conn, response GatewayResponse) {
+	if err := conn.WriteJSON(response); err != nil {
+		logger.ErrorWithContext(logger.WithComponent("websocket_hub"), "Failed to send %s to Gateway: %v", response.Type, err)
+	}
+}
+
+// gatewayMessageID reads the message_id of a Gateway request, sent as a JSON number
+func gatewayMessageID(msg GatewayMessage) uint {
+	return gatewayID(msg.Data["message_id"])
+}
+
+func gatewayID(value interface{}) uint {
+	switch id := value.(type) {
+	case float64:
+		if id > 0 {
+			return uint(id)
+		}
+	case string:
+		return parseUintFromString(id)
+	}
+	return 0
+}
+
+// gatewayMessageIDs reads the message_ids of a Gateway request
+func gatewayMessageIDs(msg GatewayMessage) []uint {
+	raw, _ := msg.Data["message_ids"].([]interface{})
+	ids := make([]uint, 0, len(raw))
+	for _, value := range raw {
+		if id := gatewayID(value); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
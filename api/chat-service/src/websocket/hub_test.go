@@ -2,12 +2,22 @@ package websocket
 
 import (
 	"chat-service/src/models"
+	"chat-service/src/types"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
-// Mock ChatRepository for testing
+// Mock ChatRepository for testing. The embedded interface covers the methods
+// the hub never calls; calling one of them panics.
 type mockChatRepository struct {
+	types.ChatRepository
 	participants map[uint][]uint
 }
 
@@ -19,7 +29,7 @@ func (m *mockChatRepository) GetConversationParticipants(conversationID uint) ([
 }
 
 // Implement other required methods as no-ops for testing
-func (m *mockChatRepository) GetUserConversations(userID uint) ([]models.Discussion, error) {
+func (m *mockChatRepository) GetUserConversations(userID uint, archived bool) ([]models.Discussion, error) {
 	return []models.Discussion{}, nil
 }
 
@@ -43,18 +53,50 @@ func (m *mockChatRepository) UpdateLastMessage(conversationID uint, content stri
 	return nil
 }
 
-func (m *mockChatRepository) GetMessages(conversationID uint, limit, offset int) ([]models.Message, error) {
+func (m *mockChatRepository) GetMessages(conversationID, userID uint, query types.MessageQuery) ([]models.Message, error) {
 	return []models.Message{}, nil
 }
 
+func (m *mockChatRepository) GetChangedMessages(conversationID, userID uint, until types.MessageQuery, since time.Time) ([]models.Message, error) {
+	return []models.Message{}, nil
+}
+
+func (m *mockChatRepository) GetHiddenMessageIDs(conversationID, userID uint, since time.Time) ([]uint, error) {
+	return nil, nil
+}
+
+func (m *mockChatRepository) SearchMessages(userID uint, query types.SearchQuery) ([]types.SearchResult, error) {
+	return nil, nil
+}
+
+func (m *mockChatRepository) GetReactedMessageIDs(conversationID uint, since time.Time) ([]uint, error) {
+	return nil, nil
+}
+
 func (m *mockChatRepository) SaveMessage(senderID, conversationID uint, content string) (*models.Message, error) {
 	return &models.Message{ID: 1, SenderID: senderID, ConvID: conversationID, Msg: content}, nil
 }
 
-func (m *mockChatRepository) MarkMessagesAsRead(conversationID, userID uint) error {
+func (m *mockChatRepository) MarkMessagesDelivered(conversationID, userID uint, messageIDs []uint, at time.Time) ([]uint, error) {
+	return messageIDs, nil
+}
+
+func (m *mockChatRepository) MarkMessagesAsRead(conversationID, userID, upToID uint, at time.Time) ([]uint, error) {
+	return nil, nil
+}
+
+func (m *mockChatRepository) GetChatSettings(userID uint) (*models.ChatSettings, error) {
+	return models.DefaultChatSettings(userID), nil
+}
+
+func (m *mockChatRepository) SaveChatSettings(settings *models.ChatSettings) error {
 	return nil
 }
 
+func (m *mockChatRepository) IsPremium(userID uint) (bool, error) {
+	return false, nil
+}
+
 func (m *mockChatRepository) GetUnreadCount(conversationID, userID uint) (int64, error) {
 	return 0, nil
 }
@@ -117,7 +159,7 @@ func TestHubBroadcastMessage(t *testing.T) {
 	}
 
 	hub := NewHub(nil, mockRepo)
-	go hub.Run() // Start hub in background
+	go hub.Run(context.Background()) // Start hub in background
 
 	// Give hub time to start
 	time.Sleep(10 * time.Millisecond)
@@ -134,4 +176,426 @@ func TestHubBroadcastMessage(t *testing.T) {
 
 	// Test broadcast to specific user
 	hub.BroadcastToUser(200, msg)
-}
\ No newline at end of file
+}
+
+func TestHubBroadcastQueueDepth(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: map[uint][]uint{1: {100}}})
+	msg := OutgoingMessage{Type: MessageTypeNewMessage, Timestamp: time.Now()}
+
+	// Nothing takes the broadcasts until the hub runs
+	for i := 0; i < 3; i++ {
+		go hub.BroadcastToConversation(1, msg, 0)
+	}
+	waitForQueueDepth(t, hub, 3)
+
+	go hub.Run(context.Background())
+	waitForQueueDepth(t, hub, 0)
+}
+
+func waitForQueueDepth(t *testing.T, hub *Hub, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for hub.BroadcastQueueDepth() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d queued broadcasts, got %d", want, hub.BroadcastQueueDepth())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+func newTestConnection(hub *Hub, userID uint) *Connection {
+	return &Connection{
+		id:     newConnectionID(),
+		userID: userID,
+		send:   make(chan OutgoingMessage, 16),
+		hub:    hub,
+	}
+}
+
+func TestHubMultipleDevices(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: make(map[uint][]uint)})
+
+	phone := newTestConnection(hub, 100)
+	laptop := newTestConnection(hub, 100)
+	hub.registerConnection(phone)
+	hub.registerConnection(laptop)
+
+	if phone.closed || laptop.closed {
+		t.Fatal("Expected both devices to stay connected")
+	}
+	if got := hub.ConnectionCount(); got != 2 {
+		t.Fatalf("Expected 2 connections, got %d", got)
+	}
+	if got := len(hub.GetConnectedUsers()); got != 1 {
+		t.Fatalf("Expected 1 connected user, got %d", got)
+	}
+
+	// Drain the connected confirmations
+	for _, conn := range []*Connection{phone, laptop} {
+		msg := <-conn.send
+		data := msg.Data.(ConnectionData)
+		if msg.Type != MessageTypeConnected || data.ConnectionID != conn.id {
+			t.Fatalf("Expected connected confirmation with ID %s, got %+v", conn.id, msg)
+		}
+	}
+
+	hub.BroadcastToUser(100, OutgoingMessage{Type: MessageTypeNewMessage})
+	for _, conn := range []*Connection{phone, laptop} {
+		select {
+		case msg := <-conn.send:
+			if msg.Type != MessageTypeNewMessage {
+				t.Fatalf("Expected new_message, got %s", msg.Type)
+			}
+		default:
+			t.Fatal("Expected every device to receive the broadcast")
+		}
+	}
+
+	hub.unregisterConnection(phone)
+	if !phone.closed {
+		t.Error("Expected the phone connection to be closed")
+	}
+	if !hub.IsUserOnline(100) {
+		t.Error("Expected user to stay online while the laptop is connected")
+	}
+
+	// Unregistering twice is a no-op
+	hub.unregisterConnection(phone)
+	if got := hub.ConnectionCount(); got != 1 {
+		t.Fatalf("Expected 1 connection, got %d", got)
+	}
+
+	hub.unregisterConnection(laptop)
+	if hub.IsUserOnline(100) {
+		t.Error("Expected user to be offline once the last device left")
+	}
+}
+
+// sharedPresence stands for the Redis counter shared by the replicas
+type sharedPresence struct {
+	connections map[uint]map[string]bool
+}
+
+func (p *sharedPresence) Connect(userID uint, connID string) (bool, error) {
+	if p.connections[userID] == nil {
+		p.connections[userID] = make(map[string]bool)
+	}
+	p.connections[userID][connID] = true
+	return len(p.connections[userID]) == 1, nil
+}
+
+func (p *sharedPresence) Disconnect(userID uint, connID string) (bool, error) {
+	delete(p.connections[userID], connID)
+	return len(p.connections[userID]) == 0, nil
+}
+
+func (p *sharedPresence) Refresh(map[uint][]string) error { return nil }
+
+// presenceChatService records the presence changes of the hub
+type presenceChatService struct {
+	types.ChatService
+	changes []string
+}
+
+func (s *presenceChatService) SetUserOnline(userID uint) error {
+	s.changes = append(s.changes, "online")
+	return nil
+}
+
+func (s *presenceChatService) SetUserOffline(userID uint) error {
+	s.changes = append(s.changes, "offline")
+	return nil
+}
+
+func (s *presenceChatService) GetUserPresence(userID uint) (*models.UserPresence, error) {
+	return &models.UserPresence{UserID: userID}, nil
+}
+
+func TestHubKeepsUserOnlineWhileAnotherReplicaHoldsADevice(t *testing.T) {
+	presence := &sharedPresence{connections: make(map[uint]map[string]bool)}
+	chatService := &presenceChatService{}
+	hub := NewHub(chatService, &mockChatRepository{participants: make(map[uint][]uint)})
+	hub.SetPresence(presence)
+
+	// The phone is connected to another replica
+	presence.Connect(100, "phone")
+
+	laptop := newTestConnection(hub, 100)
+	hub.registerConnection(laptop)
+	hub.unregisterConnection(laptop)
+	if len(chatService.changes) != 0 {
+		t.Fatalf("Expected no presence change while the phone is connected, got %v", chatService.changes)
+	}
+
+	presence.Disconnect(100, "phone")
+	laptop = newTestConnection(hub, 100)
+	hub.registerConnection(laptop)
+	hub.unregisterConnection(laptop)
+	if got := strings.Join(chatService.changes, ","); got != "online,offline" {
+		t.Fatalf("Expected online then offline, got %s", got)
+	}
+}
+
+type recordingRelay struct {
+	events     []types.Event
+	recipients [][]uint
+	presence   []types.Event
+}
+
+func (r *recordingRelay) Publish(ctx context.Context, event *types.Event, userIDs []uint) (int, error) {
+	r.events = append(r.events, *event)
+	r.recipients = append(r.recipients, userIDs)
+	return len(userIDs), nil
+}
+
+func (r *recordingRelay) PublishPresence(ctx context.Context, event *types.Event) error {
+	r.presence = append(r.presence, *event)
+	return nil
+}
+
+func TestHubRelaysBroadcasts(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: map[uint][]uint{1: {100, 200}}})
+	relay := &recordingRelay{}
+	hub.SetRelay(relay)
+
+	conn := newTestConnection(hub, 200)
+	hub.registerConnection(conn)
+	<-conn.send // connected confirmation
+
+	typing := OutgoingMessage{Type: MessageTypeTyping, ConversationID: 1, Data: TypingData{UserID: 100, IsTyping: true}}
+	hub.handleBroadcast(BroadcastMessage{Message: typing, ConversationID: 1, ExcludeUserID: 100, EventID: "evt-1"})
+
+	if len(conn.send) != 1 {
+		t.Fatalf("Expected the local participant to receive the event, got %d messages", len(conn.send))
+	}
+	if len(relay.events) != 1 || relay.events[0].ID != "evt-1" || relay.events[0].UserID != 100 {
+		t.Fatalf("Expected the event to be relayed with its ID and author, got %+v", relay.events)
+	}
+	if len(relay.recipients[0]) != 1 || relay.recipients[0][0] != 200 {
+		t.Fatalf("Expected the relay to skip the excluded user, got %v", relay.recipients[0])
+	}
+
+	// The Redis echo of a local delivery is dropped
+	hub.HandleEvent(200, relay.events[0])
+	if len(conn.send) != 1 {
+		t.Fatalf("Expected the echo to be dropped, got %d messages", len(conn.send))
+	}
+
+	// Local-only deliveries are not published again
+	hub.handleBroadcast(BroadcastMessage{Message: typing, ConversationID: 1, EventID: "evt-2", LocalOnly: true})
+	if len(relay.events) != 1 {
+		t.Fatalf("Expected local-only deliveries not to be relayed, got %d events", len(relay.events))
+	}
+}
+
+func TestHubHandleEventFromOtherReplica(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: make(map[uint][]uint)})
+
+	phone := newTestConnection(hub, 200)
+	laptop := newTestConnection(hub, 200)
+	hub.registerConnection(phone)
+	hub.registerConnection(laptop)
+	<-phone.send
+	<-laptop.send
+
+	// The phone sent the message and already got its confirmation
+	event := types.Event{
+		ID:             types.MessageEventID(9),
+		Type:           types.EventNewMessage,
+		ConversationID: 1,
+		Message:        &models.Message{ID: 9, ConvID: 1, SenderID: 200, Msg: "hello"},
+	}
+	hub.deliverTo(phone, event.ID, OutgoingMessage{Type: MessageTypeNewMessage})
+	<-phone.send
+
+	hub.HandleEvent(200, event)
+	hub.HandleEvent(200, event)
+
+	if len(phone.send) != 0 {
+		t.Fatalf("Expected the sending device not to get the message twice, got %d", len(phone.send))
+	}
+	if len(laptop.send) != 1 {
+		t.Fatalf("Expected the other device to get the message once, got %d", len(laptop.send))
+	}
+	msg := <-laptop.send
+	if data, ok := msg.Data.(MessageData); !ok || data.ID != 9 || data.Message != "hello" {
+		t.Fatalf("Expected new message data, got %+v", msg.Data)
+	}
+
+	// Presence broadcasts reach everyone but the user they are about
+	hub.HandleEvent(0, types.Event{ID: "p1", Type: types.EventPresenceUpdate, UserID: 200})
+	if len(phone.send) != 0 || len(laptop.send) != 0 {
+		t.Fatal("Expected presence updates not to be sent to the user themselves")
+	}
+}
+
+func TestHubMessageDeletedForMe(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: map[uint][]uint{1: {100, 200}}})
+	relay := &recordingRelay{}
+	hub.SetRelay(relay)
+
+	author := newTestConnection(hub, 100)
+	other := newTestConnection(hub, 200)
+	hub.registerConnection(author)
+	hub.registerConnection(other)
+	<-author.send // connected confirmation
+	<-other.send
+
+	message := &models.Message{ID: 7, ConvID: 1, SenderID: 200}
+	eventID := hub.BroadcastMessageDeleted(message, 100, models.DeleteForMe)
+
+	if len(author.send) != 1 {
+		t.Fatalf("Expected the deleting user to receive message_deleted, got %d messages", len(author.send))
+	}
+	if msg := <-author.send; msg.Type != MessageTypeMessageDeleted {
+		t.Fatalf("Expected message_deleted, got %s", msg.Type)
+	}
+	if len(other.send) != 0 {
+		t.Fatalf("Expected the other participant not to be told, got %d messages", len(other.send))
+	}
+	if len(relay.events) != 1 || relay.events[0].ID != eventID || len(relay.recipients[0]) != 1 || relay.recipients[0][0] != 100 {
+		t.Fatalf("Expected the event to be relayed to the deleting user only, got %+v %v", relay.events, relay.recipients)
+	}
+}
+
+func TestHubReceiptGoesToSender(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: map[uint][]uint{1: {100, 200}}})
+	relay := &recordingRelay{}
+	hub.SetRelay(relay)
+
+	sender := newTestConnection(hub, 100)
+	reader := newTestConnection(hub, 200)
+	hub.registerConnection(sender)
+	hub.registerConnection(reader)
+	<-sender.send // connected confirmation
+	<-reader.send
+
+	eventID := hub.BroadcastReceipt(&types.Receipt{
+		ConversationID: 1,
+		SenderID:       100,
+		UserID:         200,
+		Status:         models.ReceiptRead,
+		MessageIDs:     []uint{7, 8},
+		At:             time.Now(),
+	})
+
+	if len(sender.send) != 1 {
+		t.Fatalf("Expected the sender to receive message_read, got %d messages", len(sender.send))
+	}
+	if msg := <-sender.send; msg.Type != MessageTypeRead {
+		t.Fatalf("Expected message_read, got %s", msg.Type)
+	}
+	if len(reader.send) != 0 {
+		t.Fatalf("Expected the reader not to be told, got %d messages", len(reader.send))
+	}
+	if len(relay.events) != 1 || relay.events[0].ID != eventID || relay.events[0].UserID != 200 || relay.recipients[0][0] != 100 {
+		t.Fatalf("Expected the receipt to be relayed to the sender only, got %+v %v", relay.events, relay.recipients)
+	}
+
+	if hub.BroadcastReceipt(nil) != "" || len(sender.send) != 0 || len(relay.events) != 1 {
+		t.Fatal("Expected a nil receipt to send nothing")
+	}
+}
+
+func TestHubConversationSettingsGoToUser(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: map[uint][]uint{1: {100, 200}}})
+	relay := &recordingRelay{}
+	hub.SetRelay(relay)
+
+	user := newTestConnection(hub, 100)
+	other := newTestConnection(hub, 200)
+	hub.registerConnection(user)
+	hub.registerConnection(other)
+	<-user.send // connected confirmation
+	<-other.send
+
+	eventID := hub.BroadcastConversationSettings(100, &models.ConversationSettings{ConversationID: 1, UserID: 100, Pinned: true})
+
+	if len(user.send) != 1 {
+		t.Fatalf("Expected the user to receive conversation_settings, got %d messages", len(user.send))
+	}
+	if msg := <-user.send; msg.Type != MessageTypeConvSettings || msg.ConversationID != 1 {
+		t.Fatalf("Expected conversation_settings for conversation 1, got %s for %d", msg.Type, msg.ConversationID)
+	}
+	if len(other.send) != 0 {
+		t.Fatalf("Expected the other participant not to be told, got %d messages", len(other.send))
+	}
+	if len(relay.events) != 1 || relay.events[0].ID != eventID || relay.recipients[0][0] != 100 {
+		t.Fatalf("Expected the settings to be relayed to the user only, got %+v %v", relay.events, relay.recipients)
+	}
+}
+
+func TestHubDrainClosesConnectionsForRestart(t *testing.T) {
+	hub := NewHub(nil, &mockChatRepository{participants: make(map[uint][]uint)})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		if r.URL.Path == "/gateway" {
+			hub.HandleGatewayConnection(r.Context(), conn)
+			return
+		}
+		wsConn := NewConnection(conn, 100, hub)
+		hub.RegisterConnection(wsConn)
+		wsConn.Start(nil)
+	}))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	client, _, err := websocket.DefaultDialer.Dial(url+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+	gateway, _, err := websocket.DefaultDialer.Dial(url+"/gateway", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect the gateway: %v", err)
+	}
+	defer gateway.Close()
+
+	var connected OutgoingMessage
+	if err := client.ReadJSON(&connected); err != nil || connected.Type != MessageTypeConnected {
+		t.Fatalf("Expected connected confirmation, got %+v (%v)", connected, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for hub.gatewayCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the gateway connection to be tracked")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer drainCancel()
+	if err := hub.Drain(drainCtx); err != nil {
+		t.Fatalf("Expected the connections to be drained, got %v", err)
+	}
+	if hub.ConnectionCount() != 0 || hub.gatewayCount() != 0 {
+		t.Fatalf("Expected no connection left, got %d and %d gateway(s)", hub.ConnectionCount(), hub.gatewayCount())
+	}
+
+	for _, conn := range []*websocket.Conn{client, gateway} {
+		_, _, err := conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseServiceRestart {
+			t.Fatalf("Expected a service restart close frame, got %v", err)
+		}
+		if !strings.Contains(closeErr.Text, "reconnect_after_ms") {
+			t.Errorf("Expected a reconnect hint, got %q", closeErr.Text)
+		}
+	}
+
+	// Once stopped, the hub no longer blocks its callers
+	cancel()
+	<-hub.stopped
+	hub.BroadcastToConversation(1, OutgoingMessage{Type: MessageTypeNewMessage}, 0)
+	hub.UnregisterConnection(newTestConnection(hub, 100))
+	if got := hub.BroadcastQueueDepth(); got != 0 {
+		t.Errorf("Expected the broadcast to be dropped, got %d queued", got)
+	}
+}
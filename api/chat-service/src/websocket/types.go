@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"chat-service/src/models"
 	"time"
 )
 
@@ -19,6 +20,15 @@ const (
 	MessageTypeReactionRemove MessageType = "reaction_remove"
 	MessageTypeReactionUpdate MessageType = "reaction_update"
 	MessageTypePresenceUpdate MessageType = "presence_update"
+	MessageTypeEditMessage    MessageType = "edit_message"
+	MessageTypeDeleteMessage  MessageType = "delete_message"
+	MessageTypeMessageEdited  MessageType = "message_edited"
+	MessageTypeMessageDeleted MessageType = "message_deleted"
+	MessageTypeAckDelivered   MessageType = "ack_delivered"
+	MessageTypeAckRead        MessageType = "ack_read"
+	MessageTypeDelivered      MessageType = "message_delivered"
+	MessageTypeRead           MessageType = "message_read"
+	MessageTypeConvSettings   MessageType = "conversation_settings"
 )
 
 // WSMessage represents a WebSocket message
@@ -35,8 +45,11 @@ type IncomingMessage struct {
 	ConversationID uint        `json:"conversation_id,omitempty"`
 	Content        string      `json:"content,omitempty"`
 	IsTyping       bool        `json:"is_typing,omitempty"`
-	MessageID      uint        `json:"message_id,omitempty"`
+	MessageID      uint        `json:"message_id,omitempty"`  // ack_read: read up to this message
+	MessageIDs     []uint      `json:"message_ids,omitempty"` // ack_delivered
 	Emoji          string      `json:"emoji,omitempty"`
+	Scope          string      `json:"scope,omitempty"` // delete_message: "me" or "everyone"
+	AttachmentIDs  []uint      `json:"attachment_ids,omitempty"`
 }
 
 // OutgoingMessage represents messages sent to clients
@@ -49,11 +62,25 @@ type OutgoingMessage struct {
 
 // MessageData represents the data payload for new messages
 type MessageData struct {
-	ID        uint      `json:"id"`
-	SenderID  uint      `json:"sender_id"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
-	ReadAt    *time.Time `json:"read_at"`
+	ID          uint                       `json:"id"`
+	SenderID    uint                       `json:"sender_id"`
+	Message     string                     `json:"message"`
+	Timestamp   time.Time                  `json:"timestamp"`
+	ReadAt      *time.Time                 `json:"read_at"`
+	Warning     string                     `json:"warning,omitempty"` // recipients show the content blurred
+	Attachments []models.MessageAttachment `json:"attachments,omitempty"`
+}
+
+func newMessageData(message *models.Message) MessageData {
+	return MessageData{
+		ID:          message.ID,
+		SenderID:    message.SenderID,
+		Message:     message.Msg,
+		Timestamp:   message.Time,
+		ReadAt:      message.ReadAt,
+		Warning:     message.Warning,
+		Attachments: message.Attachments,
+	}
 }
 
 // TypingData represents typing notification data
@@ -70,9 +97,10 @@ type ErrorData struct {
 
 // ConnectionData represents connection status data
 type ConnectionData struct {
-	UserID    uint   `json:"user_id"`
-	Status    string `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
+	UserID       uint      `json:"user_id"`
+	ConnectionID string    `json:"connection_id,omitempty"`
+	Status       string    `json:"status"`
+	Timestamp    time.Time `json:"timestamp"`
 }
 
 // ReactionData represents reaction event data
@@ -83,6 +111,23 @@ type ReactionData struct {
 	Action    string `json:"action"` // "add" or "remove"
 }
 
+// MessageEditedData represents the new content of an edited message
+type MessageEditedData struct {
+	MessageID uint       `json:"message_id"`
+	SenderID  uint       `json:"sender_id"`
+	Message   string     `json:"message"`
+	EditedAt  *time.Time `json:"edited_at"`
+	Warning   string     `json:"warning,omitempty"`
+}
+
+// MessageDeletedData represents a message deletion; with scope "me" it only
+// goes to the devices of the user who deleted it
+type MessageDeletedData struct {
+	MessageID uint   `json:"message_id"`
+	UserID    uint   `json:"user_id"`
+	Scope     string `json:"scope"`
+}
+
 // PresenceData represents user presence data
 type PresenceData struct {
 	UserID   uint  `json:"user_id"`
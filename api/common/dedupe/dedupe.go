@@ -0,0 +1,60 @@
+// Package dedupe remembers recent deliveries of real-time events, so that an
+// event reaching a connection twice (delivered locally, then relayed or
+// echoed back by Redis) is only sent once. It is used by the chat-service hub
+// and by the gateway WebSocket manager.
+package dedupe
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache keeps the keys of the deliveries made during the last ttl
+type Cache struct {
+	ttl       time.Duration
+	mutex     sync.Mutex
+	entries   map[string]time.Time
+	lastPrune time.Time
+}
+
+// New creates a cache keeping deliveries for ttl
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:       ttl,
+		entries:   make(map[string]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+// MarkDelivered records the delivery and reports whether it is the first one
+func (d *Cache) MarkDelivered(key string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(d.lastPrune) > d.ttl {
+		d.prune(now)
+	}
+
+	if seenAt, exists := d.entries[key]; exists && now.Sub(seenAt) <= d.ttl {
+		return false
+	}
+	d.entries[key] = now
+	return true
+}
+
+// Len returns the number of remembered deliveries
+func (d *Cache) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.entries)
+}
+
+func (d *Cache) prune(now time.Time) {
+	for key, seenAt := range d.entries {
+		if now.Sub(seenAt) > d.ttl {
+			delete(d.entries, key)
+		}
+	}
+	d.lastPrune = now
+}
@@ -0,0 +1,34 @@
+package dedupe
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkDelivered(t *testing.T) {
+	d := New(time.Minute)
+
+	if !d.MarkDelivered("message:1/conn-a") {
+		t.Fatal("Expected first delivery to be accepted")
+	}
+	if d.MarkDelivered("message:1/conn-a") {
+		t.Fatal("Expected second delivery to the same connection to be dropped")
+	}
+	if !d.MarkDelivered("message:1/conn-b") {
+		t.Fatal("Expected delivery to another connection to be accepted")
+	}
+}
+
+func TestExpires(t *testing.T) {
+	d := New(10 * time.Millisecond)
+	d.MarkDelivered("a")
+	time.Sleep(20 * time.Millisecond)
+
+	if !d.MarkDelivered("a") {
+		t.Fatal("Expected an expired delivery to be accepted again")
+	}
+	d.MarkDelivered("b")
+	if d.Len() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", d.Len())
+	}
+}
@@ -0,0 +1,73 @@
+// Package internalapi guards the /internal routes that the Matcha services
+// call on each other.
+package internalapi
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxg56/matcha/api/common/utils"
+)
+
+// KeyHeader carries the INTERNAL_API_KEY shared by the services
+const KeyHeader = "X-Internal-Key"
+
+// Middleware restricts a route to other Matcha services.
+//
+// When INTERNAL_API_KEY is set the X-Internal-Key header must match it.
+// Without a key, development setups accept the callers connected from a
+// loopback or private network address; the address is the one of the TCP
+// connection, X-Forwarded-For and X-Real-IP are not trusted. Outside
+// development a missing key is logged when the route is set up and every
+// request is refused.
+func Middleware() gin.HandlerFunc {
+	expectedKey := os.Getenv("INTERNAL_API_KEY")
+	development := Development()
+	if expectedKey == "" && !development {
+		slog.Error("INTERNAL_API_KEY is not set, the internal routes refuse every request")
+	}
+
+	return func(c *gin.Context) {
+		switch {
+		case expectedKey != "":
+			if subtle.ConstantTimeCompare([]byte(c.GetHeader(KeyHeader)), []byte(expectedKey)) == 1 {
+				c.Next()
+				return
+			}
+		case !development:
+			utils.RespondErrorWithAbort(c, http.StatusInternalServerError, "internal API key not configured")
+			return
+		case privatePeer(c.Request.RemoteAddr):
+			c.Next()
+			return
+		}
+
+		utils.RespondErrorWithAbort(c, http.StatusUnauthorized, "internal service access required")
+	}
+}
+
+// Development reports whether the service runs in development: ENVIRONMENT
+// is "development", or ENVIRONMENT is unset and Gin is not in release mode
+// (GIN_MODE=release in docker-compose.prode.yml).
+func Development() bool {
+	if environment := os.Getenv("ENVIRONMENT"); environment != "" {
+		return environment == "development"
+	}
+	return gin.Mode() != gin.ReleaseMode
+}
+
+// privatePeer reports whether remoteAddr, the host:port of a connection, is
+// a loopback or private network address
+func privatePeer(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
@@ -0,0 +1,74 @@
+package internalapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func serve(remoteAddr string, header map[string]string) int {
+	r := gin.New()
+	r.GET("/internal", Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/internal", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestMiddleware_WithKey(t *testing.T) {
+	t.Setenv("INTERNAL_API_KEY", "secret")
+	t.Setenv("ENVIRONMENT", "production")
+
+	assert.Equal(t, http.StatusOK, serve("203.0.113.7:4000", map[string]string{KeyHeader: "secret"}))
+	assert.Equal(t, http.StatusUnauthorized, serve("203.0.113.7:4000", map[string]string{KeyHeader: "wrong"}))
+	// The network does not replace the key once one is set
+	assert.Equal(t, http.StatusUnauthorized, serve("10.0.0.3:4000", nil))
+}
+
+func TestMiddleware_WithoutKeyInDevelopment(t *testing.T) {
+	t.Setenv("INTERNAL_API_KEY", "")
+	t.Setenv("ENVIRONMENT", "development")
+
+	assert.Equal(t, http.StatusOK, serve("127.0.0.1:4000", nil))
+	assert.Equal(t, http.StatusOK, serve("172.18.0.5:4000", nil))
+	assert.Equal(t, http.StatusOK, serve("[::1]:4000", nil))
+	assert.Equal(t, http.StatusUnauthorized, serve("203.0.113.7:4000", nil))
+	// Forwarding headers are not trusted
+	assert.Equal(t, http.StatusUnauthorized, serve("203.0.113.7:4000", map[string]string{
+		"X-Forwarded-For": "10.0.0.3",
+		"X-Real-IP":       "10.0.0.3",
+	}))
+}
+
+func TestMiddleware_WithoutKeyOutsideDevelopment(t *testing.T) {
+	t.Setenv("INTERNAL_API_KEY", "")
+	t.Setenv("ENVIRONMENT", "production")
+
+	assert.Equal(t, http.StatusInternalServerError, serve("127.0.0.1:4000", nil))
+}
+
+func TestDevelopment(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "")
+	previous := gin.Mode()
+	t.Cleanup(func() { gin.SetMode(previous) })
+
+	gin.SetMode(gin.ReleaseMode)
+	assert.False(t, Development())
+	gin.SetMode(gin.DebugMode)
+	assert.True(t, Development())
+
+	t.Setenv("ENVIRONMENT", "staging")
+	assert.False(t, Development())
+}
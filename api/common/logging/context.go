@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// requestFields are the fields of the request a context belongs to. The user
+// ID is set once the request is authenticated, after the fields are created.
+type requestFields struct {
+	requestID string
+	route     string
+
+	mu     sync.RWMutex
+	userID string
+}
+
+// WithRequest returns ctx carrying the request ID and route of a request, to
+// be added to the records logged with it
+func WithRequest(ctx context.Context, requestID, route string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestFields{requestID: requestID, route: route})
+}
+
+// SetUserID records the authenticated user of the request of ctx. It does
+// nothing when ctx does not come from WithRequest.
+func SetUserID(ctx context.Context, userID string) {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		fields.mu.Lock()
+		fields.userID = userID
+		fields.mu.Unlock()
+	}
+}
+
+// RequestID returns the request ID of ctx, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		return fields.requestID
+	}
+	return ""
+}
+
+// requestAttrs returns the request fields of ctx and its trace ID
+func requestAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		attrs = append(attrs, slog.String("request_id", fields.requestID))
+		if fields.route != "" {
+			attrs = append(attrs, slog.String("route", fields.route))
+		}
+		fields.mu.RLock()
+		if fields.userID != "" {
+			attrs = append(attrs, slog.String("user_id", fields.userID))
+		}
+		fields.mu.RUnlock()
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return attrs
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request from the gateway to the
+// services, and back to the client
+const RequestIDHeader = "X-Request-ID"
+
+// quietPaths are polled by Docker and Prometheus, their requests are not
+// logged
+var quietPaths = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// validRequestID keeps the IDs sent by the clients from forging log lines
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Middleware gives each request an ID, kept from the X-Request-ID header
+// when the caller sent one, and adds it to the request context with the
+// route and the user. It logs a line per request once it is answered.
+//
+// The user is the one forwarded by the gateway in X-User-ID; the gateway
+// itself calls SetUserID once the JWT is checked.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+			// Forwarded as is by the proxies of the gateway
+			c.Request.Header.Set(RequestIDHeader, requestID)
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := WithRequest(c.Request.Context(), requestID, c.FullPath())
+		if userID := c.GetHeader("X-User-ID"); userID != "" {
+			SetUserID(ctx, userID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if quietPaths[c.Request.URL.Path] {
+			return
+		}
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Error()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
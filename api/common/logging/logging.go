@@ -0,0 +1,125 @@
+// Package logging sets up the log/slog logger of a service: one record per
+// line, in JSON or text, carrying the service name and the fields of the
+// request being handled (request ID, user ID, route, trace ID), with tokens
+// and emails redacted.
+//
+//	func main() {
+//		logging.Init("match-service")
+//		r := gin.New()
+//		r.Use(gin.Recovery(), logging.Middleware())
+//		...
+//		slog.InfoContext(c.Request.Context(), "match created", "match_id", id)
+//	}
+//
+// The output is configured with LOG_LEVEL (debug, info, warn or error; info
+// by default) and LOG_FORMAT (json or text; json when GIN_MODE=release, text
+// otherwise).
+//
+// Lines written with the standard log package go through the same logger at
+// info level, without request fields: use slog.*Context instead.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formats accepted in LOG_FORMAT
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configures a logger
+type Options struct {
+	Level  slog.Level
+	Format string
+	Output io.Writer
+}
+
+// OptionsFromEnv reads LOG_LEVEL and LOG_FORMAT
+func OptionsFromEnv() Options {
+	format := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
+	if format != FormatJSON && format != FormatText {
+		format = FormatText
+		if os.Getenv("GIN_MODE") == "release" {
+			format = FormatJSON
+		}
+	}
+	return Options{
+		Level:  ParseLevel(os.Getenv("LOG_LEVEL")),
+		Format: format,
+		Output: os.Stderr,
+	}
+}
+
+// ParseLevel parses a LOG_LEVEL value, info when empty or unknown
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error", "fatal":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Init installs the logger of the service as the slog and log default, with
+// the options of the environment
+func Init(service string) *slog.Logger {
+	logger := New(service, OptionsFromEnv())
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New returns a logger writing the records of service, with the request
+// fields of their context, to opts.Output
+func New(service string, opts Options) *slog.Logger {
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+	handlerOptions := &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var base slog.Handler
+	if opts.Format == FormatJSON {
+		base = slog.NewJSONHandler(opts.Output, handlerOptions)
+	} else {
+		base = slog.NewTextHandler(opts.Output, handlerOptions)
+	}
+	return slog.New(&handler{
+		next:  base.WithAttrs([]slog.Attr{slog.String("service", service)}),
+		level: opts.Level,
+	})
+}
+
+// handler adds the request fields of the context to the records
+type handler struct {
+	next  slog.Handler
+	level slog.Level
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(requestAttrs(ctx)...)
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{next: h.next.WithAttrs(attrs), level: h.level}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), level: h.level}
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the secrets removed from the logs
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the parts of attribute names whose value is never logged
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key", "apikey", "signature"}
+
+var (
+	// JWTs, as sent by the clients and between the services
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// Authorization header values
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	// Secrets in query strings and key=value pairs
+	secretParamPattern = regexp.MustCompile(`(?i)\b(token|access_token|refresh_token|password|secret|api_key)=[^&\s"']+`)
+	// Stripe secret keys and webhook secrets
+	stripeKeyPattern = regexp.MustCompile(`\b(sk|rk)_(live|test)_[A-Za-z0-9]+|\bwhsec_[A-Za-z0-9]+`)
+	emailPattern     = regexp.MustCompile(`[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}`)
+)
+
+// Redact removes the tokens and secrets from s, and masks the local part of
+// the email addresses it contains. The domain of the emails is kept, it
+// helps debugging delivery issues.
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	s = secretParamPattern.ReplaceAllString(s, "$1="+Redacted)
+	s = stripeKeyPattern.ReplaceAllString(s, Redacted)
+	return emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		return "***" + email[strings.LastIndex(email, "@"):]
+	})
+}
+
+// redactAttr is the ReplaceAttr of the handlers: it runs on the message and
+// on every attribute
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if redacted := Redact(a.Value.String()); redacted != a.Value.String() {
+			return slog.String(a.Key, redacted)
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// Package metrics exposes the Prometheus metrics of a service on /metrics:
+// RED metrics of the gin routes (rate, errors, duration), the connection
+// pool of the database, cache lookups, open WebSocket connections, and the
+// metrics the service declares itself with promauto.
+//
+//	r := gin.Default()
+//	metrics.Register(r)
+//	metrics.RegisterDB(conf.DB, "match")
+//
+// Every service is a scrape target of its own, its name is the job label.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// Path is the route serving the metrics
+const Path = "/metrics"
+
+// unmatchedRoute labels the requests that matched no route, so that
+// scanners cannot create a series per path
+const unmatchedRoute = "unmatched"
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to answer HTTP requests, by route and method.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"route", "method"})
+
+	requestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests being handled.",
+	})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Cache lookups, by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+)
+
+// Register adds the RED middleware and the /metrics route to r. It must be
+// called before the routes are declared.
+func Register(r *gin.Engine) {
+	r.Use(Middleware())
+	r.GET(Path, Handler())
+}
+
+// Middleware counts the requests by route, method and status code, and times
+// them. WebSocket upgrades are counted but not timed, a connection lasting
+// for hours would skew the histogram.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == Path {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		websocket := c.IsWebsocket()
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		requests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		if !websocket {
+			requestDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+		}
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// RegisterDB exposes the connection pool stats of db as the go_sql_* metrics,
+// labeled with db_name
+func RegisterDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return prometheus.Register(collectors.NewDBStatsCollector(sqlDB, name))
+}
+
+// CacheLookup counts a lookup in cache. The hit ratio is
+//
+//	sum by (cache) (rate(cache_lookups_total{result="hit"}[5m]))
+//	  / sum by (cache) (rate(cache_lookups_total[5m]))
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// GaugeFunc exposes a gauge whose value is read from value at each scrape
+func GaugeFunc(name, help string, value func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, value)
+}
+
+// WebSocketConnections exposes the number of open WebSocket connections,
+// read from count at each scrape
+func WebSocketConnections(count func() int) {
+	GaugeFunc("websocket_connections", "Open WebSocket connections.", func() float64 {
+		return float64(count())
+	})
+}
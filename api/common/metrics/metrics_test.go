@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestMiddleware_CountsRequestsByRoute(t *testing.T) {
+	r := gin.New()
+	Register(r)
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(requests.WithLabelValues("/users/:id", "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues(unmatchedRoute, "GET", "404")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(requestsInFlight))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`)
+	assert.NotContains(t, body, `route="/metrics"`, "scrapes are not counted")
+}
+
+func TestCacheLookup(t *testing.T) {
+	CacheLookup("test", true)
+	CacheLookup("test", true)
+	CacheLookup("test", false)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(cacheLookups.WithLabelValues("test", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cacheLookups.WithLabelValues("test", "miss")))
+}
+
+func TestHandler_ExposesPoolStatsAndGauges(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, RegisterDB(db, "test"))
+	assert.Error(t, RegisterDB(db, "test"), "a database is registered once")
+
+	WebSocketConnections(func() int { return 3 })
+
+	w := httptest.NewRecorder()
+	Handler()(testContext(w))
+	body := w.Body.String()
+	assert.Contains(t, body, `go_sql_max_open_connections{db_name="test"}`)
+	assert.Contains(t, body, "websocket_connections 3")
+}
+
+func testContext(w http.ResponseWriter) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, Path, nil)
+	return c
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultShutdownTimeout leaves time to exit before Docker kills the
+	// container, 10 seconds after SIGTERM
+	DefaultShutdownTimeout = 8 * time.Second
+	// checkTimeout bounds each readiness check
+	checkTimeout = 2 * time.Second
+	// reconnectSpread is the window over which the WebSocket clients of a
+	// stopping service are told to reconnect
+	reconnectSpread = 5 * time.Second
+)
+
+// Check reports whether a dependency of the service can be used
+type Check func(ctx context.Context) error
+
+// Hook is a step of the shutdown, given the time left to exit
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	run  Hook
+}
+
+// Lifecycle runs a service: its HTTP server, its background workers and its
+// readiness, and stops them in order on SIGINT or SIGTERM:
+//
+//  1. the service reports itself not ready, and stops accepting requests
+//     while the ones in flight are answered
+//  2. the drain hooks run, e.g. to close the WebSocket connections
+//  3. the context of the workers is cancelled, and they are waited for
+//  4. the flush hooks run, e.g. to send what is left in an outbox
+//  5. the close hooks run, e.g. to close the DB and Redis clients
+//
+// All of it must fit in ShutdownTimeout.
+type Lifecycle struct {
+	// ShutdownTimeout is the time given to the whole shutdown
+	ShutdownTimeout time.Duration
+
+	service  string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	workers  sync.WaitGroup
+	stopping atomic.Bool
+
+	mu     sync.Mutex
+	checks map[string]Check
+	drain  []namedHook
+	flush  []namedHook
+	close  []namedHook
+}
+
+// New creates the lifecycle of a service
+func New(service string) *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		ShutdownTimeout: DefaultShutdownTimeout,
+		service:         service,
+		ctx:             ctx,
+		cancel:          cancel,
+		checks:          make(map[string]Check),
+	}
+}
+
+// Context is cancelled when the workers must stop
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Go runs a background worker. The worker must return once ctx is cancelled,
+// the shutdown waits for it.
+func (l *Lifecycle) Go(name string, worker func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		worker(l.ctx)
+		if l.ctx.Err() == nil {
+			slog.Warn("worker stopped before shutdown", "worker", name)
+		}
+	}()
+}
+
+// AddCheck adds a dependency checked by the readiness probe
+func (l *Lifecycle) AddCheck(name string, check Check) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checks[name] = check
+}
+
+// OnDrain adds a hook run once the HTTP server no longer accepts requests,
+// before the workers are stopped
+func (l *Lifecycle) OnDrain(name string, hook Hook) {
+	l.addHook(&l.drain, name, hook)
+}
+
+// OnFlush adds a hook run once the workers are stopped
+func (l *Lifecycle) OnFlush(name string, hook Hook) {
+	l.addHook(&l.flush, name, hook)
+}
+
+// OnClose adds a hook run last, to close the clients of the service
+func (l *Lifecycle) OnClose(name string, hook Hook) {
+	l.addHook(&l.close, name, hook)
+}
+
+func (l *Lifecycle) addHook(hooks *[]namedHook, name string, hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*hooks = append(*hooks, namedHook{name: name, run: hook})
+}
+
+// RegisterProbes adds the liveness and readiness probes to r:
+//
+//   - /health/live answers 200 as long as the process serves requests
+//   - /health/ready runs the checks and answers 503 when one fails, or once
+//     the shutdown has started
+func (l *Lifecycle) RegisterProbes(r gin.IRoutes) {
+	r.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive", "service": l.service})
+	})
+	r.GET("/health/ready", func(c *gin.Context) {
+		results, ready := l.Ready(c.Request.Context())
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"status": status, "service": l.service, "checks": results})
+	})
+}
+
+// Ready runs the checks in parallel. It returns "ok" or the error of each
+// check, and whether the service can take requests.
+func (l *Lifecycle) Ready(ctx context.Context) (map[string]string, bool) {
+	l.mu.Lock()
+	checks := make(map[string]Check, len(l.checks))
+	for name, check := range l.checks {
+		checks[name] = check
+	}
+	l.mu.Unlock()
+
+	results := make(map[string]string, len(checks))
+	ready := !l.stopping.Load()
+	if !ready {
+		results["shutdown"] = "in progress"
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			err := check(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				results[name] = err.Error()
+				ready = false
+				return
+			}
+			results[name] = "ok"
+		}(name, check)
+	}
+	wg.Wait()
+	return results, ready
+}
+
+// Run serves handler on addr until SIGINT or SIGTERM, then shuts the service
+// down. It exits the process when the server cannot start.
+func (l *Lifecycle) Run(addr string, handler http.Handler) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server started", "addr", addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case sig := <-quit:
+		slog.Info("shutting down", "signal", sig.String())
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server failed", "addr", addr, "error", err)
+			l.cancel()
+			os.Exit(1)
+		}
+	}
+
+	l.Shutdown(srv)
+}
+
+// Shutdown stops srv, then the rest of the service, in the order described
+// on Lifecycle. srv may be nil when the service has no HTTP server.
+func (l *Lifecycle) Shutdown(srv *http.Server) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), l.ShutdownTimeout)
+	defer cancel()
+
+	l.stopping.Store(true)
+
+	if srv != nil {
+		// The WebSocket connections are hijacked, Shutdown does not wait for
+		// them: they are closed by the drain hooks
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Warn("HTTP server forced to shut down", "error", err)
+		}
+	}
+
+	l.runHooks(ctx, "drain", &l.drain)
+
+	l.cancel()
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("workers still running at the end of the shutdown")
+	}
+
+	l.runHooks(ctx, "flush", &l.flush)
+	l.runHooks(ctx, "close", &l.close)
+
+	slog.Info("shutdown complete", "duration_ms", time.Since(start).Milliseconds())
+}
+
+func (l *Lifecycle) runHooks(ctx context.Context, step string, registered *[]namedHook) {
+	l.mu.Lock()
+	hooks := append([]namedHook(nil), *registered...)
+	l.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook.run(ctx); err != nil {
+			slog.Warn("shutdown hook failed", "step", step, "hook", hook.name, "error", err)
+		}
+	}
+}
+
+// DBCheck pings the database behind db
+func DBCheck(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return errors.New("not connected")
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// CloseDB returns a hook closing the connections of db
+func CloseDB(db *gorm.DB) Hook {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return nil
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	}
+}
+
+// RedisCheck pings Redis. A nil client fails the check: use it only for the
+// services that cannot run without Redis.
+func RedisCheck(client redis.UniversalClient) Check {
+	return func(ctx context.Context) error {
+		if client == nil {
+			return errors.New("not connected")
+		}
+		return client.Ping(ctx).Err()
+	}
+}
+
+// ReconnectHint is the reason of the close frame (1012, service restart) sent
+// to the WebSocket clients of a stopping service. It tells the client when to
+// reconnect, spread over a few seconds so that the clients of a replica do not
+// all come back at once.
+func ReconnectHint() string {
+	delay := time.Second + rand.N(reconnectSpread)
+	return fmt.Sprintf(`{"reconnect_after_ms":%d}`, delay.Milliseconds())
+}
+
+// ParseReconnectHint returns the delay before reconnecting given by the
+// reason of a close frame built with ReconnectHint
+func ParseReconnectHint(reason string) (time.Duration, bool) {
+	var hint struct {
+		ReconnectAfterMS *int64 `json:"reconnect_after_ms"`
+	}
+	if err := json.Unmarshal([]byte(reason), &hint); err != nil || hint.ReconnectAfterMS == nil || *hint.ReconnectAfterMS < 0 {
+		return 0, false
+	}
+	return time.Duration(*hint.ReconnectAfterMS) * time.Millisecond, true
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// probe calls path on r and decodes the answer
+func probe(t *testing.T, r http.Handler, path string) (int, map[string]any) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestRegisterProbes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	lc := New("test-service")
+	lc.AddCheck("database", DBCheck(db))
+	r := gin.New()
+	lc.RegisterProbes(r)
+
+	code, body := probe(t, r, "/health/ready")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body["status"])
+	assert.Equal(t, map[string]any{"database": "ok"}, body["checks"])
+
+	lc.AddCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	code, body = probe(t, r, "/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "connection refused", body["checks"].(map[string]any)["redis"])
+
+	code, _ = probe(t, r, "/health/live")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestShutdown_RunsTheStepsInOrder(t *testing.T) {
+	lc := New("test-service")
+	r := gin.New()
+	lc.RegisterProbes(r)
+
+	var (
+		mu    sync.Mutex
+		steps []string
+	)
+	step := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, name)
+	}
+
+	lc.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		step("worker stopped")
+	})
+	lc.OnClose("close", func(ctx context.Context) error {
+		step("close")
+		return nil
+	})
+	lc.OnFlush("flush", func(ctx context.Context) error {
+		step("flush")
+		return errors.New("failed hooks do not stop the shutdown")
+	})
+	lc.OnDrain("drain", func(ctx context.Context) error {
+		code, body := probe(t, r, "/health/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code, "not ready once the shutdown started")
+		assert.Equal(t, "not_ready", body["status"])
+		assert.NoError(t, lc.Context().Err(), "workers run until the connections are drained")
+		step("drain")
+		return nil
+	})
+
+	lc.Shutdown(nil)
+
+	assert.Equal(t, []string{"drain", "worker stopped", "flush", "close"}, steps)
+}
+
+func TestShutdown_DoesNotWaitPastTheTimeout(t *testing.T) {
+	lc := New("test-service")
+	lc.ShutdownTimeout = 50 * time.Millisecond
+	stuck := make(chan struct{})
+	defer close(stuck)
+	lc.Go("stuck", func(ctx context.Context) { <-stuck })
+
+	closed := false
+	lc.OnClose("close", func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	start := time.Now()
+	lc.Shutdown(nil)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, closed)
+}
+
+func TestReconnectHint(t *testing.T) {
+	var hint struct {
+		ReconnectAfterMS int64 `json:"reconnect_after_ms"`
+	}
+	reason := ReconnectHint()
+	require.NoError(t, json.Unmarshal([]byte(reason), &hint))
+	assert.GreaterOrEqual(t, hint.ReconnectAfterMS, int64(1000))
+	assert.Less(t, hint.ReconnectAfterMS, int64(6000))
+	// Close frame reasons are limited to 123 bytes
+	assert.LessOrEqual(t, len(reason), 123)
+
+	delay, ok := ParseReconnectHint(reason)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(hint.ReconnectAfterMS)*time.Millisecond, delay)
+	_, ok = ParseReconnectHint("going away")
+	assert.False(t, ok)
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// untracedPaths are polled by Docker and Prometheus, and would drown the
+// requests of the users
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// Middleware starts a server span for each request, continuing the trace of
+// the traceparent header sent by the caller. The span is named after the
+// route, and the trace ID is returned in the X-Trace-ID header.
+func Middleware(serviceName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if untracedPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched route"
+		}
+		ctx, span := Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("service.component", serviceName),
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", c.FullPath()),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		if traceID := TraceID(ctx); traceID != "" {
+			c.Header(TraceIDHeader, traceID)
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if userID := requestUserID(c); userID != "" {
+			span.SetAttributes(attribute.String("enduser.id", userID))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// requestUserID returns the user set by the auth middleware of the service,
+// or the one forwarded by the gateway
+func requestUserID(c *gin.Context) string {
+	for _, key := range []string{"userID", "user_id"} {
+		if userID, ok := c.Get(key); ok {
+			return fmt.Sprint(userID)
+		}
+	}
+	return c.GetHeader("X-User-ID")
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GORMPlugin traces the queries run with the context of a traced request:
+//
+//	conf.DB.Use(tracing.GORMPlugin{})
+//	conf.DB.WithContext(c.Request.Context()).First(&user, id)
+//
+// The SQL is recorded with its placeholders, never with its values.
+type GORMPlugin struct{}
+
+// Name implements gorm.Plugin
+func (GORMPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin
+func (GORMPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", startQuery("create")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", endQuery),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", startQuery("select")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", endQuery),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", startQuery("update")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", endQuery),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", startQuery("delete")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", endQuery),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", startQuery("row")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", endQuery),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", startQuery("raw")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", endQuery),
+	)
+}
+
+func startQuery(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		if !inTrace(tx.Statement.Context) {
+			return
+		}
+		ctx, span := Tracer().Start(tx.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", tx.Dialector.Name()),
+				attribute.String("db.operation.name", operation),
+			),
+		)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endQuery(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.query.text", tx.Statement.SQL.String()),
+		attribute.String("db.collection.name", tx.Statement.Table),
+		attribute.Int64("db.response.rows_affected", tx.Statement.RowsAffected),
+	)
+	err := tx.Error
+	if ignoredError(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
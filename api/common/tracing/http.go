@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Transport wraps base, http.DefaultTransport when nil, to send a client
+// span and the traceparent header with each request made within a trace.
+// Requests must be built with the context of the caller
+// (http.NewRequestWithContext) to belong to its trace.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base,
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return inTrace(r.Context())
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Host
+		}),
+	)
+}
+
+// NewClient returns an HTTP client with the given timeout whose requests are
+// traced
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Transport(nil),
+	}
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentRedis traces the commands client runs with the context of a
+// traced request. Commands run by background loops are not traced.
+func InstrumentRedis(client redis.UniversalClient) {
+	if client != nil {
+		client.AddHook(redisHook{})
+	}
+}
+
+type redisHook struct{}
+
+// DialHook implements redis.Hook
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !inTrace(ctx) {
+			return next(ctx, cmd)
+		}
+		ctx, span := startCommand(ctx, "redis."+cmd.Name(), cmd.Name())
+		err := next(ctx, cmd)
+		endCommand(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !inTrace(ctx) {
+			return next(ctx, cmds)
+		}
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+		ctx, span := startCommand(ctx, "redis.pipeline", strings.Join(names, " "))
+		span.SetAttributes(attribute.Int("db.operation.batch.size", len(cmds)))
+		err := next(ctx, cmds)
+		endCommand(span, err)
+		return err
+	}
+}
+
+func startCommand(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation.name", operation),
+		),
+	)
+}
+
+func endCommand(span trace.Span, err error) {
+	// A missing key is not a failure
+	if ignoredError(err, redis.Nil) {
+		err = nil
+	}
+	End(span, err)
+}
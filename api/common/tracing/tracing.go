@@ -0,0 +1,161 @@
+// Package tracing sets up OpenTelemetry tracing for a service: W3C
+// traceparent propagation between services, and spans around gin handlers,
+// GORM queries, Redis commands, outbound HTTP requests and WebSocket
+// messages.
+//
+// Spans are exported according to OTEL_TRACES_EXPORTER:
+//
+//	otlp    OTLP over HTTP, to OTEL_EXPORTER_OTLP_ENDPOINT (http://localhost:4318 by default)
+//	stdout  pretty printed JSON on the standard output
+//	none    nothing is exported (default); trace context is still propagated
+//
+// The other standard variables apply, such as OTEL_SERVICE_NAME,
+// OTEL_RESOURCE_ATTRIBUTES, OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters accepted in OTEL_TRACES_EXPORTER
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// TraceIDHeader is the response header carrying the trace ID, to find the
+// trace of a request from the client or the logs
+const TraceIDHeader = "X-Trace-ID"
+
+const instrumentationName = "github.com/maxg56/matcha/api/common/tracing"
+
+// ShutdownFunc flushes the spans not exported yet
+type ShutdownFunc func(context.Context) error
+
+// Init installs the W3C trace context propagator and the tracer provider of
+// the service. The returned function flushes pending spans and must be
+// called before the service exits.
+func Init(serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(os.Getenv("OTEL_TRACES_EXPORTER"))
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("tracing enabled", "service", serviceName, "exporter", strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")))
+	return provider.Shutdown, nil
+}
+
+func newExporter(name string) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		// Reads OTEL_EXPORTER_OTLP_ENDPOINT and the other OTLP variables
+		return otlptracehttp.New(context.Background())
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q, expected %s, %s or %s",
+			name, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+}
+
+// Tracer returns the tracer used by the instrumentation of this package
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span, child of the span of ctx if any
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the ID of the trace of ctx, or "" outside of a trace
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// TraceParent returns the W3C traceparent of ctx, to carry the trace in
+// messages that are not HTTP requests, or "" outside of a trace
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// WithTraceParent returns ctx continuing the trace of a W3C traceparent.
+// ctx is returned as is when traceparent is empty or malformed.
+func WithTraceParent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+}
+
+// inTrace tells whether ctx belongs to a trace. Queries and commands run
+// outside of a trace, by background jobs, are not traced so that they do not
+// flood the exporter with root spans.
+func inTrace(ctx context.Context) bool {
+	return ctx != nil && trace.SpanContextFromContext(ctx).IsValid()
+}
+
+// ignoredError tells whether err is an expected outcome rather than a
+// failure worth flagging on the span
+func ignoredError(err error, expected ...error) bool {
+	for _, target := range expected {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return err == nil
+}
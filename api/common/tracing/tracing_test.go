@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const parentTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupRecorder records the spans ended during the test
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestInit_RejectsUnknownExporter(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	_, err := Init("test-service")
+	assert.ErrorContains(t, err, `unknown OTEL_TRACES_EXPORTER "zipkin"`)
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	shutdown, err := Init("test-service")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestMiddleware_ContinuesTheTraceOfTheCaller(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	var outbound string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	r := gin.New()
+	r.Use(Middleware("test-service"))
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Set("user_id", 7)
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := NewClient(0).Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		c.Status(http.StatusBadGateway)
+	})
+	r.GET("/health", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", parentTraceParent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(TraceIDHeader))
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	client, server := spans[0], spans[1]
+	assert.Equal(t, "GET /users/:id", server.Name())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Contains(t, server.Attributes(), attributeOf("enduser.id", "7"))
+	assert.Equal(t, server.SpanContext().SpanID(), client.Parent().SpanID())
+	assert.Contains(t, outbound, client.SpanContext().SpanID().String())
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Len(t, recorder.Ended(), 2, "health checks are not traced")
+}
+
+func TestGORMPlugin_TracesQueriesOfTracedRequests(t *testing.T) {
+	recorder := setupRecorder(t)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GORMPlugin{}))
+
+	type Item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&Item{}))
+	require.NoError(t, db.Create(&Item{Name: "untraced"}).Error)
+	assert.Empty(t, recorder.Ended(), "queries outside of a trace are not traced")
+
+	ctx, span := Start(context.Background(), "request")
+	var item Item
+	err = db.WithContext(ctx).Where("name = ?", "secret@example.com").First(&item).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "gorm.select", query.Name())
+	assert.Equal(t, span.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, codes.Unset, query.Status().Code, "a missing record is not an error")
+	for _, attr := range query.Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "secret@example.com")
+	}
+	assert.Contains(t, query.Attributes(), attributeOf("db.collection.name", "items"))
+}
+
+func TestRedisHook_TracesCommandsOfTracedRequests(t *testing.T) {
+	recorder := setupRecorder(t)
+	hook := redisHook{}
+	failure := errors.New("connection refused")
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "get" {
+			return redis.Nil
+		}
+		return failure
+	})
+
+	assert.ErrorIs(t, process(context.Background(), redis.NewCmd(context.Background(), "set", "k", "v")), failure)
+	assert.Empty(t, recorder.Ended())
+
+	ctx, span := Start(context.Background(), "request")
+	process(ctx, redis.NewCmd(ctx, "get", "k"))
+	process(ctx, redis.NewCmd(ctx, "set", "k", "v"))
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "redis.get", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "redis.set", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestStartMessage(t *testing.T) {
+	recorder := setupRecorder(t)
+	conn, connSpan := Start(context.Background(), "GET /ws")
+
+	_, span := StartMessage(conn, parentTraceParent, "chat_message")
+	span.End()
+	_, span = StartMessage(conn, "", "typing")
+	span.End()
+	connSpan.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	continued, linked := spans[0], spans[1]
+	assert.Equal(t, "ws chat_message", continued.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", continued.SpanContext().TraceID().String())
+	assert.NotEqual(t, connSpan.SpanContext().TraceID(), linked.SpanContext().TraceID())
+	require.Len(t, linked.Links(), 1)
+	assert.Equal(t, connSpan.SpanContext().SpanID(), linked.Links()[0].SpanContext.SpanID())
+
+	// The traceparent round trips
+	assert.Equal(t, parentTraceParent[:36], TraceParent(WithTraceParent(context.Background(), parentTraceParent))[:36])
+}
+
+func attributeOf(key, value string) attribute.KeyValue {
+	return attribute.String(key, value)
+}
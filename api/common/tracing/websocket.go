@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartMessage starts the span handling one WebSocket message. A message
+// carrying a traceparent continues that trace. Otherwise the message starts
+// its own trace, linked to the span of the connection: a connection lasts
+// for hours and would otherwise gather every message in a single trace.
+func StartMessage(conn context.Context, traceparent, messageType string) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "websocket"),
+			attribute.String("messaging.operation.name", messageType),
+		),
+	}
+
+	ctx := WithTraceParent(conn, traceparent)
+	if trace.SpanContextFromContext(ctx).Equal(trace.SpanContextFromContext(conn)) {
+		opts = append(opts, trace.WithNewRoot(), trace.WithLinks(trace.LinkFromContext(conn)))
+	}
+	return Tracer().Start(ctx, "ws "+messageType, opts...)
+}
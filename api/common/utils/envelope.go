@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope is the response format of the /api/v2 routes. Data is set on
+// success, Error otherwise.
+type Envelope struct {
+	Success bool           `json:"success"`
+	Data    interface{}    `json:"data,omitempty"`
+	Error   *EnvelopeError `json:"error,omitempty"`
+}
+
+// EnvelopeError describes why a request failed. Code is stable and meant for
+// programs, Message for humans.
+type EnvelopeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorCode is the code of the errors answered with status, e.g. not_found
+// for 404
+func ErrorCode(status int) string {
+	switch status {
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusInternalServerError:
+		return "internal_error"
+	}
+	text := http.StatusText(status)
+	if text == "" {
+		if status >= http.StatusInternalServerError {
+			return "internal_error"
+		}
+		return "error"
+	}
+	text = strings.ReplaceAll(strings.ToLower(text), "-", " ")
+	return strings.Join(strings.Fields(text), "_")
+}
+
+// RespondEnvelope sends data in the envelope of the /api/v2 routes
+func RespondEnvelope(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, Envelope{Success: true, Data: data})
+}
+
+// RespondEnvelopeError sends an error in the envelope of the /api/v2 routes,
+// with the code of status
+func RespondEnvelopeError(c *gin.Context, status int, message string) {
+	c.JSON(status, Envelope{
+		Success: false,
+		Error:   &EnvelopeError{Code: ErrorCode(status), Message: message},
+	})
+}
+
+// ToEnvelope converts a response of the /api/v1 routes to the envelope. It
+// understands the formats of every service:
+//
+//   - {"success": true, "data": ...} gives its data, or the other fields
+//     when there is no data (e.g. {"success": true, "message": "..."})
+//   - {"success": false, "error": "..."}, {"error": "..."} and
+//     {"error": {"code": "...", "message": "..."}} give an error
+//   - any other JSON value is the data itself
+//
+// Statuses from 400 are always errors, and bodies that are not JSON are only
+// accepted for them.
+func ToEnvelope(status int, body []byte) (Envelope, bool) {
+	failed := status >= http.StatusBadRequest
+	body = bytes.TrimSpace(body)
+
+	var value interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &value); err != nil {
+			if !failed {
+				return Envelope{}, false
+			}
+			// e.g. the text/plain answer of an unknown route
+			return errorEnvelope(status, "", string(body)), true
+		}
+	}
+
+	object, isObject := value.(map[string]interface{})
+	if !isObject {
+		if failed {
+			message, _ := value.(string)
+			return errorEnvelope(status, "", message), true
+		}
+		return Envelope{Success: true, Data: value}, true
+	}
+
+	success, hasSuccess := object["success"].(bool)
+	if failed || (hasSuccess && !success) {
+		code, message := "", ""
+		switch e := object["error"].(type) {
+		case string:
+			message = e
+		case map[string]interface{}:
+			code, _ = e["code"].(string)
+			message, _ = e["message"].(string)
+		}
+		if message == "" {
+			message, _ = object["message"].(string)
+		}
+		return errorEnvelope(status, code, message), true
+	}
+
+	if !hasSuccess {
+		return Envelope{Success: true, Data: object}, true
+	}
+	if data, ok := object["data"]; ok {
+		return Envelope{Success: true, Data: data}, true
+	}
+	delete(object, "success")
+	if len(object) == 0 {
+		return Envelope{Success: true}, true
+	}
+	return Envelope{Success: true, Data: object}, true
+}
+
+func errorEnvelope(status int, code, message string) Envelope {
+	if status < http.StatusBadRequest {
+		// {"success": false} answered with a 2xx
+		status = http.StatusBadRequest
+	}
+	if code == "" {
+		code = ErrorCode(status)
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return Envelope{Success: false, Error: &EnvelopeError{Code: code, Message: message}}
+}
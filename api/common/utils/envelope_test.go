@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespondEnvelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	RespondEnvelope(c, http.StatusCreated, map[string]int{"id": 1})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"success":true,"data":{"id":1}}`, w.Body.String())
+}
+
+func TestRespondEnvelopeError(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	RespondEnvelopeError(c, http.StatusNotFound, "user not found")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":{"code":"not_found","message":"user not found"}}`, w.Body.String())
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "bad_request", ErrorCode(http.StatusBadRequest))
+	assert.Equal(t, "request_entity_too_large", ErrorCode(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, "rate_limited", ErrorCode(http.StatusTooManyRequests))
+	assert.Equal(t, "internal_error", ErrorCode(http.StatusInternalServerError))
+	assert.Equal(t, "internal_error", ErrorCode(599))
+}
+
+func TestToEnvelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{"standard response", 200, `{"success":true,"data":{"id":1}}`, `{"success":true,"data":{"id":1}}`},
+		{"paiements message dropped", 200, `{"success":true,"data":{"id":"cs_1"},"message":"created"}`, `{"success":true,"data":{"id":"cs_1"}}`},
+		{"message without data", 200, `{"success":true,"message":"Logged out"}`, `{"success":true,"data":{"message":"Logged out"}}`},
+		{"success alone", 200, `{"success":true}`, `{"success":true}`},
+		{"bare object", 200, `{"status":"ok"}`, `{"success":true,"data":{"status":"ok"}}`},
+		{"bare array", 200, `[1,2]`, `{"success":true,"data":[1,2]}`},
+		{"empty body", 204, ``, `{"success":true}`},
+		{"standard error", 400, `{"success":false,"error":"invalid payload"}`, `{"success":false,"error":{"code":"bad_request","message":"invalid payload"}}`},
+		{"gateway error", 401, `{"error":"token expired"}`, `{"success":false,"error":{"code":"unauthorized","message":"token expired"}}`},
+		{"error object", 409, `{"error":{"code":"email_taken","message":"Email already used"}}`, `{"success":false,"error":{"code":"email_taken","message":"Email already used"}}`},
+		{"error message field", 404, `{"success":false,"message":"no such user"}`, `{"success":false,"error":{"code":"not_found","message":"no such user"}}`},
+		{"failure with 200", 200, `{"success":false,"error":"plan unknown"}`, `{"success":false,"error":{"code":"bad_request","message":"plan unknown"}}`},
+		{"plain text error", 404, "404 page not found\n", `{"success":false,"error":{"code":"not_found","message":"404 page not found"}}`},
+		{"empty error", 503, ``, `{"success":false,"error":{"code":"service_unavailable","message":"Service Unavailable"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, ok := ToEnvelope(tt.status, []byte(tt.body))
+			require.True(t, ok)
+			encoded, err := json.Marshal(envelope)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(encoded))
+		})
+	}
+
+	_, ok := ToEnvelope(http.StatusOK, []byte("<html></html>"))
+	assert.False(t, ok, "successful bodies that are not JSON are left alone")
+}
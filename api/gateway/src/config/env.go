@@ -1,7 +1,9 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
@@ -24,6 +26,19 @@ type Config struct {
 	// Security
 	RateLimitEnabled bool
 	RateLimitRPS     int
+	// RateLimitPolicies overrides named policies (name=limit/period[:identity],...)
+	RateLimitPolicies string
+	// AdminUserIDs may use the /api/v1/admin routes
+	AdminUserIDs []string
+
+	// Routes
+	// RoutesFile is the route table, the embedded one when empty
+	RoutesFile string
+	// RouteProbeEnabled checks at startup that upstreams serve their routes
+	RouteProbeEnabled bool
+	// OpenAPIValidation checks requests and responses against the OpenAPI
+	// document, on by default in development
+	OpenAPIValidation bool
 	
 	// Logging
 	LogLevel string
@@ -36,7 +51,10 @@ func LoadAndValidateConfig() (*Config, error) {
 	config := &Config{}
 	
 	// Required variables
-	config.JWTSecret = getRequiredEnv("JWT_SECRET")
+	config.JWTSecret = os.Getenv("JWT_SECRET")
+	if config.JWTSecret == "" {
+		return nil, errors.New("required environment variable JWT_SECRET is not set")
+	}
 	
 	// Optional with defaults
 	config.Port = getEnvWithDefault("PORT", "8080")
@@ -54,24 +72,37 @@ func LoadAndValidateConfig() (*Config, error) {
 	httpTimeoutStr := getEnvWithDefault("HTTP_TIMEOUT", "30s")
 	httpTimeout, err := time.ParseDuration(httpTimeoutStr)
 	if err != nil {
-		log.Fatalf("Invalid HTTP_TIMEOUT: %v", err)
+		return nil, fmt.Errorf("invalid HTTP_TIMEOUT: %w", err)
 	}
 	config.HTTPTimeout = httpTimeout
 	
 	redisTimeoutStr := getEnvWithDefault("REDIS_TIMEOUT", "5s")
 	redisTimeout, err := time.ParseDuration(redisTimeoutStr)
 	if err != nil {
-		log.Fatalf("Invalid REDIS_TIMEOUT: %v", err)
+		return nil, fmt.Errorf("invalid REDIS_TIMEOUT: %w", err)
 	}
 	config.RedisTimeout = redisTimeout
 	
 	// Rate limiting
 	config.RateLimitEnabled = getBoolEnvWithDefault("RATE_LIMIT_ENABLED", true)
 	config.RateLimitRPS = getIntEnvWithDefault("RATE_LIMIT_RPS", 100)
+	config.RateLimitPolicies = os.Getenv("RATE_LIMIT_POLICIES")
+
+	// Administrators
+	for _, id := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			config.AdminUserIDs = append(config.AdminUserIDs, id)
+		}
+	}
+	
+	// Routes
+	config.RoutesFile = os.Getenv("GATEWAY_ROUTES_FILE")
+	config.RouteProbeEnabled = getBoolEnvWithDefault("ROUTE_PROBE_ENABLED", true)
+	config.OpenAPIValidation = getBoolEnvWithDefault("OPENAPI_VALIDATION", config.Environment == "development")
 	
 	// Validate configuration
 	if err := validateConfig(config); err != nil {
-		log.Fatalf("Configuration validation failed: %v", err)
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 	
 	// Set global config
@@ -81,15 +112,6 @@ func LoadAndValidateConfig() (*Config, error) {
 	return config, nil
 }
 
-// getRequiredEnv gets a required environment variable or exits
-func getRequiredEnv(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		log.Fatalf("Required environment variable %s is not set", key)
-	}
-	return value
-}
-
 // getEnvWithDefault gets an environment variable with a default value
 func getEnvWithDefault(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -108,7 +130,7 @@ func getBoolEnvWithDefault(key string, defaultValue bool) bool {
 	
 	boolVal, err := strconv.ParseBool(value)
 	if err != nil {
-		log.Printf("Invalid boolean value for %s: %s, using default: %t", key, value, defaultValue)
+		slog.Warn("invalid boolean value, using the default", "key", key, "value", value, "default", defaultValue)
 		return defaultValue
 	}
 	return boolVal
@@ -123,7 +145,7 @@ func getIntEnvWithDefault(key string, defaultValue int) int {
 	
 	intVal, err := strconv.Atoi(value)
 	if err != nil {
-		log.Printf("Invalid integer value for %s: %s, using default: %d", key, value, defaultValue)
+		slog.Warn("invalid integer value, using the default", "key", key, "value", value, "default", defaultValue)
 		return defaultValue
 	}
 	return intVal
@@ -133,29 +155,29 @@ func getIntEnvWithDefault(key string, defaultValue int) int {
 func validateConfig(config *Config) error {
 	// Validate JWT secret strength (production)
 	if config.Environment == "production" && len(config.JWTSecret) < 32 {
-		log.Fatal("JWT_SECRET must be at least 32 characters in production")
+		return errors.New("JWT_SECRET must be at least 32 characters in production")
 	}
 	
 	// Validate allowed origins
 	for i, origin := range config.AllowedOrigins {
 		config.AllowedOrigins[i] = strings.TrimSpace(origin)
 		if config.AllowedOrigins[i] == "" {
-			log.Fatal("Empty origin found in ALLOWED_ORIGINS")
+			return errors.New("empty origin found in ALLOWED_ORIGINS")
 		}
 	}
 	
 	// Validate timeouts
 	if config.HTTPTimeout < time.Second {
-		log.Fatal("HTTP_TIMEOUT must be at least 1 second")
+		return errors.New("HTTP_TIMEOUT must be at least 1 second")
 	}
 	
 	if config.RedisTimeout < time.Millisecond*100 {
-		log.Fatal("REDIS_TIMEOUT must be at least 100ms")
+		return errors.New("REDIS_TIMEOUT must be at least 100ms")
 	}
 	
 	// Validate rate limiting
 	if config.RateLimitRPS < 1 {
-		log.Fatal("RATE_LIMIT_RPS must be at least 1")
+		return errors.New("RATE_LIMIT_RPS must be at least 1")
 	}
 	
 	return nil
@@ -163,15 +185,24 @@ func validateConfig(config *Config) error {
 
 // logConfiguration logs the loaded configuration (without secrets)
 func logConfiguration(config *Config) {
-	log.Println("=== Gateway Configuration ===")
-	log.Printf("Environment: %s", config.Environment)
-	log.Printf("Port: %s", config.Port)
-	log.Printf("Redis Address: %s", config.RedisAddr)
-	log.Printf("Allowed Origins: %v", config.AllowedOrigins)
-	log.Printf("HTTP Timeout: %v", config.HTTPTimeout)
-	log.Printf("Redis Timeout: %v", config.RedisTimeout)
-	log.Printf("Rate Limiting: %t (RPS: %d)", config.RateLimitEnabled, config.RateLimitRPS)
-	log.Printf("Log Level: %s", config.LogLevel)
-	log.Printf("JWT Secret: [REDACTED %d chars]", len(config.JWTSecret))
-	log.Println("=============================")
-}
\ No newline at end of file
+	routesFile := config.RoutesFile
+	if routesFile == "" {
+		routesFile = "embedded"
+	}
+	slog.Info("gateway configuration",
+		"environment", config.Environment,
+		"port", config.Port,
+		"redis_addr", config.RedisAddr,
+		"allowed_origins", config.AllowedOrigins,
+		"http_timeout", config.HTTPTimeout.String(),
+		"redis_timeout", config.RedisTimeout.String(),
+		"rate_limit_enabled", config.RateLimitEnabled,
+		"rate_limit_rps", config.RateLimitRPS,
+		"rate_limit_policies", config.RateLimitPolicies,
+		"admin_users", len(config.AdminUserIDs),
+		"routes_file", routesFile,
+		"route_probe", config.RouteProbeEnabled,
+		"openapi_validation", config.OpenAPIValidation,
+		"log_level", config.LogLevel,
+		"jwt_secret_length", len(config.JWTSecret))
+}
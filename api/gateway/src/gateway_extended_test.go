@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -12,10 +13,12 @@ import (
 
 	"gateway/src/handlers"
 	"gateway/src/middleware"
+	"gateway/src/routetable"
 	"gateway/src/services"
 	"gateway/src/utils"
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/maxg56/matcha/api/common/server"
 )
 
 // Integration Tests for Routes
@@ -188,7 +191,7 @@ func TestRoutes_ProtectedRouteWithValidToken(t *testing.T) {
 // Redis Blacklist Tests
 func TestRedisBlacklist_WithoutRedis(t *testing.T) {
 	// Test behavior when Redis is not connected
-	isBlacklisted := utils.IsTokenBlacklisted("test-token")
+	isBlacklisted := utils.IsTokenBlacklisted(context.Background(), "test-token")
 	if isBlacklisted {
 		t.Error("expected token not to be blacklisted when Redis unavailable")
 	}
@@ -278,7 +281,7 @@ func TestServiceConfig_GetServicesStatus(t *testing.T) {
 
 	status := services.GetServicesStatus()
 
-	expectedServices := []string{"auth", "user", "media", "match", "chat", "notify"}
+	expectedServices := []string{"auth", "user", "media", "match", "chat"}
 	for _, serviceName := range expectedServices {
 		if url, exists := status[serviceName]; !exists {
 			t.Errorf("expected service '%s' in status", serviceName)
@@ -433,3 +436,39 @@ func TestProxy_ResponseHeaderForwarding(t *testing.T) {
 		t.Errorf("expected 2 Set-Cookie headers, got %d", len(cookies))
 	}
 }
+
+// Route table Tests
+func TestNewRouter_DefaultRouteTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.InitServices()
+
+	r, err := newRouter(routetable.Default(), server.New("gateway"))
+	if err != nil {
+		t.Fatalf("expected the embedded route table to build, got %v", err)
+	}
+
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for _, route := range []string{
+		"GET /health",
+		"GET /health/ready",
+		"GET /ws",
+		"GET /api/v1/admin/rate-limits",
+		"GET /api/v1/admin/deletions",
+		"DELETE /api/v1/matches/seen",
+		"POST /api/v1/auth/login",
+		"POST /api/v2/auth/login",
+		"GET /api/v2/users/profile/:id",
+		"GET /api/openapi.json",
+		"GET /api/docs",
+	} {
+		if !routes[route] {
+			t.Errorf("expected route %s", route)
+		}
+	}
+	if routes["GET /api/v1/matrix/users"] || routes["GET /api/v1/admin/performance"] {
+		t.Error("expected the routes match-service does not serve to be gone")
+	}
+}
@@ -124,6 +124,58 @@ func TestJWTMiddleware_AllowsWithValidToken(t *testing.T) {
 	}
 }
 
+func TestOptionalJWTMiddleware_AnonymousAndSpoofedHeader(t *testing.T) {
+	t.Setenv("JWT_SECRET", "testsecret")
+	r := setupTestRouter()
+	r.Use(middleware.OptionalJWTMiddleware())
+	r.GET("/public", func(c *gin.Context) {
+		if _, exists := c.Get(middleware.CtxUserIDKey); exists {
+			t.Fatalf("expected no userID in context")
+		}
+		if got := c.Request.Header.Get("X-User-ID"); got != "" {
+			t.Fatalf("expected client X-User-ID to be dropped, got %q", got)
+		}
+		c.String(200, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set("X-User-ID", "42")
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestOptionalJWTMiddleware_SetsUserWithValidToken(t *testing.T) {
+	secret := "testsecret"
+	t.Setenv("JWT_SECRET", secret)
+	token, err := signTestToken("user-123", secret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	r := setupTestRouter()
+	r.Use(middleware.OptionalJWTMiddleware())
+	r.GET("/public", func(c *gin.Context) {
+		if v, exists := c.Get(middleware.CtxUserIDKey); !exists || v.(string) != "user-123" {
+			t.Fatalf("expected userID in context")
+		}
+		c.String(200, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
 // Health Check Tests
 func TestHealthCheck(t *testing.T) {
 	// Initialize services for testing
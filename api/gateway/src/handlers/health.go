@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"gateway/src/proxy"
 	"gateway/src/services"
 	"gateway/src/websocket"
 	"github.com/gin-gonic/gin"
@@ -14,20 +15,31 @@ type HealthCheckResponse struct {
 	Status              string            `json:"status"`
 	Timestamp           time.Time         `json:"timestamp"`
 	Services            map[string]string `json:"services"`
-	NotificationClient  string            `json:"notification_client"`
+	RealtimeEvents      string            `json:"realtime_events"`
+	Breakers            map[string]proxy.BreakerStatus `json:"breakers"` // circuit breaker of each service
 }
 
-// HealthCheck returns the gateway health status and service configuration
+// HealthCheck returns the gateway health status and service configuration.
+// The gateway is degraded while the breaker of a service is not closed.
 func HealthCheck(c *gin.Context) {
-	notificationStatus := "disconnected"
-	if websocket.IsNotificationClientConnected() {
-		notificationStatus = "connected"
+	eventsStatus := "disconnected"
+	if websocket.IsEventSubscriberRunning() {
+		eventsStatus = "connected"
+	}
+
+	status := "ok"
+	breakers := proxy.BreakerStatuses()
+	for _, breaker := range breakers {
+		if breaker.State != proxy.BreakerClosed {
+			status = "degraded"
+		}
 	}
 	
 	c.JSON(http.StatusOK, HealthCheckResponse{
-		Status:             "ok",
+		Status:             status,
 		Timestamp:          time.Now().UTC(),
 		Services:           services.GetServicesStatus(),
-		NotificationClient: notificationStatus,
+		RealtimeEvents:     eventsStatus,
+		Breakers:           breakers,
 	})
 }
@@ -177,7 +177,8 @@ func (h *InternalHandler) HealthCheck(c *gin.Context) {
 	// Vérifier l'état du WebSocket manager
 	if websocket.GlobalManager != nil {
 		status["websocket"] = "running"
-		status["connected_users"] = websocket.GlobalManager.GetConnectionCount()
+		status["connected_users"] = len(websocket.GlobalManager.GetConnectedUsers())
+		status["connections"] = websocket.GlobalManager.GetConnectionCount()
 	} else {
 		status["websocket"] = "not_initialized"
 		status["status"] = "degraded"
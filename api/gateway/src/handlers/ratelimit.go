@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"gateway/src/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitIdentity reads the identity to inspect or reset from
+// ?ip=, ?user_id= or ?api_key=
+func rateLimitIdentity(c *gin.Context) (string, bool) {
+	if ip := c.Query("ip"); ip != "" {
+		if net.ParseIP(ip) == nil {
+			return "", false
+		}
+		return "ip:" + ip, true
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		if _, err := strconv.ParseUint(userID, 10, 64); err != nil {
+			return "", false
+		}
+		return "user:" + userID, true
+	}
+	if key := c.Query("api_key"); key != "" {
+		return "key:" + middleware.HashAPIKey(key), true
+	}
+	return "", false
+}
+
+// GetRateLimits lists the policies, and the state of one identity under
+// each of them when ?ip=, ?user_id= or ?api_key= is given
+func GetRateLimits(c *gin.Context) {
+	stats := middleware.GetRateLimitStats()
+	if c.Query("ip") == "" && c.Query("user_id") == "" && c.Query("api_key") == "" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "rate_limits": stats})
+		return
+	}
+
+	identity, ok := rateLimitIdentity(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid identity"})
+		return
+	}
+	states, err := middleware.RateLimitInspect(c.Request.Context(), identity)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "rate_limits": stats, "identity": identity, "states": states})
+}
+
+// ResetRateLimits clears the counters of one identity, under ?policy= or
+// every policy
+func ResetRateLimits(c *gin.Context) {
+	identity, ok := rateLimitIdentity(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "ip, user_id or api_key is required"})
+		return
+	}
+	policy := c.Query("policy")
+	if policy != "" && !middleware.HasRateLimitPolicy(policy) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "unknown policy"})
+		return
+	}
+
+	cleared, err := middleware.RateLimitReset(c.Request.Context(), identity, policy)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "identity": identity, "cleared": cleared})
+}
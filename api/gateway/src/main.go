@@ -1,16 +1,28 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
+	"github.com/redis/go-redis/v9"
 
 	"gateway/src/config"
 	"gateway/src/handlers"
 	"gateway/src/middleware"
+	"gateway/src/openapi"
 	"gateway/src/routes"
+	"gateway/src/routetable"
 	"gateway/src/services"
 	"gateway/src/utils"
 	"gateway/src/websocket"
@@ -18,66 +30,172 @@ import (
 
 func main() {
 	// Load environment variables
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("No .env file found, using environment variables")
+	envErr := godotenv.Load()
+	logging.Init("gateway")
+	if envErr != nil {
+		slog.Info("no .env file found, using environment variables")
 	}
 
 	// Load and validate configuration
 	cfg, err := config.LoadAndValidateConfig()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		slog.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Trace requests from the gateway down to the services
+	shutdownTracing, err := tracing.Init("gateway")
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
 	}
+	defer shutdownTracing(context.Background())
 
-	// Initialize services configuration
-	services.InitServices()
+	lc := server.New("gateway")
 
-	// Initialize WebSocket manager
+	// Load the route table, which also declares the upstream services
+	table, err := routetable.Load(cfg.RoutesFile)
+	if err != nil {
+		slog.Error("failed to load route table", "error", err)
+		os.Exit(1)
+	}
+	services.Configure(table.Upstreams)
+
+	// Initialize WebSocket manager. At shutdown, the clients are told to
+	// reconnect to another replica.
 	websocket.InitManager()
+	lc.OnDrain("websocket", websocket.GlobalManager.Drain)
+	metrics.WebSocketConnections(websocket.GlobalManager.GetConnectionCount)
+	metrics.GaugeFunc("gateway_ws_broadcast_queue_depth", "Messages waiting for the WebSocket manager loop.", func() float64 {
+		return float64(websocket.GlobalManager.BroadcastQueueDepth())
+	})
 
 	// Initialize Chat Service WebSocket client
-	websocket.InitChatServiceClient()
+	chatClient := websocket.InitChatServiceClient()
+	lc.OnClose("chat-client", func(ctx context.Context) error {
+		chatClient.Stop()
+		return nil
+	})
 
-	// Initialize rate limiter
-	if cfg.RateLimitEnabled {
-		middleware.InitRateLimiter(cfg.RateLimitRPS)
-		log.Printf("Rate limiter initialized: %d RPS per client", cfg.RateLimitRPS)
+	// Initialize Redis for JWT blacklisting and rate limiting
+	redisErr := utils.InitRedis()
+	if redisErr != nil {
+		slog.Warn("redis unavailable, JWT blacklisting is disabled", "error", redisErr)
+	} else {
+		lc.AddCheck("redis", server.RedisCheck(utils.GetRedisClient()))
+		lc.OnClose("redis", func(ctx context.Context) error { return utils.GetRedisClient().Close() })
+
+		// Relay chat events published by every chat-service replica, and
+		// the notifications stored by user-service
+		lc.Go("event-subscriber", func(ctx context.Context) {
+			websocket.RunEventSubscriber(ctx, utils.GetRedisClient())
+		})
 	}
 
-	// Initialize Redis for JWT blacklisting
-	if err := utils.InitRedis(); err != nil {
-		log.Printf("Failed to initialize Redis: %v", err)
-		log.Println("Redis initialization failed - JWT blacklisting will be disabled")
-	} else {
-		log.Println("Redis initialized successfully for JWT blacklisting")
+	// Initialize rate limiter, shared by every replica through Redis
+	if cfg.RateLimitEnabled {
+		var client *redis.Client
+		if redisErr == nil {
+			client = utils.GetRedisClient()
+		}
+		if err := middleware.InitRateLimiter(client, cfg.RateLimitRPS); err != nil {
+			slog.Error("invalid rate limit policies", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("rate limiter initialized", "rps", cfg.RateLimitRPS, "distributed", client != nil)
 	}
 
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
+	r, err := newRouter(table, lc)
+	if err != nil {
+		slog.Error("invalid route table", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("route table loaded", "routes", len(table.Entries()))
+
+	// The router is replaced as a whole when the route table is reloaded
+	var router atomic.Pointer[gin.Engine]
+	router.Store(r)
+	go reloadRoutesOnSignal(&router, cfg, lc)
+
+	if cfg.RouteProbeEnabled {
+		go routes.CheckRoutes(lc.Context(), table)
+	}
+
+	// Start server
+	slog.Info("gateway starting", "port", cfg.Port)
+	lc.Run(":"+cfg.Port, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		router.Load().ServeHTTP(w, req)
+	}))
+}
+
+// newRouter builds the routes of the gateway and of the route table. The
+// probes of lc are served by every router.
+func newRouter(table *routetable.Table, lc *server.Lifecycle) (*gin.Engine, error) {
+	if err := table.Validate(middleware.HasRateLimitPolicy); err != nil {
+		return nil, err
+	}
+
 	r := gin.New()
+	doc := openapi.Build(table)
 
 	// Global middlewares
-	r.Use(gin.Recovery())
-	r.Use(gin.Logger())
+	r.Use(middleware.Recovery())
+	// The user ID comes from the JWT middlewares, never from the client
+	r.Use(func(c *gin.Context) { c.Request.Header.Del("X-User-ID") })
+	r.Use(logging.Middleware())
+	r.Use(tracing.Middleware("gateway"))
+	metrics.Register(r)
 	r.Use(handlers.CORSMiddleware())
+	if config.GlobalConfig != nil && config.GlobalConfig.OpenAPIValidation {
+		r.Use(doc.Validator())
+	}
+	// The /api/v2 answers, rate limit errors included, share one envelope
+	r.Use(middleware.EnvelopeMiddleware())
 	r.Use(middleware.RateLimitMiddleware())
 
-	// Health check endpoints
+	// Health check endpoints, liveness and readiness probes
 	r.GET("/health", handlers.HealthCheck)
 	r.GET("/api/health", handlers.HealthCheck)
+	lc.RegisterProbes(r)
 
-	// Setup service routes
-	routes.SetupAuthRoutes(r)
-	routes.SetupUserRoutes(r)
-	routes.SetupMediaRoutes(r)
-	routes.SetupMatchRoutes(r)
-	routes.SetupChatRoutes(r)
-	routes.SetupNotifyRoutes(r)
-	routes.SetupPaiementsRoutes(r)
+	// Routes served by the gateway itself
 	routes.SetupWebSocketRoutes(r)
 	routes.SetupInternalRoutes(r)
+	routes.SetupAdminRoutes(r)
+	routes.SetupOpenAPIRoutes(r, doc)
 
-	// Start server
-	log.Printf("Gateway starting on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
+	// Routes proxied to the services
+	if err := routes.SetupTableRoutes(r, table); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reloadRoutesOnSignal reloads the route table on SIGHUP. An invalid table
+// is logged and the current routes are kept.
+func reloadRoutesOnSignal(router *atomic.Pointer[gin.Engine], cfg *config.Config, lc *server.Lifecycle) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		table, err := routetable.Load(cfg.RoutesFile)
+		var r *gin.Engine
+		if err == nil {
+			r, err = newRouter(table, lc)
+		}
+		if err != nil {
+			slog.Error("route table reload failed, keeping the current routes", "error", err)
+			continue
+		}
+
+		services.Configure(table.Upstreams)
+		router.Store(r)
+		slog.Info("route table reloaded", "routes", len(table.Entries()))
+
+		if cfg.RouteProbeEnabled {
+			go routes.CheckRoutes(lc.Context(), table)
+		}
+	}
 }
@@ -1,15 +1,16 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"slices"
 
+	"gateway/src/config"
 	"github.com/gin-gonic/gin"
 )
 
-// AdminMiddleware checks if the user has admin privileges
-// Currently implements a basic check - in production, this should validate
-// admin role from JWT claims or database
+// AdminMiddleware checks that the authenticated user is listed in
+// ADMIN_USER_IDS. Without any administrator configured, admin routes are closed.
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context (set by JWT middleware)
@@ -33,16 +34,17 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// TODO: In production, implement proper admin role checking
-		// This could involve:
-		// 1. Checking JWT claims for admin role
-		// 2. Querying user service for user role
-		// 3. Maintaining an admin user list
-		// 
-		// For now, we'll log the admin access attempt and allow it
-		// since the routes are already protected by JWT middleware
-		log.Printf("Admin access: user_id=%s path=%s", userID, c.Request.URL.Path)
+		if config.GlobalConfig == nil || !slices.Contains(config.GlobalConfig.AdminUserIDs, userID) {
+			slog.WarnContext(c.Request.Context(), "admin access denied", "user_id", userID, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Admin privileges required",
+			})
+			c.Abort()
+			return
+		}
 
+		slog.InfoContext(c.Request.Context(), "admin access", "user_id", userID, "path", c.Request.URL.Path)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gateway/src/config"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	previous := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = previous })
+
+	tests := []struct {
+		name   string
+		admins []string
+		userID string
+		status int
+	}{
+		{"listed administrator", []string{"1", "7"}, "7", http.StatusOK},
+		{"other user", []string{"1", "7"}, "8", http.StatusForbidden},
+		{"no administrator configured", nil, "1", http.StatusForbidden},
+		{"anonymous", []string{"1"}, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.GlobalConfig = &config.Config{AdminUserIDs: tt.admins}
+			w := httptest.NewRecorder()
+			rateLimitedRouter(tt.userID, AdminMiddleware()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
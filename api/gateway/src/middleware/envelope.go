@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"gateway/src/routetable"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/utils"
+)
+
+// EnvelopeMiddleware rewrites the answers of the /api/v2 routes into the
+// envelope of api/common/utils, whatever the format of the service, the
+// gateway errors included. JSON answers and errors are buffered to be
+// rewritten; other answers, e.g. images, are streamed as they are.
+func EnvelopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, routetable.V2Prefix+"/") {
+			c.Next()
+			return
+		}
+		// Compressed answers could not be rewritten
+		c.Request.Header.Del("Accept-Encoding")
+
+		w := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if !w.buffered {
+			return
+		}
+		status := w.status
+		envelope, ok := utils.ToEnvelope(status, w.body.Bytes())
+		if !ok {
+			w.ResponseWriter.WriteHeader(status)
+			_, _ = w.ResponseWriter.Write(w.body.Bytes())
+			return
+		}
+		if !envelope.Success && status < http.StatusBadRequest {
+			// {"success": false} answered with a 2xx
+			status = http.StatusBadRequest
+		}
+		body, err := json.Marshal(envelope)
+		if err != nil {
+			status = http.StatusInternalServerError
+			body = []byte(`{"success":false,"error":{"code":"internal_error","message":"Failed to encode the response"}}`)
+		}
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.ResponseWriter.WriteHeader(status)
+		_, _ = w.ResponseWriter.Write(body)
+	}
+}
+
+// envelopeWriter buffers the JSON answers and the errors, and passes the
+// other answers through
+type envelopeWriter struct {
+	gin.ResponseWriter
+	status   int
+	decided  bool
+	buffered bool
+	body     bytes.Buffer
+}
+
+func (w *envelopeWriter) WriteHeader(status int) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	switch {
+	case status == http.StatusNoContent || status == http.StatusNotModified:
+	case status >= http.StatusBadRequest, mediaType == "application/json", mediaType == "":
+		w.buffered = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *envelopeWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.WriteHeader(w.ResponseWriter.Status())
+	}
+	if !w.buffered {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	if w.buffered {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *envelopeWriter) Status() int {
+	if w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *envelopeWriter) Written() bool {
+	return w.decided
+}
+
+func (w *envelopeWriter) Flush() {
+	if w.decided && !w.buffered {
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnvelopeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(EnvelopeMiddleware())
+	legacy := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"id": 1}, "message": "found"})
+	}
+	r.GET("/api/v1/users/profile", legacy)
+	r.GET("/api/v2/users/profile", legacy)
+	r.GET("/api/v2/users/missing", func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	})
+	r.POST("/api/v2/stripe/subscription", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": "Invalid plan type"})
+	})
+	r.GET("/api/v2/media/get/:filename", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte("\x89PNG"))
+	})
+	r.DELETE("/api/v2/matches/seen", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodGet, "/api/v1/users/profile", 200, `{"data":{"id":1},"message":"found","success":true}`},
+		{http.MethodGet, "/api/v2/users/profile", 200, `{"success":true,"data":{"id":1}}`},
+		{http.MethodGet, "/api/v2/users/missing", 404, `{"success":false,"error":{"code":"not_found","message":"user not found"}}`},
+		{http.MethodPost, "/api/v2/stripe/subscription", 400, `{"success":false,"error":{"code":"bad_request","message":"Invalid plan type"}}`},
+		{http.MethodGet, "/api/v2/media/get/a.png", 200, "\x89PNG"},
+		{http.MethodDelete, "/api/v2/matches/seen", 204, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %d %s, got %d %s", tt.method, tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
@@ -2,8 +2,7 @@ package middleware
 
 import (
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -12,6 +11,7 @@ import (
 	"gateway/src/utils"
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/maxg56/matcha/api/common/logging"
 )
 
 const (
@@ -22,7 +22,7 @@ const (
 func JWTMiddleware() gin.HandlerFunc {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
-		log.Println("[gateway] WARNING: JWT_SECRET is not set; protected routes will reject requests")
+		slog.Warn("JWT_SECRET is not set, protected routes will reject requests")
 	}
 
 	return func(c *gin.Context) {
@@ -31,42 +31,80 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := utils.ExtractToken(c)
-		if tokenString == "" || secret == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
-			return
-		}
-
-		// Check if token is blacklisted before parsing
-		if utils.IsTokenBlacklisted(tokenString) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
-			return
-		}
-
-		claims, err := parseJWT(tokenString, secret)
-		if err != nil {
-			errorMsg := "invalid token"
-			if strings.Contains(err.Error(), "expired") {
-				errorMsg = "token expired"
-			} else if strings.Contains(err.Error(), "not yet valid") {
-				errorMsg = "token not yet valid"
-			}
+		claims, errorMsg := authenticate(c, secret)
+		if errorMsg != "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errorMsg})
 			return
 		}
 
 		// Extract common identifiers
 		if sub, ok := claims["sub"].(string); ok && sub != "" {
-			fmt.Printf("[JWT] Setting user ID in context: '%s'\n", sub)
 			c.Set(CtxUserIDKey, sub)
+			logging.SetUserID(c.Request.Context(), sub)
 		} else {
-			fmt.Printf("[JWT] No valid 'sub' claim found in token: %v\n", claims)
+			slog.WarnContext(c.Request.Context(), "token has no subject claim")
 		}
 
 		c.Next()
 	}
 }
 
+// OptionalJWTMiddleware identifies the user on public routes whose answer
+// depends on the viewer. Anonymous or invalid tokens are let through without
+// a user ID, and a client-supplied X-User-ID is never forwarded.
+func OptionalJWTMiddleware() gin.HandlerFunc {
+	secret := os.Getenv("JWT_SECRET")
+
+	return func(c *gin.Context) {
+		c.Request.Header.Del("X-User-ID")
+
+		if c.Request.Method != http.MethodOptions && utils.ExtractToken(c) != "" {
+			if claims, errorMsg := authenticate(c, secret); errorMsg == "" {
+				if sub, ok := claims["sub"].(string); ok && sub != "" {
+					c.Set(CtxUserIDKey, sub)
+					logging.SetUserID(c.Request.Context(), sub)
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// authenticate validates the request token and returns its claims,
+// or the error message to send back to the client
+func authenticate(c *gin.Context, secret string) (jwt.MapClaims, string) {
+	tokenString := utils.ExtractToken(c)
+	if tokenString == "" || secret == "" {
+		return nil, "missing token"
+	}
+
+	// Check if token is blacklisted before parsing
+	if utils.IsTokenBlacklisted(c.Request.Context(), tokenString) {
+		return nil, "token revoked"
+	}
+
+	claims, err := parseJWT(tokenString, secret)
+	if err != nil {
+		errorMsg := "invalid token"
+		if strings.Contains(err.Error(), "expired") {
+			errorMsg = "token expired"
+		} else if strings.Contains(err.Error(), "not yet valid") {
+			errorMsg = "token not yet valid"
+		}
+		return nil, errorMsg
+	}
+
+	// Reject tokens issued before a user-wide revocation (email change, ...)
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		if iat, ok := utils.GetNumericClaim(claims["iat"]); ok && utils.IsUserTokensInvalidated(c.Request.Context(), sub, iat) {
+			return nil, "token revoked"
+		}
+	}
+
+	return claims, ""
+}
+
 // parseJWT parses and validates a JWT token
 func parseJWT(tokenString, secret string) (jwt.MapClaims, error) {
 	if tokenString == "" {
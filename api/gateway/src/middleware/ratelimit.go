@@ -1,146 +1,427 @@
 package middleware
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gateway/src/config"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
 )
 
-// RateLimiter represents a rate limiter for a specific client
-type RateLimiter struct {
-	tokens     int
-	maxTokens  int
-	refillRate int
-	lastRefill time.Time
-	mu         sync.Mutex
+
+// Identity is what a rate limit counts requests per
+type Identity string
+
+const (
+	// IdentityIP counts requests per client IP
+	IdentityIP Identity = "ip"
+	// IdentityUser counts requests per authenticated user, per IP for
+	// anonymous requests. Use it after JWTMiddleware.
+	IdentityUser Identity = "user"
+	// IdentityAPIKey counts requests per API key (X-API-Key, then
+	// X-Internal-Key), per IP without one
+	IdentityAPIKey Identity = "api_key"
+)
+
+// GlobalPolicy applies to every request, see RateLimitMiddleware
+const GlobalPolicy = "global"
+
+// rateLimitKeyPrefix prefixes the Redis keys: ratelimit:<policy>:<identity>
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimitPolicy allows Limit requests per Period for each identity, in
+// bursts of up to Burst requests (Limit when unset)
+type RateLimitPolicy struct {
+	Name     string
+	Limit    int
+	Period   time.Duration
+	Burst    int
+	Identity Identity
+}
+
+// DefaultRateLimitPolicies are the policies route groups refer to by name.
+// RATE_LIMIT_POLICIES overrides them, and RATE_LIMIT_RPS sets the global one.
+var DefaultRateLimitPolicies = []RateLimitPolicy{
+	{Name: GlobalPolicy, Limit: 100, Period: time.Second, Identity: IdentityIP},
+	// Login, registration and account recovery
+	{Name: "auth", Limit: 10, Period: time.Minute, Burst: 5, Identity: IdentityIP},
+	// Authenticated API calls
+	{Name: "api", Limit: 300, Period: time.Minute, Burst: 60, Identity: IdentityUser},
+	// Public images
+	{Name: "media", Limit: 600, Period: time.Minute, Burst: 120, Identity: IdentityIP},
+	// Uploads and image processing
+	{Name: "upload", Limit: 20, Period: time.Minute, Burst: 5, Identity: IdentityUser},
+	// Calls from other services
+	{Name: "internal", Limit: 6000, Period: time.Minute, Burst: 600, Identity: IdentityAPIKey},
+}
+
+// burst returns the number of requests allowed at once
+func (p RateLimitPolicy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// interval is the time one request takes to be forgotten
+func (p RateLimitPolicy) interval() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// ParseRateLimitPolicies reads policies written as name=limit/period[:identity]
+// separated by commas, e.g. "auth=5/1m,api=600/1m:user"
+func ParseRateLimitPolicies(spec string) ([]RateLimitPolicy, error) {
+	var policies []RateLimitPolicy
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rule, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid rate limit policy %q", entry)
+		}
+		rule, identity, _ := strings.Cut(rule, ":")
+		limit, period, ok := strings.Cut(rule, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit policy %q: expected limit/period", entry)
+		}
+
+		policy := RateLimitPolicy{Name: strings.TrimSpace(name), Identity: Identity(identity)}
+		var err error
+		if policy.Limit, err = strconv.Atoi(limit); err != nil || policy.Limit < 1 {
+			return nil, fmt.Errorf("invalid limit in rate limit policy %q", entry)
+		}
+		if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+			return nil, fmt.Errorf("invalid period in rate limit policy %q", entry)
+		}
+		switch policy.Identity {
+		case "", IdentityIP, IdentityUser, IdentityAPIKey:
+		default:
+			return nil, fmt.Errorf("unknown identity in rate limit policy %q", entry)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
 }
 
-// TokenBucket manages rate limiting per client
-type TokenBucket struct {
-	limiters map[string]*RateLimiter
+// rateLimitResult is the outcome of one request against one policy
+type rateLimitResult struct {
+	allowed bool
+	// retryAfter is how long to wait before the next request is allowed
+	retryAfter time.Duration
+	// resetAfter is how long until the identity is back to a full burst
+	resetAfter time.Duration
+}
+
+// rateLimitStore keeps the theoretical arrival time (GCRA) of each key
+type rateLimitStore interface {
+	take(ctx context.Context, key string, interval, tolerance time.Duration) (rateLimitResult, error)
+	// peek returns how long until the key is back to a full burst
+	peek(ctx context.Context, key string) (time.Duration, error)
+	reset(ctx context.Context, pattern string) (int, error)
+}
+
+// RateLimiter applies the policies, on Redis so that limits hold across
+// gateway replicas. It falls back to an in-process store while Redis fails.
+type RateLimiter struct {
 	mu       sync.RWMutex
-	
-	maxTokens  int
-	refillRate int // tokens per second
+	policies map[string]RateLimitPolicy
+	store    rateLimitStore
+	fallback *memoryRateLimitStore
+	// lastFallback is when Redis last failed, to log once a minute
+	lastFallback atomic.Int64
+}
+
+var globalRateLimiter *RateLimiter
+
+var rateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_rate_limit_requests_total",
+	Help: "Requests checked by the rate limiter, by policy and result (allowed or limited).",
+}, []string{"policy", "result"})
+
+// NewRateLimiter creates a limiter with the given policies. A nil client
+// keeps the counters in process.
+func NewRateLimiter(client *redis.Client, policies []RateLimitPolicy) *RateLimiter {
+	rl := &RateLimiter{
+		policies: make(map[string]RateLimitPolicy),
+		fallback: newMemoryRateLimitStore(),
+	}
+	rl.store = rl.fallback
+	if client != nil {
+		rl.store = &redisRateLimitStore{client: client}
+	}
+	rl.SetPolicies(policies)
+	return rl
+}
+
+// InitRateLimiter sets up the global rate limiter. The global policy allows
+// maxRPS requests per second and IP; RATE_LIMIT_POLICIES overrides the others.
+func InitRateLimiter(client *redis.Client, maxRPS int) error {
+	policies := append([]RateLimitPolicy{}, DefaultRateLimitPolicies...)
+	for i := range policies {
+		if policies[i].Name == GlobalPolicy {
+			policies[i].Limit = maxRPS
+		}
+	}
+
+	if config.GlobalConfig != nil && config.GlobalConfig.RateLimitPolicies != "" {
+		overrides, err := ParseRateLimitPolicies(config.GlobalConfig.RateLimitPolicies)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, overrides...)
+	}
+
+	globalRateLimiter = NewRateLimiter(client, policies)
+	go globalRateLimiter.fallback.cleanup()
+	return nil
+}
+
+// SetPolicies replaces the policies; later entries win over earlier ones
+func (rl *RateLimiter) SetPolicies(policies []RateLimitPolicy) {
+	byName := make(map[string]RateLimitPolicy, len(policies))
+	for _, policy := range policies {
+		if policy.Identity == "" {
+			policy.Identity = IdentityIP
+		}
+		byName[policy.Name] = policy
+	}
+	rl.mu.Lock()
+	rl.policies = byName
+	rl.mu.Unlock()
 }
 
-var globalRateLimiter *TokenBucket
+// Policy returns the policy with the given name
+func (rl *RateLimiter) Policy(name string) (RateLimitPolicy, bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	policy, ok := rl.policies[name]
+	return policy, ok
+}
+
+// Policies returns every policy, sorted by name
+func (rl *RateLimiter) Policies() []RateLimitPolicy {
+	rl.mu.RLock()
+	policies := make([]RateLimitPolicy, 0, len(rl.policies))
+	for _, policy := range rl.policies {
+		policies = append(policies, policy)
+	}
+	rl.mu.RUnlock()
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies
+}
+
+func (rl *RateLimiter) take(ctx context.Context, policy RateLimitPolicy, identity string) rateLimitResult {
+	key := rateLimitKeyPrefix + policy.Name + ":" + identity
+	interval := policy.interval()
+	tolerance := interval * time.Duration(policy.burst())
 
-// InitRateLimiter initializes the global rate limiter
-func InitRateLimiter(maxRPS int) {
-	globalRateLimiter = &TokenBucket{
-		limiters:   make(map[string]*RateLimiter),
-		maxTokens:  maxRPS,
-		refillRate: maxRPS,
+	result, err := rl.store.take(ctx, key, interval, tolerance)
+	if err != nil && rl.store != rateLimitStore(rl.fallback) {
+		if now, last := time.Now().Unix(), rl.lastFallback.Load(); now-last >= 60 && rl.lastFallback.CompareAndSwap(last, now) {
+			slog.WarnContext(ctx, "redis unavailable, rate limiting in process", "error", err)
+		}
+		result, err = rl.fallback.take(ctx, key, interval, tolerance)
 	}
-	
-	// Start cleanup goroutine to remove old limiters
-	go globalRateLimiter.cleanup()
+	if err != nil {
+		// Never reject traffic because the limiter failed
+		return rateLimitResult{allowed: true}
+	}
+	return result
 }
 
-// RateLimitMiddleware provides rate limiting per IP address
+// RateLimitMiddleware applies the global policy to every request
 func RateLimitMiddleware() gin.HandlerFunc {
+	return RateLimit(GlobalPolicy)
+}
+
+// RateLimit applies the named policy to a route group. Unknown policies and
+// a disabled limiter let every request through.
+func RateLimit(policyName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip if rate limiting is disabled
-		if config.GlobalConfig == nil || !config.GlobalConfig.RateLimitEnabled {
+		if globalRateLimiter == nil || config.GlobalConfig == nil || !config.GlobalConfig.RateLimitEnabled {
+			c.Next()
+			return
+		}
+		policy, ok := globalRateLimiter.Policy(policyName)
+		if !ok {
 			c.Next()
 			return
 		}
-		
-		// Get client identifier (IP address)
-		clientID := c.ClientIP()
-		
-		// Check rate limit
-		if !globalRateLimiter.Allow(clientID) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please try again later.",
+
+		result := globalRateLimiter.take(c.Request.Context(), policy, identify(c, policy.Identity))
+		setRateLimitHeaders(c, policy, result)
+		if !result.allowed {
+			rateLimitDecisions.WithLabelValues(policy.Name, "limited").Inc()
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Rate limit exceeded. Please try again later.",
+				"policy":      policy.Name,
+				"retry_after": ceilSeconds(result.retryAfter),
 			})
-			c.Abort()
 			return
 		}
-		
+		rateLimitDecisions.WithLabelValues(policy.Name, "allowed").Inc()
+
 		c.Next()
 	}
 }
 
-// Allow checks if a request is allowed for the given client
-func (tb *TokenBucket) Allow(clientID string) bool {
-	tb.mu.Lock()
-	defer tb.mu.Unlock()
-	
-	// Get or create rate limiter for this client
-	limiter, exists := tb.limiters[clientID]
-	if !exists {
-		limiter = &RateLimiter{
-			tokens:     tb.maxTokens,
-			maxTokens:  tb.maxTokens,
-			refillRate: tb.refillRate,
-			lastRefill: time.Now(),
+// identify returns the identity a request is counted against
+func identify(c *gin.Context, identity Identity) string {
+	switch identity {
+	case IdentityUser:
+		if userID := c.GetString(CtxUserIDKey); userID != "" {
+			return "user:" + userID
+		}
+	case IdentityAPIKey:
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = c.GetHeader("X-Internal-Key")
+		}
+		if key != "" {
+			return "key:" + HashAPIKey(key)
 		}
-		tb.limiters[clientID] = limiter
 	}
-	
-	return limiter.consume()
+	return "ip:" + c.ClientIP()
 }
 
-// consume tries to consume a token from the rate limiter
-func (rl *RateLimiter) consume() bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	
-	now := time.Now()
-	elapsed := now.Sub(rl.lastRefill)
-	
-	// Refill tokens based on elapsed time
-	if elapsed > 0 {
-		tokensToAdd := int(elapsed.Seconds()) * rl.refillRate
-		rl.tokens = min(rl.maxTokens, rl.tokens+tokensToAdd)
-		rl.lastRefill = now
-	}
-	
-	// Try to consume a token
-	if rl.tokens > 0 {
-		rl.tokens--
-		return true
-	}
-	
-	return false
+// HashAPIKey returns the short hash API keys are stored under
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ctxRateLimitRemaining holds the lowest remaining count reported so far,
+// so that the headers describe the policy closest to its limit
+const ctxRateLimitRemaining = "ratelimit_remaining"
+
+// setRateLimitHeaders sets the RateLimit-* headers (IETF draft)
+func setRateLimitHeaders(c *gin.Context, policy RateLimitPolicy, result rateLimitResult) {
+	burst := policy.burst()
+	remaining := 0
+	if result.allowed {
+		remaining = int((time.Duration(burst)*policy.interval() - result.resetAfter) / policy.interval())
+		remaining = max(0, min(burst, remaining))
+	}
+
+	if previous, exists := c.Get(ctxRateLimitRemaining); exists && previous.(int) < remaining {
+		return
+	}
+	c.Set(ctxRateLimitRemaining, remaining)
+
+	c.Header("RateLimit-Limit", strconv.Itoa(burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.resetAfter)))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d;policy=%q",
+		policy.Limit, ceilSeconds(policy.Period), burst, policy.Name))
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// HasRateLimitPolicy tells whether a policy with that name exists, among
+// the default policies when rate limiting is off
+func HasRateLimitPolicy(name string) bool {
+	if globalRateLimiter == nil {
+		return slices.ContainsFunc(DefaultRateLimitPolicies, func(p RateLimitPolicy) bool { return p.Name == name })
+	}
+	_, ok := globalRateLimiter.Policy(name)
+	return ok
+}
+
+// RateLimitState is the state of one identity under one policy
+type RateLimitState struct {
+	Policy    string `json:"policy"`
+	Identity  string `json:"identity"`
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	Burst     int    `json:"burst"`
+	Remaining int    `json:"remaining"`
+	ResetIn   int    `json:"reset_in"`
 }
 
-// cleanup removes old rate limiters to prevent memory leaks
-func (tb *TokenBucket) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-	
-	for {
-		select {
-		case <-ticker.C:
-			tb.mu.Lock()
-			now := time.Now()
-			
-			for clientID, limiter := range tb.limiters {
-				limiter.mu.Lock()
-				// Remove limiters that haven't been used for 10 minutes
-				if now.Sub(limiter.lastRefill) > 10*time.Minute {
-					delete(tb.limiters, clientID)
-				}
-				limiter.mu.Unlock()
-			}
-			
-			tb.mu.Unlock()
+// RateLimitInspect returns the state of an identity (ip:<addr>, user:<id>
+// or key:<hash>) under every policy counting that kind of identity
+func RateLimitInspect(ctx context.Context, identity string) ([]RateLimitState, error) {
+	if globalRateLimiter == nil {
+		return nil, fmt.Errorf("rate limiting is disabled")
+	}
+
+	var states []RateLimitState
+	for _, policy := range globalRateLimiter.Policies() {
+		if !policyCounts(policy, identity) {
+			continue
+		}
+		resetAfter, err := globalRateLimiter.store.peek(ctx, rateLimitKeyPrefix+policy.Name+":"+identity)
+		if err != nil {
+			return nil, err
 		}
+		burst := policy.burst()
+		remaining := int((time.Duration(burst)*policy.interval() - resetAfter) / policy.interval())
+		states = append(states, RateLimitState{
+			Policy:    policy.Name,
+			Identity:  identity,
+			Limit:     policy.Limit,
+			Window:    policy.Period.String(),
+			Burst:     burst,
+			Remaining: max(0, min(burst, remaining)),
+			ResetIn:   ceilSeconds(resetAfter),
+		})
+	}
+	return states, nil
+}
+
+// RateLimitReset clears the counters of an identity, under one policy or
+// all of them when policy is empty. It returns the number of counters cleared.
+func RateLimitReset(ctx context.Context, identity, policy string) (int, error) {
+	if globalRateLimiter == nil {
+		return 0, fmt.Errorf("rate limiting is disabled")
 	}
+	if policy == "" {
+		policy = "*"
+	}
+	pattern := rateLimitKeyPrefix + policy + ":" + identity
+	cleared, err := globalRateLimiter.store.reset(ctx, pattern)
+	if globalRateLimiter.store != rateLimitStore(globalRateLimiter.fallback) {
+		n, _ := globalRateLimiter.fallback.reset(ctx, pattern)
+		cleared += n
+	}
+	return cleared, err
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// policyCounts tells whether the policy may hold counters for the identity
+func policyCounts(policy RateLimitPolicy, identity string) bool {
+	kind, _, _ := strings.Cut(identity, ":")
+	switch kind {
+	case "ip":
+		return true // every identity falls back to the IP
+	case "user":
+		return policy.Identity == IdentityUser
+	case "key":
+		return policy.Identity == IdentityAPIKey
 	}
-	return b
+	return false
 }
 
 // GetRateLimitStats returns current rate limiting statistics
@@ -150,14 +431,16 @@ func GetRateLimitStats() map[string]interface{} {
 			"enabled": false,
 		}
 	}
-	
-	globalRateLimiter.mu.RLock()
-	defer globalRateLimiter.mu.RUnlock()
-	
+
+	_, distributed := globalRateLimiter.store.(*redisRateLimitStore)
+	policies := make([]string, 0)
+	for _, policy := range globalRateLimiter.Policies() {
+		policies = append(policies, policy.Name)
+	}
 	return map[string]interface{}{
-		"enabled":        true,
-		"max_tokens":     globalRateLimiter.maxTokens,
-		"refill_rate":    globalRateLimiter.refillRate,
-		"active_clients": len(globalRateLimiter.limiters),
+		"enabled":          true,
+		"distributed":      distributed,
+		"policies":         policies,
+		"fallback_clients": globalRateLimiter.fallback.size(),
 	}
-}
\ No newline at end of file
+}
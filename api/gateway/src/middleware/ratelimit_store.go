@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript applies the generic cell rate algorithm atomically. The key
+// holds the theoretical arrival time (TAT) in microseconds, on the Redis
+// clock so that every replica agrees on time.
+// Returns {allowed, retry_after_us, reset_after_us}.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, allow_at - now, tat - now}
+end
+
+-- Lua prints large numbers in %.14g, keep every microsecond
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, 0, new_tat - now}
+`)
+
+// peekScript returns how long until the key is back to a full burst, in microseconds
+var peekScript = redis.NewScript(`
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat then
+	return 0
+end
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+if tat < now then
+	return 0
+end
+return tat - now
+`)
+
+// redisRateLimitStore shares the counters between gateway replicas
+type redisRateLimitStore struct {
+	client *redis.Client
+}
+
+func (s *redisRateLimitStore) take(ctx context.Context, key string, interval, tolerance time.Duration) (rateLimitResult, error) {
+	values, err := gcraScript.Run(ctx, s.client, []string{key}, interval.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+	return rateLimitResult{
+		allowed:    values[0] == 1,
+		retryAfter: time.Duration(values[1]) * time.Microsecond,
+		resetAfter: time.Duration(values[2]) * time.Microsecond,
+	}, nil
+}
+
+func (s *redisRateLimitStore) peek(ctx context.Context, key string) (time.Duration, error) {
+	us, err := peekScript.Run(ctx, s.client, []string{key}).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(us) * time.Microsecond, nil
+}
+
+func (s *redisRateLimitStore) reset(ctx context.Context, pattern string) (int, error) {
+	cleared := 0
+	iter := s.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := s.client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return cleared, err
+		}
+		cleared += int(n)
+	}
+	return cleared, iter.Err()
+}
+
+// memoryRateLimitStore applies the same algorithm in process, for a single
+// gateway or while Redis is unavailable
+type memoryRateLimitStore struct {
+	mu  sync.Mutex
+	tat map[string]time.Time
+	now func() time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{tat: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *memoryRateLimitStore) take(ctx context.Context, key string, interval, tolerance time.Duration) (rateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	tat, ok := s.tat[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-tolerance)
+	if now.Before(allowAt) {
+		return rateLimitResult{retryAfter: allowAt.Sub(now), resetAfter: tat.Sub(now)}, nil
+	}
+
+	s.tat[key] = newTAT
+	return rateLimitResult{allowed: true, resetAfter: newTAT.Sub(now)}, nil
+}
+
+func (s *memoryRateLimitStore) peek(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tat, ok := s.tat[key]
+	if now := s.now(); ok && tat.After(now) {
+		return tat.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (s *memoryRateLimitStore) reset(ctx context.Context, pattern string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cleared := 0
+	for key := range s.tat {
+		if matched, _ := path.Match(pattern, key); matched {
+			delete(s.tat, key)
+			cleared++
+		}
+	}
+	return cleared, nil
+}
+
+func (s *memoryRateLimitStore) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tat)
+}
+
+// cleanup removes the keys that are back to a full burst
+func (s *memoryRateLimitStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := s.now()
+		for key, tat := range s.tat {
+			if tat.Before(now) {
+				delete(s.tat, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gateway/src/config"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// setupRateLimiter installs an in-process limiter on a fixed clock
+func setupRateLimiter(t *testing.T, policies ...RateLimitPolicy) *time.Time {
+	t.Helper()
+	previousConfig, previousLimiter := config.GlobalConfig, globalRateLimiter
+	t.Cleanup(func() { config.GlobalConfig, globalRateLimiter = previousConfig, previousLimiter })
+
+	config.GlobalConfig = &config.Config{RateLimitEnabled: true, AdminUserIDs: []string{"1"}}
+	globalRateLimiter = NewRateLimiter(nil, policies)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	globalRateLimiter.fallback.now = func() time.Time { return now }
+	return &now
+}
+
+func rateLimitedRouter(userID string, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set(CtxUserIDKey, userID)
+		}
+	})
+	handlers = append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/test", handlers...)
+	return r
+}
+
+func get(r *gin.Engine, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_AllowsBurstThenRejects(t *testing.T) {
+	now := setupRateLimiter(t, RateLimitPolicy{Name: "auth", Limit: 6, Period: time.Minute, Burst: 3, Identity: IdentityIP})
+	r := rateLimitedRouter("", RateLimit("auth"))
+
+	for i := 0; i < 3; i++ {
+		w := get(r, "10.0.0.1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+		if got, want := w.Header().Get("RateLimit-Remaining"), []string{"2", "1", "0"}[i]; got != want {
+			t.Errorf("request %d: expected RateLimit-Remaining %s, got %s", i+1, want, got)
+		}
+	}
+	w := get(r, "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("expected Retry-After 10 (one request every 10s), got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "3" {
+		t.Errorf("expected RateLimit-Limit 3, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != `6;w=60;burst=3;policy="auth"` {
+		t.Errorf("unexpected RateLimit-Policy %q", got)
+	}
+
+	if w := get(r, "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("other IPs have their own limit, got %d", w.Code)
+	}
+
+	*now = now.Add(10 * time.Second)
+	if w := get(r, "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("expected a request to be allowed after the interval, got %d", w.Code)
+	}
+	if w := get(r, "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected only one request to be allowed after the interval, got %d", w.Code)
+	}
+}
+
+func TestRateLimit_CountsDecisions(t *testing.T) {
+	setupRateLimiter(t, RateLimitPolicy{Name: "counted", Limit: 2, Period: time.Minute, Identity: IdentityIP})
+	r := rateLimitedRouter("", RateLimit("counted"))
+
+	for i := 0; i < 3; i++ {
+		get(r, "10.0.0.1")
+	}
+	if got := testutil.ToFloat64(rateLimitDecisions.WithLabelValues("counted", "allowed")); got != 2 {
+		t.Errorf("expected 2 allowed requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(rateLimitDecisions.WithLabelValues("counted", "limited")); got != 1 {
+		t.Errorf("expected 1 limited request, got %v", got)
+	}
+}
+
+func TestRateLimit_CountsPerUser(t *testing.T) {
+	setupRateLimiter(t, RateLimitPolicy{Name: "api", Limit: 1, Period: time.Minute, Identity: IdentityUser})
+	alice := rateLimitedRouter("1", RateLimit("api"))
+	bob := rateLimitedRouter("2", RateLimit("api"))
+
+	if w := get(alice, "10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := get(bob, "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("users behind the same IP have their own limit, got %d", w.Code)
+	}
+	if w := get(alice, "10.0.0.2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("a user keeps their limit across IPs, got %d", w.Code)
+	}
+}
+
+func TestRateLimit_ReportsPolicyClosestToLimit(t *testing.T) {
+	setupRateLimiter(t,
+		RateLimitPolicy{Name: GlobalPolicy, Limit: 100, Period: time.Second},
+		RateLimitPolicy{Name: "upload", Limit: 5, Period: time.Minute, Identity: IdentityUser},
+	)
+	r := rateLimitedRouter("1", RateLimit(GlobalPolicy), RateLimit("upload"), RateLimit("unknown"))
+
+	w := get(r, "10.0.0.1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "4" {
+		t.Errorf("expected the upload policy to be reported, got remaining %q", got)
+	}
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	setupRateLimiter(t, RateLimitPolicy{Name: "auth", Limit: 1, Period: time.Minute})
+	config.GlobalConfig.RateLimitEnabled = false
+	r := rateLimitedRouter("", RateLimit("auth"))
+
+	for i := 0; i < 3; i++ {
+		if w := get(r, "10.0.0.1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("expected rate limiting to be skipped, got %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimit_InspectAndReset(t *testing.T) {
+	setupRateLimiter(t,
+		RateLimitPolicy{Name: "auth", Limit: 2, Period: time.Minute},
+		RateLimitPolicy{Name: "api", Limit: 2, Period: time.Minute, Identity: IdentityUser},
+	)
+	r := rateLimitedRouter("7", RateLimit("auth"), RateLimit("api"))
+	get(r, "10.0.0.1")
+	get(r, "10.0.0.1")
+	if w := get(r, "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+
+	ctx := context.Background()
+	states, err := RateLimitInspect(ctx, "user:7")
+	if err != nil || len(states) != 1 || states[0].Policy != "api" || states[0].Remaining != 0 {
+		t.Fatalf("unexpected state of user:7: %+v, %v", states, err)
+	}
+
+	cleared, err := RateLimitReset(ctx, "ip:10.0.0.1", "auth")
+	if err != nil || cleared != 1 {
+		t.Fatalf("expected one counter cleared, got %d, %v", cleared, err)
+	}
+	states, _ = RateLimitInspect(ctx, "ip:10.0.0.1")
+	for _, state := range states {
+		if state.Policy == "auth" && state.Remaining != 2 {
+			t.Errorf("expected the auth limit to be reset, got %+v", state)
+		}
+	}
+	if w := get(r, "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the api limit of the user to still apply, got %d", w.Code)
+	}
+
+	if _, err := RateLimitReset(ctx, "user:7", ""); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(r, "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("expected every limit to be reset, got %d", w.Code)
+	}
+}
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	policies, err := ParseRateLimitPolicies("auth=5/1m, api=600/1m:user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 2 || policies[0].Limit != 5 || policies[0].Period != time.Minute ||
+		policies[1].Identity != IdentityUser {
+		t.Errorf("unexpected policies: %+v", policies)
+	}
+
+	for _, spec := range []string{"auth", "auth=5", "auth=0/1m", "auth=5/soon", "auth=5/1m:device"} {
+		if _, err := ParseRateLimitPolicies(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestAdminMiddleware_RequiresListedUser(t *testing.T) {
+	setupRateLimiter(t)
+	for userID, want := range map[string]int{"1": http.StatusOK, "2": http.StatusForbidden, "": http.StatusUnauthorized} {
+		r := rateLimitedRouter(userID, AdminMiddleware())
+		if w := get(r, "10.0.0.1"); w.Code != want {
+			t.Errorf("user %q: expected %d, got %d", userID, want, w.Code)
+		}
+	}
+}
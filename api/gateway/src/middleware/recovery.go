@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery answers 500 when a handler panics, like gin.Recovery, but lets
+// http.ErrAbortHandler through: net/http then closes the connection, so the
+// client sees an interrupted response rather than a complete but truncated one.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, p any) {
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		slog.ErrorContext(c.Request.Context(), "panic recovered",
+			"panic", p,
+			"path", c.Request.URL.Path,
+			"stack", string(debug.Stack()),
+		)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,374 @@
+// Package openapi describes the public API of the gateway as an OpenAPI 3
+// document, built from the route table, and checks requests and responses
+// against it in development.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gateway/src/routetable"
+)
+
+// Version is the version of OpenAPI the document follows
+const Version = "3.0.3"
+
+const bearerAuth = "bearerAuth"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	// operations are indexed by method and gin path, e.g. "GET /users/:id"
+	operations map[string]*Operation
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations in the docs
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path, by lower case method
+type PathItem map[string]*Operation
+
+// Operation is a route of the API
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+
+	// body, success and failure are checked by the validator. success and
+	// failure are only set on the /api/v2 routes, whose answers have a
+	// known format.
+	body    *routetable.Schema
+	success *routetable.Schema
+	failure *routetable.Schema
+}
+
+// Parameter is a path parameter
+type Parameter struct {
+	Name     string             `json:"name"`
+	In       string             `json:"in"`
+	Required bool               `json:"required"`
+	Schema   *routetable.Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of a request
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is an answer of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType gives the schema of a body
+type MediaType struct {
+	Schema *routetable.Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by the operations
+type Components struct {
+	Schemas         map[string]*routetable.Schema `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme     `json:"securitySchemes"`
+}
+
+// SecurityScheme is how a client authenticates
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+const description = `Every route of the API is served under /api/v2, with
+the same authentication and rate limits as under /api/v1.
+
+The /api/v2 routes answer with one envelope: {"success": true, "data": ...}
+or {"success": false, "error": {"code": "not_found", "message": "..."}}.
+The /api/v1 routes answer in the format of each service, and are kept for
+the existing clients.`
+
+// envelopeSchemas are the formats of the answers
+var envelopeSchemas = map[string]*routetable.Schema{
+	"Envelope": {
+		Type:        "object",
+		Description: "Answer of the /api/v2 routes",
+		Required:    []string{"success"},
+		Properties: map[string]*routetable.Schema{
+			"success": {Type: "boolean"},
+			"data":    {Nullable: true},
+			"error":   ref("Error"),
+		},
+	},
+	"ErrorEnvelope": {
+		Type:        "object",
+		Description: "Failed answer of the /api/v2 routes",
+		Required:    []string{"success", "error"},
+		Properties: map[string]*routetable.Schema{
+			"success": {Type: "boolean"},
+			"error":   ref("Error"),
+		},
+	},
+	"Error": {
+		Type:     "object",
+		Required: []string{"code", "message"},
+		Properties: map[string]*routetable.Schema{
+			"code":    {Type: "string", Description: "Stable code, e.g. not_found or rate_limited"},
+			"message": {Type: "string"},
+		},
+	},
+	"LegacyResponse": {
+		Type:        "object",
+		Description: "Answer of the /api/v1 routes, in the format of each service",
+		Properties: map[string]*routetable.Schema{
+			"success": {Type: "boolean"},
+			"data":    {Nullable: true},
+			"error":   {Type: "string"},
+			"message": {Type: "string"},
+		},
+	},
+}
+
+// gatewayRoutes are served by the gateway itself
+var gatewayRoutes = []struct {
+	method, path, summary string
+	auth                  routetable.Auth
+}{
+	{http.MethodGet, "/health", "Health of the gateway", routetable.AuthNone},
+	{http.MethodGet, "/api/health", "Health of the gateway", routetable.AuthNone},
+	{http.MethodGet, "/health/live", "Liveness probe", routetable.AuthNone},
+	{http.MethodGet, "/health/ready", "Readiness probe", routetable.AuthNone},
+	{http.MethodGet, "/ws", "Unified WebSocket: chat, notifications and presence", routetable.AuthJWT},
+	{http.MethodGet, "/api/v1/admin/rate-limits", "Inspect the rate limits of a client", routetable.AuthAdmin},
+	{http.MethodDelete, "/api/v1/admin/rate-limits", "Reset the rate limits of a client", routetable.AuthAdmin},
+}
+
+// Build describes the routes of the gateway and of table
+func Build(table *routetable.Table) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Matcha API",
+			Description: description,
+			Version:     "2",
+		},
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*routetable.Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		operations: make(map[string]*Operation),
+	}
+	for name, schema := range envelopeSchemas {
+		doc.Components.Schemas[name] = schema
+	}
+	for name, schema := range table.Schemas {
+		doc.Components.Schemas[name] = schema
+	}
+
+	tags := map[string]bool{"gateway": true}
+	for _, route := range gatewayRoutes {
+		op := &Operation{
+			Summary:   route.summary,
+			Tags:      []string{"gateway"},
+			Responses: map[string]Response{"default": {Description: "Answer of the gateway"}},
+			Security:  security(route.auth),
+		}
+		doc.add(route.method, route.path, op)
+	}
+
+	for _, entry := range table.Entries() {
+		tag := serviceName(table, entry.Upstream) + " (v1)"
+		tags[tag] = true
+		doc.add(entry.Method, entry.Path, doc.operation(entry, tag, false))
+	}
+	for _, entry := range table.V2Entries() {
+		tag := serviceName(table, entry.Upstream)
+		tags[tag] = true
+		doc.add(entry.Method, entry.Path, doc.operation(entry, tag, true))
+	}
+
+	for name := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: name})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		// The /api/v2 routes first
+		iv1, jv1 := strings.HasSuffix(doc.Tags[i].Name, "(v1)"), strings.HasSuffix(doc.Tags[j].Name, "(v1)")
+		if iv1 != jv1 {
+			return jv1
+		}
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+	return doc
+}
+
+// operation describes a route of the table
+func (d *Document) operation(entry routetable.Entry, tag string, v2 bool) *Operation {
+	op := &Operation{
+		Summary:  entry.Summary,
+		Tags:     []string{tag},
+		Security: security(entry.Auth),
+	}
+
+	if entry.Body != "" {
+		op.body = ref(entry.Body)
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: op.body}},
+		}
+	}
+
+	if !v2 {
+		op.Responses = map[string]Response{
+			"default": {
+				Description: "Answer of " + strings.TrimSuffix(tag, " (v1)"),
+				Content:     jsonContent(ref("LegacyResponse")),
+			},
+		}
+		return op
+	}
+
+	op.success = ref("Envelope")
+	if entry.Response != "" {
+		op.success = &routetable.Schema{
+			Type:     "object",
+			Required: []string{"success", "data"},
+			Properties: map[string]*routetable.Schema{
+				"success": {Type: "boolean"},
+				"data":    ref(entry.Response),
+			},
+		}
+	}
+	op.failure = ref("ErrorEnvelope")
+	op.Responses = map[string]Response{
+		"2XX":     {Description: "Success", Content: jsonContent(op.success)},
+		"default": {Description: "Error", Content: jsonContent(op.failure)},
+	}
+	return op
+}
+
+// add registers op under the gin path of the route
+func (d *Document) add(method, ginPath string, op *Operation) {
+	path, params := convertPath(ginPath)
+	for _, param := range params {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   &routetable.Schema{Type: "string"},
+		})
+	}
+	op.OperationID = operationID(method, path)
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+	d.operations[method+" "+ginPath] = op
+}
+
+// Operation returns the operation of a route, given its gin path
+func (d *Document) Operation(method, ginPath string) (*Operation, bool) {
+	op, ok := d.operations[method+" "+ginPath]
+	return op, ok
+}
+
+// JSON encodes the document
+func (d *Document) JSON() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+// Schema resolves a reference to a schema of the document
+func (d *Document) Schema(s *routetable.Schema) (*routetable.Schema, error) {
+	for s != nil && s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, routetable.SchemaRefPrefix)
+		resolved, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema %q", s.Ref)
+		}
+		s = resolved
+	}
+	return s, nil
+}
+
+func ref(name string) *routetable.Schema {
+	return &routetable.Schema{Ref: routetable.SchemaRefPrefix + name}
+}
+
+func jsonContent(schema *routetable.Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+func security(auth routetable.Auth) []map[string][]string {
+	switch auth {
+	case routetable.AuthJWT, routetable.AuthAdmin:
+		return []map[string][]string{{bearerAuth: {}}}
+	case routetable.AuthOptional:
+		// Anonymous, or identified when a token is sent
+		return []map[string][]string{{}, {bearerAuth: {}}}
+	default:
+		return nil
+	}
+}
+
+func serviceName(table *routetable.Table, upstream string) string {
+	if u, ok := table.Upstreams[upstream]; ok {
+		return u.Name
+	}
+	return upstream
+}
+
+// convertPath turns the :param and *param segments of a gin path into
+// OpenAPI {param}
+func convertPath(ginPath string) (string, []string) {
+	segments := strings.Split(ginPath, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+var nonWord = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// operationID names an operation after its method and path, e.g.
+// get_api_v2_users_profile_id
+func operationID(method, path string) string {
+	name := strings.Trim(nonWord.ReplaceAllString(path, "_"), "_")
+	id := strings.ToLower(method) + "_" + name
+	if strings.HasSuffix(path, "/") && path != "/" {
+		// /api/stripe/subscription/ and /api/stripe/subscription
+		id += "_"
+	}
+	return id
+}
@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"gateway/src/routetable"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/utils"
+)
+
+// maxValidatedBody bounds the bodies read by the validator, larger ones are
+// not checked
+const maxValidatedBody = 1 << 20
+
+// Validator checks the requests and responses of the routes against the
+// document. A request whose body does not match its schema is rejected with
+// a 400 listing the mismatches; a response that does not match is logged.
+// It reads the bodies, and is meant for development.
+func (d *Document) Validator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := d.Operation(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if op.body != nil {
+			if problems := d.checkRequest(c.Request, op.body); len(problems) > 0 {
+				message := "Request does not match the API schema: " + strings.Join(problems, "; ")
+				if strings.HasPrefix(c.FullPath(), routetable.V2Prefix+"/") {
+					utils.RespondEnvelopeError(c, http.StatusBadRequest, message)
+				} else {
+					utils.RespondError(c, http.StatusBadRequest, message)
+				}
+				c.Abort()
+				return
+			}
+		}
+
+		if op.success == nil {
+			c.Next()
+			return
+		}
+		w := &teeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if problems := d.checkResponse(w, op); len(problems) > 0 {
+			slog.WarnContext(c.Request.Context(), "response does not match the API schema",
+				"method", c.Request.Method,
+				"route", c.FullPath(),
+				"status", w.Status(),
+				"problems", strings.Join(problems, "; "),
+			)
+		}
+	}
+}
+
+// checkRequest checks the JSON body of req, and puts it back for the
+// handlers
+func (d *Document) checkRequest(req *http.Request, schema *routetable.Schema) []string {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return []string{"body: must be sent as application/json"}
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return []string{"body: is required"}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxValidatedBody+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+	if err != nil || len(data) > maxValidatedBody {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []string{"body: invalid JSON"}
+	}
+	return d.Validate(schema, value)
+}
+
+// checkResponse checks the JSON answer recorded by w
+func (d *Document) checkResponse(w *teeWriter, op *Operation) []string {
+	status := w.Status()
+	if w.truncated || status == http.StatusNoContent || status == http.StatusNotModified {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if mediaType != "application/json" {
+		if status >= http.StatusBadRequest {
+			return []string{"body: must be sent as application/json"}
+		}
+		// Files, e.g. images and exports
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(w.body.Bytes(), &value); err != nil {
+		return []string{"body: invalid JSON"}
+	}
+	schema := op.success
+	if status >= http.StatusBadRequest {
+		schema = op.failure
+	}
+	return d.Validate(schema, value)
+}
+
+// teeWriter keeps a copy of the response it writes
+type teeWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *teeWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *teeWriter) record(data []byte) {
+	if w.body.Len()+len(data) > maxValidatedBody {
+		w.truncated = true
+		return
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway/src/routetable"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBuild_DescribesTheRouteTable(t *testing.T) {
+	doc := Build(routetable.Default())
+
+	login := doc.Paths["/api/v2/auth/login"]["post"]
+	if login == nil || login.RequestBody == nil || login.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/LoginRequest" {
+		t.Fatalf("expected the v2 login to take a LoginRequest, got %+v", login)
+	}
+	if login.Security != nil || login.Tags[0] != "auth-service" {
+		t.Errorf("unexpected login operation: %+v", login)
+	}
+	if _, ok := login.Responses["2XX"]; !ok {
+		t.Errorf("expected the v2 login to answer with the envelope, got %+v", login.Responses)
+	}
+	if legacy := doc.Paths["/api/v1/auth/login"]["post"]; legacy == nil || legacy.Tags[0] != "auth-service (v1)" {
+		t.Errorf("expected the v1 login to be kept, got %+v", legacy)
+	}
+
+	profile := doc.Paths["/api/v1/users/profile/{id}"]["get"]
+	if profile == nil || len(profile.Parameters) != 1 || profile.Parameters[0].Name != "id" || profile.Parameters[0].In != "path" {
+		t.Fatalf("expected the id path parameter, got %+v", profile)
+	}
+	if len(profile.Security) != 2 {
+		t.Errorf("expected the profile to be served with or without a token, got %+v", profile.Security)
+	}
+	if ws := doc.Paths["/ws"]["get"]; ws == nil || ws.Security[0][bearerAuth] == nil {
+		t.Errorf("expected the WebSocket to require a token, got %+v", ws)
+	}
+
+	ids := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if ids[op.OperationID] {
+				t.Errorf("%s %s: operation ID %s used twice", method, path, op.OperationID)
+			}
+			ids[op.OperationID] = true
+		}
+	}
+
+	data, err := doc.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every reference resolves
+	for _, match := range strings.Split(string(data), `"$ref":"`)[1:] {
+		ref := match[:strings.Index(match, `"`)]
+		if _, err := doc.Schema(&routetable.Schema{Ref: ref}); err != nil {
+			t.Errorf("unresolved reference: %v", err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := Build(routetable.Default())
+	request := func(body string) []string {
+		var value any
+		if err := json.Unmarshal([]byte(body), &value); err != nil {
+			t.Fatal(err)
+		}
+		return doc.Validate(&routetable.Schema{Ref: "#/components/schemas/MessageRequest"}, value)
+	}
+
+	if problems := request(`{"conversation_id": 3, "message": "hi", "attachment_ids": [1]}`); len(problems) != 0 {
+		t.Errorf("expected a valid message, got %v", problems)
+	}
+	problems := request(`{"conversation_id": 0.5, "message": 3, "attachment_ids": ["a"]}`)
+	for _, want := range []string{
+		"conversation_id: must be an integer",
+		"conversation_id: must be at least 1",
+		"message: must be a string",
+		"attachment_ids[0]: must be a number",
+	} {
+		if !strings.Contains(strings.Join(problems, "\n"), want) {
+			t.Errorf("expected %q in %v", want, problems)
+		}
+	}
+	if problems := request(`{}`); len(problems) != 1 || problems[0] != "body: conversation_id is required" {
+		t.Errorf("expected the conversation to be required, got %v", problems)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc := Build(routetable.Default())
+	r := gin.New()
+	r.Use(doc.Validator())
+	login := func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"login": body["login"]}})
+	}
+	r.POST("/api/v1/auth/login", login)
+	r.POST("/api/v2/auth/login", login)
+
+	tests := []struct {
+		path, contentType, body string
+		status                  int
+		response                string
+	}{
+		{"/api/v1/auth/login", "application/json", `{"login":"ada","password":"secret"}`, 200, `"login":"ada"`},
+		{"/api/v1/auth/login", "application/json", `{"login":"ada"}`, 400, `"error":"Request does not match the API schema: body: password is required"`},
+		{"/api/v1/auth/login", "text/plain", `login=ada`, 400, "must be sent as application/json"},
+		{"/api/v2/auth/login", "application/json", `{"login":1,"password":"secret"}`, 400, `"code":"bad_request"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.response) {
+			t.Errorf("%s %s: expected %d with %s, got %d %s", tt.path, tt.body, tt.status, tt.response, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidator_LogsResponsesNotMatching(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	gin.SetMode(gin.TestMode)
+	doc := Build(routetable.Default())
+	r := gin.New()
+	r.Use(doc.Validator())
+	r.POST("/api/v2/auth/refresh", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"access_token": "a"}})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/auth/refresh", strings.NewReader(`{"refresh_token":"r"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the response to be sent anyway, got %d", w.Code)
+	}
+	for _, want := range []string{"response does not match the API schema", "data: refresh_token is required"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected %q in the logs:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/openapi.json", Build(routetable.Default()).Handler())
+	r.GET("/api/docs", DocsHandler("/api/openapi.json"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc.OpenAPI != Version || doc.Paths["/api/v2/matches/like"] == nil {
+		t.Errorf("unexpected document: %v %s", err, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if !strings.Contains(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), `url: "/api/openapi.json"`) {
+		t.Errorf("unexpected docs page: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,154 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"gateway/src/routetable"
+)
+
+// Validate checks value, decoded from JSON, against schema. It lists every
+// mismatch, prefixed with the path of the value in the body.
+func (d *Document) Validate(schema *routetable.Schema, value any) []string {
+	var problems []string
+	d.validate(schema, value, "", &problems)
+	return problems
+}
+
+func (d *Document) validate(schema *routetable.Schema, value any, path string, problems *[]string) {
+	report := func(format string, args ...any) {
+		where := path
+		if where == "" {
+			where = "body"
+		}
+		*problems = append(*problems, where+": "+fmt.Sprintf(format, args...))
+	}
+
+	schema, err := d.Schema(schema)
+	if err != nil {
+		report("%v", err)
+		return
+	}
+	if schema == nil {
+		return
+	}
+	if value == nil {
+		if schema.Type != "" && !schema.Nullable {
+			report("must not be null")
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			report("must be an object")
+			return
+		}
+		for _, field := range schema.Required {
+			if _, ok := object[field]; !ok {
+				report("%s is required", field)
+			}
+		}
+		fields := make([]string, 0, len(object))
+		for field := range object {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if property, ok := schema.Properties[field]; ok {
+				d.validate(property, object[field], join(path, field), problems)
+			}
+		}
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			report("must be an array")
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			report("must have at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			report("must have at most %d items", *schema.MaxItems)
+		}
+		for i, item := range items {
+			d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			report("must be a string")
+			return
+		}
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			report("must have at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			report("must have at most %d characters", *schema.MaxLength)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+			report("must be one of %v", schema.Enum)
+		}
+		if err := checkFormat(schema.Format, s); err != nil {
+			report("%v", err)
+		}
+
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			report("must be a number")
+			return
+		}
+		if schema.Type == "integer" && n != math.Trunc(n) {
+			report("must be an integer")
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			report("must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			report("must be at most %v", *schema.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("must be a boolean")
+		}
+	}
+}
+
+// checkFormat checks the formats the services rely on. Others are only
+// documented.
+func checkFormat(format, s string) error {
+	switch format {
+	case "email":
+		if _, err := mail.ParseAddress(s); err != nil {
+			return fmt.Errorf("must be an email")
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return fmt.Errorf("must be a date (YYYY-MM-DD)")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("must be an RFC 3339 date and time")
+		}
+	}
+	return nil
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gateway/src/services"
+)
+
+const (
+	// breakerThreshold consecutive failures open the breaker
+	breakerThreshold = 5
+	// breakerCooldown is how long an open breaker rejects requests before
+	// letting a probe through
+	breakerCooldown = 15 * time.Second
+)
+
+// ErrCircuitOpen is returned while the breaker of an upstream rejects requests
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets every request through
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects every request until the cooldown is over
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets one probe request through, which closes the
+	// breaker when it succeeds and opens it again when it fails
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// Breaker stops sending requests to an upstream after consecutive failures
+type Breaker struct {
+	name     string
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// NewBreaker returns the closed breaker of an upstream
+func NewBreaker(name string) *Breaker {
+	return &Breaker{name: name, state: BreakerClosed, now: time.Now}
+}
+
+// Allow tells whether a request may be sent. Every allowed request must be
+// followed by a call to Record.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < breakerCooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Record reports the outcome of an allowed request
+func (b *Breaker) Record(ctx context.Context, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if success {
+			b.state, b.failures = BreakerClosed, 0
+			slog.InfoContext(ctx, "circuit breaker closed", "service", b.name)
+		} else {
+			b.state, b.openedAt = BreakerOpen, b.now()
+			slog.WarnContext(ctx, "circuit breaker opened again, probe failed", "service", b.name)
+		}
+		return
+	}
+
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerClosed && b.failures >= breakerThreshold {
+		b.state, b.openedAt = BreakerOpen, b.now()
+		slog.WarnContext(ctx, "circuit breaker opened", "service", b.name, "consecutive_failures", b.failures)
+	}
+}
+
+// Release gives back an allowed request whose outcome is unknown, such as
+// a request cancelled by the client
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// BreakerStatus is the state of the breaker of one upstream, shown on /health
+type BreakerStatus struct {
+	State    BreakerState `json:"state"`
+	Failures int          `json:"consecutive_failures"`
+	// RetryIn is the time left before a probe, while open
+	RetryIn string `json:"retry_in,omitempty"`
+}
+
+// Status returns the current state of the breaker
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state == BreakerOpen {
+		if left := breakerCooldown - b.now().Sub(b.openedAt); left > 0 {
+			status.RetryIn = left.Round(time.Second).String()
+		} else {
+			status.State = BreakerHalfOpen
+		}
+	}
+	return status
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*Breaker)
+)
+
+// breakerFor returns the breaker of an upstream
+func breakerFor(upstream string) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	breaker, ok := breakers[upstream]
+	if !ok {
+		breaker = NewBreaker(upstream)
+		breakers[upstream] = breaker
+	}
+	return breaker
+}
+
+// BreakerStatuses returns the breaker of every configured upstream
+func BreakerStatuses() map[string]BreakerStatus {
+	statuses := make(map[string]BreakerStatus)
+	for name := range services.GetServicesStatus() {
+		statuses[name] = breakerFor(name).Status()
+	}
+	return statuses
+}
@@ -1,24 +1,50 @@
 package proxy
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"gateway/src/config"
 	"gateway/src/middleware"
 	"gateway/src/services"
 	"gateway/src/utils"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/koding/websocketproxy"
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
+// defaultTimeout applies to services without a timeout when HTTP_TIMEOUT
+// is not loaded
+const defaultTimeout = 30 * time.Second
+
+// Options tune how a route is proxied
+type Options struct {
+	// Timeout bounds the whole exchange, body included. Zero uses the
+	// timeout of the service, then HTTP_TIMEOUT.
+	Timeout time.Duration
+	// Hedge sends a second GET when the first has not answered after that
+	// delay, and keeps the first answer. Zero disables it.
+	Hedge time.Duration
+}
+
 // ProxyRequest creates a handler that proxies requests to the specified service
 func ProxyRequest(serviceName, path string) gin.HandlerFunc {
+	return ProxyRoute(serviceName, path, Options{})
+}
+
+// ProxyRoute creates a handler that streams requests to the specified
+// service and its answers back. Connections are pooled between requests,
+// idempotent requests without body are retried, and the circuit breaker of
+// the service rejects requests while it is failing.
+func ProxyRoute(serviceName, path string, opts Options) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		service, exists := services.GetService(serviceName)
 		if !exists {
@@ -27,60 +53,101 @@ func ProxyRequest(serviceName, path string) gin.HandlerFunc {
 			})
 			return
 		}
-
-		// Build target URL
-		targetURL := service.URL + replacePlaceholders(path, c)
-
-		// Copy query parameters
-		if c.Request.URL.RawQuery != "" {
-			targetURL += "?" + c.Request.URL.RawQuery
-		}
-
-		// Create request
-		var body io.Reader
-		if c.Request.Body != nil {
-			bodyBytes, err := io.ReadAll(c.Request.Body)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Failed to read request body",
-				})
-				return
-			}
-			body = bytes.NewReader(bodyBytes)
-		}
-
-		req, err := http.NewRequest(c.Request.Method, targetURL, body)
+		target, err := url.Parse(service.URL)
 		if err != nil {
-			log.Printf("Error creating request to %s: %v", targetURL, err)
+			slog.ErrorContext(c.Request.Context(), "invalid service URL", "service", serviceName, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to create request",
 			})
 			return
 		}
 
-		// Copy headers
-		copyHeaders(c, req)
-
-		// Execute request
-		client := &http.Client{
-			Timeout: 30 * time.Second,
+		ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout(service, opts.Timeout))
+		defer cancel()
+
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.Out.URL.Scheme = target.Scheme
+				pr.Out.URL.Host = target.Host
+				pr.Out.URL.Path = target.Path + replacePlaceholders(path, c)
+				pr.Out.URL.RawPath = ""
+				pr.Out.URL.RawQuery = pr.In.URL.RawQuery
+				pr.Out.Host = ""
+
+				// Keep the chain of the reverse proxy in front of the gateway
+				pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+				pr.SetXForwarded()
+				setUserHeaders(c, pr.Out)
+			},
+			Transport: &upstreamTransport{
+				base:    tracedTransport,
+				breaker: breakerFor(serviceName),
+				hedge:   opts.Hedge,
+			},
+			ModifyResponse: func(resp *http.Response) error {
+				// The client gets the trace ID from the gateway, which
+				// started the trace
+				resp.Header.Del(tracing.TraceIDHeader)
+				return nil
+			},
+			ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+				proxyError(c, service, req, err)
+			},
 		}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("Error proxying request to %s: %v", targetURL, err)
-			c.JSON(http.StatusBadGateway, gin.H{
-				"error": fmt.Sprintf("Service %s unavailable", service.Name),
-			})
-			return
-		}
-		defer resp.Body.Close()
+		defer func() {
+			// The upstream failed while its answer was streamed. The panic
+			// goes on to net/http, which closes the client connection so
+			// that the truncated response is not taken as complete.
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					slog.WarnContext(ctx, "upstream response interrupted", "service", service.Name)
+				}
+				panic(p)
+			}
+		}()
+		proxy.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	}
+}
 
-		// Copy response
-		copyResponse(c, resp)
+// proxyError answers a request the upstream did not answer
+func proxyError(c *gin.Context, service services.ServiceConfig, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The client went away
+		c.Abort()
+	case errors.Is(err, ErrCircuitOpen):
+		c.Header("Retry-After", strconv.Itoa(int(breakerCooldown.Seconds())))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": fmt.Sprintf("Service %s temporarily unavailable", service.Name),
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.ErrorContext(req.Context(), "upstream timed out", "service", service.Name, "url", req.URL.String(), "error", err)
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error": fmt.Sprintf("Service %s timed out", service.Name),
+		})
+	default:
+		slog.ErrorContext(req.Context(), "upstream unavailable", "service", service.Name, "url", req.URL.String(), "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": fmt.Sprintf("Service %s unavailable", service.Name),
+		})
 	}
 }
 
+// requestTimeout picks the timeout of the route, then of the service
+func requestTimeout(service services.ServiceConfig, timeout time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	if service.Timeout > 0 {
+		return service.Timeout
+	}
+	if config.GlobalConfig != nil && config.GlobalConfig.HTTPTimeout > 0 {
+		return config.GlobalConfig.HTTPTimeout
+	}
+	return defaultTimeout
+}
+
 // replacePlaceholders replaces path parameters in the target path
 func replacePlaceholders(path string, c *gin.Context) string {
 	result := path
@@ -94,23 +161,13 @@ func replacePlaceholders(path string, c *gin.Context) string {
 	return result
 }
 
-// copyHeaders copies request headers and adds user context headers
-func copyHeaders(c *gin.Context, req *http.Request) {
-	// Copy all headers except Host, preserving multiple values
-	for key, values := range c.Request.Header {
-		if key == "Host" {
-			continue
-		}
-		for _, v := range values {
-			req.Header.Add(key, v)
-		}
-	}
-
+// setUserHeaders adds the user context to a proxied request
+func setUserHeaders(c *gin.Context, req *http.Request) {
 	// Propagate authenticated user id if present in context
 	if v, ok := c.Get(middleware.CtxUserIDKey); ok {
 		if s, ok := v.(string); ok && s != "" {
 			req.Header.Set("X-User-ID", s)
-			utils.LogDebug("proxy", "User authenticated and ID propagated to service")
+			slog.DebugContext(c.Request.Context(), "user ID propagated to the service")
 		}
 	}
 
@@ -120,34 +177,12 @@ func copyHeaders(c *gin.Context, req *http.Request) {
 	}
 }
 
-// copyResponse copies the upstream response to the client
-func copyResponse(c *gin.Context, resp *http.Response) {
-	// Set status code and copy headers (including multiple Set-Cookie values)
-	c.Status(resp.StatusCode)
-	for key, values := range resp.Header {
-		for _, v := range values {
-			c.Writer.Header().Add(key, v)
-		}
-	}
-
-	// Copy body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to read response body",
-		})
-		return
-	}
-
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), body)
-}
-
 // ProxyWebSocket creates a handler that proxies WebSocket connections to the specified service
 func ProxyWebSocket(serviceName, path string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		service, exists := services.GetService(serviceName)
 		if !exists {
-			log.Printf("WebSocket service %s not available", serviceName)
+			slog.WarnContext(c.Request.Context(), "websocket service not available", "service", serviceName)
 			c.AbortWithStatus(http.StatusServiceUnavailable)
 			return
 		}
@@ -165,12 +200,12 @@ func ProxyWebSocket(serviceName, path string) gin.HandlerFunc {
 			targetURL += "?" + c.Request.URL.RawQuery
 		}
 
-		log.Printf("Proxying WebSocket connection to: %s", targetURL)
+		slog.DebugContext(c.Request.Context(), "proxying websocket connection", "service", serviceName, "path", targetPath)
 
 		// Parse the target URL
 		backendURL, err := url.Parse(targetURL)
 		if err != nil {
-			log.Printf("Error parsing WebSocket URL %s: %v", targetURL, err)
+			slog.ErrorContext(c.Request.Context(), "invalid websocket URL", "service", serviceName, "path", targetPath, "error", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
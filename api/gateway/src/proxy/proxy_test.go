@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gateway/src/middleware"
+	"gateway/src/routetable"
+	"gateway/src/services"
+	"github.com/gin-gonic/gin"
+)
+
+// setupUpstream serves handler as the service name
+func setupUpstream(t *testing.T, name string, handler http.HandlerFunc) {
+	t.Helper()
+	upstream := httptest.NewServer(handler)
+	t.Cleanup(upstream.Close)
+	services.Configure(map[string]routetable.Upstream{name: {Name: name + "-service", URL: upstream.URL}})
+	t.Cleanup(services.InitServices)
+}
+
+func serve(r *gin.Engine, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, body))
+	return w
+}
+
+func TestProxyRoute_StreamsRequestAndResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupUpstream(t, "stream", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		fmt.Fprintf(w, "%s %s?%s user=%s bytes=%d", r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("X-User-ID"), n)
+	})
+
+	r := gin.New()
+	r.POST("/upload/:id", func(c *gin.Context) { c.Set(middleware.CtxUserIDKey, "7") },
+		ProxyRoute("stream", "/api/v1/media/:id/upload", Options{}))
+
+	w := serve(r, http.MethodPost, "/upload/42?kind=photo", bytes.NewReader(make([]byte, 5<<20)))
+	if want := "POST /api/v1/media/42/upload?kind=photo user=7 bytes=5242880"; w.Body.String() != want {
+		t.Errorf("expected %q, got %d %q", want, w.Code, w.Body.String())
+	}
+	if cookies := w.Header()["Set-Cookie"]; len(cookies) != 2 {
+		t.Errorf("expected both cookies, got %v", cookies)
+	}
+}
+
+func TestProxyRoute_RetriesIdempotentRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	setupUpstream(t, "retry", func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := gin.New()
+	r.Any("/test", ProxyRoute("retry", "/test", Options{}))
+
+	if w := serve(r, http.MethodGet, "/test", nil); w.Code != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("expected the GET to be retried once, got %d after %d calls", w.Code, calls.Load())
+	}
+
+	calls.Store(0)
+	if w := serve(r, http.MethodPost, "/test", strings.NewReader("{}")); w.Code != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("expected the POST not to be retried, got %d after %d calls", w.Code, calls.Load())
+	}
+}
+
+func TestProxyRoute_CircuitBreaker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	setupUpstream(t, "flaky", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	now := time.Now()
+	breaker := breakerFor("flaky")
+	breaker.now = func() time.Time { return now }
+
+	r := gin.New()
+	r.POST("/test", ProxyRoute("flaky", "/test", Options{}))
+
+	for i := 0; i < breakerThreshold; i++ {
+		serve(r, http.MethodPost, "/test", nil)
+	}
+	calls.Store(0)
+	w := serve(r, http.MethodPost, "/test", nil)
+	if w.Code != http.StatusServiceUnavailable || calls.Load() != 0 || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected the open breaker to reject the request, got %d after %d calls", w.Code, calls.Load())
+	}
+	if status := BreakerStatuses()["flaky"]; status.State != BreakerOpen {
+		t.Errorf("expected the breaker to be reported open, got %+v", status)
+	}
+
+	// After the cooldown one probe goes through, and closes the breaker
+	now = now.Add(breakerCooldown)
+	healthy.Store(true)
+	if w := serve(r, http.MethodPost, "/test", nil); w.Code != http.StatusOK || calls.Load() != 1 {
+		t.Fatalf("expected the probe to reach the upstream, got %d after %d calls", w.Code, calls.Load())
+	}
+	if status := breaker.Status(); status.State != BreakerClosed {
+		t.Errorf("expected the breaker to be closed, got %+v", status)
+	}
+}
+
+func TestBreaker_HalfOpenLetsOneProbeThrough(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker("test")
+	breaker.now = func() time.Time { return now }
+	for i := 0; i < breakerThreshold; i++ {
+		breaker.Allow()
+		breaker.Record(context.Background(), false)
+	}
+	if breaker.Allow() {
+		t.Fatal("expected the open breaker to reject requests")
+	}
+
+	now = now.Add(breakerCooldown)
+	if !breaker.Allow() || breaker.Allow() {
+		t.Fatal("expected a single probe while half open")
+	}
+	breaker.Record(context.Background(), false)
+	if breaker.Allow() {
+		t.Error("expected a failed probe to open the breaker again")
+	}
+
+	now = now.Add(breakerCooldown)
+	if !breaker.Allow() {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	breaker.Release()
+	if !breaker.Allow() {
+		t.Error("expected a released probe to let another one through")
+	}
+}
+
+func TestProxyRoute_HedgesSlowReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	setupUpstream(t, "hedge", func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("fast"))
+	})
+
+	r := gin.New()
+	r.GET("/test", ProxyRoute("hedge", "/test", Options{Hedge: 20 * time.Millisecond}))
+
+	start := time.Now()
+	w := serve(r, http.MethodGet, "/test", nil)
+	if w.Body.String() != "fast" || time.Since(start) > time.Second {
+		t.Errorf("expected the hedged request to answer, got %q after %v", w.Body.String(), time.Since(start))
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", calls.Load())
+	}
+}
+
+func TestProxyRoute_Timeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupUpstream(t, "slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+
+	r := gin.New()
+	r.GET("/test", ProxyRoute("slow", "/test", Options{Timeout: 50 * time.Millisecond}))
+
+	w := serve(r, http.MethodGet, "/test", nil)
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusGatewayTimeout || body["error"] != "Service slow-service timed out" {
+		t.Errorf("expected 504, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestProxyRoute_InterruptedResponseAbortsTheConnection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupUpstream(t, "broken", func(w http.ResponseWriter, r *http.Request) {
+		// Chunked: the gateway cannot tell the client how long the body is
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	})
+
+	r := gin.New()
+	r.Use(middleware.Recovery())
+	r.GET("/test", ProxyRoute("broken", "/test", Options{}))
+	gateway := httptest.NewServer(r)
+	defer gateway.Close()
+
+	resp, err := http.Get(gateway.URL + "/test")
+	if err == nil {
+		defer resp.Body.Close()
+		var body []byte
+		body, err = io.ReadAll(resp.Body)
+		if err == nil {
+			t.Errorf("expected the client to see the response interrupted, got a complete %q", body)
+		}
+	}
+}
+
+func TestProxyRoute_UnknownService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/test", ProxyRequest("nonexistent", "/test"))
+
+	if w := serve(r, http.MethodGet, "/test", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
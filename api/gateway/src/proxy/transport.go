@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+const (
+	// maxRetries of an idempotent request without body
+	maxRetries = 2
+	// retryBackoff doubles after each attempt, with jitter
+	retryBackoff = 50 * time.Millisecond
+)
+
+// transport is shared by every upstream, so that connections are reused
+// between requests
+var transport = &http.Transport{
+	DialContext: (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          512,
+	MaxIdleConnsPerHost:   64,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ExpectContinueTimeout: time.Second,
+	// Uploads and downloads are streamed, not buffered
+	WriteBufferSize: 32 << 10,
+	ReadBufferSize:  32 << 10,
+}
+
+// tracedTransport sends the trace context to the upstream, with a client
+// span for each attempt of a retried or hedged request
+var tracedTransport = tracing.Transport(transport)
+
+var idempotentMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete,
+}
+
+// upstreamTransport sends the requests of one upstream through its circuit
+// breaker, retries the idempotent ones and hedges the slow reads
+type upstreamTransport struct {
+	base    http.RoundTripper
+	breaker *Breaker
+	// hedge is the delay before a second GET is sent, zero to disable
+	hedge time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	// A streamed body cannot be sent twice
+	if slices.Contains(idempotentMethods, req.Method) && req.Body == nil {
+		attempts += maxRetries
+	}
+
+	for attempt := 1; ; attempt++ {
+		if !t.breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+		resp, err := t.send(req)
+		if errors.Is(req.Context().Err(), context.Canceled) {
+			// The client went away, which says nothing of the upstream
+			t.breaker.Release()
+		} else {
+			t.breaker.Record(req.Context(), !upstreamFailed(resp, err))
+		}
+
+		if attempt == attempts || !upstreamFailed(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+
+		backoff := retryBackoff << (attempt - 1)
+		backoff += rand.N(backoff / 2)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// upstreamFailed tells whether the upstream could not handle the request
+func upstreamFailed(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (t *upstreamTransport) send(req *http.Request) (*http.Response, error) {
+	if t.hedge <= 0 || req.Method != http.MethodGet || req.Body != nil {
+		return t.base.RoundTrip(req)
+	}
+	return hedged(t.base, req, t.hedge)
+}
+
+type hedgeResult struct {
+	resp  *http.Response
+	err   error
+	index int
+}
+
+// hedged sends a second request when the first one has not answered after
+// delay, and returns the first answer. The other request is cancelled.
+func hedged(rt http.RoundTripper, req *http.Request, delay time.Duration) (*http.Response, error) {
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := rt.RoundTrip(req.Clone(ctx))
+			results <- hedgeResult{resp: resp, err: err, index: index}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	for {
+		select {
+		case <-timer.C:
+			launch()
+			pending++
+		case result := <-results:
+			pending--
+			if result.err != nil {
+				cancels[result.index]()
+				if pending > 0 {
+					continue
+				}
+				return nil, result.err
+			}
+
+			timer.Stop()
+			for i, cancel := range cancels {
+				if i != result.index {
+					cancel()
+				}
+			}
+			// The losing request may still answer before noticing
+			for ; pending > 0; pending-- {
+				go func() {
+					if lost := <-results; lost.resp != nil {
+						lost.resp.Body.Close()
+					}
+				}()
+			}
+			result.resp.Body = &cancelOnClose{ReadCloser: result.resp.Body, cancel: cancels[result.index]}
+			return result.resp, nil
+		}
+	}
+}
+
+// cancelOnClose releases the context of a hedged request with its body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
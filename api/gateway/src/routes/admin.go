@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"gateway/src/handlers"
+	"gateway/src/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes configures the admin routes served by the gateway itself
+func SetupAdminRoutes(r *gin.Engine) {
+	admin := r.Group("/api/v1/admin")
+	admin.Use(middleware.JWTMiddleware())
+	admin.Use(middleware.AdminMiddleware())
+	{
+		// Inspect and reset rate limits (?ip=, ?user_id= or ?api_key=)
+		admin.GET("/rate-limits", handlers.GetRateLimits)
+		admin.DELETE("/rate-limits", handlers.ResetRateLimits)
+	}
+}
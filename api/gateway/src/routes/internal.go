@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
 	"gateway/src/handlers"
 	"gateway/src/middleware"
 )
@@ -12,7 +13,8 @@ func SetupInternalRoutes(r *gin.Engine) {
 
 	// Routes internes (protégées par clé API ou IP restriction)
 	internal := r.Group("/api/internal")
-	internal.Use(middleware.InternalServiceMiddleware()) // Middleware pour vérifier les services internes
+	internal.Use(internalapi.Middleware()) // Middleware pour vérifier les services internes
+	internal.Use(middleware.RateLimit("internal"))
 	{
 		// WebSocket broadcast endpoint pour les services externes
 		websocket := internal.Group("/websocket")
@@ -1,37 +0,0 @@
-package routes
-
-import (
-	"gateway/src/middleware"
-	"gateway/src/proxy"
-	"github.com/gin-gonic/gin"
-)
-
-// SetupMediaRoutes configures media service routes
-func SetupMediaRoutes(r *gin.Engine) {
-	media := r.Group("/api/v1/media")
-
-	// Health check endpoint (without authentication)
-	media.GET("/health", proxy.ProxyRequest("media", "/health"))
-	media.GET("/", proxy.ProxyRequest("media", "/health"))
-
-	// Public image serving (no authentication required)
-	media.GET("/uploads/:filename", proxy.ProxyRequest("media", "/api/v1/media/uploads/:filename"))
-	media.GET("/get/:filename", proxy.ProxyRequest("media", "/api/v1/media/get/:filename"))
-
-	media.Use(middleware.JWTMiddleware()) // All other media routes require authentication
-
-	// File upload and management
-	media.POST("/upload", proxy.ProxyRequest("media", "/api/v1/media/upload"))
-	media.DELETE("/delete/:filename", proxy.ProxyRequest("media", "/api/v1/media/delete/:filename"))
-
-	// Image processing
-	media.POST("/resize", proxy.ProxyRequest("media", "/api/v1/media/resize"))
-
-	// User media management
-	media.GET("/my", proxy.ProxyRequest("media", "/api/v1/media/my"))
-	media.GET("/user/:userId", proxy.ProxyRequest("media", "/api/v1/media/user/:userId"))
-	media.POST("/profile", proxy.ProxyRequest("media", "/api/v1/media/profile"))
-
-	// Health check endpoint (without authentication)
-	r.GET("/api/media/health", proxy.ProxyRequest("media", "/health"))
-}
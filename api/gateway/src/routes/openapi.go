@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"gateway/src/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOpenAPIRoutes serves the OpenAPI document of the public API and its
+// docs
+func SetupOpenAPIRoutes(r *gin.Engine, doc *openapi.Document) {
+	r.GET("/api/openapi.json", doc.Handler())
+	r.GET("/api/docs", openapi.DocsHandler("/api/openapi.json"))
+}
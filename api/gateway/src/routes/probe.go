@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"gateway/src/routetable"
+)
+
+const (
+	probeTimeout  = 5 * time.Second
+	probeAttempts = 6
+	probeDelay    = 10 * time.Second
+)
+
+// ginNotFound is the body gin answers for a path without handler, as
+// opposed to a handler answering 404 for a missing resource
+var ginNotFound = []byte("404 page not found")
+
+// ProbeResult is the answer of an upstream to one route of the table
+type ProbeResult struct {
+	Entry  routetable.Entry
+	Status int
+	// Missing is set when the upstream has no handler for the route
+	Missing bool
+	// Err is set when the upstream could not be reached
+	Err error
+}
+
+// ProbeRoutes sends each route to its upstream, without credentials nor
+// body, to find the routes the upstream does not serve. Path parameters are
+// set to 0. The routes of an unreachable upstream are not sent.
+func ProbeRoutes(ctx context.Context, client *http.Client, upstreams map[string]routetable.Upstream, entries []routetable.Entry) []ProbeResult {
+	down := make(map[string]error)
+	results := make([]ProbeResult, 0, len(entries))
+	for _, entry := range entries {
+		result := ProbeResult{Entry: entry}
+		if err, ok := down[entry.Upstream]; ok {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		result.Status, result.Missing, result.Err = probe(ctx, client, upstreams[entry.Upstream].URL+probePath(entry.Target), entry.Method)
+		if result.Err != nil {
+			down[entry.Upstream] = result.Err
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func probe(ctx context.Context, client *http.Client, url, method string) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("User-Agent", "matcha-gateway-route-probe")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(len(ginNotFound))+1))
+	missing := resp.StatusCode == http.StatusNotFound && bytes.Equal(bytes.TrimSpace(body), ginNotFound)
+	return resp.StatusCode, missing, nil
+}
+
+// probePath fills the path parameters of a target
+func probePath(target string) string {
+	segments := strings.Split(target, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "0"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// CheckRoutes probes the routes of the table and logs the ones their
+// upstream does not serve. Unreachable upstreams, which may still be
+// starting, are probed again a few times.
+func CheckRoutes(ctx context.Context, table *routetable.Table) {
+	client := &http.Client{Timeout: probeTimeout}
+
+	var pending []routetable.Entry
+	for _, entry := range table.Entries() {
+		if entry.Probe {
+			pending = append(pending, entry)
+		}
+	}
+	total, missing := len(pending), 0
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		var unreachable []routetable.Entry
+		down := make(map[string]error)
+		for _, result := range ProbeRoutes(ctx, client, table.Upstreams, pending) {
+			entry := result.Entry
+			switch {
+			case result.Err != nil:
+				unreachable = append(unreachable, entry)
+				down[entry.Upstream] = result.Err
+			case result.Missing:
+				missing++
+				slog.WarnContext(ctx, "route not served by its upstream", "method", entry.Method, "path", entry.Path,
+					"upstream", table.Upstreams[entry.Upstream].Name, "target", entry.Target)
+			}
+		}
+		pending = unreachable
+		if len(pending) == 0 {
+			break
+		}
+
+		if attempt == probeAttempts {
+			for upstream, err := range down {
+				slog.WarnContext(ctx, "upstream unreachable, its routes were not checked", "upstream", table.Upstreams[upstream].Name, "error", err)
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(probeDelay):
+		}
+	}
+
+	slog.InfoContext(ctx, "route probe finished", "served", total-missing-len(pending), "total", total, "unchecked", len(pending))
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"fmt"
+
+	"gateway/src/middleware"
+	"gateway/src/proxy"
+	"gateway/src/routetable"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTableRoutes registers the proxied routes of the route table, and
+// their /api/v2 version. Routes that conflict with each other or with the
+// routes of the gateway are reported as an error.
+func SetupTableRoutes(r *gin.Engine, table *routetable.Table) (err error) {
+	defer func() {
+		// gin panics on conflicting routes
+		if p := recover(); p != nil {
+			err = fmt.Errorf("conflicting routes: %v", p)
+		}
+	}()
+
+	for _, entry := range append(table.Entries(), table.V2Entries()...) {
+		handlers := authHandlers(entry.Auth)
+		for _, policy := range entry.RateLimits {
+			handlers = append(handlers, middleware.RateLimit(policy))
+		}
+		handlers = append(handlers, proxy.ProxyRoute(entry.Upstream, entry.Target, proxy.Options{
+			Timeout: entry.Timeout,
+			Hedge:   entry.Hedge,
+		}))
+		r.Handle(entry.Method, entry.Path, handlers...)
+	}
+	return nil
+}
+
+func authHandlers(auth routetable.Auth) []gin.HandlerFunc {
+	switch auth {
+	case routetable.AuthOptional:
+		return []gin.HandlerFunc{middleware.OptionalJWTMiddleware()}
+	case routetable.AuthJWT:
+		return []gin.HandlerFunc{middleware.JWTMiddleware()}
+	case routetable.AuthAdmin:
+		return []gin.HandlerFunc{middleware.JWTMiddleware(), middleware.AdminMiddleware()}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway/src/middleware"
+	"gateway/src/routetable"
+	"gateway/src/services"
+	"github.com/gin-gonic/gin"
+)
+
+func parseTable(t *testing.T, upstreamURL, routes string) *routetable.Table {
+	t.Helper()
+	table, err := routetable.Parse([]byte("upstreams:\n  user:\n    url: " + upstreamURL + "\n" + routes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestSetupTableRoutes_ProxiesWithAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	table := parseTable(t, upstream.URL, `
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    routes:
+      - GET /:id/images
+      - GET / -> /health
+  - prefix: /api/v1/users
+    upstream: user
+    auth: jwt
+    routes:
+      - GET /profile
+`)
+	services.Configure(table.Upstreams)
+	t.Cleanup(services.InitServices)
+
+	r := gin.New()
+	if err := SetupTableRoutes(r, table); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"/api/v1/users/42/images": "GET /api/v1/users/42/images",
+		"/api/v1/users/":          "GET /health",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("%s: expected %q, got %d %q", path, want, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/profile", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the profile to require a token, got %d", w.Code)
+	}
+}
+
+func TestSetupTableRoutes_ServesV2WithTheEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/users/42/images" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"error":"User not found"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":["a.png"]}`))
+	}))
+	defer upstream.Close()
+
+	table := parseTable(t, upstream.URL, `
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    routes:
+      - GET /:id/images
+`)
+	services.Configure(table.Upstreams)
+	t.Cleanup(services.InitServices)
+
+	r := gin.New()
+	r.Use(middleware.EnvelopeMiddleware())
+	if err := SetupTableRoutes(r, table); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"/api/v1/users/42/images": `{"success":true,"data":["a.png"]}`,
+		"/api/v2/users/42/images": `{"success":true,"data":["a.png"]}`,
+		"/api/v2/users/43/images": `{"success":false,"error":{"code":"not_found","message":"User not found"}}`,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Body.String() != want {
+			t.Errorf("%s: expected %s, got %d %s", path, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestSetupTableRoutes_ReportsConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	table := parseTable(t, "http://user-service:8002", `
+groups:
+  - prefix: /ws
+    upstream: user
+    routes:
+      - GET
+`)
+	r := gin.New()
+	SetupWebSocketRoutes(r)
+	if err := SetupTableRoutes(r, table); err == nil || !strings.Contains(err.Error(), "conflicting routes") {
+		t.Errorf("expected a conflict with /ws, got %v", err)
+	}
+}
+
+func TestProbeRoutes_FindsMissingRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := gin.New()
+	upstream.GET("/api/v1/users/profile/:id", func(c *gin.Context) {
+		// A missing resource is not a missing route
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	})
+	upstream.POST("/api/v1/users/setup", func(c *gin.Context) { c.Status(http.StatusUnauthorized) })
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	table := parseTable(t, server.URL, `
+  down:
+    url: http://127.0.0.1:1
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    routes:
+      - GET /profile/:id
+      - POST /setup
+      - GET /matrix
+  - prefix: /api/v1/down
+    upstream: down
+    routes:
+      - GET /a
+      - GET /b
+`)
+
+	results := ProbeRoutes(context.Background(), http.DefaultClient, table.Upstreams, table.Entries())
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for _, result := range results {
+		path := result.Entry.Path
+		switch {
+		case strings.HasPrefix(path, "/api/v1/down"):
+			if result.Err == nil {
+				t.Errorf("%s: expected the upstream to be unreachable", path)
+			}
+		case path == "/api/v1/users/matrix":
+			if !result.Missing {
+				t.Errorf("%s: expected the route to be missing, got %d", path, result.Status)
+			}
+		default:
+			if result.Missing || result.Err != nil {
+				t.Errorf("%s: expected the route to be served, got %+v", path, result)
+			}
+		}
+	}
+}
@@ -0,0 +1,472 @@
+// Package routetable loads the declarative route table of the gateway:
+// upstream services, and the routes proxied to them with their
+// authentication, rate limit policy, timeout and hedging.
+package routetable
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed routes.yaml
+var defaultTable []byte
+
+// Auth is the authentication a route requires
+type Auth string
+
+const (
+	// AuthNone lets anonymous requests through
+	AuthNone Auth = "none"
+	// AuthOptional identifies the user when a valid token is sent
+	AuthOptional Auth = "optional"
+	// AuthJWT requires a valid token
+	AuthJWT Auth = "jwt"
+	// AuthAdmin requires a valid token of a user listed in ADMIN_USER_IDS
+	AuthAdmin Auth = "admin"
+)
+
+var methods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Upstream is a service the gateway proxies to
+type Upstream struct {
+	// Name is the service name shown in errors, <key>-service by default
+	Name         string        `yaml:"name"`
+	URL          string        `yaml:"url"`
+	WebSocketURL string        `yaml:"websocket_url"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// Group holds routes sharing a prefix, an upstream and defaults
+type Group struct {
+	Prefix    string        `yaml:"prefix"`
+	Upstream  string        `yaml:"upstream"`
+	Target    string        `yaml:"target"`
+	Auth      Auth          `yaml:"auth"`
+	RateLimit string        `yaml:"rate_limit"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Hedge     time.Duration `yaml:"hedge"`
+	Routes    []Route       `yaml:"routes"`
+}
+
+// Route is one entry of a group, written "METHOD /path [-> /target]"
+type Route struct {
+	Method    string
+	Path      string
+	Target    string
+	Auth      Auth
+	RateLimit string
+	Timeout   time.Duration
+	Hedge     time.Duration
+	Probe     *bool
+	// Summary, Body and Response document the route in the OpenAPI document.
+	// Body and Response name schemas of the table.
+	Summary  string
+	Body     string
+	Response string
+}
+
+// UnmarshalYAML accepts both the short and the mapping form of a route
+func (r *Route) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return r.parse(node.Value)
+	}
+
+	var raw struct {
+		Route     string        `yaml:"route"`
+		Auth      Auth          `yaml:"auth"`
+		RateLimit string        `yaml:"rate_limit"`
+		Timeout   time.Duration `yaml:"timeout"`
+		Hedge     time.Duration `yaml:"hedge"`
+		Probe     *bool         `yaml:"probe"`
+		Summary   string        `yaml:"summary"`
+		Body      string        `yaml:"body"`
+		Response  string        `yaml:"response"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	if err := r.parse(raw.Route); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	r.Auth, r.RateLimit, r.Timeout, r.Hedge, r.Probe = raw.Auth, raw.RateLimit, raw.Timeout, raw.Hedge, raw.Probe
+	r.Summary, r.Body, r.Response = raw.Summary, raw.Body, raw.Response
+	return nil
+}
+
+func (r *Route) parse(spec string) error {
+	route, target, _ := strings.Cut(spec, "->")
+	fields := strings.Fields(route)
+	if len(fields) == 0 || len(fields) > 2 {
+		return fmt.Errorf("route %q must be written \"METHOD /path [-> /target]\"", spec)
+	}
+	r.Method = strings.ToUpper(fields[0])
+	if len(fields) == 2 {
+		r.Path = fields[1]
+	}
+	r.Target = strings.TrimSpace(target)
+	return nil
+}
+
+// Schema is a JSON schema of a request or response body, in the subset
+// understood by OpenAPI 3.0
+type Schema struct {
+	Ref         string             `yaml:"$ref" json:"$ref,omitempty"`
+	Type        string             `yaml:"type" json:"type,omitempty"`
+	Format      string             `yaml:"format" json:"format,omitempty"`
+	Description string             `yaml:"description" json:"description,omitempty"`
+	Nullable    bool               `yaml:"nullable" json:"nullable,omitempty"`
+	Enum        []string           `yaml:"enum" json:"enum,omitempty"`
+	Required    []string           `yaml:"required" json:"required,omitempty"`
+	Properties  map[string]*Schema `yaml:"properties" json:"properties,omitempty"`
+	Items       *Schema            `yaml:"items" json:"items,omitempty"`
+	MinLength   *int               `yaml:"min_length" json:"minLength,omitempty"`
+	MaxLength   *int               `yaml:"max_length" json:"maxLength,omitempty"`
+	Minimum     *float64           `yaml:"minimum" json:"minimum,omitempty"`
+	Maximum     *float64           `yaml:"maximum" json:"maximum,omitempty"`
+	MinItems    *int               `yaml:"min_items" json:"minItems,omitempty"`
+	MaxItems    *int               `yaml:"max_items" json:"maxItems,omitempty"`
+}
+
+// SchemaRefPrefix starts the references to the schemas of the table
+const SchemaRefPrefix = "#/components/schemas/"
+
+// Table is the route table
+type Table struct {
+	Upstreams map[string]Upstream `yaml:"upstreams"`
+	Groups    []Group             `yaml:"groups"`
+	// Schemas are the bodies referenced by the routes
+	Schemas map[string]*Schema `yaml:"schemas"`
+}
+
+// Entry is a route resolved against the defaults of its group
+type Entry struct {
+	Method   string
+	Path     string
+	Upstream string
+	Target   string
+	Auth     Auth
+	// RateLimits are applied in order, the policy of the group first
+	RateLimits []string
+	// Timeout is zero to use the one of the upstream
+	Timeout time.Duration
+	// Hedge is the delay before a second request is sent, zero for none
+	Hedge time.Duration
+	Probe bool
+	// Summary, Body and Response document the route
+	Summary  string
+	Body     string
+	Response string
+}
+
+// Entries lists the routes of the table
+func (t *Table) Entries() []Entry {
+	var entries []Entry
+	for _, group := range t.Groups {
+		targetPrefix := group.Target
+		if targetPrefix == "" {
+			targetPrefix = group.Prefix
+		}
+		for _, route := range group.Routes {
+			entry := Entry{
+				Method:   route.Method,
+				Path:     group.Prefix + route.Path,
+				Upstream: group.Upstream,
+				Target:   route.Target,
+				Auth:     group.Auth,
+				Timeout:  group.Timeout,
+				Hedge:    group.Hedge,
+				Probe:    route.Probe == nil || *route.Probe,
+				Summary:  route.Summary,
+				Body:     route.Body,
+				Response: route.Response,
+			}
+			if entry.Target == "" {
+				entry.Target = targetPrefix + route.Path
+			}
+			if route.Auth != "" {
+				entry.Auth = route.Auth
+			}
+			if entry.Auth == "" {
+				entry.Auth = AuthNone
+			}
+			for _, policy := range []string{group.RateLimit, route.RateLimit} {
+				if policy != "" && !slices.Contains(entry.RateLimits, policy) {
+					entry.RateLimits = append(entry.RateLimits, policy)
+				}
+			}
+			if route.Timeout != 0 {
+				entry.Timeout = route.Timeout
+			}
+			if route.Hedge != 0 {
+				entry.Hedge = route.Hedge
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// V2Prefix starts the routes answering with the envelope shared by every
+// service
+const V2Prefix = "/api/v2"
+
+// V2Path is the /api/v2 path of a route: /api/v1/x and /api/x are served as
+// /api/v2/x
+func V2Path(path string) (string, bool) {
+	switch {
+	case path == V2Prefix || strings.HasPrefix(path, V2Prefix+"/"):
+		return "", false
+	case strings.HasPrefix(path, "/api/v1/"):
+		return V2Prefix + strings.TrimPrefix(path, "/api/v1"), true
+	case strings.HasPrefix(path, "/api/"):
+		return V2Prefix + strings.TrimPrefix(path, "/api"), true
+	}
+	return "", false
+}
+
+// V2Entries lists the /api/v2 routes derived from the table, proxied like
+// the route they come from. Routes the table declares under /api/v2 are
+// kept, and the first route declared wins when two give the same path.
+func (t *Table) V2Entries() []Entry {
+	entries := t.Entries()
+	declared := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		declared[entry.Method+" "+entry.Path] = true
+	}
+
+	var v2 []Entry
+	for _, entry := range entries {
+		path, ok := V2Path(entry.Path)
+		if !ok || declared[entry.Method+" "+path] {
+			continue
+		}
+		declared[entry.Method+" "+path] = true
+		entry.Path = path
+		v2 = append(v2, entry)
+	}
+	return v2
+}
+
+// Default returns the route table embedded in the gateway
+func Default() *Table {
+	table, err := Parse(defaultTable)
+	if err != nil {
+		panic(fmt.Sprintf("embedded route table: %v", err))
+	}
+	return table
+}
+
+// Load reads the route table from path, or returns the embedded one when
+// path is empty
+func Load(path string) (*Table, error) {
+	if path == "" {
+		return Parse(defaultTable)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route table: %w", err)
+	}
+	table, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+// Parse decodes a route table and checks that it is consistent. Rate limit
+// policies are checked by Validate, as they are configured elsewhere.
+func Parse(data []byte) (*Table, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(expandEnv(string(data)))))
+	decoder.KnownFields(true)
+
+	var table Table
+	if err := decoder.Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid route table: %w", err)
+	}
+	for key, upstream := range table.Upstreams {
+		if upstream.Name == "" {
+			upstream.Name = key + "-service"
+			table.Upstreams[key] = upstream
+		}
+	}
+	if err := table.Validate(nil); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// Validate reports every inconsistency of the table. knownPolicy, when set,
+// tells whether a rate limit policy exists.
+func (t *Table) Validate(knownPolicy func(string) bool) error {
+	var errs []error
+	if len(t.Upstreams) == 0 {
+		errs = append(errs, errors.New("no upstream defined"))
+	}
+	for key, upstream := range t.Upstreams {
+		if err := validateURL(upstream.URL, "http", "https"); err != nil {
+			errs = append(errs, fmt.Errorf("upstream %s: url: %w", key, err))
+		}
+		if upstream.WebSocketURL != "" {
+			if err := validateURL(upstream.WebSocketURL, "ws", "wss"); err != nil {
+				errs = append(errs, fmt.Errorf("upstream %s: websocket_url: %w", key, err))
+			}
+		}
+		if upstream.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("upstream %s: negative timeout", key))
+		}
+	}
+
+	for i, group := range t.Groups {
+		if !strings.HasPrefix(group.Prefix, "/") {
+			errs = append(errs, fmt.Errorf("group %d: prefix %q must start with /", i+1, group.Prefix))
+		}
+		if _, ok := t.Upstreams[group.Upstream]; !ok {
+			errs = append(errs, fmt.Errorf("group %s: unknown upstream %q", group.Prefix, group.Upstream))
+		}
+		if len(group.Routes) == 0 {
+			errs = append(errs, fmt.Errorf("group %s: no routes", group.Prefix))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range t.Entries() {
+		name := entry.Method + " " + entry.Path
+		if !slices.Contains(methods, entry.Method) {
+			errs = append(errs, fmt.Errorf("%s: unknown method", name))
+		}
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("%s: declared twice", name))
+		}
+		seen[name] = true
+
+		if !strings.HasPrefix(entry.Target, "/") {
+			errs = append(errs, fmt.Errorf("%s: target %q must start with /", name, entry.Target))
+		}
+		params := pathParams(entry.Path)
+		for _, param := range pathParams(entry.Target) {
+			if !slices.Contains(params, param) {
+				errs = append(errs, fmt.Errorf("%s: target %s uses :%s, missing from the path", name, entry.Target, param))
+			}
+		}
+
+		switch entry.Auth {
+		case AuthNone, AuthOptional, AuthJWT, AuthAdmin:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown auth %q", name, entry.Auth))
+		}
+		if knownPolicy != nil {
+			for _, policy := range entry.RateLimits {
+				if !knownPolicy(policy) {
+					errs = append(errs, fmt.Errorf("%s: unknown rate limit policy %q", name, policy))
+				}
+			}
+		}
+		if entry.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("%s: negative timeout", name))
+		}
+		// Only reads may be sent twice
+		if entry.Hedge < 0 || (entry.Hedge > 0 && entry.Method != http.MethodGet) {
+			errs = append(errs, fmt.Errorf("%s: hedge is only allowed on GET routes", name))
+		}
+		for _, schema := range []string{entry.Body, entry.Response} {
+			if _, ok := t.Schemas[schema]; schema != "" && !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown schema %q", name, schema))
+			}
+		}
+		if entry.Body != "" && (entry.Method == http.MethodGet || entry.Method == http.MethodHead) {
+			errs = append(errs, fmt.Errorf("%s: %s requests have no body", name, entry.Method))
+		}
+	}
+
+	for name, schema := range t.Schemas {
+		if err := schema.validate(t.Schemas); err != nil {
+			errs = append(errs, fmt.Errorf("schema %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// schemaTypes are the types of OpenAPI 3.0
+var schemaTypes = []string{"", "object", "array", "string", "integer", "number", "boolean"}
+
+// validate checks the types and references of s and of its children
+func (s *Schema) validate(schemas map[string]*Schema) error {
+	if s == nil {
+		return errors.New("empty schema")
+	}
+	if s.Ref != "" {
+		if _, ok := schemas[strings.TrimPrefix(s.Ref, SchemaRefPrefix)]; !ok || !strings.HasPrefix(s.Ref, SchemaRefPrefix) {
+			return fmt.Errorf("unknown reference %q", s.Ref)
+		}
+		return nil
+	}
+	if !slices.Contains(schemaTypes, s.Type) {
+		return fmt.Errorf("unknown type %q", s.Type)
+	}
+	for _, field := range s.Required {
+		if _, ok := s.Properties[field]; !ok {
+			return fmt.Errorf("required property %s is not declared", field)
+		}
+	}
+	for field, property := range s.Properties {
+		if err := property.validate(schemas); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	if s.Type == "array" {
+		if err := s.Items.validate(schemas); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("%q must be an absolute %s URL", raw, strings.Join(schemes, " or "))
+	}
+	return nil
+}
+
+// pathParams lists the :param and *param segments of a path
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+		}
+	}
+	return params
+}
+
+var envVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// expandEnv replaces ${VAR} and ${VAR:-default} with the environment. Other
+// $ are left alone, e.g. in the $ref of schemas.
+func expandEnv(s string) string {
+	return envVariable.ReplaceAllStringFunc(s, func(match string) string {
+		name, fallback, _ := strings.Cut(match[2:len(match)-1], ":-")
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return fallback
+	})
+}
@@ -0,0 +1,254 @@
+package routetable
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func findEntry(entries []Entry, method, path string) (Entry, bool) {
+	for _, entry := range entries {
+		if entry.Method == method && entry.Path == path {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+func TestDefault_IsValid(t *testing.T) {
+	table := Default()
+	known := map[string]bool{"auth": true, "api": true, "media": true, "upload": true}
+	if err := table.Validate(func(policy string) bool { return known[policy] }); err != nil {
+		t.Fatalf("embedded route table is invalid: %v", err)
+	}
+
+	entries := table.Entries()
+	if entry, ok := findEntry(entries, "DELETE", "/api/v1/matches/seen"); !ok || entry.Upstream != "match" {
+		t.Errorf("expected DELETE /api/v1/matches/seen to go to match-service, got %+v", entry)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Path, "/api/v1/matrix") {
+			t.Errorf("match-service has no matrix routes, found %s %s", entry.Method, entry.Path)
+		}
+	}
+	if table.Upstreams["auth"].URL != "http://auth-service:8001" || table.Upstreams["auth"].Name != "auth-service" {
+		t.Errorf("unexpected auth upstream: %+v", table.Upstreams["auth"])
+	}
+}
+
+func TestParse_RouteForms(t *testing.T) {
+	t.Setenv("TEST_USER_URL", "http://users:9000")
+	table, err := Parse([]byte(`
+upstreams:
+  user:
+    url: ${TEST_USER_URL:-http://user-service:8002}
+    timeout: 5s
+  match:
+    url: ${TEST_UNSET_URL:-http://match-service:8003}
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    auth: jwt
+    rate_limit: api
+    routes:
+      - GET
+      - GET /health -> /health
+      - route: POST /upload/:id
+        auth: optional
+        rate_limit: upload
+        timeout: 1m
+        probe: false
+  - prefix: /api/v2/matches
+    upstream: match
+    target: /api/v1/matches
+    routes:
+      - delete /seen
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.Upstreams["user"].URL != "http://users:9000" || table.Upstreams["match"].URL != "http://match-service:8003" {
+		t.Errorf("unexpected upstream URLs: %+v", table.Upstreams)
+	}
+	if table.Upstreams["user"].Timeout != 5*time.Second || table.Upstreams["match"].Name != "match-service" {
+		t.Errorf("unexpected upstreams: %+v", table.Upstreams)
+	}
+
+	entries := table.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 routes, got %+v", entries)
+	}
+	if entry, _ := findEntry(entries, "GET", "/api/v1/users"); entry.Target != "/api/v1/users" || entry.Auth != AuthJWT {
+		t.Errorf("unexpected prefix route: %+v", entry)
+	}
+	if entry, _ := findEntry(entries, "GET", "/api/v1/users/health"); entry.Target != "/health" {
+		t.Errorf("unexpected health route: %+v", entry)
+	}
+	upload, _ := findEntry(entries, "POST", "/api/v1/users/upload/:id")
+	if upload.Auth != AuthOptional || upload.Timeout != time.Minute || upload.Probe ||
+		strings.Join(upload.RateLimits, ",") != "api,upload" {
+		t.Errorf("unexpected upload route: %+v", upload)
+	}
+	if entry, _ := findEntry(entries, "DELETE", "/api/v2/matches/seen"); entry.Target != "/api/v1/matches/seen" || entry.Auth != AuthNone || !entry.Probe {
+		t.Errorf("unexpected match route: %+v", entry)
+	}
+}
+
+func TestValidate_ReportsEveryError(t *testing.T) {
+	table, err := Parse([]byte(`
+upstreams:
+  user:
+    url: http://user-service:8002
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    routes:
+      - GET /profile
+      - GET /profile
+      - GET /:id -> /api/v1/users/:user_id
+      - FETCH /things
+      - route: GET /me
+        auth: session
+      - route: POST /me
+        hedge: 100ms
+  - prefix: /api/v1/matches
+    upstream: match
+    routes:
+      - GET /
+`))
+	if err == nil {
+		t.Fatalf("expected the table to be rejected, got %+v", table)
+	}
+	for _, want := range []string{
+		"GET /api/v1/users/profile: declared twice",
+		"uses :user_id, missing from the path",
+		"FETCH /api/v1/users/things: unknown method",
+		`unknown auth "session"`,
+		"POST /api/v1/users/me: hedge is only allowed on GET routes",
+		`unknown upstream "match"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q in:\n%v", want, err)
+		}
+	}
+
+	valid, err := Parse([]byte(`
+upstreams:
+  user:
+    url: http://user-service:8002
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    rate_limit: unknown
+    routes:
+      - GET /profile
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = valid.Validate(func(policy string) bool { return policy == "api" })
+	if err == nil || !strings.Contains(err.Error(), `unknown rate limit policy "unknown"`) {
+		t.Errorf("expected the policy to be rejected, got %v", err)
+	}
+}
+
+func TestParse_RejectsUnknownFieldsAndBadURLs(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field": "upstreams:\n  user:\n    url: http://user-service:8002\n    retries: 3\n",
+		"relative url":  "upstreams:\n  user:\n    url: user-service:8002\n",
+		"no upstream":   "groups: []\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestV2Entries(t *testing.T) {
+	table, err := Parse([]byte(`
+upstreams:
+  user:
+    url: http://user-service:8002
+  paiements:
+    url: http://paiements-service:8085
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    auth: jwt
+    routes:
+      - GET /profile
+      - GET /:id/images
+  - prefix: /api/v2/users
+    upstream: user
+    routes:
+      - GET /:id/images -> /api/v2/users/:id/images
+  - prefix: /api/stripe
+    upstream: paiements
+    routes:
+      - POST /webhook
+  - prefix: /api/v1/stripe
+    upstream: paiements
+    target: /api/stripe
+    routes:
+      - POST /webhook
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := table.V2Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", entries)
+	}
+	if entry, ok := findEntry(entries, "GET", "/api/v2/users/profile"); !ok || entry.Target != "/api/v1/users/profile" || entry.Auth != AuthJWT {
+		t.Errorf("unexpected profile route: %+v", entry)
+	}
+	// The first route declared wins
+	if entry, ok := findEntry(entries, "POST", "/api/v2/stripe/webhook"); !ok || entry.Target != "/api/stripe/webhook" {
+		t.Errorf("unexpected webhook route: %+v", entry)
+	}
+
+	if _, ok := V2Path("/health"); ok {
+		t.Error("expected routes outside /api to stay unversioned")
+	}
+}
+
+func TestValidate_ReportsSchemaErrors(t *testing.T) {
+	_, err := Parse([]byte(`
+upstreams:
+  user:
+    url: http://user-service:8002
+groups:
+  - prefix: /api/v1/users
+    upstream: user
+    routes:
+      - route: POST /reports
+        body: ReportRequest
+      - route: GET /search
+        body: Search
+      - route: GET /profile
+        response: Profile
+schemas:
+  Search:
+    type: object
+  Profile:
+    type: object
+    required: [id]
+    properties:
+      tags: {type: list}
+      best_match: {$ref: '#/components/schemas/Match'}
+`))
+	if err == nil {
+		t.Fatal("expected the table to be rejected")
+	}
+	for _, want := range []string{
+		`POST /api/v1/users/reports: unknown schema "ReportRequest"`,
+		"GET /api/v1/users/search: GET requests have no body",
+		"schema Profile: required property id is not declared",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q in:\n%v", want, err)
+		}
+	}
+}
@@ -1,61 +1,64 @@
 package services
 
+import (
+	"sync"
+	"time"
+
+	"gateway/src/routetable"
+)
+
 // ServiceConfig represents the configuration for an upstream service
 type ServiceConfig struct {
-	Name     string
-	URL      string
+	Name      string
+	URL       string
 	WebSocket bool
-	URL_WS   string
+	URL_WS    string
+	// Timeout of the requests proxied to the service, zero for HTTP_TIMEOUT
+	Timeout time.Duration
 }
 
-var services map[string]ServiceConfig
+var (
+	servicesMu sync.RWMutex
+	services   map[string]ServiceConfig
+)
 
-// InitServices initializes the service configuration map
+// InitServices initializes the service configuration map from the route
+// table embedded in the gateway
 func InitServices() {
-	services = map[string]ServiceConfig{
-		"auth": {
-			Name: "auth-service",
-			URL:  "http://auth-service:8001",
-		},
-		"user": {
-			Name: "user-service",
-			URL:  "http://user-service:8002",
-		},
-		"media": {
-			Name: "media-service",
-			URL:  "http://media-service:8006",
-		},
-		"match": {
-			Name: "match-service",
-			URL:  "http://match-service:8003",
-		},
-		"chat": {
-			Name: "chat-service",
-			URL:  "http://chat-service:8004",
-			WebSocket: true,
-			URL_WS: "ws://chat-service:8004",
-		},
-		"notify": {
-			Name: "notify-service",
-			URL:  "http://notify-service:8005",
-			WebSocket: true,
-			URL_WS: "ws://notify-service:8005",
-		},
-		"paiements": {
-			Name: "paiements-service",
-			URL:  "http://paiements-service:8085",
-		},
+	Configure(routetable.Default().Upstreams)
+}
+
+// Configure replaces the service configuration with the upstreams of a
+// route table
+func Configure(upstreams map[string]routetable.Upstream) {
+	configured := make(map[string]ServiceConfig, len(upstreams))
+	for key, upstream := range upstreams {
+		configured[key] = ServiceConfig{
+			Name:      upstream.Name,
+			URL:       upstream.URL,
+			WebSocket: upstream.WebSocketURL != "",
+			URL_WS:    upstream.WebSocketURL,
+			Timeout:   upstream.Timeout,
+		}
 	}
+
+	servicesMu.Lock()
+	services = configured
+	servicesMu.Unlock()
 }
 
 // GetService returns service configuration by name
 func GetService(name string) (ServiceConfig, bool) {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
 	service, exists := services[name]
 	return service, exists
 }
 
 // GetServicesStatus returns the status map of all configured services
 func GetServicesStatus() map[string]string {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
 	status := make(map[string]string)
 	for name, service := range services {
 		status[name] = service.URL
@@ -4,10 +4,11 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
+	"github.com/maxg56/matcha/api/common/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -31,6 +32,8 @@ func InitRedis() error {
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
 	})
+	// Rate limiting runs with the context of the request
+	tracing.InstrumentRedis(redisClient)
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -38,21 +41,20 @@ func InitRedis() error {
 
 	_, err := redisClient.Ping(ctx).Result()
 	if err != nil {
-		log.Printf("Failed to connect to Redis: %v", err)
 		return err
 	}
 
-	log.Println("Redis connected successfully for JWT blacklisting")
+	slog.Info("redis connected for JWT blacklisting", "addr", redisAddr)
 	return nil
 }
 
 // IsTokenBlacklisted checks if a JWT token is blacklisted
-func IsTokenBlacklisted(token string) bool {
+func IsTokenBlacklisted(ctx context.Context, token string) bool {
 	if redisClient == nil {
 		return false // If Redis is not available, allow the token
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	// Use SHA256 hash of token as key to avoid storing full tokens
@@ -62,7 +64,7 @@ func IsTokenBlacklisted(token string) bool {
 
 	exists, err := redisClient.Exists(ctx, "blacklist:"+tokenHash).Result()
 	if err != nil {
-		log.Printf("Error checking token blacklist: %v", err)
+		slog.WarnContext(ctx, "failed to check the token blacklist", "error", err)
 		return false // If Redis error, allow the token
 	}
 
@@ -70,12 +72,12 @@ func IsTokenBlacklisted(token string) bool {
 }
 
 // BlacklistToken adds a token to the blacklist with TTL matching token expiration
-func BlacklistToken(token string, ttl time.Duration) error {
+func BlacklistToken(ctx context.Context, token string, ttl time.Duration) error {
 	if redisClient == nil {
 		return nil // If Redis is not available, skip blacklisting
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	// Use SHA256 hash of token as key
@@ -85,9 +87,38 @@ func BlacklistToken(token string, ttl time.Duration) error {
 
 	err := redisClient.Set(ctx, "blacklist:"+tokenHash, "1", ttl).Err()
 	if err != nil {
-		log.Printf("Error blacklisting token: %v", err)
+		slog.WarnContext(ctx, "failed to blacklist token", "error", err)
 		return err
 	}
 
 	return nil
 }
+
+// IsUserTokensInvalidated checks whether all tokens issued to the user before
+// the revocation timestamp set by auth-service (user_invalidated:<id>) are revoked
+func IsUserTokensInvalidated(ctx context.Context, userID string, issuedAt int64) bool {
+	if redisClient == nil {
+		return false // If Redis is not available, allow the token
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	invalidatedAt, err := redisClient.Get(ctx, "user_invalidated:"+userID).Int64()
+	if err == redis.Nil {
+		return false
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check user token invalidation", "user_id", userID, "error", err)
+		return false // If Redis error, allow the token
+	}
+
+	// iat has a one second granularity: a token issued in the same second as
+	// the revocation may predate it, so it is revoked too
+	return issuedAt <= invalidatedAt
+}
+
+// GetRedisClient returns the shared Redis client, nil when Redis is unavailable
+func GetRedisClient() *redis.Client {
+	return redisClient
+}
@@ -1,8 +1,9 @@
 package websocket
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"sync"
@@ -10,6 +11,8 @@ import (
 
 	"gateway/src/services"
 	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 // ChatServiceClient manages the WebSocket connection to the chat service
@@ -24,6 +27,9 @@ type ChatServiceClient struct {
 	messageChan     chan ChatServiceMessage
 	responseHandlers map[string]chan ChatServiceResponse
 	handlerMutex    sync.RWMutex
+	// restartDelay is the delay asked by a restarting chat service before
+	// reconnecting, read from its close frame
+	restartDelay    time.Duration
 }
 
 // ChatServiceMessage represents a message sent to the chat service
@@ -35,6 +41,8 @@ type ChatServiceMessage struct {
 	Token          string                 `json:"token,omitempty"`
 	RequestID      string                 `json:"request_id,omitempty"`
 	Data           map[string]interface{} `json:"data,omitempty"`
+	// TraceParent continues the trace of the client message in chat-service
+	TraceParent    string                 `json:"traceparent,omitempty"`
 }
 
 // ChatServiceResponse represents a response from the chat service
@@ -79,17 +87,17 @@ func (c *ChatServiceClient) Start() {
 	for {
 		select {
 		case <-c.stopChan:
-			log.Println("🛑 Chat service client stopped")
+			slog.Info("chat service client stopped")
 			return
 		default:
 			if err := c.connect(); err != nil {
-				log.Printf("❌ Failed to connect to chat service: %v", err)
 				if c.reconnectCount >= c.maxReconnects {
-					log.Printf("🚫 Max reconnection attempts reached, stopping")
+					slog.Error("failed to connect to chat service, max reconnection attempts reached", "error", err)
 					return
 				}
 				c.reconnectCount++
-				log.Printf("🔄 Reconnecting in %v (attempt %d/%d)", c.reconnectDelay, c.reconnectCount, c.maxReconnects)
+				slog.Warn("failed to connect to chat service", "error", err,
+					"retry_in", c.reconnectDelay.String(), "attempt", c.reconnectCount, "max_attempts", c.maxReconnects)
 				time.Sleep(c.reconnectDelay)
 				continue
 			}
@@ -103,6 +111,19 @@ func (c *ChatServiceClient) Start() {
 
 			// Wait for disconnection
 			c.waitForDisconnection()
+
+			// A restarting chat service tells when to come back
+			c.mutex.Lock()
+			delay := c.restartDelay
+			c.restartDelay = 0
+			c.mutex.Unlock()
+			if delay > 0 {
+				slog.Info("chat service restarting", "reconnect_in", delay.String())
+				select {
+				case <-c.stopChan:
+				case <-time.After(delay):
+				}
+			}
 		}
 	}
 }
@@ -145,7 +166,7 @@ func (c *ChatServiceClient) connect() error {
 	c.connected = true
 	c.mutex.Unlock()
 
-	log.Printf("✅ Connected to chat service via WebSocket: %s", u.String())
+	slog.Info("connected to chat service", "url", u.String())
 	return nil
 }
 
@@ -169,8 +190,13 @@ func (c *ChatServiceClient) readMessages() {
 
 		var response ChatServiceResponse
 		if err := conn.ReadJSON(&response); err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("❌ Chat service WebSocket read error: %v", err)
+			if closeErr, ok := err.(*websocket.CloseError); ok && closeErr.Code == websocket.CloseServiceRestart {
+				delay, _ := server.ParseReconnectHint(closeErr.Text)
+				c.mutex.Lock()
+				c.restartDelay = delay
+				c.mutex.Unlock()
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				slog.Warn("chat service websocket read failed", "error", err)
 			}
 			break
 		}
@@ -200,16 +226,16 @@ func (c *ChatServiceClient) writeMessages() {
 			c.mutex.RUnlock()
 
 			if !connected {
-				log.Printf("⚠️ Cannot send message, not connected to chat service")
+				slog.Warn("cannot send message, not connected to chat service", "type", message.Type)
 				return
 			}
 
 			if err := conn.WriteJSON(message); err != nil {
-				log.Printf("❌ Failed to send message to chat service: %v", err)
+				slog.Warn("failed to send message to chat service", "type", message.Type, "error", err)
 				return
 			}
 
-			log.Printf("📤 Sent message to chat service: type=%s, user=%s", message.Type, message.UserID)
+			slog.Debug("message sent to chat service", "type", message.Type, "user_id", message.UserID)
 
 		case <-c.stopChan:
 			return
@@ -219,7 +245,7 @@ func (c *ChatServiceClient) writeMessages() {
 
 // handleResponse processes responses from the chat service
 func (c *ChatServiceClient) handleResponse(response ChatServiceResponse) {
-	log.Printf("📥 Received from chat service: type=%s, status=%s", response.Type, response.Status)
+	slog.Debug("message received from chat service", "type", response.Type, "status", response.Status, "user_id", response.UserID)
 
 	switch response.Type {
 	case "chat_message":
@@ -242,6 +268,9 @@ func (c *ChatServiceClient) handleResponse(response ChatServiceResponse) {
 		// Handle presence updates
 		c.handlePresenceUpdate(response)
 
+	case "message_edited", "message_deleted":
+		c.handleMessageChange(response)
+
 	case "response":
 		// Handle request responses
 		if response.RequestID != "" {
@@ -249,14 +278,14 @@ func (c *ChatServiceClient) handleResponse(response ChatServiceResponse) {
 		}
 
 	default:
-		log.Printf("⚠️ Unknown response type from chat service: %s", response.Type)
+		slog.Warn("unknown message type from chat service", "type", response.Type)
 	}
 }
 
 // broadcastChatMessage broadcasts a chat message to appropriate users
 func (c *ChatServiceClient) broadcastChatMessage(response ChatServiceResponse) {
 	if response.ConversationID == "" {
-		log.Printf("⚠️ Chat message missing conversation_id")
+		slog.Warn("chat message without conversation_id", "user_id", response.UserID)
 		return
 	}
 
@@ -280,14 +309,13 @@ func (c *ChatServiceClient) broadcastChatMessage(response ChatServiceResponse) {
 		}
 	}
 
-	GlobalManager.SendToChannel(channelName, "chat_message", messageData, response.UserID)
-	log.Printf("📢 Broadcasted chat message to channel: %s", channelName)
+	GlobalManager.SendEventToChannel(eventIDOf(response.Data), channelName, "chat_message", messageData, response.UserID)
 }
 
 // forwardAcknowledgment forwards acknowledgment to the original sender
 func (c *ChatServiceClient) forwardAcknowledgment(response ChatServiceResponse) {
 	if response.UserID == "" {
-		log.Printf("⚠️ Chat acknowledgment missing user_id")
+		slog.Warn("chat acknowledgment without user_id", "conversation_id", response.ConversationID)
 		return
 	}
 
@@ -304,19 +332,15 @@ func (c *ChatServiceClient) forwardAcknowledgment(response ChatServiceResponse)
 	}
 
 	GlobalManager.SendToUser(response.UserID, "chat_ack", ackData)
-	log.Printf("✅ Forwarded acknowledgment to user: %s", response.UserID)
 }
 
 // handleUserStatus handles user status updates
 func (c *ChatServiceClient) handleUserStatus(response ChatServiceResponse) {
 	// This can be extended to handle user online/offline status
-	log.Printf("👤 User status update: %s", response.UserID)
 }
 
 // handleReactionUpdate handles reaction updates from chat service
 func (c *ChatServiceClient) handleReactionUpdate(response ChatServiceResponse) {
-	log.Printf("👍 Reaction update: user=%s, data=%+v", response.UserID, response.Data)
-
 	// Broadcast reaction update to all users in the conversation
 	if response.Data != nil {
 		if conversationID, exists := response.Data["conversation_id"]; exists {
@@ -333,7 +357,6 @@ func (c *ChatServiceClient) handleReactionUpdate(response ChatServiceResponse) {
 				conversationIDStr = fmt.Sprintf("%v", v)
 			}
 			channelName := fmt.Sprintf("chat_%s", conversationIDStr)
-			log.Printf("🔔 Broadcasting reaction update to channel: %s", channelName)
 			reactionData := map[string]interface{}{
 				"type": "reaction_update",
 				"timestamp": time.Now().Unix(),
@@ -344,16 +367,37 @@ func (c *ChatServiceClient) handleReactionUpdate(response ChatServiceResponse) {
 				reactionData[k] = v
 			}
 
-			GlobalManager.SendToChannel(channelName, "reaction_update", reactionData, "")
-			log.Printf("📢 Broadcasted reaction update to channel: %s", channelName)
+			GlobalManager.SendEventToChannel(eventIDOf(response.Data), channelName, "reaction_update", reactionData, "")
 		}
 	}
 }
 
+// handleMessageChange broadcasts an edited or deleted message. A message
+// deleted for "me" only goes to the devices of the user who deleted it.
+func (c *ChatServiceClient) handleMessageChange(response ChatServiceResponse) {
+	if response.ConversationID == "" || response.Data == nil {
+		slog.Warn("message change without conversation_id or data", "type", response.Type, "user_id", response.UserID)
+		return
+	}
+
+	data := map[string]interface{}{
+		"type":            response.Type,
+		"conversation_id": response.ConversationID,
+		"timestamp":       time.Now().Unix(),
+	}
+	for k, v := range response.Data {
+		data[k] = v
+	}
+
+	if scope, _ := response.Data["scope"].(string); response.Type == "message_deleted" && scope != "everyone" {
+		GlobalManager.SendEventToUser(eventIDOf(response.Data), response.UserID, "", response.Type, data, "")
+		return
+	}
+	GlobalManager.SendEventToChannel(eventIDOf(response.Data), "chat_"+response.ConversationID, response.Type, data, response.UserID)
+}
+
 // handlePresenceUpdate handles user presence updates from chat service
 func (c *ChatServiceClient) handlePresenceUpdate(response ChatServiceResponse) {
-	log.Printf("🟢 Presence update: user=%s, data=%+v", response.UserID, response.Data)
-
 	// Broadcast presence update to relevant users
 	if response.Data != nil {
 		presenceData := map[string]interface{}{
@@ -369,7 +413,6 @@ func (c *ChatServiceClient) handlePresenceUpdate(response ChatServiceResponse) {
 
 		// Broadcast to all users (or specific channels based on business logic)
 		GlobalManager.SendToChannel("user-updates", "presence_update", presenceData, response.UserID)
-		log.Printf("📢 Broadcasted presence update for user: %s", response.UserID)
 	}
 }
 
@@ -383,7 +426,7 @@ func (c *ChatServiceClient) forwardResponse(response ChatServiceResponse) {
 		select {
 		case responseChan <- response:
 		case <-time.After(5 * time.Second):
-			log.Printf("⚠️ Timeout forwarding response for request: %s", response.RequestID)
+			slog.Warn("timed out forwarding chat service response", "request_id", response.RequestID)
 		}
 
 		// Clean up the handler
@@ -410,7 +453,7 @@ func (c *ChatServiceClient) waitForDisconnection() {
 }
 
 // SendMessage sends a message to the chat service
-func (c *ChatServiceClient) SendMessage(userID, conversationID, content, token string) error {
+func (c *ChatServiceClient) SendMessage(ctx context.Context, userID, conversationID, content string, attachmentIDs []uint, token string) error {
 	c.mutex.RLock()
 	connected := c.connected
 	c.mutex.RUnlock()
@@ -426,6 +469,10 @@ func (c *ChatServiceClient) SendMessage(userID, conversationID, content, token s
 		Content:        content,
 		Token:          token,
 		RequestID:      generateRequestID(),
+		TraceParent:    tracing.TraceParent(ctx),
+	}
+	if len(attachmentIDs) > 0 {
+		message.Data = map[string]interface{}{"attachment_ids": attachmentIDs}
 	}
 
 	select {
@@ -437,7 +484,7 @@ func (c *ChatServiceClient) SendMessage(userID, conversationID, content, token s
 }
 
 // SendReaction sends a reaction to the chat service
-func (c *ChatServiceClient) SendReaction(userID string, messageID uint, emoji, action, token string) error {
+func (c *ChatServiceClient) SendReaction(ctx context.Context, userID string, messageID uint, emoji, action, token string) error {
 	c.mutex.RLock()
 	connected := c.connected
 	c.mutex.RUnlock()
@@ -452,10 +499,11 @@ func (c *ChatServiceClient) SendReaction(userID string, messageID uint, emoji, a
 	}
 
 	message := ChatServiceMessage{
-		Type:      messageType,
-		UserID:    userID,
-		Token:     token,
-		RequestID: generateRequestID(),
+		Type:        messageType,
+		UserID:      userID,
+		Token:       token,
+		RequestID:   generateRequestID(),
+		TraceParent: tracing.TraceParent(ctx),
 		Data: map[string]interface{}{
 			"message_id": messageID,
 			"emoji":      emoji,
@@ -470,8 +518,67 @@ func (c *ChatServiceClient) SendReaction(userID string, messageID uint, emoji, a
 	}
 }
 
+// SendEdit sends a message edit to the chat service
+func (c *ChatServiceClient) SendEdit(ctx context.Context, userID string, messageID uint, content, token string) error {
+	return c.send(ChatServiceMessage{
+		Type:        "edit_message",
+		UserID:      userID,
+		Content:     content,
+		Token:       token,
+		RequestID:   generateRequestID(),
+		TraceParent: tracing.TraceParent(ctx),
+		Data: map[string]interface{}{
+			"message_id": messageID,
+		},
+	})
+}
+
+// SendDelete sends a message deletion to the chat service
+func (c *ChatServiceClient) SendDelete(ctx context.Context, userID string, messageID uint, scope, token string) error {
+	return c.send(ChatServiceMessage{
+		Type:        "delete_message",
+		UserID:      userID,
+		Token:       token,
+		RequestID:   generateRequestID(),
+		TraceParent: tracing.TraceParent(ctx),
+		Data: map[string]interface{}{
+			"message_id": messageID,
+			"scope":      scope,
+		},
+	})
+}
+
+// SendAck sends a delivery or read acknowledgment to the chat service
+func (c *ChatServiceClient) SendAck(ctx context.Context, userID, ackType, conversationID string, messageID uint, messageIDs []uint, token string) error {
+	return c.send(ChatServiceMessage{
+		Type:           ackType,
+		UserID:         userID,
+		ConversationID: conversationID,
+		Token:          token,
+		RequestID:      generateRequestID(),
+		TraceParent:    tracing.TraceParent(ctx),
+		Data: map[string]interface{}{
+			"message_id":  messageID,
+			"message_ids": messageIDs,
+		},
+	})
+}
+
+func (c *ChatServiceClient) send(message ChatServiceMessage) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to chat service")
+	}
+
+	select {
+	case c.messageChan <- message:
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("timeout sending %s", message.Type)
+	}
+}
+
 // SendTyping sends a typing indicator to the chat service
-func (c *ChatServiceClient) SendTyping(userID, conversationID, token string) error {
+func (c *ChatServiceClient) SendTyping(ctx context.Context, userID, conversationID, token string) error {
 	c.mutex.RLock()
 	connected := c.connected
 	c.mutex.RUnlock()
@@ -486,6 +593,7 @@ func (c *ChatServiceClient) SendTyping(userID, conversationID, token string) err
 		ConversationID: conversationID,
 		Token:          token,
 		RequestID:      generateRequestID(),
+		TraceParent:    tracing.TraceParent(ctx),
 	}
 
 	select {
@@ -514,6 +622,13 @@ func (c *ChatServiceClient) Stop() {
 	c.mutex.Unlock()
 }
 
+// eventIDOf returns the event ID the chat service attached to a broadcast, so
+// that the copy relayed by Redis is not delivered again
+func eventIDOf(data map[string]interface{}) string {
+	eventID, _ := data["event_id"].(string)
+	return eventID
+}
+
 // generateRequestID generates a unique request ID
 func generateRequestID() string {
 	return fmt.Sprintf("req_%d", time.Now().UnixNano())
@@ -1,15 +1,19 @@
 package websocket
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/server"
 )
 
 // Client represents a WebSocket client connection
 type Client struct {
 	ID            string             // User ID
+	ConnID        string             // Connection ID, one per device of the user
 	Conn          *websocket.Conn    // WebSocket connection
 	Send          chan []byte        // Buffered channel of outbound messages
 	Subscriptions map[string]bool    // Channels this client is subscribed to
@@ -23,6 +27,7 @@ type Client struct {
 func NewClient(userID string, conn *websocket.Conn) *Client {
 	return &Client{
 		ID:            userID,
+		ConnID:        newConnectionID(),
 		Conn:          conn,
 		Send:          make(chan []byte, 256),
 		Subscriptions: make(map[string]bool),
@@ -30,6 +35,15 @@ func NewClient(userID string, conn *websocket.Conn) *Client {
 	}
 }
 
+// newConnectionID returns a random identifier for a device connection
+func newConnectionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
 // IsSubscribed checks if the client is subscribed to a channel
 func (c *Client) IsSubscribed(channel string) bool {
 	c.mu.RLock()
@@ -102,6 +116,17 @@ func (c *Client) Close() {
 	}
 }
 
+// CloseForRestart sends a service restart close frame (1012) telling the
+// client when to reconnect, then closes the client. Its read loop then fails
+// and unregisters it.
+func (c *Client) CloseForRestart() {
+	if c.Conn != nil {
+		frame := websocket.FormatCloseMessage(websocket.CloseServiceRestart, server.ReconnectHint())
+		c.Conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+	}
+	c.Close()
+}
+
 // IsClosed returns whether the client is closed
 func (c *Client) IsClosed() bool {
 	c.mu.RLock()
@@ -0,0 +1,209 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis channels on which chat-service replicas publish real-time events:
+// user:<id> for the events of a user, chat:presence for presence updates
+const (
+	chatUserChannelPattern = "user:*"
+	chatPresenceChannel    = "chat:presence"
+)
+
+// ChatEvent is an event published on Redis by chat-service
+type ChatEvent struct {
+	ID             string         `json:"event_id"`
+	Origin         string         `json:"origin,omitempty"`
+	Type           string         `json:"type"`
+	ConversationID uint           `json:"conversation_id,omitempty"`
+	UserID         uint           `json:"user_id,omitempty"`
+	Message        *ChatEventMsg  `json:"message,omitempty"`
+	Timestamp      time.Time      `json:"timestamp"`
+	Data           map[string]any `json:"data,omitempty"`
+}
+
+// ChatEventMsg is the message carried by a new_message event
+type ChatEventMsg struct {
+	ID          uint             `json:"id"`
+	ConvID      uint             `json:"conv_id"`
+	SenderID    uint             `json:"sender_id"`
+	Msg         string           `json:"msg"`
+	Time        time.Time        `json:"time"`
+	ReadAt      *time.Time       `json:"read_at"`
+	Warning     string           `json:"warning,omitempty"` // flagged by screening: shown blurred
+	Attachments []map[string]any `json:"attachments,omitempty"`
+}
+
+// eventsSubscribed is true while the subscriber listens to Redis
+var eventsSubscribed atomic.Bool
+
+// IsEventSubscriberRunning reports whether chat events and notifications are relayed
+func IsEventSubscriberRunning() bool {
+	return eventsSubscribed.Load()
+}
+
+// RunEventSubscriber relays the chat events published by any chat-service
+// replica, and the notifications published by user-service, to the clients
+// connected to this gateway, until ctx is cancelled
+func RunEventSubscriber(ctx context.Context, client *redis.Client) {
+	if client == nil || GlobalManager == nil {
+		slog.WarnContext(ctx, "chat event subscriber disabled, redis or websocket manager unavailable")
+		return
+	}
+
+	sub := client.PSubscribe(ctx, chatUserChannelPattern, notificationChannelPattern)
+	defer sub.Close()
+	if err := sub.Subscribe(ctx, chatPresenceChannel); err != nil {
+		LogError(ctx, "chat_events", "subscribe_failed", err, "channel:", chatPresenceChannel)
+	}
+
+	eventsSubscribed.Store(true)
+	defer eventsSubscribed.Store(false)
+	slog.InfoContext(ctx, "listening to chat events and notifications",
+		"channels", []string{chatUserChannelPattern, chatPresenceChannel, notificationChannelPattern})
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "chat event subscriber stopped")
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			if strings.HasPrefix(msg.Channel, "notifications:") {
+				relayNotification(msg.Channel, msg.Payload)
+				continue
+			}
+			relayChatEvent(msg.Channel, msg.Payload)
+		}
+	}
+}
+
+// relayChatEvent translates an event to the gateway client protocol
+func relayChatEvent(channel, payload string) {
+	userID, ok := parseChatEventChannel(channel)
+	if !ok {
+		return
+	}
+
+	var event ChatEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		LogError(context.Background(), "chat_events", "unmarshal_error", err, "channel:", channel)
+		return
+	}
+	if event.ID == "" {
+		return
+	}
+
+	conversationID := strconv.FormatUint(uint64(event.ConversationID), 10)
+	chatChannel := "chat_" + conversationID
+	user := strconv.FormatUint(uint64(userID), 10)
+	actor := ""
+	if event.UserID != 0 {
+		actor = strconv.FormatUint(uint64(event.UserID), 10)
+	}
+
+	switch event.Type {
+	case "new_message":
+		if event.Message == nil {
+			return
+		}
+		sender := strconv.FormatUint(uint64(event.Message.SenderID), 10)
+		data := map[string]any{
+			"conversation_id": conversationID,
+			"message":         event.Message.Msg,
+			"from_user":       sender,
+			"timestamp":       time.Now().Unix(),
+			"type":            "chat_message",
+			"message_id":      event.Message.ID,
+			"sender_id":       event.Message.SenderID,
+			"read_at":         event.Message.ReadAt,
+			"attachments":     event.Message.Attachments,
+			"warning":         event.Message.Warning,
+			"event_id":        event.ID,
+		}
+		GlobalManager.SendEventToUser(event.ID, user, chatChannel, "chat_message", data, sender)
+
+	case "typing", "reaction_update":
+		data := copyEventData(event)
+		data["type"] = event.Type
+		data["conversation_id"] = conversationID
+		data["timestamp"] = time.Now().Unix()
+		GlobalManager.SendEventToUser(event.ID, user, chatChannel, event.Type, data, actor)
+
+	case "message_edited", "message_deleted":
+		data := copyEventData(event)
+		data["type"] = event.Type
+		data["conversation_id"] = conversationID
+		data["timestamp"] = time.Now().Unix()
+		if scope, _ := event.Data["scope"].(string); event.Type == "message_deleted" && scope != "everyone" {
+			GlobalManager.SendEventToUser(event.ID, user, "", event.Type, data, "")
+			return
+		}
+		GlobalManager.SendEventToUser(event.ID, user, chatChannel, event.Type, data, actor)
+
+	case "message_delivered", "message_read":
+		// Receipts update the conversation list too, so they reach the
+		// sender whether or not the conversation is open
+		data := copyEventData(event)
+		data["type"] = event.Type
+		data["conversation_id"] = conversationID
+		data["timestamp"] = time.Now().Unix()
+		GlobalManager.SendEventToUser(event.ID, user, "", event.Type, data, "")
+
+	case "conversation_settings":
+		// Mute, pin and archive changes sync to the other devices of the user
+		data := copyEventData(event)
+		data["type"] = event.Type
+		data["conversation_id"] = conversationID
+		GlobalManager.SendEventToUser(event.ID, user, "", event.Type, data, "")
+
+	case "conversation_updated":
+		data := copyEventData(event)
+		data["conversation_id"] = conversationID
+		GlobalManager.SendEventToUser(event.ID, user, "", event.Type, data, "")
+
+	case "presence_update":
+		data := copyEventData(event)
+		data["type"] = "presence_update"
+		data["user_id"] = actor
+		data["timestamp"] = time.Now().Unix()
+		GlobalManager.SendEventToChannel(event.ID, string(ChannelTypeUserUpdates), "presence_update", data, actor)
+	}
+}
+
+func copyEventData(event ChatEvent) map[string]any {
+	data := make(map[string]any, len(event.Data)+4)
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	return data
+}
+
+// parseChatEventChannel returns the user of a user:<id> channel, or 0 for
+// the presence channel
+func parseChatEventChannel(channel string) (uint, bool) {
+	if channel == chatPresenceChannel {
+		return 0, true
+	}
+	raw, found := strings.CutPrefix(channel, "user:")
+	if !found {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil || userID == 0 {
+		return 0, false
+	}
+	return uint(userID), true
+}
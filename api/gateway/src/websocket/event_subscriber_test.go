@@ -0,0 +1,182 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// drainBroadcasts runs the queued broadcasts like Manager.Run would
+func drainBroadcasts(m *Manager) {
+	for {
+		select {
+		case message := <-m.broadcast:
+			m.broadcastMessage(message)
+		default:
+			return
+		}
+	}
+}
+
+func TestRelayChatEventDedupe(t *testing.T) {
+	previous := GlobalManager
+	GlobalManager = NewManager()
+	defer func() { GlobalManager = previous }()
+
+	client := NewClient("200", nil)
+	GlobalManager.addClientUnsafe(client)
+	GlobalManager.SubscribeToChannel(client, "chat_3")
+
+	// Broadcast by this gateway from the chat service response
+	GlobalManager.SendEventToChannel("message:9", "chat_3", "chat_message", map[string]any{"message": "hi"}, "100")
+	drainBroadcasts(GlobalManager)
+
+	// Same message relayed by Redis
+	payload := `{"event_id":"message:9","type":"new_message","conversation_id":3,"user_id":100,
+		"message":{"id":9,"conv_id":3,"sender_id":100,"msg":"hi"}}`
+	relayChatEvent("user:200", payload)
+	drainBroadcasts(GlobalManager)
+
+	if len(client.Send) != 1 {
+		t.Fatalf("Expected the message once, got %d", len(client.Send))
+	}
+
+	// An event from another gateway is relayed
+	relayChatEvent("user:200", `{"event_id":"e2","type":"typing","conversation_id":3,"user_id":100,"data":{"is_typing":true}}`)
+	drainBroadcasts(GlobalManager)
+	<-client.Send
+	var typing BroadcastMessage
+	if err := json.Unmarshal(<-client.Send, &typing); err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	if typing.Type != "typing" || typing.Channel != "chat_3" || typing.FromUser != "100" {
+		t.Fatalf("Unexpected typing message: %+v", typing)
+	}
+
+	// Chat events are only sent to users subscribed to the conversation
+	relayChatEvent("user:200", `{"event_id":"e3","type":"typing","conversation_id":4,"user_id":100}`)
+	drainBroadcasts(GlobalManager)
+	if len(client.Send) != 0 {
+		t.Fatalf("Expected no message for an unsubscribed conversation, got %d", len(client.Send))
+	}
+}
+
+func TestRelayMessageDeletedForMe(t *testing.T) {
+	previous := GlobalManager
+	GlobalManager = NewManager()
+	defer func() { GlobalManager = previous }()
+
+	// Not subscribed to chat_3: a deletion for "me" still reaches the user
+	client := NewClient("100", nil)
+	GlobalManager.addClientUnsafe(client)
+
+	relayChatEvent("user:100", `{"event_id":"e1","type":"message_deleted","conversation_id":3,"user_id":100,
+		"data":{"message_id":9,"user_id":100,"scope":"me"}}`)
+	drainBroadcasts(GlobalManager)
+
+	if len(client.Send) != 1 {
+		t.Fatalf("Expected the deletion to reach the user, got %d messages", len(client.Send))
+	}
+	var deleted BroadcastMessage
+	if err := json.Unmarshal(<-client.Send, &deleted); err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	if deleted.Type != "message_deleted" || deleted.Channel != "" {
+		t.Fatalf("Unexpected deletion message: %+v", deleted)
+	}
+
+	// Unsent messages follow the conversation subscription
+	relayChatEvent("user:100", `{"event_id":"e2","type":"message_deleted","conversation_id":3,"user_id":200,
+		"data":{"message_id":9,"user_id":200,"scope":"everyone"}}`)
+	drainBroadcasts(GlobalManager)
+	if len(client.Send) != 0 {
+		t.Fatalf("Expected no message for an unsubscribed conversation, got %d", len(client.Send))
+	}
+}
+
+func TestParseChatEventChannel(t *testing.T) {
+	if userID, ok := parseChatEventChannel("user:42"); !ok || userID != 42 {
+		t.Errorf("Expected user 42, got %d, %v", userID, ok)
+	}
+	if userID, ok := parseChatEventChannel(chatPresenceChannel); !ok || userID != 0 {
+		t.Errorf("Expected presence channel, got %d, %v", userID, ok)
+	}
+	if _, ok := parseChatEventChannel("user:online:42"); ok {
+		t.Error("Expected user:online:42 to be ignored")
+	}
+}
+
+func TestRelayReceiptReachesSender(t *testing.T) {
+	previous := GlobalManager
+	GlobalManager = NewManager()
+	defer func() { GlobalManager = previous }()
+
+	// Not subscribed to chat_3: the sender sees the receipt in the conversation list
+	client := NewClient("100", nil)
+	GlobalManager.addClientUnsafe(client)
+
+	relayChatEvent("user:100", `{"event_id":"e1","type":"message_read","conversation_id":3,"user_id":200,
+		"data":{"conversation_id":3,"user_id":200,"status":"read","message_ids":[8,9]}}`)
+	drainBroadcasts(GlobalManager)
+
+	if len(client.Send) != 1 {
+		t.Fatalf("Expected the receipt to reach the sender, got %d messages", len(client.Send))
+	}
+	var receipt BroadcastMessage
+	if err := json.Unmarshal(<-client.Send, &receipt); err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	if receipt.Type != "message_read" || receipt.Channel != "" {
+		t.Fatalf("Unexpected receipt message: %+v", receipt)
+	}
+}
+
+func TestRelayConversationSettingsReachUser(t *testing.T) {
+	previous := GlobalManager
+	GlobalManager = NewManager()
+	defer func() { GlobalManager = previous }()
+
+	client := NewClient("100", nil)
+	GlobalManager.addClientUnsafe(client)
+
+	relayChatEvent("user:100", `{"event_id":"e2","type":"conversation_settings","conversation_id":3,"user_id":100,
+		"data":{"conversation_id":3,"muted_until":null,"pinned":true,"archived":false}}`)
+	drainBroadcasts(GlobalManager)
+
+	if len(client.Send) != 1 {
+		t.Fatalf("Expected the settings to reach the user, got %d messages", len(client.Send))
+	}
+	var settings BroadcastMessage
+	if err := json.Unmarshal(<-client.Send, &settings); err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	if settings.Type != "conversation_settings" || settings.Channel != "" {
+		t.Fatalf("Unexpected settings message: %+v", settings)
+	}
+}
+
+func TestRelayNotificationReachesUser(t *testing.T) {
+	previous := GlobalManager
+	GlobalManager = NewManager()
+	defer func() { GlobalManager = previous }()
+
+	client := NewClient("100", nil)
+	GlobalManager.addClientUnsafe(client)
+
+	payload := `{"event_id":"notification:5","to_user_id":100,"notification":{"id":5,"kind":"match","message":"C'est un match !"}}`
+	relayNotification("notifications:100", payload)
+	relayNotification("notifications:100", payload)
+	relayNotification("notifications:abc", payload)
+	drainBroadcasts(GlobalManager)
+
+	if len(client.Send) != 1 {
+		t.Fatalf("Expected the notification once, got %d messages", len(client.Send))
+	}
+	var notification BroadcastMessage
+	if err := json.Unmarshal(<-client.Send, &notification); err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	data, _ := notification.Data.(map[string]any)
+	if notification.Type != "notification_received" || data["kind"] != "match" {
+		t.Fatalf("Unexpected notification message: %+v", notification)
+	}
+}
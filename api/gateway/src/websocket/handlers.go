@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -9,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Message represents a WebSocket message with routing information
@@ -24,6 +27,8 @@ type Message struct {
 	ConversationID string `json:"conversation_id,omitempty"`
 	Content        string `json:"content,omitempty"`
 	IsTyping       bool   `json:"is_typing,omitempty"`
+	Scope          string `json:"scope,omitempty"` // delete_message: "me" or "everyone"
+	MessageIDs     []uint `json:"message_ids,omitempty"` // ack_delivered
 }
 
 var upgrader = websocket.Upgrader{
@@ -34,11 +39,12 @@ var upgrader = websocket.Upgrader{
 func UnifiedWebSocketHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
+		ctx := c.Request.Context()
 		
 		// Upgrade HTTP connection to WebSocket
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
-			LogError("unknown", "upgrade_failed", err, "remote_addr:", c.RemoteIP())
+			LogError(ctx, "unknown", "upgrade_failed", err, "remote_addr:", c.RemoteIP())
 			return
 		}
 		defer conn.Close()
@@ -46,7 +52,7 @@ func UnifiedWebSocketHandler() gin.HandlerFunc {
 		// Get user ID from context (set by JWT middleware)
 		userIDInterface, exists := c.Get(middleware.CtxUserIDKey)
 		if !exists {
-			LogError("unknown", "missing_user_id", fmt.Errorf("no user ID in WebSocket context"))
+			LogError(ctx, "unknown", "missing_user_id", fmt.Errorf("no user ID in WebSocket context"))
 			return
 		}
 		userID := userIDInterface.(string)
@@ -54,7 +60,7 @@ func UnifiedWebSocketHandler() gin.HandlerFunc {
 		// Get JWT token for potential forwarding
 		token := utils.ExtractToken(c)
 
-		LogConnection(userID, "established", "remote_addr:", c.RemoteIP())
+		LogConnection(ctx, userID, "established", "remote_addr:", c.RemoteIP())
 
 		// Create client and register with manager
 		client := NewClient(userID, conn)
@@ -62,11 +68,11 @@ func UnifiedWebSocketHandler() gin.HandlerFunc {
 		GlobalManager.RegisterClient(client)
 		defer func() {
 			GlobalManager.UnregisterClient(client)
-			LogConnection(userID, "closed", "duration:", time.Since(startTime))
+			LogConnection(ctx, userID, "closed", "duration:", time.Since(startTime))
 		}()
 
 		// Start goroutine to handle outgoing messages
-		go handleClientWrites(client)
+		go handleClientWrites(ctx, client)
 
 		// Handle incoming WebSocket messages
 		for {
@@ -74,45 +80,55 @@ func UnifiedWebSocketHandler() gin.HandlerFunc {
 			err := conn.ReadJSON(&msg)
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					LogError(userID, "read_error", err)
+					LogError(ctx, userID, "read_error", err)
 				}
 				break
 			}
 
-			LogMessage(userID, msg.Type, "data_size:", len(fmt.Sprintf("%v", msg.Data)))
+			// Each message gets its own trace, carried to chat-service
+			ctx, span := tracing.StartMessage(ctx, "", msg.Type)
+			span.SetAttributes(attribute.String("enduser.id", userID))
+			LogMessage(ctx, userID, msg.Type, "data_size:", len(fmt.Sprintf("%v", msg.Data)))
 
 			// Route message based on type
 			switch MessageType(msg.Type) {
 			case MessageTypeChat:
-				HandleChatMessage(msg, userID, token)
+				HandleChatMessage(ctx, msg, userID, token)
 			case MessageTypeSendMessage:
-				HandleChatMessage(msg, userID, token)
+				HandleChatMessage(ctx, msg, userID, token)
 			case MessageTypeNotification:
-				HandleNotificationMessage(msg, userID, token)
+				HandleNotificationMessage(ctx, msg, userID, token)
 			case MessageTypeSubscribe:
-				HandleSubscription(msg, userID)
+				HandleSubscription(ctx, msg, client)
 			case MessageTypeUnsubscribe:
-				HandleUnsubscription(msg, userID)
+				HandleUnsubscription(ctx, msg, client)
 			case MessageTypePing:
-				HandlePing(msg, userID)
+				HandlePing(ctx, msg, client)
 			case MessageTypeReactionAdd:
-				HandleReactionMessage(msg, userID, token, "add")
+				HandleReactionMessage(ctx, msg, userID, token, "add")
 			case MessageTypeReactionRemove:
-				HandleReactionMessage(msg, userID, token, "remove")
+				HandleReactionMessage(ctx, msg, userID, token, "remove")
 			case MessageTypeJoinConversation:
-				HandleJoinConversation(msg, userID, token)
+				HandleJoinConversation(ctx, msg, userID, token)
 			case MessageTypeTyping:
-				HandleTypingMessage(msg, userID, token)
+				HandleTypingMessage(ctx, msg, userID, token)
+			case MessageTypeEditMessage:
+				HandleEditMessage(ctx, msg, userID, token)
+			case MessageTypeDeleteMessage:
+				HandleDeleteMessage(ctx, msg, userID, token)
+			case MessageTypeAckDelivered, MessageTypeAckRead:
+				HandleAck(ctx, msg, userID, token)
 			default:
-				LogError(userID, "unknown_message_type", fmt.Errorf("unknown message type: %s", msg.Type))
-				SendErrorToUser(userID, "unknown_message_type", fmt.Sprintf("Unknown message type: %s", msg.Type))
+				LogError(ctx, userID, "unknown_message_type", fmt.Errorf("unknown message type: %s", msg.Type))
+				SendErrorToUser(ctx, userID, "unknown_message_type", fmt.Sprintf("Unknown message type: %s", msg.Type))
 			}
+			span.End()
 		}
 	}
 }
 
 // handleClientWrites handles outgoing messages for a client
-func handleClientWrites(client *Client) {
+func handleClientWrites(ctx context.Context, client *Client) {
 	defer client.Conn.Close()
 	
 	ticker := time.NewTicker(54 * time.Second) // Ping every 54 seconds
@@ -122,13 +138,13 @@ func handleClientWrites(client *Client) {
 		select {
 		case message, ok := <-client.Send:
 			if !ok {
-				LogConnection(client.ID, "send_channel_closed")
+				LogConnection(ctx, client.ID, "send_channel_closed")
 				client.Conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 			
 			if err := client.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				LogError(client.ID, "write_error", err, "message_size:", len(message))
+				LogError(ctx, client.ID, "write_error", err, "message_size:", len(message))
 				return
 			}
 			
@@ -136,7 +152,7 @@ func handleClientWrites(client *Client) {
 			// Send ping to keep connection alive and update last ping
 			client.UpdateLastPing()
 			if err := client.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				LogError(client.ID, "ping_error", err)
+				LogError(ctx, client.ID, "ping_error", err)
 				return
 			}
 		}
@@ -144,82 +160,82 @@ func handleClientWrites(client *Client) {
 }
 
 // HandleChatMessage routes chat messages to chat service via WebSocket
-func HandleChatMessage(msg Message, userID, token string) {
+func HandleChatMessage(ctx context.Context, msg Message, userID, token string) {
 	startTime := time.Now()
 	defer func() {
-		LogPerformance("chat_message", time.Since(startTime), "user:", userID)
+		LogPerformance(ctx, "chat_message", time.Since(startTime), "user:", userID)
 	}()
 
 	// Parse chat message data
 	chatData, err := parseChatMessageData(msg.Data)
 	if err != nil {
-		LogError(userID, "chat_parse_error", err)
-		SendErrorToUser(userID, "invalid_chat_data", err.Error())
+		LogError(ctx, userID, "chat_parse_error", err)
+		SendErrorToUser(ctx, userID, "invalid_chat_data", err.Error())
 		return
 	}
 
 	// Validate required fields
 	if chatData.ConversationID == "" {
-		LogError(userID, "chat_validation_error", fmt.Errorf("missing conversation_id"))
-		SendErrorToUser(userID, "missing_conversation_id", "Missing conversation_id in chat message")
+		LogError(ctx, userID, "chat_validation_error", fmt.Errorf("missing conversation_id"))
+		SendErrorToUser(ctx, userID, "missing_conversation_id", "Missing conversation_id in chat message")
 		return
 	}
 
-	if chatData.Message == "" {
-		LogError(userID, "chat_validation_error", fmt.Errorf("empty message"))
-		SendErrorToUser(userID, "empty_message", "Message cannot be empty")
+	if chatData.Message == "" && len(chatData.AttachmentIDs) == 0 {
+		LogError(ctx, userID, "chat_validation_error", fmt.Errorf("empty message"))
+		SendErrorToUser(ctx, userID, "empty_message", "Message cannot be empty")
 		return
 	}
 
-	LogMessage(userID, "chat_processing", "conversation:", chatData.ConversationID, "message_length:", len(chatData.Message))
+	LogMessage(ctx, userID, "chat_processing", "conversation:", chatData.ConversationID, "message_length:", len(chatData.Message))
 
 	// Validate user access to conversation
-	if !validateUserInConversation(userID, chatData.ConversationID, token) {
-		LogError(userID, "chat_access_denied", fmt.Errorf("access denied to conversation %s", chatData.ConversationID))
-		SendErrorToUser(userID, "access_denied", "Access denied to conversation")
+	if !validateUserInConversation(ctx, userID, chatData.ConversationID, token) {
+		LogError(ctx, userID, "chat_access_denied", fmt.Errorf("access denied to conversation %s", chatData.ConversationID))
+		SendErrorToUser(ctx, userID, "access_denied", "Access denied to conversation")
 		return
 	}
 
 	// Check if chat service client is connected
 	if GlobalChatClient == nil || !GlobalChatClient.IsConnected() {
-		LogError(userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
-		SendErrorToUser(userID, "service_unavailable", "Chat service is currently unavailable")
+		LogError(ctx, userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
+		SendErrorToUser(ctx, userID, "service_unavailable", "Chat service is currently unavailable")
 		return
 	}
 
 	// Send message through WebSocket to Chat Service
-	err = GlobalChatClient.SendMessage(userID, chatData.ConversationID, chatData.Message, token)
+	err = GlobalChatClient.SendMessage(ctx, userID, chatData.ConversationID, chatData.Message, chatData.AttachmentIDs, token)
 	if err != nil {
-		LogError(userID, "chat_relay_failed", err, "conversation:", chatData.ConversationID)
-		SendErrorToUser(userID, "message_failed", "Failed to send message")
+		LogError(ctx, userID, "chat_relay_failed", err, "conversation:", chatData.ConversationID)
+		SendErrorToUser(ctx, userID, "message_failed", "Failed to send message")
 		return
 	}
 
-	LogMessage(userID, "chat_relayed", "conversation:", chatData.ConversationID)
+	LogMessage(ctx, userID, "chat_relayed", "conversation:", chatData.ConversationID)
 }
 
 // HandleNotificationMessage handles notification-related messages
-func HandleNotificationMessage(msg Message, userID, token string) {
-	LogMessage(userID, "notification_request", "action:", msg.Data)
+func HandleNotificationMessage(ctx context.Context, msg Message, userID, token string) {
+	LogMessage(ctx, userID, "notification_request", "action:", msg.Data)
 	
 	// Parse notification action data
 	notifData, err := parseNotificationActionData(msg.Data)
 	if err != nil {
-		SendErrorToUser(userID, "invalid_notification_data", err.Error())
+		SendErrorToUser(ctx, userID, "invalid_notification_data", err.Error())
 		return
 	}
 	
 	switch notifData.Action {
 	case "mark_read":
 		if notifData.NotificationID == "" {
-			SendErrorToUser(userID, "missing_notification_id", "Missing notification_id for mark_read action")
+			SendErrorToUser(ctx, userID, "missing_notification_id", "Missing notification_id for mark_read action")
 			return
 		}
 		
 		// Call notification service to mark as read
-		if err := markNotificationAsRead(userID, notifData.NotificationID, token); err != nil {
-			LogError(userID, "notification_mark_read_failed", err, "notification_id:", notifData.NotificationID)
-			SendErrorToUser(userID, "notification_service_error", fmt.Sprintf("Failed to mark notification as read: %s", err.Error()))
+		if err := markNotificationAsRead(ctx, userID, notifData.NotificationID, token); err != nil {
+			LogError(ctx, userID, "notification_mark_read_failed", err, "notification_id:", notifData.NotificationID)
+			SendErrorToUser(ctx, userID, "notification_service_error", fmt.Sprintf("Failed to mark notification as read: %s", err.Error()))
 			return
 		}
 		
@@ -231,9 +247,9 @@ func HandleNotificationMessage(msg Message, userID, token string) {
 		
 	case "mark_all_read":
 		// Call notification service to mark all as read
-		if err := markAllNotificationsAsRead(userID, token); err != nil {
-			LogError(userID, "notification_mark_all_read_failed", err)
-			SendErrorToUser(userID, "notification_service_error", fmt.Sprintf("Failed to mark all notifications as read: %s", err.Error()))
+		if err := markAllNotificationsAsRead(ctx, userID, token); err != nil {
+			LogError(ctx, userID, "notification_mark_all_read_failed", err)
+			SendErrorToUser(ctx, userID, "notification_service_error", fmt.Sprintf("Failed to mark all notifications as read: %s", err.Error()))
 			return
 		}
 		
@@ -243,15 +259,16 @@ func HandleNotificationMessage(msg Message, userID, token string) {
 		})
 		
 	default:
-		SendErrorToUser(userID, "unknown_notification_action", 
+		SendErrorToUser(ctx, userID, "unknown_notification_action", 
 			fmt.Sprintf("Unknown notification action: %s", notifData.Action))
 	}
 }
 
-// HandleSubscription manages user subscriptions to different channels
-func HandleSubscription(msg Message, userID string) {
+// HandleSubscription subscribes the connection to a channel
+func HandleSubscription(ctx context.Context, msg Message, client *Client) {
+	userID := client.ID
 	// Log the actual data received for debugging
-	LogMessage(userID, "subscription_debug", "data_type:", fmt.Sprintf("%T", msg.Data), "data_value:", msg.Data)
+	LogMessage(ctx, userID, "subscription_debug", "data_type:", fmt.Sprintf("%T", msg.Data), "data_value:", msg.Data)
 
 	// Handle different data formats
 	var channel string
@@ -271,32 +288,33 @@ func HandleSubscription(msg Message, userID string) {
 	}
 
 	if !ok || channel == "" {
-		LogError(userID, "subscription_data_error", fmt.Errorf("data_type: %T, data_value: %v", msg.Data, msg.Data))
-		SendErrorToUser(userID, "invalid_subscription_data", "Channel name must be a string")
+		LogError(ctx, userID, "subscription_data_error", fmt.Errorf("data_type: %T, data_value: %v", msg.Data, msg.Data))
+		SendErrorToUser(ctx, userID, "invalid_subscription_data", "Channel name must be a string")
 		return
 	}
 
 	// Validate channel name format
 	if !isValidChannelName(channel) {
-		SendErrorToUser(userID, "invalid_channel_name", "Invalid channel name format")
+		SendErrorToUser(ctx, userID, "invalid_channel_name", "Invalid channel name format")
 		return
 	}
 
-	LogSubscription(userID, channel, "subscribe")
-	GlobalManager.SubscribeToChannel(userID, channel)
+	LogSubscription(ctx, userID, channel, "subscribe")
+	GlobalManager.SubscribeToChannel(client, channel)
 
 	// Send confirmation
-	GlobalManager.SendToUser(userID, string(MessageTypeSubscriptionAck), map[string]any{
+	GlobalManager.SendToClient(client, string(MessageTypeSubscriptionAck), map[string]any{
 		"channel": channel,
 		"status": "subscribed",
 		"timestamp": time.Now().Unix(),
 	})
 }
 
-// HandleUnsubscription manages user unsubscriptions from channels
-func HandleUnsubscription(msg Message, userID string) {
+// HandleUnsubscription unsubscribes the connection from a channel
+func HandleUnsubscription(ctx context.Context, msg Message, client *Client) {
+	userID := client.ID
 	// Log the actual data received for debugging
-	LogMessage(userID, "unsubscription_debug", "data_type:", fmt.Sprintf("%T", msg.Data), "data_value:", msg.Data)
+	LogMessage(ctx, userID, "unsubscription_debug", "data_type:", fmt.Sprintf("%T", msg.Data), "data_value:", msg.Data)
 
 	// Handle different data formats
 	var channel string
@@ -316,16 +334,16 @@ func HandleUnsubscription(msg Message, userID string) {
 	}
 
 	if !ok || channel == "" {
-		LogError(userID, "unsubscription_data_error", fmt.Errorf("data_type: %T, data_value: %v", msg.Data, msg.Data))
-		SendErrorToUser(userID, "invalid_unsubscription_data", "Channel name must be a string")
+		LogError(ctx, userID, "unsubscription_data_error", fmt.Errorf("data_type: %T, data_value: %v", msg.Data, msg.Data))
+		SendErrorToUser(ctx, userID, "invalid_unsubscription_data", "Channel name must be a string")
 		return
 	}
 
-	LogSubscription(userID, channel, "unsubscribe")
-	GlobalManager.UnsubscribeFromChannel(userID, channel)
+	LogSubscription(ctx, userID, channel, "unsubscribe")
+	GlobalManager.UnsubscribeFromChannel(client, channel)
 
 	// Send confirmation
-	GlobalManager.SendToUser(userID, "unsubscription_ack", map[string]any{
+	GlobalManager.SendToClient(client, "unsubscription_ack", map[string]any{
 		"channel": channel,
 		"status": "unsubscribed",
 		"timestamp": time.Now().Unix(),
@@ -333,15 +351,15 @@ func HandleUnsubscription(msg Message, userID string) {
 }
 
 // HandlePing responds to ping messages to keep connection alive
-func HandlePing(msg Message, userID string) {
-	GlobalManager.SendToUser(userID, "pong", map[string]any{
+func HandlePing(ctx context.Context, msg Message, client *Client) {
+	GlobalManager.SendToClient(client, "pong", map[string]any{
 		"timestamp": time.Now(),
 	})
 }
 
 // SendErrorToUser sends an error message to a specific user
-func SendErrorToUser(userID, errorType, errorMessage string) {
-	LogError(userID, "user_error_sent", fmt.Errorf("error_type: %s, message: %s", errorType, errorMessage))
+func SendErrorToUser(ctx context.Context, userID, errorType, errorMessage string) {
+	LogError(ctx, userID, "user_error_sent", fmt.Errorf("error_type: %s, message: %s", errorType, errorMessage))
 	
 	errorData := ErrorData{
 		ErrorType: errorType,
@@ -396,6 +414,20 @@ func parseChatMessageData(data any) (*ChatMessageData, error) {
 			return nil, fmt.Errorf("message must be a string")
 		}
 	}
+
+	if ids, exists := dataMap["attachment_ids"]; exists {
+		list, ok := ids.([]any)
+		if !ok {
+			return nil, fmt.Errorf("attachment_ids must be an array")
+		}
+		for _, id := range list {
+			value, ok := id.(float64)
+			if !ok || value < 1 {
+				return nil, fmt.Errorf("attachment_ids must be positive integers")
+			}
+			chatData.AttachmentIDs = append(chatData.AttachmentIDs, uint(value))
+		}
+	}
 	
 	return chatData, nil
 }
@@ -431,56 +463,56 @@ func parseNotificationActionData(data any) (*NotificationActionData, error) {
 }
 
 // HandleReactionMessage handles reaction add/remove messages
-func HandleReactionMessage(msg Message, userID, token, action string) {
+func HandleReactionMessage(ctx context.Context, msg Message, userID, token, action string) {
 	startTime := time.Now()
 	defer func() {
-		LogPerformance("reaction_"+action, time.Since(startTime), "user:", userID)
+		LogPerformance(ctx, "reaction_"+action, time.Since(startTime), "user:", userID)
 	}()
 
 	// Validate required fields - now using direct fields from Message structure
 	if msg.MessageID == 0 {
-		LogError(userID, "reaction_validation_error", fmt.Errorf("missing message_id"))
-		SendErrorToUser(userID, "missing_message_id", "Missing message_id in reaction")
+		LogError(ctx, userID, "reaction_validation_error", fmt.Errorf("missing message_id"))
+		SendErrorToUser(ctx, userID, "missing_message_id", "Missing message_id in reaction")
 		return
 	}
 
 	if msg.Emoji == "" {
-		LogError(userID, "reaction_validation_error", fmt.Errorf("missing emoji"))
-		SendErrorToUser(userID, "missing_emoji", "Missing emoji in reaction")
+		LogError(ctx, userID, "reaction_validation_error", fmt.Errorf("missing emoji"))
+		SendErrorToUser(ctx, userID, "missing_emoji", "Missing emoji in reaction")
 		return
 	}
 
 	// Validate emoji (basic validation)
 	if len(msg.Emoji) > 10 {
-		LogError(userID, "reaction_validation_error", fmt.Errorf("invalid emoji length"))
-		SendErrorToUser(userID, "invalid_emoji", "Invalid emoji")
+		LogError(ctx, userID, "reaction_validation_error", fmt.Errorf("invalid emoji length"))
+		SendErrorToUser(ctx, userID, "invalid_emoji", "Invalid emoji")
 		return
 	}
 
-	LogMessage(userID, "reaction_processing", "action:", action, "message_id:", msg.MessageID, "emoji:", msg.Emoji)
+	LogMessage(ctx, userID, "reaction_processing", "action:", action, "message_id:", msg.MessageID, "emoji:", msg.Emoji)
 
 	// Check if chat service client is connected
 	if GlobalChatClient == nil || !GlobalChatClient.IsConnected() {
-		LogError(userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
-		SendErrorToUser(userID, "service_unavailable", "Chat service is currently unavailable")
+		LogError(ctx, userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
+		SendErrorToUser(ctx, userID, "service_unavailable", "Chat service is currently unavailable")
 		return
 	}
 
 	// Send reaction through WebSocket to Chat Service
-	err := GlobalChatClient.SendReaction(userID, msg.MessageID, msg.Emoji, action, token)
+	err := GlobalChatClient.SendReaction(ctx, userID, msg.MessageID, msg.Emoji, action, token)
 	if err != nil {
-		LogError(userID, "reaction_relay_failed", err, "message_id:", msg.MessageID)
-		SendErrorToUser(userID, "reaction_failed", "Failed to process reaction")
+		LogError(ctx, userID, "reaction_relay_failed", err, "message_id:", msg.MessageID)
+		SendErrorToUser(ctx, userID, "reaction_failed", "Failed to process reaction")
 		return
 	}
 
-	LogMessage(userID, "reaction_relayed", "action:", action, "message_id:", msg.MessageID)
+	LogMessage(ctx, userID, "reaction_relayed", "action:", action, "message_id:", msg.MessageID)
 }
 
 
 // HandleJoinConversation handles joining a conversation
-func HandleJoinConversation(msg Message, userID, token string) {
-	LogMessage(userID, "join_conversation", "data:", msg.Data)
+func HandleJoinConversation(ctx context.Context, msg Message, userID, token string) {
+	LogMessage(ctx, userID, "join_conversation", "data:", msg.Data)
 	// This could be used for presence or room management
 	// For now, just acknowledge
 	GlobalManager.SendToUser(userID, string(MessageTypeConnectionAck), map[string]any{
@@ -490,27 +522,27 @@ func HandleJoinConversation(msg Message, userID, token string) {
 }
 
 // HandleTypingMessage handles typing indicators
-func HandleTypingMessage(msg Message, userID, token string) {
-	LogMessage(userID, "typing_indicator", "data:", msg.Data)
+func HandleTypingMessage(ctx context.Context, msg Message, userID, token string) {
+	LogMessage(ctx, userID, "typing_indicator", "data:", msg.Data)
 
 	// Parse typing data
 	dataMap, ok := msg.Data.(map[string]any)
 	if !ok {
-		SendErrorToUser(userID, "invalid_typing_data", "Invalid typing data format")
+		SendErrorToUser(ctx, userID, "invalid_typing_data", "Invalid typing data format")
 		return
 	}
 
 	conversationID, exists := dataMap["conversation_id"]
 	if !exists {
-		SendErrorToUser(userID, "missing_conversation_id", "Missing conversation_id in typing message")
+		SendErrorToUser(ctx, userID, "missing_conversation_id", "Missing conversation_id in typing message")
 		return
 	}
 
 	// Forward typing indicator to chat service
 	if GlobalChatClient != nil && GlobalChatClient.IsConnected() {
-		err := GlobalChatClient.SendTyping(userID, conversationID.(string), token)
+		err := GlobalChatClient.SendTyping(ctx, userID, conversationID.(string), token)
 		if err != nil {
-			LogError(userID, "typing_relay_failed", err)
+			LogError(ctx, userID, "typing_relay_failed", err)
 		}
 	}
 }
\ No newline at end of file
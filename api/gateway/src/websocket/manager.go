@@ -3,21 +3,21 @@ package websocket
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
-	"fmt"
-	"net/http"
-	"io/ioutil"
+
+	"github.com/maxg56/matcha/api/common/dedupe"
 )
 
 // Manager maintains the set of active clients and broadcasts messages to them
 type Manager struct {
-	clients    map[string]*Client                // Connected clients by user ID
+	clients    map[string]map[string]*Client     // Connected clients by user ID, then connection ID
 	broadcast  chan BroadcastMessage             // Inbound messages from clients
 	register   chan *Client                      // Register requests from clients
 	unregister chan *Client                      // Unregister requests from clients
-	channels   map[string]map[string]*Client     // Channel subscriptions: channel -> userID -> client
+	channels   map[string]map[string]*Client     // Channel subscriptions: channel -> connection ID -> client
+	delivered  *dedupe.Cache                     // Chat events already sent, by event and user
 	mu         sync.RWMutex                      // Protect maps
 	ctx        context.Context                   // Context for graceful shutdown
 	cancel     context.CancelFunc                // Cancel function
@@ -28,8 +28,10 @@ type BroadcastMessage struct {
 	Type     string `json:"type"`
 	Data     any    `json:"data"`
 	Channel  string `json:"channel,omitempty"`  // Target channel
-	UserID   string `json:"user_id,omitempty"`  // Target specific user
+	UserID   string `json:"user_id,omitempty"`  // Target specific user, on every connection
+	ConnID   string `json:"-"`                   // Target a single connection of UserID
 	FromUser string `json:"from_user,omitempty"` // Sender user ID
+	EventID  string `json:"-"`                   // Chat event ID, each user gets an event once
 }
 
 // Global manager instance
@@ -44,11 +46,12 @@ func (m *Manager) GetBroadcastChannel() chan<- BroadcastMessage {
 func NewManager() *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Manager{
-		clients:    make(map[string]*Client),
+		clients:    make(map[string]map[string]*Client),
 		broadcast:  make(chan BroadcastMessage, 256),
 		register:   make(chan *Client, 10),
 		unregister: make(chan *Client, 10),
 		channels:   make(map[string]map[string]*Client),
+		delivered:  dedupe.New(2 * time.Minute),
 		ctx:        ctx,
 		cancel:     cancel,
 	}
@@ -58,11 +61,6 @@ func NewManager() *Manager {
 func InitManager() {
 	GlobalManager = NewManager()
 	go GlobalManager.Run()
-	
-	// Initialize notification client to receive notifications from notify-service
-	InitNotificationClient()
-	
-	log.Println("WebSocket Manager initialized and running")
 }
 
 // Run starts the manager's main loop
@@ -86,7 +84,6 @@ func (m *Manager) Run() {
 			m.cleanupStaleConnections()
 			
 		case <-m.ctx.Done():
-			log.Println("WebSocket Manager shutting down...")
 			m.shutdown()
 			return
 		}
@@ -101,10 +98,12 @@ func (m *Manager) cleanupStaleConnections() {
 	staleThreshold := 2 * time.Minute
 	now := time.Now()
 	
-	for userID, client := range m.clients {
-		if now.Sub(client.GetLastPing()) > staleThreshold {
-			log.Printf("Removing stale connection for user %s", userID)
-			m.unregisterClientUnsafe(client)
+	for userID, conns := range m.clients {
+		for _, client := range conns {
+			if now.Sub(client.GetLastPing()) > staleThreshold {
+				slog.Info("removing stale websocket connection", "user_id", userID, "conn_id", client.ConnID)
+				m.unregisterClientUnsafe(client)
+			}
 		}
 	}
 }
@@ -115,81 +114,106 @@ func (m *Manager) shutdown() {
 	defer m.mu.Unlock()
 	
 	// Close all client connections
-	for _, client := range m.clients {
-		client.Close()
+	for _, conns := range m.clients {
+		for _, client := range conns {
+			client.Close()
+		}
 	}
 	
 	// Clear maps
-	m.clients = make(map[string]*Client)
+	m.clients = make(map[string]map[string]*Client)
 	m.channels = make(map[string]map[string]*Client)
 	
-	log.Println("WebSocket Manager shutdown complete")
+	slog.Info("websocket manager stopped")
 }
 
 // Shutdown stops the manager gracefully
 func (m *Manager) Shutdown() {
-	// Stop notification client first
-	StopNotificationClient()
 	m.cancel()
 }
 
+// Drain closes every client with a service restart close frame telling it
+// when to reconnect, waits for the clients to be unregistered or for ctx to
+// be done, then stops the manager
+func (m *Manager) Drain(ctx context.Context) error {
+	defer m.Shutdown()
+
+	m.mu.RLock()
+	var clients []*Client
+	for _, conns := range m.clients {
+		for _, client := range conns {
+			clients = append(clients, client)
+		}
+	}
+	m.mu.RUnlock()
+
+	slog.InfoContext(ctx, "draining websocket clients", "clients", len(clients))
+	for _, client := range clients {
+		client.CloseForRestart()
+	}
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for m.GetConnectionCount() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
 // RegisterClient adds a new client to the manager (exported method)
 func (m *Manager) RegisterClient(client *Client) {
-	m.register <- client
+	select {
+	case m.register <- client:
+	case <-m.ctx.Done():
+		client.Close()
+	}
 }
 
 // UnregisterClient removes a client from the manager (exported method)
 func (m *Manager) UnregisterClient(client *Client) {
-	m.unregister <- client
+	select {
+	case m.unregister <- client:
+	case <-m.ctx.Done():
+	}
 }
 
-// registerClient adds a new client to the manager
+// registerClient adds a new client to the manager. Each device of a user
+// keeps its own connection.
 func (m *Manager) registerClient(client *Client) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	
-	// Close existing connection if user reconnects
-	if existingClient, exists := m.clients[client.ID]; exists {
-		close(existingClient.Send)
-		existingClient.Conn.Close()
-	}
-	
-	m.clients[client.ID] = client
-	log.Printf("Client %s registered. Total clients: %d", client.ID, len(m.clients))
-	
+
+	m.addClientUnsafe(client)
+	slog.Debug("websocket client registered", "user_id", client.ID, "conn_id", client.ConnID, "user_connections", len(m.clients[client.ID]))
+
 	// Send welcome message
 	welcome := BroadcastMessage{
 		Type: "connection_ack",
 		Data: map[string]any{
-			"status": "connected",
+			"status":  "connected",
 			"user_id": client.ID,
 		},
 	}
-	
+
 	select {
 	case client.Send <- m.messageToBytes(welcome):
 	default:
-		close(client.Send)
-		delete(m.clients, client.ID)
-	}
-	// Fetch existing notifications for the user from notify-service
-	url := fmt.Sprintf("http://notify-service:8005/api/v1/notifications/get?user_id=%s", client.ID)
-	
-	
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatalf("Erreur requête : %v", err)
+		m.unregisterClientUnsafe(client)
 	}
-	defer resp.Body.Close()
-	
-	
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatalf("Erreur lecture réponse : %v", err)
+}
+
+// addClientUnsafe adds a connection of the user without locking
+func (m *Manager) addClientUnsafe(client *Client) {
+	conns, exists := m.clients[client.ID]
+	if !exists {
+		conns = make(map[string]*Client)
+		m.clients[client.ID] = conns
 	}
-	
-	fmt.Println("Réponse FastAPI:", string(body))
-	// endpoint to fetch notifications
+	conns[client.ConnID] = client
 }
 
 // unregisterClient removes a client from the manager
@@ -199,108 +223,128 @@ func (m *Manager) unregisterClient(client *Client) {
 	m.unregisterClientUnsafe(client)
 }
 
-// unregisterClientUnsafe removes a client without locking (internal use)
+// unregisterClientUnsafe removes a client without locking (internal use).
+// The other connections of the user are left open.
 func (m *Manager) unregisterClientUnsafe(client *Client) {
-	if _, exists := m.clients[client.ID]; exists {
+	conns := m.clients[client.ID]
+	if conns[client.ConnID] != client {
+		return
+	}
+	delete(conns, client.ConnID)
+	if len(conns) == 0 {
 		delete(m.clients, client.ID)
-		client.Close()
-		
-		// Remove from all channels
-		for channelName, subscribers := range m.channels {
-			delete(subscribers, client.ID)
-			if len(subscribers) == 0 {
-				delete(m.channels, channelName)
-			}
+	}
+	client.Close()
+
+	// Remove from all channels
+	for channelName, subscribers := range m.channels {
+		delete(subscribers, client.ConnID)
+		if len(subscribers) == 0 {
+			delete(m.channels, channelName)
 		}
-		
-		log.Printf("Client %s unregistered. Total clients: %d", client.ID, len(m.clients))
 	}
+
+	slog.Debug("websocket client unregistered", "user_id", client.ID, "conn_id", client.ConnID, "user_connections", len(conns))
 }
 
-// broadcastMessage sends a message to the appropriate clients
+// broadcastMessage sends a message to the appropriate clients. Clients too
+// slow to take it are dropped; they reconnect and resync.
 func (m *Manager) broadcastMessage(message BroadcastMessage) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	messageBytes := m.messageToBytes(message)
-	
-	if message.UserID != "" {
-		// Send to specific user
-		if client, exists := m.clients[message.UserID]; exists {
-			select {
-			case client.Send <- messageBytes:
-			default:
-				close(client.Send)
-				delete(m.clients, message.UserID)
+
+	var targets []*Client
+	switch {
+	case message.UserID != "":
+		// Every connection of the user, only the subscribed ones when a channel is given
+		for _, client := range m.clients[message.UserID] {
+			if message.ConnID != "" && client.ConnID != message.ConnID {
+				continue
 			}
-		}
-	} else if message.Channel != "" {
-		// Send to all subscribers of a channel
-		if subscribers, exists := m.channels[message.Channel]; exists {
-			for userID, client := range subscribers {
-				select {
-				case client.Send <- messageBytes:
-				default:
-					close(client.Send)
-					delete(m.clients, userID)
-					delete(subscribers, userID)
-				}
+			if message.Channel != "" && !client.IsSubscribed(message.Channel) {
+				continue
 			}
+			targets = append(targets, client)
+		}
+	case message.Channel != "":
+		// All subscribers of a channel
+		for _, client := range m.channels[message.Channel] {
+			targets = append(targets, client)
 		}
-	} else {
-		// Broadcast to all connected clients
-		for userID, client := range m.clients {
-			select {
-			case client.Send <- messageBytes:
-			default:
-				close(client.Send)
-				delete(m.clients, userID)
+	default:
+		// All connected clients
+		for _, conns := range m.clients {
+			for _, client := range conns {
+				targets = append(targets, client)
 			}
 		}
 	}
+
+	for _, client := range targets {
+		if !m.firstDelivery(message, client) {
+			continue
+		}
+		select {
+		case client.Send <- messageBytes:
+		default:
+			m.unregisterClientUnsafe(client)
+		}
+	}
+}
+
+// firstDelivery reports whether the connection has not received the chat event yet
+func (m *Manager) firstDelivery(message BroadcastMessage, client *Client) bool {
+	return message.EventID == "" || m.delivered.MarkDelivered(message.EventID+"/"+client.ConnID)
 }
 
-// SubscribeToChannel subscribes a client to a channel
-func (m *Manager) SubscribeToChannel(userID, channel string) {
+// SubscribeToChannel subscribes a connection to a channel
+func (m *Manager) SubscribeToChannel(client *Client, channel string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	
-	client, exists := m.clients[userID]
-	if !exists {
-		log.Printf("Cannot subscribe user %s to channel %s: client not found", userID, channel)
+
+	if m.clients[client.ID][client.ConnID] != client {
+		slog.Warn("cannot subscribe to channel, client not found", "user_id", client.ID, "conn_id", client.ConnID, "channel", channel)
 		return
 	}
-	
+
 	// Initialize channel if it doesn't exist
 	if _, exists := m.channels[channel]; !exists {
 		m.channels[channel] = make(map[string]*Client)
 	}
-	
-	m.channels[channel][userID] = client
+
+	m.channels[channel][client.ConnID] = client
 	client.Subscribe(channel)
-	
-	log.Printf("User %s subscribed to channel %s (total subscribers: %d)", 
-		userID, channel, len(m.channels[channel]))
+
+	slog.Debug("subscribed to channel", "user_id", client.ID, "conn_id", client.ConnID, "channel", channel, "subscribers", len(m.channels[channel]))
 }
 
-// UnsubscribeFromChannel unsubscribes a client from a channel
-func (m *Manager) UnsubscribeFromChannel(userID, channel string) {
+// UnsubscribeFromChannel unsubscribes a connection from a channel
+func (m *Manager) UnsubscribeFromChannel(client *Client, channel string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	
+
 	if subscribers, exists := m.channels[channel]; exists {
-		delete(subscribers, userID)
+		delete(subscribers, client.ConnID)
 		if len(subscribers) == 0 {
 			delete(m.channels, channel)
-			log.Printf("Channel %s removed (no subscribers)", channel)
 		}
 	}
-	
-	if client, exists := m.clients[userID]; exists {
-		client.Unsubscribe(channel)
-	}
-	
-	log.Printf("User %s unsubscribed from channel %s", userID, channel)
+	client.Unsubscribe(channel)
+
+	slog.Debug("unsubscribed from channel", "user_id", client.ID, "conn_id", client.ConnID, "channel", channel)
+}
+
+// SendToClient sends a message to a single connection, e.g. the answer to
+// a request it made
+func (m *Manager) SendToClient(client *Client, messageType string, data any) {
+	m.queue(BroadcastMessage{
+		Type:   messageType,
+		Data:   data,
+		UserID: client.ID,
+		ConnID: client.ConnID,
+	})
 }
 
 // SendToUser sends a message to a specific user
@@ -314,7 +358,7 @@ func (m *Manager) SendToUser(userID string, messageType string, data any) {
 	select {
 	case m.broadcast <- message:
 	default:
-		log.Printf("Broadcast channel full, dropping message to user %s", userID)
+		slog.Warn("broadcast queue full, dropping message", "type", messageType, "user_id", userID)
 	}
 }
 
@@ -330,7 +374,40 @@ func (m *Manager) SendToChannel(channel string, messageType string, data any, fr
 	select {
 	case m.broadcast <- message:
 	default:
-		log.Printf("Broadcast channel full, dropping message to channel %s", channel)
+		slog.Warn("broadcast queue full, dropping message", "type", messageType, "channel", channel)
+	}
+}
+
+// SendEventToChannel sends a chat event to all subscribers of a channel.
+// Subscribers that already received the event are skipped.
+func (m *Manager) SendEventToChannel(eventID, channel, messageType string, data any, fromUser string) {
+	m.queue(BroadcastMessage{
+		Type:     messageType,
+		Data:     data,
+		Channel:  channel,
+		FromUser: fromUser,
+		EventID:  eventID,
+	})
+}
+
+// SendEventToUser sends a chat event to a user. With a channel, the event is
+// only sent if the user is subscribed to it.
+func (m *Manager) SendEventToUser(eventID, userID, channel, messageType string, data any, fromUser string) {
+	m.queue(BroadcastMessage{
+		Type:     messageType,
+		Data:     data,
+		Channel:  channel,
+		UserID:   userID,
+		FromUser: fromUser,
+		EventID:  eventID,
+	})
+}
+
+func (m *Manager) queue(message BroadcastMessage) {
+	select {
+	case m.broadcast <- message:
+	default:
+		slog.Warn("broadcast queue full, dropping event", "type", message.Type, "event_id", message.EventID)
 	}
 }
 
@@ -338,7 +415,7 @@ func (m *Manager) SendToChannel(channel string, messageType string, data any, fr
 func (m *Manager) messageToBytes(message BroadcastMessage) []byte {
 	data, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("Error marshaling message: %v", err)
+		slog.Error("failed to marshal websocket message", "type", message.Type, "error", err)
 		return nil
 	}
 	return data
@@ -361,26 +438,40 @@ func (m *Manager) IsUserOnline(userID string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	_, exists := m.clients[userID]
-	return exists
+	return len(m.clients[userID]) > 0
 }
 
-// GetConnectionCount returns the total number of active WebSocket connections
+// BroadcastQueueDepth returns the number of messages waiting for the main
+// loop; they are dropped once it reaches the capacity of the channel
+func (m *Manager) BroadcastQueueDepth() int {
+	return len(m.broadcast)
+}
+
+// GetConnectionCount returns the total number of active WebSocket connections,
+// all devices included
 func (m *Manager) GetConnectionCount() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	return len(m.clients)
+	count := 0
+	for _, conns := range m.clients {
+		count += len(conns)
+	}
+	return count
 }
 
-// GetUserConnectionInfo returns detailed connection info for a user
+// GetUserConnectionInfo returns detailed connection info for a user: whether
+// one of their devices is connected, and the latest ping of them
 func (m *Manager) GetUserConnectionInfo(userID string) (bool, time.Time) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if client, exists := m.clients[userID]; exists {
-		return true, client.GetLastPing()
+	var lastPing time.Time
+	for _, client := range m.clients[userID] {
+		if ping := client.GetLastPing(); ping.After(lastPing) {
+			lastPing = ping
+		}
 	}
-	return false, time.Time{}
+	return len(m.clients[userID]) > 0, lastPing
 }
 
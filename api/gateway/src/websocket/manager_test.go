@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/maxg56/matcha/api/common/server"
+)
+
+func TestManagerDrainClosesClientsForRestart(t *testing.T) {
+	m := NewManager()
+	go m.Run()
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		// Read loop of UnifiedWebSocketHandler
+		client := NewClient("42", conn)
+		m.RegisterClient(client)
+		defer m.UnregisterClient(client)
+		go handleClientWrites(r.Context(), client)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("Expected connection_ack, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := m.Drain(ctx); err != nil {
+		t.Fatalf("Expected the clients to be drained, got %v", err)
+	}
+	if count := m.GetConnectionCount(); count != 0 {
+		t.Fatalf("Expected no client left, got %d", count)
+	}
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseServiceRestart {
+		t.Fatalf("Expected a service restart close frame, got %v", err)
+	}
+	if _, ok := server.ParseReconnectHint(closeErr.Text); !ok {
+		t.Errorf("Expected a reconnect hint, got %q", closeErr.Text)
+	}
+
+	// The manager is stopped: registering no longer blocks
+	m.RegisterClient(NewClient("43", nil))
+}
+
+func TestManagerKeepsEveryDeviceOfAUser(t *testing.T) {
+	m := NewManager()
+	phone := NewClient("42", nil)
+	laptop := NewClient("42", nil)
+	m.registerClient(phone)
+	m.registerClient(laptop)
+	<-phone.Send
+	<-laptop.Send
+
+	if phone.IsClosed() || m.GetConnectionCount() != 2 {
+		t.Fatalf("Expected both devices connected, got %d connections", m.GetConnectionCount())
+	}
+
+	m.SubscribeToChannel(laptop, "chat_3")
+	m.broadcastMessage(BroadcastMessage{Type: "notification_received", UserID: "42", EventID: "n1"})
+	m.broadcastMessage(BroadcastMessage{Type: "chat_message", Channel: "chat_3", EventID: "m1"})
+	m.broadcastMessage(BroadcastMessage{Type: "pong", UserID: "42", ConnID: phone.ConnID})
+	if len(phone.Send) != 2 || len(laptop.Send) != 2 {
+		t.Fatalf("Expected 2 messages per device, got %d and %d", len(phone.Send), len(laptop.Send))
+	}
+
+	// A device leaving does not sign the other one out
+	m.unregisterClient(phone)
+	m.unregisterClient(phone)
+	if !m.IsUserOnline("42") || laptop.IsClosed() {
+		t.Fatal("Expected the laptop to stay connected")
+	}
+	m.unregisterClient(laptop)
+	if m.IsUserOnline("42") {
+		t.Fatal("Expected the user to be offline once every device left")
+	}
+}
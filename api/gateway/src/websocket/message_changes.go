@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+)
+
+// HandleEditMessage relays a message edit to the chat service
+func HandleEditMessage(ctx context.Context, msg Message, userID, token string) {
+	if msg.MessageID == 0 || msg.Content == "" {
+		SendErrorToUser(ctx, userID, "invalid_edit", "Missing message_id or content")
+		return
+	}
+	if len(msg.Content) > 1000 {
+		SendErrorToUser(ctx, userID, "message_too_long", "Message too long")
+		return
+	}
+
+	if GlobalChatClient == nil || !GlobalChatClient.IsConnected() {
+		LogError(ctx, userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
+		SendErrorToUser(ctx, userID, "service_unavailable", "Chat service is currently unavailable")
+		return
+	}
+
+	if err := GlobalChatClient.SendEdit(ctx, userID, msg.MessageID, msg.Content, token); err != nil {
+		LogError(ctx, userID, "edit_relay_failed", err, "message_id:", msg.MessageID)
+		SendErrorToUser(ctx, userID, "edit_failed", "Failed to edit message")
+		return
+	}
+
+	LogMessage(ctx, userID, "edit_relayed", "message_id:", msg.MessageID)
+}
+
+// HandleAck relays a delivery (ack_delivered, message_ids) or read (ack_read,
+// read up to message_id) acknowledgment to the chat service
+func HandleAck(ctx context.Context, msg Message, userID, token string) {
+	if msg.ConversationID == "" {
+		SendErrorToUser(ctx, userID, "invalid_ack", "Missing conversation_id")
+		return
+	}
+	if MessageType(msg.Type) == MessageTypeAckRead && msg.MessageID == 0 {
+		SendErrorToUser(ctx, userID, "invalid_ack", "Missing message_id")
+		return
+	}
+	if MessageType(msg.Type) == MessageTypeAckDelivered && len(msg.MessageIDs) == 0 {
+		SendErrorToUser(ctx, userID, "invalid_ack", "Missing message_ids")
+		return
+	}
+
+	if GlobalChatClient == nil || !GlobalChatClient.IsConnected() {
+		LogError(ctx, userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
+		SendErrorToUser(ctx, userID, "service_unavailable", "Chat service is currently unavailable")
+		return
+	}
+
+	if err := GlobalChatClient.SendAck(ctx, userID, msg.Type, msg.ConversationID, msg.MessageID, msg.MessageIDs, token); err != nil {
+		LogError(ctx, userID, "ack_relay_failed", err, "conversation_id:", msg.ConversationID)
+	}
+}
+
+// HandleDeleteMessage relays a message deletion to the chat service
+func HandleDeleteMessage(ctx context.Context, msg Message, userID, token string) {
+	if msg.MessageID == 0 {
+		SendErrorToUser(ctx, userID, "invalid_delete", "Missing message_id")
+		return
+	}
+	scope := msg.Scope
+	if scope == "" {
+		scope = "me"
+	}
+	if scope != "me" && scope != "everyone" {
+		SendErrorToUser(ctx, userID, "invalid_delete", "Scope must be me or everyone")
+		return
+	}
+
+	if GlobalChatClient == nil || !GlobalChatClient.IsConnected() {
+		LogError(ctx, userID, "chat_service_unavailable", fmt.Errorf("chat service WebSocket not available"))
+		SendErrorToUser(ctx, userID, "service_unavailable", "Chat service is currently unavailable")
+		return
+	}
+
+	if err := GlobalChatClient.SendDelete(ctx, userID, msg.MessageID, scope, token); err != nil {
+		LogError(ctx, userID, "delete_relay_failed", err, "message_id:", msg.MessageID)
+		SendErrorToUser(ctx, userID, "delete_failed", "Failed to delete message")
+		return
+	}
+
+	LogMessage(ctx, userID, "delete_relayed", "message_id:", msg.MessageID, "scope:", scope)
+}
@@ -0,0 +1,44 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// notificationChannelPattern matches the Redis channels on which user-service
+// publishes the notifications stored for each user (notifications:<id>)
+const notificationChannelPattern = "notifications:*"
+
+// NotificationEvent is a notification published on Redis by user-service
+type NotificationEvent struct {
+	ID           string         `json:"event_id"`
+	ToUserID     uint           `json:"to_user_id"`
+	Notification map[string]any `json:"notification"`
+}
+
+// relayNotification sends a new notification to the recipient, whatever
+// channels they subscribed to
+func relayNotification(channel, payload string) {
+	raw, found := strings.CutPrefix(channel, "notifications:")
+	if !found {
+		return
+	}
+	userID, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil || userID == 0 {
+		return
+	}
+
+	var event NotificationEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		LogError(context.Background(), "notifications", "unmarshal_error", err, "channel:", channel)
+		return
+	}
+	if event.ID == "" || event.Notification == nil {
+		return
+	}
+
+	user := strconv.FormatUint(userID, 10)
+	GlobalManager.SendEventToUser(event.ID, user, "", string(MessageTypeNotificationReceived), event.Notification, "")
+}
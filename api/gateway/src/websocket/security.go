@@ -43,7 +43,7 @@ func secureCheckOrigin(r *http.Request) bool {
 	origin := r.Header.Get("Origin")
 	
 	// Log the origin for security monitoring
-	LogConnection("system", "origin_check", "origin:", origin, "allowed:", isOriginAllowed(origin))
+	LogConnection(r.Context(), "system", "origin_check", "origin:", origin, "allowed:", isOriginAllowed(origin))
 	
 	return isOriginAllowed(origin)
 }
\ No newline at end of file
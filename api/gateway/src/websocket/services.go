@@ -12,6 +12,8 @@ import (
 
 	"gateway/src/config"
 	"gateway/src/services"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 // ServiceClient handles HTTP calls to backend services
@@ -27,29 +29,27 @@ func NewServiceClient() *ServiceClient {
 	}
 	
 	return &ServiceClient{
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client: tracing.NewClient(timeout),
 	}
 }
 
 var serviceClient = NewServiceClient()
 
 // validateUserInConversation checks if user has access to the conversation via chat service
-func validateUserInConversation(userID, conversationID, token string) bool {
+func validateUserInConversation(ctx context.Context, userID, conversationID, token string) bool {
 	// Get chat service configuration
 	chatService, exists := services.GetService("chat")
 	if !exists {
-		LogError(userID, "chat_service_unavailable", fmt.Errorf("chat service not configured"))
+		LogError(ctx, userID, "chat_service_unavailable", fmt.Errorf("chat service not configured"))
 		return false
 	}
 	
 	// Prepare the validation request using existing conversation endpoint
 	url := fmt.Sprintf("%s/api/v1/chat/conversations/%s", chatService.URL, conversationID)
 	
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		LogError(userID, "chat_validation_request_error", err)
+		LogError(ctx, userID, "chat_validation_request_error", err)
 		return false
 	}
 	
@@ -61,7 +61,7 @@ func validateUserInConversation(userID, conversationID, token string) bool {
 	// Make the request
 	resp, err := serviceClient.client.Do(req)
 	if err != nil {
-		LogError(userID, "chat_validation_network_error", err)
+		LogError(ctx, userID, "chat_validation_network_error", err)
 		return false
 	}
 	defer resp.Body.Close()
@@ -69,33 +69,33 @@ func validateUserInConversation(userID, conversationID, token string) bool {
 	// Check response status
 	switch resp.StatusCode {
 	case http.StatusOK:
-		LogMessage(userID, "chat_validation_success", "conversation:", conversationID)
+		LogMessage(ctx, userID, "chat_validation_success", "conversation:", conversationID)
 		return true
 	case http.StatusForbidden, http.StatusUnauthorized:
-		LogError(userID, "chat_validation_access_denied", 
+		LogError(ctx, userID, "chat_validation_access_denied", 
 			fmt.Errorf("access denied to conversation %s, status: %d", conversationID, resp.StatusCode))
 		return false
 	case http.StatusNotFound:
-		LogError(userID, "chat_validation_not_found", 
+		LogError(ctx, userID, "chat_validation_not_found", 
 			fmt.Errorf("conversation %s not found, status: %d", conversationID, resp.StatusCode))
 		return false
 	default:
-		LogError(userID, "chat_validation_error", 
+		LogError(ctx, userID, "chat_validation_error", 
 			fmt.Errorf("validation failed for conversation %s, status: %d", conversationID, resp.StatusCode))
 		return false
 	}
 }
 
-// markNotificationAsRead calls the notification service to mark a notification as read
-func markNotificationAsRead(userID, notificationID, token string) error {
-	// Get notification service configuration
-	notifyService, exists := services.GetService("notify")
+// markNotificationAsRead calls user-service to mark a notification as read
+func markNotificationAsRead(ctx context.Context, userID, notificationID, token string) error {
+	// Get user service configuration
+	userService, exists := services.GetService("user")
 	if !exists {
-		return fmt.Errorf("notification service not configured")
+		return fmt.Errorf("user service not configured")
 	}
 	
 	// Prepare the request
-	url := fmt.Sprintf("%s/api/v1/notifications/%s/read", notifyService.URL, notificationID)
+	url := fmt.Sprintf("%s/api/v1/notifications/%s/read", userService.URL, notificationID)
 	
 	req, err := http.NewRequest("PUT", url, nil)
 	if err != nil {
@@ -108,7 +108,7 @@ func markNotificationAsRead(userID, notificationID, token string) error {
 	req.Header.Set("Content-Type", "application/json")
 	
 	// Make the request
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	req = req.WithContext(ctx)
 	
@@ -121,7 +121,7 @@ func markNotificationAsRead(userID, notificationID, token string) error {
 	// Check response
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusNoContent:
-		LogMessage(userID, "notification_marked_read", "notification_id:", notificationID)
+		LogMessage(ctx, userID, "notification_marked_read", "notification_id:", notificationID)
 		return nil
 	case http.StatusNotFound:
 		return fmt.Errorf("notification %s not found", notificationID)
@@ -134,16 +134,16 @@ func markNotificationAsRead(userID, notificationID, token string) error {
 	}
 }
 
-// markAllNotificationsAsRead calls the notification service to mark all notifications as read
-func markAllNotificationsAsRead(userID, token string) error {
-	// Get notification service configuration
-	notifyService, exists := services.GetService("notify")
+// markAllNotificationsAsRead calls user-service to mark all notifications as read
+func markAllNotificationsAsRead(ctx context.Context, userID, token string) error {
+	// Get user service configuration
+	userService, exists := services.GetService("user")
 	if !exists {
-		return fmt.Errorf("notification service not configured")
+		return fmt.Errorf("user service not configured")
 	}
 	
 	// Prepare the request
-	url := fmt.Sprintf("%s/api/v1/notifications/read-all", notifyService.URL)
+	url := fmt.Sprintf("%s/api/v1/notifications/read-all", userService.URL)
 	
 	req, err := http.NewRequest("PUT", url, nil)
 	if err != nil {
@@ -156,7 +156,7 @@ func markAllNotificationsAsRead(userID, token string) error {
 	req.Header.Set("Content-Type", "application/json")
 	
 	// Make the request
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	req = req.WithContext(ctx)
 	
@@ -169,7 +169,7 @@ func markAllNotificationsAsRead(userID, token string) error {
 	// Check response
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusNoContent:
-		LogMessage(userID, "all_notifications_marked_read")
+		LogMessage(ctx, userID, "all_notifications_marked_read")
 		return nil
 	case http.StatusForbidden:
 		return fmt.Errorf("access denied")
@@ -181,7 +181,7 @@ func markAllNotificationsAsRead(userID, token string) error {
 }
 
 // sendMessageToChatService sends a message to the chat service for persistence
-func sendMessageToChatService(userID, conversationID, message, token string) error {
+func sendMessageToChatService(ctx context.Context, userID, conversationID, message, token string) error {
 	// Get chat service configuration
 	chatService, exists := services.GetService("chat")
 	if !exists {
@@ -219,7 +219,7 @@ func sendMessageToChatService(userID, conversationID, message, token string) err
 	req.Header.Set("Content-Type", "application/json")
 	
 	// Make the request
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	req = req.WithContext(ctx)
 	
@@ -231,7 +231,7 @@ func sendMessageToChatService(userID, conversationID, message, token string) err
 	
 	// Check response
 	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK {
-		LogMessage(userID, "message_persisted", "conversation:", conversationID)
+		LogMessage(ctx, userID, "message_persisted", "conversation:", conversationID)
 		return nil
 	} else {
 		// Try to read error message from response
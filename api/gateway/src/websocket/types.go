@@ -15,6 +15,10 @@ const (
 	MessageTypeTyping        MessageType = "typing"
 	MessageTypeReactionAdd   MessageType = "reaction_add"
 	MessageTypeReactionRemove MessageType = "reaction_remove"
+	MessageTypeEditMessage   MessageType = "edit_message"
+	MessageTypeDeleteMessage MessageType = "delete_message"
+	MessageTypeAckDelivered  MessageType = "ack_delivered"
+	MessageTypeAckRead       MessageType = "ack_read"
 
 	// Server to client message types
 	MessageTypeChatMessage    MessageType = "chat_message"
@@ -31,6 +35,11 @@ const (
 	MessageTypeDisconnected     MessageType = "disconnected"
 	MessageTypeReactionUpdate   MessageType = "reaction_update"
 	MessageTypePresenceUpdate   MessageType = "presence_update"
+	MessageTypeMessageEdited    MessageType = "message_edited"
+	MessageTypeMessageDeleted   MessageType = "message_deleted"
+	MessageTypeMessageDelivered MessageType = "message_delivered"
+	MessageTypeMessageRead      MessageType = "message_read"
+	MessageTypeConvSettings     MessageType = "conversation_settings"
 	MessageTypeError           MessageType = "error"
 )
 
@@ -50,6 +59,7 @@ type ChatMessageData struct {
 	FromUser       string `json:"from_user,omitempty"`
 	Timestamp      int64  `json:"timestamp,omitempty"`
 	Type           string `json:"type,omitempty"`
+	AttachmentIDs  []uint `json:"attachment_ids,omitempty"`
 }
 
 // NotificationActionData represents notification action data
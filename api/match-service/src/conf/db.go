@@ -2,13 +2,17 @@ package conf
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"github.com/maxg56/matcha/api/common/metrics"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+
 	"match-service/src/models"
 	"match-service/src/utils"
 )
@@ -33,14 +37,21 @@ func InitDB() {
 	})
 
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		slog.Error("failed to connect to the database", "error", err)
+		os.Exit(1)
 	}
 
-	log.Printf("Connected to database: %s@%s:%s/%s", dbUser, dbHost, dbPort, dbName)
+	slog.Info("database connected", "user", dbUser, "host", dbHost, "port", dbPort, "name", dbName)
+
+	if err := DB.Use(tracing.GORMPlugin{}); err != nil {
+		slog.Warn("failed to enable query tracing", "error", err)
+	}
+	if err := metrics.RegisterDB(DB, "match"); err != nil {
+		slog.Warn("failed to expose connection pool metrics", "error", err)
+	}
 
 	// Initialize caches
 	utils.InitializeCaches()
-	log.Println("In-memory caches initialized")
 
 	// Auto-migrate models if enabled
 	if getEnv("AUTO_MIGRATE", "false") == "true" {
@@ -53,23 +64,24 @@ func InitDB() {
 			&models.Match{},
 		)
 		if err != nil {
-			log.Fatalf("Failed to auto-migrate: %v", err)
+			slog.Error("AutoMigrate failed", "error", err)
+			os.Exit(1)
 		}
-		log.Println("Database auto-migration completed")
+		slog.Info("AutoMigrate completed")
 
 		// Create performance indexes if enabled
 		if getEnv("CREATE_INDEXES", "false") == "true" {
 			if err := utils.CreateOptimizationIndexes(DB); err != nil {
-				log.Printf("Warning: Failed to create optimization indexes: %v", err)
+				slog.Warn("failed to create optimization indexes", "error", err)
 			}
 			
 			if err := utils.CreateCompositeIndexesForVectorMatching(DB); err != nil {
-				log.Printf("Warning: Failed to create vector matching indexes: %v", err)
+				slog.Warn("failed to create vector matching indexes", "error", err)
 			}
 
 			// Analyze tables for better query planning
 			if err := utils.AnalyzeTablesForPerformance(DB); err != nil {
-				log.Printf("Warning: Failed to analyze tables: %v", err)
+				slog.Warn("failed to analyze tables", "error", err)
 			}
 		}
 	}
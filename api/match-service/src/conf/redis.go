@@ -1,7 +1,7 @@
 package conf
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 )
@@ -27,8 +27,7 @@ func InitRedisConfig() {
 		Enabled:  getEnvAsBool("USE_REDIS_CACHE", false),
 	}
 
-	log.Printf("Redis config - Host: %s, Port: %s, DB: %d, Enabled: %t", 
-		Redis.Host, Redis.Port, Redis.DB, Redis.Enabled)
+	slog.Info("redis configuration loaded", "host", Redis.Host, "port", Redis.Port, "db", Redis.DB, "enabled", Redis.Enabled)
 }
 
 // GetRedisAddr returns the Redis address in host:port format
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"match-service/src/conf"
+	"match-service/src/models"
+	"match-service/src/utils"
+)
+
+// DeleteUserDataHandler removes every match-service row involving a deleted account:
+// interactions and seen profiles in both directions, matches and matching preferences.
+// Idempotent, so the deletion saga of user-service can safely retry it.
+func DeleteUserDataHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	deleted := map[string]int64{}
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		steps := []struct {
+			name  string
+			model interface{}
+			where string
+		}{
+			{"interactions", &models.UserInteraction{}, "user_id = ? OR target_user_id = ?"},
+			{"matches", &models.Match{}, "user1_id = ? OR user2_id = ?"},
+			{"seen_profiles", &models.UserSeenProfile{}, "user_id = ? OR seen_user_id = ?"},
+		}
+		for _, step := range steps {
+			result := tx.Where(step.where, userID, userID).Delete(step.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted[step.name] = result.RowsAffected
+		}
+
+		result := tx.Where("user_id = ?", userID).Delete(&models.UserMatchingPreferences{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted["matching_preferences"] = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to delete user data")
+		return
+	}
+
+	utils.InvalidateUserCache(int(userID))
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"user_id": userID,
+		"deleted": deleted,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"match-service/src/conf"
+	"match-service/src/models"
+	"match-service/src/utils"
+)
+
+// exportedMatch is a match as seen from the exporting user
+type exportedMatch struct {
+	ID          uint      `json:"id"`
+	OtherUserID uint      `json:"other_user_id"`
+	IsActive    bool      `json:"is_active"`
+	MatchedAt   time.Time `json:"matched_at"`
+}
+
+// ExportUserDataHandler returns the match-service section of a GDPR export:
+// interactions made by the user, matches, seen profiles and matching preferences.
+// Internal route, called by user-service.
+func ExportUserDataHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var interactions []models.UserInteraction
+	if err := conf.DB.Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&interactions).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load interactions")
+		return
+	}
+
+	var rawMatches []models.Match
+	if err := conf.DB.Where("user1_id = ? OR user2_id = ?", userID, userID).
+		Order("matched_at").
+		Find(&rawMatches).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load matches")
+		return
+	}
+	matches := make([]exportedMatch, 0, len(rawMatches))
+	for _, m := range rawMatches {
+		other := m.User1ID
+		if other == uint(userID) {
+			other = m.User2ID
+		}
+		matches = append(matches, exportedMatch{
+			ID:          m.ID,
+			OtherUserID: other,
+			IsActive:    m.IsActive,
+			MatchedAt:   m.MatchedAt,
+		})
+	}
+
+	var seen []models.UserSeenProfile
+	if err := conf.DB.Where("user_id = ?", userID).Order("seen_at").Find(&seen).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load seen profiles")
+		return
+	}
+
+	var preferences []models.UserMatchingPreferences
+	if err := conf.DB.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "Failed to load matching preferences")
+		return
+	}
+
+	// Strip the JSON encoding of the embedded relations
+	exportedInteractions := make([]gin.H, 0, len(interactions))
+	for _, i := range interactions {
+		exportedInteractions = append(exportedInteractions, gin.H{
+			"id":               i.ID,
+			"target_user_id":   i.TargetUserID,
+			"interaction_type": i.InteractionType,
+			"created_at":       i.CreatedAt,
+		})
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"interactions":         exportedInteractions,
+		"matches":              matches,
+		"seen_profiles":        seen,
+		"matching_preferences": preferences,
+	})
+}
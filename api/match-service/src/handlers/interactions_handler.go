@@ -29,7 +29,7 @@ func LikeUserHandler(c *gin.Context) {
 
 	// Use match service for handling likes
 	matchService := services.NewMatchService()
-	result, err := matchService.LikeUser(userID, request.TargetUserID)
+	result, err := matchService.LikeUser(c.Request.Context(), userID, request.TargetUserID)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to like user: "+err.Error())
 		return
@@ -62,7 +62,7 @@ func UnlikeUserHandler(c *gin.Context) {
 
 	// Use match service for handling unlikes
 	matchService := services.NewMatchService()
-	result, err := matchService.UnlikeUser(userID, request.TargetUserID)
+	result, err := matchService.UnlikeUser(c.Request.Context(), userID, request.TargetUserID)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to unlike user: "+err.Error())
 		return
@@ -95,7 +95,7 @@ func BlockUserHandler(c *gin.Context) {
 
 	// Use match service for handling blocks
 	matchService := services.NewMatchService()
-	result, err := matchService.BlockUser(userID, request.TargetUserID)
+	result, err := matchService.BlockUser(c.Request.Context(), userID, request.TargetUserID)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to block user: "+err.Error())
 		return
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"match-service/src/services/interactions/matches"
+	"match-service/src/utils"
+)
+
+// CheckMatchHandler tells other services whether two users have an active match.
+// chat-service calls it before opening a conversation or accepting a message.
+func CheckMatchHandler(c *gin.Context) {
+	user1ID, err1 := strconv.Atoi(c.Query("user1_id"))
+	user2ID, err2 := strconv.Atoi(c.Query("user2_id"))
+	if err1 != nil || err2 != nil || user1ID <= 0 || user2ID <= 0 {
+		utils.RespondError(c, http.StatusBadRequest, "user1_id and user2_id are required")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"user1_id": user1ID,
+		"user2_id": user2ID,
+		"matched":  user1ID != user2ID && matches.IsMatched(user1ID, user2ID),
+	})
+}
@@ -92,7 +92,7 @@ func MatchingAlgorithmHandler(c *gin.Context) {
 
 	if fullProfiles {
 		// Return full profile data (legacy behavior)
-		matches, err := matchService.RunMatchingAlgorithm(userID, algorithmType, limit, maxDistance, ageRange)
+		matches, err := matchService.RunMatchingAlgorithm(c.Request.Context(), userID, algorithmType, limit, maxDistance, ageRange)
 		if err != nil {
 			utils.RespondError(c, http.StatusInternalServerError, "Failed to run matching algorithm: "+err.Error())
 			return
@@ -111,7 +111,7 @@ func MatchingAlgorithmHandler(c *gin.Context) {
 		})
 	} else {
 		// Return only candidate IDs with scores (new default behavior)
-		candidates, err := matchService.GetMatchingCandidates(userID, algorithmType, limit, maxDistance, ageRange)
+		candidates, err := matchService.GetMatchingCandidates(c.Request.Context(), userID, algorithmType, limit, maxDistance, ageRange)
 		if err != nil {
 			utils.RespondError(c, http.StatusInternalServerError, "Failed to run matching algorithm: "+err.Error())
 			return
@@ -153,7 +153,7 @@ func UnmatchHandler(c *gin.Context) {
 	
 	// Use the interaction manager to handle the unmatch
 	interactionManager := services.NewInteractionManager()
-	err := interactionManager.UnmatchUsers(userID, req.TargetUserID)
+	err := interactionManager.UnmatchUsers(c.Request.Context(), userID, req.TargetUserID)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to unmatch: "+err.Error())
 		return
@@ -199,7 +199,7 @@ func TestUnmatchHandler(c *gin.Context) {
 
 	// Use the interaction manager to handle the unmatch
 	interactionManager := services.NewInteractionManager()
-	err = interactionManager.UnmatchUsers(userID, req.TargetUserID)
+	err = interactionManager.UnmatchUsers(c.Request.Context(), userID, req.TargetUserID)
 	if err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, "Failed to unmatch: "+err.Error())
 		return
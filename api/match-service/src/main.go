@@ -1,10 +1,17 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
 
 	"match-service/src/conf"
 	"match-service/src/handlers"
@@ -13,11 +20,25 @@ import (
 )
 
 func main() {
+	logging.Init("match-service")
+
+	// Initialize tracing (OTEL_TRACES_EXPORTER)
+	shutdownTracing, err := tracing.Init("match-service")
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	lc := server.New("match-service")
+
 	// Initialize Redis configuration
 	conf.InitRedisConfig()
 	
 	// Initialize database
 	conf.InitDB()
+	lc.AddCheck("database", server.DBCheck(conf.DB))
+	lc.OnClose("database", server.CloseDB(conf.DB))
 
 	// Initialize cache system with centralized Redis config
 	utils.InitializeCachesWithConfig(
@@ -26,14 +47,20 @@ func main() {
 		conf.Redis.Password,
 		conf.Redis.DB,
 	)
-	log.Println("Cache system initialized with centralized config")
+	if utils.UsesRedis() {
+		lc.AddCheck("redis", utils.PingCaches)
+	}
+	lc.OnClose("caches", utils.CloseCaches)
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), logging.Middleware())
+	r.Use(tracing.Middleware("match-service"))
+	metrics.Register(r)
 
 	// Add performance monitoring middleware
 	r.Use(middleware.PerformanceMiddleware())
 
-	// Health check
+	// Health check, liveness and readiness probes
 	r.GET("/health", func(c *gin.Context) {
 		cacheStats := utils.GetCacheStats()
 		c.JSON(http.StatusOK, gin.H{
@@ -42,6 +69,7 @@ func main() {
 			"cache_stats": cacheStats,
 		})
 	})
+	lc.RegisterProbes(r)
 
 	// API routes
 	api := r.Group("/api/v1")
@@ -61,6 +89,15 @@ func main() {
 			matches.DELETE("/seen", handlers.ResetSeenProfilesHandler)
 		}
 
+		// Internal routes (called by other services)
+		internal := api.Group("/internal")
+		internal.Use(internalapi.Middleware())
+		{
+			internal.GET("/users/:user_id/export", handlers.ExportUserDataHandler)
+			internal.DELETE("/users/:user_id", handlers.DeleteUserDataHandler)
+			internal.GET("/matches/check", handlers.CheckMatchHandler)
+		}
+
 		// Test routes (no auth required - for debugging)
 		test := api.Group("/test")
 		{
@@ -68,6 +105,6 @@ func main() {
 		}
 	}
 
-	log.Println("Match service starting on port 8003")
-	log.Fatal(http.ListenAndServe(":8003", r))
+	slog.Info("match service starting", "port", 8003)
+	lc.Run(":8003", r)
 }
\ No newline at end of file
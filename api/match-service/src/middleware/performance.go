@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,11 +20,10 @@ func PerformanceMiddleware() gin.HandlerFunc {
 		
 		// Log slow requests (>1 second)
 		if duration > time.Second {
-			log.Printf("SLOW REQUEST: %s %s took %v (user: %v)", 
-				c.Request.Method, 
-				c.Request.URL.Path, 
-				duration,
-				c.GetInt("userID"))
+			slog.WarnContext(c.Request.Context(), "slow request",
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"duration_ms", duration.Milliseconds())
 		}
 		
 		// Add performance header
@@ -35,6 +34,6 @@ func PerformanceMiddleware() gin.HandlerFunc {
 // DatabaseQueryLogger logs slow database queries
 func LogSlowQuery(query string, duration time.Duration, args ...interface{}) {
 	if duration > 100*time.Millisecond {
-		log.Printf("SLOW QUERY: %v - Query: %s - Args: %v", duration, query, args)
+		slog.Warn("slow query", "duration_ms", duration.Milliseconds(), "query", query, "args", args)
 	}
 }
\ No newline at end of file
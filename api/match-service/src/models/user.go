@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -56,6 +58,11 @@ type User struct {
 	CreatedAt time.Time       `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time       `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
+	// Set while the account waits for deletion; such users are excluded from matching
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index" json:"-"`
+	// Set while the user paused their profile
+	PausedAt *time.Time `gorm:"column:paused_at" json:"-"`
+
 	// Relationships
 	Tags   []UserTag `gorm:"foreignKey:UserID" json:"tags,omitempty"`
 	Images []Image   `gorm:"foreignKey:UserID" json:"images,omitempty"`
@@ -64,3 +71,9 @@ type User struct {
 func (User) TableName() string {
 	return "users"
 }
+
+// Discoverable restricts a users query to profiles that can be suggested:
+// not paused and not waiting for deletion
+func Discoverable(db *gorm.DB) *gorm.DB {
+	return db.Where("users.paused_at IS NULL AND users.deleted_at IS NULL")
+}
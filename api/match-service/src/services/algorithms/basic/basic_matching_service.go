@@ -32,7 +32,7 @@ func (b *BasicMatchingService) GetMatches(userID int, limit int, maxDistance *in
 	}
 
 	// Build query for compatible users
-	query := conf.DB.Table("users").
+	query := conf.DB.Table("users").Scopes(models.Discoverable).
 		Where("id != ? AND latitude IS NOT NULL AND longitude IS NOT NULL", userID)
 
 	// Apply compatibility filters based on sexual preferences
@@ -113,7 +113,7 @@ func (b *BasicMatchingService) GetNearbyUsers(userID int, maxDistanceKm int, lim
 	latRange := float64(maxDistanceKm) / 111.0
 	lngRange := latRange / math.Cos(targetUser.Latitude.Float64*math.Pi/180)
 
-	query := conf.DB.Where("id != ? AND latitude IS NOT NULL AND longitude IS NOT NULL", userID).
+	query := conf.DB.Scopes(models.Discoverable).Where("id != ? AND latitude IS NOT NULL AND longitude IS NOT NULL", userID).
 		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
 			targetUser.Latitude.Float64-latRange, targetUser.Latitude.Float64+latRange,
 			targetUser.Longitude.Float64-lngRange, targetUser.Longitude.Float64+lngRange).
@@ -160,7 +160,7 @@ func (b *BasicMatchingService) GetRandomMatches(userID int, limit int) ([]types.
 	}
 
 	// Build basic compatibility query
-	query := conf.DB.Table("users").Where("id != ?", userID)
+	query := conf.DB.Table("users").Scopes(models.Discoverable).Where("id != ?", userID)
 
 	// Apply sexual preference filtering
 	if targetUser.SexPref == "both" {
@@ -204,7 +204,7 @@ func (b *BasicMatchingService) GetNewUsers(userID int, limit int, daysBack int)
 	}
 
 	// Build query for new users
-	query := conf.DB.Table("users").
+	query := conf.DB.Table("users").Scopes(models.Discoverable).
 		Where("id != ? AND created_at >= NOW() - INTERVAL ? DAY", userID, daysBack)
 
 	// Apply sexual preference filtering
@@ -249,7 +249,7 @@ func (b *BasicMatchingService) GetPopularUsers(userID int, limit int, minFame in
 	}
 
 	// Build query for popular users
-	query := conf.DB.Table("users").
+	query := conf.DB.Table("users").Scopes(models.Discoverable).
 		Where("id != ? AND fame >= ?", userID, minFame)
 
 	// Apply sexual preference filtering
@@ -1,7 +1,7 @@
 package compatibility
 
 import (
-	"log"
+	"log/slog"
 	"math"
 	"time"
 
@@ -43,7 +43,7 @@ func NewCompatibilityService() *CompatibilityService {
 func (c *CompatibilityService) CalculateCompatibilityScore(userID, targetUserID int, preferenceVector, candidateVector utils.UserVector, currentUser, candidate *models.User) utils.CompatibilityScore {
 	// Check cache first
 	if cached, exists := utils.GetCachedCompatibilityScore(userID, targetUserID); exists {
-		log.Printf("Cache hit for compatibility score between users %d and %d", userID, targetUserID)
+		slog.Debug("compatibility score cache hit", "user_id", userID, "target_user_id", targetUserID)
 		return cached
 	}
 
@@ -1,10 +1,31 @@
 package core
 
 import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
 	"match-service/src/services/types"
 	"match-service/src/services/cache"
 )
 
+// algorithmDuration is exposed on /metrics, by algorithm type and outcome
+var algorithmDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "match_algorithm_duration_seconds",
+	Help:    "Time taken by the matching algorithms, by algorithm type and outcome.",
+	Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+}, []string{"algorithm", "outcome"})
+
+// observeAlgorithm records a run of algorithm that took duration
+func observeAlgorithm(algorithm types.AlgorithmType, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	algorithmDuration.WithLabelValues(string(algorithm), outcome).Observe(duration.Seconds())
+}
+
 // AlgorithmMetrics handles metrics and metadata for matching algorithms
 type AlgorithmMetrics struct {
 	cacheService *cache.CacheService
@@ -1,15 +1,20 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	"match-service/src/services/types"
 	"match-service/src/services/algorithms/vector"
 	"match-service/src/services/algorithms/basic"
 )
 
+// errUnknownAlgorithm is returned for an algorithm type the router does not know
+var errUnknownAlgorithm = errors.New("unknown algorithm type")
+
 // AlgorithmRouter handles routing requests to appropriate matching algorithms
 type AlgorithmRouter struct {
 	vectorMatchingService *vector.VectorMatchingService
@@ -24,11 +29,20 @@ func NewAlgorithmRouter() *AlgorithmRouter {
 	}
 }
 
-// ExecuteAlgorithm routes to the appropriate matching algorithm
-func (r *AlgorithmRouter) ExecuteAlgorithm(request *types.MatchingRequest) ([]types.MatchResult, error) {
+// ExecuteAlgorithm routes to the appropriate matching algorithm and records its latency
+func (r *AlgorithmRouter) ExecuteAlgorithm(ctx context.Context, request *types.MatchingRequest) ([]types.MatchResult, error) {
+	start := time.Now()
+	results, err := r.executeAlgorithm(ctx, request)
+	if !errors.Is(err, errUnknownAlgorithm) {
+		observeAlgorithm(request.Algorithm, time.Since(start), err)
+	}
+	return results, err
+}
+
+func (r *AlgorithmRouter) executeAlgorithm(ctx context.Context, request *types.MatchingRequest) ([]types.MatchResult, error) {
 	switch request.Algorithm {
 	case types.AlgorithmVectorBased, types.AlgorithmEnhancedVector:
-		return r.executeVectorAlgorithm(request)
+		return r.executeVectorAlgorithm(ctx, request)
 
 	case types.AlgorithmBasicCompatibility:
 		return r.executeBasicCompatibility(request)
@@ -46,39 +60,30 @@ func (r *AlgorithmRouter) ExecuteAlgorithm(request *types.MatchingRequest) ([]ty
 		return r.executePopularAlgorithm(request)
 
 	default:
-		return nil, fmt.Errorf("unknown algorithm type: %s", request.Algorithm)
+		return nil, fmt.Errorf("%w: %s", errUnknownAlgorithm, request.Algorithm)
 	}
 }
 
 // ExecuteCandidateAlgorithm routes to the appropriate algorithm and returns only candidates
-func (r *AlgorithmRouter) ExecuteCandidateAlgorithm(request *types.MatchingRequest) ([]types.MatchCandidate, error) {
-	log.Printf("🔍 [DEBUG Router] ExecuteCandidateAlgorithm - Algorithm: %s, UserID: %d", request.Algorithm, request.UserID)
-
+func (r *AlgorithmRouter) ExecuteCandidateAlgorithm(ctx context.Context, request *types.MatchingRequest) ([]types.MatchCandidate, error) {
 	// For now, we'll convert full results to candidates
 	// This is a temporary solution until we implement dedicated candidate methods in each service
-	results, err := r.ExecuteAlgorithm(request)
+	results, err := r.ExecuteAlgorithm(ctx, request)
 	if err != nil {
-		log.Printf("❌ [ERROR Router] ExecuteAlgorithm failed: %v", err)
 		return nil, err
 	}
 
-	log.Printf("✅ [DEBUG Router] ExecuteAlgorithm returned %d results", len(results))
-	candidates := r.convertResultsToCandidates(results)
-	log.Printf("✅ [DEBUG Router] Converted to %d candidates", len(candidates))
-
-	return candidates, nil
+	return r.convertResultsToCandidates(results), nil
 }
 
 // executeVectorAlgorithm executes vector-based matching algorithms
-func (r *AlgorithmRouter) executeVectorAlgorithm(request *types.MatchingRequest) ([]types.MatchResult, error) {
-	log.Printf("🔍 [DEBUG Router] Executing vector algorithm for user %d", request.UserID)
-	results, err := r.vectorMatchingService.GetPotentialMatches(
+func (r *AlgorithmRouter) executeVectorAlgorithm(ctx context.Context, request *types.MatchingRequest) ([]types.MatchResult, error) {
+	results, err := r.vectorMatchingService.GetPotentialMatches(ctx,
 		request.UserID, request.Limit, request.MaxDistance, request.AgeRange)
 	if err != nil {
-		log.Printf("❌ [ERROR Router] Vector algorithm failed: %v", err)
+		slog.WarnContext(ctx, "vector algorithm failed", "error", err)
 		return nil, err
 	}
-	log.Printf("✅ [DEBUG Router] Vector algorithm returned %d results", len(results))
 	return results, nil
 }
 
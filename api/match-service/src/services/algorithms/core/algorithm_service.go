@@ -1,7 +1,8 @@
 package core
 
 import (
-	"log"
+	"context"
+	"log/slog"
 
 	"match-service/src/services/types"
 	"match-service/src/services/validation"
@@ -27,7 +28,7 @@ func NewAlgorithmService() *AlgorithmService {
 }
 
 // RunMatchingAlgorithm executes the specified matching algorithm
-func (a *AlgorithmService) RunMatchingAlgorithm(request *types.MatchingRequest) ([]types.MatchResult, error) {
+func (a *AlgorithmService) RunMatchingAlgorithm(ctx context.Context, request *types.MatchingRequest) ([]types.MatchResult, error) {
 	// Validate request
 	if err := a.validator.ValidateMatchingRequest(request); err != nil {
 		return nil, err
@@ -46,7 +47,7 @@ func (a *AlgorithmService) RunMatchingAlgorithm(request *types.MatchingRequest)
 	}
 
 	// Execute the appropriate algorithm
-	results, err := a.router.ExecuteAlgorithm(request)
+	results, err := a.router.ExecuteAlgorithm(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -61,31 +62,20 @@ func (a *AlgorithmService) RunMatchingAlgorithm(request *types.MatchingRequest)
 }
 
 // GetMatchingCandidates executes the specified matching algorithm and returns only IDs with scores
-func (a *AlgorithmService) GetMatchingCandidates(request *types.MatchingRequest) ([]types.MatchCandidate, error) {
-	log.Printf("🔍 [DEBUG AlgorithmService] GetMatchingCandidates - Algorithm: %s, UserID: %d", request.Algorithm, request.UserID)
-
+func (a *AlgorithmService) GetMatchingCandidates(ctx context.Context, request *types.MatchingRequest) ([]types.MatchCandidate, error) {
 	// Validate request
 	if err := a.validator.ValidateMatchingRequest(request); err != nil {
-		log.Printf("❌ [ERROR AlgorithmService] Validation failed: %v", err)
+		slog.DebugContext(ctx, "invalid matching request", "algorithm", request.Algorithm, "error", err)
 		return nil, err
 	}
 
 	if err := a.validator.ValidateAlgorithmRequirements(request); err != nil {
-		log.Printf("❌ [ERROR AlgorithmService] Algorithm requirements validation failed: %v", err)
+		slog.DebugContext(ctx, "algorithm requirements not met", "algorithm", request.Algorithm, "error", err)
 		return nil, err
 	}
 
-	log.Printf("✅ [DEBUG AlgorithmService] Validation passed, executing algorithm...")
-
 	// Execute the appropriate algorithm
-	candidates, err := a.router.ExecuteCandidateAlgorithm(request)
-	if err != nil {
-		log.Printf("❌ [ERROR AlgorithmService] Router execution failed: %v", err)
-		return nil, err
-	}
-
-	log.Printf("✅ [DEBUG AlgorithmService] Router returned %d candidates", len(candidates))
-	return candidates, nil
+	return a.router.ExecuteCandidateAlgorithm(ctx, request)
 }
 
 
@@ -55,7 +55,7 @@ type Metadata struct {
 
 func (m *MatrixService) GetUsersMatrix(userIDs []int, includeMetadata bool) (*MatrixData, error) {
 	var users []models.User
-	query := conf.DB
+	query := conf.DB.Scopes(models.Discoverable)
 
 	if len(userIDs) > 0 {
 		query = query.Where("id IN ?", userIDs)
@@ -159,7 +159,7 @@ func (m *MatrixService) GetCompatibleUsersMatrix(userID int) ([]UserMatrix, erro
 
 	// Find compatible users based on sexual preferences
 	var users []models.User
-	query := conf.DB.Where("id != ? AND latitude IS NOT NULL AND longitude IS NOT NULL", userID)
+	query := conf.DB.Scopes(models.Discoverable).Where("id != ? AND latitude IS NOT NULL AND longitude IS NOT NULL", userID)
 
 	if targetUser.SexPref == "both" {
 		query = query.Where("sex_pref = ? OR sex_pref = ?", targetUser.Gender, "both")
@@ -1,7 +1,8 @@
 package vector
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"math"
 	"math/rand"
 	"sort"
@@ -41,10 +42,10 @@ func NewVectorMatchingService() *VectorMatchingService {
 	}
 }
 
-func (v *VectorMatchingService) GetPotentialMatches(userID int, limit int, maxDistance *int, ageRange *types.AgeRange) ([]types.MatchResult, error) {
+func (v *VectorMatchingService) GetPotentialMatches(ctx context.Context, userID int, limit int, maxDistance *int, ageRange *types.AgeRange) ([]types.MatchResult, error) {
 	// Check cache first
 	if cached, exists := v.cacheService.GetCachedMatchResults(userID, "enhanced_vector", limit, maxDistance); exists {
-		log.Printf("Cache hit for user %d matches", userID)
+		slog.DebugContext(ctx, "match results cache hit", "user_id", userID)
 		return cached, nil
 	}
 
@@ -64,21 +65,17 @@ func (v *VectorMatchingService) GetPotentialMatches(userID int, limit int, maxDi
 	preferenceVector := currentUserVector
 
 	// Get potential candidates
-	log.Printf("🔍 [DEBUG Vector] Getting candidates for user %d with maxDistance: %v, ageRange: %v", userID, maxDistance, ageRange)
-	candidates, err := v.getCandidateUsers(userID, maxDistance, ageRange, currentUser)
+	candidates, err := v.getCandidateUsers(ctx, userID, maxDistance, ageRange, currentUser)
 	if err != nil {
-		log.Printf("❌ [ERROR Vector] Failed to get candidates: %v", err)
 		return nil, err
 	}
-	log.Printf("🔍 [DEBUG Vector] Found %d potential candidates", len(candidates))
 
 	// Calculate compatibility scores
-	log.Printf("🔍 [DEBUG Vector] Starting compatibility score calculation for %d candidates", len(candidates))
 	var scores []utils.CompatibilityScore
-	for i, candidate := range candidates {
+	for _, candidate := range candidates {
 		candidateVector, err := v.userService.GetUserVector(int(candidate.ID))
 		if err != nil {
-			log.Printf("❌ [ERROR Vector] Getting vector for user %d: %v", candidate.ID, err)
+			slog.WarnContext(ctx, "failed to get user vector", "candidate_id", candidate.ID, "error", err)
 			continue
 		}
 
@@ -86,13 +83,8 @@ func (v *VectorMatchingService) GetPotentialMatches(userID int, limit int, maxDi
 			userID, int(candidate.ID), preferenceVector, candidateVector, currentUser, &candidate,
 		)
 		scores = append(scores, score)
-
-		if i < 3 { // Log first 3 scores for debugging
-			log.Printf("🔍 [DEBUG Vector] Candidate %d (ID: %d): score=%.3f, distance=%.2f",
-				i+1, candidate.ID, score.CompatibilityScore, score.Distance)
-		}
 	}
-	log.Printf("🔍 [DEBUG Vector] Calculated %d compatibility scores", len(scores))
+	slog.DebugContext(ctx, "compatibility scores calculated", "candidates", len(candidates), "scores", len(scores))
 
 	// Sort by compatibility score (descending)
 	sort.Slice(scores, func(i, j int) bool {
@@ -146,7 +138,6 @@ func (v *VectorMatchingService) GetPotentialMatches(userID int, limit int, maxDi
 		results = append(results, result)
 	}
 
-	log.Printf("✅ [DEBUG Vector] Returning %d final match results", len(results))
 
 	// Cache the results for 5 minutes
 	v.cacheService.CacheMatchResults(userID, "enhanced_vector", limit, maxDistance, results)
@@ -170,36 +161,28 @@ func (v *VectorMatchingService) GetUserPreferences(userID int) (map[string]inter
 }
 
 // getCandidateUsers retrieves potential candidate users for matching with full preference filtering
-func (v *VectorMatchingService) getCandidateUsers(userID int, maxDistance *int, ageRange *types.AgeRange, currentUser *models.User) ([]models.User, error) {
-	log.Printf("🔍 [DEBUG Vector] getCandidateUsers for user %d", userID)
-
+func (v *VectorMatchingService) getCandidateUsers(ctx context.Context, userID int, maxDistance *int, ageRange *types.AgeRange, currentUser *models.User) ([]models.User, error) {
 	// Get user preferences
 	userPreferences, err := v.preferencesManager.GetUserMatchingPreferences(userID)
 	if err != nil {
-		log.Printf("❌ [ERROR Vector] Failed to get preferences for user %d: %v", userID, err)
+		slog.WarnContext(ctx, "failed to get matching preferences", "user_id", userID, "error", err)
 		return nil, err
 	}
 
-	log.Printf("🔍 [DEBUG Vector] User preferences: MinFame=%d, PreferredGenders=%s, AgeMin=%d, AgeMax=%d",
-		userPreferences.MinFame, userPreferences.PreferredGenders, userPreferences.AgeMin, userPreferences.AgeMax)
-
-	query := conf.DB.Where("id != ?", userID)
+	query := conf.DB.Scopes(models.Discoverable).Where("id != ?", userID)
 
 	// Apply age range filter (use preferences if not overridden)
 	if ageRange != nil {
-		log.Printf("🔍 [DEBUG Vector] Applying age range filter: %d-%d", ageRange.Min, ageRange.Max)
 		query = query.Where("age BETWEEN ? AND ?", ageRange.Min, ageRange.Max)
 	}
 
 	// Apply minimum fame filter from preferences
 	if userPreferences.MinFame > 0 {
-		log.Printf("🔍 [DEBUG Vector] Applying fame filter: >= %d", userPreferences.MinFame)
 		query = query.Where("fame >= ?", userPreferences.MinFame)
 	}
 
 	// Apply gender preference filtering from preferences
 	if userPreferences.PreferredGenders != "" && userPreferences.PreferredGenders != `["man","woman","other"]` {
-		log.Printf("🔍 [DEBUG Vector] Applying gender filter: %s", userPreferences.PreferredGenders)
 		// Parse JSON array - for now, handle basic cases
 		if strings.Contains(userPreferences.PreferredGenders, `"man"`) && !strings.Contains(userPreferences.PreferredGenders, `"woman"`) {
 			query = query.Where("gender = ?", "man")
@@ -214,11 +197,9 @@ func (v *VectorMatchingService) getCandidateUsers(userID int, maxDistance *int,
 		Select("seen_user_id").
 		Where("user_id = ?", userID)
 	query = query.Where("id NOT IN (?)", seenSubquery)
-	log.Printf("🔍 [DEBUG Vector] Applied seen profiles filter")
 
 	// Apply distance filter if specified
 	if maxDistance != nil && currentUser.Latitude.Valid && currentUser.Longitude.Valid {
-		log.Printf("🔍 [DEBUG Vector] Applying distance bounding box filter: maxDistance=%d", *maxDistance)
 		// Use a simple bounding box for initial filtering (more efficient than Haversine in WHERE clause)
 		latDelta := float64(*maxDistance) / 111.0 // Approximate km per degree of latitude
 		lonDelta := latDelta / math.Cos(currentUser.Latitude.Float64*math.Pi/180)
@@ -230,21 +211,19 @@ func (v *VectorMatchingService) getCandidateUsers(userID int, maxDistance *int,
 
 	var candidates []models.User
 	if err := query.Find(&candidates).Error; err != nil {
-		log.Printf("❌ [ERROR Vector] Database query failed: %v", err)
+		slog.ErrorContext(ctx, "failed to query candidates", "user_id", userID, "error", err)
 		return nil, err
 	}
-
-	log.Printf("🔍 [DEBUG Vector] Query returned %d candidates", len(candidates))
+	queried := len(candidates)
 
 	// If distance filter is applied, do precise filtering
 	if maxDistance != nil && currentUser.Latitude.Valid && currentUser.Longitude.Valid {
-		log.Printf("🔍 [DEBUG Vector] Applying precise distance filter: maxDistance=%d", *maxDistance)
-		beforeCount := len(candidates)
 		candidates = v.filterByPreciseDistance(candidates, currentUser, *maxDistance)
-		log.Printf("🔍 [DEBUG Vector] Distance filter: %d -> %d candidates", beforeCount, len(candidates))
 	}
 
-	log.Printf("✅ [DEBUG Vector] Final candidate count: %d", len(candidates))
+	slog.DebugContext(ctx, "candidates found", "user_id", userID,
+		"min_fame", userPreferences.MinFame, "preferred_genders", userPreferences.PreferredGenders,
+		"max_distance", maxDistance, "queried", queried, "count", len(candidates))
 	return candidates, nil
 }
 
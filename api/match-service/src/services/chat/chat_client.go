@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+// Reasons sent to chat-service when a match ends; chat-service decides what
+// happens to the conversation (read-only on unmatch, archived on block)
+const (
+	ReasonUnmatched = "unmatched"
+	ReasonBlocked   = "blocked"
+)
+
+// ChatClient keeps chat-service conversations in step with matches
+type ChatClient struct {
+	chatServiceURL string
+	internalKey    string
+	httpClient     *http.Client
+}
+
+type conversationPayload struct {
+	User1ID int    `json:"user1_id"`
+	User2ID int    `json:"user2_id"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// NewChatClient creates a chat client (CHAT_SERVICE_URL, INTERNAL_API_KEY)
+func NewChatClient() *ChatClient {
+	url := os.Getenv("CHAT_SERVICE_URL")
+	if url == "" {
+		url = "http://chat-service:8004"
+	}
+	return &ChatClient{
+		chatServiceURL: url,
+		internalKey:    os.Getenv("INTERNAL_API_KEY"),
+		httpClient:     tracing.NewClient(5 * time.Second),
+	}
+}
+
+// OpenConversation creates or reopens the conversation of a new match
+func (cc *ChatClient) OpenConversation(ctx context.Context, userID, targetUserID int) error {
+	return cc.post(ctx, "/api/v1/internal/conversations", conversationPayload{User1ID: userID, User2ID: targetUserID})
+}
+
+// CloseConversation tells chat-service the match between two users ended
+func (cc *ChatClient) CloseConversation(ctx context.Context, userID, targetUserID int, reason string) error {
+	return cc.post(ctx, "/api/v1/internal/conversations/close", conversationPayload{User1ID: userID, User2ID: targetUserID, Reason: reason})
+}
+
+// SyncOpen opens the conversation and only logs failures: the match stands,
+// and chat-service checks the match of a conversation it never synced when
+// the first message is sent
+func (cc *ChatClient) SyncOpen(ctx context.Context, userID, targetUserID int) {
+	if err := cc.OpenConversation(ctx, userID, targetUserID); err != nil {
+		slog.WarnContext(ctx, "failed to open conversation", "user_id", userID, "target_user_id", targetUserID, "error", err)
+	}
+}
+
+// SyncClose closes the conversation and only logs failures: chat-service
+// checks the match again once the status it holds is older than its
+// MATCH_RECHECK_INTERVAL, so a lost close only lasts that long
+func (cc *ChatClient) SyncClose(ctx context.Context, userID, targetUserID int, reason string) {
+	if err := cc.CloseConversation(ctx, userID, targetUserID, reason); err != nil {
+		slog.WarnContext(ctx, "failed to close conversation", "user_id", userID, "target_user_id", targetUserID, "reason", reason, "error", err)
+	}
+}
+
+func (cc *ChatClient) post(ctx context.Context, path string, payload conversationPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversation payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.chatServiceURL+path, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "matcha-internal-service/match-service")
+	if cc.internalKey != "" {
+		req.Header.Set("X-Internal-Key", cc.internalKey)
+	}
+
+	resp, err := cc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("chat service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("chat service returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
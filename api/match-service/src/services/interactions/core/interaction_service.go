@@ -1,11 +1,13 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"match-service/src/conf"
 	"match-service/src/models"
+	"match-service/src/services/chat"
 	"match-service/src/services/users"
 	"match-service/src/services/interactions/matches"
 	"match-service/src/services/notifications"
@@ -15,6 +17,7 @@ import (
 type InteractionService struct {
 	userService         *users.UserService
 	notificationService *notifications.NotificationService
+	chatClient          *chat.ChatClient
 }
 
 // NewInteractionService creates a new InteractionService instance
@@ -22,6 +25,7 @@ func NewInteractionService() *InteractionService {
 	return &InteractionService{
 		userService:         users.NewUserService(),
 		notificationService: notifications.NewNotificationService(),
+		chatClient:          chat.NewChatClient(),
 	}
 }
 
@@ -31,7 +35,9 @@ func (i *InteractionService) GetUserService() *users.UserService {
 }
 
 // LikeUser records a like interaction and checks for mutual matches
-func (i *InteractionService) LikeUser(userID, targetUserID int) (map[string]interface{}, error) {
+func (i *InteractionService) LikeUser(ctx context.Context, userID, targetUserID int) (map[string]interface{}, error) {
+	db := conf.DB.WithContext(ctx)
+
 	// Validate that target user exists
 	if err := i.userService.ValidateUserExists(targetUserID); err != nil {
 		return nil, errors.New("target user does not exist")
@@ -39,13 +45,13 @@ func (i *InteractionService) LikeUser(userID, targetUserID int) (map[string]inte
 
 	// Check if interaction already exists
 	var existingInteraction models.UserInteraction
-	result := conf.DB.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
+	result := db.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
 		First(&existingInteraction)
 
 	if result.Error == nil {
 		// Update existing interaction
 		existingInteraction.InteractionType = "like"
-		conf.DB.Save(&existingInteraction)
+		db.Save(&existingInteraction)
 	} else {
 		// Create new interaction
 		interaction := models.UserInteraction{
@@ -53,7 +59,7 @@ func (i *InteractionService) LikeUser(userID, targetUserID int) (map[string]inte
 			TargetUserID:    uint(targetUserID),
 			InteractionType: "like",
 		}
-		conf.DB.Create(&interaction)
+		db.Create(&interaction)
 	}
 
 	response := map[string]interface{}{
@@ -63,23 +69,26 @@ func (i *InteractionService) LikeUser(userID, targetUserID int) (map[string]inte
 	}
 
 	// Send like notification to the target user
-	i.notificationService.SendLikeNotification(targetUserID, userID)
+	i.notificationService.SendLikeNotification(ctx, targetUserID, userID)
 
 	// Check for mutual like to create match
 	var mutualLike models.UserInteraction
-	mutualResult := conf.DB.Where("user_id = ? AND target_user_id = ? AND interaction_type = ?",
+	mutualResult := db.Where("user_id = ? AND target_user_id = ? AND interaction_type = ?",
 		targetUserID, userID, "like").First(&mutualLike)
 
 	if mutualResult.Error == nil {
 		// Create match
-		match, err := matches.CreateMatch(userID, targetUserID)
+		match, err := matches.CreateMatch(ctx, userID, targetUserID)
 		if err == nil {
 			response["match_created"] = true
 			response["match_id"] = match.ID
 
+			// Open the conversation right away
+			i.chatClient.SyncOpen(ctx, userID, targetUserID)
+
 			// Send mutual like notifications to both users
-			i.notificationService.SendMutualLikeNotification(targetUserID, userID)
-			i.notificationService.SendMutualLikeNotification(userID, targetUserID)
+			i.notificationService.SendMutualLikeNotification(ctx, targetUserID, userID)
+			i.notificationService.SendMutualLikeNotification(ctx, userID, targetUserID)
 		}
 	}
 
@@ -87,7 +96,9 @@ func (i *InteractionService) LikeUser(userID, targetUserID int) (map[string]inte
 }
 
 // UnlikeUser removes a like interaction and deactivates any match
-func (i *InteractionService) UnlikeUser(userID, targetUserID int) (map[string]interface{}, error) {
+func (i *InteractionService) UnlikeUser(ctx context.Context, userID, targetUserID int) (map[string]interface{}, error) {
+	db := conf.DB.WithContext(ctx)
+
 	// Check if there was a match before unlinking
 	wasMatched := matches.IsMatched(userID, targetUserID)
 
@@ -99,7 +110,7 @@ func (i *InteractionService) UnlikeUser(userID, targetUserID int) (map[string]in
 		
 		// Transform all like interactions between these users to "pass" 
 		var interactions []models.UserInteraction
-		err := conf.DB.Where(
+		err := db.Where(
 			"((user_id = ? AND target_user_id = ?) OR (user_id = ? AND target_user_id = ?)) AND interaction_type = ?",
 			userID, targetUserID, targetUserID, userID, "like",
 		).Find(&interactions).Error
@@ -111,31 +122,33 @@ func (i *InteractionService) UnlikeUser(userID, targetUserID int) (map[string]in
 		// Transform each like to a pass
 		for _, interaction := range interactions {
 			interaction.InteractionType = "pass"
-			conf.DB.Save(&interaction)
+			db.Save(&interaction)
 		}
 		
 		// Deactivate the match
-		matches.DeactivateMatch(userID, targetUserID)
-		if err != nil {
+		if err := matches.DeactivateMatch(ctx, userID, targetUserID); err != nil {
 			return nil, fmt.Errorf("failed to unmatch users: %v", err)
 		}
+
+		// The conversation stays readable but nobody can write anymore
+		i.chatClient.SyncClose(ctx, userID, targetUserID, chat.ReasonUnmatched)
 		
 		return map[string]interface{}{
 			"action":         "unlike",
 			"target_user_id": targetUserID,
 			"success":        true,
-			"message":        "Users unliked and fully unmatched (conversation deleted)",
+			"message":        "Users unliked and fully unmatched (conversation closed)",
 		}, nil
 	}
 
 	// If they weren't matched, just remove the like interaction
 	var existingInteraction models.UserInteraction
-	result := conf.DB.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
+	result := db.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
 		First(&existingInteraction)
 
 	if result.Error == nil {
 		// Delete the interaction
-		conf.DB.Delete(&existingInteraction)
+		db.Delete(&existingInteraction)
 	}
 
 	response := map[string]interface{}{
@@ -149,7 +162,9 @@ func (i *InteractionService) UnlikeUser(userID, targetUserID int) (map[string]in
 }
 
 // BlockUser blocks a user and removes any existing match
-func (i *InteractionService) BlockUser(userID, targetUserID int) (map[string]interface{}, error) {
+func (i *InteractionService) BlockUser(ctx context.Context, userID, targetUserID int) (map[string]interface{}, error) {
+	db := conf.DB.WithContext(ctx)
+
 	// Validate that target user exists
 	if err := i.userService.ValidateUserExists(targetUserID); err != nil {
 		return nil, errors.New("target user does not exist")
@@ -157,13 +172,13 @@ func (i *InteractionService) BlockUser(userID, targetUserID int) (map[string]int
 
 	// Check if interaction already exists
 	var existingInteraction models.UserInteraction
-	result := conf.DB.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
+	result := db.Where("user_id = ? AND target_user_id = ?", userID, targetUserID).
 		First(&existingInteraction)
 
 	if result.Error == nil {
 		// Update existing interaction
 		existingInteraction.InteractionType = "block"
-		conf.DB.Save(&existingInteraction)
+		db.Save(&existingInteraction)
 	} else {
 		// Create new interaction
 		interaction := models.UserInteraction{
@@ -171,19 +186,20 @@ func (i *InteractionService) BlockUser(userID, targetUserID int) (map[string]int
 			TargetUserID:    uint(targetUserID),
 			InteractionType: "block",
 		}
-		conf.DB.Create(&interaction)
+		db.Create(&interaction)
 	}
 
-	// Deactivate any existing match
-	matches.DeactivateMatch(userID, targetUserID)
+	// Deactivate any existing match and archive the conversation
+	matches.DeactivateMatch(ctx, userID, targetUserID)
+	i.chatClient.SyncClose(ctx, userID, targetUserID, chat.ReasonBlocked)
 
 	// Also remove the reverse interaction if it exists (target user liked this user)
 	var reverseInteraction models.UserInteraction
-	reverseResult := conf.DB.Where("user_id = ? AND target_user_id = ?", targetUserID, userID).
+	reverseResult := db.Where("user_id = ? AND target_user_id = ?", targetUserID, userID).
 		First(&reverseInteraction)
 
 	if reverseResult.Error == nil {
-		conf.DB.Delete(&reverseInteraction)
+		db.Delete(&reverseInteraction)
 	}
 
 	response := map[string]interface{}{
@@ -1,12 +1,14 @@
 package interactions
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	"match-service/src/conf"
 	"match-service/src/models"
+	"match-service/src/services/chat"
 	"match-service/src/utils"
 	"match-service/src/services/users"
 	"match-service/src/services/notifications"
@@ -23,7 +25,7 @@ func NewInteractionManager() *InteractionManager {
 }
 
 // RecordInteraction records a user interaction and handles match logic
-func (m *InteractionManager) RecordInteraction(userID, targetUserID int, action string) (map[string]interface{}, error) {
+func (m *InteractionManager) RecordInteraction(ctx context.Context, userID, targetUserID int, action string) (map[string]interface{}, error) {
 	// Validate users exist
 	userService := users.NewUserService()
 	if err := userService.ValidateUserExists(userID); err != nil {
@@ -72,16 +74,18 @@ func (m *InteractionManager) RecordInteraction(userID, targetUserID int, action
 	// Handle match logic based on action
 	switch action {
 	case "like":
-		m.handleLikeAction(userID, targetUserID, resultMap)
-	case "pass", "block":
-		m.handleNegativeAction(userID, targetUserID)
+		m.handleLikeAction(ctx, userID, targetUserID, resultMap)
+	case "pass":
+		m.handleNegativeAction(ctx, userID, targetUserID, chat.ReasonUnmatched)
+	case "block":
+		m.handleNegativeAction(ctx, userID, targetUserID, chat.ReasonBlocked)
 	}
 
 	return resultMap, nil
 }
 
 // handleLikeAction checks for mutual likes and creates matches
-func (m *InteractionManager) handleLikeAction(userID, targetUserID int, result map[string]interface{}) {
+func (m *InteractionManager) handleLikeAction(ctx context.Context, userID, targetUserID int, result map[string]interface{}) {
 	var mutualLike models.UserInteraction
 	mutualResult := conf.DB.Where("user_id = ? AND target_user_id = ? AND interaction_type = ?",
 		targetUserID, userID, "like").First(&mutualLike)
@@ -92,13 +96,16 @@ func (m *InteractionManager) handleLikeAction(userID, targetUserID int, result m
 		if err == nil {
 			result["match_created"] = true
 			result["match_id"] = match.ID
+			chat.NewChatClient().SyncOpen(ctx, userID, targetUserID)
 		}
 	}
 }
 
-// handleNegativeAction deactivates matches for pass/block actions
-func (m *InteractionManager) handleNegativeAction(userID, targetUserID int) {
+// handleNegativeAction deactivates matches for pass/block actions and closes
+// the conversation accordingly
+func (m *InteractionManager) handleNegativeAction(ctx context.Context, userID, targetUserID int, reason string) {
 	m.deactivateMatch(userID, targetUserID)
+	chat.NewChatClient().SyncClose(ctx, userID, targetUserID, reason)
 }
 
 // createMatch creates a new match between two users
@@ -156,7 +163,7 @@ func (m *InteractionManager) deactivateMatch(userID, targetUserID int) error {
 }
 
 // UnmatchUsers handles unmatching between two users
-func (m *InteractionManager) UnmatchUsers(userID, targetUserID int) error {
+func (m *InteractionManager) UnmatchUsers(ctx context.Context, userID, targetUserID int) error {
 	// Validate users exist
 	userService := users.NewUserService()
 	if err := userService.ValidateUserExists(userID); err != nil {
@@ -176,13 +183,11 @@ func (m *InteractionManager) UnmatchUsers(userID, targetUserID int) error {
 		// Don't fail the unmatch operation if this fails
 	}
 
-	// Delete conversation/messages between users
-	if err := m.deleteConversation(userID, targetUserID); err != nil {
-		// Don't fail the unmatch operation if conversation deletion fails
-	}
+	// Make the conversation read-only; chat-service keeps the history
+	chat.NewChatClient().SyncClose(ctx, userID, targetUserID, chat.ReasonUnmatched)
 
 	// Send unmatch notification
-	if err := m.sendUnmatchNotification(userID, targetUserID); err != nil {
+	if err := m.sendUnmatchNotification(ctx, userID, targetUserID); err != nil {
 		// Don't fail the unmatch operation if notification fails
 	}
 
@@ -200,17 +205,6 @@ func (m *InteractionManager) GetInteractionCount(userID int) (int64, error) {
 	return count, err
 }
 
-// deleteConversation deletes all messages between two users
-func (m *InteractionManager) deleteConversation(userID, targetUserID int) error {
-	// Make HTTP call to chat service to delete conversation
-	err := m.callChatService(userID, targetUserID)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // transformLikesToPass converts like interactions to pass interactions
 func (m *InteractionManager) transformLikesToPass(userID, targetUserID int) error {
 	// Find all like interactions between these two users (in both directions)
@@ -234,28 +228,17 @@ func (m *InteractionManager) transformLikesToPass(userID, targetUserID int) erro
 	return nil
 }
 
-// callChatService logs the conversation hiding (frontend will handle visual hiding)
-func (m *InteractionManager) callChatService(userID, targetUserID int) error {
-	log.Printf("� [DEBUG] Conversation between users %d and %d should be hidden in UI", userID, targetUserID)
-	log.Printf("💡 [INFO] Frontend will filter out conversations where users are unmatched")
-	
-	// No database deletion - the frontend will handle hiding conversations
-	// by checking match status when displaying conversation list
-	
-	return nil
-}
-
 // sendUnmatchNotification sends a notification about the unmatch
-func (m *InteractionManager) sendUnmatchNotification(userID, targetUserID int) error {
+func (m *InteractionManager) sendUnmatchNotification(ctx context.Context, userID, targetUserID int) error {
 	notificationService := notifications.NewNotificationService()
 
 	// Send notification to the target user (the one being unmatched)
-	err := notificationService.SendUnmatchNotification(targetUserID, userID)
+	err := notificationService.SendUnmatchNotification(ctx, targetUserID, userID)
 	if err != nil {
-		log.Printf("❌ Failed to send unmatch notification to user %d: %v", targetUserID, err)
+		slog.WarnContext(ctx, "failed to send unmatch notification", "user_id", userID, "target_user_id", targetUserID, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Unmatch notification sent to user %d from user %d", targetUserID, userID)
+	slog.DebugContext(ctx, "unmatch notification sent", "user_id", userID, "target_user_id", targetUserID)
 	return nil
 }
\ No newline at end of file
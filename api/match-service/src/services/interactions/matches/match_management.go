@@ -1,7 +1,8 @@
 package matches
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
 	"match-service/src/conf"
@@ -9,9 +10,7 @@ import (
 )
 
 // CreateMatch creates a new match between two users
-func CreateMatch(userID, targetUserID int) (*models.Match, error) {
-	log.Printf("🔍 [DEBUG CreateMatch] Creating match between users %d and %d", userID, targetUserID)
-
+func CreateMatch(ctx context.Context, userID, targetUserID int) (*models.Match, error) {
 	match := models.Match{
 		User1ID:   uint(userID),
 		User2ID:   uint(targetUserID),
@@ -23,9 +22,6 @@ func CreateMatch(userID, targetUserID int) (*models.Match, error) {
 	if userID > targetUserID {
 		match.User1ID = uint(targetUserID)
 		match.User2ID = uint(userID)
-		log.Printf("🔍 [DEBUG CreateMatch] Reordered IDs: user1_id=%d, user2_id=%d", match.User1ID, match.User2ID)
-	} else {
-		log.Printf("🔍 [DEBUG CreateMatch] Using original order: user1_id=%d, user2_id=%d", match.User1ID, match.User2ID)
 	}
 
 	// Check if match already exists
@@ -34,61 +30,37 @@ func CreateMatch(userID, targetUserID int) (*models.Match, error) {
 		match.User1ID, match.User2ID).First(&existingMatch)
 
 	if result.Error != nil {
-		log.Printf("🔍 [DEBUG CreateMatch] No existing match found, creating new match")
-		log.Printf("🔍 [DEBUG CreateMatch] Match object before create: %+v", match)
-
 		// Create new match
 		createResult := conf.DB.Create(&match)
 		if createResult.Error != nil {
-			log.Printf("❌ [ERROR CreateMatch] Failed to create new match: %v", createResult.Error)
-			log.Printf("❌ [ERROR CreateMatch] RowsAffected: %d", createResult.RowsAffected)
+			slog.ErrorContext(ctx, "failed to create match",
+				"user1_id", match.User1ID, "user2_id", match.User2ID, "error", createResult.Error)
 			return nil, createResult.Error
 		}
 
-		log.Printf("✅ [SUCCESS CreateMatch] New match created with ID: %d, RowsAffected: %d", match.ID, createResult.RowsAffected)
-		log.Printf("🔍 [DEBUG CreateMatch] Match object after create: %+v", match)
-
-		// Verify the match was actually saved
-		var verifyMatch models.Match
-		verifyResult := conf.DB.Where("user1_id = ? AND user2_id = ?", match.User1ID, match.User2ID).First(&verifyMatch)
-		if verifyResult.Error != nil {
-			log.Printf("❌ [ERROR CreateMatch] Could not verify match creation: %v", verifyResult.Error)
-		} else {
-			log.Printf("✅ [SUCCESS CreateMatch] Verified match exists in DB: ID=%d, User1=%d, User2=%d, Active=%t",
-				verifyMatch.ID, verifyMatch.User1ID, verifyMatch.User2ID, verifyMatch.IsActive)
-		}
-
+		slog.InfoContext(ctx, "match created", "match_id", match.ID, "user1_id", match.User1ID, "user2_id", match.User2ID)
 		return &match, nil
 	} else {
-		log.Printf("🔍 [DEBUG CreateMatch] Existing match found (ID: %d), reactivating", existingMatch.ID)
-		log.Printf("🔍 [DEBUG CreateMatch] Existing match before save: %+v", existingMatch)
-
 		// Reactivate existing match
 		existingMatch.IsActive = true
 		saveResult := conf.DB.Save(&existingMatch)
 		if saveResult.Error != nil {
-			log.Printf("❌ [ERROR CreateMatch] Failed to reactivate existing match: %v", saveResult.Error)
-			log.Printf("❌ [ERROR CreateMatch] RowsAffected: %d", saveResult.RowsAffected)
+			slog.ErrorContext(ctx, "failed to reactivate match", "match_id", existingMatch.ID, "error", saveResult.Error)
 			return nil, saveResult.Error
 		}
 
-		log.Printf("✅ [SUCCESS CreateMatch] Existing match reactivated (ID: %d), RowsAffected: %d", existingMatch.ID, saveResult.RowsAffected)
-		log.Printf("🔍 [DEBUG CreateMatch] Existing match after save: %+v", existingMatch)
+		slog.InfoContext(ctx, "match reactivated", "match_id", existingMatch.ID,
+			"user1_id", existingMatch.User1ID, "user2_id", existingMatch.User2ID)
 		return &existingMatch, nil
 	}
 }
 
 // DeactivateMatch deactivates a match between two users
-func DeactivateMatch(userID, targetUserID int) error {
-	log.Printf("🔍 [DEBUG DeactivateMatch] Deactivating match between users %d and %d", userID, targetUserID)
-
+func DeactivateMatch(ctx context.Context, userID, targetUserID int) error {
 	// Determine correct ordering
 	user1ID, user2ID := userID, targetUserID
 	if userID > targetUserID {
 		user1ID, user2ID = targetUserID, userID
-		log.Printf("🔍 [DEBUG DeactivateMatch] Reordered IDs: user1_id=%d, user2_id=%d", user1ID, user2ID)
-	} else {
-		log.Printf("🔍 [DEBUG DeactivateMatch] Using original order: user1_id=%d, user2_id=%d", user1ID, user2ID)
 	}
 
 	var match models.Match
@@ -96,27 +68,21 @@ func DeactivateMatch(userID, targetUserID int) error {
 
 	if result.Error != nil {
 		if result.Error.Error() == "record not found" {
-			log.Printf("⚠️ [WARNING DeactivateMatch] No active match found between users %d and %d", userID, targetUserID)
+			slog.DebugContext(ctx, "no active match to deactivate", "user1_id", user1ID, "user2_id", user2ID)
 			return nil // Not an error - match might already be deactivated or never existed
 		}
-		log.Printf("❌ [ERROR DeactivateMatch] Failed to find match: %v", result.Error)
+		slog.ErrorContext(ctx, "failed to find match", "user1_id", user1ID, "user2_id", user2ID, "error", result.Error)
 		return result.Error
 	}
 
-	log.Printf("🔍 [DEBUG DeactivateMatch] Found active match (ID: %d), deactivating", match.ID)
-	log.Printf("🔍 [DEBUG DeactivateMatch] Match before deactivation: %+v", match)
-
 	match.IsActive = false
 	saveResult := conf.DB.Save(&match)
 	if saveResult.Error != nil {
-		log.Printf("❌ [ERROR DeactivateMatch] Failed to deactivate match: %v", saveResult.Error)
-		log.Printf("❌ [ERROR DeactivateMatch] RowsAffected: %d", saveResult.RowsAffected)
+		slog.ErrorContext(ctx, "failed to deactivate match", "match_id", match.ID, "error", saveResult.Error)
 		return saveResult.Error
 	}
 
-	log.Printf("✅ [SUCCESS DeactivateMatch] Match deactivated (ID: %d), RowsAffected: %d", match.ID, saveResult.RowsAffected)
-	log.Printf("🔍 [DEBUG DeactivateMatch] Match after deactivation: %+v", match)
-
+	slog.InfoContext(ctx, "match deactivated", "match_id", match.ID, "user1_id", user1ID, "user2_id", user2ID)
 	return nil
 }
 
@@ -1,7 +1,8 @@
 package matching
 
 import (
-	"log"
+	"context"
+	"log/slog"
 
 	"match-service/src/services/types"
 	"match-service/src/services/users"
@@ -33,39 +34,37 @@ func (s *MatchService) GetUserMatches(userID int) ([]types.MatchResult, error) {
 }
 
 // LikeUser records a like interaction and handles match creation
-func (s *MatchService) LikeUser(userID, targetUserID int) (map[string]interface{}, error) {
-	return s.interactionService.LikeUser(userID, targetUserID)
+func (s *MatchService) LikeUser(ctx context.Context, userID, targetUserID int) (map[string]interface{}, error) {
+	return s.interactionService.LikeUser(ctx, userID, targetUserID)
 }
 
 // UnlikeUser removes a like interaction and deactivates matches
-func (s *MatchService) UnlikeUser(userID, targetUserID int) (map[string]interface{}, error) {
-	return s.interactionService.UnlikeUser(userID, targetUserID)
+func (s *MatchService) UnlikeUser(ctx context.Context, userID, targetUserID int) (map[string]interface{}, error) {
+	return s.interactionService.UnlikeUser(ctx, userID, targetUserID)
 }
 
 // BlockUser blocks a user and removes any existing matches
-func (s *MatchService) BlockUser(userID, targetUserID int) (map[string]interface{}, error) {
-	return s.interactionService.BlockUser(userID, targetUserID)
+func (s *MatchService) BlockUser(ctx context.Context, userID, targetUserID int) (map[string]interface{}, error) {
+	return s.interactionService.BlockUser(ctx, userID, targetUserID)
 }
 
 // RunMatchingAlgorithm executes the specified matching algorithm and returns full profile data
-func (s *MatchService) RunMatchingAlgorithm(userID int, algorithmType string, limit int, maxDistance *int, ageRange *types.AgeRange) ([]types.MatchResult, error) {
+func (s *MatchService) RunMatchingAlgorithm(ctx context.Context, userID int, algorithmType string, limit int, maxDistance *int, ageRange *types.AgeRange) ([]types.MatchResult, error) {
 	request := BuildMatchingRequest(userID, algorithmType, limit, maxDistance, ageRange)
-	return s.algorithmService.RunMatchingAlgorithm(request)
+	return s.algorithmService.RunMatchingAlgorithm(ctx, request)
 }
 
 // GetMatchingCandidates executes the specified matching algorithm and returns only user IDs with scores
-func (s *MatchService) GetMatchingCandidates(userID int, algorithmType string, limit int, maxDistance *int, ageRange *types.AgeRange) ([]types.MatchCandidate, error) {
-	log.Printf("🔍 [DEBUG MatchService] GetMatchingCandidates called - UserID: %d, Algorithm: %s, Limit: %d", userID, algorithmType, limit)
+func (s *MatchService) GetMatchingCandidates(ctx context.Context, userID int, algorithmType string, limit int, maxDistance *int, ageRange *types.AgeRange) ([]types.MatchCandidate, error) {
 	request := BuildMatchingRequest(userID, algorithmType, limit, maxDistance, ageRange)
-	log.Printf("🔍 [DEBUG MatchService] Built request: %+v", request)
 
-	candidates, err := s.algorithmService.GetMatchingCandidates(request)
+	candidates, err := s.algorithmService.GetMatchingCandidates(ctx, request)
 	if err != nil {
-		log.Printf("❌ [ERROR MatchService] Algorithm service returned error: %v", err)
+		slog.WarnContext(ctx, "matching algorithm failed", "algorithm", algorithmType, "error", err)
 		return nil, err
 	}
 
-	log.Printf("✅ [DEBUG MatchService] Algorithm service returned %d candidates", len(candidates))
+	slog.DebugContext(ctx, "matching candidates found", "algorithm", algorithmType, "limit", limit, "count", len(candidates))
 	return candidates, nil
 }
 
@@ -126,46 +125,46 @@ func (s *MatchService) InvalidateUserCaches(userID int) {
 }
 
 // GetNearbyUsers returns users within a specified distance
-func (s *MatchService) GetNearbyUsers(userID int, maxDistanceKm int, limit int) ([]types.MatchResult, error) {
+func (s *MatchService) GetNearbyUsers(ctx context.Context, userID int, maxDistanceKm int, limit int) ([]types.MatchResult, error) {
 	request := &types.MatchingRequest{
 		UserID:      userID,
 		Algorithm:   types.AlgorithmProximity,
 		Limit:       limit,
 		MaxDistance: &maxDistanceKm,
 	}
-	return s.algorithmService.RunMatchingAlgorithm(request)
+	return s.algorithmService.RunMatchingAlgorithm(ctx, request)
 }
 
 // GetRandomMatches returns random compatible users
-func (s *MatchService) GetRandomMatches(userID int, limit int) ([]types.MatchResult, error) {
+func (s *MatchService) GetRandomMatches(ctx context.Context, userID int, limit int) ([]types.MatchResult, error) {
 	request := &types.MatchingRequest{
 		UserID:    userID,
 		Algorithm: types.AlgorithmRandom,
 		Limit:     limit,
 	}
-	return s.algorithmService.RunMatchingAlgorithm(request)
+	return s.algorithmService.RunMatchingAlgorithm(ctx, request)
 }
 
 // GetNewUsers returns recently joined users
-func (s *MatchService) GetNewUsers(userID int, limit int, daysBack int) ([]types.MatchResult, error) {
+func (s *MatchService) GetNewUsers(ctx context.Context, userID int, limit int, daysBack int) ([]types.MatchResult, error) {
 	request := &types.MatchingRequest{
 		UserID:   userID,
 		Algorithm: types.AlgorithmNewUsers,
 		Limit:    limit,
 		DaysBack: &daysBack,
 	}
-	return s.algorithmService.RunMatchingAlgorithm(request)
+	return s.algorithmService.RunMatchingAlgorithm(ctx, request)
 }
 
 // GetPopularUsers returns users with high fame ratings
-func (s *MatchService) GetPopularUsers(userID int, limit int, minFame int) ([]types.MatchResult, error) {
+func (s *MatchService) GetPopularUsers(ctx context.Context, userID int, limit int, minFame int) ([]types.MatchResult, error) {
 	request := &types.MatchingRequest{
 		UserID:   userID,
 		Algorithm: types.AlgorithmPopular,
 		Limit:    limit,
 		MinFame:  &minFame,
 	}
-	return s.algorithmService.RunMatchingAlgorithm(request)
+	return s.algorithmService.RunMatchingAlgorithm(ctx, request)
 }
 
 // ValidateUser checks if a user exists
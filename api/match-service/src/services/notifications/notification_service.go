@@ -2,130 +2,140 @@ package notifications
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
-// NotificationService handles sending notifications to the notification service
+// NotificationService sends notifications through user-service, which stores
+// them and delivers them on the channels each user chose
 type NotificationService struct {
-	notifyServiceURL string
-	httpClient       *http.Client
+	userServiceURL string
+	internalKey    string
+	httpClient     *http.Client
 }
 
-// NotificationTypes constants
+// Notification kinds sent by match-service
 const (
-	TypeProfileView = "2" // When a user's profile is viewed
-	TypeLike        = "1" // When a user receives a "like"
-	TypeMessage     = "3" // When a user receives a message (already implemented)
-	TypeMutualLike  = "4" // When a user they "liked" likes them back (mutual match)
-	TypeUnlike      = "5" // When a connected user "unlikes" them
-	TypeUnmatch     = "1" // When a connected user "unmatches" them
+	KindLike        = "like"         // When a user receives a "like"
+	KindProfileView = "profile_view" // When a user's profile is viewed
+	KindMatch       = "match"        // When a user they "liked" likes them back (mutual match)
+	KindUnlike      = "unlike"       // When a connected user "unlikes" them
+	KindUnmatch     = "unmatch"      // When a connected user "unmatches" them
 )
 
-// NotificationPayload represents the payload sent to the notification service
+// NotificationPayload is the body expected by POST /api/v1/internal/notifications
 type NotificationPayload struct {
 	ToUserID   int    `json:"to_user_id"`
-	NotifType  string `json:"notif_type"`
+	Kind       string `json:"kind"`
 	Message    string `json:"message"`
 	FromUserID int    `json:"from_user_id,omitempty"`
 }
 
-// NewNotificationService creates a new notification service instance
+// NewNotificationService creates a notification client (USER_SERVICE_URL, INTERNAL_API_KEY)
 func NewNotificationService() *NotificationService {
+	url := os.Getenv("USER_SERVICE_URL")
+	if url == "" {
+		url = "http://user-service:8002"
+	}
 	return &NotificationService{
-		notifyServiceURL: "http://notify-service:8005",
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		userServiceURL: url,
+		internalKey:    os.Getenv("INTERNAL_API_KEY"),
+		httpClient:     tracing.NewClient(5 * time.Second),
 	}
 }
 
 // SendLikeNotification sends a notification when someone likes a user
-func (ns *NotificationService) SendLikeNotification(targetUserID, fromUserID int) error {
+func (ns *NotificationService) SendLikeNotification(ctx context.Context, targetUserID, fromUserID int) error {
 	payload := NotificationPayload{
 		ToUserID:   targetUserID,
-		NotifType:  TypeLike,
+		Kind:       KindLike,
 		Message:    "Quelqu'un vous a liké ❤️",
 		FromUserID: fromUserID,
 	}
-	return ns.sendNotification(payload)
+	return ns.sendNotification(ctx, payload)
 }
 
 // SendProfileViewNotification sends a notification when someone views a user's profile
-func (ns *NotificationService) SendProfileViewNotification(targetUserID, fromUserID int) error {
+func (ns *NotificationService) SendProfileViewNotification(ctx context.Context, targetUserID, fromUserID int) error {
 	payload := NotificationPayload{
 		ToUserID:   targetUserID,
-		NotifType:  TypeProfileView,
+		Kind:       KindProfileView,
 		Message:    "Quelqu'un a consulté votre profil 👀",
 		FromUserID: fromUserID,
 	}
-	return ns.sendNotification(payload)
+	return ns.sendNotification(ctx, payload)
 }
 
 // SendMutualLikeNotification sends a notification when there's a mutual like (match)
-func (ns *NotificationService) SendMutualLikeNotification(targetUserID, fromUserID int) error {
+func (ns *NotificationService) SendMutualLikeNotification(ctx context.Context, targetUserID, fromUserID int) error {
 	payload := NotificationPayload{
 		ToUserID:   targetUserID,
-		NotifType:  TypeMutualLike,
+		Kind:       KindMatch,
 		Message:    "C'est un match ! 🎉 Vous vous êtes mutuellement likés",
 		FromUserID: fromUserID,
 	}
-	return ns.sendNotification(payload)
+	return ns.sendNotification(ctx, payload)
 }
 
 // SendUnlikeNotification sends a notification when someone unlikes a user they were matched with
-func (ns *NotificationService) SendUnlikeNotification(targetUserID, fromUserID int) error {
+func (ns *NotificationService) SendUnlikeNotification(ctx context.Context, targetUserID, fromUserID int) error {
 	payload := NotificationPayload{
 		ToUserID:   targetUserID,
-		NotifType:  TypeUnlike,
+		Kind:       KindUnlike,
 		Message:    "Un utilisateur connecté ne vous like plus 💔",
 		FromUserID: fromUserID,
 	}
-	return ns.sendNotification(payload)
+	return ns.sendNotification(ctx, payload)
 }
 
 // SendUnmatchNotification sends a notification when someone unmatches a user
-func (ns *NotificationService) SendUnmatchNotification(targetUserID, fromUserID int) error {
+func (ns *NotificationService) SendUnmatchNotification(ctx context.Context, targetUserID, fromUserID int) error {
 	payload := NotificationPayload{
 		ToUserID:   targetUserID,
-		NotifType:  TypeUnmatch,
+		Kind:       KindUnmatch,
 		Message:    "Un utilisateur a annulé votre match 💔",
 		FromUserID: fromUserID,
 	}
-	return ns.sendNotification(payload)
+	return ns.sendNotification(ctx, payload)
 }
 
-// sendNotification sends the notification to the notification service
-func (ns *NotificationService) sendNotification(payload NotificationPayload) error {
+// sendNotification hands the notification to user-service
+func (ns *NotificationService) sendNotification(ctx context.Context, payload NotificationPayload) error {
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("❌ Failed to marshal notification payload: %v", err)
 		return fmt.Errorf("failed to marshal notification payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", ns.notifyServiceURL+"/api/v1/notifications/send", bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequestWithContext(ctx, "POST", ns.userServiceURL+"/api/v1/internal/notifications", bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		log.Printf("❌ Failed to create notification request: %v", err)
 		return fmt.Errorf("failed to create notification request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "matcha-internal-service/match-service")
+	if ns.internalKey != "" {
+		req.Header.Set("X-Internal-Key", ns.internalKey)
+	}
 
 	resp, err := ns.httpClient.Do(req)
 	if err != nil {
-		log.Printf("❌ Failed to send notification: %v", err)
+		slog.WarnContext(ctx, "failed to send notification", "to_user_id", payload.ToUserID, "kind", payload.Kind, "error", err)
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("❌ Notification service returned status: %d", resp.StatusCode)
-		return fmt.Errorf("notification service returned status: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusAccepted {
+		slog.WarnContext(ctx, "notification rejected by user-service", "to_user_id", payload.ToUserID, "kind", payload.Kind, "status", resp.StatusCode)
+		return fmt.Errorf("user service returned status: %d", resp.StatusCode)
 	}
 
-	log.Printf("✅ Notification sent successfully to user %d (type: %s)", payload.ToUserID, payload.NotifType)
+	slog.DebugContext(ctx, "notification sent", "to_user_id", payload.ToUserID, "kind", payload.Kind)
 	return nil
 }
\ No newline at end of file
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -32,18 +34,18 @@ type Cache interface {
 type InMemoryCache struct {
 	items map[string]CacheItem
 	mutex sync.RWMutex
+	name  string // label of the lookups on /metrics, unset lookups are not counted
 }
 
 // RedisCache provides Redis-based caching
 type RedisCache struct {
 	client *redis.Client
 	ctx    context.Context
+	name   string // label of the lookups on /metrics, unset lookups are not counted
 }
 
 // NewRedisCacheWithConfig creates a new Redis cache client with provided configuration
 func NewRedisCacheWithConfig(addr, password string, db int) *RedisCache {
-	log.Printf("Connecting to Redis at %s (DB: %d)", addr, db)
-	
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
@@ -53,12 +55,12 @@ func NewRedisCacheWithConfig(addr, password string, db int) *RedisCache {
 	// Test connection
 	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
-		log.Printf("Failed to connect to Redis: %v", err)
-		log.Println("Falling back to in-memory cache")
+		slog.Warn("failed to connect to redis", "addr", addr, "db", db, "error", err)
 		return nil
 	}
-	log.Printf("Successfully connected to Redis at %s", addr)
-	
+	slog.Debug("redis connected", "addr", addr, "db", db)
+	tracing.InstrumentRedis(rdb)
+
 	return &RedisCache{
 		client: rdb,
 		ctx:    ctx,
@@ -116,6 +118,12 @@ func (r *RedisCache) Set(key string, value interface{}, ttl time.Duration) error
 
 // Get retrieves a value from Redis
 func (r *RedisCache) Get(key string) (interface{}, bool) {
+	value, found := r.get(key)
+	observeLookup(r.name, found)
+	return value, found
+}
+
+func (r *RedisCache) get(key string) (interface{}, bool) {
 	data, err := r.client.Get(r.ctx, key).Result()
 	if err != nil {
 		return nil, false
@@ -163,6 +171,12 @@ func (c *InMemoryCache) Set(key string, value interface{}, ttl time.Duration) er
 
 // Get retrieves a value from cache
 func (c *InMemoryCache) Get(key string) (interface{}, bool) {
+	value, found := c.get(key)
+	observeLookup(c.name, found)
+	return value, found
+}
+
+func (c *InMemoryCache) get(key string) (interface{}, bool) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	
@@ -229,11 +243,71 @@ var (
 	PreferenceCache    Cache
 )
 
+// observeLookup counts a lookup in the named cache for its hit ratio
+func observeLookup(name string, hit bool) {
+	if name != "" {
+		metrics.CacheLookup(name, hit)
+	}
+}
+
+// nameCaches labels the lookups of the global caches on /metrics
+func nameCaches() {
+	for name, cache := range map[string]Cache{
+		"compatibility": CompatibilityCache,
+		"user_vector":   UserVectorCache,
+		"preference":    PreferenceCache,
+	} {
+		switch c := cache.(type) {
+		case *RedisCache:
+			c.name = name
+		case *InMemoryCache:
+			c.name = name
+		}
+	}
+}
+
+// redisCaches returns the global caches kept in Redis
+func redisCaches() []*RedisCache {
+	var caches []*RedisCache
+	for _, cache := range []Cache{CompatibilityCache, UserVectorCache, PreferenceCache} {
+		if c, ok := cache.(*RedisCache); ok {
+			caches = append(caches, c)
+		}
+	}
+	return caches
+}
+
+// UsesRedis reports whether the caches are kept in Redis, rather than in
+// memory by configuration or after a failed connection
+func UsesRedis() bool {
+	return len(redisCaches()) > 0
+}
+
+// PingCaches checks the connection of the caches kept in Redis
+func PingCaches(ctx context.Context) error {
+	for _, cache := range redisCaches() {
+		if err := cache.client.Ping(ctx).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseCaches closes the Redis connections of the caches
+func CloseCaches(ctx context.Context) error {
+	var firstErr error
+	for _, cache := range redisCaches() {
+		if err := cache.client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // InitializeCachesWithConfig sets up all cache instances with provided Redis config
 func InitializeCachesWithConfig(useRedis bool, redisAddr, redisPassword string, redisDB int) {
 	if useRedis {
 		// Try to use Redis for caching
-		log.Println("Initializing Redis caches with provided config...")
 		redisCache := NewRedisCacheWithConfig(redisAddr, redisPassword, redisDB)
 		
 		if redisCache != nil {
@@ -241,34 +315,30 @@ func InitializeCachesWithConfig(useRedis bool, redisAddr, redisPassword string,
 			CompatibilityCache = redisCache
 			UserVectorCache = NewRedisCacheWithConfig(redisAddr, redisPassword, redisDB)
 			PreferenceCache = NewRedisCacheWithConfig(redisAddr, redisPassword, redisDB)
-			log.Println("Redis caches initialized successfully")
+			slog.Info("caches initialized", "backend", "redis")
 		} else {
 			// Redis connection failed, fall back to in-memory
-			log.Println("Redis connection failed, falling back to in-memory caches...")
 			CompatibilityCache = NewInMemoryCache()
 			UserVectorCache = NewInMemoryCache()
 			PreferenceCache = NewInMemoryCache()
-			log.Println("In-memory caches initialized as fallback")
+			slog.Warn("redis unavailable, caches fall back to memory")
 		}
 	} else {
 		// Use in-memory caching by configuration
-		log.Println("Initializing in-memory caches...")
 		CompatibilityCache = NewInMemoryCache()
 		UserVectorCache = NewInMemoryCache()
 		PreferenceCache = NewInMemoryCache()
-		log.Println("In-memory caches initialized")
+		slog.Info("caches initialized", "backend", "memory")
 	}
+	nameCaches()
 }
 
 // InitializeCaches sets up all cache instances (uses environment variables for backward compatibility)
 func InitializeCaches() {
 	// Check if Redis should be used
 	useRedis := os.Getenv("USE_REDIS_CACHE")
-	log.Printf("USE_REDIS_CACHE environment variable: %s", useRedis)
-	
 	if useRedis == "true" || useRedis == "1" {
 		// Try to use Redis for caching
-		log.Println("Initializing Redis caches...")
 		redisCache := NewRedisCache()
 		
 		if redisCache != nil {
@@ -276,23 +346,22 @@ func InitializeCaches() {
 			CompatibilityCache = redisCache
 			UserVectorCache = NewRedisCache()
 			PreferenceCache = NewRedisCache()
-			log.Println("Redis caches initialized successfully")
+			slog.Info("caches initialized", "backend", "redis")
 		} else {
 			// Redis connection failed, fall back to in-memory
-			log.Println("Redis connection failed, falling back to in-memory caches...")
 			CompatibilityCache = NewInMemoryCache()
 			UserVectorCache = NewInMemoryCache()
 			PreferenceCache = NewInMemoryCache()
-			log.Println("In-memory caches initialized as fallback")
+			slog.Warn("redis unavailable, caches fall back to memory")
 		}
 	} else {
 		// Use in-memory caching by configuration
-		log.Println("Initializing in-memory caches...")
 		CompatibilityCache = NewInMemoryCache()
 		UserVectorCache = NewInMemoryCache()
 		PreferenceCache = NewInMemoryCache()
-		log.Println("In-memory caches initialized")
+		slog.Info("caches initialized", "backend", "memory")
 	}
+	nameCaches()
 }
 
 // Cache key generators
@@ -327,7 +396,7 @@ func CacheCompatibilityScore(userID, targetUserID int, score CompatibilityScore,
 	if CompatibilityCache != nil {
 		key := CompatibilityCacheKey(userID, targetUserID)
 		if err := CompatibilityCache.Set(key, score, ttl); err != nil {
-			log.Printf("Error caching compatibility score for users %d,%d: %v", userID, targetUserID, err)
+			slog.Warn("failed to cache compatibility score", "user_id", userID, "target_user_id", targetUserID, "error", err)
 		}
 	}
 }
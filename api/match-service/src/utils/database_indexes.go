@@ -1,15 +1,13 @@
 package utils
 
 import (
-	"log"
+	"log/slog"
 
 	"gorm.io/gorm"
 )
 
 // CreateOptimizationIndexes creates database indexes for performance optimization
 func CreateOptimizationIndexes(db *gorm.DB) error {
-	log.Println("Creating performance optimization indexes...")
-
 	// User table indexes
 	userIndexes := []string{
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_age ON users(age) WHERE age IS NOT NULL;",
@@ -52,21 +50,17 @@ func CreateOptimizationIndexes(db *gorm.DB) error {
 
 	for _, indexSQL := range allIndexes {
 		if err := db.Exec(indexSQL).Error; err != nil {
-			log.Printf("Warning: Failed to create index: %s - %v", indexSQL, err)
 			// Continue with other indexes even if one fails
-		} else {
-			log.Printf("Successfully created index")
+			slog.Warn("failed to create index", "sql", indexSQL, "error", err)
 		}
 	}
 
-	log.Println("Database indexing optimization completed")
+	slog.Info("optimization indexes created", "count", len(allIndexes))
 	return nil
 }
 
 // CreateCompositeIndexesForVectorMatching creates specialized indexes for vector matching queries
 func CreateCompositeIndexesForVectorMatching(db *gorm.DB) error {
-	log.Println("Creating vector matching composite indexes...")
-
 	vectorIndexes := []string{
 		// Composite index for candidate selection with age and location filters
 		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_matching_candidates ON users(id, age, latitude, longitude, updated_at, fame) WHERE latitude IS NOT NULL AND longitude IS NOT NULL;",
@@ -86,32 +80,26 @@ func CreateCompositeIndexesForVectorMatching(db *gorm.DB) error {
 
 	for _, indexSQL := range vectorIndexes {
 		if err := db.Exec(indexSQL).Error; err != nil {
-			log.Printf("Warning: Failed to create vector index: %s - %v", indexSQL, err)
-		} else {
-			log.Printf("Successfully created vector matching index")
+			slog.Warn("failed to create vector matching index", "sql", indexSQL, "error", err)
 		}
 	}
 
-	log.Println("Vector matching indexing completed")
+	slog.Info("vector matching indexes created", "count", len(vectorIndexes))
 	return nil
 }
 
 // AnalyzeTablesForPerformance runs ANALYZE on key tables to update statistics
 func AnalyzeTablesForPerformance(db *gorm.DB) error {
-	log.Println("Analyzing tables for query performance...")
-
 	tables := []string{"users", "user_interactions", "matches", "user_preferences"}
 	
 	for _, table := range tables {
 		analyzeSQL := "ANALYZE " + table + ";"
 		if err := db.Exec(analyzeSQL).Error; err != nil {
-			log.Printf("Warning: Failed to analyze table %s: %v", table, err)
-		} else {
-			log.Printf("Analyzed table: %s", table)
+			slog.Warn("failed to analyze table", "table", table, "error", err)
 		}
 	}
 
-	log.Println("Table analysis completed")
+	slog.Info("tables analyzed", "tables", tables)
 	return nil
 }
 
@@ -137,7 +125,7 @@ func GetQueryPerformanceStats(db *gorm.DB) (map[string]interface{}, error) {
 	`
 
 	if err := db.Raw(sizeQuery).Scan(&tableStats).Error; err != nil {
-		log.Printf("Warning: Could not get table statistics: %v", err)
+		slog.Warn("failed to get table statistics", "error", err)
 	} else {
 		stats["table_stats"] = tableStats
 	}
@@ -164,7 +152,7 @@ func GetQueryPerformanceStats(db *gorm.DB) (map[string]interface{}, error) {
 	`
 
 	if err := db.Raw(indexQuery).Scan(&indexStats).Error; err != nil {
-		log.Printf("Warning: Could not get index statistics: %v", err)
+		slog.Warn("failed to get index statistics", "error", err)
 	} else {
 		stats["index_stats"] = indexStats
 	}
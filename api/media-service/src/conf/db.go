@@ -2,11 +2,14 @@ package conf
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"media-service/src/models"
 
+	"github.com/maxg56/matcha/api/common/metrics"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -27,27 +30,34 @@ func ConnectDatabase() {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
 
-	log.Printf("Database URL: postgresql://%s:%s@%s:%s/%s", user, password, host, port, dbname)
-
 	var err error
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		slog.Error("failed to connect to the database", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Database connected successfully")
+	slog.Info("database connected", "user", user, "host", host, "port", port, "name", dbname)
+
+	if err := DB.Use(tracing.GORMPlugin{}); err != nil {
+		slog.Warn("failed to enable query tracing", "error", err)
+	}
+	if err := metrics.RegisterDB(DB, "media"); err != nil {
+		slog.Warn("failed to expose connection pool metrics", "error", err)
+	}
 
 	// Auto-migrate if enabled
 	if getEnv("AUTO_MIGRATE", "true") == "true" {
-		log.Println("Running auto-migration...")
-		err = DB.AutoMigrate(&models.Image{})
+		slog.Info("running AutoMigrate (AUTO_MIGRATE=true)")
+		err = DB.AutoMigrate(&models.Image{}, &models.Attachment{})
 		if err != nil {
-			log.Fatal("Failed to migrate database:", err)
+			slog.Error("AutoMigrate failed", "error", err)
+			os.Exit(1)
 		}
-		log.Println("Database migration completed")
+		slog.Info("AutoMigrate completed")
 	}
 }
 
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"media-service/src/conf"
+	"media-service/src/models"
+	"media-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurgeUserFilesHandler removes from disk every file uploaded by a deleted account,
+// including deactivated images, resized copies and chat attachments. Files already gone are ignored,
+// so the deletion saga of user-service can safely retry it. Internal route.
+func PurgeUserFilesHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, "Invalid user ID", 400)
+		return
+	}
+
+	var images []models.Image
+	if err := conf.DB.Where("user_id = ?", uint(userID)).Find(&images).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to fetch user media for purge", "user_id", userID, "error", err)
+		utils.RespondError(c, "Failed to fetch media", 500)
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := conf.DB.Where("user_id = ?", uint(userID)).Find(&attachments).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to fetch user attachments for purge", "user_id", userID, "error", err)
+		utils.RespondError(c, "Failed to fetch media", 500)
+		return
+	}
+
+	removed := 0
+	for _, attachment := range attachments {
+		paths := []string{filepath.Join(attachmentFolder, attachment.Filename)}
+		if attachment.ThumbnailName != nil {
+			paths = append(paths, filepath.Join(attachmentFolder, *attachment.ThumbnailName))
+		}
+		for _, path := range paths {
+			if err := os.Remove(path); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				slog.ErrorContext(c.Request.Context(), "failed to delete file", "user_id", userID, "path", path, "error", err)
+				utils.RespondError(c, "Failed to delete files", 500)
+				return
+			}
+			removed++
+		}
+	}
+
+	for _, img := range images {
+		paths := []string{filepath.Join(uploadFolder, filepath.Base(img.Filename))}
+		if img.FilePath != "" && img.FilePath != paths[0] {
+			paths = append(paths, img.FilePath)
+		}
+		for _, path := range paths {
+			if err := os.Remove(path); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				slog.ErrorContext(c.Request.Context(), "failed to delete file", "user_id", userID, "path", path, "error", err)
+				utils.RespondError(c, "Failed to delete files", 500)
+				return
+			}
+			removed++
+		}
+	}
+
+	utils.RespondSuccess(c, map[string]interface{}{
+		"user_id":       userID,
+		"files_removed": removed,
+	}, "User files purged successfully")
+}
+
+// DeleteUserMediaHandler deletes the image and attachment records of a deleted account.
+// The files must have been purged first. Idempotent, internal route.
+func DeleteUserMediaHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, "Invalid user ID", 400)
+		return
+	}
+
+	result := conf.DB.Where("user_id = ?", uint(userID)).Delete(&models.Image{})
+	if result.Error != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to delete user media", "user_id", userID, "error", result.Error)
+		utils.RespondError(c, "Failed to delete media", 500)
+		return
+	}
+
+	attachments := conf.DB.Where("user_id = ?", uint(userID)).Delete(&models.Attachment{})
+	if attachments.Error != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to delete user attachments", "user_id", userID, "error", attachments.Error)
+		utils.RespondError(c, "Failed to delete media", 500)
+		return
+	}
+
+	utils.RespondSuccess(c, map[string]interface{}{
+		"user_id":             userID,
+		"images_deleted":      result.RowsAffected,
+		"attachments_deleted": attachments.RowsAffected,
+	}, "User media deleted successfully")
+}
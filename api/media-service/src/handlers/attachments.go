@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"media-service/src/conf"
+	"media-service/src/models"
+	"media-service/src/utils"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	thumbnailSize    = 320
+	maxVoiceDuration = 5 * time.Minute
+	maxWaveformLen   = 128 // amplitude samples, 0-100 each
+	maxAttachmentIDs = 10  // attachments per message
+
+	// defaultUnclaimedTTL is how long an upload waits to be sent in a message
+	defaultUnclaimedTTL = 24 * time.Hour
+	cleanupInterval     = time.Hour
+	cleanupBatch        = 100
+)
+
+var (
+	errInvalidDuration       = errors.New("duration_ms must be between 1 and 300000")
+	errInvalidWaveform       = errors.New("waveform must be a JSON array of at most 128 values between 0 and 100")
+	errAttachmentUnavailable = errors.New("attachment not found or already sent")
+)
+
+// attachmentFolder is outside the public upload folder: attachments are only
+// served through signed URLs
+var attachmentFolder = getAttachmentFolder()
+
+func getAttachmentFolder() string {
+	if dir := os.Getenv("ATTACHMENTS_DIR"); dir != "" {
+		return dir
+	}
+	return "/app/private/attachments"
+}
+
+// UploadAttachmentHandler stores a photo or voice note to be sent in a chat
+// message. Photos get a thumbnail; voice notes need a duration_ms field and
+// accept a waveform field (JSON array of amplitudes).
+func UploadAttachmentHandler(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.RespondError(c, "No file part in request", 400)
+		return
+	}
+	if file.Size > maxFileSize {
+		utils.RespondError(c, "File too large. Maximum size is 16MB", 400)
+		return
+	}
+
+	attachment := models.Attachment{
+		UserID:   userID,
+		Filename: utils.GenerateUniqueFilename(file.Filename),
+		FileSize: file.Size,
+	}
+	switch {
+	case utils.IsAllowedFile(file.Filename):
+		attachment.Kind = models.AttachmentImage
+		attachment.MimeType = utils.GetMimeType(file)
+	case utils.VoiceMimeType(file.Filename) != "":
+		attachment.Kind = models.AttachmentVoice
+		attachment.MimeType = utils.VoiceMimeType(file.Filename)
+		if err := parseVoiceMetadata(c, &attachment); err != nil {
+			utils.RespondError(c, err.Error(), 400)
+			return
+		}
+	default:
+		utils.RespondError(c, "File type not allowed. Allowed types: jpg, jpeg, png, gif, webp, m4a, aac, mp3, ogg, opus, webm", 400)
+		return
+	}
+
+	if err := os.MkdirAll(attachmentFolder, 0750); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to create attachment directory", "error", err)
+		utils.RespondError(c, "Failed to create attachment directory", 500)
+		return
+	}
+
+	filePath := filepath.Join(attachmentFolder, attachment.Filename)
+	if err := c.SaveUploadedFile(file, filePath); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to save attachment", "user_id", userID, "error", err)
+		utils.RespondError(c, "Failed to save file", 500)
+		return
+	}
+
+	if attachment.Kind == models.AttachmentImage {
+		if err := createThumbnail(filePath, &attachment); err != nil {
+			os.Remove(filePath)
+			utils.RespondError(c, "Invalid image", 400)
+			return
+		}
+	}
+
+	if err := conf.DB.Create(&attachment).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to save attachment record", "user_id", userID, "error", err)
+		removeAttachmentFiles(attachment)
+		utils.RespondError(c, "Failed to save attachment record", 500)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "attachment uploaded", "attachment_id", attachment.ID, "user_id", userID, "kind", attachment.Kind)
+	utils.RespondSuccess(c, attachment, "Attachment uploaded successfully")
+}
+
+// parseVoiceMetadata reads the duration and waveform of a voice note
+func parseVoiceMetadata(c *gin.Context, attachment *models.Attachment) error {
+	durationMs, err := strconv.Atoi(c.PostForm("duration_ms"))
+	if err != nil || durationMs <= 0 || time.Duration(durationMs)*time.Millisecond > maxVoiceDuration {
+		return errInvalidDuration
+	}
+	attachment.DurationMs = &durationMs
+
+	if raw := c.PostForm("waveform"); raw != "" {
+		var waveform []int
+		if err := json.Unmarshal([]byte(raw), &waveform); err != nil || len(waveform) > maxWaveformLen {
+			return errInvalidWaveform
+		}
+		for _, sample := range waveform {
+			if sample < 0 || sample > 100 {
+				return errInvalidWaveform
+			}
+		}
+		attachment.Waveform = waveform
+	}
+	return nil
+}
+
+// createThumbnail decodes the image, records its size and writes a JPEG
+// thumbnail next to it
+func createThumbnail(filePath string, attachment *models.Attachment) error {
+	img, err := imaging.Open(filePath, imaging.AutoOrientation(true))
+	if err != nil {
+		return err
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	attachment.Width = &width
+	attachment.Height = &height
+
+	thumbnailName := "thumb_" + attachment.Filename + ".jpg"
+	thumbnail := imaging.Fit(img, thumbnailSize, thumbnailSize, imaging.Lanczos)
+	if err := imaging.Save(thumbnail, filepath.Join(attachmentFolder, thumbnailName), imaging.JPEGQuality(80)); err != nil {
+		return err
+	}
+	attachment.ThumbnailName = &thumbnailName
+	return nil
+}
+
+func removeAttachmentFiles(attachment models.Attachment) {
+	os.Remove(filepath.Join(attachmentFolder, attachment.Filename))
+	if attachment.ThumbnailName != nil {
+		os.Remove(filepath.Join(attachmentFolder, *attachment.ThumbnailName))
+	}
+}
+
+// ServeAttachmentHandler serves an attachment through a URL signed by
+// chat-service for a conversation participant: ?variant=original|thumbnail
+// &expires=<unix>&sig=<hex>. No other authentication, so that the URL can
+// be used directly in <img> and <audio> tags.
+func ServeAttachmentHandler(c *gin.Context) {
+	attachmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondError(c, "Invalid attachment ID", 400)
+		return
+	}
+
+	key := utils.AttachmentSigningKey()
+	if key == "" {
+		utils.RespondError(c, "Attachments are not configured", 503)
+		return
+	}
+
+	variant := c.DefaultQuery("variant", "original")
+	if !utils.VerifyAttachmentSignature(key, uint(attachmentID), variant, c.Query("expires"), c.Query("sig")) {
+		utils.RespondError(c, "Invalid or expired link", 403)
+		return
+	}
+
+	var attachment models.Attachment
+	if err := conf.DB.Where("id = ? AND conversation_id IS NOT NULL", attachmentID).First(&attachment).Error; err != nil {
+		utils.RespondError(c, "Attachment not found", 404)
+		return
+	}
+
+	filename, mimeType := attachment.Filename, attachment.MimeType
+	if variant == "thumbnail" {
+		if attachment.ThumbnailName == nil {
+			utils.RespondError(c, "Attachment has no thumbnail", 404)
+			return
+		}
+		filename, mimeType = *attachment.ThumbnailName, "image/jpeg"
+	}
+
+	filePath := filepath.Join(attachmentFolder, filepath.Base(filename))
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		slog.WarnContext(c.Request.Context(), "attachment not found on disk", "attachment_id", attachment.ID, "path", filePath)
+		utils.RespondError(c, "Attachment not found", 404)
+		return
+	}
+
+	c.Header("Content-Type", mimeType)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.File(filePath)
+}
+
+// ClaimAttachmentsHandler attaches uploads of a user to a conversation when
+// they send them in a message. Each attachment can only be sent once.
+// Internal route, called by chat-service.
+func ClaimAttachmentsHandler(c *gin.Context) {
+	var req struct {
+		UserID         uint   `json:"user_id" binding:"required"`
+		ConversationID uint   `json:"conversation_id" binding:"required"`
+		AttachmentIDs  []uint `json:"attachment_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, "Invalid request data", 400)
+		return
+	}
+	if len(req.AttachmentIDs) > maxAttachmentIDs {
+		utils.RespondError(c, "Too many attachments", 400)
+		return
+	}
+
+	var attachments []models.Attachment
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id IN ? AND user_id = ? AND conversation_id IS NULL", req.AttachmentIDs, req.UserID).
+			Order("id").
+			Find(&attachments).Error; err != nil {
+			return err
+		}
+		if len(attachments) != len(req.AttachmentIDs) {
+			return errAttachmentUnavailable
+		}
+		return tx.Model(&models.Attachment{}).
+			Where("id IN ?", req.AttachmentIDs).
+			Update("conversation_id", req.ConversationID).Error
+	})
+	if errors.Is(err, errAttachmentUnavailable) {
+		utils.RespondError(c, err.Error(), 409)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to claim attachments", "user_id", req.UserID, "conversation_id", req.ConversationID, "error", err)
+		utils.RespondError(c, "Failed to claim attachments", 500)
+		return
+	}
+
+	utils.RespondSuccess(c, gin.H{"attachments": attachments}, "Attachments claimed")
+}
+
+// DeleteAttachmentsHandler removes the records and the files of attachments
+// sent in a conversation, when their message is deleted for everyone.
+// Attachments already gone are ignored, so chat-service can retry.
+// Internal route, called by chat-service.
+func DeleteAttachmentsHandler(c *gin.Context) {
+	var req struct {
+		ConversationID uint   `json:"conversation_id" binding:"required"`
+		AttachmentIDs  []uint `json:"attachment_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, "Invalid request data", 400)
+		return
+	}
+	if len(req.AttachmentIDs) > maxAttachmentIDs {
+		utils.RespondError(c, "Too many attachments", 400)
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := conf.DB.Where("id IN ? AND conversation_id = ?", req.AttachmentIDs, req.ConversationID).
+		Find(&attachments).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to fetch attachments to delete", "conversation_id", req.ConversationID, "error", err)
+		utils.RespondError(c, "Failed to delete attachments", 500)
+		return
+	}
+	if err := deleteAttachments(attachments); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to delete attachments", "conversation_id", req.ConversationID, "error", err)
+		utils.RespondError(c, "Failed to delete attachments", 500)
+		return
+	}
+
+	utils.RespondSuccess(c, gin.H{"deleted": len(attachments)}, "Attachments deleted")
+}
+
+// deleteAttachments removes the records, then the files, so that a file is
+// never served without its record
+func deleteAttachments(attachments []models.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(attachments))
+	for _, attachment := range attachments {
+		ids = append(ids, attachment.ID)
+	}
+	if err := conf.DB.Where("id IN ?", ids).Delete(&models.Attachment{}).Error; err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		removeAttachmentFiles(attachment)
+	}
+	return nil
+}
+
+// RunAttachmentCleanup deletes, every hour until ctx is cancelled, the
+// uploads that were never sent in a message after ATTACHMENT_UNCLAIMED_TTL
+// (24h by default)
+func RunAttachmentCleanup(ctx context.Context) {
+	ttl := defaultUnclaimedTTL
+	if value, err := time.ParseDuration(os.Getenv("ATTACHMENT_UNCLAIMED_TTL")); err == nil && value > 0 {
+		ttl = value
+	}
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		if removed := PurgeUnclaimedAttachments(ctx, ttl); removed > 0 {
+			slog.InfoContext(ctx, "unclaimed attachments deleted", "count", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeUnclaimedAttachments deletes the uploads older than ttl that were
+// never sent in a message, and returns how many were deleted
+func PurgeUnclaimedAttachments(ctx context.Context, ttl time.Duration) int {
+	removed := 0
+	for ctx.Err() == nil {
+		var attachments []models.Attachment
+		if err := conf.DB.WithContext(ctx).
+			Where("conversation_id IS NULL AND created_at < ?", time.Now().Add(-ttl)).
+			Order("id").
+			Limit(cleanupBatch).
+			Find(&attachments).Error; err != nil {
+			slog.ErrorContext(ctx, "failed to fetch unclaimed attachments", "error", err)
+			break
+		}
+		for _, attachment := range attachments {
+			// Unless it was sent in the meantime
+			result := conf.DB.Where("id = ? AND conversation_id IS NULL", attachment.ID).Delete(&models.Attachment{})
+			if result.Error != nil {
+				slog.ErrorContext(ctx, "failed to delete unclaimed attachment", "attachment_id", attachment.ID, "error", result.Error)
+				return removed
+			}
+			if result.RowsAffected == 1 {
+				removeAttachmentFiles(attachment)
+				removed++
+			}
+		}
+		if len(attachments) < cleanupBatch {
+			break
+		}
+	}
+	return removed
+}
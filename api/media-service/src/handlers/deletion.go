@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"os"
 
 	"media-service/src/conf"
@@ -42,7 +42,7 @@ func DeleteFileHandler(c *gin.Context) {
 	image.IsProfile = false // Remove profile status if it was set
 	if err := tx.Save(&image).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Failed to soft delete image: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to soft delete image", "user_id", userID, "filename", filename, "error", err)
 		utils.RespondError(c, "Failed to delete image", 500)
 		return
 	}
@@ -52,11 +52,11 @@ func DeleteFileHandler(c *gin.Context) {
 
 	// Then try to delete physical file (non-critical if it fails)
 	if err := os.Remove(image.FilePath); err != nil {
-		log.Printf("Warning: Failed to delete physical file %s: %v", image.FilePath, err)
+		slog.WarnContext(c.Request.Context(), "failed to delete physical file", "path", image.FilePath, "error", err)
 		// Don't return error here as the database operation succeeded
 	}
 
-	log.Printf("File deleted successfully: %s for user %v", filename, userID)
+	slog.InfoContext(c.Request.Context(), "file deleted", "user_id", userID, "filename", filename)
 	utils.RespondSuccess(c, map[string]interface{}{
 		"filename": filename,
 		"deleted":  true,
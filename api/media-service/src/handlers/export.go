@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"media-service/src/conf"
+	"media-service/src/models"
+	"media-service/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportUserMediaHandler returns the media-service section of a GDPR export:
+// metadata of every image the user uploaded, including deactivated ones, and
+// of the attachments they sent in chat.
+// Internal route, called by user-service.
+func ExportUserMediaHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, "Invalid user ID", 400)
+		return
+	}
+
+	var images []models.Image
+	if err := conf.DB.Where("user_id = ?", uint(userID)).Order("created_at").Find(&images).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to fetch user media for export", "user_id", userID, "error", err)
+		utils.RespondError(c, "Failed to fetch media", 500)
+		return
+	}
+
+	response := make([]map[string]interface{}, 0, len(images))
+	for _, img := range images {
+		response = append(response, img.ToMap())
+	}
+
+	var attachments []models.Attachment
+	if err := conf.DB.Where("user_id = ?", uint(userID)).Order("created_at").Find(&attachments).Error; err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to fetch user attachments for export", "user_id", userID, "error", err)
+		utils.RespondError(c, "Failed to fetch media", 500)
+		return
+	}
+
+	// The stored filename is hidden from the chat API, the export lists it so
+	// that user-service can download the original file
+	exported := make([]exportedAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		exported = append(exported, exportedAttachment{Attachment: attachment, Filename: filepath.Base(attachment.Filename)})
+	}
+
+	utils.RespondSuccess(c, gin.H{"images": response, "attachments": exported}, "User media exported successfully")
+}
+
+// exportedAttachment is a chat attachment with the name of its original file
+type exportedAttachment struct {
+	models.Attachment
+	Filename string `json:"filename"`
+}
+
+// ExportUserFileHandler streams the original file of one of the user's images,
+// whether or not it is still active, or of one of the attachments they sent in
+// chat. Internal route, called by user-service.
+func ExportUserFileHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, "Invalid user ID", 400)
+		return
+	}
+
+	filename := c.Param("filename")
+	var filePath, mimeType string
+	var imageRecord models.Image
+	var attachment models.Attachment
+	switch {
+	case conf.DB.Where("filename = ? AND user_id = ?", filename, uint(userID)).First(&imageRecord).Error == nil:
+		filePath = filepath.Join("/app/uploads", filepath.Base(imageRecord.Filename))
+		mimeType = imageRecord.MimeType
+	case conf.DB.Where("filename = ? AND user_id = ?", filename, uint(userID)).First(&attachment).Error == nil:
+		filePath = filepath.Join(attachmentFolder, filepath.Base(attachment.Filename))
+		mimeType = attachment.MimeType
+	default:
+		utils.RespondError(c, "File not found", 404)
+		return
+	}
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		slog.WarnContext(c.Request.Context(), "file not found on disk", "user_id", userID, "path", filePath)
+		utils.RespondError(c, "File not found", 404)
+		return
+	}
+
+	c.Header("Content-Type", mimeType)
+	c.File(filePath)
+}
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -77,7 +77,7 @@ func ResizeImageHandler(c *gin.Context) {
 	// Open original image
 	originalImg, err := imaging.Open(originalImage.FilePath)
 	if err != nil {
-		log.Printf("Failed to open original image: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to open original image", "filename", request.Filename, "error", err)
 		utils.RespondError(c, "Failed to open original image", 500)
 		return
 	}
@@ -101,7 +101,7 @@ func ResizeImageHandler(c *gin.Context) {
 	}
 
 	if saveErr != nil {
-		log.Printf("Failed to save resized image: %v", saveErr)
+		slog.ErrorContext(c.Request.Context(), "failed to save resized image", "filename", resizedFilename, "error", saveErr)
 		utils.RespondError(c, "Failed to save resized image", 500)
 		return
 	}
@@ -109,7 +109,7 @@ func ResizeImageHandler(c *gin.Context) {
 	// Get file info
 	fileInfo, err := os.Stat(resizedFilePath)
 	if err != nil {
-		log.Printf("Failed to get file info: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to get file info", "filename", resizedFilename, "error", err)
 		utils.RespondError(c, "Failed to get file info", 500)
 		return
 	}
@@ -128,7 +128,7 @@ func ResizeImageHandler(c *gin.Context) {
 	}
 
 	if err := conf.DB.Create(&resizedRecord).Error; err != nil {
-		log.Printf("Failed to save resized image record: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to save resized image record", "filename", resizedFilename, "error", err)
 		// Clean up file if database save failed
 		os.Remove(resizedFilePath)
 		utils.RespondError(c, "Failed to save resized image record", 500)
@@ -138,7 +138,7 @@ func ResizeImageHandler(c *gin.Context) {
 	// Generate public URL
 	fileURL := fmt.Sprintf("/api/v1/media/get/%s", resizedFilename)
 
-	log.Printf("Image resized successfully: %s -> %s for user %v", request.Filename, resizedFilename, userID)
+	slog.InfoContext(c.Request.Context(), "image resized", "user_id", userID, "filename", request.Filename, "resized_filename", resizedFilename)
 
 	// Return response
 	response := map[string]interface{}{
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -24,7 +24,7 @@ func GetFileHandler(c *gin.Context) {
 	// Verify file exists in database and is active
 	var imageRecord models.Image
 	if err := conf.DB.Where("filename = ? AND is_active = ?", filename, true).First(&imageRecord).Error; err != nil {
-		log.Printf("File not found in database: %s", filename)
+		slog.DebugContext(c.Request.Context(), "file not found in database", "filename", filename)
 		utils.RespondError(c, "File not found", 404)
 		return
 	}
@@ -32,7 +32,7 @@ func GetFileHandler(c *gin.Context) {
 	// Check if file exists on disk
 	filePath := filepath.Join("/app/uploads", filename)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		log.Printf("File not found on disk: %s", filePath)
+		slog.WarnContext(c.Request.Context(), "file not found on disk", "path", filePath)
 		utils.RespondError(c, "File not found", 404)
 		return
 	}
@@ -59,11 +59,11 @@ func ServeUploadHandler(c *gin.Context) {
 
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		log.Printf("File not found: %s", filePath)
+		slog.DebugContext(c.Request.Context(), "file not found", "path", filePath)
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
 
-	log.Printf("Serving file: %s", filePath)
+	slog.DebugContext(c.Request.Context(), "serving file", "path", filePath)
 	c.File(filePath)
 }
\ No newline at end of file
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -62,14 +62,14 @@ func UploadHandler(c *gin.Context) {
 
 	// Create upload directory if it doesn't exist
 	if err := os.MkdirAll(uploadFolder, 0755); err != nil {
-		log.Printf("Failed to create upload directory: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to create upload directory", "error", err)
 		utils.RespondError(c, "Failed to create upload directory", 500)
 		return
 	}
 
 	// Save file to disk
 	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		log.Printf("Failed to save file: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to save file", "user_id", userID, "error", err)
 		utils.RespondError(c, "Failed to save file", 500)
 		return
 	}
@@ -101,7 +101,7 @@ func UploadHandler(c *gin.Context) {
 
 	// Save to database
 	if err := conf.DB.Create(&imageRecord).Error; err != nil {
-		log.Printf("Failed to save image record: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to save image record", "user_id", userID, "error", err)
 		// Clean up file if database save failed
 		os.Remove(filePath)
 		utils.RespondError(c, "Failed to save image record", 500)
@@ -111,7 +111,7 @@ func UploadHandler(c *gin.Context) {
 	// Generate public URL
 	fileURL := fmt.Sprintf("/api/v1/media/get/%s", uniqueFilename)
 
-	log.Printf("File uploaded successfully: %s for user %v", uniqueFilename, userID)
+	slog.InfoContext(c.Request.Context(), "file uploaded", "user_id", userID, "filename", uniqueFilename)
 
 	// Return response
 	response := map[string]interface{}{
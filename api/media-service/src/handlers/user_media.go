@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"strconv"
 
 	"media-service/src/conf"
@@ -23,7 +23,7 @@ func ListUserMediaHandler(c *gin.Context) {
 	var images []models.Image
 	if err := conf.DB.Where("user_id = ? AND is_active = ?", userID, true).
 		Order("created_at DESC").Find(&images).Error; err != nil {
-		log.Printf("Failed to fetch user media: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to fetch user media", "user_id", userID, "error", err)
 		utils.RespondError(c, "Failed to fetch media", 500)
 		return
 	}
@@ -49,7 +49,7 @@ func GetUserMediaHandler(c *gin.Context) {
 	var images []models.Image
 	if err := conf.DB.Where("user_id = ? AND is_active = ?", uint(userID), true).
 		Order("is_profile DESC, created_at DESC").Find(&images).Error; err != nil {
-		log.Printf("Failed to fetch user media: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to fetch user media", "user_id", userID, "error", err)
 		utils.RespondError(c, "Failed to fetch media", 500)
 		return
 	}
@@ -92,7 +92,7 @@ func SetProfileImageHandler(c *gin.Context) {
 		Where("user_id = ?", userID).
 		Update("is_profile", false).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Failed to unset profile images: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to unset profile images", "user_id", userID, "error", err)
 		utils.RespondError(c, "Failed to update profile image", 500)
 		return
 	}
@@ -109,7 +109,7 @@ func SetProfileImageHandler(c *gin.Context) {
 	image.IsProfile = true
 	if err := tx.Save(&image).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Failed to set profile image: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to set profile image", "user_id", userID, "image_id", request.ImageID, "error", err)
 		utils.RespondError(c, "Failed to update profile image", 500)
 		return
 	}
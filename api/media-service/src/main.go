@@ -1,8 +1,8 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"log/slog"
 	"os"
 
 	"media-service/src/conf"
@@ -10,26 +10,52 @@ import (
 	"media-service/src/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 func main() {
+	logging.Init("media-service")
+
+	// Initialize tracing (OTEL_TRACES_EXPORTER)
+	shutdownTracing, err := tracing.Init("media-service")
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	lc := server.New("media-service")
+
 	// Initialize database
 	conf.ConnectDatabase()
+	lc.AddCheck("database", server.DBCheck(conf.DB))
+	lc.OnClose("database", server.CloseDB(conf.DB))
+
+	// Uploads never sent in a message are deleted after ATTACHMENT_UNCLAIMED_TTL
+	lc.Go("attachment-cleanup", handlers.RunAttachmentCleanup)
 
 	// Create upload directory if it doesn't exist
 	uploadDir := "/app/uploads"
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		log.Printf("Warning: Failed to create upload directory: %v", err)
+		slog.Warn("failed to create upload directory", "dir", uploadDir, "error", err)
 	}
 
 	// Initialize Gin router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), logging.Middleware())
+	r.Use(tracing.Middleware("media-service"))
+	metrics.Register(r)
 
 	// Set max multipart memory (16MB)
 	r.MaxMultipartMemory = 16 << 20
 
-	// Health check (no auth required)
+	// Health check, liveness and readiness probes (no auth required)
 	r.GET("/health", handlers.HealthCheckHandler)
+	lc.RegisterProbes(r)
 
 	// API routes
 	api := r.Group("/api/v1")
@@ -38,8 +64,9 @@ func main() {
 		{
 			// Public routes (no auth required)
 			media.GET("/get/:filename", handlers.GetFileHandler)
-			media.GET("/uploads/:filename", handlers.ServeUploadHandler) // For compatibility
-			media.GET("/user/:user_id", handlers.GetUserMediaHandler)   // Public user media
+			media.GET("/uploads/:filename", handlers.ServeUploadHandler)   // For compatibility
+			media.GET("/user/:user_id", handlers.GetUserMediaHandler)      // Public user media
+			media.GET("/attachments/:id", handlers.ServeAttachmentHandler) // Signed URL, chat participants only
 
 			// Protected routes (auth required)
 			authorized := media.Group("")
@@ -47,19 +74,31 @@ func main() {
 			{
 				authorized.POST("/upload", handlers.UploadHandler)
 				authorized.DELETE("/delete/:filename", handlers.DeleteFileHandler)
+				authorized.POST("/attachments", handlers.UploadAttachmentHandler)
 			}
 		}
 	}
 
+	// Internal routes (called by other services)
+	internal := r.Group("/api/v1/internal")
+	internal.Use(internalapi.Middleware())
+	{
+		internal.POST("/attachments/claim", handlers.ClaimAttachmentsHandler)
+		internal.POST("/attachments/delete", handlers.DeleteAttachmentsHandler)
+		internal.GET("/users/:user_id/export", handlers.ExportUserMediaHandler)
+		internal.GET("/users/:user_id/files/:filename", handlers.ExportUserFileHandler)
+		internal.DELETE("/users/:user_id/files", handlers.PurgeUserFilesHandler)
+		internal.DELETE("/users/:user_id", handlers.DeleteUserMediaHandler)
+	}
+
 	// Get port from environment
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8006"
 	}
 
-	log.Printf("Media service starting on port %s", port)
-	log.Printf("Upload directory: %s", uploadDir)
+	slog.Info("media service starting", "port", port, "upload_dir", uploadDir)
 
-	// Start server
-	log.Fatal(http.ListenAndServe(":"+port, r))
-}
\ No newline at end of file
+	// Start server; uploads in progress are finished before exiting
+	lc.Run(":"+port, r)
+}
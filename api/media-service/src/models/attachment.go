@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of chat attachments
+const (
+	AttachmentImage = "image"
+	AttachmentVoice = "voice"
+)
+
+// Attachment is a file sent in a chat message. It is stored outside the
+// public upload folder and only served through URLs signed by chat-service.
+type Attachment struct {
+	ID             uint      `gorm:"primaryKey;column:id" json:"id"`
+	UserID         uint      `gorm:"column:user_id;not null;index" json:"user_id"`
+	ConversationID *uint     `gorm:"column:conversation_id;index" json:"conversation_id"` // set once sent in a message
+	Kind           string    `gorm:"column:kind;not null;size:10" json:"kind"`
+	Filename       string    `gorm:"column:filename;not null;unique;size:255" json:"-"`
+	ThumbnailName  *string   `gorm:"column:thumbnail_name;size:255" json:"-"`
+	MimeType       string    `gorm:"column:mime_type;not null;size:100" json:"mime_type"`
+	FileSize       int64     `gorm:"column:file_size;not null" json:"file_size"`
+	Width          *int      `gorm:"column:width" json:"width,omitempty"`
+	Height         *int      `gorm:"column:height" json:"height,omitempty"`
+	DurationMs     *int      `gorm:"column:duration_ms" json:"duration_ms,omitempty"`
+	Waveform       []int     `gorm:"column:waveform;type:text;serializer:json" json:"waveform,omitempty"`
+	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (Attachment) TableName() string {
+	return "chat_attachments"
+}
@@ -36,4 +36,4 @@ func RequireAuth() gin.HandlerFunc {
 		c.Set("user_id", userID)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
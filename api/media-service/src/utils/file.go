@@ -17,6 +17,16 @@ var allowedExtensions = map[string]bool{
 	".webp": true,
 }
 
+// Voice note formats recorded by the browsers and mobile apps
+var voiceMimeTypes = map[string]string{
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".webm": "audio/webm",
+}
+
 // IsAllowedFile checks if the file extension is allowed
 func IsAllowedFile(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -55,4 +65,9 @@ func GetMimeType(file *multipart.FileHeader) string {
 	default:
 		return "image/jpeg"
 	}
-}
\ No newline at end of file
+}
+// VoiceMimeType returns the MIME type of a voice note file, or "" when the
+// extension is not an accepted audio format
+func VoiceMimeType(filename string) string {
+	return voiceMimeTypes[strings.ToLower(filepath.Ext(filename))]
+}
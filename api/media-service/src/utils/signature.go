@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// AttachmentSigningKey returns the secret shared with chat-service to sign
+// attachment URLs (ATTACHMENT_URL_SECRET). Attachments cannot be served
+// without it.
+func AttachmentSigningKey() string {
+	return os.Getenv("ATTACHMENT_URL_SECRET")
+}
+
+// VerifyAttachmentSignature checks a signed attachment URL: sig must be the
+// HMAC-SHA256 of "<id>:<variant>:<expires>" and expires must be in the future
+func VerifyAttachmentSignature(key string, attachmentID uint, variant, expires, sig string) bool {
+	if key == "" {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	provided, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%d:%s:%d", attachmentID, variant, expiresAt)
+	return hmac.Equal(provided, mac.Sum(nil))
+}
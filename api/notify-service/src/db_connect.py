@@ -1,23 +0,0 @@
-import os
-import psycopg2
-from dotenv import load_dotenv
-
-def db_connection():
-    # Charger les variables depuis le .env
-    load_dotenv()
-
-    DB_HOST = os.getenv("DB_HOST")
-    DB_PORT = os.getenv("DB_PORT", 5432)
-    DB_NAME = os.getenv("DB_NAME")
-    DB_USER = os.getenv("DB_USER")
-    DB_PASS = os.getenv("DB_PASSWORD")
-
-    # Connexion PostgreSQL
-    conn = psycopg2.connect(
-        host=DB_HOST,
-        port=DB_PORT,
-        dbname=DB_NAME,
-        user=DB_USER,
-        password=DB_PASS
-    )
-    return conn
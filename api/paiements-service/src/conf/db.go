@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
 	"github.com/matcha/api/paiements-service/src/models"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -70,10 +72,16 @@ func InitDatabase() error {
 	})
 
 	if err != nil {
-		log.Printf("Failed to connect to database: %v", err)
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := DB.Use(tracing.GORMPlugin{}); err != nil {
+		slog.Warn("failed to enable query tracing", "error", err)
+	}
+	if err := metrics.RegisterDB(DB, "paiements"); err != nil {
+		slog.Warn("failed to expose connection pool metrics", "error", err)
+	}
+
 	// Configuration de la pool de connexions
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -90,14 +98,14 @@ func InitDatabase() error {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("Connected to database successfully")
+	slog.Info("database connected")
 
 	// Auto-migration si activée
 	if getEnv("AUTO_MIGRATE", "false") == "true" {
 		if err := runMigrations(); err != nil {
-			log.Printf("Warning: Auto-migration failed: %v", err)
+			slog.Warn("AutoMigrate failed", "error", err)
 		} else {
-			log.Println("Auto-migration completed successfully")
+			slog.Info("AutoMigrate completed")
 		}
 	}
 
@@ -108,7 +116,7 @@ func InitDatabase() error {
 func runMigrations() error {
 	// Créer les types enum PostgreSQL s'ils n'existent pas
 	if err := createEnumTypes(); err != nil {
-		log.Printf("Warning: Failed to create enum types: %v", err)
+		slog.Warn("failed to create enum types", "error", err)
 	}
 
 	// Exécuter les migrations GORM
@@ -132,12 +140,10 @@ func createEnumTypes() error {
 
 	for _, query := range enumQueries {
 		if err := DB.Exec(query).Error; err != nil {
-			log.Printf("Failed to create enum type: %s - %v", query, err)
-			return err
+			return fmt.Errorf("failed to create enum type (%s): %w", query, err)
 		}
 	}
 
-	log.Println("Enum types created successfully")
 	return nil
 }
 
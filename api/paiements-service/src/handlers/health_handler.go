@@ -38,40 +38,3 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 
 	c.JSON(statusCode, health)
 }
-
-// ReadinessCheck vérifie si le service est prêt à recevoir du trafic
-func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
-	ready := true
-	checks := gin.H{}
-
-	// Vérifier la base de données
-	if conf.IsConnected() {
-		checks["database"] = "ready"
-	} else {
-		checks["database"] = "not_ready"
-		ready = false
-	}
-
-	// Ajouter d'autres vérifications si nécessaire
-	checks["stripe_config"] = "ready" // Assume que la config Stripe est OK si le service démarre
-
-	result := gin.H{
-		"ready":  ready,
-		"checks": checks,
-	}
-
-	statusCode := http.StatusOK
-	if !ready {
-		statusCode = http.StatusServiceUnavailable
-	}
-
-	c.JSON(statusCode, result)
-}
-
-// LivenessCheck vérifie si le service est vivant
-func (h *HealthHandler) LivenessCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"alive":   true,
-		"service": "paiements-service",
-	})
-}
\ No newline at end of file
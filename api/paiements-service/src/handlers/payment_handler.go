@@ -216,4 +216,31 @@ func (h *PaymentHandler) SyncUserPayments(c *gin.Context) {
 		"data":    result,
 		"message": fmt.Sprintf("Payment synchronization completed for user %d", userID),
 	})
-}
\ No newline at end of file
+}
+
+// ExportUserData renvoie la section paiements de l'export RGPD d'un utilisateur
+// Route interne, appelée par user-service
+func (h *PaymentHandler) ExportUserData(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid user ID",
+		})
+		return
+	}
+
+	export, err := h.paymentService.GetUserDataExport(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
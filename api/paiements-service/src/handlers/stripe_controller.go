@@ -60,7 +60,7 @@ func (sc *StripeController) CreateCheckoutSession(c *gin.Context) {
 	}
 
 	// Utiliser le nouveau service Stripe
-	session, err := sc.stripeService.CreateCheckoutSession(userID, planType, "user@example.com")
+	session, err := sc.stripeService.CreateCheckoutSession(c.Request.Context(), userID, planType, "user@example.com")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -79,7 +79,7 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	userEmail := "user@example.com" // Placeholder
 
 	// Créer la session de checkout avec le nouveau service
-	checkoutSession, err := h.checkoutService.CreateCheckoutSession(uint(userID), planType, userEmail)
+	checkoutSession, err := h.checkoutService.CreateCheckoutSession(c.Request.Context(), uint(userID), planType, userEmail)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -177,7 +177,7 @@ func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
 	}
 
 	// Annuler l'abonnement
-	err = h.subscriptionService.CancelSubscription(uint(userID), req.AtPeriodEnd)
+	err = h.subscriptionService.CancelSubscription(c.Request.Context(), uint(userID), req.AtPeriodEnd)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -197,6 +197,35 @@ func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
 	})
 }
 
+// CancelForAccountDeletion annule immédiatement les abonnements d'un compte supprimé
+// Route interne, appelée par la saga de suppression de user-service
+func (h *SubscriptionHandler) CancelForAccountDeletion(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid user ID",
+		})
+		return
+	}
+
+	canceled, err := h.subscriptionService.CancelForAccountDeletion(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"canceled_subscriptions": canceled,
+		},
+	})
+}
+
 // CheckPremiumStatus vérifie si l'utilisateur a un statut premium actif
 func (h *SubscriptionHandler) CheckPremiumStatus(c *gin.Context) {
 	// Récupérer l'ID utilisateur depuis le header JWT
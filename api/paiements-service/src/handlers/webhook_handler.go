@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
@@ -31,37 +32,32 @@ func NewWebhookHandler() *WebhookHandler {
 
 // HandleStripeWebhook traite les webhooks Stripe
 func (h *WebhookHandler) HandleStripeWebhook(c *gin.Context) {
-	log.Printf("🔍 [DEBUG] Webhook received from IP: %s", c.ClientIP())
+	ctx := c.Request.Context()
 
 	// Lire le corps de la requête
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("❌ Failed to read webhook body: %v", err)
+		slog.WarnContext(ctx, "failed to read stripe webhook body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to read request body",
 		})
 		return
 	}
 
-	log.Printf("🔍 [DEBUG] Webhook body length: %d bytes", len(body))
-
 	// Récupérer la signature Stripe
 	signature := c.GetHeader("Stripe-Signature")
 	if signature == "" {
-		log.Printf("❌ Missing Stripe-Signature header")
-		log.Printf("🔍 [DEBUG] Available headers: %v", c.Request.Header)
+		slog.WarnContext(ctx, "stripe webhook without signature header", "client_ip", c.ClientIP())
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Missing Stripe signature",
 		})
 		return
 	}
 
-	log.Printf("🔍 [DEBUG] Stripe signature found: %s", signature)
-
 	// Vérifier la signature du webhook
 	webhookSecret := h.stripeService.GetWebhookSecret()
 	if webhookSecret == "" {
-		log.Printf("⚠️  Webhook secret not configured - running in development mode")
+		slog.WarnContext(ctx, "stripe webhook secret not configured, skipping signature verification")
 	}
 
 	var event stripe.Event
@@ -70,50 +66,37 @@ func (h *WebhookHandler) HandleStripeWebhook(c *gin.Context) {
 	if webhookSecret != "" {
 		eventFromWebhook, webhookErr := webhook.ConstructEvent(body, signature, webhookSecret)
 		if webhookErr != nil {
-			log.Printf("⚠️  Failed to verify webhook signature: %v", webhookErr)
-			log.Printf("🔧 Attempting to parse webhook without signature verification...")
+			slog.WarnContext(ctx, "failed to verify stripe webhook signature, parsing without verification", "error", webhookErr)
 
 			// Parser directement le JSON
 			if parseErr := json.Unmarshal(body, &event); parseErr != nil {
-				log.Printf("❌ Failed to parse webhook JSON: %v", parseErr)
+				slog.WarnContext(ctx, "failed to parse stripe webhook", "error", parseErr)
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Invalid JSON",
 				})
 				return
 			}
-			log.Printf("🔧 Webhook parsed without signature verification")
 		} else {
 			event = eventFromWebhook
 		}
 	} else {
 		// Pas de secret configuré, parser directement
 		if parseErr := json.Unmarshal(body, &event); parseErr != nil {
-			log.Printf("❌ Failed to parse webhook JSON: %v", parseErr)
+			slog.WarnContext(ctx, "failed to parse stripe webhook", "error", parseErr)
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Invalid JSON",
 			})
 			return
 		}
-		log.Printf("🔧 Webhook parsed without signature verification (no secret configured)")
 	}
 
-	// Logger l'événement reçu avec plus de détails
-	log.Printf("🔔 Received Stripe webhook: %s (ID: %s)", event.Type, event.ID)
-
-	// Logger des détails supplémentaires selon le type d'événement
-	switch event.Type {
-	case "payment_intent.succeeded", "payment_intent.payment_failed":
-		log.Printf("💳 Payment event details: PaymentIntent processing for event %s", event.ID)
-	case "invoice.payment_succeeded", "invoice.payment_failed":
-		log.Printf("🧾 Invoice event details: Invoice processing for event %s", event.ID)
-	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
-		log.Printf("📋 Subscription event details: Subscription processing for event %s", event.ID)
-	}
+	slog.InfoContext(ctx, "stripe webhook received", "event_id", event.ID, "type", event.Type)
 
-	// Traiter l'événement de manière asynchrone
+	// Traiter l'événement de manière asynchrone, sans dépendre de la durée de la requête
+	asyncCtx := context.WithoutCancel(ctx)
 	go func() {
-		if err := h.eventService.ProcessWebhookEvent(&event); err != nil {
-			log.Printf("Failed to process webhook event %s: %v", event.ID, err)
+		if err := h.eventService.ProcessWebhookEvent(asyncCtx, &event); err != nil {
+			slog.ErrorContext(asyncCtx, "failed to process stripe webhook event", "event_id", event.ID, "type", event.Type, "error", err)
 		}
 	}()
 
@@ -152,7 +135,7 @@ func (h *WebhookHandler) GetWebhookEvents(c *gin.Context) {
 	// Récupérer l'historique des événements
 	events, err := h.eventService.GetEventHistory(limit, offset)
 	if err != nil {
-		log.Printf("Failed to get event history: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to get webhook event history", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve event history",
 		})
@@ -180,8 +163,8 @@ func (h *WebhookHandler) RetryFailedEvents(c *gin.Context) {
 	}
 
 	// Relancer les événements échoués
-	if err := h.eventService.RetryFailedEvents(); err != nil {
-		log.Printf("Failed to retry failed events: %v", err)
+	if err := h.eventService.RetryFailedEvents(c.Request.Context()); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to retry webhook events", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retry failed events",
 		})
@@ -247,11 +230,9 @@ func (h *WebhookHandler) TestWebhook(c *gin.Context) {
 		return
 	}
 
-	log.Printf("🧪 Processing test webhook event: %s", req.EventType)
-
 	// Traiter l'événement de test
-	if err := h.eventService.ProcessWebhookEvent(testEvent); err != nil {
-		log.Printf("Test webhook failed: %v", err)
+	if err := h.eventService.ProcessWebhookEvent(c.Request.Context(), testEvent); err != nil {
+		slog.WarnContext(c.Request.Context(), "test webhook failed", "type", req.EventType, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -276,10 +257,8 @@ func (h *WebhookHandler) runSimpleWebhookTest(c *gin.Context) {
 		},
 	}
 
-	log.Printf("🧪 Processing simple test webhook event")
-
-	if err := h.eventService.ProcessWebhookEvent(testEvent); err != nil {
-		log.Printf("Test webhook failed: %v", err)
+	if err := h.eventService.ProcessWebhookEvent(c.Request.Context(), testEvent); err != nil {
+		slog.WarnContext(c.Request.Context(), "test webhook failed", "type", testEvent.Type, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -300,7 +279,7 @@ func (h *WebhookHandler) isAdminRequest(c *gin.Context) bool {
 	expectedKey := getEnv("INTERNAL_API_KEY", "")
 
 	if expectedKey == "" {
-		log.Printf("INTERNAL_API_KEY not configured")
+		slog.WarnContext(c.Request.Context(), "INTERNAL_API_KEY not configured")
 		return false
 	}
 
@@ -1,13 +1,16 @@
 package main
 
 import (
-    "log"
+    "context"
+    "log/slog"
     "os"
-    "os/signal"
-    "syscall"
 
     "github.com/gin-gonic/gin"
     "github.com/joho/godotenv"
+    "github.com/maxg56/matcha/api/common/logging"
+    "github.com/maxg56/matcha/api/common/metrics"
+    "github.com/maxg56/matcha/api/common/server"
+    "github.com/maxg56/matcha/api/common/tracing"
     "github.com/matcha/api/paiements-service/src/conf"
     "github.com/matcha/api/paiements-service/src/middleware"
     "github.com/matcha/api/paiements-service/src/routes"
@@ -15,32 +18,50 @@ import (
 
 func main() {
     // Charger le .env
-    if err := godotenv.Load("../../../.env"); err != nil {
-        log.Println("No .env file found, relying on environment variables")
+    envErr := godotenv.Load("../../../.env")
+    logging.Init("paiements-service")
+    if envErr != nil {
+        slog.Info("no .env file found, relying on environment variables")
     }
 
+    // Initialiser le tracing (OTEL_TRACES_EXPORTER)
+    shutdownTracing, err := tracing.Init("paiements-service")
+    if err != nil {
+        slog.Error("failed to initialize tracing", "error", err)
+        os.Exit(1)
+    }
+    defer shutdownTracing(context.Background())
+
+    lc := server.New("paiements-service")
+
     // Initialiser la base de données
     if err := conf.InitDatabase(); err != nil {
-        log.Fatalf("Failed to initialize database: %v", err)
+        slog.Error("failed to initialize database", "error", err)
+        os.Exit(1)
     }
-    defer conf.CloseDatabase()
+    lc.AddCheck("database", server.DBCheck(conf.DB))
+    lc.OnClose("database", func(ctx context.Context) error { return conf.CloseDatabase() })
 
     // Configuration Gin selon l'environnement
     if os.Getenv("GIN_MODE") == "release" {
         gin.SetMode(gin.ReleaseMode)
     }
 
-    r := gin.Default()
+    r := gin.New()
 
     // Configuration des proxies de confiance
     r.SetTrustedProxies([]string{"127.0.0.1", "::1", "172.16.0.0/12", "192.168.0.0/16", "10.0.0.0/8"})
 
     // Middlewares globaux
+    r.Use(gin.Recovery(), logging.Middleware())
+    r.Use(tracing.Middleware("paiements-service"))
+    metrics.Register(r)
     r.Use(middleware.CORSMiddleware())
     r.Use(middleware.RequestLoggerMiddleware())
 
-    // Configurer toutes les routes
+    // Configurer toutes les routes, et les sondes de disponibilité
     routes.SetupRoutes(r)
+    lc.RegisterProbes(r)
 
     // Routes configurées via routes.SetupRoutes() avec le système complet
 
@@ -50,23 +71,10 @@ func main() {
         port = "8085" // port par défaut pour le service paiements
     }
 
-    log.Printf("🚀 Paiements service started on port %s", port)
-    log.Printf("📊 Health check: http://localhost:%s/health", port)
-    log.Printf("🔗 Stripe webhooks: http://localhost:%s/api/stripe/webhook", port)
-
-    // Démarrer le serveur dans une goroutine
-    go func() {
-        if err := r.Run(":" + port); err != nil {
-            log.Fatalf("Failed to start server: %v", err)
-        }
-    }()
-
-    // Attendre le signal d'arrêt graceful
-    quit := make(chan os.Signal, 1)
-    signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-    <-quit
+    slog.Info("paiements service starting", "port", port)
 
-    log.Println("🛑 Shutting down payment service...")
-    // Ici on pourrait ajouter du cleanup si nécessaire
-    log.Println("✅ Payment service stopped")
+    // Servir jusqu'à SIGINT/SIGTERM ; les webhooks en cours sont traités
+    // avant l'arrêt
+    lc.Run(":"+port, r)
+    slog.Info("paiements service stopped")
 }
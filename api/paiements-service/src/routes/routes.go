@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
 	"github.com/matcha/api/paiements-service/src/handlers"
 	"github.com/matcha/api/paiements-service/src/middleware"
 )
@@ -14,12 +15,11 @@ func SetupRoutes(r *gin.Engine) {
 	subscriptionHandler := handlers.NewSubscriptionHandler()
 	paymentHandler := handlers.NewPaymentHandler()
 
-	// Routes de santé (non protégées)
+	// Routes de santé (non protégées). /health/ready et /health/live sont
+	// les sondes communes, ajoutées dans main.go
 	health := r.Group("/health")
 	{
 		health.GET("/", healthHandler.HealthCheck)
-		health.GET("/ready", healthHandler.ReadinessCheck)
-		health.GET("/live", healthHandler.LivenessCheck)
 	}
 
 	// Routes publiques pour Stripe
@@ -62,6 +62,14 @@ func SetupRoutes(r *gin.Engine) {
 		}
 	}
 
+	// Routes internes (appelées par les autres services)
+	internal := r.Group("/api/v1/internal")
+	internal.Use(internalapi.Middleware())
+	{
+		internal.GET("/users/:user_id/export", paymentHandler.ExportUserData)
+		internal.DELETE("/users/:user_id/subscriptions", subscriptionHandler.CancelForAccountDeletion)
+	}
+
 	// Routes d'administration (nécessitent une clé API interne)
 	admin := r.Group("/api/admin")
 	admin.Use(middleware.AdminMiddleware())
@@ -1,9 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/matcha/api/paiements-service/src/conf"
@@ -24,9 +25,9 @@ func NewCheckoutService() *CheckoutService {
 }
 
 // CreateCheckoutSession crée une session de checkout et la stocke en base
-func (s *CheckoutService) CreateCheckoutSession(userID uint, planType models.PlanType, userEmail string) (*models.CheckoutSession, error) {
+func (s *CheckoutService) CreateCheckoutSession(ctx context.Context, userID uint, planType models.PlanType, userEmail string) (*models.CheckoutSession, error) {
 	// Nettoyer les sessions expirées pour cet utilisateur
-	s.cleanupExpiredSessions(userID)
+	s.cleanupExpiredSessions(ctx, userID)
 
 	// Vérifier si l'utilisateur n'a pas déjà une session active
 	var existingSession models.CheckoutSession
@@ -34,7 +35,7 @@ func (s *CheckoutService) CreateCheckoutSession(userID uint, planType models.Pla
 	if err == nil {
 		// Vérifier si la session n'a pas expiré
 		if !existingSession.IsExpired() {
-			log.Printf("User %d already has an active checkout session: %s", userID, existingSession.StripeSessionID)
+			slog.InfoContext(ctx, "reusing the active checkout session", "user_id", userID, "session_id", existingSession.StripeSessionID)
 			return &existingSession, nil
 		} else {
 			// Marquer l'ancienne session comme expirée
@@ -46,7 +47,7 @@ func (s *CheckoutService) CreateCheckoutSession(userID uint, planType models.Pla
 	}
 
 	// Créer la session Stripe
-	stripeSession, err := s.stripeService.CreateCheckoutSession(userID, planType, userEmail)
+	stripeSession, err := s.stripeService.CreateCheckoutSession(ctx, userID, planType, userEmail)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Stripe session: %w", err)
 	}
@@ -69,13 +70,9 @@ func (s *CheckoutService) CreateCheckoutSession(userID uint, planType models.Pla
 	}
 
 	if err := conf.DB.Create(checkoutSession).Error; err != nil {
-		log.Printf("Failed to save checkout session: %v", err)
 		return nil, fmt.Errorf("failed to save checkout session: %w", err)
 	}
 
-	log.Printf("✅ Created and stored checkout session %s for user %d (plan: %s)",
-		stripeSession.ID, userID, planType)
-
 	return checkoutSession, nil
 }
 
@@ -92,14 +89,14 @@ func (s *CheckoutService) GetSessionByStripeID(stripeSessionID string) (*models.
 }
 
 // CompleteSession marque une session comme complétée et lie l'abonnement
-func (s *CheckoutService) CompleteSession(stripeSessionID string, subscriptionID string) error {
+func (s *CheckoutService) CompleteSession(ctx context.Context, stripeSessionID string, subscriptionID string) error {
 	session, err := s.GetSessionByStripeID(stripeSessionID)
 	if err != nil {
 		return err
 	}
 
 	if session.Status != models.SessionPending {
-		log.Printf("⚠️  Session %s is not pending (status: %s)", stripeSessionID, session.Status)
+		slog.WarnContext(ctx, "checkout session is not pending", "session_id", stripeSessionID, "status", session.Status)
 		return nil // Pas d'erreur car peut-être déjà traité
 	}
 
@@ -109,7 +106,7 @@ func (s *CheckoutService) CompleteSession(stripeSessionID string, subscriptionID
 		return fmt.Errorf("failed to update session status: %w", err)
 	}
 
-	log.Printf("✅ Session %s marked as completed with subscription %s", stripeSessionID, subscriptionID)
+	slog.InfoContext(ctx, "checkout session completed", "session_id", stripeSessionID, "subscription_id", subscriptionID)
 	return nil
 }
 
@@ -121,7 +118,7 @@ func (s *CheckoutService) ValidateSessionForUser(stripeSessionID string, userID
 }
 
 // CleanupExpiredSessions marque les sessions expirées
-func (s *CheckoutService) CleanupExpiredSessions() error {
+func (s *CheckoutService) CleanupExpiredSessions(ctx context.Context) error {
 	now := time.Now()
 
 	result := conf.DB.Model(&models.CheckoutSession{}).
@@ -136,7 +133,7 @@ func (s *CheckoutService) CleanupExpiredSessions() error {
 	}
 
 	if result.RowsAffected > 0 {
-		log.Printf("🧹 Cleaned up %d expired checkout sessions", result.RowsAffected)
+		slog.InfoContext(ctx, "expired checkout sessions cleaned up", "count", result.RowsAffected)
 	}
 
 	return nil
@@ -164,13 +161,13 @@ func timeFromTimestampCheckout(timestamp int64) *time.Time {
 }
 
 // cleanupExpiredSessions nettoie les sessions expirées pour un utilisateur
-func (s *CheckoutService) cleanupExpiredSessions(userID uint) {
+func (s *CheckoutService) cleanupExpiredSessions(ctx context.Context, userID uint) {
 	// Marquer comme expirées toutes les sessions qui ont dépassé leur date d'expiration
 	result := conf.DB.Model(&models.CheckoutSession{}).
 		Where("user_id = ? AND status = ? AND expires_at < ?", userID, models.SessionPending, time.Now()).
 		Update("status", models.SessionExpired)
 
 	if result.RowsAffected > 0 {
-		log.Printf("Cleaned up %d expired sessions for user %d", result.RowsAffected, userID)
+		slog.DebugContext(ctx, "expired checkout sessions cleaned up", "user_id", userID, "count", result.RowsAffected)
 	}
 }
\ No newline at end of file
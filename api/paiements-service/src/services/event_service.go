@@ -1,18 +1,41 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/matcha/api/paiements-service/src/conf"
 	"github.com/matcha/api/paiements-service/src/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/stripe/stripe-go/v82"
 	"gorm.io/gorm"
 )
 
+// Issues du traitement d'un webhook Stripe, exposées sur /metrics
+const (
+	webhookProcessed = "processed"
+	webhookDuplicate = "duplicate"
+	webhookFailed    = "failed"
+)
+
+var (
+	webhookEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stripe_webhook_events_total",
+		Help: "Stripe webhook events handled, by event type and outcome (processed, duplicate or failed).",
+	}, []string{"type", "outcome"})
+
+	webhookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stripe_webhook_processing_duration_seconds",
+		Help:    "Time taken to process Stripe webhook events, by event type.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"type"})
+)
+
 // EventService gère les événements webhook et leur traitement
 type EventService struct {
 	subscriptionService *SubscriptionService
@@ -32,13 +55,21 @@ func NewEventService() *EventService {
 }
 
 // ProcessWebhookEvent traite un événement webhook reçu de Stripe
-func (s *EventService) ProcessWebhookEvent(event *stripe.Event) error {
+func (s *EventService) ProcessWebhookEvent(ctx context.Context, event *stripe.Event) error {
+	start := time.Now()
+	outcome := webhookFailed
+	defer func() {
+		webhookEvents.WithLabelValues(string(event.Type), outcome).Inc()
+		webhookDuration.WithLabelValues(string(event.Type)).Observe(time.Since(start).Seconds())
+	}()
+
 	// Vérifier si l'événement a déjà été traité
 	var existingEvent models.WebhookEvent
 	err := conf.DB.Where("stripe_event_id = ?", event.ID).First(&existingEvent).Error
 	if err == nil {
 		if existingEvent.Processed {
-			log.Printf("Event %s already processed, skipping", event.ID)
+			slog.InfoContext(ctx, "stripe event already processed, skipping", "event_id", event.ID, "type", event.Type)
+			outcome = webhookDuplicate
 			return nil
 		}
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -62,7 +93,7 @@ func (s *EventService) ProcessWebhookEvent(event *stripe.Event) error {
 	}
 
 	// Traiter l'événement selon son type
-	if err := s.handleEventByType(event, webhookEvent); err != nil {
+	if err := s.handleEventByType(ctx, event, webhookEvent); err != nil {
 		// Marquer l'événement comme ayant échoué
 		webhookEvent.MarkAsError(err.Error())
 		conf.DB.Save(webhookEvent)
@@ -72,58 +103,57 @@ func (s *EventService) ProcessWebhookEvent(event *stripe.Event) error {
 	// Marquer l'événement comme traité
 	webhookEvent.MarkAsProcessed()
 	if err := conf.DB.Save(webhookEvent).Error; err != nil {
-		log.Printf("Failed to mark event as processed: %v", err)
+		slog.WarnContext(ctx, "failed to mark stripe event as processed", "event_id", event.ID, "error", err)
 	}
 
-	log.Printf("✅ Successfully processed event %s of type %s", event.ID, event.Type)
+	slog.InfoContext(ctx, "stripe event processed", "event_id", event.ID, "type", event.Type)
+	outcome = webhookProcessed
 	return nil
 }
 
 // handleEventByType traite un événement selon son type
-func (s *EventService) handleEventByType(event *stripe.Event, webhookEvent *models.WebhookEvent) error {
+func (s *EventService) handleEventByType(ctx context.Context, event *stripe.Event, webhookEvent *models.WebhookEvent) error {
 	switch event.Type {
 	case "checkout.session.completed":
-		return s.handleCheckoutSessionCompleted(event)
+		return s.handleCheckoutSessionCompleted(ctx, event)
 	case "customer.subscription.created":
-		return s.handleSubscriptionCreated(event)
+		return s.handleSubscriptionCreated(ctx, event)
 	case "customer.subscription.updated":
-		return s.handleSubscriptionUpdated(event)
+		return s.handleSubscriptionUpdated(ctx, event)
 	case "customer.subscription.deleted":
-		return s.handleSubscriptionDeleted(event)
+		return s.handleSubscriptionDeleted(ctx, event)
 	case "invoice.payment_succeeded":
-		return s.handleInvoicePaymentSucceeded(event)
+		return s.handleInvoicePaymentSucceeded(ctx, event)
 	case "invoice.payment_failed":
-		return s.handleInvoicePaymentFailed(event)
+		return s.handleInvoicePaymentFailed(ctx, event)
 	case "payment_intent.succeeded":
-		return s.handlePaymentIntentSucceeded(event)
+		return s.handlePaymentIntentSucceeded(ctx, event)
 	case "payment_intent.payment_failed":
-		return s.handlePaymentIntentFailed(event)
+		return s.handlePaymentIntentFailed(ctx, event)
 	default:
-		log.Printf("Unhandled event type: %s", event.Type)
+		slog.DebugContext(ctx, "unhandled stripe event type", "event_id", event.ID, "type", event.Type)
 		return nil // Ne pas considérer comme une erreur
 	}
 }
 
 // handleCheckoutSessionCompleted traite la completion d'une session de checkout
-func (s *EventService) handleCheckoutSessionCompleted(event *stripe.Event) error {
+func (s *EventService) handleCheckoutSessionCompleted(ctx context.Context, event *stripe.Event) error {
 	var session stripe.CheckoutSession
 	if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
 		return fmt.Errorf("failed to parse checkout session data: %w", err)
 	}
 
-	log.Printf("🛒 Processing checkout session completed: %s", session.ID)
-
 	// Récupérer notre session stockée
 	checkoutSession, err := s.checkoutService.GetSessionByStripeID(session.ID)
 	if err != nil {
-		log.Printf("⚠️  Checkout session not found in our database: %s", session.ID)
+		slog.WarnContext(ctx, "checkout session not found", "session_id", session.ID)
 		// Pour les paiements externes ou tests, on peut continuer sans erreur
 		return nil
 	}
 
 	// Vérifier que la session est bien en attente
 	if checkoutSession.Status != models.SessionPending {
-		log.Printf("⚠️  Session %s is not pending (status: %s)", session.ID, checkoutSession.Status)
+		slog.WarnContext(ctx, "checkout session is not pending", "session_id", session.ID, "status", checkoutSession.Status)
 		return nil
 	}
 
@@ -132,40 +162,38 @@ func (s *EventService) handleCheckoutSessionCompleted(event *stripe.Event) error
 		subscriptionID := session.Subscription.ID
 
 		// Marquer la session comme complétée
-		if err := s.checkoutService.CompleteSession(session.ID, subscriptionID); err != nil {
+		if err := s.checkoutService.CompleteSession(ctx, session.ID, subscriptionID); err != nil {
 			return fmt.Errorf("failed to complete checkout session: %w", err)
 		}
 
 		// Envoyer une notification WebSocket
-		s.websocketService.SendSubscriptionEvent(checkoutSession.UserID, "checkout_completed", map[string]interface{}{
+		s.websocketService.SendSubscriptionEvent(ctx, checkoutSession.UserID, "checkout_completed", map[string]interface{}{
 			"session_id":      session.ID,
 			"subscription_id": subscriptionID,
 			"plan_type":       checkoutSession.PlanType,
 		})
 
-		log.Printf("✅ Checkout session %s completed for user %d with subscription %s",
-			session.ID, checkoutSession.UserID, subscriptionID)
 	}
 
 	return nil
 }
 
 // handleSubscriptionCreated traite la création d'un abonnement
-func (s *EventService) handleSubscriptionCreated(event *stripe.Event) error {
+func (s *EventService) handleSubscriptionCreated(ctx context.Context, event *stripe.Event) error {
 	var subscription stripe.Subscription
 	if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
 		return fmt.Errorf("failed to parse subscription data: %w", err)
 	}
 
 	// Créer l'abonnement dans notre base de données
-	createdSubscription, err := s.subscriptionService.CreateSubscriptionFromStripe(&subscription)
+	createdSubscription, err := s.subscriptionService.CreateSubscriptionFromStripe(ctx, &subscription)
 	if err != nil {
 		return fmt.Errorf("failed to create subscription: %w", err)
 	}
 
 	// Envoyer une notification WebSocket
 	if createdSubscription != nil {
-		s.websocketService.SendSubscriptionEvent(createdSubscription.UserID, "subscription_created", map[string]interface{}{
+		s.websocketService.SendSubscriptionEvent(ctx, createdSubscription.UserID, "subscription_created", map[string]interface{}{
 			"subscription_id": createdSubscription.ID,
 			"plan_type":       createdSubscription.PlanType,
 			"status":          createdSubscription.Status,
@@ -176,21 +204,21 @@ func (s *EventService) handleSubscriptionCreated(event *stripe.Event) error {
 }
 
 // handleSubscriptionUpdated traite la mise à jour d'un abonnement
-func (s *EventService) handleSubscriptionUpdated(event *stripe.Event) error {
+func (s *EventService) handleSubscriptionUpdated(ctx context.Context, event *stripe.Event) error {
 	var subscription stripe.Subscription
 	if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
 		return fmt.Errorf("failed to parse subscription data: %w", err)
 	}
 
 	// Mettre à jour l'abonnement dans notre base de données
-	updatedSubscription, err := s.subscriptionService.UpdateSubscriptionFromStripe(&subscription)
+	updatedSubscription, err := s.subscriptionService.UpdateSubscriptionFromStripe(ctx, &subscription)
 	if err != nil {
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
 	// Envoyer une notification WebSocket
 	if updatedSubscription != nil {
-		s.websocketService.SendSubscriptionEvent(updatedSubscription.UserID, "subscription_updated", map[string]interface{}{
+		s.websocketService.SendSubscriptionEvent(ctx, updatedSubscription.UserID, "subscription_updated", map[string]interface{}{
 			"subscription_id":      updatedSubscription.ID,
 			"status":               updatedSubscription.Status,
 			"cancel_at_period_end": updatedSubscription.CancelAtPeriodEnd,
@@ -201,7 +229,7 @@ func (s *EventService) handleSubscriptionUpdated(event *stripe.Event) error {
 }
 
 // handleSubscriptionDeleted traite la suppression d'un abonnement
-func (s *EventService) handleSubscriptionDeleted(event *stripe.Event) error {
+func (s *EventService) handleSubscriptionDeleted(ctx context.Context, event *stripe.Event) error {
 	var subscription stripe.Subscription
 	if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
 		return fmt.Errorf("failed to parse subscription data: %w", err)
@@ -211,7 +239,7 @@ func (s *EventService) handleSubscriptionDeleted(event *stripe.Event) error {
 	var localSubscription models.Subscription
 	if err := conf.DB.Where("stripe_subscription_id = ?", subscription.ID).First(&localSubscription).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("Subscription %s not found in database", subscription.ID)
+			slog.WarnContext(ctx, "deleted subscription not found", "subscription_id", subscription.ID)
 			return nil
 		}
 		return fmt.Errorf("failed to find subscription: %w", err)
@@ -227,7 +255,7 @@ func (s *EventService) handleSubscriptionDeleted(event *stripe.Event) error {
 	}
 
 	// Envoyer une notification WebSocket
-	s.websocketService.SendSubscriptionEvent(localSubscription.UserID, "subscription_cancelled", map[string]interface{}{
+	s.websocketService.SendSubscriptionEvent(ctx, localSubscription.UserID, "subscription_cancelled", map[string]interface{}{
 		"subscription_id": localSubscription.ID,
 		"canceled_at":     localSubscription.CanceledAt,
 	})
@@ -236,19 +264,17 @@ func (s *EventService) handleSubscriptionDeleted(event *stripe.Event) error {
 }
 
 // handleInvoicePaymentSucceeded traite le succès d'un paiement de facture
-func (s *EventService) handleInvoicePaymentSucceeded(event *stripe.Event) error {
+func (s *EventService) handleInvoicePaymentSucceeded(ctx context.Context, event *stripe.Event) error {
 	var invoice stripe.Invoice
 	if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
 		return fmt.Errorf("failed to parse invoice data: %w", err)
 	}
 
-	log.Printf("💰 Processing invoice payment succeeded: %s (amount: %.2f %s)", invoice.ID, float64(invoice.AmountPaid)/100.0, invoice.Currency)
-
 	// Créer un enregistrement de paiement seulement si il y a un abonnement
 	if err := s.paymentService.CreatePaymentFromInvoice(&invoice); err != nil {
 		return fmt.Errorf("failed to create payment record: %w", err)
 	}
-	log.Printf("💰 Payment record created from invoice %s (amount: %.2f %s)", invoice.ID, float64(invoice.AmountPaid)/100.0, invoice.Currency)
+	slog.InfoContext(ctx, "payment recorded from invoice", "invoice_id", invoice.ID, "amount", invoice.AmountPaid, "currency", invoice.Currency)
 
 	// Dans v82, nous devons récupérer l'abonnement via les métadonnées du paiement ou directement avec Stripe
 	// Pour maintenant, cherchons l'abonnement via l'invoice en utilisant l'API Stripe
@@ -258,7 +284,7 @@ func (s *EventService) handleInvoicePaymentSucceeded(event *stripe.Event) error
 		var subscription models.Subscription
 		if err := conf.DB.Where("stripe_customer_id = ?", invoice.Customer.ID).Order("created_at DESC").First(&subscription).Error; err == nil {
 			// Envoyer une notification WebSocket
-			s.websocketService.SendSubscriptionEvent(subscription.UserID, "payment_succeeded", map[string]interface{}{
+			s.websocketService.SendSubscriptionEvent(ctx, subscription.UserID, "payment_succeeded", map[string]interface{}{
 				"amount":     float64(invoice.AmountPaid) / 100.0,
 				"currency":   invoice.Currency,
 				"invoice_id": invoice.ID,
@@ -270,7 +296,7 @@ func (s *EventService) handleInvoicePaymentSucceeded(event *stripe.Event) error
 }
 
 // handleInvoicePaymentFailed traite l'échec d'un paiement de facture
-func (s *EventService) handleInvoicePaymentFailed(event *stripe.Event) error {
+func (s *EventService) handleInvoicePaymentFailed(ctx context.Context, event *stripe.Event) error {
 	var invoice stripe.Invoice
 	if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
 		return fmt.Errorf("failed to parse invoice data: %w", err)
@@ -281,7 +307,7 @@ func (s *EventService) handleInvoicePaymentFailed(event *stripe.Event) error {
 		var subscription models.Subscription
 		if err := conf.DB.Where("stripe_customer_id = ?", invoice.Customer.ID).Order("created_at DESC").First(&subscription).Error; err == nil {
 			// Envoyer une notification WebSocket
-			s.websocketService.SendSubscriptionEvent(subscription.UserID, "payment_failed", map[string]interface{}{
+			s.websocketService.SendSubscriptionEvent(ctx, subscription.UserID, "payment_failed", map[string]interface{}{
 				"amount":     float64(invoice.AmountDue) / 100.0,
 				"currency":   invoice.Currency,
 				"invoice_id": invoice.ID,
@@ -294,7 +320,7 @@ func (s *EventService) handleInvoicePaymentFailed(event *stripe.Event) error {
 }
 
 // handlePaymentIntentSucceeded traite le succès d'un PaymentIntent
-func (s *EventService) handlePaymentIntentSucceeded(event *stripe.Event) error {
+func (s *EventService) handlePaymentIntentSucceeded(ctx context.Context, event *stripe.Event) error {
 	var paymentIntent stripe.PaymentIntent
 	if err := json.Unmarshal(event.Data.Raw, &paymentIntent); err != nil {
 		return fmt.Errorf("failed to parse payment intent data: %w", err)
@@ -304,13 +330,13 @@ func (s *EventService) handlePaymentIntentSucceeded(event *stripe.Event) error {
 	if err := s.paymentService.CreatePaymentFromPaymentIntent(&paymentIntent); err != nil {
 		return fmt.Errorf("failed to create payment record: %w", err)
 	}
-	log.Printf("💳 Payment record created from PaymentIntent %s (amount: %.2f %s)", paymentIntent.ID, float64(paymentIntent.Amount)/100.0, paymentIntent.Currency)
+	slog.InfoContext(ctx, "payment recorded from payment intent", "payment_intent_id", paymentIntent.ID, "amount", paymentIntent.Amount, "currency", paymentIntent.Currency)
 
 	return nil
 }
 
 // handlePaymentIntentFailed traite l'échec d'un PaymentIntent
-func (s *EventService) handlePaymentIntentFailed(event *stripe.Event) error {
+func (s *EventService) handlePaymentIntentFailed(ctx context.Context, event *stripe.Event) error {
 	var paymentIntent stripe.PaymentIntent
 	if err := json.Unmarshal(event.Data.Raw, &paymentIntent); err != nil {
 		return fmt.Errorf("failed to parse payment intent data: %w", err)
@@ -331,7 +357,7 @@ func (s *EventService) handlePaymentIntentFailed(event *stripe.Event) error {
 }
 
 // RetryFailedEvents relance le traitement des événements qui ont échoué
-func (s *EventService) RetryFailedEvents() error {
+func (s *EventService) RetryFailedEvents(ctx context.Context) error {
 	var failedEvents []models.WebhookEvent
 	if err := conf.DB.Where("processed = ? AND retry_count < ?", false, 3).Find(&failedEvents).Error; err != nil {
 		return fmt.Errorf("failed to find failed events: %w", err)
@@ -342,12 +368,10 @@ func (s *EventService) RetryFailedEvents() error {
 			continue
 		}
 
-		log.Printf("Retrying event %s (attempt %d)", event.StripeEventID, event.RetryCount+1)
-
 		// Désérialiser les données de l'événement
 		var eventData map[string]interface{}
 		if err := event.GetEventData(&eventData); err != nil {
-			log.Printf("Failed to deserialize event data for %s: %v", event.StripeEventID, err)
+			slog.WarnContext(ctx, "failed to deserialize stripe event data", "event_id", event.StripeEventID, "error", err)
 			continue
 		}
 
@@ -361,12 +385,12 @@ func (s *EventService) RetryFailedEvents() error {
 		}
 
 		// Retraiter l'événement
-		if err := s.handleEventByType(stripeEvent, &event); err != nil {
+		if err := s.handleEventByType(ctx, stripeEvent, &event); err != nil {
 			event.MarkAsError(err.Error())
-			log.Printf("Retry failed for event %s: %v", event.StripeEventID, err)
+			slog.WarnContext(ctx, "stripe event retry failed", "event_id", event.StripeEventID, "attempt", event.RetryCount+1, "error", err)
 		} else {
 			event.MarkAsProcessed()
-			log.Printf("Successfully retried event %s", event.StripeEventID)
+			slog.InfoContext(ctx, "stripe event retried", "event_id", event.StripeEventID, "attempt", event.RetryCount+1)
 		}
 
 		// Sauvegarder les modifications
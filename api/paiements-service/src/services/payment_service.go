@@ -276,6 +276,34 @@ func (s *PaymentService) GetPaymentStats(userID uint) (*PaymentStats, error) {
 	return stats, nil
 }
 
+// UserPaymentExport regroupe les données de paiement d'un utilisateur pour l'export RGPD
+type UserPaymentExport struct {
+	Subscriptions    []models.Subscription    `json:"subscriptions"`
+	Payments         []models.Payment         `json:"payments"`
+	CheckoutSessions []models.CheckoutSession `json:"checkout_sessions"`
+}
+
+// GetUserDataExport récupère abonnements, paiements et sessions de checkout d'un utilisateur
+func (s *PaymentService) GetUserDataExport(userID uint) (*UserPaymentExport, error) {
+	export := &UserPaymentExport{
+		Subscriptions:    []models.Subscription{},
+		Payments:         []models.Payment{},
+		CheckoutSessions: []models.CheckoutSession{},
+	}
+
+	if err := conf.DB.Where("user_id = ?", userID).Order("created_at").Find(&export.Subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	if err := conf.DB.Where("user_id = ?", userID).Order("created_at").Find(&export.Payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+	if err := conf.DB.Where("user_id = ?", userID).Order("created_at").Find(&export.CheckoutSessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get checkout sessions: %w", err)
+	}
+
+	return export, nil
+}
+
 // updatePaymentFromPaymentIntent met à jour un paiement existant à partir d'un PaymentIntent
 func (s *PaymentService) updatePaymentFromPaymentIntent(payment *models.Payment, paymentIntent *stripe.PaymentIntent) error {
 	payment.Status = ConvertPaymentIntentStatus(paymentIntent.Status)
@@ -1,9 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -31,7 +32,8 @@ type StripeService struct {
 func NewStripeService() *StripeService {
 	secretKey := os.Getenv("STRIPE_SECRET_KEY")
 	if secretKey == "" {
-		log.Fatal("STRIPE_SECRET_KEY environment variable is required")
+		slog.Error("STRIPE_SECRET_KEY environment variable is required")
+		os.Exit(1)
 	}
 
 	stripe.Key = secretKey
@@ -47,7 +49,7 @@ func NewStripeService() *StripeService {
 }
 
 // CreateCheckoutSession crée une session de checkout Stripe
-func (s *StripeService) CreateCheckoutSession(userID uint, planType models.PlanType, userEmail string) (*stripe.CheckoutSession, error) {
+func (s *StripeService) CreateCheckoutSession(ctx context.Context, userID uint, planType models.PlanType, userEmail string) (*stripe.CheckoutSession, error) {
 	// Récupérer l'ID du prix selon le plan
 	var priceID string
 	switch planType {
@@ -64,7 +66,7 @@ func (s *StripeService) CreateCheckoutSession(userID uint, planType models.PlanT
 	}
 
 	// Récupérer ou créer le customer Stripe
-	customerID, err := s.getOrCreateCustomer(userID, userEmail)
+	customerID, err := s.getOrCreateCustomer(ctx, userID, userEmail)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get or create customer: %w", err)
 	}
@@ -100,7 +102,7 @@ func (s *StripeService) CreateCheckoutSession(userID uint, planType models.PlanT
 		return nil, fmt.Errorf("failed to create checkout session: %w", err)
 	}
 
-	log.Printf("Created checkout session %s for user %d with plan %s", sess.ID, userID, planType)
+	slog.InfoContext(ctx, "stripe checkout session created", "session_id", sess.ID, "user_id", userID, "plan_type", planType)
 	return sess, nil
 }
 
@@ -120,7 +122,7 @@ func (s *StripeService) CreateBillingPortalSession(customerID, returnURL string)
 }
 
 // CancelSubscription annule un abonnement Stripe
-func (s *StripeService) CancelSubscription(subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error) {
+func (s *StripeService) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*stripe.Subscription, error) {
 	params := &stripe.SubscriptionParams{}
 
 	if atPeriodEnd {
@@ -134,10 +136,27 @@ func (s *StripeService) CancelSubscription(subscriptionID string, atPeriodEnd bo
 		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
 	}
 
-	log.Printf("Subscription %s cancel_at_period_end set to %t", subscriptionID, atPeriodEnd)
+	slog.InfoContext(ctx, "stripe subscription updated", "subscription_id", subscriptionID, "cancel_at_period_end", atPeriodEnd)
 	return sub, nil
 }
 
+// CancelSubscriptionNow annule immédiatement un abonnement Stripe.
+// Un abonnement déjà supprimé côté Stripe n'est pas considéré comme une erreur.
+func (s *StripeService) CancelSubscriptionNow(ctx context.Context, subscriptionID string) error {
+	_, err := subscription.Cancel(subscriptionID, nil)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			slog.InfoContext(ctx, "stripe subscription already removed", "subscription_id", subscriptionID)
+			return nil
+		}
+		return fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	slog.InfoContext(ctx, "stripe subscription canceled", "subscription_id", subscriptionID)
+	return nil
+}
+
 // GetSubscription récupère un abonnement Stripe
 func (s *StripeService) GetSubscription(subscriptionID string) (*stripe.Subscription, error) {
 	sub, err := subscription.Get(subscriptionID, nil)
@@ -159,7 +178,7 @@ func (s *StripeService) GetCustomer(customerID string) (*stripe.Customer, error)
 }
 
 // getOrCreateCustomer récupère ou crée un customer Stripe pour un utilisateur
-func (s *StripeService) getOrCreateCustomer(userID uint, userEmail string) (string, error) {
+func (s *StripeService) getOrCreateCustomer(ctx context.Context, userID uint, userEmail string) (string, error) {
 	// Vérifier si l'utilisateur a déjà un customer Stripe
 	var subscription models.Subscription
 	if err := conf.DB.Where("user_id = ?", userID).First(&subscription).Error; err == nil {
@@ -169,7 +188,7 @@ func (s *StripeService) getOrCreateCustomer(userID uint, userEmail string) (stri
 		}
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		// Seules les vraies erreurs sont loggées, pas "record not found"
-		log.Printf("Error checking existing subscription: %v", err)
+		slog.WarnContext(ctx, "failed to check existing subscription", "user_id", userID, "error", err)
 	}
 
 	// Créer un nouveau customer Stripe
@@ -185,7 +204,7 @@ func (s *StripeService) getOrCreateCustomer(userID uint, userEmail string) (stri
 		return "", fmt.Errorf("failed to create customer: %w", err)
 	}
 
-	log.Printf("Created Stripe customer %s for user %d", cust.ID, userID)
+	slog.InfoContext(ctx, "stripe customer created", "customer_id", cust.ID, "user_id", userID)
 	return cust.ID, nil
 }
 
@@ -1,9 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/matcha/api/paiements-service/src/conf"
@@ -25,7 +26,7 @@ func NewSubscriptionService() *SubscriptionService {
 }
 
 // CreateCheckoutSession crée une session de checkout Stripe
-func (s *SubscriptionService) CreateCheckoutSession(userID uint, userEmail string, planType models.PlanType) (*stripe.CheckoutSession, error) {
+func (s *SubscriptionService) CreateCheckoutSession(ctx context.Context, userID uint, userEmail string, planType models.PlanType) (*stripe.CheckoutSession, error) {
 	// Vérifier si l'utilisateur existe
 	var user models.User
 	if err := conf.DB.First(&user, userID).Error; err != nil {
@@ -46,17 +47,16 @@ func (s *SubscriptionService) CreateCheckoutSession(userID uint, userEmail strin
 	// Si err == gorm.ErrRecordNotFound, c'est normal - l'utilisateur n'a pas d'abonnement actif
 
 	// Créer une session de checkout Stripe
-	session, err := s.stripeService.CreateCheckoutSession(userID, planType, userEmail)
+	session, err := s.stripeService.CreateCheckoutSession(ctx, userID, planType, userEmail)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create checkout session: %w", err)
 	}
 
-	log.Printf("Created checkout session %s for user %d", session.ID, userID)
 	return session, nil
 }
 
 // CreateSubscription crée un nouvel abonnement
-func (s *SubscriptionService) CreateSubscription(userID uint, userEmail string, planType models.PlanType) (*models.Subscription, error) {
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, userID uint, userEmail string, planType models.PlanType) (*models.Subscription, error) {
 	// Vérifier si l'utilisateur existe
 	var user models.User
 	if err := conf.DB.First(&user, userID).Error; err != nil {
@@ -76,19 +76,17 @@ func (s *SubscriptionService) CreateSubscription(userID uint, userEmail string,
 	}
 
 	// Créer une session de checkout Stripe
-	session, err := s.stripeService.CreateCheckoutSession(userID, planType, userEmail)
+	_, err = s.stripeService.CreateCheckoutSession(ctx, userID, planType, userEmail)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create checkout session: %w", err)
 	}
 
-	log.Printf("Created checkout session for user %d: %s", userID, session.URL)
-
 	// L'abonnement sera créé via webhook une fois le paiement confirmé
 	return nil, nil
 }
 
 // CreateSubscriptionFromStripe crée un abonnement à partir des données Stripe
-func (s *SubscriptionService) CreateSubscriptionFromStripe(stripeSubscription *stripe.Subscription) (*models.Subscription, error) {
+func (s *SubscriptionService) CreateSubscriptionFromStripe(ctx context.Context, stripeSubscription *stripe.Subscription) (*models.Subscription, error) {
 	// Extraire l'ID utilisateur des métadonnées
 	userIDStr, exists := stripeSubscription.Metadata["user_id"]
 	if !exists {
@@ -136,20 +134,20 @@ func (s *SubscriptionService) CreateSubscriptionFromStripe(stripeSubscription *s
 
 	// Mettre à jour le statut premium de l'utilisateur
 	if err := s.updateUserPremiumStatus(userID, subscription.IsPremiumValid()); err != nil {
-		log.Printf("Failed to update user premium status: %v", err)
+		slog.WarnContext(ctx, "failed to update user premium status", "user_id", userID, "error", err)
 	}
 
-	log.Printf("Created subscription %d for user %d", subscription.ID, userID)
+	slog.InfoContext(ctx, "subscription created", "subscription_id", subscription.ID, "user_id", userID, "plan_type", planType)
 	return subscription, nil
 }
 
 // UpdateSubscriptionFromStripe met à jour un abonnement à partir des données Stripe
-func (s *SubscriptionService) UpdateSubscriptionFromStripe(stripeSubscription *stripe.Subscription) (*models.Subscription, error) {
+func (s *SubscriptionService) UpdateSubscriptionFromStripe(ctx context.Context, stripeSubscription *stripe.Subscription) (*models.Subscription, error) {
 	var subscription models.Subscription
 	if err := conf.DB.Where("stripe_subscription_id = ?", stripeSubscription.ID).First(&subscription).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// L'abonnement n'existe pas, le créer
-			return s.CreateSubscriptionFromStripe(stripeSubscription)
+			return s.CreateSubscriptionFromStripe(ctx, stripeSubscription)
 		}
 		return nil, fmt.Errorf("failed to find subscription: %w", err)
 	}
@@ -173,15 +171,15 @@ func (s *SubscriptionService) UpdateSubscriptionFromStripe(stripeSubscription *s
 
 	// Mettre à jour le statut premium de l'utilisateur
 	if err := s.updateUserPremiumStatus(subscription.UserID, subscription.IsPremiumValid()); err != nil {
-		log.Printf("Failed to update user premium status: %v", err)
+		slog.WarnContext(ctx, "failed to update user premium status", "user_id", subscription.UserID, "error", err)
 	}
 
-	log.Printf("Updated subscription %d for user %d", subscription.ID, subscription.UserID)
+	slog.InfoContext(ctx, "subscription updated", "subscription_id", subscription.ID, "user_id", subscription.UserID, "status", subscription.Status)
 	return &subscription, nil
 }
 
 // CancelSubscription annule un abonnement
-func (s *SubscriptionService) CancelSubscription(userID uint, atPeriodEnd bool) error {
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, userID uint, atPeriodEnd bool) error {
 	// Récupérer l'abonnement de l'utilisateur
 	var subscription models.Subscription
 	if err := conf.DB.Where("user_id = ? AND status = ?", userID, models.StatusActive).First(&subscription).Error; err != nil {
@@ -192,7 +190,7 @@ func (s *SubscriptionService) CancelSubscription(userID uint, atPeriodEnd bool)
 	}
 
 	// Annuler l'abonnement dans Stripe
-	_, err := s.stripeService.CancelSubscription(subscription.StripeSubscriptionID, atPeriodEnd)
+	_, err := s.stripeService.CancelSubscription(ctx, subscription.StripeSubscriptionID, atPeriodEnd)
 	if err != nil {
 		return fmt.Errorf("failed to cancel Stripe subscription: %w", err)
 	}
@@ -212,14 +210,47 @@ func (s *SubscriptionService) CancelSubscription(userID uint, atPeriodEnd bool)
 	// Mettre à jour le statut premium si l'annulation est immédiate
 	if !atPeriodEnd {
 		if err := s.updateUserPremiumStatus(userID, false); err != nil {
-			log.Printf("Failed to update user premium status: %v", err)
+			slog.WarnContext(ctx, "failed to update user premium status", "user_id", userID, "error", err)
 		}
 	}
 
-	log.Printf("Canceled subscription for user %d (at period end: %t)", userID, atPeriodEnd)
+	slog.InfoContext(ctx, "subscription canceled", "user_id", userID, "at_period_end", atPeriodEnd)
 	return nil
 }
 
+// CancelForAccountDeletion annule immédiatement tout abonnement non terminé
+// d'un utilisateur dont le compte est supprimé. Idempotent : sans abonnement
+// à annuler, rien n'est fait.
+func (s *SubscriptionService) CancelForAccountDeletion(ctx context.Context, userID uint) (int, error) {
+	var subscriptions []models.Subscription
+	if err := conf.DB.Where("user_id = ? AND status <> ?", userID, models.StatusCanceled).Find(&subscriptions).Error; err != nil {
+		return 0, fmt.Errorf("failed to find subscriptions: %w", err)
+	}
+
+	for i := range subscriptions {
+		sub := &subscriptions[i]
+		if err := s.stripeService.CancelSubscriptionNow(ctx, sub.StripeSubscriptionID); err != nil {
+			return i, err
+		}
+
+		now := time.Now()
+		sub.Status = models.StatusCanceled
+		sub.CancelAtPeriodEnd = false
+		sub.CanceledAt = &now
+		if err := conf.DB.Save(sub).Error; err != nil {
+			return i, fmt.Errorf("failed to update subscription: %w", err)
+		}
+	}
+
+	if len(subscriptions) > 0 {
+		if err := s.updateUserPremiumStatus(userID, false); err != nil {
+			slog.WarnContext(ctx, "failed to update user premium status", "user_id", userID, "error", err)
+		}
+		slog.InfoContext(ctx, "subscriptions canceled for deleted account", "user_id", userID, "count", len(subscriptions))
+	}
+	return len(subscriptions), nil
+}
+
 // GetUserSubscription récupère l'abonnement d'un utilisateur
 func (s *SubscriptionService) GetUserSubscription(userID uint) (*models.Subscription, error) {
 	var subscription models.Subscription
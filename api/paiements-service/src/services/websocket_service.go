@@ -2,9 +2,10 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
@@ -36,29 +37,29 @@ type WebSocketMessage struct {
 }
 
 // SendSubscriptionEvent envoie un événement d'abonnement via WebSocket
-func (ws *WebSocketService) SendSubscriptionEvent(userID uint, eventType string, data map[string]interface{}) {
+func (ws *WebSocketService) SendSubscriptionEvent(ctx context.Context, userID uint, eventType string, data map[string]interface{}) {
 	message := WebSocketMessage{
 		Type:   eventType,
 		UserID: fmt.Sprintf("%d", userID),
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendPaymentEvent envoie un événement de paiement via WebSocket
-func (ws *WebSocketService) SendPaymentEvent(userID uint, eventType string, data map[string]interface{}) {
+func (ws *WebSocketService) SendPaymentEvent(ctx context.Context, userID uint, eventType string, data map[string]interface{}) {
 	message := WebSocketMessage{
 		Type:   eventType,
 		UserID: fmt.Sprintf("%d", userID),
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendPremiumActivation envoie une notification d'activation premium
-func (ws *WebSocketService) SendPremiumActivation(userID uint, planType string, endDate *time.Time) {
+func (ws *WebSocketService) SendPremiumActivation(ctx context.Context, userID uint, planType string, endDate *time.Time) {
 	data := map[string]interface{}{
 		"message":   "Premium activé avec succès!",
 		"plan_type": planType,
@@ -74,11 +75,11 @@ func (ws *WebSocketService) SendPremiumActivation(userID uint, planType string,
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendPremiumCancellation envoie une notification d'annulation premium
-func (ws *WebSocketService) SendPremiumCancellation(userID uint, cancelAtPeriodEnd bool, endDate *time.Time) {
+func (ws *WebSocketService) SendPremiumCancellation(ctx context.Context, userID uint, cancelAtPeriodEnd bool, endDate *time.Time) {
 	data := map[string]interface{}{
 		"cancel_at_period_end": cancelAtPeriodEnd,
 	}
@@ -98,11 +99,11 @@ func (ws *WebSocketService) SendPremiumCancellation(userID uint, cancelAtPeriodE
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendPaymentSuccess envoie une notification de paiement réussi
-func (ws *WebSocketService) SendPaymentSuccess(userID uint, amount float64, currency string) {
+func (ws *WebSocketService) SendPaymentSuccess(ctx context.Context, userID uint, amount float64, currency string) {
 	data := map[string]interface{}{
 		"message":  "Paiement effectué avec succès!",
 		"amount":   amount,
@@ -115,11 +116,11 @@ func (ws *WebSocketService) SendPaymentSuccess(userID uint, amount float64, curr
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendPaymentFailure envoie une notification d'échec de paiement
-func (ws *WebSocketService) SendPaymentFailure(userID uint, reason string) {
+func (ws *WebSocketService) SendPaymentFailure(ctx context.Context, userID uint, reason string) {
 	data := map[string]interface{}{
 		"message": "Échec du paiement",
 		"reason":  reason,
@@ -131,11 +132,11 @@ func (ws *WebSocketService) SendPaymentFailure(userID uint, reason string) {
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendTrialEndingWarning envoie un avertissement de fin d'essai gratuit
-func (ws *WebSocketService) SendTrialEndingWarning(userID uint, daysLeft int) {
+func (ws *WebSocketService) SendTrialEndingWarning(ctx context.Context, userID uint, daysLeft int) {
 	data := map[string]interface{}{
 		"message":   fmt.Sprintf("Votre essai gratuit se termine dans %d jour(s)", daysLeft),
 		"days_left": daysLeft,
@@ -147,23 +148,23 @@ func (ws *WebSocketService) SendTrialEndingWarning(userID uint, daysLeft int) {
 		Data:   data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // sendMessage envoie un message au gateway pour diffusion WebSocket
-func (ws *WebSocketService) sendMessage(message WebSocketMessage) {
+func (ws *WebSocketService) sendMessage(ctx context.Context, message WebSocketMessage) {
 	// Sérialiser le message en JSON
 	jsonData, err := json.Marshal(message)
 	if err != nil {
-		log.Printf("Failed to marshal WebSocket message: %v", err)
+		slog.ErrorContext(ctx, "failed to marshal websocket message", "type", message.Type, "error", err)
 		return
 	}
 
 	// Envoyer la requête HTTP au gateway
 	url := fmt.Sprintf("%s/api/internal/websocket/broadcast", ws.gatewayURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Printf("Failed to create HTTP request for WebSocket: %v", err)
+		slog.ErrorContext(ctx, "failed to create websocket broadcast request", "error", err)
 		return
 	}
 
@@ -178,32 +179,32 @@ func (ws *WebSocketService) sendMessage(message WebSocketMessage) {
 	// Exécuter la requête
 	resp, err := ws.client.Do(req)
 	if err != nil {
-		log.Printf("Failed to send WebSocket message to gateway: %v", err)
+		slog.WarnContext(ctx, "failed to send websocket message to gateway", "type", message.Type, "user_id", message.UserID, "error", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Gateway returned non-OK status for WebSocket message: %d", resp.StatusCode)
+		slog.WarnContext(ctx, "websocket message rejected by gateway", "type", message.Type, "user_id", message.UserID, "status", resp.StatusCode)
 		return
 	}
 
-	log.Printf("Successfully sent WebSocket message of type %s to user %s", message.Type, message.UserID)
+	slog.DebugContext(ctx, "websocket message sent", "type", message.Type, "user_id", message.UserID)
 }
 
 // BroadcastToChannel envoie un message à un canal spécifique
-func (ws *WebSocketService) BroadcastToChannel(channel string, eventType string, data map[string]interface{}) {
+func (ws *WebSocketService) BroadcastToChannel(ctx context.Context, channel string, eventType string, data map[string]interface{}) {
 	message := WebSocketMessage{
 		Type:    eventType,
 		Channel: channel,
 		Data:    data,
 	}
 
-	ws.sendMessage(message)
+	ws.sendMessage(ctx, message)
 }
 
 // SendSystemNotification envoie une notification système
-func (ws *WebSocketService) SendSystemNotification(userID uint, title string, message string, notificationType string) {
+func (ws *WebSocketService) SendSystemNotification(ctx context.Context, userID uint, title string, message string, notificationType string) {
 	data := map[string]interface{}{
 		"title":   title,
 		"message": message,
@@ -216,7 +217,7 @@ func (ws *WebSocketService) SendSystemNotification(userID uint, title string, me
 		Data:   data,
 	}
 
-	ws.sendMessage(wsMessage)
+	ws.sendMessage(ctx, wsMessage)
 }
 
 // getEnv récupère une variable d'environnement avec une valeur par défaut
@@ -2,9 +2,12 @@ package conf
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
+	"github.com/maxg56/matcha/api/common/metrics"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -32,18 +35,26 @@ func InitDB() {
 	})
 
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		slog.Error("failed to connect to the database", "error", err)
+		os.Exit(1)
 	}
 
 	// Auto-migrate tables if environment variable is set
 	if getEnvWithDefault("AUTO_MIGRATE", "false") == "true" {
-		log.Println("Skipping AutoMigrate to avoid constraint conflicts with existing views")
+		slog.Info("skipping AutoMigrate to avoid constraint conflicts with existing views")
 		// if err := autoMigrate(); err != nil {
 		//	log.Fatal("Failed to auto-migrate:", err)
 		// }
 	}
 
-	log.Println("Database connection established successfully")
+	if err := DB.Use(tracing.GORMPlugin{}); err != nil {
+		slog.Warn("failed to enable query tracing", "error", err)
+	}
+	if err := metrics.RegisterDB(DB, "user"); err != nil {
+		slog.Warn("failed to expose connection pool metrics", "error", err)
+	}
+
+	slog.Info("database connected")
 }
 
 func autoMigrate() error {
@@ -56,6 +67,13 @@ func autoMigrate() error {
 		&models.UserPreference{},
 		&models.UserReport{},
 		&models.ProfileView{},
+		&models.DataExport{},
+		&models.AccountDeletion{},
+		&models.AccountDeletionStep{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.PushSubscription{},
+		&models.NotificationQuietHours{},
 	)
 }
 
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/maxg56/matcha/api/common/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -40,6 +41,8 @@ func InitRedis() error {
 		DB:       db,
 	})
 
+	tracing.InstrumentRedis(RedisClient)
+
 	// Test connection
 	_, err := RedisClient.Ping(Ctx).Result()
 	if err != nil {
@@ -39,5 +39,6 @@ func GetOwnProfileHandler(c *gin.Context) {
 
 	utils.RespondSuccess(c, http.StatusOK, gin.H{
 		"profile": profile,
+		"pause":   pauseStatus(&user),
 	})
 }
\ No newline at end of file
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"user-service/src/conf"
+	"user-service/src/models"
+	"user-service/src/utils"
+)
+
+// dataExportCooldown limits how often a user can request a new archive
+const dataExportCooldown = 24 * time.Hour
+
+// RequestDataExportHandler queues a GDPR export of the authenticated user's data.
+// The archive is built in the background; the user is notified when it is ready.
+func RequestDataExportHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var latest models.DataExport
+	err = conf.DB.Where("user_id = ?", userID).Order("created_at DESC").First(&latest).Error
+	if err == nil {
+		switch {
+		case latest.Status == models.DataExportPending || latest.Status == models.DataExportProcessing:
+			utils.RespondError(c, http.StatusConflict, "an export is already in progress")
+			return
+		case latest.Status != models.DataExportFailed && time.Since(latest.CreatedAt) < dataExportCooldown:
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(latest.CreatedAt.Add(dataExportCooldown)).Seconds())))
+			utils.RespondError(c, http.StatusTooManyRequests, "only one export can be requested every 24 hours")
+			return
+		}
+	}
+
+	export := models.DataExport{
+		UserID: userID,
+		Status: models.DataExportPending,
+		Locale: utils.PreferredLanguage(c),
+	}
+	if err := conf.DB.Create(&export).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to create export")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusAccepted, gin.H{
+		"export":  export,
+		"message": "Export requested, you will be notified when it is ready",
+	})
+}
+
+// ListDataExportsHandler returns the authenticated user's exports, most recent first
+func ListDataExportsHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var exports []models.DataExport
+	if err := conf.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(20).Find(&exports).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load exports")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"exports": exports})
+}
+
+// DownloadDataExportHandler streams a ready archive to its owner
+func DownloadDataExportHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	exportID, err := strconv.ParseUint(c.Param("export_id"), 10, 32)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid export ID")
+		return
+	}
+
+	var export models.DataExport
+	if err := conf.DB.Where("id = ? AND user_id = ?", exportID, userID).First(&export).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, "export not found")
+		return
+	}
+
+	if export.Status != models.DataExportReady || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		utils.RespondError(c, http.StatusGone, "export is not available for download")
+		return
+	}
+	if _, err := os.Stat(export.FilePath); err != nil {
+		utils.RespondError(c, http.StatusGone, "export is not available for download")
+		return
+	}
+
+	c.FileAttachment(export.FilePath, "matcha-data-export.zip")
+}
@@ -103,7 +103,7 @@ func GetMatchedUsersHandler(c *gin.Context) {
 		Joins(`JOIN matches ON
 			(matches.user1_id = ? AND matches.user2_id = users.id AND matches.is_active = true) OR
 			(matches.user2_id = ? AND matches.user1_id = users.id AND matches.is_active = true)`, userID, userID).
-		Where("users.latitude IS NOT NULL AND users.longitude IS NOT NULL").
+		Where("users.latitude IS NOT NULL AND users.longitude IS NOT NULL AND users.deleted_at IS NULL").
 		Find(&matchedUsers).Error
 
 	if err != nil {
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"user-service/src/conf"
+	"user-service/src/services/notifications"
+	"user-service/src/utils"
+)
+
+// notificationDispatcher delivers notifications sent through the API, set by main
+var notificationDispatcher *notifications.Dispatcher
+
+// SetNotificationDispatcher sets the dispatcher used by the notification handlers
+func SetNotificationDispatcher(dispatcher *notifications.Dispatcher) {
+	notificationDispatcher = dispatcher
+}
+
+// vapidKeys sign Web Push requests, nil when push is not configured
+var vapidKeys *notifications.VAPIDKeys
+
+// SetVAPIDKeys sets the keys whose public part browsers subscribe with
+func SetVAPIDKeys(keys *notifications.VAPIDKeys) {
+	vapidKeys = keys
+}
+
+// notificationService runs its queries within the trace of the request
+func notificationService(c *gin.Context) *notifications.Service {
+	return notifications.NewService(conf.DB.WithContext(c.Request.Context()), notificationDispatcher)
+}
+
+// UpdateNotificationPreferencesRequest lists the kinds to change
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []notifications.PreferenceUpdate `json:"preferences" binding:"required,dive"`
+}
+
+// UnsubscribePushRequest names the subscription to remove
+type UnsubscribePushRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+}
+
+// ListNotificationsHandler lists the notifications of the authenticated user,
+// newest first (?unread=true, ?before_id=, ?limit=)
+func ListNotificationsHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var beforeID uint64
+	if raw := c.Query("before_id"); raw != "" {
+		if beforeID, err = strconv.ParseUint(raw, 10, 32); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, "invalid before_id")
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	service := notificationService(c)
+	views, err := service.List(userID, c.Query("unread") == "true", uint(beforeID), limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load notifications")
+		return
+	}
+	unread, err := service.UnreadCount(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to count notifications")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"notifications": views,
+		"unread_count":  unread,
+	})
+}
+
+// GetUnreadNotificationCountHandler returns the number of unread notifications
+func GetUnreadNotificationCountHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	unread, err := notificationService(c).UnreadCount(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to count notifications")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkNotificationReadHandler marks one notification as read
+func MarkNotificationReadHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("notification_id"), 10, 32)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid notification ID")
+		return
+	}
+
+	service := notificationService(c)
+	err = service.MarkRead(userID, uint(notificationID))
+	if errors.Is(err, notifications.ErrNotFound) {
+		utils.RespondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to mark notification as read")
+		return
+	}
+
+	unread, _ := service.UnreadCount(userID)
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"notification_id": notificationID,
+		"unread_count":    unread,
+	})
+}
+
+// MarkAllNotificationsReadHandler marks every notification as read
+func MarkAllNotificationsReadHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	marked, err := notificationService(c).MarkAllRead(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to mark notifications as read")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"marked":       marked,
+		"unread_count": 0,
+	})
+}
+
+// GetNotificationPreferencesHandler returns the channels of every kind
+func GetNotificationPreferencesHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	prefs, err := notificationService(c).Preferences(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load notification preferences")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// UpdateNotificationPreferencesHandler changes the channels of some kinds
+func UpdateNotificationPreferencesHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	prefs, err := notificationService(c).UpdatePreferences(userID, req.Preferences)
+	if errors.Is(err, notifications.ErrUnknownKind) {
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to update notification preferences")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// SendNotificationHandler lets other services notify a user. The notification
+// is stored right away and delivered on the other channels in the background.
+func SendNotificationHandler(c *gin.Context) {
+	var req notifications.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid notification: "+err.Error())
+		return
+	}
+
+	notif, err := notificationService(c).Notify(c.Request.Context(), req)
+	if errors.Is(err, notifications.ErrUnknownKind) {
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to send notification")
+		return
+	}
+
+	data := gin.H{"sent": notif != nil}
+	if notif != nil && notif.ID != 0 {
+		data["notification_id"] = notif.ID
+	}
+	utils.RespondSuccess(c, http.StatusAccepted, data)
+}
+
+// GetVAPIDPublicKeyHandler returns the applicationServerKey browsers
+// subscribe with
+func GetVAPIDPublicKeyHandler(c *gin.Context) {
+	if vapidKeys == nil {
+		utils.RespondError(c, http.StatusServiceUnavailable, "push notifications are not configured")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"public_key": vapidKeys.PublicKey})
+}
+
+// ListPushSubscriptionsHandler lists the browsers receiving push notifications
+func ListPushSubscriptionsHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	subs, err := notificationService(c).Subscriptions(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load push subscriptions")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// SubscribePushHandler registers the PushSubscription of a browser
+func SubscribePushHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var req notifications.SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid subscription: "+err.Error())
+		return
+	}
+	req.UserAgent = c.GetHeader("User-Agent")
+
+	sub, err := notificationService(c).Subscribe(userID, req)
+	if errors.Is(err, notifications.ErrInvalidSubscription) {
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to save push subscription")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusCreated, gin.H{"subscription": sub})
+}
+
+// UnsubscribePushHandler removes the subscription of a browser
+func UnsubscribePushHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var req UnsubscribePushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	if err := notificationService(c).Unsubscribe(userID, req.Endpoint); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to remove push subscription")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"endpoint": req.Endpoint})
+}
+
+// GetQuietHoursHandler returns the quiet hours, null when there are none
+func GetQuietHoursHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	quiet, err := notificationService(c).QuietHours(userID)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load quiet hours")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"quiet_hours": quiet})
+}
+
+// UpdateQuietHoursHandler sets the daily window without push notifications.
+// Empty start and end remove it.
+func UpdateQuietHoursHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var req notifications.QuietHoursUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	quiet, err := notificationService(c).SetQuietHours(userID, req)
+	if errors.Is(err, notifications.ErrInvalidQuietHours) {
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to update quiet hours")
+		return
+	}
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"quiet_hours": quiet})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"user-service/src/conf"
+	"user-service/src/models"
+	"user-service/src/services/notifications"
+)
+
+func TestNotificationHandlers(t *testing.T) {
+	testDB := setupTestDB()
+	testDB.AutoMigrate(&models.Notification{}, &models.NotificationPreference{}, &models.NotificationQuietHours{})
+	conf.DB = testDB
+	user := createTestUser(testDB)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/internal/notifications", SendNotificationHandler)
+	notifs := router.Group("/notifications")
+	notifs.Use(func(c *gin.Context) { c.Set("user_id", int(user.ID)) })
+	notifs.GET("", ListNotificationsHandler)
+	notifs.PUT("/read-all", MarkAllNotificationsReadHandler)
+	notifs.PUT("/:notification_id/read", MarkNotificationReadHandler)
+	notifs.PUT("/preferences", UpdateNotificationPreferencesHandler)
+	notifs.PUT("/quiet-hours", UpdateQuietHoursHandler)
+	notifs.GET("/push/vapid-public-key", GetVAPIDPublicKeyHandler)
+
+	request := func(method, path, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	t.Run("Other services send notifications", func(t *testing.T) {
+		code, _ := request("POST", "/internal/notifications", `{"to_user_id": 1, "kind": "like", "from_user_id": 2, "message": "Quelqu'un vous a liké"}`)
+		assert.Equal(t, http.StatusAccepted, code)
+
+		code, _ = request("POST", "/internal/notifications", `{"to_user_id": 1, "kind": "wink", "message": "?"}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Lists and marks notifications as read", func(t *testing.T) {
+		code, response := request("GET", "/notifications", "")
+		assert.Equal(t, http.StatusOK, code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(1), data["unread_count"])
+		list := data["notifications"].([]interface{})
+		assert.Len(t, list, 1)
+		assert.Equal(t, "like", list[0].(map[string]interface{})["kind"])
+
+		code, _ = request("PUT", "/notifications/1/read", "")
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = request("PUT", "/notifications/99/read", "")
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = request("PUT", "/notifications/read-all", "")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Updates preferences", func(t *testing.T) {
+		code, _ := request("PUT", "/notifications/preferences", `{"preferences": [{"kind": "like", "push": false}]}`)
+		assert.Equal(t, http.StatusOK, code)
+
+		code, _ = request("PUT", "/notifications/preferences", `{"preferences": [{"kind": "wink", "push": false}]}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Sets quiet hours", func(t *testing.T) {
+		code, response := request("PUT", "/notifications/quiet-hours", `{"start": "22:00", "end": "07:30", "time_zone": "Europe/Paris"}`)
+		assert.Equal(t, http.StatusOK, code)
+		quiet := response["data"].(map[string]interface{})["quiet_hours"].(map[string]interface{})
+		assert.Equal(t, "07:30", quiet["end"])
+
+		code, _ = request("PUT", "/notifications/quiet-hours", `{"start": "22h", "end": "07:30"}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Returns the VAPID public key once configured", func(t *testing.T) {
+		SetVAPIDKeys(nil)
+		code, _ := request("GET", "/notifications/push/vapid-public-key", "")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+
+		keys, err := notifications.GenerateVAPIDKeys("mailto:test@example.com")
+		assert.NoError(t, err)
+		SetVAPIDKeys(keys)
+		defer SetVAPIDKeys(nil)
+		code, response := request("GET", "/notifications/push/vapid-public-key", "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, keys.PublicKey, response["data"].(map[string]interface{})["public_key"])
+	})
+}
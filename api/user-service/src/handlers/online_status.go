@@ -22,12 +22,12 @@ func GetUserOnlineStatusHandler(c *gin.Context) {
 
 	// First try to get real-time status from Gateway WebSocket connections
 	gatewayPresenceService := services.NewGatewayPresenceService()
-	presence, err := gatewayPresenceService.GetUserWebSocketStatus(uint(userID))
+	presence, err := gatewayPresenceService.GetUserWebSocketStatus(c.Request.Context(), uint(userID))
 
 	if err != nil {
 		// Fallback to Redis if Gateway is unavailable
 		presenceService := services.NewPresenceService()
-		redisPresence, redisErr := presenceService.GetUserPresence(uint(userID))
+		redisPresence, redisErr := presenceService.GetUserPresence(c.Request.Context(), uint(userID))
 		if redisErr != nil {
 			utils.RespondError(c, http.StatusInternalServerError, "failed to get user status from both Gateway and Redis: "+err.Error())
 			return
@@ -1,17 +1,28 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"user-service/src/conf"
 	"user-service/src/models"
+	"user-service/src/services/accountdeletion"
+	"user-service/src/services/notifications"
 	"user-service/src/utils"
 )
 
-// DeleteProfileHandler soft deletes a user profile
+func deletionSaga() *accountdeletion.Saga {
+	return accountdeletion.NewSaga(conf.DB, accountdeletion.DefaultSteps(conf.DB))
+}
+
+// DeleteProfileHandler schedules the deletion of the user's account.
+// The profile is hidden right away and purged from every service once the
+// grace period is over; until then the deletion can be cancelled.
 func DeleteProfileHandler(c *gin.Context) {
 	userIDParam := c.Param("id")
 
@@ -21,15 +32,13 @@ func DeleteProfileHandler(c *gin.Context) {
 		return
 	}
 
-	// Get authenticated user ID from JWT middleware
-	authenticatedUserID, exists := c.Get("user_id")
-	if !exists {
-		utils.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+	authenticatedUserID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
 		return
 	}
 
 	// Users can only delete their own profile
-	if uint(id) != uint(authenticatedUserID.(int)) {
+	if uint(id) != authenticatedUserID {
 		utils.RespondError(c, http.StatusForbidden, "cannot delete another user's profile")
 		return
 	}
@@ -40,53 +49,119 @@ func DeleteProfileHandler(c *gin.Context) {
 		return
 	}
 
-	// Cascade delete: soft delete user and related data
-	tx := conf.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	alreadyScheduled := user.DeletedAt != nil
+	deletion, err := deletionSaga().Schedule(c.Request.Context(), user.ID, accountdeletion.GracePeriodFromEnv())
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to schedule account deletion")
+		return
+	}
+
+	if !alreadyScheduled {
+		frontendURL := os.Getenv("FRONTEND_URL")
+		if frontendURL == "" {
+			frontendURL = "https://localhost:8443"
+		}
+		data := map[string]interface{}{
+			"PurgeAt":   deletion.PurgeAfter.Format("02/01/2006"),
+			"CancelURL": frontendURL + "/app/settings",
+		}
+		if err := notifications.NewEmailClient().Send(c.Request.Context(), user.Email, "account_deletion_scheduled", utils.PreferredLanguage(c), data); err != nil {
+			slog.WarnContext(c.Request.Context(), "failed to email about account deletion", "user_id", user.ID, "error", err)
 		}
-	}()
+	}
+
+	utils.RespondSuccess(c, http.StatusAccepted, gin.H{
+		"message":  "Account scheduled for deletion",
+		"deletion": deletion,
+	})
+}
 
-	// Delete ALL message reactions for messages sent by this user AND reactions made by this user
-	if err := tx.Exec(`
-		DELETE FROM message_reactions 
-		WHERE message_id IN (
-			SELECT id FROM messages WHERE sender_id = ?
-		) OR user_id = ?
-	`, user.ID, user.ID).Error; err != nil {
-		tx.Rollback()
-		utils.RespondError(c, http.StatusInternalServerError, "failed to delete message reactions")
+// GetAccountDeletionHandler returns the pending deletion of the authenticated user
+func GetAccountDeletionHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
 		return
 	}
 
-	// Delete related tags
-	if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserTag{}).Error; err != nil {
-		tx.Rollback()
-		utils.RespondError(c, http.StatusInternalServerError, "failed to delete user tags")
+	deletion, err := deletionSaga().Pending(userID)
+	if errors.Is(err, accountdeletion.ErrNoDeletion) {
+		utils.RespondError(c, http.StatusNotFound, "no pending account deletion")
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load account deletion")
 		return
 	}
 
-	// Delete related images
-	if err := tx.Where("user_id = ?", user.ID).Delete(&models.Image{}).Error; err != nil {
-		tx.Rollback()
-		utils.RespondError(c, http.StatusInternalServerError, "failed to delete user images")
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"deletion": deletion})
+}
+
+// CancelAccountDeletionHandler restores the account during the grace period
+func CancelAccountDeletionHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
 		return
 	}
 
-	// Soft delete the user
-	if err := tx.Delete(&user).Error; err != nil {
-		tx.Rollback()
-		utils.RespondError(c, http.StatusInternalServerError, "failed to delete profile")
+	deletion, err := deletionSaga().Cancel(c.Request.Context(), userID)
+	switch {
+	case errors.Is(err, accountdeletion.ErrNoDeletion):
+		utils.RespondError(c, http.StatusNotFound, "no pending account deletion")
+		return
+	case errors.Is(err, accountdeletion.ErrNotCancellable):
+		utils.RespondError(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, "failed to cancel account deletion")
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		utils.RespondError(c, http.StatusInternalServerError, "failed to commit deletion")
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message":  "Account deletion cancelled",
+		"deletion": deletion,
+	})
+}
+
+// ListAccountDeletionsHandler is the admin view of deletion sagas.
+// ?stuck=true lists deletions that keep failing; ?status= filters by status.
+func ListAccountDeletionsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	deletions, err := deletionSaga().List(c.Query("status"), c.Query("stuck") == "true", limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to load account deletions")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"deletions":      deletions,
+		"stuck_attempts": accountdeletion.StuckAttempts,
+	})
+}
+
+// RetryAccountDeletionHandler makes a running deletion due immediately
+func RetryAccountDeletionHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("deletion_id"), 10, 32)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid deletion ID")
+		return
+	}
+
+	deletion, err := deletionSaga().Retry(uint(id))
+	if errors.Is(err, accountdeletion.ErrNoDeletion) {
+		utils.RespondError(c, http.StatusNotFound, "no running account deletion with this ID")
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to retry account deletion")
 		return
 	}
 
 	utils.RespondSuccess(c, http.StatusOK, gin.H{
-		"message": "Profile deleted successfully",
+		"message":  "Account deletion will be retried shortly",
+		"deletion": deletion,
 	})
 }
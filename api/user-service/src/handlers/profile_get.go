@@ -25,7 +25,13 @@ func GetProfileHandler(c *gin.Context) {
 	var user models.User
 	if err := conf.DB.Preload("Tags").Preload("Images", func(db *gorm.DB) *gorm.DB {
 		return db.Where("is_active = ?", true).Order("created_at ASC")
-	}).First(&user, id).Error; err != nil {
+	}).Where("deleted_at IS NULL").First(&user, id).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, "user not found")
+		return
+	}
+
+	// A paused profile is only visible to its owner and their matches
+	if user.IsPaused() && !canViewPausedProfile(c, user.ID) {
 		utils.RespondError(c, http.StatusNotFound, "user not found")
 		return
 	}
@@ -37,3 +43,22 @@ func GetProfileHandler(c *gin.Context) {
 		"profile": profile,
 	})
 }
+
+// canViewPausedProfile reports whether the viewer, identified by the optional
+// X-User-ID header set by the gateway, is the owner or an active match
+func canViewPausedProfile(c *gin.Context, profileID uint) bool {
+	viewerID, err := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 32)
+	if err != nil || viewerID == 0 {
+		return false
+	}
+	if uint(viewerID) == profileID {
+		return true
+	}
+
+	var count int64
+	err = conf.DB.Table("matches").
+		Where("is_active = ? AND ((user1_id = ? AND user2_id = ?) OR (user1_id = ? AND user2_id = ?))",
+			true, viewerID, profileID, profileID, viewerID).
+		Count(&count).Error
+	return err == nil && count > 0
+}
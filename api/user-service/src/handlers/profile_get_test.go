@@ -240,4 +240,42 @@ func TestGetProfileHandlerWithImages(t *testing.T) {
 	
 	assert.Contains(t, imageURLs, "https://localhost:8443/api/v1/media/get/image1.jpg")
 	assert.Contains(t, imageURLs, "https://localhost:8443/api/v1/media/get/image2.jpg")
-}
\ No newline at end of file
+}
+func TestGetProfileHandlerPausedProfile(t *testing.T) {
+	testDB := setupTestDB()
+	conf.DB = testDB
+	testDB.Exec(`CREATE TABLE matches (id INTEGER PRIMARY KEY, user1_id INTEGER, user2_id INTEGER, is_active BOOLEAN)`)
+
+	testUser := createTestUser(testDB)
+	pausedAt := time.Now()
+	testDB.Model(testUser).Update("paused_at", pausedAt)
+	testDB.Exec(`INSERT INTO matches (user1_id, user2_id, is_active) VALUES (?, 2, true)`, testUser.ID)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/profile/:id", GetProfileHandler)
+
+	cases := []struct {
+		name     string
+		viewerID string
+		expected int
+	}{
+		{"Anonymous viewer", "", http.StatusNotFound},
+		{"Not a match", "3", http.StatusNotFound},
+		{"Active match", "2", http.StatusOK},
+		{"Owner", "1", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/profile/1", nil)
+			if tc.viewerID != "" {
+				req.Header.Set("X-User-ID", tc.viewerID)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
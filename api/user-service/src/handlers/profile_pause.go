@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"user-service/src/conf"
+	"user-service/src/models"
+	"user-service/src/services/profilepause"
+	"user-service/src/utils"
+)
+
+// PauseProfileRequest represents the pause payload; both fields are optional
+type PauseProfileRequest struct {
+	Until         *time.Time `json:"until,omitempty"`
+	ResumeOnLogin bool       `json:"resume_on_login"`
+}
+
+func pauseService() *profilepause.Service {
+	return profilepause.NewService(conf.DB, profilepause.NewUserNotifier())
+}
+
+// pauseStatus describes the pause state of the user's own profile
+func pauseStatus(user *models.User) gin.H {
+	return gin.H{
+		"paused":          user.IsPaused(),
+		"paused_at":       user.PausedAt,
+		"pause_until":     user.PauseUntil,
+		"resume_on_login": user.ResumeOnLogin,
+	}
+}
+
+// PauseProfileHandler hides the authenticated user's profile from matching,
+// search and non-matches. Matches and conversations are kept.
+func PauseProfileHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	var req PauseProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondError(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	user, err := pauseService().Pause(c.Request.Context(), userID, req.Until, req.ResumeOnLogin)
+	switch {
+	case errors.Is(err, profilepause.ErrInvalidUntil):
+		utils.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, profilepause.ErrDeletionPending):
+		utils.RespondError(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, "failed to pause profile")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message": "Profile paused",
+		"pause":   pauseStatus(user),
+	})
+}
+
+// ResumeProfileHandler makes the authenticated user's profile visible again
+func ResumeProfileHandler(c *gin.Context) {
+	userID, err := utils.GetAuthenticatedUserID(c)
+	if err != nil {
+		return
+	}
+
+	user, err := pauseService().Resume(c.Request.Context(), userID)
+	switch {
+	case errors.Is(err, profilepause.ErrNotPaused):
+		utils.RespondError(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, "failed to resume profile")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{
+		"message": "Profile resumed",
+		"pause":   pauseStatus(user),
+	})
+}
+
+// ResumeOnLoginHandler is called by auth-service once a user has logged in,
+// to end a pause they asked to end on their next login
+func ResumeOnLoginHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || userID == 0 {
+		utils.RespondError(c, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	resumed, err := pauseService().ResumeOnLogin(c.Request.Context(), uint(userID))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, "user not found")
+		return
+	case err != nil:
+		utils.RespondError(c, http.StatusInternalServerError, "failed to resume profile")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusOK, gin.H{"resumed": resumed})
+}
@@ -28,7 +28,7 @@ func TrackProfileViewHandler(c *gin.Context) {
 
 	// Track profile view using service
 	service := services.NewProfileViewService()
-	tracked, err := service.TrackProfileView(viewerID, uint(viewedID))
+	tracked, err := service.TrackProfileView(c.Request.Context(), viewerID, uint(viewedID))
 	if err != nil {
 		if appErr, ok := err.(*utils.AppError); ok {
 			utils.RespondError(c, appErr.StatusCode, appErr.Message)
@@ -59,10 +59,11 @@ func CreateReportHandler(c *gin.Context) {
 	// Create new report
 	report := models.UserReport{
 		ReporterID:  reporterID,
-		ReportedID:  req.ReportedID,
+		ReportedID:  &req.ReportedID,
 		ReportType:  req.ReportType,
 		Description: req.Description,
 		Status:      "pending",
+		Source:      models.ReportSourceUser,
 	}
 
 	if err := conf.DB.Create(&report).Error; err != nil {
@@ -82,6 +83,70 @@ func CreateReportHandler(c *gin.Context) {
 	})
 }
 
+// AutomaticReportRequest is a report filed by another service on behalf of
+// a user, such as chat-service blocking a message sent to them
+type AutomaticReportRequest struct {
+	ReporterID  uint   `json:"reporter_id" binding:"required"`
+	ReportedID  uint   `json:"reported_id" binding:"required"`
+	ReportType  string `json:"report_type" binding:"required,oneof=fake_account inappropriate_content harassment spam other"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// CreateAutomaticReportHandler records a report filed by another service.
+// While a pending automatic report of the same type exists it is returned
+// instead of a new one, so that a burst of blocked messages is reviewed once.
+func CreateAutomaticReportHandler(c *gin.Context) {
+	var req AutomaticReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, "invalid report data: "+err.Error())
+		return
+	}
+	if req.ReportedID == req.ReporterID {
+		utils.RespondError(c, http.StatusBadRequest, "cannot report yourself")
+		return
+	}
+
+	var reportedUser models.User
+	if err := conf.DB.First(&reportedUser, req.ReportedID).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, "reported user not found")
+		return
+	}
+
+	var existing models.UserReport
+	if err := conf.DB.Where("reporter_id = ? AND reported_id = ? AND report_type = ? AND source = ? AND status = ?",
+		req.ReporterID, req.ReportedID, req.ReportType, models.ReportSourceAutomatic, "pending").First(&existing).Error; err == nil {
+		utils.RespondSuccess(c, http.StatusOK, gin.H{"report": reportSummary(existing)})
+		return
+	}
+
+	report := models.UserReport{
+		ReporterID:  req.ReporterID,
+		ReportedID:  &req.ReportedID,
+		ReportType:  req.ReportType,
+		Description: req.Description,
+		Status:      "pending",
+		Source:      models.ReportSourceAutomatic,
+	}
+	if err := conf.DB.Create(&report).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, "failed to create report")
+		return
+	}
+
+	utils.RespondSuccess(c, http.StatusCreated, gin.H{"report": reportSummary(report)})
+}
+
+func reportSummary(report models.UserReport) gin.H {
+	return gin.H{
+		"id":          report.ID,
+		"reporter_id": report.ReporterID,
+		"reported_id": report.ReportedID,
+		"report_type": report.ReportType,
+		"source":      report.Source,
+		"status":      report.Status,
+		"created_at":  report.CreatedAt,
+	}
+}
+
 // GetUserReportsHandler gets reports submitted by the authenticated user
 func GetUserReportsHandler(c *gin.Context) {
 	// Get authenticated user ID
@@ -125,18 +190,23 @@ func GetUserReportsHandler(c *gin.Context) {
 	// Format response
 	var reportData []gin.H
 	for _, report := range reports {
-		reportData = append(reportData, gin.H{
-			"id":           report.ID,
-			"reported_id":  report.ReportedID,
-			"report_type":  report.ReportType,
-			"description":  report.Description,
-			"status":       report.Status,
-			"created_at":   report.CreatedAt,
-			"updated_at":   report.UpdatedAt,
-			"reported_user": gin.H{
+		// The reported account may have been deleted since
+		var reportedUser gin.H
+		if report.ReportedID != nil {
+			reportedUser = gin.H{
 				"id":       report.Reported.ID,
 				"username": report.Reported.Username,
-			},
+			}
+		}
+		reportData = append(reportData, gin.H{
+			"id":            report.ID,
+			"reported_id":   report.ReportedID,
+			"report_type":   report.ReportType,
+			"description":   report.Description,
+			"status":        report.Status,
+			"created_at":    report.CreatedAt,
+			"updated_at":    report.UpdatedAt,
+			"reported_user": reportedUser,
 		})
 	}
 
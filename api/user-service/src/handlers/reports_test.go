@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"user-service/src/conf"
+	"user-service/src/models"
+)
+
+func TestCreateAutomaticReportHandler(t *testing.T) {
+	testDB := setupTestDB()
+	conf.DB = testDB
+	reported := createTestUser(testDB)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/internal/reports", CreateAutomaticReportHandler)
+
+	post := func(body string) int {
+		req, _ := http.NewRequest("POST", "/internal/reports", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	body := `{"reporter_id": 42, "reported_id": 1, "report_type": "harassment", "description": "blocked message"}`
+
+	t.Run("Creates a pending automatic report", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, post(body))
+
+		var report models.UserReport
+		assert.NoError(t, testDB.Where("reported_id = ?", reported.ID).First(&report).Error)
+		assert.Equal(t, uint(42), report.ReporterID)
+		assert.Equal(t, models.ReportSourceAutomatic, report.Source)
+		assert.Equal(t, "pending", report.Status)
+	})
+
+	t.Run("Reuses the pending report", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, post(body))
+
+		var count int64
+		testDB.Model(&models.UserReport{}).Where("reported_id = ?", reported.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Unknown reported user", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, post(`{"reporter_id": 42, "reported_id": 999, "report_type": "spam"}`))
+	})
+
+	t.Run("Invalid report type", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post(`{"reporter_id": 42, "reported_id": 1, "report_type": "rude"}`))
+	})
+}
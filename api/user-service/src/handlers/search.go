@@ -77,7 +77,9 @@ func SearchUsersHandler(c *gin.Context) {
 	query := conf.DB.Model(&models.User{}).
 		Preload("Tags").
 		Preload("Images", "is_active = ?", true).
-		Where("id != ?", userID) // Exclude current user
+		Where("id != ?", userID). // Exclude current user
+		Where("deleted_at IS NULL"). // and accounts waiting for deletion
+		Where("paused_at IS NULL")   // or on a break
 
 	// Apply filters
 	if req.AgeMin != nil {
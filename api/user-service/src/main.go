@@ -1,37 +1,110 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxg56/matcha/api/common/internalapi"
+	"github.com/maxg56/matcha/api/common/logging"
+	"github.com/maxg56/matcha/api/common/metrics"
+	"github.com/maxg56/matcha/api/common/server"
+	"github.com/maxg56/matcha/api/common/tracing"
 
 	"user-service/src/conf"
 	"user-service/src/handlers"
 	"user-service/src/middleware"
+	"user-service/src/services"
+	"user-service/src/services/accountdeletion"
+	"user-service/src/services/dataexport"
+	"user-service/src/services/notifications"
+	"user-service/src/services/profilepause"
 )
 
 func main() {
+	logging.Init("user-service")
+
+	// Initialize tracing (OTEL_TRACES_EXPORTER)
+	shutdownTracing, err := tracing.Init("user-service")
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	lc := server.New("user-service")
+
 	// Initialize database
 	conf.InitDB()
+	lc.AddCheck("database", server.DBCheck(conf.DB))
+	lc.OnClose("database", server.CloseDB(conf.DB))
 
 	// Initialize Redis
 	if err := conf.InitRedis(); err != nil {
-		log.Printf("Warning: Failed to connect to Redis: %v", err)
-		log.Println("Some features may not work properly without Redis")
+		slog.Warn("failed to connect to redis, some features may not work", "error", err)
+	} else {
+		slog.Info("redis connected")
+	}
+	lc.AddCheck("redis", server.RedisCheck(conf.RedisClient))
+	lc.OnClose("redis", func(ctx context.Context) error { return conf.RedisClient.Close() })
+
+	// Build requested data exports in the background
+	exportWorker := dataexport.NewWorker(conf.DB, dataexport.NewExporterFromEnv(conf.DB), dataexport.NewUserNotifier(conf.DB))
+	lc.Go("data-export", exportWorker.Run)
+
+	// Purge accounts whose deletion grace period is over
+	deletionSaga := accountdeletion.NewSaga(conf.DB, accountdeletion.DefaultSteps(conf.DB))
+	lc.Go("account-deletion", deletionSaga.Run)
+
+	// Resume paused profiles whose end date has passed
+	lc.Go("profile-pause", profilepause.NewService(conf.DB, profilepause.NewUserNotifier()).Run)
+
+	// Deliver notifications in the background, and send the ones other
+	// services queue on Redis
+	channels := []notifications.Channel{
+		notifications.NewRealtimeChannel(conf.RedisClient),
+		notifications.NewEmailChannel(conf.DB, notifications.NewEmailClient()),
+	}
+	// Web Push reaches users with no WebSocket open on the gateway
+	if vapidKeys, err := notifications.LoadVAPIDKeys(); err != nil {
+		slog.Warn("web push disabled", "error", err)
 	} else {
-		log.Println("Redis connection established successfully")
+		presence := services.NewGatewayPresenceService()
+		channels = append(channels, notifications.NewPushChannel(conf.DB, notifications.NewWebPushClient(vapidKeys),
+			func(ctx context.Context, userID uint) (bool, error) {
+				status, err := presence.GetUserWebSocketStatus(ctx, userID)
+				if err != nil {
+					return false, err
+				}
+				return status.IsOnline, nil
+			}))
+		handlers.SetVAPIDKeys(vapidKeys)
 	}
+	// Notifications still queued at shutdown are delivered before exiting
+	notificationDispatcher := notifications.NewDispatcher(channels...)
+	lc.Go("notification-dispatcher", notificationDispatcher.Run)
+	lc.OnFlush("notifications", notificationDispatcher.Flush)
+	handlers.SetNotificationDispatcher(notificationDispatcher)
+	notificationService := notifications.NewService(conf.DB, notificationDispatcher)
+	lc.Go("notification-queue", func(ctx context.Context) {
+		notificationService.ConsumeQueue(ctx, conf.RedisClient)
+	})
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), logging.Middleware())
+	r.Use(tracing.Middleware("user-service"))
+	metrics.Register(r)
 
-	// Health check
+	// Health check, liveness and readiness probes
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "ok",
 			"service": "user-service",
 		})
 	})
+	lc.RegisterProbes(r)
 
 	// User routes
 	users := r.Group("/api/v1/users")
@@ -76,6 +149,19 @@ func main() {
 			protected.GET("/profile/views/stats", handlers.GetProfileViewStatsHandler)
 			protected.GET("/profile/views/history", handlers.GetMyProfileViewsHandler)
 
+			// GDPR data export
+			protected.POST("/me/export", handlers.RequestDataExportHandler)
+			protected.GET("/me/exports", handlers.ListDataExportsHandler)
+			protected.GET("/me/exports/:export_id/download", handlers.DownloadDataExportHandler)
+
+			// Account deletion
+			protected.GET("/me/deletion", handlers.GetAccountDeletionHandler)
+			protected.POST("/me/deletion/cancel", handlers.CancelAccountDeletionHandler)
+
+			// Pause ("take a break") mode
+			protected.POST("/me/pause", handlers.PauseProfileHandler)
+			protected.POST("/me/resume", handlers.ResumeProfileHandler)
+
 			// Media management
 			protected.PUT("/:id/images/order", handlers.UpdateImageOrderHandler)
 			protected.DELETE("/:id/images/:image_id", handlers.DeleteImageHandler)
@@ -83,6 +169,43 @@ func main() {
 		}
 	}
 
+	// Notification inbox, preferences and push subscriptions
+	notifs := r.Group("/api/v1/notifications")
+	notifs.Use(middleware.AuthMiddleware())
+	{
+		notifs.GET("", handlers.ListNotificationsHandler)
+		notifs.GET("/unread-count", handlers.GetUnreadNotificationCountHandler)
+		notifs.PUT("/read-all", handlers.MarkAllNotificationsReadHandler)
+		notifs.PUT("/:notification_id/read", handlers.MarkNotificationReadHandler)
+		notifs.GET("/preferences", handlers.GetNotificationPreferencesHandler)
+		notifs.PUT("/preferences", handlers.UpdateNotificationPreferencesHandler)
+		notifs.GET("/quiet-hours", handlers.GetQuietHoursHandler)
+		notifs.PUT("/quiet-hours", handlers.UpdateQuietHoursHandler)
+
+		// Web Push
+		notifs.GET("/push/vapid-public-key", handlers.GetVAPIDPublicKeyHandler)
+		notifs.GET("/push/subscriptions", handlers.ListPushSubscriptionsHandler)
+		notifs.POST("/push/subscriptions", handlers.SubscribePushHandler)
+		notifs.DELETE("/push/subscriptions", handlers.UnsubscribePushHandler)
+	}
+
+	// Admin routes (ADMIN_USER_IDS, checked here as well as by the gateway)
+	admin := r.Group("/api/v1/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.GET("/deletions", handlers.ListAccountDeletionsHandler)
+		admin.POST("/deletions/:deletion_id/retry", handlers.RetryAccountDeletionHandler)
+	}
+
+	// Internal routes (called by other services)
+	internal := r.Group("/api/v1/internal")
+	internal.Use(internalapi.Middleware())
+	{
+		internal.POST("/reports", handlers.CreateAutomaticReportHandler)
+		internal.POST("/notifications", handlers.SendNotificationHandler)
+		internal.POST("/users/:id/resume-on-login", handlers.ResumeOnLoginHandler)
+	}
+
 	// Location API routes (matching frontend expectations)
 	location := r.Group("/api/v1/location")
 	location.Use(middleware.AuthMiddleware())
@@ -94,6 +217,6 @@ func main() {
 		location.GET("/reverse-geocode", handlers.ReverseGeocodeHandler)
 	}
 
-	log.Println("User service starting on port 8002")
-	log.Fatal(http.ListenAndServe(":8002", r))
+	slog.Info("user service starting", "port", 8002)
+	lc.Run(":8002", r)
 }
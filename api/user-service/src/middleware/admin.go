@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"user-service/src/utils"
+)
+
+// AdminMiddleware checks that the user set by AuthMiddleware is listed in
+// ADMIN_USER_IDS, the comma-separated list also read by the gateway. The
+// check does not rely on the gateway: without any administrator configured,
+// admin routes are closed.
+func AdminMiddleware() gin.HandlerFunc {
+	admins := make(map[int]bool)
+	for _, id := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if userID, err := strconv.Atoi(id); err == nil && userID > 0 {
+			admins[userID] = true
+		} else {
+			slog.Warn("ignoring invalid ADMIN_USER_IDS entry", "entry", id)
+		}
+	}
+
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			utils.RespondError(c, http.StatusUnauthorized, "user not authenticated")
+			c.Abort()
+			return
+		}
+		if id, _ := userID.(int); !admins[id] {
+			slog.WarnContext(c.Request.Context(), "admin access denied", "user_id", userID, "path", c.Request.URL.Path)
+			utils.RespondError(c, http.StatusForbidden, "admin privileges required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_USER_IDS", "7, 12,oops")
+
+	r := gin.New()
+	r.GET("/api/v1/admin/deletions", AuthMiddleware(), AdminMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		userID string
+		status int
+	}{
+		{"12", http.StatusOK},
+		{"7", http.StatusOK},
+		{"8", http.StatusForbidden},
+		{"", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/deletions", nil)
+		if tt.userID != "" {
+			req.Header.Set("X-User-ID", tt.userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.status, w.Code, "user %q", tt.userID)
+	}
+}
+
+func TestAdminMiddleware_ClosedWithoutAdministrators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_USER_IDS", "")
+
+	r := gin.New()
+	r.GET("/api/v1/admin/deletions", AuthMiddleware(), AdminMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/deletions", nil)
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Account deletion statuses
+const (
+	AccountDeletionScheduled = "scheduled"
+	AccountDeletionRunning   = "running"
+	AccountDeletionCompleted = "completed"
+	AccountDeletionCancelled = "cancelled"
+)
+
+// Account deletion step statuses
+const (
+	DeletionStepPending = "pending"
+	DeletionStepDone    = "done"
+)
+
+// AccountDeletion tracks the deletion saga of an account. The user is soft deleted
+// when the request is made and purged from every service once PurgeAfter is reached.
+// UserID has no foreign key so the record outlives the user for auditing.
+type AccountDeletion struct {
+	ID            uint                  `gorm:"primaryKey;column:id" json:"id"`
+	UserID        uint                  `gorm:"column:user_id;not null;index" json:"user_id"`
+	Status        string                `gorm:"column:status;type:varchar(16);not null;default:scheduled;index" json:"status"`
+	PurgeAfter    time.Time             `gorm:"column:purge_after;not null" json:"purge_after"`
+	Attempts      int                   `gorm:"column:attempts;default:0" json:"attempts"`
+	NextAttemptAt time.Time             `gorm:"column:next_attempt_at;not null" json:"next_attempt_at"`
+	LastError     string                `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	CreatedAt     time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	CancelledAt   *time.Time            `gorm:"column:cancelled_at" json:"cancelled_at,omitempty"`
+	CompletedAt   *time.Time            `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	Steps         []AccountDeletionStep `gorm:"foreignKey:DeletionID" json:"steps,omitempty"`
+}
+
+func (AccountDeletion) TableName() string { return "account_deletions" }
+
+// AccountDeletionStep is one per-service step of a deletion saga
+type AccountDeletionStep struct {
+	ID          uint       `gorm:"primaryKey;column:id" json:"id"`
+	DeletionID  uint       `gorm:"column:deletion_id;not null;uniqueIndex:idx_account_deletion_step" json:"-"`
+	Name        string     `gorm:"column:name;type:varchar(32);not null;uniqueIndex:idx_account_deletion_step" json:"name"`
+	Position    int        `gorm:"column:position;not null" json:"position"`
+	Status      string     `gorm:"column:status;type:varchar(16);not null;default:pending" json:"status"`
+	Attempts    int        `gorm:"column:attempts;default:0" json:"attempts"`
+	LastError   string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (AccountDeletionStep) TableName() string { return "account_deletion_steps" }
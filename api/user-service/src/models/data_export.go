@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Data export statuses
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+	DataExportExpired    = "expired"
+)
+
+// DataExport is a user-requested GDPR export ("download my data").
+// The archive is built in the background and can be downloaded until ExpiresAt.
+type DataExport struct {
+	ID          uint       `gorm:"primaryKey;column:id" json:"id"`
+	UserID      uint       `gorm:"column:user_id;not null;index" json:"user_id"`
+	Status      string     `gorm:"column:status;type:varchar(16);not null;default:pending;index" json:"status"`
+	Locale      string     `gorm:"column:locale;size:8" json:"-"`
+	FilePath    string     `gorm:"column:file_path;size:500" json:"-"`
+	FileSize    int64      `gorm:"column:file_size;default:0" json:"file_size"`
+	Error       string     `gorm:"column:error;type:text" json:"error,omitempty"`
+	Attempts    int        `gorm:"column:attempts;default:0" json:"attempts"`
+	CreatedAt   time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at" json:"expires_at,omitempty"`
+}
+
+func (DataExport) TableName() string { return "data_exports" }
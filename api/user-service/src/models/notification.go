@@ -2,16 +2,66 @@ package models
 
 import "time"
 
-// Notification represents a notification sent to a user
+// Notification represents a notification sent to a user.
+//
+// Version 1 rows were written by the former notify-service and only carry the
+// numeric notif_type and msg; version 2 rows also carry a typed kind, the
+// sender and structured data.
 type Notification struct {
-	ID        uint      `gorm:"primaryKey;column:id" json:"id"`
-	ToUserID  uint      `gorm:"column:to_user_id;not null" json:"to_user_id"`
-	NotifType string    `gorm:"column:notif_type;not null;size:10" json:"notif_type"`
-	Msg       string    `gorm:"column:msg;not null" json:"msg"`
-	Time      time.Time `gorm:"column:time;default:CURRENT_TIMESTAMP" json:"time"`
+	ID         uint           `gorm:"primaryKey;column:id" json:"id"`
+	ToUserID   uint           `gorm:"column:to_user_id;not null;index" json:"to_user_id"`
+	NotifType  string         `gorm:"column:notif_type;not null;size:10" json:"notif_type"`
+	Kind       string         `gorm:"column:kind;size:30" json:"kind"`
+	Version    int            `gorm:"column:version;not null;default:1" json:"version"`
+	FromUserID *uint          `gorm:"column:from_user_id" json:"from_user_id,omitempty"`
+	Msg        string         `gorm:"column:msg;not null" json:"msg"`
+	Data       map[string]any `gorm:"column:data;type:text;serializer:json" json:"data,omitempty"`
+	Time       time.Time      `gorm:"column:time;default:CURRENT_TIMESTAMP" json:"time"`
+	ReadAt     *time.Time     `gorm:"column:read_at" json:"read_at"`
 
 	// Relations
 	ToUser User `gorm:"foreignKey:ToUserID;references:ID"`
 }
 
-func (Notification) TableName() string { return "notifications" }
\ No newline at end of file
+func (Notification) TableName() string { return "notifications" }
+
+// NotificationPreference holds the delivery channels a user chose for one
+// kind of notification. Kinds without a row use their defaults.
+type NotificationPreference struct {
+	UserID    uint      `gorm:"primaryKey;column:user_id" json:"-"`
+	Kind      string    `gorm:"primaryKey;column:kind;size:30" json:"kind"`
+	InApp     bool      `gorm:"column:in_app;not null" json:"in_app"`
+	Email     bool      `gorm:"column:email;not null" json:"email"`
+	Push      bool      `gorm:"column:push;not null" json:"push"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (NotificationPreference) TableName() string { return "notification_preferences" }
+
+// PushSubscription is a Web Push subscription of one browser, as returned by
+// PushManager.subscribe() on the client
+type PushSubscription struct {
+	ID         uint       `gorm:"primaryKey;column:id" json:"id"`
+	UserID     uint       `gorm:"column:user_id;not null;index" json:"-"`
+	Endpoint   string     `gorm:"column:endpoint;type:text;not null;uniqueIndex" json:"endpoint"`
+	P256dh     string     `gorm:"column:p256dh;size:100;not null" json:"-"`
+	Auth       string     `gorm:"column:auth;size:50;not null" json:"-"`
+	UserAgent  string     `gorm:"column:user_agent;size:255" json:"user_agent"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+}
+
+func (PushSubscription) TableName() string { return "push_subscriptions" }
+
+// NotificationQuietHours is the daily window during which a user gets no
+// push notifications. Start and end are HH:MM in the user's time zone; the
+// window wraps past midnight when end is before start.
+type NotificationQuietHours struct {
+	UserID    uint      `gorm:"primaryKey;column:user_id" json:"-"`
+	Start     string    `gorm:"column:start_time;size:5;not null" json:"start"`
+	End       string    `gorm:"column:end_time;size:5;not null" json:"end"`
+	TimeZone  string    `gorm:"column:time_zone;size:64;not null" json:"time_zone"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (NotificationQuietHours) TableName() string { return "notification_quiet_hours" }
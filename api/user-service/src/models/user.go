@@ -56,6 +56,12 @@ type User struct {
 	Longitude sql.NullFloat64 `gorm:"column:longitude" json:"longitude"`
 	CreatedAt time.Time       `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time       `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt *time.Time      `gorm:"column:deleted_at;index" json:"-"` // set during the deletion grace period
+
+	// Pause ("take a break"): the profile is hidden from non-matches until resumed
+	PausedAt      *time.Time `gorm:"column:paused_at" json:"paused_at,omitempty"`
+	PauseUntil    *time.Time `gorm:"column:pause_until" json:"pause_until,omitempty"`
+	ResumeOnLogin bool       `gorm:"column:resume_on_login;default:false" json:"resume_on_login"`
 
 	// Relations
 	Tags   []Tag   `gorm:"many2many:user_tags;" json:"tags,omitempty"`
@@ -64,6 +70,9 @@ type User struct {
 
 func (User) TableName() string { return "users" }
 
+// IsPaused reports whether the user paused their profile
+func (u *User) IsPaused() bool { return u.PausedAt != nil }
+
 // PublicProfile returns a safe version of user data for public viewing
 type PublicProfile struct {
 	ID                  uint      `json:"id"`
@@ -6,10 +6,11 @@ import "time"
 type UserReport struct {
 	ID            uint      `gorm:"primaryKey;column:id" json:"id"`
 	ReporterID    uint      `gorm:"column:reporter_id;not null" json:"reporter_id"`
-	ReportedID    uint      `gorm:"column:reported_id;not null" json:"reported_id"`
+	ReportedID    *uint     `gorm:"column:reported_id" json:"reported_id"` // nil once the reported account is deleted
 	ReportType    string    `gorm:"column:report_type;not null" json:"report_type"` // fake_account, inappropriate_content, harassment, spam, other
 	Description   string    `gorm:"column:description;size:500" json:"description"`
 	Status        string    `gorm:"column:status;default:pending" json:"status"` // pending, reviewed, resolved, dismissed
+	Source        string    `gorm:"column:source;size:20;default:user" json:"source"` // user, automatic
 	AdminNotes    string    `gorm:"column:admin_notes;size:500" json:"admin_notes,omitempty"`
 	CreatedAt     time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt     time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
@@ -20,4 +21,10 @@ type UserReport struct {
 	Reported User `gorm:"foreignKey:ReportedID;references:ID"`
 }
 
-func (UserReport) TableName() string { return "user_reports" }
\ No newline at end of file
+func (UserReport) TableName() string { return "user_reports" }
+
+// Sources of a report
+const (
+	ReportSourceUser      = "user"      // submitted by the reporter
+	ReportSourceAutomatic = "automatic" // filed by chat screening on behalf of the recipient
+)
@@ -0,0 +1,338 @@
+// Package accountdeletion runs the account deletion saga. A deletion request
+// soft deletes the user right away; once the grace period is over every
+// service purges its own data through an idempotent step, retried with
+// backoff until it succeeds. Steps run in order and a failed step blocks
+// the following ones, so the user row is always removed last.
+package accountdeletion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+const (
+	// DefaultGracePeriod before a scheduled deletion is carried out
+	DefaultGracePeriod = 30 * 24 * time.Hour
+	// StuckAttempts is the number of failed runs after which a deletion is reported as stuck
+	StuckAttempts = 5
+	// lease keeps a running deletion from being picked twice; a crashed worker's lease simply expires
+	lease = 10 * time.Minute
+	// stepTimeout bounds a single step
+	stepTimeout = 2 * time.Minute
+)
+
+var (
+	// ErrNotCancellable is returned when the purge has already started
+	ErrNotCancellable = errors.New("account deletion can no longer be cancelled")
+	// ErrNoDeletion is returned when the user has no pending deletion
+	ErrNoDeletion = errors.New("no pending account deletion")
+)
+
+// Step deletes the data of one service. It must be idempotent:
+// running it again after a success, or after a partial failure, is safe.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, userID uint) error
+}
+
+// Saga schedules and carries out account deletions
+type Saga struct {
+	db       *gorm.DB
+	steps    []Step
+	interval time.Duration
+}
+
+// NewSaga creates a saga running the given steps in order
+func NewSaga(db *gorm.DB, steps []Step) *Saga {
+	return &Saga{db: db, steps: steps, interval: time.Minute}
+}
+
+// Schedule soft deletes the user and plans the purge after the grace period.
+// Scheduling an account that is already waiting for deletion returns the existing saga.
+func (s *Saga) Schedule(ctx context.Context, userID uint, grace time.Duration) (*models.AccountDeletion, error) {
+	if existing, err := s.Pending(userID); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, ErrNoDeletion) {
+		return nil, err
+	}
+
+	now := time.Now()
+	deletion := models.AccountDeletion{
+		UserID:        userID,
+		Status:        models.AccountDeletionScheduled,
+		PurgeAfter:    now.Add(grace),
+		NextAttemptAt: now.Add(grace),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&deletion).Error; err != nil {
+			return err
+		}
+		for i, step := range s.steps {
+			deletion.Steps = append(deletion.Steps, models.AccountDeletionStep{
+				DeletionID: deletion.ID,
+				Name:       step.Name,
+				Position:   i,
+				Status:     models.DeletionStepPending,
+			})
+		}
+		if len(deletion.Steps) > 0 {
+			if err := tx.Create(&deletion.Steps).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+
+	slog.InfoContext(ctx, "account scheduled for deletion", "user_id", userID, "purge_after", deletion.PurgeAfter)
+	return &deletion, nil
+}
+
+// Pending returns the scheduled or running deletion of a user
+func (s *Saga) Pending(userID uint) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := s.db.Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("user_id = ? AND status IN ?", userID, []string{models.AccountDeletionScheduled, models.AccountDeletionRunning}).
+		First(&deletion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoDeletion
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// Cancel restores an account during its grace period
+func (s *Saga) Cancel(ctx context.Context, userID uint) (*models.AccountDeletion, error) {
+	deletion, err := s.Pending(userID)
+	if err != nil {
+		return nil, err
+	}
+	if deletion.Status != models.AccountDeletionScheduled {
+		return nil, ErrNotCancellable
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Guard against the worker claiming the deletion in the meantime
+		result := tx.Model(&models.AccountDeletion{}).
+			Where("id = ? AND status = ?", deletion.ID, models.AccountDeletionScheduled).
+			Updates(map[string]interface{}{
+				"status":       models.AccountDeletionCancelled,
+				"cancelled_at": now,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrNotCancellable
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deletion.Status = models.AccountDeletionCancelled
+	deletion.CancelledAt = &now
+	slog.InfoContext(ctx, "account deletion cancelled", "user_id", userID)
+	return deletion, nil
+}
+
+// Run processes due deletions until ctx is cancelled
+func (s *Saga) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "account deletion worker started")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.ProcessDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "account deletion worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue runs every deletion whose grace period is over and whose next attempt is due
+func (s *Saga) ProcessDue(ctx context.Context) {
+	now := time.Now()
+	var due []models.AccountDeletion
+	if err := s.db.
+		Where("status IN ? AND purge_after <= ? AND next_attempt_at <= ?",
+			[]string{models.AccountDeletionScheduled, models.AccountDeletionRunning}, now, now).
+		Order("next_attempt_at").
+		Limit(10).
+		Find(&due).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to load due account deletions", "error", err)
+		return
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if !s.claim(&due[i]) {
+			continue
+		}
+		s.execute(ctx, &due[i])
+	}
+}
+
+// claim marks the deletion as running and takes a lease on it
+func (s *Saga) claim(deletion *models.AccountDeletion) bool {
+	now := time.Now()
+	result := s.db.Model(&models.AccountDeletion{}).
+		Where("id = ? AND status IN ? AND next_attempt_at <= ?", deletion.ID,
+			[]string{models.AccountDeletionScheduled, models.AccountDeletionRunning}, now).
+		Updates(map[string]interface{}{
+			"status":          models.AccountDeletionRunning,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(lease),
+			"updated_at":      now,
+		})
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false
+	}
+	deletion.Status = models.AccountDeletionRunning
+	deletion.Attempts++
+	return true
+}
+
+func (s *Saga) execute(ctx context.Context, deletion *models.AccountDeletion) {
+	var states []models.AccountDeletionStep
+	if err := s.db.Where("deletion_id = ?", deletion.ID).Find(&states).Error; err != nil {
+		s.fail(ctx, deletion, fmt.Errorf("failed to load steps: %w", err))
+		return
+	}
+	done := make(map[string]bool, len(states))
+	for _, st := range states {
+		done[st.Name] = st.Status == models.DeletionStepDone
+	}
+
+	for _, step := range s.steps {
+		if done[step.Name] {
+			continue
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+		err := step.Run(stepCtx, deletion.UserID)
+		cancel()
+
+		now := time.Now()
+		if err != nil {
+			s.db.Model(&models.AccountDeletionStep{}).
+				Where("deletion_id = ? AND name = ?", deletion.ID, step.Name).
+				Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": err.Error(),
+					"updated_at": now,
+				})
+			s.fail(ctx, deletion, fmt.Errorf("step %s: %w", step.Name, err))
+			return
+		}
+
+		s.db.Model(&models.AccountDeletionStep{}).
+			Where("deletion_id = ? AND name = ?", deletion.ID, step.Name).
+			Updates(map[string]interface{}{
+				"status":       models.DeletionStepDone,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"last_error":   "",
+				"completed_at": now,
+				"updated_at":   now,
+			})
+	}
+
+	now := time.Now()
+	s.db.Model(&models.AccountDeletion{}).Where("id = ?", deletion.ID).Updates(map[string]interface{}{
+		"status":       models.AccountDeletionCompleted,
+		"last_error":   "",
+		"completed_at": now,
+		"updated_at":   now,
+	})
+	slog.InfoContext(ctx, "account deleted", "user_id", deletion.UserID, "deletion_id", deletion.ID, "attempts", deletion.Attempts)
+}
+
+// fail records the error and schedules the next attempt
+func (s *Saga) fail(ctx context.Context, deletion *models.AccountDeletion, err error) {
+	slog.WarnContext(ctx, "account deletion failed", "user_id", deletion.UserID, "deletion_id", deletion.ID, "attempt", deletion.Attempts, "error", err)
+	s.db.Model(&models.AccountDeletion{}).Where("id = ?", deletion.ID).Updates(map[string]interface{}{
+		"last_error":      err.Error(),
+		"next_attempt_at": time.Now().Add(Backoff(deletion.Attempts)),
+		"updated_at":      time.Now(),
+	})
+}
+
+// Retry makes a running deletion due immediately, e.g. after an admin fixed the failing service
+func (s *Saga) Retry(id uint) (*models.AccountDeletion, error) {
+	result := s.db.Model(&models.AccountDeletion{}).
+		Where("id = ? AND status = ?", id, models.AccountDeletionRunning).
+		Updates(map[string]interface{}{
+			"next_attempt_at": time.Now(),
+			"updated_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, ErrNoDeletion
+	}
+	return s.Get(id)
+}
+
+// Get returns a deletion with its steps
+func (s *Saga) Get(id uint) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := s.db.Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).First(&deletion, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoDeletion
+	}
+	return &deletion, err
+}
+
+// List returns deletions for the admin view. With stuck set, only running
+// deletions that failed at least StuckAttempts times are returned.
+func (s *Saga) List(status string, stuck bool, limit int) ([]models.AccountDeletion, error) {
+	query := s.db.Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+	if stuck {
+		query = query.Where("status = ? AND attempts >= ?", models.AccountDeletionRunning, StuckAttempts)
+	} else if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deletions []models.AccountDeletion
+	err := query.Order("updated_at DESC").Limit(limit).Find(&deletions).Error
+	return deletions, err
+}
+
+// Backoff returns the delay before the next run: one minute doubling per attempt,
+// capped at six hours, with 20% jitter
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := time.Minute
+	for i := 1; i < attempts && delay < 6*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 6*time.Hour {
+		delay = 6 * time.Hour
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 5))
+	return delay - delay/10 + jitter
+}
@@ -0,0 +1,227 @@
+package accountdeletion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+func setupDeletionDB(t *testing.T) (*gorm.DB, models.User) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Tag{},
+		&models.UserTag{},
+		&models.UserPreference{},
+		&models.UserReport{},
+		&models.ProfileView{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.PushSubscription{},
+		&models.NotificationQuietHours{},
+		&models.DataExport{},
+		&models.AccountDeletion{},
+		&models.AccountDeletionStep{},
+	))
+
+	user := models.User{
+		Username:         "alice",
+		FirstName:        "Alice",
+		LastName:         "Doe",
+		Email:            "alice@example.com",
+		PasswordHash:     "hash",
+		BirthDate:        time.Now().AddDate(-25, 0, 0),
+		Gender:           "woman",
+		SexPref:          "both",
+		RelationshipType: "long_term",
+	}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&models.ProfileView{ViewerID: 2, ViewedID: user.ID}).Error)
+	return db, user
+}
+
+// recorder is a step that records its calls and fails while failures > 0
+type recorder struct {
+	calls    []string
+	failures map[string]int
+}
+
+func (r *recorder) step(name string) Step {
+	return Step{Name: name, Run: func(ctx context.Context, userID uint) error {
+		r.calls = append(r.calls, name)
+		if r.failures[name] > 0 {
+			r.failures[name]--
+			return errors.New(name + " unavailable")
+		}
+		return nil
+	}}
+}
+
+// makeDue skips the grace period and any pending backoff
+func makeDue(t *testing.T, db *gorm.DB, id uint) {
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, db.Model(&models.AccountDeletion{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"purge_after": past, "next_attempt_at": past}).Error)
+}
+
+func TestSaga_ScheduleAndCancel(t *testing.T) {
+	db, user := setupDeletionDB(t)
+	rec := &recorder{}
+	saga := NewSaga(db, []Step{rec.step("chat"), rec.step("match")})
+
+	deletion, err := saga.Schedule(context.Background(), user.ID, DefaultGracePeriod)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountDeletionScheduled, deletion.Status)
+	assert.WithinDuration(t, time.Now().Add(DefaultGracePeriod), deletion.PurgeAfter, time.Minute)
+	assert.Len(t, deletion.Steps, 2)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.NotNil(t, stored.DeletedAt, "user is soft deleted during the grace period")
+
+	again, err := saga.Schedule(context.Background(), user.ID, DefaultGracePeriod)
+	require.NoError(t, err)
+	assert.Equal(t, deletion.ID, again.ID, "scheduling twice reuses the pending deletion")
+
+	// Nothing runs before the grace period is over
+	saga.ProcessDue(context.Background())
+	assert.Empty(t, rec.calls)
+
+	cancelled, err := saga.Cancel(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountDeletionCancelled, cancelled.Status)
+	var restored models.User
+	require.NoError(t, db.First(&restored, user.ID).Error)
+	assert.Nil(t, restored.DeletedAt)
+
+	_, err = saga.Cancel(context.Background(), user.ID)
+	assert.ErrorIs(t, err, ErrNoDeletion)
+}
+
+func TestSaga_RetriesFailedStepAndResumes(t *testing.T) {
+	db, user := setupDeletionDB(t)
+	rec := &recorder{failures: map[string]int{"match": 1}}
+	saga := NewSaga(db, []Step{rec.step("chat"), rec.step("match"), ProfileStep(db)})
+
+	deletion, err := saga.Schedule(context.Background(), user.ID, 0)
+	require.NoError(t, err)
+
+	makeDue(t, db, deletion.ID)
+	saga.ProcessDue(context.Background())
+	assert.Equal(t, []string{"chat", "match"}, rec.calls, "a failed step blocks the following ones")
+
+	stored, err := saga.Get(deletion.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountDeletionRunning, stored.Status)
+	assert.Contains(t, stored.LastError, "step match")
+	assert.True(t, stored.NextAttemptAt.After(time.Now()), "next attempt is backed off")
+	assert.Equal(t, models.DeletionStepDone, stored.Steps[0].Status)
+	assert.Equal(t, models.DeletionStepPending, stored.Steps[1].Status)
+	assert.Equal(t, 1, stored.Steps[1].Attempts)
+
+	// The second run skips the completed step
+	makeDue(t, db, deletion.ID)
+	saga.ProcessDue(context.Background())
+	assert.Equal(t, []string{"chat", "match", "match"}, rec.calls)
+
+	stored, err = saga.Get(deletion.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountDeletionCompleted, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	for _, step := range stored.Steps {
+		assert.Equal(t, models.DeletionStepDone, step.Status, step.Name)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Where("id = ?", user.ID).Count(&count)
+	assert.Zero(t, count)
+	db.Model(&models.ProfileView{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestProfileStep_KeepsReportsAgainstTheUser(t *testing.T) {
+	db, user := setupDeletionDB(t)
+	other := uint(2)
+	filed := models.UserReport{ReporterID: user.ID, ReportedID: &other, ReportType: "spam"}
+	against := models.UserReport{ReporterID: other, ReportedID: &user.ID, ReportType: "harassment", Description: "insults"}
+	require.NoError(t, db.Create(&filed).Error)
+	require.NoError(t, db.Create(&against).Error)
+
+	require.NoError(t, ProfileStep(db).Run(context.Background(), user.ID))
+
+	var reports []models.UserReport
+	require.NoError(t, db.Find(&reports).Error)
+	require.Len(t, reports, 1, "the report filed by the user is deleted")
+	assert.Equal(t, against.ID, reports[0].ID)
+	assert.Nil(t, reports[0].ReportedID)
+	assert.Equal(t, other, reports[0].ReporterID)
+	assert.Equal(t, "insults", reports[0].Description)
+}
+
+func TestSaga_ListStuckAndRetry(t *testing.T) {
+	db, user := setupDeletionDB(t)
+	rec := &recorder{failures: map[string]int{"stripe": StuckAttempts}}
+	saga := NewSaga(db, []Step{rec.step("stripe")})
+
+	deletion, err := saga.Schedule(context.Background(), user.ID, 0)
+	require.NoError(t, err)
+	for i := 0; i < StuckAttempts; i++ {
+		makeDue(t, db, deletion.ID)
+		saga.ProcessDue(context.Background())
+	}
+
+	stuck, err := saga.List("", true, 10)
+	require.NoError(t, err)
+	require.Len(t, stuck, 1)
+	assert.Equal(t, deletion.ID, stuck[0].ID)
+	assert.Equal(t, StuckAttempts, stuck[0].Steps[0].Attempts)
+
+	_, err = saga.Cancel(context.Background(), user.ID)
+	assert.ErrorIs(t, err, ErrNotCancellable, "a running purge cannot be cancelled")
+
+	retried, err := saga.Retry(deletion.ID)
+	require.NoError(t, err)
+	assert.False(t, retried.NextAttemptAt.After(time.Now()))
+
+	saga.ProcessDue(context.Background())
+	stored, err := saga.Get(deletion.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountDeletionCompleted, stored.Status)
+}
+
+func TestRemoteStep(t *testing.T) {
+	status := http.StatusInternalServerError
+	var gotPath, gotMethod string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotMethod = r.URL.Path, r.Method
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	step := RemoteStep("media_files", server.URL, "/api/v1/internal/users/%d/files")
+
+	err := step.Run(context.Background(), 42)
+	assert.ErrorContains(t, err, "status 500")
+	assert.Equal(t, http.MethodDelete, gotMethod)
+	assert.Equal(t, "/api/v1/internal/users/42/files", gotPath)
+
+	status = http.StatusOK
+	assert.NoError(t, step.Run(context.Background(), 42))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.InDelta(t, float64(time.Minute), float64(Backoff(1)), float64(15*time.Second))
+	assert.InDelta(t, float64(4*time.Minute), float64(Backoff(3)), float64(time.Minute))
+	assert.LessOrEqual(t, Backoff(50), 6*time.Hour+6*time.Hour/5)
+}
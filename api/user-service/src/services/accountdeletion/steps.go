@@ -0,0 +1,136 @@
+package accountdeletion
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+// RemoteStep calls DELETE <baseURL><path> on another service, where path contains
+// a %d verb for the user ID. Any 2xx answer means the data is gone.
+func RemoteStep(name, baseURL, path string) Step {
+	client := tracing.NewClient(60 * time.Second)
+	internalKey := os.Getenv("INTERNAL_API_KEY")
+
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, userID uint) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, baseURL+fmt.Sprintf(path, userID), nil)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("User-Agent", "matcha-internal-service/user-service")
+			if internalKey != "" {
+				req.Header.Set("X-Internal-Key", internalKey)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+				return fmt.Errorf("%s returned status %d: %s", name, resp.StatusCode, body)
+			}
+			return nil
+		},
+	}
+}
+
+// ExportsStep removes the user's data export archives from disk
+func ExportsStep(db *gorm.DB) Step {
+	return Step{
+		Name: "exports",
+		Run: func(ctx context.Context, userID uint) error {
+			var exports []models.DataExport
+			if err := db.Where("user_id = ? AND file_path <> ''", userID).Find(&exports).Error; err != nil {
+				return err
+			}
+			for _, export := range exports {
+				if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to delete %s: %w", export.FilePath, err)
+				}
+			}
+			return db.Where("user_id = ?", userID).Delete(&models.DataExport{}).Error
+		},
+	}
+}
+
+// ProfileStep deletes the rows owned by user-service, then the user itself.
+// Auth-service tables are removed by the ON DELETE CASCADE of their foreign keys.
+// The reports the user filed are deleted; the reports against the user are
+// kept for moderation, without the reported user.
+func ProfileStep(db *gorm.DB) Step {
+	return Step{
+		Name: "profile",
+		Run: func(ctx context.Context, userID uint) error {
+			return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				deletes := []struct {
+					model interface{}
+					where string
+					args  []interface{}
+				}{
+					{&models.UserTag{}, "user_id = ?", []interface{}{userID}},
+					{&models.UserPreference{}, "user_id = ?", []interface{}{userID}},
+					{&models.ProfileView{}, "viewer_id = ? OR viewed_id = ?", []interface{}{userID, userID}},
+					{&models.UserReport{}, "reporter_id = ?", []interface{}{userID}},
+					{&models.Notification{}, "to_user_id = ?", []interface{}{userID}},
+					{&models.NotificationPreference{}, "user_id = ?", []interface{}{userID}},
+					{&models.PushSubscription{}, "user_id = ?", []interface{}{userID}},
+					{&models.NotificationQuietHours{}, "user_id = ?", []interface{}{userID}},
+				}
+				for _, d := range deletes {
+					if err := tx.Where(d.where, d.args...).Delete(d.model).Error; err != nil {
+						return err
+					}
+				}
+				if err := tx.Model(&models.UserReport{}).Where("reported_id = ?", userID).
+					Update("reported_id", nil).Error; err != nil {
+					return err
+				}
+				return tx.Where("id = ?", userID).Delete(&models.User{}).Error
+			})
+		},
+	}
+}
+
+// DefaultSteps wires the saga to the services of the docker network. Files are
+// purged before the media records that point to them, and the user row goes last.
+func DefaultSteps(db *gorm.DB) []Step {
+	mediaURL := getEnv("MEDIA_SERVICE_URL", "http://media-service:8006")
+	return []Step{
+		RemoteStep("chat", getEnv("CHAT_SERVICE_URL", "http://chat-service:8004"), "/api/v1/internal/users/%d"),
+		RemoteStep("match", getEnv("MATCH_SERVICE_URL", "http://match-service:8003"), "/api/v1/internal/users/%d"),
+		RemoteStep("stripe", getEnv("PAYMENTS_SERVICE_URL", "http://paiements-service:8085"), "/api/v1/internal/users/%d/subscriptions"),
+		RemoteStep("media_files", mediaURL, "/api/v1/internal/users/%d/files"),
+		RemoteStep("media", mediaURL, "/api/v1/internal/users/%d"),
+		ExportsStep(db),
+		ProfileStep(db),
+	}
+}
+
+// GracePeriodFromEnv reads ACCOUNT_DELETION_GRACE_DAYS, defaulting to DefaultGracePeriod
+func GracePeriodFromEnv() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS")); err == nil && days >= 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return DefaultGracePeriod
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
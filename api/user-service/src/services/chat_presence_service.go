@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 // ChatPresenceService handles communication with Chat Service for real-time presence
@@ -41,9 +43,7 @@ func NewChatPresenceService() *ChatPresenceService {
 
 	return &ChatPresenceService{
 		chatServiceURL: chatServiceURL,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
-		},
+		httpClient:     tracing.NewClient(5 * time.Second),
 	}
 }
 
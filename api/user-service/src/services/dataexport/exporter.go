@@ -0,0 +1,447 @@
+// Package dataexport builds GDPR "download my data" archives. user-service
+// writes its own tables and pulls the other sections from each service's
+// internal export endpoint, then zips everything with the original media.
+package dataexport
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+// Source is a remote service contributing a section of the export through
+// GET <BaseURL>/api/v1/internal/users/:user_id/export
+type Source struct {
+	Name    string
+	BaseURL string
+}
+
+// Exporter builds export archives
+type Exporter struct {
+	db          *gorm.DB
+	dir         string
+	sources     []Source
+	mediaURL    string
+	internalKey string
+	client      *http.Client
+	retries     int
+}
+
+// NewExporter creates an exporter writing archives to dir
+func NewExporter(db *gorm.DB, dir string, sources []Source, mediaURL string) *Exporter {
+	return &Exporter{
+		db:          db,
+		dir:         dir,
+		sources:     sources,
+		mediaURL:    mediaURL,
+		internalKey: os.Getenv("INTERNAL_API_KEY"),
+		client:      tracing.NewClient(60 * time.Second),
+		retries:     3,
+	}
+}
+
+// NewExporterFromEnv wires the exporter to the services of the docker network
+func NewExporterFromEnv(db *gorm.DB) *Exporter {
+	mediaURL := getEnv("MEDIA_SERVICE_URL", "http://media-service:8006")
+	return NewExporter(db, getEnv("DATA_EXPORT_DIR", "/app/exports"), []Source{
+		{Name: "matching", BaseURL: getEnv("MATCH_SERVICE_URL", "http://match-service:8003")},
+		{Name: "chat", BaseURL: getEnv("CHAT_SERVICE_URL", "http://chat-service:8004")},
+		{Name: "media", BaseURL: mediaURL},
+		{Name: "payments", BaseURL: getEnv("PAYMENTS_SERVICE_URL", "http://paiements-service:8085")},
+	}, mediaURL)
+}
+
+// Build writes the archive of userID and returns its path and size.
+// The archive is written to a temporary file first so a failed build never
+// leaves a partial ZIP behind.
+func (e *Exporter) Build(ctx context.Context, exportID, userID uint) (string, int64, error) {
+	if err := os.MkdirAll(e.dir, 0o750); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	finalPath := filepath.Join(e.dir, fmt.Sprintf("matcha-export-%d-%d.zip", userID, exportID))
+	tmp, err := os.CreateTemp(e.dir, "export-*.zip.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := zip.NewWriter(tmp)
+	if err := e.writeArchive(ctx, zw, userID); err != nil {
+		zw.Close()
+		tmp.Close()
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), finalPath); err != nil {
+		return "", 0, fmt.Errorf("failed to store archive: %w", err)
+	}
+	info, err := os.Stat(finalPath)
+	if err != nil {
+		return "", 0, err
+	}
+	return finalPath, info.Size(), nil
+}
+
+func (e *Exporter) writeArchive(ctx context.Context, zw *zip.Writer, userID uint) error {
+	manifest := map[string]interface{}{
+		"user_id":      userID,
+		"generated_at": time.Now().UTC(),
+		"format":       "JSON files per section, original media under media/ and chat attachments under media/attachments/",
+	}
+	var files []string
+
+	local, err := e.localSections(userID)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"profile", "preferences", "profile_views", "reports", "notifications", "notification_preferences", "push"} {
+		if err := writeJSON(zw, name+".json", local[name]); err != nil {
+			return err
+		}
+		files = append(files, name+".json")
+	}
+
+	var mediaSection json.RawMessage
+	for _, src := range e.sources {
+		data, err := e.fetchSection(ctx, src, userID)
+		if err != nil {
+			return fmt.Errorf("section %s: %w", src.Name, err)
+		}
+		if err := writeJSON(zw, src.Name+".json", data); err != nil {
+			return err
+		}
+		files = append(files, src.Name+".json")
+		if src.Name == "media" {
+			mediaSection = data
+		}
+	}
+
+	mediaFiles, err := e.writeMedia(ctx, zw, userID, mediaSection)
+	if err != nil {
+		return err
+	}
+	files = append(files, mediaFiles...)
+
+	manifest["files"] = files
+	return writeJSON(zw, "manifest.json", manifest)
+}
+
+// profileViewRow and reportRow keep related users down to their ID
+type profileViewRow struct {
+	ViewerID  uint      `json:"viewer_id"`
+	ViewedID  uint      `json:"viewed_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type reportRow struct {
+	ID          uint       `json:"id"`
+	ReportedID  *uint      `json:"reported_id"`
+	ReportType  string     `json:"report_type"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type notificationRow struct {
+	ID        uint       `json:"id"`
+	NotifType string     `json:"notif_type"`
+	Kind      string     `json:"kind,omitempty"`
+	Msg       string     `json:"msg"`
+	Time      time.Time  `json:"time"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+type notificationPreferenceRow struct {
+	Kind  string `json:"kind"`
+	InApp bool   `json:"in_app"`
+	Email bool   `json:"email"`
+	Push  bool   `json:"push"`
+}
+
+type pushSubscriptionRow struct {
+	Endpoint   string     `json:"endpoint"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// localSections reads the data owned by user-service
+func (e *Exporter) localSections(userID uint) (map[string]interface{}, error) {
+	var user models.User
+	if err := e.db.Preload("Tags").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	var preferences []models.UserPreference
+	if err := e.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	exportedPreferences := make([]map[string]interface{}, 0, len(preferences))
+	for _, p := range preferences {
+		exportedPreferences = append(exportedPreferences, map[string]interface{}{
+			"age_min":             p.AgeMin,
+			"age_max":             p.AgeMax,
+			"max_distance":        p.MaxDistance,
+			"min_fame":            p.MinFame,
+			"preferred_genders":   p.PreferredGenders,
+			"required_tags":       p.RequiredTags,
+			"blocked_tags":        p.BlockedTags,
+			"smoking_preference":  p.SmokingPreference,
+			"alcohol_preference":  p.AlcoholPreference,
+			"drugs_preference":    p.DrugsPreference,
+			"cannabis_preference": p.CannabisPreference,
+			"religion_preference": p.ReligionPreference,
+			"blocked_religions":   p.BlockedReligions,
+			"updated_at":          p.UpdatedAt,
+		})
+	}
+
+	viewsGiven := []profileViewRow{}
+	if err := e.db.Model(&models.ProfileView{}).Where("viewer_id = ?", userID).Order("created_at").Find(&viewsGiven).Error; err != nil {
+		return nil, fmt.Errorf("failed to load profile views: %w", err)
+	}
+	viewsReceived := []profileViewRow{}
+	if err := e.db.Model(&models.ProfileView{}).Where("viewed_id = ?", userID).Order("created_at").Find(&viewsReceived).Error; err != nil {
+		return nil, fmt.Errorf("failed to load profile views: %w", err)
+	}
+
+	reports := []reportRow{}
+	if err := e.db.Model(&models.UserReport{}).Where("reporter_id = ?", userID).Order("created_at").Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to load reports: %w", err)
+	}
+
+	notifs := []notificationRow{}
+	if err := e.db.Model(&models.Notification{}).Where("to_user_id = ?", userID).Order("time").Find(&notifs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load notifications: %w", err)
+	}
+	notifPrefs := []notificationPreferenceRow{}
+	if err := e.db.Model(&models.NotificationPreference{}).Where("user_id = ?", userID).Order("kind").Find(&notifPrefs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	pushSubs := []pushSubscriptionRow{}
+	if err := e.db.Model(&models.PushSubscription{}).Where("user_id = ?", userID).Order("created_at").Find(&pushSubs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load push subscriptions: %w", err)
+	}
+	var quietHours []models.NotificationQuietHours
+	if err := e.db.Where("user_id = ?", userID).Limit(1).Find(&quietHours).Error; err != nil {
+		return nil, fmt.Errorf("failed to load quiet hours: %w", err)
+	}
+	push := map[string]interface{}{"subscriptions": pushSubs, "quiet_hours": nil}
+	if len(quietHours) > 0 {
+		push["quiet_hours"] = quietHours[0]
+	}
+
+	// Images are exported by media-service, keep the profile section flat
+	user.Images = nil
+	profile, err := flattenNullFields(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	return map[string]interface{}{
+		"profile":     profile,
+		"preferences": exportedPreferences,
+		"profile_views": map[string]interface{}{
+			"viewed_by_me": viewsGiven,
+			"viewed_me":    viewsReceived,
+		},
+		"reports":                  reports,
+		"notifications":            notifs,
+		"notification_preferences": notifPrefs,
+		"push":                     push,
+	}, nil
+}
+
+// fetchSection calls a service's internal export endpoint, retrying transient failures
+func (e *Exporter) fetchSection(ctx context.Context, src Source, userID uint) (json.RawMessage, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/internal/users/%d/export", src.BaseURL, userID)
+
+	var lastErr error
+	for attempt := 1; attempt <= e.retries; attempt++ {
+		resp, err := e.get(ctx, endpoint)
+		if err == nil {
+			var envelope struct {
+				Success bool            `json:"success"`
+				Data    json.RawMessage `json:"data"`
+				Error   string          `json:"error"`
+			}
+			decodeErr := json.NewDecoder(resp.Body).Decode(&envelope)
+			resp.Body.Close()
+
+			switch {
+			case resp.StatusCode != http.StatusOK:
+				lastErr = fmt.Errorf("%s returned status %d: %s", src.Name, resp.StatusCode, envelope.Error)
+				if resp.StatusCode < 500 {
+					return nil, lastErr
+				}
+			case decodeErr != nil:
+				return nil, fmt.Errorf("invalid response from %s: %w", src.Name, decodeErr)
+			default:
+				return envelope.Data, nil
+			}
+		} else {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+	return nil, lastErr
+}
+
+// writeMedia copies the original files listed in the media section under
+// media/: the images, and the chat attachments under media/attachments/
+func (e *Exporter) writeMedia(ctx context.Context, zw *zip.Writer, userID uint, section json.RawMessage) ([]string, error) {
+	if len(section) == 0 {
+		return nil, nil
+	}
+	type mediaFile struct {
+		Filename string `json:"filename"`
+	}
+	var media struct {
+		Images      []mediaFile `json:"images"`
+		Attachments []mediaFile `json:"attachments"`
+	}
+	if err := json.Unmarshal(section, &media); err != nil {
+		return nil, fmt.Errorf("invalid media section: %w", err)
+	}
+
+	var files []string
+	for _, group := range []struct {
+		dir   string
+		files []mediaFile
+	}{
+		{"media/", media.Images},
+		{"media/attachments/", media.Attachments},
+	} {
+		for _, f := range group.files {
+			name := filepath.Base(f.Filename)
+			if name == "." || name == "/" || name == "" {
+				continue
+			}
+			archived, err := e.writeMediaFile(ctx, zw, userID, name, group.dir+name)
+			if err != nil {
+				return nil, err
+			}
+			if archived {
+				files = append(files, group.dir+name)
+			}
+		}
+	}
+	return files, nil
+}
+
+// writeMediaFile downloads the original file name of the user to path in the
+// archive. It reports false when media-service has no file for it.
+func (e *Exporter) writeMediaFile(ctx context.Context, zw *zip.Writer, userID uint, name, path string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/internal/users/%d/files/%s", e.mediaURL, userID, url.PathEscape(name))
+	resp, err := e.get(ctx, endpoint)
+	if err != nil {
+		return false, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// Metadata without a file on disk: the JSON section still lists it
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to download %s: status %d", name, resp.StatusCode)
+	}
+
+	w, err := zw.Create(path)
+	if err == nil {
+		_, err = io.Copy(w, resp.Body)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return true, nil
+}
+
+func (e *Exporter) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "matcha-internal-service/user-service")
+	if e.internalKey != "" {
+		req.Header.Set("X-Internal-Key", e.internalKey)
+	}
+	return e.client.Do(req)
+}
+
+// flattenNullFields encodes v as a JSON object where sql.Null* values
+// ({"String": "x", "Valid": true}) are replaced by their value or null
+func flattenNullFields(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		nested, ok := value.(map[string]interface{})
+		if !ok || len(nested) != 2 {
+			continue
+		}
+		valid, ok := nested["Valid"].(bool)
+		if !ok {
+			continue
+		}
+		fields[key] = nil
+		if valid {
+			for k, inner := range nested {
+				if k != "Valid" {
+					fields[key] = inner
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
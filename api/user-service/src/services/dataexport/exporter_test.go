@@ -0,0 +1,214 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+type recordingNotifier struct {
+	ready  []uint
+	failed []uint
+}
+
+func (n *recordingNotifier) ExportReady(export *models.DataExport) {
+	n.ready = append(n.ready, export.ID)
+}
+func (n *recordingNotifier) ExportFailed(export *models.DataExport) {
+	n.failed = append(n.failed, export.ID)
+}
+
+func setupExportDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Tag{},
+		&models.UserTag{},
+		&models.UserPreference{},
+		&models.UserReport{},
+		&models.ProfileView{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.PushSubscription{},
+		&models.NotificationQuietHours{},
+		&models.DataExport{},
+	))
+
+	user := models.User{
+		Username:         "alice",
+		FirstName:        "Alice",
+		LastName:         "Doe",
+		Email:            "alice@example.com",
+		PasswordHash:     "secret-hash",
+		BirthDate:        time.Now().AddDate(-25, 0, 0),
+		Gender:           "woman",
+		SexPref:          "both",
+		RelationshipType: "long_term",
+	}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&models.ProfileView{ViewerID: 2, ViewedID: user.ID}).Error)
+	return db
+}
+
+// fakeServices mimics the internal export endpoints of the other services
+func fakeServices(t *testing.T, failing string) *httptest.Server {
+	mux := http.NewServeMux()
+	section := func(name string, data interface{}) {
+		mux.HandleFunc("/"+name+"/api/v1/internal/users/1/export", func(w http.ResponseWriter, r *http.Request) {
+			if name == failing {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+		})
+	}
+	section("matching", map[string]interface{}{"matches": []interface{}{map[string]interface{}{"other_user_id": 2}}})
+	section("chat", map[string]interface{}{"messages": []interface{}{map[string]interface{}{"msg": "hello"}}})
+	section("media", map[string]interface{}{
+		"images": []interface{}{
+			map[string]interface{}{"filename": "photo.jpg"},
+			map[string]interface{}{"filename": "missing.jpg"},
+		},
+		"attachments": []interface{}{
+			map[string]interface{}{"id": 7, "kind": "voice", "filename": "voice.webm"},
+		},
+	})
+	mux.HandleFunc("/media/api/v1/internal/users/1/files/voice.webm", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("WEBMDATA"))
+	})
+	mux.HandleFunc("/media/api/v1/internal/users/1/files/photo.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("JPEGDATA"))
+	})
+	mux.HandleFunc("/media/api/v1/internal/users/1/files/missing.jpg", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestExporter(db *gorm.DB, dir, baseURL string) *Exporter {
+	e := NewExporter(db, dir, []Source{
+		{Name: "matching", BaseURL: baseURL + "/matching"},
+		{Name: "chat", BaseURL: baseURL + "/chat"},
+		{Name: "media", BaseURL: baseURL + "/media"},
+	}, baseURL+"/media")
+	e.retries = 1
+	return e
+}
+
+func readZip(t *testing.T, path string) map[string]string {
+	r, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestWorker_BuildsArchiveAndNotifies(t *testing.T) {
+	db := setupExportDB(t)
+	server := fakeServices(t, "")
+	notifier := &recordingNotifier{}
+	worker := NewWorker(db, newTestExporter(db, t.TempDir(), server.URL), notifier)
+
+	export := models.DataExport{UserID: 1, Status: models.DataExportPending}
+	require.NoError(t, db.Create(&export).Error)
+
+	worker.ProcessPending(context.Background())
+
+	var stored models.DataExport
+	require.NoError(t, db.First(&stored, export.ID).Error)
+	assert.Equal(t, models.DataExportReady, stored.Status)
+	require.NotNil(t, stored.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(DownloadTTL), *stored.ExpiresAt, time.Minute)
+	assert.Equal(t, []uint{export.ID}, notifier.ready)
+
+	files := readZip(t, stored.FilePath)
+	for _, name := range []string{"manifest.json", "profile.json", "preferences.json", "profile_views.json",
+		"reports.json", "notifications.json", "notification_preferences.json", "push.json", "matching.json", "chat.json", "media.json"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Equal(t, "JPEGDATA", files["media/photo.jpg"])
+	assert.NotContains(t, files, "media/missing.jpg")
+	assert.Equal(t, "WEBMDATA", files["media/attachments/voice.webm"])
+
+	assert.Contains(t, files["profile.json"], "alice@example.com")
+	assert.NotContains(t, files["profile.json"], "secret-hash")
+	assert.NotContains(t, files["profile.json"], `"Valid"`)
+	assert.Contains(t, files["chat.json"], "hello")
+	assert.Contains(t, files["profile_views.json"], `"viewer_id": 2`)
+}
+
+func TestWorker_RetriesThenFails(t *testing.T) {
+	db := setupExportDB(t)
+	server := fakeServices(t, "chat")
+	notifier := &recordingNotifier{}
+	dir := t.TempDir()
+	worker := NewWorker(db, newTestExporter(db, dir, server.URL), notifier)
+
+	export := models.DataExport{UserID: 1, Status: models.DataExportPending}
+	require.NoError(t, db.Create(&export).Error)
+
+	var stored models.DataExport
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		worker.ProcessPending(context.Background())
+		require.NoError(t, db.First(&stored, export.ID).Error)
+		assert.Equal(t, attempt, stored.Attempts)
+	}
+
+	assert.Equal(t, models.DataExportFailed, stored.Status)
+	assert.Contains(t, stored.Error, "section chat")
+	assert.Equal(t, []uint{export.ID}, notifier.failed)
+	assert.Empty(t, notifier.ready)
+
+	// No partial archive is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestWorker_PurgeExpired(t *testing.T) {
+	db := setupExportDB(t)
+	server := fakeServices(t, "")
+	worker := NewWorker(db, newTestExporter(db, t.TempDir(), server.URL), &recordingNotifier{})
+
+	export := models.DataExport{UserID: 1, Status: models.DataExportPending}
+	require.NoError(t, db.Create(&export).Error)
+	worker.ProcessPending(context.Background())
+
+	var stored models.DataExport
+	require.NoError(t, db.First(&stored, export.ID).Error)
+	path := stored.FilePath
+	require.FileExists(t, path)
+
+	db.Model(&stored).Update("expires_at", time.Now().Add(-time.Minute))
+	worker.PurgeExpired()
+
+	require.NoError(t, db.First(&stored, export.ID).Error)
+	assert.Equal(t, models.DataExportExpired, stored.Status)
+	assert.NoFileExists(t, path)
+}
@@ -0,0 +1,60 @@
+package dataexport
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+	"user-service/src/services/notifications"
+)
+
+// UserNotifier notifies in-app and by email through the shared auth-service mailer
+type UserNotifier struct {
+	db     *gorm.DB
+	notifs *notifications.Sender
+	emails *notifications.EmailClient
+}
+
+// NewUserNotifier creates the default export notifier
+func NewUserNotifier(db *gorm.DB) *UserNotifier {
+	return &UserNotifier{
+		db:     db,
+		notifs: notifications.NewSender(),
+		emails: notifications.NewEmailClient(),
+	}
+}
+
+// ExportReady tells the user the archive can be downloaded
+func (n *UserNotifier) ExportReady(export *models.DataExport) {
+	if err := n.notifs.SendAccountNotification(export.UserID, "Votre export de données est prêt 📦"); err != nil {
+		slog.Warn("failed to notify about data export", "user_id", export.UserID, "export_id", export.ID, "error", err)
+	}
+
+	var user models.User
+	if err := n.db.Select("id", "email").First(&user, export.UserID).Error; err != nil {
+		slog.Warn("failed to load email of user", "user_id", export.UserID, "error", err)
+		return
+	}
+
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "https://localhost:8443"
+	}
+	data := map[string]interface{}{
+		"DownloadURL": frontendURL + "/app/settings",
+		"ExpiresAt":   export.ExpiresAt.Format("02/01/2006 15:04"),
+	}
+	if err := n.emails.Send(context.Background(), user.Email, "data_export_ready", export.Locale, data); err != nil {
+		slog.Warn("failed to email about data export", "user_id", export.UserID, "export_id", export.ID, "error", err)
+	}
+}
+
+// ExportFailed tells the user to try again later
+func (n *UserNotifier) ExportFailed(export *models.DataExport) {
+	if err := n.notifs.SendAccountNotification(export.UserID, "Votre export de données a échoué, veuillez réessayer plus tard"); err != nil {
+		slog.Warn("failed to notify about data export", "user_id", export.UserID, "export_id", export.ID, "error", err)
+	}
+}
@@ -0,0 +1,175 @@
+package dataexport
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+const (
+	// DownloadTTL is how long a ready archive can be downloaded
+	DownloadTTL = 7 * 24 * time.Hour
+	// maxAttempts before an export is marked as failed
+	maxAttempts = 3
+	// stuckAfter releases exports left in "processing" by a crashed worker
+	stuckAfter = 30 * time.Minute
+)
+
+// Notifier tells the user about the outcome of an export
+type Notifier interface {
+	ExportReady(export *models.DataExport)
+	ExportFailed(export *models.DataExport)
+}
+
+// Worker builds pending exports and purges expired archives
+type Worker struct {
+	db       *gorm.DB
+	exporter *Exporter
+	notifier Notifier
+	interval time.Duration
+}
+
+// NewWorker creates an export worker
+func NewWorker(db *gorm.DB, exporter *Exporter, notifier Notifier) *Worker {
+	return &Worker{db: db, exporter: exporter, notifier: notifier, interval: 10 * time.Second}
+}
+
+// Run processes exports until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "data export worker started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.releaseStuck()
+		w.ProcessPending(ctx)
+		w.PurgeExpired()
+
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "data export worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending builds every pending export, one at a time
+func (w *Worker) ProcessPending(ctx context.Context) {
+	var pending []models.DataExport
+	if err := w.db.Where("status = ?", models.DataExportPending).Order("created_at").Limit(5).Find(&pending).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to load pending data exports", "error", err)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		if !w.claim(&pending[i]) {
+			continue
+		}
+		w.process(ctx, &pending[i])
+	}
+}
+
+// claim marks the export as processing; it fails if another worker got it first
+func (w *Worker) claim(export *models.DataExport) bool {
+	result := w.db.Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", export.ID, models.DataExportPending).
+		Updates(map[string]interface{}{
+			"status":     models.DataExportProcessing,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false
+	}
+	export.Status = models.DataExportProcessing
+	export.Attempts++
+	return true
+}
+
+func (w *Worker) process(ctx context.Context, export *models.DataExport) {
+	path, size, err := w.exporter.Build(ctx, export.ID, export.UserID)
+	now := time.Now()
+
+	if err != nil {
+		slog.WarnContext(ctx, "data export failed",
+			"export_id", export.ID, "user_id", export.UserID, "attempt", export.Attempts, "max_attempts", maxAttempts, "error", err)
+
+		status := models.DataExportPending
+		if export.Attempts >= maxAttempts {
+			status = models.DataExportFailed
+		}
+		w.db.Model(&models.DataExport{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+			"status":     status,
+			"error":      err.Error(),
+			"updated_at": now,
+		})
+		if status == models.DataExportFailed {
+			export.Status = status
+			export.Error = err.Error()
+			w.notifier.ExportFailed(export)
+		}
+		return
+	}
+
+	expiresAt := now.Add(DownloadTTL)
+	if err := w.db.Model(&models.DataExport{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+		"status":       models.DataExportReady,
+		"file_path":    path,
+		"file_size":    size,
+		"error":        "",
+		"completed_at": now,
+		"expires_at":   expiresAt,
+		"updated_at":   now,
+	}).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to update data export", "export_id", export.ID, "error", err)
+		return
+	}
+
+	export.Status = models.DataExportReady
+	export.FilePath = path
+	export.FileSize = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	slog.InfoContext(ctx, "data export ready", "export_id", export.ID, "user_id", export.UserID, "size", size)
+	w.notifier.ExportReady(export)
+}
+
+// PurgeExpired deletes archives past their download window
+func (w *Worker) PurgeExpired() {
+	var expired []models.DataExport
+	if err := w.db.Where("status = ? AND expires_at < ?", models.DataExportReady, time.Now()).Find(&expired).Error; err != nil {
+		return
+	}
+	for _, export := range expired {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				slog.Warn("failed to delete expired export", "path", export.FilePath, "error", err)
+				continue
+			}
+		}
+		w.db.Model(&models.DataExport{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+			"status":     models.DataExportExpired,
+			"file_path":  "",
+			"updated_at": time.Now(),
+		})
+	}
+}
+
+// releaseStuck puts back exports whose worker died mid-build
+func (w *Worker) releaseStuck() {
+	w.db.Model(&models.DataExport{}).
+		Where("status = ? AND updated_at < ?", models.DataExportProcessing, time.Now().Add(-stuckAfter)).
+		Updates(map[string]interface{}{
+			"status":     models.DataExportPending,
+			"updated_at": time.Now(),
+		})
+}
@@ -1,17 +1,22 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/maxg56/matcha/api/common/internalapi"
+	"github.com/maxg56/matcha/api/common/tracing"
 )
 
 // GatewayPresenceService handles communication with Gateway for real-time WebSocket presence
 type GatewayPresenceService struct {
-	gatewayURL string
-	httpClient *http.Client
+	gatewayURL  string
+	internalKey string
+	httpClient  *http.Client
 }
 
 // GatewayOnlineStatusResponse represents the response from Gateway WebSocket status endpoint
@@ -24,6 +29,7 @@ type GatewayOnlineStatusResponse struct {
 }
 
 // NewGatewayPresenceService creates a new Gateway Presence Service
+// (GATEWAY_URL, INTERNAL_API_KEY)
 func NewGatewayPresenceService() *GatewayPresenceService {
 	gatewayURL := os.Getenv("GATEWAY_URL")
 	if gatewayURL == "" {
@@ -31,24 +37,21 @@ func NewGatewayPresenceService() *GatewayPresenceService {
 	}
 
 	return &GatewayPresenceService{
-		gatewayURL: gatewayURL,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
-		},
+		gatewayURL:  gatewayURL,
+		internalKey: os.Getenv("INTERNAL_API_KEY"),
+		httpClient:  tracing.NewClient(5 * time.Second),
 	}
 }
 
 // GetUserWebSocketStatus gets real-time WebSocket connection status from Gateway
-func (s *GatewayPresenceService) GetUserWebSocketStatus(userID uint) (*UserPresence, error) {
+func (s *GatewayPresenceService) GetUserWebSocketStatus(ctx context.Context, userID uint) (*UserPresence, error) {
 	url := fmt.Sprintf("%s/api/internal/users/%d/online-status", s.gatewayURL, userID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-
-	// Add internal service header for authentication (if required by middleware)
-	req.Header.Set("X-Internal-Service", "user-service")
+	s.authenticate(req)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
@@ -85,15 +88,14 @@ func (s *GatewayPresenceService) GetUserWebSocketStatus(userID uint) (*UserPrese
 }
 
 // IsGatewayAvailable checks if the Gateway is reachable
-func (s *GatewayPresenceService) IsGatewayAvailable() bool {
+func (s *GatewayPresenceService) IsGatewayAvailable(ctx context.Context) bool {
 	url := fmt.Sprintf("%s/api/internal/health", s.gatewayURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return false
 	}
-
-	req.Header.Set("X-Internal-Service", "user-service")
+	s.authenticate(req)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
@@ -102,4 +104,12 @@ func (s *GatewayPresenceService) IsGatewayAvailable() bool {
 	defer resp.Body.Close()
 
 	return resp.StatusCode == http.StatusOK
-}
\ No newline at end of file
+}
+
+// authenticate sets the headers the internal routes of the gateway require
+func (s *GatewayPresenceService) authenticate(req *http.Request) {
+	req.Header.Set("X-Internal-Service", "user-service")
+	if s.internalKey != "" {
+		req.Header.Set(internalapi.KeyHeader, s.internalKey)
+	}
+}
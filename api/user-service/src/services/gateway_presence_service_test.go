@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maxg56/matcha/api/common/internalapi"
+)
+
+// fakeGateway serves the online status route behind the internal guard
+func fakeGateway(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal := router.Group("/api/internal", internalapi.Middleware())
+	internal.GET("/users/:userID/online-status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "user_id": c.Param("userID"), "is_online": true})
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGatewayPresence_SendsTheInternalKey(t *testing.T) {
+	t.Setenv("INTERNAL_API_KEY", "secret")
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("GATEWAY_URL", fakeGateway(t).URL)
+
+	presence, err := NewGatewayPresenceService().GetUserWebSocketStatus(context.Background(), 42)
+	require.NoError(t, err)
+	assert.True(t, presence.IsOnline)
+
+	// A wrong key is refused rather than read as offline
+	t.Setenv("INTERNAL_API_KEY", "other")
+	_, err = NewGatewayPresenceService().GetUserWebSocketStatus(context.Background(), 42)
+	assert.ErrorContains(t, err, "status 401")
+}
@@ -0,0 +1,94 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+// LiveChannelPrefix prefixes the Redis channel of each user (notifications:<id>)
+// on which the gateway picks up new notifications for connected clients
+const LiveChannelPrefix = "notifications:"
+
+// LiveEvent is published on the Redis channel of the recipient
+type LiveEvent struct {
+	EventID      string `json:"event_id"`
+	ToUserID     uint   `json:"to_user_id"`
+	Notification View   `json:"notification"`
+}
+
+// RealtimeChannel pushes stored notifications to the gateway, which forwards
+// them to the connected devices of the recipient
+type RealtimeChannel struct {
+	client *redis.Client
+}
+
+// NewRealtimeChannel creates the live part of the in-app channel
+func NewRealtimeChannel(client *redis.Client) *RealtimeChannel {
+	return &RealtimeChannel{client: client}
+}
+
+// Name implements Channel
+func (c *RealtimeChannel) Name() string { return ChannelInApp }
+
+// Deliver implements Channel. Notifications that were not stored have no
+// inbox entry to show and are skipped.
+func (c *RealtimeChannel) Deliver(ctx context.Context, notif *models.Notification) error {
+	if notif.ID == 0 {
+		return nil
+	}
+	if c.client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	payload, err := json.Marshal(LiveEvent{
+		EventID:      "notification:" + strconv.FormatUint(uint64(notif.ID), 10),
+		ToUserID:     notif.ToUserID,
+		Notification: NewView(notif),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification event: %w", err)
+	}
+	channel := LiveChannelPrefix + strconv.FormatUint(uint64(notif.ToUserID), 10)
+	return c.client.Publish(ctx, channel, payload).Err()
+}
+
+// EmailChannel emails notifications with the generic notification template
+type EmailChannel struct {
+	db          *gorm.DB
+	emails      *EmailClient
+	frontendURL string
+}
+
+// NewEmailChannel creates the email channel (FRONTEND_URL for the app link)
+func NewEmailChannel(db *gorm.DB, emails *EmailClient) *EmailChannel {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "https://localhost:8443"
+	}
+	return &EmailChannel{db: db, emails: emails, frontendURL: frontendURL}
+}
+
+// Name implements Channel
+func (c *EmailChannel) Name() string { return ChannelEmail }
+
+// Deliver implements Channel
+func (c *EmailChannel) Deliver(ctx context.Context, notif *models.Notification) error {
+	var user models.User
+	if err := c.db.WithContext(ctx).Select("id", "email").First(&user, notif.ToUserID).Error; err != nil {
+		return fmt.Errorf("failed to load email of user %d: %w", notif.ToUserID, err)
+	}
+
+	data := map[string]interface{}{
+		"Message": notif.Msg,
+		"AppURL":  c.frontendURL + "/app",
+	}
+	return c.emails.Send(ctx, user.Email, "notification", "", data)
+}
@@ -0,0 +1,182 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"user-service/src/models"
+)
+
+const (
+	// queueSize is the number of deliveries waiting before new ones are dropped
+	queueSize = 1024
+	// deliveryAttempts per channel before a delivery is given up
+	deliveryAttempts = 3
+	// deliveryTimeout bounds one attempt on one channel
+	deliveryTimeout = 10 * time.Second
+	// stopTimeout is how long the deliveries in flight when Run is stopped
+	// have to finish, within the shutdown of the service
+	stopTimeout = 3 * time.Second
+)
+
+// Channel delivers notifications outside the stored inbox
+type Channel interface {
+	// Name is the channel name used in preferences (in_app, email, push)
+	Name() string
+	Deliver(ctx context.Context, notif *models.Notification) error
+}
+
+// Delivery is a notification waiting to go out on the enabled channels
+type Delivery struct {
+	Notification models.Notification
+	Channels     Channels
+	// Trace is the span of the request that sent the notification
+	Trace trace.SpanContext
+}
+
+// Dispatcher delivers notifications in the background. Channels that are not
+// registered are skipped, as are channels the recipient turned off.
+type Dispatcher struct {
+	channels map[string]Channel
+	queue    chan Delivery
+	workers  int
+	backoff  time.Duration
+	stop     time.Duration
+}
+
+// NewDispatcher creates a dispatcher for the given channels
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	d := &Dispatcher{
+		channels: make(map[string]Channel, len(channels)),
+		queue:    make(chan Delivery, queueSize),
+		workers:  4,
+		backoff:  time.Second,
+		stop:     stopTimeout,
+	}
+	for _, channel := range channels {
+		d.channels[channel.Name()] = channel
+	}
+	return d
+}
+
+// Enqueue queues a delivery without waiting. It returns false when the queue
+// is full and the delivery was dropped.
+func (d *Dispatcher) Enqueue(delivery Delivery) bool {
+	if d == nil {
+		return true
+	}
+	select {
+	case d.queue <- delivery:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run delivers queued notifications until ctx is cancelled. The deliveries
+// in flight then have d.stop to finish; the queued ones are left to Flush.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "notification dispatcher started", "channels", len(d.channels))
+
+	deliverCtx, cancelDeliveries := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelDeliveries()
+	stopDeliveries := context.AfterFunc(ctx, func() {
+		time.AfterFunc(d.stop, cancelDeliveries)
+	})
+	defer stopDeliveries()
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case delivery := <-d.queue:
+					d.Deliver(deliverCtx, delivery)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	slog.InfoContext(ctx, "notification dispatcher stopped")
+}
+
+// Flush delivers the notifications left in the queue once Run has returned,
+// until the queue is empty or ctx is done. It returns ctx.Err() when
+// deliveries were left undelivered.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	flushed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			slog.WarnContext(ctx, "notifications left undelivered", "count", len(d.queue))
+			return ctx.Err()
+		case delivery := <-d.queue:
+			d.Deliver(ctx, delivery)
+			flushed++
+		default:
+			if flushed > 0 {
+				slog.InfoContext(ctx, "notification queue flushed", "count", flushed)
+			}
+			return nil
+		}
+	}
+}
+
+// Deliver sends one notification on each enabled channel, retrying failed
+// channels a few times
+func (d *Dispatcher) Deliver(ctx context.Context, delivery Delivery) {
+	ctx, span := tracing.Start(trace.ContextWithSpanContext(ctx, delivery.Trace), "notification.deliver",
+		trace.WithAttributes(
+			attribute.String("notification.kind", delivery.Notification.Kind),
+			attribute.Int("notification.to_user_id", int(delivery.Notification.ToUserID)),
+		))
+	defer span.End()
+
+	enabled := map[string]bool{
+		ChannelInApp: delivery.Channels.InApp,
+		ChannelEmail: delivery.Channels.Email,
+		ChannelPush:  delivery.Channels.Push,
+	}
+
+	for name, channel := range d.channels {
+		if !enabled[name] {
+			continue
+		}
+		if err := d.deliverWithRetry(ctx, channel, &delivery.Notification); err != nil {
+			slog.WarnContext(ctx, "failed to deliver notification",
+				"kind", delivery.Notification.Kind, "to_user_id", delivery.Notification.ToUserID, "channel", name, "error", err)
+		}
+	}
+}
+
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, channel Channel, notif *models.Notification) error {
+	var err error
+	for attempt := 1; attempt <= deliveryAttempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+		err = channel.Deliver(attemptCtx, notif)
+		cancel()
+		if err == nil || attempt == deliveryAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d.backoff * time.Duration(attempt)):
+		}
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+)
+
+// EmailClient queues templated emails through auth-service's internal email API
+type EmailClient struct {
+	authServiceURL string
+	internalKey    string
+	httpClient     *http.Client
+}
+
+// emailPayload is the body expected by POST /api/v1/internal/emails
+type emailPayload struct {
+	To       string                 `json:"to"`
+	Template string                 `json:"template"`
+	Locale   string                 `json:"locale"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// NewEmailClient creates an email client (AUTH_SERVICE_URL, INTERNAL_API_KEY)
+func NewEmailClient() *EmailClient {
+	url := os.Getenv("AUTH_SERVICE_URL")
+	if url == "" {
+		url = "http://auth-service:8001"
+	}
+	return &EmailClient{
+		authServiceURL: url,
+		internalKey:    os.Getenv("INTERNAL_API_KEY"),
+		httpClient:     tracing.NewClient(10 * time.Second),
+	}
+}
+
+// Send queues the given template for delivery; auth-service handles retries
+func (ec *EmailClient) Send(ctx context.Context, to, template, locale string, data map[string]interface{}) error {
+	body, err := json.Marshal(emailPayload{To: to, Template: template, Locale: locale, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal email payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", ec.authServiceURL+"/api/v1/internal/emails", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create email request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "matcha-internal-service/user-service")
+	if ec.internalKey != "" {
+		req.Header.Set("X-Internal-Key", ec.internalKey)
+	}
+
+	resp, err := ec.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("auth service returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
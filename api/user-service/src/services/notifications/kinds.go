@@ -0,0 +1,85 @@
+package notifications
+
+// Kind identifies what a notification is about. Kinds are the contract with
+// the services that send notifications and with the clients that show them.
+type Kind string
+
+const (
+	KindLike        Kind = "like"         // a user received a like
+	KindProfileView Kind = "profile_view" // a user's profile was viewed
+	KindMessage     Kind = "message"      // a user received a chat message
+	KindMatch       Kind = "match"        // a like was returned
+	KindUnlike      Kind = "unlike"       // a match withdrew their like
+	KindUnmatch     Kind = "unmatch"      // a match ended the match
+	KindAccount     Kind = "account"      // account notices (data export ready, ...)
+)
+
+// Version is the version of the notifications written by this package.
+// Version 1 notifications only had a numeric notif_type and a message.
+const Version = 2
+
+// Delivery channel names, as used in preferences
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// Channels are the delivery channels enabled for a kind
+type Channels struct {
+	InApp bool `json:"in_app"`
+	Email bool `json:"email"`
+	Push  bool `json:"push"`
+}
+
+// Any is true when the notification goes out on at least one channel
+func (c Channels) Any() bool {
+	return c.InApp || c.Email || c.Push
+}
+
+// kindInfo describes a kind: its numeric code in the notif_type column and
+// the channels used until the user changes them
+type kindInfo struct {
+	code     string
+	defaults Channels
+}
+
+var kinds = map[Kind]kindInfo{
+	KindLike:        {code: "1", defaults: Channels{InApp: true, Push: true}},
+	KindProfileView: {code: "2", defaults: Channels{InApp: true}},
+	KindMessage:     {code: "3", defaults: Channels{InApp: true, Push: true}},
+	KindMatch:       {code: "4", defaults: Channels{InApp: true, Email: true, Push: true}},
+	KindUnlike:      {code: "5", defaults: Channels{InApp: true}},
+	KindAccount:     {code: "6", defaults: Channels{InApp: true}},
+	KindUnmatch:     {code: "7", defaults: Channels{InApp: true}},
+}
+
+// Kinds lists every kind, in the order preferences are shown
+var Kinds = []Kind{KindMessage, KindMatch, KindLike, KindProfileView, KindUnlike, KindUnmatch, KindAccount}
+
+// Valid reports whether k is a known kind
+func (k Kind) Valid() bool {
+	_, ok := kinds[k]
+	return ok
+}
+
+// Code is the numeric code of the kind, stored in notif_type
+func (k Kind) Code() string {
+	return kinds[k].code
+}
+
+// DefaultChannels are the channels of a kind for users who kept the defaults
+func (k Kind) DefaultChannels() Channels {
+	return kinds[k].defaults
+}
+
+// KindFromCode returns the kind of a version 1 notification. Unmatch used to
+// share code 1 with likes, so those rows read as likes.
+func KindFromCode(code string) (Kind, bool) {
+	for kind, info := range kinds {
+		if info.code == code {
+			return kind, true
+		}
+	}
+	return "", false
+}
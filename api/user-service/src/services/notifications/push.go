@@ -0,0 +1,327 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // quiet hours use IANA zones, missing from slim images
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"user-service/src/models"
+)
+
+const (
+	// pushTTL is how long push services keep a notification for an offline device
+	pushTTL = 24 * time.Hour
+	// maxPushSubscriptions per user, the oldest one is dropped beyond
+	maxPushSubscriptions = 10
+)
+
+var (
+	// ErrInvalidSubscription is returned for subscriptions that cannot receive pushes
+	ErrInvalidSubscription = errors.New("invalid push subscription")
+	// ErrInvalidQuietHours is returned for malformed quiet hours
+	ErrInvalidQuietHours = errors.New("invalid quiet hours")
+)
+
+// SubscriptionRequest is the PushSubscription JSON of the browser
+type SubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+	UserAgent string `json:"-"`
+}
+
+// QuietHoursUpdate sets the quiet hours; empty start and end turn them off
+type QuietHoursUpdate struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"time_zone"`
+}
+
+// Subscribe registers a browser subscription. A subscription registered by
+// another account on the same browser moves to this user.
+func (s *Service) Subscribe(userID uint, req SubscriptionRequest) (*models.PushSubscription, error) {
+	if err := validateSubscription(req); err != nil {
+		return nil, err
+	}
+
+	sub := models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: truncate(req.UserAgent, 255),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "endpoint"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth", "user_agent"}),
+		}).Create(&sub).Error; err != nil {
+			return err
+		}
+
+		// Keep the most recent subscriptions only
+		var stale []uint
+		if err := tx.Model(&models.PushSubscription{}).Where("user_id = ?", userID).
+			Order("created_at DESC, id DESC").Offset(maxPushSubscriptions).Pluck("id", &stale).Error; err != nil {
+			return err
+		}
+		if len(stale) > 0 {
+			return tx.Delete(&models.PushSubscription{}, stale).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save push subscription: %w", err)
+	}
+	if err := s.db.Where("endpoint = ?", req.Endpoint).First(&sub).Error; err != nil {
+		return nil, fmt.Errorf("failed to load push subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// Unsubscribe removes a subscription of the user. Removing an unknown
+// subscription is not an error.
+func (s *Service) Unsubscribe(userID uint, endpoint string) error {
+	return s.db.Where("user_id = ? AND endpoint = ?", userID, endpoint).Delete(&models.PushSubscription{}).Error
+}
+
+// Subscriptions lists the push subscriptions of the user
+func (s *Service) Subscriptions(userID uint) ([]models.PushSubscription, error) {
+	subs := []models.PushSubscription{}
+	err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&subs).Error
+	return subs, err
+}
+
+// QuietHours returns the quiet hours of the user, nil when there are none
+func (s *Service) QuietHours(userID uint) (*models.NotificationQuietHours, error) {
+	var rows []models.NotificationQuietHours
+	if err := s.db.Where("user_id = ?", userID).Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// SetQuietHours changes or removes the quiet hours of the user
+func (s *Service) SetQuietHours(userID uint, update QuietHoursUpdate) (*models.NotificationQuietHours, error) {
+	if update.Start == "" && update.End == "" {
+		if err := s.db.Where("user_id = ?", userID).Delete(&models.NotificationQuietHours{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to remove quiet hours: %w", err)
+		}
+		return nil, nil
+	}
+
+	if update.TimeZone == "" {
+		update.TimeZone = "UTC"
+	}
+	if _, err := time.Parse("15:04", update.Start); err != nil {
+		return nil, fmt.Errorf("%w: start must be HH:MM", ErrInvalidQuietHours)
+	}
+	if _, err := time.Parse("15:04", update.End); err != nil {
+		return nil, fmt.Errorf("%w: end must be HH:MM", ErrInvalidQuietHours)
+	}
+	if update.Start == update.End {
+		return nil, fmt.Errorf("%w: start and end are equal", ErrInvalidQuietHours)
+	}
+	if _, err := time.LoadLocation(update.TimeZone); err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidQuietHours, update.TimeZone)
+	}
+
+	quiet := models.NotificationQuietHours{
+		UserID:   userID,
+		Start:    update.Start,
+		End:      update.End,
+		TimeZone: update.TimeZone,
+	}
+	if err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&quiet).Error; err != nil {
+		return nil, fmt.Errorf("failed to save quiet hours: %w", err)
+	}
+	return &quiet, nil
+}
+
+// inQuietHours tells whether now falls in the quiet hours, which end at End
+// (excluded) and may span midnight
+func inQuietHours(quiet *models.NotificationQuietHours, now time.Time) bool {
+	if quiet == nil {
+		return false
+	}
+	loc, err := time.LoadLocation(quiet.TimeZone)
+	if err != nil {
+		return false
+	}
+	start, err1 := time.Parse("15:04", quiet.Start)
+	end, err2 := time.Parse("15:04", quiet.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+func validateSubscription(req SubscriptionRequest) error {
+	u, err := url.Parse(req.Endpoint)
+	// Push services are only reached over https, which also keeps the
+	// endpoint from pointing at internal services
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidSubscription)
+	}
+	// Encrypting a dummy payload checks both keys
+	if _, err := encryptPushPayload(req.Keys.P256dh, req.Keys.Auth, nil); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	return nil
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return strings.ToValidUTF8(value[:max], "")
+}
+
+// PresenceFunc tells whether a user has a live connection to the app
+type PresenceFunc func(ctx context.Context, userID uint) (bool, error)
+
+// PushPayload is the JSON the service worker receives
+type PushPayload struct {
+	Title          string         `json:"title"`
+	Body           string         `json:"body"`
+	Kind           Kind           `json:"kind"`
+	URL            string         `json:"url"`
+	NotificationID uint           `json:"notification_id,omitempty"`
+	Data           map[string]any `json:"data,omitempty"`
+}
+
+// PushChannel sends Web Push notifications to users with no live connection,
+// outside their quiet hours. Expired subscriptions are removed.
+type PushChannel struct {
+	db          *gorm.DB
+	client      *WebPushClient
+	online      PresenceFunc
+	frontendURL string
+	now         func() time.Time
+}
+
+// NewPushChannel creates the push channel. Without presence, every
+// notification is pushed.
+func NewPushChannel(db *gorm.DB, client *WebPushClient, online PresenceFunc) *PushChannel {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "https://localhost:8443"
+	}
+	return &PushChannel{
+		db:          db,
+		client:      client,
+		online:      online,
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		now:         time.Now,
+	}
+}
+
+// Name implements Channel
+func (c *PushChannel) Name() string { return ChannelPush }
+
+// Deliver implements Channel. It fails only when no subscription could be
+// reached, so that retries do not push twice to the same device.
+func (c *PushChannel) Deliver(ctx context.Context, notif *models.Notification) error {
+	if c.online != nil {
+		online, err := c.online(ctx, notif.ToUserID)
+		if err != nil {
+			// Better a push too many than a lost message
+			slog.WarnContext(ctx, "presence unknown, pushing anyway", "to_user_id", notif.ToUserID, "error", err)
+		} else if online {
+			return nil
+		}
+	}
+
+	service := NewService(c.db.WithContext(ctx), nil)
+	quiet, err := service.QuietHours(notif.ToUserID)
+	if err != nil {
+		return fmt.Errorf("failed to load quiet hours: %w", err)
+	}
+	if inQuietHours(quiet, c.now()) {
+		return nil
+	}
+
+	subs, err := service.Subscriptions(notif.ToUserID)
+	if err != nil {
+		return fmt.Errorf("failed to load push subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(c.payload(notif))
+	if err != nil {
+		return fmt.Errorf("failed to marshal push payload: %w", err)
+	}
+	urgency := "normal"
+	if kind := Kind(notif.Kind); kind == KindMessage || kind == KindMatch {
+		urgency = "high"
+	}
+
+	delivered := 0
+	var lastErr error
+	for _, sub := range subs {
+		err := c.client.Send(ctx, PushMessage{
+			Endpoint: sub.Endpoint,
+			P256dh:   sub.P256dh,
+			Auth:     sub.Auth,
+			Payload:  payload,
+			TTL:      pushTTL,
+			Urgency:  urgency,
+		})
+		switch {
+		case errors.Is(err, ErrSubscriptionGone):
+			slog.InfoContext(ctx, "removing expired push subscription", "subscription_id", sub.ID, "user_id", sub.UserID)
+			c.db.WithContext(ctx).Delete(&models.PushSubscription{}, sub.ID)
+		case err != nil:
+			lastErr = err
+		default:
+			delivered++
+			c.db.WithContext(ctx).Model(&models.PushSubscription{}).Where("id = ?", sub.ID).Update("last_used_at", c.now())
+		}
+	}
+
+	if delivered == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
+
+func (c *PushChannel) payload(notif *models.Notification) PushPayload {
+	payload := PushPayload{
+		Title:          "Matcha",
+		Body:           notif.Msg,
+		Kind:           Kind(notif.Kind),
+		URL:            c.frontendURL + "/app",
+		NotificationID: notif.ID,
+		Data:           notif.Data,
+	}
+	// Large data would not fit in a push, the app loads it from the inbox
+	if encoded, _ := json.Marshal(notif.Data); len(encoded) > 2000 {
+		payload.Data = nil
+	}
+	return payload
+}
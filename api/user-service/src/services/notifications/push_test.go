@@ -0,0 +1,324 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+// browser holds the keys of a push subscription, as a browser would
+type browser struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{private: private, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) SubscriptionRequest {
+	req := SubscriptionRequest{Endpoint: endpoint}
+	req.Keys.P256dh = base64.RawURLEncoding.EncodeToString(b.private.PublicKey().Bytes())
+	req.Keys.Auth = base64.RawURLEncoding.EncodeToString(b.auth)
+	return req
+}
+
+// decrypt reverses the aes128gcm content coding
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	require.Greater(t, len(body), 21)
+	salt := body[:16]
+	assert.Equal(t, uint32(pushRecordSize), binary.BigEndian.Uint32(body[16:20]))
+	idLen := int(body[20])
+	senderPublic := body[21 : 21+idLen]
+
+	sender, err := ecdh.P256().NewPublicKey(senderPublic)
+	require.NoError(t, err)
+	secret, err := b.private.ECDH(sender)
+	require.NoError(t, err)
+	cek, nonce := pushContentKeys(secret, b.auth, salt, b.private.PublicKey().Bytes(), senderPublic)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x02), plaintext[len(plaintext)-1], "last record delimiter")
+	return plaintext[:len(plaintext)-1]
+}
+
+type pushRequest struct {
+	Path          string
+	Authorization string
+	Headers       http.Header
+	Body          []byte
+}
+
+// pushServiceStub records pushes and answers with the status set per path
+type pushServiceStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   map[string]int
+	requests []pushRequest
+}
+
+func newPushServiceStub(t *testing.T) *pushServiceStub {
+	stub := &pushServiceStub{status: map[string]int{}}
+	stub.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.requests = append(stub.requests, pushRequest{r.URL.Path, r.Header.Get("Authorization"), r.Header, body})
+		status, ok := stub.status[r.URL.Path]
+		if !ok {
+			status = http.StatusCreated
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func verifyVAPID(t *testing.T, authorization, audience string) {
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "vapid "), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		params[key] = value
+	}
+	rawKey, err := base64.RawURLEncoding.DecodeString(params["k"])
+	require.NoError(t, err)
+	public, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), rawKey)
+	require.NoError(t, err)
+
+	parts := strings.Split(params["t"], ".")
+	require.Len(t, parts, 3)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	assert.True(t, ecdsa.Verify(public, digest[:], r, s), "VAPID signature")
+
+	rawClaims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(rawClaims, &claims))
+	assert.Equal(t, audience, claims["aud"])
+	assert.Greater(t, claims["exp"], float64(time.Now().Unix()))
+}
+
+func setupPushDB(t *testing.T) *gorm.DB {
+	db := setupNotificationDB(t)
+	require.NoError(t, db.AutoMigrate(&models.PushSubscription{}, &models.NotificationQuietHours{}))
+	return db
+}
+
+func TestVAPIDKeysRoundTrip(t *testing.T) {
+	keys, err := GenerateVAPIDKeys("mailto:test@example.com")
+	require.NoError(t, err)
+
+	parsed, err := ParseVAPIDKeys(keys.PrivateKey(), keys.Subject)
+	require.NoError(t, err)
+	assert.Equal(t, keys.PublicKey, parsed.PublicKey)
+
+	t.Setenv("VAPID_PRIVATE_KEY", "")
+	t.Setenv("VAPID_KEY_FILE", t.TempDir()+"/keys/vapid.key")
+	generated, err := LoadVAPIDKeys()
+	require.NoError(t, err)
+	loaded, err := LoadVAPIDKeys()
+	require.NoError(t, err)
+	assert.Equal(t, generated.PublicKey, loaded.PublicKey, "kept across restarts")
+
+	t.Setenv("VAPID_PRIVATE_KEY", keys.PrivateKey())
+	t.Setenv("VAPID_PUBLIC_KEY", generated.PublicKey)
+	_, err = LoadVAPIDKeys()
+	assert.Error(t, err, "mismatched key pair")
+}
+
+func TestPushChannelSendsEncryptedPushes(t *testing.T) {
+	db := setupPushDB(t)
+	stub := newPushServiceStub(t)
+	service := NewService(db, nil)
+
+	alice := newBrowser(t)
+	_, err := service.Subscribe(1, alice.subscription(stub.URL+"/alice"))
+	require.NoError(t, err)
+	gone := newBrowser(t)
+	_, err = service.Subscribe(1, gone.subscription(stub.URL+"/gone"))
+	require.NoError(t, err)
+	stub.status["/gone"] = http.StatusGone
+
+	keys, err := GenerateVAPIDKeys("mailto:test@example.com")
+	require.NoError(t, err)
+	client := NewWebPushClient(keys)
+	client.httpClient = stub.Client()
+	channel := NewPushChannel(db, client, nil)
+
+	err = channel.Deliver(context.Background(), &models.Notification{
+		ID: 3, ToUserID: 1, Kind: string(KindMessage), Msg: "Vous avez reçu un nouveau message",
+		Data: map[string]any{"conversation_id": float64(4)},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, stub.requests, 2)
+	var received *pushRequest
+	for i := range stub.requests {
+		if stub.requests[i].Path == "/alice" {
+			received = &stub.requests[i]
+		}
+	}
+	require.NotNil(t, received)
+	assert.Equal(t, "aes128gcm", received.Headers.Get("Content-Encoding"))
+	assert.Equal(t, "86400", received.Headers.Get("TTL"))
+	assert.Equal(t, "high", received.Headers.Get("Urgency"))
+	verifyVAPID(t, received.Authorization, stub.URL)
+
+	var payload PushPayload
+	require.NoError(t, json.Unmarshal(alice.decrypt(t, received.Body), &payload))
+	assert.Equal(t, "Vous avez reçu un nouveau message", payload.Body)
+	assert.Equal(t, KindMessage, payload.Kind)
+	assert.Equal(t, uint(3), payload.NotificationID)
+	assert.Equal(t, float64(4), payload.Data["conversation_id"])
+
+	subs, err := service.Subscriptions(1)
+	require.NoError(t, err)
+	require.Len(t, subs, 1, "expired subscription pruned")
+	assert.Equal(t, stub.URL+"/alice", subs[0].Endpoint)
+	assert.NotNil(t, subs[0].LastUsedAt)
+}
+
+func TestPushChannelSkipsOnlineUsersAndQuietHours(t *testing.T) {
+	db := setupPushDB(t)
+	stub := newPushServiceStub(t)
+	service := NewService(db, nil)
+	for _, userID := range []uint{1, 2} {
+		_, err := service.Subscribe(userID, newBrowser(t).subscription(stub.URL+"/"+string(rune('a'+userID))))
+		require.NoError(t, err)
+	}
+
+	keys, _ := GenerateVAPIDKeys("mailto:test@example.com")
+	client := NewWebPushClient(keys)
+	client.httpClient = stub.Client()
+	channel := NewPushChannel(db, client, func(ctx context.Context, userID uint) (bool, error) {
+		return userID == 1, nil
+	})
+	channel.now = func() time.Time { return time.Date(2024, 6, 1, 21, 30, 0, 0, time.UTC) }
+
+	// 23:30 in Paris, inside 22:00-07:00
+	_, err := service.SetQuietHours(2, QuietHoursUpdate{Start: "22:00", End: "07:00", TimeZone: "Europe/Paris"})
+	require.NoError(t, err)
+
+	for _, userID := range []uint{1, 2} {
+		require.NoError(t, channel.Deliver(context.Background(), &models.Notification{ToUserID: userID, Kind: string(KindLike), Msg: "Like"}))
+	}
+	assert.Empty(t, stub.requests, "online user and quiet hours")
+
+	_, err = service.SetQuietHours(2, QuietHoursUpdate{})
+	require.NoError(t, err)
+	require.NoError(t, channel.Deliver(context.Background(), &models.Notification{ToUserID: 2, Kind: string(KindLike), Msg: "Like"}))
+	assert.Len(t, stub.requests, 1)
+}
+
+func TestPushChannelFailsWhenNoDeviceIsReached(t *testing.T) {
+	db := setupPushDB(t)
+	stub := newPushServiceStub(t)
+	stub.status["/busy"] = http.StatusTooManyRequests
+	service := NewService(db, nil)
+	_, err := service.Subscribe(1, newBrowser(t).subscription(stub.URL+"/busy"))
+	require.NoError(t, err)
+
+	keys, _ := GenerateVAPIDKeys("mailto:test@example.com")
+	client := NewWebPushClient(keys)
+	client.httpClient = stub.Client()
+
+	err = NewPushChannel(db, client, nil).Deliver(context.Background(), &models.Notification{ToUserID: 1, Msg: "Like"})
+	assert.ErrorContains(t, err, "429")
+	subs, _ := service.Subscriptions(1)
+	assert.Len(t, subs, 1, "kept for the next attempt")
+}
+
+func TestSubscribeValidatesSubscriptions(t *testing.T) {
+	db := setupPushDB(t)
+	service := NewService(db, nil)
+	b := newBrowser(t)
+
+	_, err := service.Subscribe(1, b.subscription("http://auth-service:8001/internal"))
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+
+	bad := b.subscription("https://push.example.com/1")
+	bad.Keys.Auth = "short"
+	_, err = service.Subscribe(1, bad)
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+
+	// The same browser used by another account moves to it
+	_, err = service.Subscribe(1, b.subscription("https://push.example.com/1"))
+	require.NoError(t, err)
+	sub, err := service.Subscribe(2, b.subscription("https://push.example.com/1"))
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), sub.UserID)
+	subs, _ := service.Subscriptions(1)
+	assert.Empty(t, subs)
+
+	for i := 0; i < maxPushSubscriptions+2; i++ {
+		_, err := service.Subscribe(3, b.subscription("https://push.example.com/many/"+string(rune('a'+i))))
+		require.NoError(t, err)
+	}
+	subs, _ = service.Subscriptions(3)
+	assert.Len(t, subs, maxPushSubscriptions)
+}
+
+func TestQuietHours(t *testing.T) {
+	service := NewService(setupPushDB(t), nil)
+
+	for _, update := range []QuietHoursUpdate{
+		{Start: "25:00", End: "07:00"},
+		{Start: "22:00", End: "22:00"},
+		{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"},
+	} {
+		_, err := service.SetQuietHours(1, update)
+		assert.ErrorIs(t, err, ErrInvalidQuietHours, "%+v", update)
+	}
+
+	quiet, err := service.SetQuietHours(1, QuietHoursUpdate{Start: "13:00", End: "14:00"})
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", quiet.TimeZone)
+
+	at := func(hour, minute int) time.Time { return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC) }
+	assert.True(t, inQuietHours(quiet, at(13, 0)))
+	assert.False(t, inQuietHours(quiet, at(14, 0)))
+
+	overnight := &models.NotificationQuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}
+	assert.True(t, inQuietHours(overnight, at(23, 59)))
+	assert.True(t, inQuietHours(overnight, at(6, 59)))
+	assert.False(t, inQuietHours(overnight, at(12, 0)))
+	assert.False(t, inQuietHours(nil, at(23, 0)))
+}
+
+func TestEncryptRejectsLargePayloads(t *testing.T) {
+	req := newBrowser(t).subscription("https://push.example.com/1")
+	_, err := encryptPushPayload(req.Keys.P256dh, req.Keys.Auth, bytes.Repeat([]byte("a"), maxPushPayload+1))
+	assert.ErrorIs(t, err, ErrPushPayloadTooLarge)
+}
@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/maxg56/matcha/api/common/tracing"
+	"github.com/redis/go-redis/v9"
+
+	"user-service/src/conf"
+)
+
+// QueueKey is the Redis list on which services push notification requests
+const QueueKey = "notification_requests"
+
+// ConsumeQueue sends the requests pushed on the Redis queue until ctx is
+// cancelled. Requests that cannot be sent are logged and dropped. A request
+// popped as ctx is cancelled is no longer on the queue, it is still sent.
+func (s *Service) ConsumeQueue(ctx context.Context, client *redis.Client) {
+	if client == nil {
+		slog.WarnContext(ctx, "notification queue disabled: redis unavailable")
+		return
+	}
+	slog.InfoContext(ctx, "listening for notification requests", "queue", QueueKey)
+
+	for ctx.Err() == nil {
+		result, err := client.BRPop(ctx, 5*time.Second, QueueKey).Result()
+		if err == nil {
+			// result is [key, payload]
+			s.handleQueued(context.WithoutCancel(ctx), result[1])
+			continue
+		}
+		if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "failed to read notification queue", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+	}
+	slog.InfoContext(ctx, "notification queue consumer stopped")
+}
+
+func (s *Service) handleQueued(ctx context.Context, payload string) {
+	var req Request
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		slog.WarnContext(ctx, "dropping malformed notification request", "error", err)
+		return
+	}
+	if req.ToUserID == 0 || req.Message == "" {
+		slog.WarnContext(ctx, "dropping incomplete notification request", "to_user_id", req.ToUserID)
+		return
+	}
+	ctx = tracing.WithTraceParent(ctx, req.TraceParent)
+	if _, err := s.Notify(ctx, req); err != nil {
+		slog.WarnContext(ctx, "failed to send notification", "kind", req.Kind, "to_user_id", req.ToUserID, "error", err)
+	}
+}
+
+// Sender queues notifications from within user-service
+type Sender struct {
+	client *redis.Client
+}
+
+// NewSender creates a sender on the shared Redis connection
+func NewSender() *Sender {
+	return &Sender{client: conf.RedisClient}
+}
+
+// Send queues a notification request
+func (ns *Sender) Send(req Request) error {
+	if ns.client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification request: %w", err)
+	}
+	if err := ns.client.LPush(conf.Ctx, QueueKey, payload).Err(); err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// SendProfileViewNotification tells a user someone viewed their profile
+func (ns *Sender) SendProfileViewNotification(targetUserID, fromUserID uint) error {
+	return ns.Send(Request{
+		ToUserID:   targetUserID,
+		Kind:       KindProfileView,
+		FromUserID: fromUserID,
+		Message:    "Quelqu'un a consulté votre profil 👀",
+	})
+}
+
+// SendAccountNotification sends an account notice (no sender) to a user
+func (ns *Sender) SendAccountNotification(userID uint, message string) error {
+	return ns.Send(Request{
+		ToUserID: userID,
+		Kind:     KindAccount,
+		Message:  message,
+	})
+}
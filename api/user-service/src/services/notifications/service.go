@@ -0,0 +1,313 @@
+// Package notifications stores the notifications of users and delivers them
+// on the channels each user chose per kind: the in-app inbox (with a live copy
+// through the gateway), email and web push. Other services hand requests over
+// through the internal API or the Redis queue; delivery is asynchronous so a
+// slow channel never holds up the sender.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"user-service/src/models"
+)
+
+const (
+	// DefaultPageSize is the number of notifications listed by default
+	DefaultPageSize = 30
+	// MaxPageSize caps the number of notifications listed at once
+	MaxPageSize = 100
+)
+
+var (
+	// ErrUnknownKind is returned for a request without a known kind
+	ErrUnknownKind = errors.New("unknown notification kind")
+	// ErrNotFound is returned when the notification does not belong to the user
+	ErrNotFound = errors.New("notification not found")
+)
+
+// Request asks for a notification to be sent. Requests in the version 1
+// format only carry notif_type, which is mapped to its kind.
+type Request struct {
+	ToUserID   uint           `json:"to_user_id" binding:"required"`
+	Kind       Kind           `json:"kind"`
+	NotifType  string         `json:"notif_type,omitempty"`
+	FromUserID uint           `json:"from_user_id,omitempty"`
+	Message    string         `json:"message" binding:"required,max=500"`
+	Data       map[string]any `json:"data,omitempty"`
+	// TraceParent continues the trace of the sender for requests pushed
+	// on the Redis queue; HTTP requests carry it in their headers
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// View is a notification as shown to its recipient
+type View struct {
+	ID         uint           `json:"id"`
+	Kind       Kind           `json:"kind"`
+	Version    int            `json:"version"`
+	Message    string         `json:"message"`
+	FromUserID *uint          `json:"from_user_id,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+	Read       bool           `json:"read"`
+	ReadAt     *time.Time     `json:"read_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// Preference is the channels of one kind for a user
+type Preference struct {
+	Kind Kind `json:"kind"`
+	Channels
+}
+
+// PreferenceUpdate changes the channels of one kind; nil fields are kept
+type PreferenceUpdate struct {
+	Kind  Kind  `json:"kind" binding:"required"`
+	InApp *bool `json:"in_app"`
+	Email *bool `json:"email"`
+	Push  *bool `json:"push"`
+}
+
+// Service stores notifications and hands them to the dispatcher
+type Service struct {
+	db         *gorm.DB
+	dispatcher *Dispatcher
+}
+
+// NewService creates a notification service. A nil dispatcher only stores
+// notifications in the inbox.
+func NewService(db *gorm.DB, dispatcher *Dispatcher) *Service {
+	return &Service{db: db, dispatcher: dispatcher}
+}
+
+// Notify stores the notification in the inbox of the recipient when they
+// keep in-app notifications for its kind, and queues its delivery on the
+// other channels. It returns nil when the user turned every channel off.
+// The delivery continues the trace of ctx.
+func (s *Service) Notify(ctx context.Context, req Request) (*models.Notification, error) {
+	kind := req.Kind
+	if kind == "" {
+		kind, _ = KindFromCode(req.NotifType)
+	}
+	if !kind.Valid() {
+		return nil, ErrUnknownKind
+	}
+
+	channels, err := s.channels(req.ToUserID, kind)
+	if err != nil {
+		return nil, err
+	}
+	if !channels.Any() {
+		return nil, nil
+	}
+
+	notif := &models.Notification{
+		ToUserID:  req.ToUserID,
+		NotifType: kind.Code(),
+		Kind:      string(kind),
+		Version:   Version,
+		Msg:       req.Message,
+		Data:      req.Data,
+		Time:      time.Now(),
+	}
+	if req.FromUserID != 0 {
+		from := req.FromUserID
+		notif.FromUserID = &from
+	}
+
+	if channels.InApp {
+		if err := s.db.Create(notif).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if !s.dispatcher.Enqueue(Delivery{Notification: *notif, Channels: channels, Trace: trace.SpanContextFromContext(ctx)}) {
+		slog.WarnContext(ctx, "notification queue full, notification not delivered", "kind", kind, "to_user_id", req.ToUserID)
+	}
+	return notif, nil
+}
+
+// List returns the notifications of a user, newest first. beforeID pages
+// through older notifications.
+func (s *Service) List(userID uint, unreadOnly bool, beforeID uint, limit int) ([]View, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	query := s.db.Where("to_user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	var notifs []models.Notification
+	if err := query.Order("id DESC").Limit(limit).Find(&notifs).Error; err != nil {
+		return nil, err
+	}
+
+	views := make([]View, 0, len(notifs))
+	for i := range notifs {
+		views = append(views, NewView(&notifs[i]))
+	}
+	return views, nil
+}
+
+// UnreadCount returns the number of unread notifications of a user
+func (s *Service) UnreadCount(userID uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.Notification{}).Where("to_user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one notification of the user as read. Reading it again
+// keeps the first read time.
+func (s *Service) MarkRead(userID, notificationID uint) error {
+	var notif models.Notification
+	err := s.db.Where("id = ? AND to_user_id = ?", notificationID, userID).First(&notif).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if notif.ReadAt != nil {
+		return nil
+	}
+	return s.db.Model(&notif).Update("read_at", time.Now()).Error
+}
+
+// MarkAllRead marks every notification of the user as read and returns how
+// many were unread
+func (s *Service) MarkAllRead(userID uint) (int64, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("to_user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// Preferences returns the channels of every kind for a user
+func (s *Service) Preferences(userID uint) ([]Preference, error) {
+	saved, err := s.savedPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make([]Preference, 0, len(Kinds))
+	for _, kind := range Kinds {
+		channels := kind.DefaultChannels()
+		if pref, ok := saved[kind]; ok {
+			channels = Channels{InApp: pref.InApp, Email: pref.Email, Push: pref.Push}
+		}
+		prefs = append(prefs, Preference{Kind: kind, Channels: channels})
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences changes the channels of the given kinds and returns the
+// channels of every kind
+func (s *Service) UpdatePreferences(userID uint, updates []PreferenceUpdate) ([]Preference, error) {
+	for _, update := range updates {
+		if !update.Kind.Valid() {
+			return nil, ErrUnknownKind
+		}
+	}
+
+	current, err := s.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	byKind := make(map[Kind]Channels, len(current))
+	for _, pref := range current {
+		byKind[pref.Kind] = pref.Channels
+	}
+
+	now := time.Now()
+	rows := make([]models.NotificationPreference, 0, len(updates))
+	for _, update := range updates {
+		channels := byKind[update.Kind]
+		if update.InApp != nil {
+			channels.InApp = *update.InApp
+		}
+		if update.Email != nil {
+			channels.Email = *update.Email
+		}
+		if update.Push != nil {
+			channels.Push = *update.Push
+		}
+		byKind[update.Kind] = channels
+		rows = append(rows, models.NotificationPreference{
+			UserID:    userID,
+			Kind:      string(update.Kind),
+			InApp:     channels.InApp,
+			Email:     channels.Email,
+			Push:      channels.Push,
+			UpdatedAt: now,
+		})
+	}
+
+	if len(rows) > 0 {
+		if err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	return s.Preferences(userID)
+}
+
+// channels returns the channels of one kind for a user
+func (s *Service) channels(userID uint, kind Kind) (Channels, error) {
+	var pref models.NotificationPreference
+	err := s.db.Where("user_id = ? AND kind = ?", userID, string(kind)).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return kind.DefaultChannels(), nil
+	}
+	if err != nil {
+		return Channels{}, err
+	}
+	return Channels{InApp: pref.InApp, Email: pref.Email, Push: pref.Push}, nil
+}
+
+func (s *Service) savedPreferences(userID uint) (map[Kind]models.NotificationPreference, error) {
+	var rows []models.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	saved := make(map[Kind]models.NotificationPreference, len(rows))
+	for _, row := range rows {
+		saved[Kind(row.Kind)] = row
+	}
+	return saved, nil
+}
+
+// NewView describes a stored notification, reading the kind of version 1
+// rows from their numeric code
+func NewView(notif *models.Notification) View {
+	kind := Kind(notif.Kind)
+	if kind == "" {
+		kind, _ = KindFromCode(notif.NotifType)
+	}
+	version := notif.Version
+	if version == 0 {
+		version = 1
+	}
+	return View{
+		ID:         notif.ID,
+		Kind:       kind,
+		Version:    version,
+		Message:    notif.Msg,
+		FromUserID: notif.FromUserID,
+		Data:       notif.Data,
+		Read:       notif.ReadAt != nil,
+		ReadAt:     notif.ReadAt,
+		CreatedAt:  notif.Time,
+	}
+}
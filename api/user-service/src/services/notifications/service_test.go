@@ -0,0 +1,278 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+type fakeChannel struct {
+	name      string
+	failures  int
+	delivered []uint
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+
+func (f *fakeChannel) Deliver(ctx context.Context, notif *models.Notification) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("unavailable")
+	}
+	f.delivered = append(f.delivered, notif.ToUserID)
+	return nil
+}
+
+func setupNotificationDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Notification{}, &models.NotificationPreference{}))
+	return db
+}
+
+func TestKindCodesAreDistinct(t *testing.T) {
+	seen := map[string]Kind{}
+	for _, kind := range Kinds {
+		require.True(t, kind.Valid())
+		if other, ok := seen[kind.Code()]; ok {
+			t.Fatalf("%s and %s share code %s", kind, other, kind.Code())
+		}
+		seen[kind.Code()] = kind
+
+		got, ok := KindFromCode(kind.Code())
+		assert.True(t, ok)
+		assert.Equal(t, kind, got)
+	}
+	assert.Len(t, seen, len(kinds))
+}
+
+func TestNotifyStoresAndQueuesDelivery(t *testing.T) {
+	db := setupNotificationDB(t)
+	dispatcher := NewDispatcher()
+	service := NewService(db, dispatcher)
+
+	notif, err := service.Notify(context.Background(), Request{
+		ToUserID:   1,
+		Kind:       KindMatch,
+		FromUserID: 2,
+		Message:    "C'est un match !",
+		Data:       map[string]any{"match_id": float64(7)},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, notif)
+	assert.NotZero(t, notif.ID)
+
+	var stored models.Notification
+	require.NoError(t, db.First(&stored, notif.ID).Error)
+	assert.Equal(t, "match", stored.Kind)
+	assert.Equal(t, "4", stored.NotifType)
+	assert.Equal(t, Version, stored.Version)
+	assert.Equal(t, uint(2), *stored.FromUserID)
+	assert.Equal(t, float64(7), stored.Data["match_id"])
+
+	delivery := <-dispatcher.queue
+	assert.Equal(t, notif.ID, delivery.Notification.ID)
+	assert.Equal(t, KindMatch.DefaultChannels(), delivery.Channels)
+
+	// Version 1 requests carry a numeric type only
+	notif, err = service.Notify(context.Background(), Request{ToUserID: 1, NotifType: "3", Message: "Nouveau message"})
+	require.NoError(t, err)
+	assert.Equal(t, "message", notif.Kind)
+
+	_, err = service.Notify(context.Background(), Request{ToUserID: 1, Kind: "wink", Message: "?"})
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+func TestNotifyFollowsPreferences(t *testing.T) {
+	db := setupNotificationDB(t)
+	dispatcher := NewDispatcher()
+	service := NewService(db, dispatcher)
+	off, on := false, true
+
+	_, err := service.UpdatePreferences(1, []PreferenceUpdate{
+		{Kind: KindLike, InApp: &off},
+		{Kind: KindProfileView, InApp: &off},
+		{Kind: KindAccount, Email: &on},
+	})
+	require.NoError(t, err)
+
+	// Push only: delivered, not kept in the inbox
+	notif, err := service.Notify(context.Background(), Request{ToUserID: 1, Kind: KindLike, Message: "Quelqu'un vous a liké"})
+	require.NoError(t, err)
+	require.NotNil(t, notif)
+	assert.Zero(t, notif.ID)
+	delivery := <-dispatcher.queue
+	assert.Equal(t, Channels{Push: true}, delivery.Channels)
+
+	// Every channel off: nothing happens
+	notif, err = service.Notify(context.Background(), Request{ToUserID: 1, Kind: KindProfileView, Message: "Vu"})
+	require.NoError(t, err)
+	assert.Nil(t, notif)
+	assert.Empty(t, dispatcher.queue)
+
+	var count int64
+	db.Model(&models.Notification{}).Count(&count)
+	assert.Zero(t, count)
+
+	prefs, err := service.Preferences(1)
+	require.NoError(t, err)
+	require.Len(t, prefs, len(Kinds))
+	for _, pref := range prefs {
+		switch pref.Kind {
+		case KindAccount:
+			assert.Equal(t, Channels{InApp: true, Email: true}, pref.Channels)
+		case KindMessage:
+			assert.Equal(t, KindMessage.DefaultChannels(), pref.Channels)
+		}
+	}
+
+	_, err = service.UpdatePreferences(1, []PreferenceUpdate{{Kind: "wink", Push: &on}})
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+func TestMarkReadAndUnreadCount(t *testing.T) {
+	db := setupNotificationDB(t)
+	service := NewService(db, nil)
+
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		notif, err := service.Notify(context.Background(), Request{ToUserID: 1, Kind: KindMessage, Message: "Nouveau message"})
+		require.NoError(t, err)
+		ids = append(ids, notif.ID)
+	}
+	other, err := service.Notify(context.Background(), Request{ToUserID: 2, Kind: KindMessage, Message: "Nouveau message"})
+	require.NoError(t, err)
+
+	unread, err := service.UnreadCount(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), unread)
+
+	require.NoError(t, service.MarkRead(1, ids[0]))
+	require.NoError(t, service.MarkRead(1, ids[0]), "marking twice is allowed")
+	assert.ErrorIs(t, service.MarkRead(1, other.ID), ErrNotFound)
+
+	views, err := service.List(1, true, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.Equal(t, ids[2], views[0].ID, "newest first")
+
+	marked, err := service.MarkAllRead(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), marked)
+	unread, _ = service.UnreadCount(1)
+	assert.Zero(t, unread)
+	unread, _ = service.UnreadCount(2)
+	assert.Equal(t, int64(1), unread)
+
+	views, err = service.List(1, false, ids[2], 10)
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.True(t, views[0].Read)
+}
+
+func TestNewViewReadsVersion1Rows(t *testing.T) {
+	view := NewView(&models.Notification{ID: 1, NotifType: "2", Msg: "Vu", Time: time.Now()})
+	assert.Equal(t, KindProfileView, view.Kind)
+	assert.Equal(t, 1, view.Version)
+	assert.False(t, view.Read)
+}
+
+func TestDispatcherDeliversOnEnabledChannels(t *testing.T) {
+	inApp := &fakeChannel{name: ChannelInApp}
+	email := &fakeChannel{name: ChannelEmail, failures: 2}
+	push := &fakeChannel{name: ChannelPush}
+	dispatcher := NewDispatcher(inApp, email, push)
+	dispatcher.backoff = time.Millisecond
+
+	dispatcher.Deliver(context.Background(), Delivery{
+		Notification: models.Notification{ID: 1, ToUserID: 5},
+		Channels:     Channels{InApp: true, Email: true},
+	})
+
+	assert.Equal(t, []uint{5}, inApp.delivered)
+	assert.Equal(t, []uint{5}, email.delivered, "retried until delivered")
+	assert.Empty(t, push.delivered, "turned off")
+}
+
+func TestDispatcherFlushDeliversQueuedNotifications(t *testing.T) {
+	inApp := &fakeChannel{name: ChannelInApp}
+	dispatcher := NewDispatcher(inApp)
+	for _, userID := range []uint{3, 4} {
+		require.True(t, dispatcher.Enqueue(Delivery{
+			Notification: models.Notification{ToUserID: userID},
+			Channels:     Channels{InApp: true},
+		}))
+	}
+
+	require.NoError(t, dispatcher.Flush(context.Background()))
+	assert.Equal(t, []uint{3, 4}, inApp.delivered)
+	require.NoError(t, dispatcher.Flush(context.Background()), "nothing left to flush")
+}
+
+// slowChannel takes a while to deliver, and fails if its context is
+// cancelled in the meantime
+type slowChannel struct {
+	started chan struct{}
+	err     chan error
+}
+
+func (f *slowChannel) Name() string { return ChannelInApp }
+
+func (f *slowChannel) Deliver(ctx context.Context, notif *models.Notification) error {
+	close(f.started)
+	select {
+	case <-ctx.Done():
+		f.err <- ctx.Err()
+	case <-time.After(50 * time.Millisecond):
+		f.err <- nil
+	}
+	return nil
+}
+
+func TestDispatcherRunFinishesTheDeliveriesInFlight(t *testing.T) {
+	channel := &slowChannel{started: make(chan struct{}), err: make(chan error, 1)}
+	dispatcher := NewDispatcher(channel)
+	dispatcher.backoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(stopped)
+	}()
+
+	require.True(t, dispatcher.Enqueue(Delivery{
+		Notification: models.Notification{ToUserID: 3},
+		Channels:     Channels{InApp: true},
+	}))
+	<-channel.started
+	cancel()
+	<-stopped
+
+	assert.NoError(t, <-channel.err, "the delivery in flight outlives the stop")
+}
+
+func TestHandleQueuedContinuesTheTraceOfTheSender(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	dispatcher := NewDispatcher()
+	service := NewService(setupNotificationDB(t), dispatcher)
+
+	service.handleQueued(context.Background(), `{"to_user_id":1,"kind":"message","message":"Nouveau message",`+
+		`"traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}`)
+
+	delivery := <-dispatcher.queue
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", delivery.Trace.TraceID().String())
+}
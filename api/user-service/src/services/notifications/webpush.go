@@ -0,0 +1,331 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// pushRecordSize is the record size announced in the aes128gcm header.
+	// Payloads always fit in a single record.
+	pushRecordSize = 4096
+	// maxPushPayload leaves room for the header, padding delimiter and tag
+	// within the 4096 bytes every push service accepts
+	maxPushPayload = 3993
+	// vapidTokenTTL is the lifetime of the signed VAPID token (24h at most)
+	vapidTokenTTL = 12 * time.Hour
+)
+
+var (
+	// ErrPushPayloadTooLarge is returned for payloads that do not fit in one record
+	ErrPushPayloadTooLarge = errors.New("push payload too large")
+	// ErrSubscriptionGone is returned when the push service no longer knows
+	// the subscription (404 or 410) and it should be removed
+	ErrSubscriptionGone = errors.New("push subscription expired")
+)
+
+// VAPIDKeys identify this server to push services (RFC 8292)
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+	// PublicKey is the uncompressed P-256 point, base64url encoded, that
+	// clients pass as applicationServerKey when subscribing
+	PublicKey string
+	// Subject is a mailto: or https: contact for push service operators
+	Subject string
+}
+
+// GenerateVAPIDKeys creates a new key pair
+func GenerateVAPIDKeys(subject string) (*VAPIDKeys, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate VAPID key: %w", err)
+	}
+	return newVAPIDKeys(private, subject)
+}
+
+// ParseVAPIDKeys reads a base64url encoded raw private key, the format
+// printed by the usual web-push tooling
+func ParseVAPIDKeys(privateKey, subject string) (*VAPIDKeys, error) {
+	raw, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	private, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	return newVAPIDKeys(private, subject)
+}
+
+func newVAPIDKeys(private *ecdsa.PrivateKey, subject string) (*VAPIDKeys, error) {
+	public, err := private.PublicKey.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID public key: %w", err)
+	}
+	return &VAPIDKeys{
+		private:   private,
+		PublicKey: base64.RawURLEncoding.EncodeToString(public),
+		Subject:   subject,
+	}, nil
+}
+
+// PrivateKey returns the base64url encoded raw private key
+func (k *VAPIDKeys) PrivateKey() string {
+	raw, _ := k.private.Bytes()
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// LoadVAPIDKeys reads the keys from VAPID_PRIVATE_KEY. Without it, a key is
+// generated on first start and kept in VAPID_KEY_FILE so that subscriptions
+// survive restarts. VAPID_SUBJECT defaults to the support address.
+func LoadVAPIDKeys() (*VAPIDKeys, error) {
+	subject := os.Getenv("VAPID_SUBJECT")
+	if subject == "" {
+		subject = "mailto:support@matcha.local"
+	}
+
+	if privateKey := os.Getenv("VAPID_PRIVATE_KEY"); privateKey != "" {
+		keys, err := ParseVAPIDKeys(privateKey, subject)
+		if err != nil {
+			return nil, err
+		}
+		if public := os.Getenv("VAPID_PUBLIC_KEY"); public != "" && public != keys.PublicKey {
+			return nil, fmt.Errorf("VAPID_PUBLIC_KEY does not match VAPID_PRIVATE_KEY")
+		}
+		return keys, nil
+	}
+
+	keyFile := os.Getenv("VAPID_KEY_FILE")
+	if keyFile == "" {
+		keyFile = "/app/data/vapid_private.key"
+	}
+	if content, err := os.ReadFile(keyFile); err == nil {
+		return ParseVAPIDKeys(strings.TrimSpace(string(content)), subject)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", keyFile, err)
+	}
+
+	keys, err := GenerateVAPIDKeys(subject)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(keyFile), err)
+	}
+	if err := os.WriteFile(keyFile, []byte(keys.PrivateKey()+"\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", keyFile, err)
+	}
+	slog.Info("generated VAPID keys", "path", keyFile, "public_key", keys.PublicKey)
+	return keys, nil
+}
+
+// authorization builds the VAPID Authorization header for an endpoint
+func (k *VAPIDKeys) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid push endpoint %q", endpoint)
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTokenTTL).Unix(),
+		"sub": k.Subject,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + k.PublicKey, nil
+}
+
+// PushMessage is one push sent to one subscription
+type PushMessage struct {
+	Endpoint string
+	// P256dh and Auth are the base64url keys of the subscription
+	P256dh  string
+	Auth    string
+	Payload []byte
+	// TTL is how long the push service keeps the message for an offline device
+	TTL time.Duration
+	// Urgency is very-low, low, normal or high (RFC 8030)
+	Urgency string
+	// Topic replaces a pending message with the same topic
+	Topic string
+}
+
+// WebPushClient sends encrypted pushes to push services
+type WebPushClient struct {
+	keys       *VAPIDKeys
+	httpClient *http.Client
+}
+
+// NewWebPushClient creates a client signing with the given keys
+func NewWebPushClient(keys *VAPIDKeys) *WebPushClient {
+	return &WebPushClient{
+		keys:       keys,
+		httpClient: &http.Client{Timeout: deliveryTimeout},
+	}
+}
+
+// Send encrypts the payload for the subscription and posts it to the push
+// service. It returns ErrSubscriptionGone on 404 and 410.
+func (c *WebPushClient) Send(ctx context.Context, msg PushMessage) error {
+	body, err := encryptPushPayload(msg.P256dh, msg.Auth, msg.Payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.keys.authorization(msg.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(msg.TTL.Seconds())))
+	if msg.Urgency != "" {
+		req.Header.Set("Urgency", msg.Urgency)
+	}
+	if msg.Topic != "" {
+		req.Header.Set("Topic", msg.Topic)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("push service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encryptPushPayload encrypts a payload with the aes128gcm content coding
+// for the subscription keys (RFC 8291). The body starts with the header:
+// salt (16) | record size (4) | key id length (1) | sender public key (65).
+func encryptPushPayload(p256dh, authSecret string, payload []byte) ([]byte, error) {
+	if len(payload) > maxPushPayload {
+		return nil, ErrPushPayloadTooLarge
+	}
+
+	rawReceiver, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	receiver, err := ecdh.P256().NewPublicKey(rawReceiver)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	auth, err := decodeBase64URL(authSecret)
+	if err != nil || len(auth) != 16 {
+		return nil, fmt.Errorf("invalid auth secret")
+	}
+
+	sender, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate push key: %w", err)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	secret, err := sender.ECDH(receiver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push secret: %w", err)
+	}
+	senderPublic := sender.PublicKey().Bytes()
+	cek, nonce := pushContentKeys(secret, auth, salt, rawReceiver, senderPublic)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 16+4+1+len(senderPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(senderPublic)))
+	header = append(header, senderPublic...)
+
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// pushContentKeys derives the content encryption key and nonce (RFC 8291 §3.4)
+func pushContentKeys(secret, auth, salt, receiverPublic, senderPublic []byte) (cek, nonce []byte) {
+	keyInfo := append([]byte("WebPush: info\x00"), receiverPublic...)
+	keyInfo = append(keyInfo, senderPublic...)
+	ikm := hkdfExpand(hkdfExtract(auth, secret), keyInfo, 32)
+
+	prk := hkdfExtract(salt, ikm)
+	cek = hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce = hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	return cek, nonce
+}
+
+func hkdfExtract(salt, ikm []byte) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// hkdfExpand is HKDF-Expand for lengths up to one SHA-256 block, all Web Push needs
+func hkdfExpand(prk, info []byte, length int) []byte {
+	mac := hmac.New(sha256.New, prk)
+	mac.Write(info)
+	mac.Write([]byte{0x01})
+	return mac.Sum(nil)[:length]
+}
+
+func decodeBase64URL(value string) ([]byte, error) {
+	value = strings.TrimRight(strings.TrimSpace(value), "=")
+	value = strings.NewReplacer("+", "-", "/", "_").Replace(value)
+	return base64.RawURLEncoding.DecodeString(value)
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"time"
 	"user-service/src/conf"
 )
@@ -23,7 +25,7 @@ type UserPresence struct {
 }
 
 // GetUserPresence gets presence information for a single user
-func (ps *PresenceService) GetUserPresence(userID uint) (*UserPresence, error) {
+func (ps *PresenceService) GetUserPresence(ctx context.Context, userID uint) (*UserPresence, error) {
 	if conf.RedisClient == nil {
 		return nil, fmt.Errorf("Redis client not initialized")
 	}
@@ -45,7 +47,7 @@ func (ps *PresenceService) GetUserPresence(userID uint) (*UserPresence, error) {
 		lastSeen, err := conf.GetUserLastSeen(userID)
 		if err != nil {
 			// Log error but don't fail the request
-			fmt.Printf("Warning: failed to get last seen for user %d: %v\n", userID, err)
+			slog.WarnContext(ctx, "failed to get last seen", "target_user_id", userID, "error", err)
 		} else {
 			presence.LastSeen = lastSeen
 		}
@@ -55,7 +57,7 @@ func (ps *PresenceService) GetUserPresence(userID uint) (*UserPresence, error) {
 }
 
 // GetMultipleUsersPresence gets presence information for multiple users efficiently
-func (ps *PresenceService) GetMultipleUsersPresence(userIDs []uint) (map[uint]*UserPresence, error) {
+func (ps *PresenceService) GetMultipleUsersPresence(ctx context.Context, userIDs []uint) (map[uint]*UserPresence, error) {
 	if conf.RedisClient == nil {
 		return nil, fmt.Errorf("Redis client not initialized")
 	}
@@ -90,7 +92,7 @@ func (ps *PresenceService) GetMultipleUsersPresence(userIDs []uint) (map[uint]*U
 			lastSeen, err := conf.GetUserLastSeen(userID)
 			if err != nil {
 				// Log error but don't fail the request
-				fmt.Printf("Warning: failed to get last seen for user %d: %v\n", userID, err)
+				slog.WarnContext(ctx, "failed to get last seen", "target_user_id", userID, "error", err)
 			} else {
 				presence.LastSeen = lastSeen
 			}
@@ -1,7 +1,8 @@
 package services
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
 	"user-service/src/conf"
@@ -12,18 +13,18 @@ import (
 
 // ProfileViewService handles profile view operations
 type ProfileViewService struct{
-	notificationService *notifications.NotificationService
+	notificationService *notifications.Sender
 }
 
 // NewProfileViewService creates a new profile view service
 func NewProfileViewService() *ProfileViewService {
 	return &ProfileViewService{
-		notificationService: notifications.NewNotificationService(),
+		notificationService: notifications.NewSender(),
 	}
 }
 
 // TrackProfileView records a profile view between two users
-func (s *ProfileViewService) TrackProfileView(viewerID, viewedID uint) (bool, error) {
+func (s *ProfileViewService) TrackProfileView(ctx context.Context, viewerID, viewedID uint) (bool, error) {
 	// Users cannot track viewing their own profile
 	if viewerID == viewedID {
 		return false, utils.NewAppError("cannot track viewing your own profile", 400)
@@ -53,11 +54,9 @@ func (s *ProfileViewService) TrackProfileView(viewerID, viewedID uint) (bool, er
 		}
 
 		// Send profile view notification to the viewed user
-		if err := s.notificationService.SendProfileViewNotification(int(viewedID), int(viewerID)); err != nil {
+		if err := s.notificationService.SendProfileViewNotification(viewedID, viewerID); err != nil {
 			// Log but don't fail the profile view tracking if notification fails
-			// Using a simple log.Printf since we don't have a logger package here
-			// In a production environment, you'd want proper logging
-			log.Printf("⚠️ [WARNING] Failed to send profile view notification: %v", err)
+			slog.WarnContext(ctx, "failed to send profile view notification", "viewed_id", viewedID, "error", err)
 		}
 
 		return true, nil // New record created
@@ -0,0 +1,54 @@
+package profilepause
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"user-service/src/models"
+	"user-service/src/services/notifications"
+)
+
+// UserNotifier notifies in-app, and by email when a pause ends on its own
+type UserNotifier struct {
+	notifs *notifications.Sender
+	emails *notifications.EmailClient
+}
+
+// NewUserNotifier creates the default pause notifier
+func NewUserNotifier() *UserNotifier {
+	return &UserNotifier{
+		notifs: notifications.NewSender(),
+		emails: notifications.NewEmailClient(),
+	}
+}
+
+// Paused confirms the profile is now hidden
+func (n *UserNotifier) Paused(ctx context.Context, user *models.User) {
+	message := "Votre profil est en pause, il n'est plus proposé aux autres membres ⏸️"
+	if user.PauseUntil != nil {
+		message = "Votre profil est en pause jusqu'au " + user.PauseUntil.Format("02/01/2006") + " ⏸️"
+	}
+	if err := n.notifs.SendAccountNotification(user.ID, message); err != nil {
+		slog.WarnContext(ctx, "failed to notify about profile pause", "user_id", user.ID, "error", err)
+	}
+}
+
+// Resumed tells the user their profile is visible again
+func (n *UserNotifier) Resumed(ctx context.Context, user *models.User, automatic bool) {
+	if err := n.notifs.SendAccountNotification(user.ID, "Votre profil est de nouveau visible 👋"); err != nil {
+		slog.WarnContext(ctx, "failed to notify about profile resume", "user_id", user.ID, "error", err)
+	}
+	if !automatic {
+		return
+	}
+
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "https://localhost:8443"
+	}
+	data := map[string]interface{}{"AppURL": frontendURL + "/app"}
+	if err := n.emails.Send(ctx, user.Email, "profile_resumed", "", data); err != nil {
+		slog.WarnContext(ctx, "failed to email about profile resume", "user_id", user.ID, "error", err)
+	}
+}
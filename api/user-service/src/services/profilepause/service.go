@@ -0,0 +1,192 @@
+// Package profilepause lets users take a break without deleting their account.
+// A paused profile is hidden from matching, search and non-matches; existing
+// matches and conversations are kept. The pause ends when the user resumes,
+// on the chosen date, or on the next login when requested.
+package profilepause
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+// MaxPause is the longest break that can be scheduled with an end date
+const MaxPause = 365 * 24 * time.Hour
+
+var (
+	// ErrDeletionPending is returned when the account is waiting for deletion
+	ErrDeletionPending = errors.New("account is scheduled for deletion")
+	// ErrInvalidUntil is returned when the end date is in the past or too far away
+	ErrInvalidUntil = errors.New("pause end date must be in the future and within one year")
+	// ErrNotPaused is returned when resuming a profile that is not paused
+	ErrNotPaused = errors.New("profile is not paused")
+)
+
+// Notifier tells the user their profile was paused or resumed.
+// automatic is true when the pause ended on its scheduled date.
+type Notifier interface {
+	Paused(ctx context.Context, user *models.User)
+	Resumed(ctx context.Context, user *models.User, automatic bool)
+}
+
+// Service pauses and resumes profiles
+type Service struct {
+	db       *gorm.DB
+	notifier Notifier
+	interval time.Duration
+}
+
+// NewService creates a pause service
+func NewService(db *gorm.DB, notifier Notifier) *Service {
+	return &Service{db: db, notifier: notifier, interval: time.Minute}
+}
+
+// Pause hides the profile until it is resumed. until is optional; with
+// resumeOnLogin the next successful login ends the pause. Pausing an already
+// paused profile updates its options.
+func (s *Service) Pause(ctx context.Context, userID uint, until *time.Time, resumeOnLogin bool) (*models.User, error) {
+	now := time.Now()
+	if until != nil && (!until.After(now) || until.After(now.Add(MaxPause))) {
+		return nil, ErrInvalidUntil
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, ErrDeletionPending
+	}
+
+	wasPaused := user.IsPaused()
+	if !wasPaused {
+		user.PausedAt = &now
+	}
+	user.PauseUntil = until
+	user.ResumeOnLogin = resumeOnLogin
+
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"paused_at":       user.PausedAt,
+		"pause_until":     user.PauseUntil,
+		"resume_on_login": user.ResumeOnLogin,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if !wasPaused {
+		slog.InfoContext(ctx, "profile paused", "user_id", userID)
+		s.notifier.Paused(ctx, &user)
+	}
+	return &user, nil
+}
+
+// Resume makes a paused profile visible again
+func (s *Service) Resume(ctx context.Context, userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if !user.IsPaused() {
+		return nil, ErrNotPaused
+	}
+	if !s.resume(ctx, &user, "") {
+		return nil, ErrNotPaused
+	}
+
+	slog.InfoContext(ctx, "profile resumed", "user_id", userID)
+	s.notifier.Resumed(ctx, &user, false)
+	return &user, nil
+}
+
+// ResumeOnLogin ends the pause of a user who just logged in, when they asked
+// for it to end on their next login. It reports whether the profile was resumed.
+func (s *Service) ResumeOnLogin(ctx context.Context, userID uint) (bool, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return false, err
+	}
+	if !user.IsPaused() || !user.ResumeOnLogin {
+		return false, nil
+	}
+	if !s.resume(ctx, &user, "resume_on_login = ?", true) {
+		return false, nil
+	}
+
+	slog.InfoContext(ctx, "profile resumed on login", "user_id", userID)
+	s.notifier.Resumed(ctx, &user, false)
+	return true, nil
+}
+
+// Run resumes profiles whose pause is over until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "profile pause worker started")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.ResumeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "profile pause worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ResumeDue resumes every profile whose end date has passed and returns how many were resumed
+func (s *Service) ResumeDue(ctx context.Context) int {
+	var due []models.User
+	if err := s.db.
+		Where("paused_at IS NOT NULL AND pause_until IS NOT NULL AND pause_until <= ?", time.Now()).
+		Limit(100).
+		Find(&due).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to load profiles to resume", "error", err)
+		return 0
+	}
+
+	resumed := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		// The user may have resumed or changed the pause in the meantime
+		if !s.resume(ctx, &due[i], "pause_until <= ?", time.Now()) {
+			continue
+		}
+		resumed++
+		slog.InfoContext(ctx, "pause over, profile resumed", "user_id", due[i].ID)
+		s.notifier.Resumed(ctx, &due[i], true)
+	}
+	return resumed
+}
+
+// resume clears the pause, if the profile still matches the optional condition
+func (s *Service) resume(ctx context.Context, user *models.User, condition string, args ...interface{}) bool {
+	query := s.db.Model(&models.User{}).Where("id = ? AND paused_at IS NOT NULL", user.ID)
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+	result := query.Updates(map[string]interface{}{
+		"paused_at":       nil,
+		"pause_until":     nil,
+		"resume_on_login": false,
+	})
+	if result.Error != nil {
+		slog.ErrorContext(ctx, "failed to resume profile", "user_id", user.ID, "error", result.Error)
+		return false
+	}
+	if result.RowsAffected != 1 {
+		return false
+	}
+	user.PausedAt = nil
+	user.PauseUntil = nil
+	user.ResumeOnLogin = false
+	return true
+}
@@ -0,0 +1,146 @@
+package profilepause
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"user-service/src/models"
+)
+
+type fakeNotifier struct {
+	paused  []uint
+	resumed map[uint]bool
+}
+
+func (f *fakeNotifier) Paused(ctx context.Context, user *models.User) {
+	f.paused = append(f.paused, user.ID)
+}
+
+func (f *fakeNotifier) Resumed(ctx context.Context, user *models.User, automatic bool) {
+	f.resumed[user.ID] = automatic
+}
+
+func setupPauseDB(t *testing.T) (*gorm.DB, models.User) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}))
+
+	user := models.User{
+		Username:         "alice",
+		FirstName:        "Alice",
+		LastName:         "Doe",
+		Email:            "alice@example.com",
+		PasswordHash:     "hash",
+		BirthDate:        time.Now().AddDate(-25, 0, 0),
+		Gender:           "woman",
+		SexPref:          "both",
+		RelationshipType: "long_term",
+	}
+	require.NoError(t, db.Create(&user).Error)
+	return db, user
+}
+
+func TestPauseAndResume(t *testing.T) {
+	db, user := setupPauseDB(t)
+	notifier := &fakeNotifier{resumed: map[uint]bool{}}
+	service := NewService(db, notifier)
+
+	paused, err := service.Pause(context.Background(), user.ID, nil, true)
+	require.NoError(t, err)
+	assert.True(t, paused.IsPaused())
+	assert.True(t, paused.ResumeOnLogin)
+
+	// Pausing again only updates the options
+	until := time.Now().Add(48 * time.Hour)
+	_, err = service.Pause(context.Background(), user.ID, &until, false)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{user.ID}, notifier.paused)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.NotNil(t, stored.PausedAt)
+	assert.NotNil(t, stored.PauseUntil)
+	assert.False(t, stored.ResumeOnLogin)
+
+	resumed, err := service.Resume(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.False(t, resumed.IsPaused())
+	assert.Equal(t, map[uint]bool{user.ID: false}, notifier.resumed)
+
+	_, err = service.Resume(context.Background(), user.ID)
+	assert.ErrorIs(t, err, ErrNotPaused)
+}
+
+func TestResumeOnLogin(t *testing.T) {
+	db, user := setupPauseDB(t)
+	notifier := &fakeNotifier{resumed: map[uint]bool{}}
+	service := NewService(db, notifier)
+
+	_, err := service.Pause(context.Background(), user.ID, nil, false)
+	require.NoError(t, err)
+	resumed, err := service.ResumeOnLogin(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.False(t, resumed, "the pause only ends on login when requested")
+	assert.Empty(t, notifier.resumed)
+
+	_, err = service.Pause(context.Background(), user.ID, nil, true)
+	require.NoError(t, err)
+	resumed, err = service.ResumeOnLogin(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, map[uint]bool{user.ID: false}, notifier.resumed)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.False(t, stored.IsPaused())
+	assert.False(t, stored.ResumeOnLogin)
+
+	resumed, err = service.ResumeOnLogin(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.False(t, resumed, "only the first login resumes the profile")
+}
+
+func TestPause_Validation(t *testing.T) {
+	db, user := setupPauseDB(t)
+	service := NewService(db, &fakeNotifier{resumed: map[uint]bool{}})
+
+	past := time.Now().Add(-time.Hour)
+	_, err := service.Pause(context.Background(), user.ID, &past, false)
+	assert.ErrorIs(t, err, ErrInvalidUntil)
+
+	tooFar := time.Now().Add(MaxPause + 24*time.Hour)
+	_, err = service.Pause(context.Background(), user.ID, &tooFar, false)
+	assert.ErrorIs(t, err, ErrInvalidUntil)
+
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("deleted_at", time.Now()).Error)
+	_, err = service.Pause(context.Background(), user.ID, nil, false)
+	assert.ErrorIs(t, err, ErrDeletionPending)
+}
+
+func TestResumeDue(t *testing.T) {
+	db, user := setupPauseDB(t)
+	notifier := &fakeNotifier{resumed: map[uint]bool{}}
+	service := NewService(db, notifier)
+
+	until := time.Now().Add(time.Hour)
+	_, err := service.Pause(context.Background(), user.ID, &until, false)
+	require.NoError(t, err)
+
+	assert.Zero(t, service.ResumeDue(context.Background()), "the pause is not over yet")
+
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).
+		Update("pause_until", time.Now().Add(-time.Minute)).Error)
+	assert.Equal(t, 1, service.ResumeDue(context.Background()))
+	assert.Equal(t, map[uint]bool{user.ID: true}, notifier.resumed)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Nil(t, stored.PausedAt)
+	assert.Nil(t, stored.PauseUntil)
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreferredLanguage returns the first tag of the Accept-Language header.
+// auth-service resolves it to one of its email template locales.
+func PreferredLanguage(c *gin.Context) string {
+	locale := c.GetHeader("Accept-Language")
+	if i := strings.IndexAny(locale, ",;"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.TrimSpace(locale)
+	if len(locale) > 8 {
+		locale = locale[:8]
+	}
+	return locale
+}
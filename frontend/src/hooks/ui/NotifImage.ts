@@ -31,35 +31,24 @@ export function Notification() {
             const notifData = data;
             // console.log("Processing notification_received:", notifData);
             
-            // Convertir le type de notification numérique en string
-            let notifType = 'message';
-            switch(notifData.type || notifData.notif_type) {
-                case '0':
-                    notifType = 'visit';
-                    break;
-                case '1':
-                    notifType = 'like';
-                    break;
-                case '2':
-                    notifType = 'match';
-                    break;
-                case '3':
-                    notifType = 'message';
-                    break;
-                case '4':
-                    notifType = 'unlike';
-                    break;
-                default:
-                    notifType = 'message';
-            }
-            
+            // Kind des notifications de user-service, code numérique pour les anciennes
+            const kinds: Record<string, 'like' | 'match' | 'message' | 'visit' | 'unlike'> = {
+                like: 'like', '1': 'like',
+                profile_view: 'visit', '2': 'visit',
+                message: 'message', '3': 'message',
+                match: 'match', '4': 'match',
+                unlike: 'unlike', '5': 'unlike',
+                unmatch: 'unlike', '7': 'unlike',
+            };
+            const notifType = kinds[String(notifData.kind ?? notifData.notif_type)] ?? 'message';
+
             addNotification({
                 type: notifType as 'like' | 'match' | 'message' | 'visit' | 'unlike',
                 message: typeof notifData.message === 'string' ? notifData.message : 'New notification',
                 userId: typeof notifData.to_user_id === 'number' ? notifData.to_user_id 
                        : typeof notifData.from_user_id === 'number' ? notifData.from_user_id 
                        : undefined,
-                db: typeof notifData.db === 'boolean' ? notifData.db : false,
+                db: typeof notifData.id === 'number',
             });
             setSeen(false); // Marquer comme non vu
         } else if (data.type === 'notification_event') {
@@ -104,7 +93,7 @@ export function Notification() {
         clearAll();
         try {
             // Utiliser le service API centralisé
-            const data = await apiService.get(`/api/v1/notifications/delete?user_id=${user.id}`);
+            const data = await apiService.put('/api/v1/notifications/read-all');
             // console.log("Réponse backend:", data);
         } catch (error) {
             console.error("Failed to clear notifications:", error);
@@ -108,7 +108,7 @@ export class WebSocketConnection {
 
           // Gestion automatique de la reconnexion
           if (this.shouldReconnect(event)) {
-            this.handleReconnect(onOpen, onMessage, onClose, onError);
+            this.handleReconnect(onOpen, onMessage, onClose, onError, this.restartDelay(event));
           }
         };
         this.ws!.onerror = (error) => {
@@ -140,15 +140,20 @@ export class WebSocketConnection {
     onOpen?: () => void,
     onMessage?: (event: MessageEvent) => void,
     onClose?: (event: CloseEvent) => void,
-    onError?: (error: Event) => void
+    onError?: (error: Event) => void,
+    restartDelay: number | null = null
   ): Promise<void> {
-    if (this.reconnectAttempts >= this.config.maxReconnectAttempts) {
+    if (restartDelay === null && this.reconnectAttempts >= this.config.maxReconnectAttempts) {
       console.error('WebSocket: Max reconnection attempts reached');
       return;
     }
 
-    this.reconnectAttempts++;
-    const delay = this.config.reconnectDelay * Math.pow(2, this.reconnectAttempts - 1);
+    // Un redémarrage du serveur ne compte pas comme un échec
+    let delay = restartDelay ?? 0;
+    if (restartDelay === null) {
+      this.reconnectAttempts++;
+      delay = this.config.reconnectDelay * Math.pow(2, this.reconnectAttempts - 1);
+    }
     
     // console.log(`WebSocket: Reconnecting in ${delay}ms (attempt ${this.reconnectAttempts})`);
     
@@ -188,6 +193,22 @@ export class WebSocketConnection {
     return this.isAuthenticated && event.code !== 1000;
   }
 
+  // Délai demandé par le serveur quand il redémarre (code 1012), null sinon
+  private restartDelay(event: CloseEvent): number | null {
+    if (event.code !== 1012) {
+      return null;
+    }
+    try {
+      const hint = JSON.parse(event.reason) as { reconnect_after_ms?: number };
+      if (typeof hint.reconnect_after_ms === 'number' && hint.reconnect_after_ms >= 0) {
+        return hint.reconnect_after_ms;
+      }
+    } catch {
+      // Raison absente ou illisible : délai par défaut
+    }
+    return this.config.reconnectDelay;
+  }
+
   private startPingInterval(): void {
     this.stopPingInterval();
 
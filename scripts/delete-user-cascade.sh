@@ -1,3 +0,0 @@
-#!/bin/bash
-
-docker exec -it matcha-postgres-1 psql -U postgres -d matcha_dev -c "truncate table users restart identity cascade";
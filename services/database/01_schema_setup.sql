@@ -8,6 +8,12 @@ SET search_path TO public;
 -- ====================
 -- RESET DES TABLES
 -- ====================
+DROP TABLE IF EXISTS conversation_settings CASCADE;
+DROP TABLE IF EXISTS chat_settings CASCADE;
+DROP TABLE IF EXISTS message_attachments CASCADE;
+DROP TABLE IF EXISTS chat_attachments CASCADE;
+DROP TABLE IF EXISTS message_deletions CASCADE;
+DROP TABLE IF EXISTS message_edits CASCADE;
 DROP TABLE IF EXISTS messages CASCADE;
 DROP TABLE IF EXISTS discussion CASCADE;
 DROP TABLE IF EXISTS relations CASCADE;
@@ -16,5 +22,13 @@ DROP TABLE IF EXISTS user_tags CASCADE;
 DROP TABLE IF EXISTS tags CASCADE;
 DROP TABLE IF EXISTS email_verifications CASCADE;
 DROP TABLE IF EXISTS password_resets CASCADE;
+DROP TABLE IF EXISTS email_changes CASCADE;
+DROP TABLE IF EXISTS email_outbox CASCADE;
+DROP TABLE IF EXISTS data_exports CASCADE;
+DROP TABLE IF EXISTS account_deletion_steps CASCADE;
+DROP TABLE IF EXISTS account_deletions CASCADE;
+DROP TABLE IF EXISTS notification_quiet_hours CASCADE;
+DROP TABLE IF EXISTS push_subscriptions CASCADE;
+DROP TABLE IF EXISTS notification_preferences CASCADE;
 DROP TABLE IF EXISTS notifications CASCADE;
 DROP TABLE IF EXISTS users CASCADE;
\ No newline at end of file
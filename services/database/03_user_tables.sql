@@ -54,7 +54,13 @@ CREATE TABLE users (
     latitude NUMERIC(9,6),
     longitude NUMERIC(9,6),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,                 -- set during the account deletion grace period
+
+    -- Pause mode: hidden from matching, search and non-matches until resumed
+    paused_at TIMESTAMP,
+    pause_until TIMESTAMP,
+    resume_on_login BOOLEAN DEFAULT FALSE
 );
 
 -- ====================
@@ -87,6 +93,49 @@ CREATE INDEX idx_password_resets_token ON password_resets(token);
 CREATE INDEX idx_password_resets_user_id ON password_resets(user_id);
 CREATE INDEX idx_password_resets_expires_at ON password_resets(expires_at);
 
+-- ====================
+-- TABLE : email_changes
+-- ====================
+CREATE TABLE email_changes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    confirm_token VARCHAR(64) NOT NULL UNIQUE,
+    confirm_expires_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    revert_token VARCHAR(64) UNIQUE,      -- sent to old_email once confirmed
+    revert_expires_at TIMESTAMP,
+    reverted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_changes_user_id ON email_changes(user_id);
+
+-- ====================
+-- TABLE : email_outbox
+-- ====================
+CREATE TABLE email_outbox (
+    id SERIAL PRIMARY KEY,
+    to_email VARCHAR(255) NOT NULL,
+    template VARCHAR(64) NOT NULL,
+    locale VARCHAR(8) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    html_body TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sending', 'sent', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_outbox_due ON email_outbox(status, next_attempt_at);
+
 -- ====================
 -- TABLE : tags
 -- ====================
@@ -138,10 +187,122 @@ CREATE TABLE profile_views (
 -- ====================
 -- TABLE : notifications
 -- ====================
+-- Version 1 rows only carry notif_type (numeric code) and msg; version 2
+-- rows also carry the kind, the sender and JSON data
 CREATE TABLE notifications (
     id SERIAL PRIMARY KEY,
     to_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     notif_type VARCHAR(10) NOT NULL,
+    kind VARCHAR(30),
+    version SMALLINT NOT NULL DEFAULT 1,
+    from_user_id INT REFERENCES users(id) ON DELETE SET NULL,
     msg TEXT NOT NULL,
-    time TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+    data TEXT,
+    time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+CREATE INDEX idx_notifications_user ON notifications(to_user_id, id);
+CREATE INDEX idx_notifications_unread ON notifications(to_user_id) WHERE read_at IS NULL;
+
+-- ====================
+-- TABLE : notification_preferences
+-- ====================
+-- Delivery channels per user and kind; kinds without a row use their defaults
+CREATE TABLE notification_preferences (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT FALSE,
+    push BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, kind)
+);
+
+-- ====================
+-- TABLE : push_subscriptions
+-- ====================
+-- Web Push subscriptions of each browser; removed when the push service
+-- answers 404 or 410
+CREATE TABLE push_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh VARCHAR(100) NOT NULL,
+    auth VARCHAR(50) NOT NULL,
+    user_agent VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+CREATE INDEX idx_push_subscriptions_user_id ON push_subscriptions(user_id);
+
+-- ====================
+-- TABLE : notification_quiet_hours
+-- ====================
+-- Daily window (HH:MM, local time) without push notifications
+CREATE TABLE notification_quiet_hours (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    start_time VARCHAR(5) NOT NULL,
+    end_time VARCHAR(5) NOT NULL,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- ====================
+-- TABLE : data_exports
+-- ====================
+CREATE TABLE data_exports (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'ready', 'failed', 'expired')),
+    locale VARCHAR(8),
+    file_path VARCHAR(500),               -- archive on disk, cleared once expired
+    file_size BIGINT DEFAULT 0,
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP                  -- end of the download window
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id);
+CREATE INDEX idx_data_exports_status ON data_exports(status);
+
+-- ====================
+-- TABLE : account_deletions
+-- ====================
+-- No foreign key on user_id: the saga outlives the user row it deletes
+CREATE TABLE account_deletions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'running', 'completed', 'cancelled')),
+    purge_after TIMESTAMP NOT NULL,       -- end of the grace period
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_account_deletions_user_id ON account_deletions(user_id);
+CREATE INDEX idx_account_deletions_due ON account_deletions(status, next_attempt_at);
+
+-- ====================
+-- TABLE : account_deletion_steps
+-- ====================
+CREATE TABLE account_deletion_steps (
+    id SERIAL PRIMARY KEY,
+    deletion_id INT NOT NULL REFERENCES account_deletions(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,            -- chat, match, stripe, media_files, media, exports, profile
+    position INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'done')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (deletion_id, name)
+);
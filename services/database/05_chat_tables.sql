@@ -11,6 +11,12 @@ CREATE TABLE discussion (
     user2_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     last_message_content TEXT,
     last_message_at TIMESTAMP,
+    -- Follows the match: active, read_only (unmatched) or archived (blocked)
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    closed_reason VARCHAR(20),
+    closed_at TIMESTAMP,
+    -- Set once the status follows the match, NULL for older conversations
+    match_synced_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW()
 );
@@ -24,9 +30,111 @@ CREATE TABLE messages (
     sender_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     msg TEXT NOT NULL,
     time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    read_at TIMESTAMP
+    -- Set when the recipient acknowledges the message, and when they read it
+    delivered_at TIMESTAMP,
+    read_at TIMESTAMP,
+    edited_at TIMESTAMP,
+    -- Set when the sender unsends the message; msg is then emptied
+    deleted_at TIMESTAMP,
+    -- Screening rule the content broke; the recipient sees it blurred
+    warning VARCHAR(30),
+    -- Full-text search, in French and English
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('french', msg) || to_tsvector('english', msg)) STORED
 );
 
+CREATE INDEX idx_messages_search ON messages USING GIN (search_vector);
+
+-- ====================
+-- TABLE : message_edits
+-- ====================
+CREATE TABLE message_edits (
+    id SERIAL PRIMARY KEY,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    previous_msg TEXT NOT NULL,
+    edited_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_edits_message ON message_edits(message_id, edited_at);
+
+-- ====================
+-- TABLE : message_deletions
+-- ====================
+-- Messages a participant deleted for themselves only
+CREATE TABLE message_deletions (
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, user_id)
+);
+
+-- ====================
+-- TABLE : chat_settings
+-- ====================
+-- Chat preferences; users without a row use the defaults
+CREATE TABLE chat_settings (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    read_receipts BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ====================
+-- TABLE : conversation_settings
+-- ====================
+-- Per-participant mute, pin and archive of a conversation
+CREATE TABLE conversation_settings (
+    conversation_id INT NOT NULL REFERENCES discussion(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_until TIMESTAMP,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+CREATE INDEX idx_conversation_settings_user ON conversation_settings(user_id);
+
+-- ====================
+-- TABLE : chat_attachments (media-service)
+-- ====================
+-- Photos and voice notes uploaded for chat, stored outside the public
+-- uploads; conversation_id is set once sent in a message
+CREATE TABLE chat_attachments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id INT REFERENCES discussion(id) ON DELETE SET NULL,
+    kind VARCHAR(10) NOT NULL,
+    filename VARCHAR(255) NOT NULL UNIQUE,
+    thumbnail_name VARCHAR(255),
+    mime_type VARCHAR(100) NOT NULL,
+    file_size BIGINT NOT NULL,
+    width INT,
+    height INT,
+    duration_ms INT,
+    waveform TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_chat_attachments_user ON chat_attachments(user_id);
+
+-- ====================
+-- TABLE : message_attachments
+-- ====================
+-- Attachments of a message, with their metadata copied at send time
+CREATE TABLE message_attachments (
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    attachment_id INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    kind VARCHAR(10) NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    file_size BIGINT NOT NULL,
+    width INT,
+    height INT,
+    duration_ms INT,
+    waveform TEXT,
+    PRIMARY KEY (message_id, attachment_id)
+);
+
+CREATE UNIQUE INDEX idx_message_attachments_attachment ON message_attachments(attachment_id);
+
 -- ====================
 -- TABLE : message_reactions
 -- ====================